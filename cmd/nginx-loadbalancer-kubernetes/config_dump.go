@@ -0,0 +1,86 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// configDumpCommand is the subcommand that prints the effective configuration and exits, e.g.:
+//
+//	nginx-loadbalancer-kubernetes config dump --config-file /etc/nlk/config.yaml
+var configDumpCommand = []string{"config", "dump"}
+
+// errInvalidConfiguration is returned by the config dump subcommand when the configuration would keep the controller
+// from starting, so it exits with a non-zero status.
+var errInvalidConfiguration = errors.New(`the configuration is invalid`)
+
+// isConfigDump determines whether the arguments, without the program name, run the config dump subcommand.
+func isConfigDump(args []string) bool {
+	return len(args) >= len(configDumpCommand) && slices.Equal(args[:len(configDumpCommand)], configDumpCommand)
+}
+
+// runConfigDump loads the settings as the controller would, from the flags, the environment, the configuration file,
+// and the ConfigMap when it can be reached, then prints them, without their secrets, as YAML. The arguments follow the
+// subcommand, and accept the flags of the controller.
+func runConfigDump(ctx context.Context, args []string, output io.Writer) error {
+	options, err := parseCommandLine(flag.NewFlagSet(strings.Join(configDumpCommand, " "), flag.ContinueOnError), args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := buildKubernetesClient(options.clientOptions, options.overrides)
+	if err != nil {
+		return fmt.Errorf(`error building a Kubernetes client: %w`, err)
+	}
+
+	settings, err := configuration.NewSettingsWithOverrides(ctx, k8sClient, options.overrides)
+	if err != nil {
+		return fmt.Errorf(`%w: %v`, errInvalidConfiguration, err)
+	}
+
+	settings.ConfigFilePath = options.configFile
+
+	return dumpSettings(settings, output)
+}
+
+// dumpSettings prints the effective settings as YAML, and returns errInvalidConfiguration along with the problems found.
+// A ConfigMap that cannot be retrieved is only warned about, the settings known without it are printed.
+func dumpSettings(settings *configuration.Settings, output io.Writer) error {
+	effective := settings.Dump()
+	if effective.ConfigMapError != "" {
+		logrus.Warnf("ConfigDump: the settings of the ConfigMap are missing: %s", effective.ConfigMapError)
+	}
+
+	document, err := yaml.Marshal(effective)
+	if err != nil {
+		return fmt.Errorf(`error occurred encoding the settings: %w`, err)
+	}
+
+	if _, err = output.Write(document); err != nil {
+		return fmt.Errorf(`error occurred writing the settings: %w`, err)
+	}
+
+	if !effective.Valid() {
+		return fmt.Errorf(`%w: %s`, errInvalidConfiguration, strings.Join(effective.Errors, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+)
+
+// The build information, injected with ldflags, e.g.:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)"
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// commandLine holds the values of the command line flags.
+type commandLine struct {
+
+	// configFile is the path to a mounted YAML configuration document, see configuration.ConfigFile.
+	configFile string
+
+	// debugEndpoint enables the /debug endpoint of the probe server.
+	debugEndpoint bool
+
+	// version prints the build information and exits.
+	version bool
+
+	// mockNginx points NLK at a fake NGINX Plus API served in process, for local demos, see simulation.NginxPlus.
+	mockNginx bool
+
+	// clientOptions selects how the Kubernetes client is configured.
+	clientOptions kubernetesClientOptions
+
+	// webhookOptions configure the admission webhook, see admission.Webhook.
+	webhookOptions webhookOptions
+
+	// overrides are the flags that mirror a setting, only the flags that are specified are set.
+	overrides configuration.Overrides
+}
+
+// webhookOptions configure the admission webhook, from the command line flags.
+type webhookOptions struct {
+
+	// address is the host and port the webhook listens on, the webhook is disabled while it is empty.
+	address string
+
+	// certificateFile is the path of the PEM-encoded serving certificate, followed by any intermediates.
+	certificateFile string
+
+	// keyFile is the path of the PEM-encoded key of the serving certificate.
+	keyFile string
+}
+
+const (
+	// defaultWebhookCertificateFile is where the serving certificate of the webhook is mounted, e.g. from a cert-manager Secret.
+	defaultWebhookCertificateFile = "/etc/nkl/webhook/tls.crt"
+
+	// defaultWebhookKeyFile is where the key of the serving certificate of the webhook is mounted.
+	defaultWebhookKeyFile = "/etc/nkl/webhook/tls.key"
+)
+
+// parseCommandLine parses the arguments, without the program name. The flags that mirror a setting take precedence over
+// its environment variable, and are only set in the overrides when specified. --help lists the flags and the environment
+// variables, and returns flag.ErrHelp.
+func parseCommandLine(flagSet *flag.FlagSet, args []string) (*commandLine, error) {
+	options := &commandLine{}
+
+	flagSet.StringVar(&options.configFile, "config-file", "", "path to a mounted YAML configuration document, see configuration.ConfigFile")
+	flagSet.BoolVar(&options.debugEndpoint, "debug-endpoint", false, "serve the desired and applied state of the upstreams as JSON on the /debug endpoint of the probe server")
+	flagSet.BoolVar(&options.version, "version", false, "print the version, commit, and build date, and exit")
+	flagSet.BoolVar(&options.mockNginx, "mock-nginx", false, "apply the changes to a fake NGINX Plus API served in process instead of the nginx-hosts, for local demos")
+
+	flagSet.StringVar(&options.clientOptions.kubeconfig, "kubeconfig", "", "path to a kubeconfig file, for running outside the cluster; defaults to the KUBECONFIG environment variable")
+	flagSet.StringVar(&options.clientOptions.context, "context", "", "kubeconfig context to use, defaults to the current context")
+	flagSet.StringVar(&options.clientOptions.masterUrl, "master-url", "", "address of the Kubernetes API server, overrides the kubeconfig")
+
+	flagSet.StringVar(&options.webhookOptions.address, "webhook-address", "", "host and port on which the admission webhook validating the ConfigMap and the ports annotations is served, e.g. :8443; disabled when empty")
+	flagSet.StringVar(&options.webhookOptions.certificateFile, "webhook-certificate-file", defaultWebhookCertificateFile, "path to the serving certificate of the admission webhook, reloaded when it changes")
+	flagSet.StringVar(&options.webhookOptions.keyFile, "webhook-key-file", defaultWebhookKeyFile, "path to the key of the serving certificate of the admission webhook")
+
+	tlsMode := flagSet.String("tls-mode", "", "TLS mode used when the ConfigMap does not set tls-mode, one of: "+configuration.TLSModeNames()+"; overrides "+configuration.TlsModeEnv)
+	watchNamespace := flagSet.String("watch-namespace", "", "comma-separated namespaces of the Services to watch; overrides "+configuration.NginxIngressNamespacesEnv)
+	logLevel := flagSet.String("log-level", "", "log level at startup, e.g. debug; overrides "+configuration.LogLevelEnv)
+	dryRun := flagSet.Bool("dry-run", false, "log the changes to the NGINX Plus upstreams without applying them; overrides "+configuration.DryRunEnv+", the dry-run ConfigMap key overrides it")
+
+	kubeApiQps := flagSet.Float64("kube-api-qps", configuration.DefaultKubeApiQPS, "Kubernetes API calls per second allowed by the client-side rate limit; overrides "+configuration.KubeApiQpsEnv)
+	kubeApiBurst := flagSet.Int("kube-api-burst", configuration.DefaultKubeApiBurst, "Kubernetes API calls allowed at once above the rate; overrides "+configuration.KubeApiBurstEnv)
+
+	forcePrune := flagSet.Bool("force-prune", false, "delete the upstream servers NLK does not own despite the ownership tag; overrides "+configuration.ForcePruneEnv)
+
+	flagSet.Usage = func() { printUsage(flagSet) }
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "tls-mode":
+			options.overrides.TlsMode = tlsMode
+		case "watch-namespace":
+			options.overrides.WatchNamespaces = watchNamespace
+		case "log-level":
+			options.overrides.LogLevel = logLevel
+		case "dry-run":
+			options.overrides.DryRun = dryRun
+		case "force-prune":
+			options.overrides.ForcePrune = forcePrune
+		case "kube-api-qps":
+			options.overrides.KubeApiQPS = kubeApiQps
+		case "kube-api-burst":
+			options.overrides.KubeApiBurst = kubeApiBurst
+		}
+	})
+
+	return options, nil
+}
+
+// printUsage lists the flags, then the environment variables; a flag takes precedence over the environment variable
+// of the same setting, which takes precedence over the default.
+func printUsage(flagSet *flag.FlagSet) {
+	output := flagSet.Output()
+
+	_, _ = fmt.Fprintf(output, "Usage of %s:\n", flagSet.Name())
+	flagSet.PrintDefaults()
+
+	_, _ = fmt.Fprintln(output, "\nSubcommands, which accept the flags above:")
+	_, _ = fmt.Fprintf(output, "  %s\n    \tprint the effective configuration as YAML, without its secrets, and exit non-zero when it is invalid\n", strings.Join(configDumpCommand, " "))
+
+	_, _ = fmt.Fprintln(output, "\nEnvironment variables, overridden by the flags above:")
+	for _, variable := range configuration.EnvironmentVariables {
+		_, _ = fmt.Fprintf(output, "  %s\n    \t%s\n", variable.Name, variable.Usage)
+	}
+}
+
+// printVersion prints the build information.
+func printVersion(output io.Writer) {
+	_, _ = fmt.Fprintf(output, "nginx-loadbalancer-kubernetes %s (commit %s, built %s)\n", version, commit, date)
+}
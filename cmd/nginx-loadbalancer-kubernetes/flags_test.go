@@ -0,0 +1,70 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+)
+
+func TestParseCommandLine_OnlyOverridesTheSpecifiedFlags(t *testing.T) {
+	options, err := parseCommandLine(flag.NewFlagSet("nlk", flag.ContinueOnError), []string{"--log-level", "debug", "--dry-run=false"})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if options.overrides.LogLevel == nil || *options.overrides.LogLevel != "debug" {
+		t.Errorf(`expected the log level to be overridden, got %v`, options.overrides.LogLevel)
+	}
+
+	// a flag set to its zero value still overrides the environment
+	if options.overrides.DryRun == nil || *options.overrides.DryRun {
+		t.Errorf(`expected dry-run to be overridden with false, got %v`, options.overrides.DryRun)
+	}
+
+	if options.mockNginx || options.overrides.NginxHosts != nil {
+		t.Errorf(`expected the NGINX Plus hosts not to be mocked`)
+	}
+
+	if options.webhookOptions.address != "" {
+		t.Errorf(`expected the admission webhook to be disabled by default, got %q`, options.webhookOptions.address)
+	}
+
+	if options.overrides.TlsMode != nil || options.overrides.WatchNamespaces != nil || options.overrides.ForcePrune != nil {
+		t.Errorf(`expected the unspecified flags to be left to the environment, got %+v`, options.overrides)
+	}
+}
+
+func TestParseCommandLine_HelpListsTheFlagsAndTheEnvironment(t *testing.T) {
+	var output bytes.Buffer
+
+	flagSet := flag.NewFlagSet("nlk", flag.ContinueOnError)
+	flagSet.SetOutput(&output)
+
+	if _, err := parseCommandLine(flagSet, []string{"--help"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf(`expected flag.ErrHelp, got %v`, err)
+	}
+
+	for _, expected := range []string{"-tls-mode", "-watch-namespace", "-log-level", "-dry-run", "-version", "-mock-nginx", configuration.LogLevelEnv, configuration.LeaseRetryPeriodEnv} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf(`expected the help to list %s`, expected)
+		}
+	}
+}
+
+func TestPrintVersion(t *testing.T) {
+	var output bytes.Buffer
+	printVersion(&output)
+
+	if !strings.Contains(output.String(), version) || !strings.Contains(output.String(), commit) {
+		t.Errorf(`expected the version and commit, got %q`, output.String())
+	}
+}
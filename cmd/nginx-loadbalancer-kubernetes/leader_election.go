@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// runWithLeaderElection competes for the Lease, as the identity, and runs the controller while this replica holds it.
+// When leadership is lost the controller's context is cancelled, so the work queues are shut down,
+// and the replica returns to standby to compete for the Lease again; the process only exits when the context is done.
+func runWithLeaderElection(ctx context.Context, k8sClient kubernetes.Interface, identity string, settings configuration.LeaderElectionSettings, controller func(context.Context) error) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      settings.LeaseName,
+			Namespace: settings.LeaseNamespace,
+		},
+		Client: k8sClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	// term is held while the controller runs, so a new term does not start before the previous one has shut down.
+	var term sync.Mutex
+
+	config := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   settings.LeaseDuration,
+		RenewDeadline:   settings.RenewDeadline,
+		RetryPeriod:     settings.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            settings.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				term.Lock()
+				defer term.Unlock()
+
+				logrus.Infof("LeaderElection: %s acquired the %s/%s Lease", identity, settings.LeaseNamespace, settings.LeaseName)
+
+				if err := controller(leaderCtx); err != nil {
+					logrus.Errorf("LeaderElection: the controller stopped with an error: %v", err)
+				}
+			},
+			OnStoppedLeading: func() {
+				logrus.Warnf("LeaderElection: %s is no longer the leader", identity)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					logrus.Infof("LeaderElection: %s is the leader, standing by", leader)
+				}
+			},
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(config)
+	if err != nil {
+		return fmt.Errorf(`error occurred creating the leader elector: %w`, err)
+	}
+
+	// Run returns when leadership is lost or the context is done; keep competing until the process is stopped.
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+
+		term.Lock()
+		term.Unlock() //nolint:staticcheck // waits for the controller to shut down
+	}
+
+	return nil
+}
+
+// buildIdentity returns a unique identity for this replica, based on the hostname (the Pod name when running in-cluster).
+func buildIdentity() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s_%s", hostname, uuid.NewUUID()), nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunWithLeaderElection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	k8sClient := fake.NewSimpleClientset()
+	settings := configuration.LeaderElectionSettings{
+		Enabled:        true,
+		LeaseName:      "nlk-leader",
+		LeaseNamespace: "nlk",
+		LeaseDuration:  time.Second,
+		RenewDeadline:  time.Millisecond * 500,
+		RetryPeriod:    time.Millisecond * 100,
+	}
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	controller := func(leaderCtx context.Context) error {
+		close(started)
+		<-leaderCtx.Done()
+		close(stopped)
+		return nil
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- runWithLeaderElection(ctx, k8sClient, "nlk-0_test", settings, controller)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf(`expected the controller to start once the Lease was acquired`)
+	}
+
+	lease, err := k8sClient.CoordinationV1().Leases("nlk").Get(ctx, "nlk-leader", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(`expected the Lease to exist: %v`, err)
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		t.Fatalf(`expected the Lease to have a holder`)
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf(`expected runWithLeaderElection to return when the context is done`)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf(`expected the controller to be stopped before returning`)
+	}
+}
@@ -7,17 +7,26 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/admission"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/observation"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/probation"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/simulation"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/synchronization"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -29,25 +38,136 @@ func main() {
 }
 
 func run() error {
-	ctx := context.Background()
-	var err error
+	if isConfigDump(os.Args[1:]) {
+		return runConfigDump(context.Background(), os.Args[1+len(configDumpCommand):], os.Stdout)
+	}
+
+	options, err := parseCommandLine(flag.CommandLine, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if options.version {
+		printVersion(os.Stdout)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logrus.Infof("nginx-loadbalancer-kubernetes %s (commit %s, built %s)", version, commit, date)
+
+	// The provider must be set before any work queues are created, and the metrics server started before the informers.
+	workqueue.SetProvider(instrumentation.NewWorkQueueMetricsProvider())
+
+	metricsServer := instrumentation.NewMetricsServer()
+	err = metricsServer.Start(ctx)
+	if err != nil {
+		return fmt.Errorf(`error occurred starting the metrics server: %w`, err)
+	}
+
+	// The time the Kubernetes API calls wait for the client-side rate limiter is observed from the first call.
+	instrumentation.RegisterKubeApiMetrics()
 
-	k8sClient, err := buildKubernetesClient()
+	k8sClient, err := buildKubernetesClient(options.clientOptions, options.overrides)
 	if err != nil {
 		return fmt.Errorf(`error building a Kubernetes client: %w`, err)
 	}
 
-	settings, err := configuration.NewSettings(ctx, k8sClient)
+	// The fake NGINX Plus API replaces the nginx-hosts, its upstreams are defined as they are used.
+	if options.mockNginx {
+		nginxPlus := simulation.NewNginxPlus()
+		nginxPlus.DefineOnDemand = true
+		options.overrides.NginxHosts = []string{nginxPlus.Start()}
+		defer nginxPlus.Close()
+	}
+
+	settings, err := configuration.NewSettingsWithOverrides(ctx, k8sClient, options.overrides)
+	if err != nil {
+		return fmt.Errorf(`error occurred creating settings: %w`, err)
+	}
+
+	// Tracing is opt-in, the spans are exported while the controller runs and flushed when it stops.
+	shutdownTracing, err := instrumentation.StartTracing(ctx, settings.Tracing.Endpoint, settings.Tracing.SampleRatio, version)
+	if err != nil {
+		return fmt.Errorf(`error occurred starting the tracing: %w`, err)
+	}
+
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logrus.Warnf(`error occurred flushing the traces: %v`, err)
+		}
+	}()
+
+	// The admin server is opt-in, it serves the settings of the controller while it runs.
+	adminServer := instrumentation.NewAdminServer(settings.Admin.Address)
+	if settings.Admin.Enabled {
+		err = adminServer.Start(ctx)
+		if err != nil {
+			return fmt.Errorf(`error occurred starting the admin server: %w`, err)
+		}
+	}
+
+	// The admission webhook is opt-in, it is served by every replica so the API server reaches it through any of them.
+	if options.webhookOptions.address != "" {
+		webhook := admission.NewWebhook(settings, options.webhookOptions.address, options.webhookOptions.certificateFile, options.webhookOptions.keyFile)
+		err = webhook.Start(ctx)
+		if err != nil {
+			return fmt.Errorf(`error occurred starting the admission webhook: %w`, err)
+		}
+	}
+
+	// The probes are served by every replica, standby replicas included.
+	probeServer := probation.NewHealthServer()
+	probeServer.DebugEnabled = options.debugEndpoint
+	probeServer.Start()
+	defer probeServer.Stop()
+
+	// The identity competes for the Lease, and is reported as the leader in the status ConfigMap.
+	identity, err := buildIdentity()
+	if err != nil {
+		return fmt.Errorf(`error occurred building the replica identity: %w`, err)
+	}
+
+	controller := func(ctx context.Context) error {
+		return runController(ctx, k8sClient, identity, options.configFile, options.overrides, probeServer, adminServer)
+	}
+
+	if !settings.LeaderElection.Enabled {
+		return controller(ctx)
+	}
+
+	return runWithLeaderElection(ctx, k8sClient, identity, settings.LeaderElection, controller)
+}
+
+// runController runs the Settings, Watcher, Handler, and Synchronizer until the context is done,
+// then shuts down the work queues. It is run once per leadership term when leader election is enabled.
+// While it runs, the readiness probe reflects the connectivity to the primary NGINX Plus hosts, and the debug endpoint serves the
+// state of the Synchronizer, and the admin server the current settings. The identity is reported as the leader in the status ConfigMap.
+func runController(ctx context.Context, k8sClient kubernetes.Interface, identity string, configFile string, overrides configuration.Overrides, probeServer *probation.HealthServer, adminServer *instrumentation.AdminServer) error {
+	var err error
+
+	settings, err := configuration.NewSettingsWithOverrides(ctx, k8sClient, overrides)
 	if err != nil {
 		return fmt.Errorf(`error occurred creating settings: %w`, err)
 	}
 
+	settings.ConfigFilePath = configFile
+
 	err = settings.Initialize()
 	if err != nil {
 		return fmt.Errorf(`error occurred initializing settings: %w`, err)
 	}
 
 	go settings.Run()
+	defer settings.Shutdown()
+
+	adminServer.SetSettingsSource(func() any { return settings.Redacted() })
+	defer adminServer.SetSettingsSource(nil)
 
 	synchronizerWorkqueue, err := buildWorkQueue(settings.Synchronizer.WorkQueueSettings)
 	if err != nil {
@@ -59,13 +179,30 @@ func run() error {
 		return fmt.Errorf(`error initializing synchronizer: %w`, err)
 	}
 
-	handlerWorkqueue, err := buildWorkQueue(settings.Synchronizer.WorkQueueSettings)
+	defer synchronizer.ShutDown()
+
+	// The readiness check shares the HTTP client of the Synchronizer, so its calls reuse the pooled connections to the hosts.
+	hostsCheck := probation.NewHostsCheck(
+		func() []string { return apiEndpoints(settings.PrimaryNginxPlusHosts()) },
+		synchronizer.HttpClient(),
+		settings.Readiness.RequiredHosts == configuration.ReadinessRequiredHostsAll,
+		settings.Readiness.CheckInterval,
+	)
+	probeServer.ReadyCheck.SetHostsCheck(hostsCheck)
+	defer probeServer.ReadyCheck.SetHostsCheck(nil)
+
+	handlerWorkqueue, err := buildWorkQueue(settings.Handler.WorkQueueSettings)
 	if err != nil {
 		return fmt.Errorf(`error occurred building a workqueue: %w`, err)
 	}
 
 	handler := observation.NewHandler(settings, synchronizer, handlerWorkqueue)
 
+	// The replica is degraded while the hosts are only retained, or while either queue is backed up.
+	hostsCheck.SetDegradation(func() string {
+		return firstDegradation(func() string { return retainedHostsDegradation(settings) }, handler.Degradation, synchronizer.Degradation)
+	})
+
 	watcher, err := observation.NewWatcher(settings, handler)
 	if err != nil {
 		return fmt.Errorf(`error occurred creating a watcher: %w`, err)
@@ -76,45 +213,133 @@ func run() error {
 		return fmt.Errorf(`error occurred initializing the watcher: %w`, err)
 	}
 
-	go handler.Run(ctx.Done())
-	go synchronizer.Run(ctx.Done())
+	hostsCheck.SetDiagnoses(synchronizer.HostDiagnoses)
+	synchronizer.SetDesiredStateSource(watcher.DesiredState)
+	synchronizer.SetResyncer(watcher.ResyncServices)
+	synchronizer.SetDrainConfirmer(watcher.ConfirmDrained)
+	synchronizer.SetLeaderIdentity(identity)
 
-	probeServer := probation.NewHealthServer()
-	probeServer.Start()
+	probeServer.Debug.SetSource(func() any {
+		snapshot := synchronizer.Snapshot()
+		snapshot.DeferredDeletes = handler.DeferredDeletes()
+		return snapshot
+	})
+	defer probeServer.Debug.SetSource(nil)
 
+	err = startWhenSynced(ctx, settings, watcher, handler.Run, synchronizer.Run)
+	if err != nil {
+		return fmt.Errorf(`error occurred starting the controller: %w`, err)
+	}
+
+	// Watch blocks until the context is done, and shuts down the Handler's queue on return.
 	err = watcher.Watch()
 	if err != nil {
 		return fmt.Errorf(`error occurred watching for events: %w`, err)
 	}
 
-	<-ctx.Done()
 	return nil
 }
 
-// buildKubernetesClient builds a Kubernetes clientset, supporting both in-cluster and out-of-cluster (kubeconfig) configurations.
-func buildKubernetesClient() (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
+// cacheSyncer waits for the cache of the ConfigMap to sync, see Settings::WaitForCacheSync.
+type cacheSyncer interface {
+	WaitForCacheSync() error
+}
+
+// informersStarter starts the informers of the Services and Nodes and waits for them to sync, see Watcher::Start.
+type informersStarter interface {
+	Start() error
+}
+
+// startWhenSynced runs the Handler and the Synchronizer once the caches of the ConfigMap, the Services, and the Nodes have
+// synced, so that no event is processed against partial caches; the events raised meanwhile wait in the Handler's queue.
+// Returns an error, without running them, when a cache does not sync within the WatcherSettings::CacheSyncTimeout.
+func startWhenSynced(ctx context.Context, settings cacheSyncer, watcher informersStarter, runners ...func(stopCh <-chan struct{})) error {
+	if err := settings.WaitForCacheSync(); err != nil {
+		return fmt.Errorf(`error occurred waiting for the ConfigMap cache to sync: %w`, err)
+	}
+
+	if err := watcher.Start(); err != nil {
+		return err
+	}
+
+	for _, run := range runners {
+		go run(ctx.Done())
+	}
+
+	return nil
+}
+
+// apiEndpoints returns the NGINX Plus API base URL of each host, without the options of its nginx-hosts entry.
+func apiEndpoints(hosts []configuration.NginxPlusHost) []string {
+	endpoints := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		endpoints = append(endpoints, host.Endpoint)
+	}
+
+	return endpoints
+}
+
+// retainedHostsDegradation reports the replica as degraded while the ConfigMap is deleted and the NGINX Plus hosts are
+// only retained, see Settings::HostsRetention.
+func retainedHostsDegradation(settings *configuration.Settings) string {
+	clearedAt, retained := settings.RetainedHostsDeadline()
+	if !retained {
+		return ""
+	}
 
-	// Try in-cluster config first
-	config, err = rest.InClusterConfig()
-	if err != nil {
-		if err == rest.ErrNotInCluster {
-			// Not running in a cluster, fall back to kubeconfig
-			kubeconfigPath := os.Getenv("KUBECONFIG")
-			if kubeconfigPath == "" {
-				kubeconfigPath = clientcmd.RecommendedHomeFile // ~/.kube/config
-			}
-
-			config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
-			if err != nil {
-				return nil, fmt.Errorf("could not get Kubernetes config: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("error occurred getting the in-cluster config: %w", err)
+	return fmt.Sprintf("the ConfigMap %s/%s was deleted, the NGINX Plus hosts are cleared at %s unless it is recreated",
+		settings.ConfigMapNamespace, settings.ConfigMapName, clearedAt.Format(time.RFC3339))
+}
+
+// firstDegradation returns the first reason the replica is degraded, or an empty string when none of the degradations reports one.
+func firstDegradation(degradations ...func() string) string {
+	for _, degradation := range degradations {
+		if reason := degradation(); reason != "" {
+			return reason
 		}
 	}
 
+	return ""
+}
+
+// kubernetesClientOptions selects how the Kubernetes client is configured, from the command line flags.
+type kubernetesClientOptions struct {
+
+	// kubeconfig is the path to a kubeconfig file; when it is empty, the files listed by the KUBECONFIG environment variable are merged.
+	kubeconfig string
+
+	// context is the kubeconfig context to use, defaults to the current context.
+	context string
+
+	// masterUrl overrides the address of the Kubernetes API server.
+	masterUrl string
+}
+
+// outOfCluster determines whether a kubeconfig, context, or API server address was specified, which selects the
+// kubeconfig mode over the in-cluster configuration.
+func (o kubernetesClientOptions) outOfCluster() bool {
+	return o.kubeconfig != "" || o.context != "" || o.masterUrl != ""
+}
+
+// buildKubernetesClient builds a Kubernetes clientset, supporting both in-cluster and out-of-cluster (kubeconfig) configurations.
+// Its client-side rate limit is set from the KubeApiSettings, as the client-go default delays the reconciliation in large clusters.
+func buildKubernetesClient(options kubernetesClientOptions, overrides configuration.Overrides) (*kubernetes.Clientset, error) {
+	kubeApi, err := configuration.NewKubeApiSettings(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	config, mode, err := buildRestConfig(options, rest.InClusterConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config.QPS = kubeApi.QPS
+	config.Burst = kubeApi.Burst
+
+	logrus.WithFields(logrus.Fields{"host": config.Host, "qps": config.QPS, "burst": config.Burst}).
+		Infof("buildKubernetesClient: connecting to the Kubernetes API using the %s configuration", mode)
+
 	// Create the clientset
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -124,6 +349,41 @@ func buildKubernetesClient() (*kubernetes.Clientset, error) {
 	return client, nil
 }
 
+// buildRestConfig returns the configuration of the Kubernetes client, and the mode it was built with, "in-cluster" or
+// "kubeconfig". The kubeconfig mode is used when a kubeconfig, context, API server address, or the KUBECONFIG environment
+// variable is specified, and when not running in a cluster. Without the kubeconfig flag, the default kubeconfig loading rules
+// apply, i.e. the files listed by KUBECONFIG, merged, or ~/.kube/config.
+func buildRestConfig(options kubernetesClientOptions, inClusterConfig func() (*rest.Config, error)) (*rest.Config, string, error) {
+	if !options.outOfCluster() && os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" {
+		config, err := inClusterConfig()
+		if err == nil {
+			return config, "in-cluster", nil
+		}
+
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, "", fmt.Errorf("error occurred getting the in-cluster config: %w", err)
+		}
+	}
+
+	// the KUBECONFIG list is in the Precedence of the default loading rules, the kubeconfig flag names a single file
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if options.kubeconfig != "" {
+		loadingRules.ExplicitPath = options.kubeconfig
+	}
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: options.context,
+		ClusterInfo:    clientcmdapi.Cluster{Server: options.masterUrl},
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not get Kubernetes config: %w", err)
+	}
+
+	return config, "kubeconfig", nil
+}
+
 func buildWorkQueue(settings configuration.WorkQueueSettings) (workqueue.RateLimitingInterface, error) {
 	logrus.Debug("Watcher::buildSynchronizerWorkQueue")
 
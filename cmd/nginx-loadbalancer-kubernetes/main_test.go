@@ -4,3 +4,147 @@
  */
 
 package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: local
+  cluster:
+    server: https://127.0.0.1:6443
+- name: remote
+  cluster:
+    server: https://remote.example.com:6443
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: developer
+- name: remote
+  context:
+    cluster: remote
+    user: developer
+current-context: local
+users:
+- name: developer
+  user:
+    token: secret
+`
+
+func TestBuildRestConfig_UsesTheInClusterConfigByDefault(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+
+	config, mode, err := buildRestConfig(kubernetesClientOptions{}, inCluster("https://10.96.0.1:443"))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if mode != "in-cluster" || config.Host != "https://10.96.0.1:443" {
+		t.Errorf(`expected the in-cluster config, got %s %s`, mode, config.Host)
+	}
+}
+
+func TestBuildRestConfig_UsesTheKubeconfigFlag(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+
+	options := kubernetesClientOptions{kubeconfig: writeKubeconfig(t)}
+
+	config, mode, err := buildRestConfig(options, inCluster("https://10.96.0.1:443"))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if mode != "kubeconfig" || config.Host != "https://127.0.0.1:6443" {
+		t.Errorf(`expected the current context of the kubeconfig, got %s %s`, mode, config.Host)
+	}
+}
+
+func TestBuildRestConfig_FallsBackToTheKubeconfigEnvironment(t *testing.T) {
+	t.Setenv("KUBECONFIG", writeKubeconfig(t))
+
+	config, mode, err := buildRestConfig(kubernetesClientOptions{context: "remote"}, inCluster("https://10.96.0.1:443"))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if mode != "kubeconfig" || config.Host != "https://remote.example.com:6443" {
+		t.Errorf(`expected the selected context of the kubeconfig, got %s %s`, mode, config.Host)
+	}
+}
+
+func TestBuildRestConfig_MergesTheKubeconfigEnvironmentList(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("KUBECONFIG", strings.Join([]string{missing, writeKubeconfig(t)}, string(filepath.ListSeparator)))
+
+	config, mode, err := buildRestConfig(kubernetesClientOptions{context: "remote"}, inCluster("https://10.96.0.1:443"))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if mode != "kubeconfig" || config.Host != "https://remote.example.com:6443" {
+		t.Errorf(`expected the selected context of the listed kubeconfig, got %s %s`, mode, config.Host)
+	}
+}
+
+func TestBuildRestConfig_OverridesTheMasterUrl(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+
+	options := kubernetesClientOptions{kubeconfig: writeKubeconfig(t), masterUrl: "https://override.example.com:6443"}
+
+	config, _, err := buildRestConfig(options, inCluster("https://10.96.0.1:443"))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if config.Host != "https://override.example.com:6443" {
+		t.Errorf(`expected the master URL to override the kubeconfig, got %s`, config.Host)
+	}
+}
+
+func TestBuildRestConfig_FallsBackToTheKubeconfigOutsideTheCluster(t *testing.T) {
+	t.Setenv("KUBECONFIG", writeKubeconfig(t))
+
+	notInCluster := func() (*rest.Config, error) { return nil, rest.ErrNotInCluster }
+
+	config, mode, err := buildRestConfig(kubernetesClientOptions{}, notInCluster)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if mode != "kubeconfig" || config.Host != "https://127.0.0.1:6443" {
+		t.Errorf(`expected the kubeconfig outside the cluster, got %s %s`, mode, config.Host)
+	}
+}
+
+func TestBuildRestConfig_RejectsAnUnknownContext(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+
+	options := kubernetesClientOptions{kubeconfig: writeKubeconfig(t), context: "staging"}
+
+	if _, _, err := buildRestConfig(options, inCluster("https://10.96.0.1:443")); err == nil {
+		t.Error(`expected an error for an unknown context`)
+	}
+}
+
+func inCluster(host string) func() (*rest.Config, error) {
+	return func() (*rest.Config, error) {
+		return &rest.Config{Host: host}, nil
+	}
+}
+
+func writeKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatalf(`error occurred writing the kubeconfig: %v`, err)
+	}
+
+	return path
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/observation"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestStartWhenSynced_RunsNothingBeforeTheCachesSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listed := make(chan struct{})
+	watcher, settings := buildBlockedWatcher(t, ctx, listed)
+
+	var runs atomic.Int32
+	run := func(<-chan struct{}) { runs.Add(1) }
+
+	started := make(chan error, 1)
+	go func() { started <- startWhenSynced(ctx, settings, watcher, run, run) }()
+
+	time.Sleep(100 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Fatal(`expected nothing to run before the caches have synced`)
+	}
+
+	close(listed)
+
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`expected the caches to sync once the Services are listed`)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); runs.Load() != 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if runs.Load() != 2 {
+		t.Fatalf(`expected the Handler and the Synchronizer to run once the caches have synced, got %d runs`, runs.Load())
+	}
+}
+
+func TestStartWhenSynced_FailsWhenTheCachesDoNotSyncInTime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listed := make(chan struct{})
+	defer close(listed)
+
+	watcher, settings := buildBlockedWatcher(t, ctx, listed)
+	settings.Watcher.CacheSyncTimeout = 100 * time.Millisecond
+
+	var runs atomic.Int32
+	err := startWhenSynced(ctx, settings, watcher, func(<-chan struct{}) { runs.Add(1) })
+	if err == nil {
+		t.Fatal(`expected an error when the caches do not sync within the timeout`)
+	}
+
+	if runs.Load() != 0 {
+		t.Fatal(`expected nothing to run when the caches have not synced`)
+	}
+}
+
+// buildBlockedWatcher builds an initialized Watcher whose listing of the Services blocks until listed is closed.
+func buildBlockedWatcher(t *testing.T, ctx context.Context, listed <-chan struct{}) (*observation.Watcher, *configuration.Settings) {
+	k8sClient := fake.NewSimpleClientset()
+	k8sClient.PrependReactor("list", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+		select {
+		case <-listed:
+		case <-ctx.Done():
+		}
+
+		return false, nil, nil
+	})
+
+	settings, err := configuration.NewSettings(ctx, k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress"}
+	settings.Watcher.RbacMode = configuration.RbacModeCluster
+
+	watcher, err := observation.NewWatcher(settings, &mocks.MockHandler{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err = watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return watcher, settings
+}
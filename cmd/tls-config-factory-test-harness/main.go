@@ -18,7 +18,7 @@ const (
 
 type TlsConfiguration struct {
 	Description string
-	Settings    configuration.Settings
+	Settings    *configuration.Settings
 }
 
 func main() {
@@ -31,7 +31,7 @@ func main() {
 
 		logrus.Infof("\n\n\t*** Building TLS config for <<< %s >>>\n\n", name)
 
-		tlsConfig, err := authentication.NewTlsConfig(&settings.Settings)
+		tlsConfig, err := authentication.NewTlsConfig(settings.Settings)
 		if err != nil {
 			panic(err)
 		}
@@ -82,12 +82,12 @@ func buildConfigMap() map[string]TlsConfiguration {
 	return configurations
 }
 
-func ssTlsConfig() configuration.Settings {
+func ssTlsConfig() *configuration.Settings {
 	certificates := make(map[string]map[string]core.SecretBytes)
 	certificates[CaCertificateSecretKey] = buildCaCertificateEntry(caCertificatePEM())
 	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(clientKeyPEM(), clientCertificatePEM())
 
-	return configuration.Settings{
+	return &configuration.Settings{
 		TlsMode: configuration.SelfSignedTLS,
 		Certificates: &certification.Certificates{
 			Certificates: certificates,
@@ -95,12 +95,12 @@ func ssTlsConfig() configuration.Settings {
 	}
 }
 
-func ssMtlsConfig() configuration.Settings {
+func ssMtlsConfig() *configuration.Settings {
 	certificates := make(map[string]map[string]core.SecretBytes)
 	certificates[CaCertificateSecretKey] = buildCaCertificateEntry(caCertificatePEM())
 	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(clientKeyPEM(), clientCertificatePEM())
 
-	return configuration.Settings{
+	return &configuration.Settings{
 		TlsMode: configuration.SelfSignedMutualTLS,
 		Certificates: &certification.Certificates{
 			Certificates: certificates,
@@ -108,17 +108,17 @@ func ssMtlsConfig() configuration.Settings {
 	}
 }
 
-func caTlsConfig() configuration.Settings {
-	return configuration.Settings{
+func caTlsConfig() *configuration.Settings {
+	return &configuration.Settings{
 		TlsMode: configuration.CertificateAuthorityTLS,
 	}
 }
 
-func caMtlsConfig() configuration.Settings {
+func caMtlsConfig() *configuration.Settings {
 	certificates := make(map[string]map[string]core.SecretBytes)
 	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(clientKeyPEM(), clientCertificatePEM())
 
-	return configuration.Settings{
+	return &configuration.Settings{
 		TlsMode: configuration.CertificateAuthorityMutualTLS,
 		Certificates: &certification.Certificates{
 			Certificates: certificates,
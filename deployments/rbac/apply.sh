@@ -7,6 +7,7 @@ echo "Applying all RBAC resources..."
 kubectl apply -f serviceaccount.yaml
 kubectl apply -f clusterrole.yaml
 kubectl apply -f clusterrolebinding.yaml
+kubectl apply -f dns-service-role.yaml
 kubectl apply -f secret.yaml
 
 popd
@@ -5,4 +5,5 @@ echo "Unapplying all RBAC resources..."
 kubectl delete -f serviceaccount.yaml
 kubectl delete -f clusterrole.yaml
 kubectl delete -f clusterrolebinding.yaml
+kubectl delete -f dns-service-role.yaml
 kubectl delete -f secret.yaml
@@ -0,0 +1,13 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+/*
+Package admission includes support for an optional validating admission webhook.
+
+The Webhook rejects the changes to the NLK ConfigMap, and to the ports annotation of the Services, that the controller would
+not apply, so the mistakes are reported to whoever makes them rather than in the logs of the controller.
+*/
+
+package admission
@@ -0,0 +1,218 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/translation"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidatePath is the path at which the AdmissionReviews are served, referenced by the ValidatingWebhookConfiguration.
+const ValidatePath = "/validate"
+
+// Webhook is a validating admission webhook for the CREATE and UPDATE of the NLK ConfigMap, see Settings::ValidateConfigMap,
+// and of the Services, see translation.ValidatePortMappings and translation.ValidateServerPorts. The other objects and operations are allowed.
+// It is served over TLS, with a serving certificate read from mounted files and reloaded when they are rotated.
+type Webhook struct {
+
+	// address is the host and port the webhook listens on.
+	address string
+
+	// settings names the ConfigMap, and holds the settings the config.yaml key is applied over.
+	settings *configuration.Settings
+
+	// paths are the paths of the serving certificate, followed by any intermediates, and of its key.
+	paths certification.CertificatePaths
+
+	// certificate is the last serving certificate that could be loaded.
+	certificate atomic.Pointer[tls.Certificate]
+
+	// The underlying HTTP server.
+	httpServer *http.Server
+}
+
+// NewWebhook creates a new Webhook listening on the address, serving the certificate and key read from the files.
+func NewWebhook(settings *configuration.Settings, address string, certificatePath string, keyPath string) *Webhook {
+	return &Webhook{
+		address:  address,
+		settings: settings,
+		paths:    certification.CertificatePaths{ClientCertificate: certificatePath, ClientKey: keyPath},
+	}
+}
+
+// Start reads the serving certificate and spins up the webhook; the certificate files are watched, and the webhook is
+// stopped, when the context is done. An error is returned when the certificate cannot be loaded.
+func (w *Webhook) Start(ctx context.Context) error {
+	logrus.Debugf("Starting admission webhook listener on %s", w.address)
+
+	certificates := certification.NewFileCertificates(ctx)
+	certificates.SetPaths(w.paths)
+
+	if err := w.loadCertificate(certificates); err != nil {
+		return err
+	}
+
+	certificates.Subscribe(func() {
+		if err := w.loadCertificate(certificates); err != nil {
+			logrus.Errorf("Webhook::Start: the serving certificate has NOT been reloaded: %v", err)
+		}
+	})
+
+	go func() {
+		if err := certificates.Run(); err != nil {
+			logrus.Errorf("Webhook::Start: the serving certificate will not be reloaded: %v", err)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(ValidatePath, w)
+
+	w.httpServer = &http.Server{
+		Addr:    w.address,
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return w.certificate.Load(), nil
+			},
+		},
+	}
+
+	listener, err := net.Listen("tcp", w.address)
+	if err != nil {
+		return fmt.Errorf(`unable to start admission webhook listener on %s: %w`, w.address, err)
+	}
+
+	go func() {
+		if err := w.httpServer.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("unable to serve the admission webhook on %s: %v", w.address, err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		w.Stop()
+	}()
+
+	logrus.Info("Started admission webhook listener on ", listener.Addr())
+
+	return nil
+}
+
+// Stop shuts down the webhook.
+func (w *Webhook) Stop() {
+	if err := w.httpServer.Shutdown(context.Background()); err != nil {
+		logrus.Errorf("unable to stop admission webhook listener on %s: %v", w.address, err)
+	}
+}
+
+// loadCertificate parses the serving certificate and key read from the files; the current certificate is kept on error.
+func (w *Webhook) loadCertificate(certificates *certification.FileCertificates) error {
+	key, certificate := certificates.GetClientCertificate()
+
+	keyPair, err := tls.X509KeyPair(certificate, key)
+	if err != nil {
+		return fmt.Errorf(`error occurred loading the serving certificate %s and key %s: %w`, w.paths.ClientCertificate, w.paths.ClientKey, err)
+	}
+
+	w.certificate.Store(&keyPair)
+
+	return nil
+}
+
+// ServeHTTP answers an AdmissionReview with the review of its request.
+func (w *Webhook) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	review := admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(request.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(writer, "the body must be an AdmissionReview with a request", http.StatusBadRequest)
+		return
+	}
+
+	response := w.review(review.Request)
+	response.UID = review.Request.UID
+
+	body, err := json.Marshal(admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: response})
+	if err != nil {
+		logrus.WithError(err).Error(`Webhook::ServeHTTP: error occurred encoding the response`)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := writer.Write(body); err != nil {
+		logrus.WithError(err).Error(`Webhook::ServeHTTP: error occurred writing the response`)
+	}
+}
+
+// review validates the object of a CREATE or UPDATE of the NLK ConfigMap or of a Service, and allows anything else.
+func (w *Webhook) review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if request.Operation != admissionv1.Create && request.Operation != admissionv1.Update {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	var errs field.ErrorList
+
+	switch request.Kind {
+	case metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}:
+		configMap := &corev1.ConfigMap{}
+		if err := json.Unmarshal(request.Object.Raw, configMap); err != nil {
+			return rejected(fmt.Sprintf("the ConfigMap cannot be decoded: %v", err))
+		}
+
+		if request.Namespace != w.settings.ConfigMapNamespace || configMap.Name != w.settings.ConfigMapName {
+			return &admissionv1.AdmissionResponse{Allowed: true}
+		}
+
+		errs = w.settings.ValidateConfigMap(configMap)
+
+	case metav1.GroupVersionKind{Version: "v1", Kind: "Service"}:
+		service := &corev1.Service{}
+		if err := json.Unmarshal(request.Object.Raw, service); err != nil {
+			return rejected(fmt.Sprintf("the Service cannot be decoded: %v", err))
+		}
+
+		endpointSlices := w.settings.Watcher.TargetMode == configuration.TargetModeEndpointSlices
+		errs = append(translation.ValidatePortMappings(service), translation.ValidateServerPorts(service, endpointSlices)...)
+	}
+
+	if len(errs) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	logrus.WithFields(logrus.Fields{"kind": request.Kind.Kind, "namespace": request.Namespace, "name": request.Name}).
+		Infof("Webhook::review: rejected the %s: %v", request.Operation, errs.ToAggregate())
+
+	return rejected(fmt.Sprintf("%s %s/%s is invalid: %v", request.Kind.Kind, request.Namespace, request.Name, errs.ToAggregate()))
+}
+
+// rejected returns a response rejecting the request, for the reason given in the message.
+func rejected(message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: message,
+		},
+	}
+}
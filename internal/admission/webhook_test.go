@@ -0,0 +1,148 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestWebhook_RejectsAnInvalidConfigMap(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), nil)
+	webhook := NewWebhook(settings, "", "", "")
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMapName, Namespace: settings.ConfigMapNamespace},
+		Data: map[string]string{
+			"nginx-hosts": "https://10.0.0.1:9000/api,10.0.0.2:9000/api",
+			"tls-mode":    "ca-tsl",
+			"config.yaml": "synchronizer:\n  coalesce-window: soon\n",
+		},
+	}
+
+	response := postReview(t, webhook, "ConfigMap", configMap.Namespace, configMap)
+	if response.Allowed || response.Result == nil {
+		t.Fatalf(`expected the ConfigMap to be rejected, got %v`, response)
+	}
+
+	for _, expected := range []string{`data[nginx-hosts][1]`, `data[tls-mode]: Unsupported value: "ca-tsl"`, `data[config.yaml]`} {
+		if !strings.Contains(response.Result.Message, expected) {
+			t.Fatalf(`expected the reason to name %s, got %s`, expected, response.Result.Message)
+		}
+	}
+
+	// the other ConfigMaps are not validated
+	configMap.Name = "another"
+	if response = postReview(t, webhook, "ConfigMap", configMap.Namespace, configMap); !response.Allowed {
+		t.Fatalf(`expected another ConfigMap to be allowed, got %v`, response.Result)
+	}
+
+	configMap.Name = settings.ConfigMapName
+	configMap.Data = map[string]string{"nginx-hosts": "https://10.0.0.1:9000/api", "tls-mode": "ca-tls"}
+	if response = postReview(t, webhook, "ConfigMap", configMap.Namespace, configMap); !response.Allowed {
+		t.Fatalf(`expected a valid ConfigMap to be allowed, got %v`, response.Result)
+	}
+}
+
+func TestWebhook_RejectsAMalformedPortsAnnotation(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), nil)
+	webhook := NewWebhook(settings, "", "", "")
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: map[string]string{
+			"nginxinc.io/ports": "8443:tls:stream,8080:web",
+		}},
+	}
+
+	response := postReview(t, webhook, "Service", service.Namespace, service)
+	if response.Allowed || !strings.Contains(response.Result.Message, `metadata.annotations[nginxinc.io/ports][1]`) {
+		t.Fatalf(`expected the Service to be rejected for its second entry, got %v`, response.Result)
+	}
+}
+
+func TestWebhook_RejectsAServiceWithoutAPortForTheServers(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), nil)
+	webhook := NewWebhook(settings, "", "", "")
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{Name: "nlk-http", Port: 80, TargetPort: intstr.FromString("http")}},
+		},
+	}
+
+	response := postReview(t, webhook, "Service", service.Namespace, service)
+	if response.Allowed || !strings.Contains(response.Result.Message, configuration.UseHostPortsAnnotation) {
+		t.Fatalf(`expected the Service to be rejected as it has no nodePort, got %v`, response.Result)
+	}
+
+	service.Annotations = map[string]string{configuration.UseHostPortsAnnotation: "true"}
+	if response = postReview(t, webhook, "Service", service.Namespace, service); response.Allowed || !strings.Contains(response.Result.Message, `spec.ports[0].targetPort`) {
+		t.Fatalf(`expected the Service to be rejected for its named targetPort, got %v`, response.Result)
+	}
+
+	settings.Watcher.TargetMode = configuration.TargetModeEndpointSlices
+	if response = postReview(t, webhook, "Service", service.Namespace, service); !response.Allowed {
+		t.Fatalf(`expected the Service to be allowed in the endpointslices target mode, got %v`, response.Result)
+	}
+}
+
+func TestWebhook_DoesNotStartWithoutACertificate(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), nil)
+	directory := t.TempDir()
+
+	webhook := NewWebhook(settings, "127.0.0.1:0", filepath.Join(directory, "tls.crt"), filepath.Join(directory, "tls.key"))
+	if err := webhook.Start(context.Background()); err == nil {
+		t.Fatalf(`expected an error when the serving certificate cannot be loaded`)
+	}
+}
+
+func postReview(t *testing.T, webhook *Webhook, kind string, namespace string, object runtime.Object) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "review",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+			Namespace: namespace,
+			Operation: admissionv1.Update,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+
+	body, _ := json.Marshal(review)
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body)))
+
+	response := admissionv1.AdmissionReview{}
+	if err = json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if response.Response == nil || response.Response.UID != "review" {
+		t.Fatalf(`expected the response to the review, got %v`, response)
+	}
+
+	return response.Response
+}
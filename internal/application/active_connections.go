@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// NginxStreamUpstreamsReaderInterface defines the function of the NGINX Plus client returning the state of the stream
+// upstreams. It is optional, the ActiveConnectionsReporter cannot tell without it.
+type NginxStreamUpstreamsReaderInterface interface {
+	// GetStreamUpstreams returns the state of the stream upstreams.
+	GetStreamUpstreams(ctx context.Context) (*nginxClient.StreamUpstreams, error)
+}
+
+// ActiveConnectionsReporter is implemented by the Border Clients that can tell the active connections of the servers of
+// an upstream, used to confirm that the draining servers of a deleted node may be removed.
+type ActiveConnectionsReporter interface {
+
+	// ActiveConnections returns the active connections of each server of the upstream of the event, by server address;
+	// the servers that are not in the upstream have none. known is false when it cannot be told, e.g. the NGINX Plus client
+	// cannot read the upstreams.
+	ActiveConnections(ctx context.Context, event *core.ServerUpdateEvent) (connections map[string]uint64, known bool, err error)
+}
+
+// ActiveConnections returns the active connections of each server of the HTTP upstream of the event.
+func (hbc *NginxHttpBorderClient) ActiveConnections(ctx context.Context, event *core.ServerUpdateEvent) (map[string]uint64, bool, error) {
+	reader, ok := hbc.nginxClient.(NginxUpstreamsReaderInterface)
+	if !ok {
+		return nil, false, nil
+	}
+
+	upstreams, err := reader.GetUpstreams(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf(`error occurred retrieving the nginx+ upstreams: %w`, newUpstreamError(event.NginxHost, OperationList, event.UpstreamName, "", err))
+	}
+
+	connections := make(map[string]uint64)
+	for _, peer := range (*upstreams)[event.UpstreamName].Peers {
+		connections[peer.Server] += peer.Active
+	}
+
+	return connections, true, nil
+}
+
+// ActiveConnections returns the active connections of each server of the stream upstream of the event.
+func (tbc *NginxStreamBorderClient) ActiveConnections(ctx context.Context, event *core.ServerUpdateEvent) (map[string]uint64, bool, error) {
+	reader, ok := tbc.nginxClient.(NginxStreamUpstreamsReaderInterface)
+	if !ok {
+		return nil, false, nil
+	}
+
+	upstreams, err := reader.GetStreamUpstreams(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf(`error occurred retrieving the nginx+ stream upstreams: %w`, newUpstreamError(event.NginxHost, OperationList, event.UpstreamName, "", err))
+	}
+
+	connections := make(map[string]uint64)
+	for _, peer := range (*upstreams)[event.UpstreamName].Peers {
+		connections[peer.Server] += peer.Active
+	}
+
+	return connections, true, nil
+}
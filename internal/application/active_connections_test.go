@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// streamUpstreamsNginxClient is a MockNginxClient that reports the state of the stream upstreams.
+type streamUpstreamsNginxClient struct {
+	*mocks.MockNginxClient
+	upstreams nginxClient.StreamUpstreams
+}
+
+func (c *streamUpstreamsNginxClient) GetStreamUpstreams(_ context.Context) (*nginxClient.StreamUpstreams, error) {
+	return &c.upstreams, nil
+}
+
+func TestHttpBorderClient_ActiveConnections(t *testing.T) {
+	client := &upstreamsNginxClient{MockNginxClient: mocks.NewMockNginxClient(), upstreams: nginxClient.Upstreams{
+		upstreamName: {Peers: []nginxClient.Peer{{Server: "10.0.0.1:30080", Active: 3}, {Server: "10.0.0.2:30080"}}},
+	}}
+
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	connections, known, err := borderClient.(ActiveConnectionsReporter).ActiveConnections(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxHttp))
+	if err != nil || !known {
+		t.Fatalf(`expected the active connections to be known, got %v, %v`, known, err)
+	}
+
+	if connections["10.0.0.1:30080"] != 3 || connections["10.0.0.2:30080"] != 0 || len(connections) != 2 {
+		t.Errorf(`expected the active connections of each server, got %v`, connections)
+	}
+}
+
+func TestStreamBorderClient_ActiveConnections(t *testing.T) {
+	client := &streamUpstreamsNginxClient{MockNginxClient: mocks.NewMockNginxClient(), upstreams: nginxClient.StreamUpstreams{
+		upstreamName: {Peers: []nginxClient.StreamPeer{{Server: "10.0.0.1:30443", Active: 1}}},
+	}}
+
+	borderClient, err := NewBorderClient(ClientTypeNginxStream, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	connections, known, err := borderClient.(ActiveConnectionsReporter).ActiveConnections(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxStream))
+	if err != nil || !known || connections["10.0.0.1:30443"] != 1 {
+		t.Fatalf(`expected the active connections of the server, got %v, %v, %v`, connections, known, err)
+	}
+
+	// the client of the other tests cannot read the upstreams
+	borderClient, _, _ = buildBorderClient(ClientTypeNginxStream)
+	if _, known, _ = borderClient.(ActiveConnectionsReporter).ActiveConnections(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxStream)); known {
+		t.Errorf(`expected the active connections to be unknown without the upstreams`)
+	}
+}
@@ -41,3 +41,15 @@ func buildServerUpdateEvent(eventType core.EventType, clientType string) *core.S
 
 	return core.NewServerUpdateEvent(eventType, upstreamName, clientType, upstreamServers)
 }
+
+func buildParameterizedUpstreamServer() *core.UpstreamServer {
+	weight := 3
+	maxFails := 2
+
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+	server.Weight = &weight
+	server.MaxFails = &maxFails
+	server.FailTimeout = "15s"
+
+	return server
+}
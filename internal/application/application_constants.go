@@ -25,4 +25,8 @@ const (
 
 	// ClientTypeNginxHttp creates an NginxHttpBorderClient that uses the HTTP* methods of the NGINX Plus client.
 	ClientTypeNginxHttp = "http"
+
+	// ClientTypeNginxUdp creates an NginxUdpBorderClient that uses the Stream* methods of the NGINX Plus client
+	// to manage stream upstreams that are proxied with `proto udp`. Service ports with `protocol: UDP` always use this client.
+	ClientTypeNginxUdp = "udp"
 )
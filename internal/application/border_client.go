@@ -6,15 +6,18 @@
 package application
 
 import (
+	"context"
 	"fmt"
+
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/sirupsen/logrus"
 )
 
-// Interface defines the functions required to implement a Border Client.
+// Interface defines the functions required to implement a Border Client. The NGINX Plus API calls are made with the
+// context, so they are aborted once it is done, e.g. when the time allowed to update the upstream has elapsed.
 type Interface interface {
-	Update(*core.ServerUpdateEvent) error
-	Delete(*core.ServerUpdateEvent) error
+	Update(context.Context, *core.ServerUpdateEvent) error
+	Delete(context.Context, *core.ServerUpdateEvent) error
 }
 
 // BorderClient defines any state need by the Border Client.
@@ -37,6 +40,9 @@ func NewBorderClient(clientType string, borderClient interface{}) (Interface, er
 	case ClientTypeNginxHttp:
 		return NewNginxHttpBorderClient(borderClient)
 
+	case ClientTypeNginxUdp:
+		return NewNginxUdpBorderClient(borderClient)
+
 	default:
 		borderClient, _ := NewNullBorderClient()
 		return borderClient, fmt.Errorf(`unknown border client type: %s`, clientType)
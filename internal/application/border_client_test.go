@@ -34,6 +34,18 @@ func TestBorderClient_CreatesTcpBorderClient(t *testing.T) {
 	}
 }
 
+func TestBorderClient_CreatesUdpBorderClient(t *testing.T) {
+	borderClient := mocks.MockNginxClient{}
+	client, err := NewBorderClient("udp", borderClient)
+	if err != nil {
+		t.Errorf(`error creating border client: %v`, err)
+	}
+
+	if _, ok := client.(*NginxUdpBorderClient); !ok {
+		t.Errorf(`expected client to be of type NginxUdpBorderClient`)
+	}
+}
+
 func TestBorderClient_UnknownClientType(t *testing.T) {
 	unknownClientType := "unknown"
 	borderClient := mocks.MockNginxClient{}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// BorderServers defines the functions needed to program a kind of Border Server, selected by the border-type setting.
+// The Synchronizer fans each event out to the hosts, and retries the hosts that fail, regardless of the kind of Border Server.
+type BorderServers interface {
+
+	// BorderClient creates the Border Client updating the servers of the upstreams of the client type on the host.
+	BorderClient(host string, clientType string) (Interface, error)
+
+	// Ping checks that the host responds, e.g. when its circuit is open; the call is made with the context.
+	Ping(ctx context.Context, host string) error
+}
+
+// BorderServersFactory creates the BorderServers of a border type, calling the hosts with the HTTP client, which is
+// shared by all the hosts and carries the TLS configuration and credentials of NLK.
+type BorderServersFactory func(httpClient *http.Client) (BorderServers, error)
+
+var (
+
+	// borderServersFactoriesLock guards borderServersFactories.
+	borderServersFactoriesLock sync.Mutex
+
+	// borderServersFactories holds the factory of each registered border type.
+	borderServersFactories = make(map[string]BorderServersFactory)
+)
+
+// RegisterBorderServers registers the factory of the BorderServers of the border type, replacing the factory registered
+// for it, if any, and registers the border type, see core.RegisterBorderType. The NGINX Plus hosts are built in, see
+// core.BorderTypeNginxPlus.
+//
+// Note, this is an extensibility point. To add a kind of Border Server...
+// 1. Create a module that implements the BorderServers interface, and the Interface of its Border Clients;
+// 2. Add a new constant in core/border_types.go that acts as the border-type setting selecting it;
+// 3. Register its factory with RegisterBorderServers, in the init function of the module;
+func RegisterBorderServers(borderType string, factory BorderServersFactory) {
+	borderServersFactoriesLock.Lock()
+	defer borderServersFactoriesLock.Unlock()
+
+	borderServersFactories[borderType] = factory
+	core.RegisterBorderType(borderType)
+}
+
+// NewBorderServers creates the BorderServers of a registered border type.
+func NewBorderServers(borderType string, httpClient *http.Client) (BorderServers, error) {
+	borderServersFactoriesLock.Lock()
+	factory, found := borderServersFactories[borderType]
+	borderServersFactoriesLock.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf(`unknown border type: %s, expected %s`, borderType, strings.Join(core.BorderTypes(), ", "))
+	}
+
+	return factory(httpClient)
+}
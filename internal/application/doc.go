@@ -14,7 +14,9 @@ To add a Border Server client...
 2. Add a new constant in application_constants.go that acts as a key for selecting the client;
 3. Update the NewBorderClient factory method in border_client.go that returns the client;
 
-At this time the only supported Border Servers are NGINX Plus servers.
+The kind of Border Server is selected by the border-type setting, see border_servers.go. The NGINX Plus servers are built in,
+the other kinds implement the BorderServers interface and register it with RegisterBorderServers:
+- WebhookBorderServers: POST the servers of each upstream as JSON to the URL of each host, e.g. an agent programming NGINX OSS.
 
 The two Border Server clients for NGINX Plus are:
 - NginxHttpBorderClient: updates NGINX Plus servers using HTTP Upstream methods on the NGINX Plus API.
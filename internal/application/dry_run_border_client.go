@@ -0,0 +1,53 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// DryRunBorderClient implements the BorderClient interface without calling the host, for the Border Servers whose
+// current servers cannot be read, e.g. the WebhookBorderServers. The servers that would be sent are logged; unlike the
+// DryRunNginxClient, the changes are not computed, so they are not counted in the nkl_dry_run_changes_total metric.
+type DryRunBorderClient struct {
+	host string
+}
+
+// NewDryRunBorderClient is the Factory function for creating a DryRunBorderClient for the host.
+func NewDryRunBorderClient(host string) Interface {
+	return &DryRunBorderClient{
+		host: host,
+	}
+}
+
+// Update logs the servers the upstream would be updated with.
+func (c *DryRunBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	c.report(event, "update")
+	return nil
+}
+
+// Delete logs the servers that would be removed from the upstream.
+func (c *DryRunBorderClient) Delete(_ context.Context, event *core.ServerUpdateEvent) error {
+	c.report(event, "delete")
+	return nil
+}
+
+// report logs the servers of the event that would be sent to the host.
+func (c *DryRunBorderClient) report(event *core.ServerUpdateEvent, action string) {
+	servers := make([]string, 0, len(event.UpstreamServers))
+	for _, server := range event.UpstreamServers {
+		servers = append(servers, server.Host)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"host":     c.host,
+		"upstream": event.UpstreamName,
+		action:     servers,
+	}).Info("DryRunBorderClient: dry run, the upstream has not been changed")
+}
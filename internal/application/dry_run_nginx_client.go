@@ -0,0 +1,287 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultWeight, defaultMaxFails, defaultFailTimeout, and defaultSlowStart are the NGINX Plus defaults for the server
+	// parameters NLK manages.
+	defaultWeight      = 1
+	defaultMaxFails    = 1
+	defaultFailTimeout = "10s"
+	defaultSlowStart   = "0s"
+)
+
+// NginxReaderInterface defines the read-only functions of the NGINX Plus client, used to compute the changes in dry-run mode.
+type NginxReaderInterface interface {
+	// GetHTTPServers returns the servers of an HTTP upstream.
+	GetHTTPServers(ctx context.Context, upstream string) ([]nginxClient.UpstreamServer, error)
+
+	// GetStreamServers returns the servers of a stream upstream.
+	GetStreamServers(ctx context.Context, upstream string) ([]nginxClient.StreamUpstreamServer, error)
+}
+
+// DryRunNginxClient implements the NginxClientInterface without changing NGINX Plus. The changes that would be made
+// are computed from the current upstream servers, logged, and counted in the nkl_dry_run_changes_total metric.
+type DryRunNginxClient struct {
+	reader NginxReaderInterface
+	host   string
+}
+
+// NewDryRunNginxClient is the Factory function for creating a DryRunNginxClient for the NGINX Plus host.
+func NewDryRunNginxClient(reader NginxReaderInterface, host string) *DryRunNginxClient {
+	return &DryRunNginxClient{
+		reader: reader,
+		host:   host,
+	}
+}
+
+// GetHTTPServers returns the current servers of the HTTP upstream.
+func (c *DryRunNginxClient) GetHTTPServers(ctx context.Context, upstream string) ([]nginxClient.UpstreamServer, error) {
+	return c.reader.GetHTTPServers(ctx, upstream)
+}
+
+// GetStreamServers returns the current servers of the stream upstream.
+func (c *DryRunNginxClient) GetStreamServers(ctx context.Context, upstream string) ([]nginxClient.StreamUpstreamServer, error) {
+	return c.reader.GetStreamServers(ctx, upstream)
+}
+
+// DeleteStreamServer logs the removal of the server from the stream upstream, if the server is present.
+func (c *DryRunNginxClient) DeleteStreamServer(ctx context.Context, upstream string, server string) error {
+	servers, err := c.reader.GetStreamServers(ctx, upstream)
+	if err != nil {
+		return fmt.Errorf(`error occurred retrieving the servers of the %s stream upstream: %w`, upstream, err)
+	}
+
+	var present []nginxClient.StreamUpstreamServer
+	for _, current := range servers {
+		if normalizeServerAddress(current.Server) == normalizeServerAddress(server) {
+			present = append(present, current)
+		}
+	}
+
+	c.report(upstream, nil, nil, streamServerNames(present))
+
+	return nil
+}
+
+// UpdateStreamServers logs the changes that would reconcile the stream upstream with the servers.
+func (c *DryRunNginxClient) UpdateStreamServers(ctx context.Context, upstream string, servers []nginxClient.StreamUpstreamServer) ([]nginxClient.StreamUpstreamServer, []nginxClient.StreamUpstreamServer, []nginxClient.StreamUpstreamServer, error) {
+	current, err := c.reader.GetStreamServers(ctx, upstream)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(`error occurred retrieving the servers of the %s stream upstream: %w`, upstream, err)
+	}
+
+	currentByName := make(map[string]nginxClient.StreamUpstreamServer)
+	for _, server := range current {
+		currentByName[normalizeServerAddress(server.Server)] = server
+	}
+
+	desiredByName := make(map[string]bool)
+	var added, deleted, updated []nginxClient.StreamUpstreamServer
+
+	for _, server := range servers {
+		desiredByName[normalizeServerAddress(server.Server)] = true
+
+		existing, found := currentByName[normalizeServerAddress(server.Server)]
+		if !found {
+			added = append(added, server)
+		} else if !sameStreamServerParameters(server, existing) {
+			updated = append(updated, server)
+		}
+	}
+
+	for _, server := range current {
+		if !desiredByName[normalizeServerAddress(server.Server)] {
+			deleted = append(deleted, server)
+		}
+	}
+
+	c.report(upstream, streamServerNames(added), streamServerNames(updated), streamServerNames(deleted))
+
+	return added, deleted, updated, nil
+}
+
+// DeleteHTTPServer logs the removal of the server from the HTTP upstream, if the server is present.
+func (c *DryRunNginxClient) DeleteHTTPServer(ctx context.Context, upstream string, server string) error {
+	servers, err := c.reader.GetHTTPServers(ctx, upstream)
+	if err != nil {
+		return fmt.Errorf(`error occurred retrieving the servers of the %s upstream: %w`, upstream, err)
+	}
+
+	var present []nginxClient.UpstreamServer
+	for _, current := range servers {
+		if normalizeServerAddress(current.Server) == normalizeServerAddress(server) {
+			present = append(present, current)
+		}
+	}
+
+	c.report(upstream, nil, nil, httpServerNames(present))
+
+	return nil
+}
+
+// UpdateHTTPServers logs the changes that would reconcile the HTTP upstream with the servers.
+func (c *DryRunNginxClient) UpdateHTTPServers(ctx context.Context, upstream string, servers []nginxClient.UpstreamServer) ([]nginxClient.UpstreamServer, []nginxClient.UpstreamServer, []nginxClient.UpstreamServer, error) {
+	current, err := c.reader.GetHTTPServers(ctx, upstream)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(`error occurred retrieving the servers of the %s upstream: %w`, upstream, err)
+	}
+
+	currentByName := make(map[string]nginxClient.UpstreamServer)
+	for _, server := range current {
+		currentByName[normalizeServerAddress(server.Server)] = server
+	}
+
+	desiredByName := make(map[string]bool)
+	var added, deleted, updated []nginxClient.UpstreamServer
+
+	for _, server := range servers {
+		desiredByName[normalizeServerAddress(server.Server)] = true
+
+		existing, found := currentByName[normalizeServerAddress(server.Server)]
+		if !found {
+			added = append(added, server)
+		} else if !sameHttpServerParameters(server, existing) {
+			updated = append(updated, server)
+		}
+	}
+
+	for _, server := range current {
+		if !desiredByName[normalizeServerAddress(server.Server)] {
+			deleted = append(deleted, server)
+		}
+	}
+
+	c.report(upstream, httpServerNames(added), httpServerNames(updated), httpServerNames(deleted))
+
+	return added, deleted, updated, nil
+}
+
+// GetKeyValPairs returns the key-value pairs of the HTTP keyval zone, none if the reader cannot read them.
+func (c *DryRunNginxClient) GetKeyValPairs(ctx context.Context, zone string) (nginxClient.KeyValPairs, error) {
+	if reader, ok := c.reader.(NginxKeyValsReaderInterface); ok {
+		return reader.GetKeyValPairs(ctx, zone)
+	}
+
+	return nginxClient.KeyValPairs{}, nil
+}
+
+// GetStreamKeyValPairs returns the key-value pairs of the stream keyval zone, none if the reader cannot read them.
+func (c *DryRunNginxClient) GetStreamKeyValPairs(ctx context.Context, zone string) (nginxClient.KeyValPairs, error) {
+	if reader, ok := c.reader.(NginxKeyValsReaderInterface); ok {
+		return reader.GetStreamKeyValPairs(ctx, zone)
+	}
+
+	return nginxClient.KeyValPairs{}, nil
+}
+
+// AddKeyValPair logs the addition of the key to the HTTP keyval zone.
+func (c *DryRunNginxClient) AddKeyValPair(_ context.Context, zone string, key string, val string) error {
+	c.reportKeyVal(zone, "add", key, val)
+	return nil
+}
+
+// AddStreamKeyValPair logs the addition of the key to the stream keyval zone.
+func (c *DryRunNginxClient) AddStreamKeyValPair(_ context.Context, zone string, key string, val string) error {
+	c.reportKeyVal(zone, "add", key, val)
+	return nil
+}
+
+// DeleteKeyValuePair logs the removal of the key from the HTTP keyval zone.
+func (c *DryRunNginxClient) DeleteKeyValuePair(_ context.Context, zone string, key string) error {
+	c.reportKeyVal(zone, "delete", key, "")
+	return nil
+}
+
+// DeleteStreamKeyValuePair logs the removal of the key from the stream keyval zone.
+func (c *DryRunNginxClient) DeleteStreamKeyValuePair(_ context.Context, zone string, key string) error {
+	c.reportKeyVal(zone, "delete", key, "")
+	return nil
+}
+
+// reportKeyVal logs the change that would be made to the keyval zone.
+func (c *DryRunNginxClient) reportKeyVal(zone string, change string, key string, value string) {
+	logrus.WithFields(logrus.Fields{
+		"host":  c.host,
+		"zone":  zone,
+		change:  key,
+		"value": value,
+	}).Info("DryRunNginxClient: dry run, the NGINX Plus keyval zone has not been changed")
+}
+
+// report logs the changes that would be made to the upstream and counts them in the metrics.
+func (c *DryRunNginxClient) report(upstream string, added []string, updated []string, deleted []string) {
+	instrumentation.ObserveDryRun(c.host, upstream, len(added), len(updated), len(deleted))
+
+	logrus.WithFields(logrus.Fields{
+		"host":     c.host,
+		"upstream": upstream,
+		"add":      added,
+		"update":   updated,
+		"delete":   deleted,
+	}).Info("DryRunNginxClient: dry run, the NGINX Plus upstream has not been changed")
+}
+
+// sameParameters compares the server parameters NLK manages, an unset parameter has the NGINX Plus default value.
+func sameParameters(weight *int, maxFails *int, failTimeout string, drain bool, currentWeight *int, currentMaxFails *int, currentFailTimeout string, currentDrain bool) bool {
+	return valueOr(weight, defaultWeight) == valueOr(currentWeight, defaultWeight) &&
+		valueOr(maxFails, defaultMaxFails) == valueOr(currentMaxFails, defaultMaxFails) &&
+		stringOr(failTimeout, defaultFailTimeout) == stringOr(currentFailTimeout, defaultFailTimeout) &&
+		drain == currentDrain
+}
+
+// sameBackup compares the backup flag of the servers, an unset flag is not managed by NLK and is always the same.
+func sameBackup(backup *bool, currentBackup *bool) bool {
+	return backup == nil || *backup == (currentBackup != nil && *currentBackup)
+}
+
+// sameDown compares the down flag of the servers, an unset flag is not managed by NLK and is always the same.
+func sameDown(down *bool, currentDown *bool) bool {
+	return down == nil || *down == (currentDown != nil && *currentDown)
+}
+
+func valueOr(value *int, defaultValue int) int {
+	if value == nil {
+		return defaultValue
+	}
+
+	return *value
+}
+
+func stringOr(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+func httpServerNames(servers []nginxClient.UpstreamServer) []string {
+	names := []string{}
+	for _, server := range servers {
+		names = append(names, server.Server)
+	}
+
+	return names
+}
+
+func streamServerNames(servers []nginxClient.StreamUpstreamServer) []string {
+	names := []string{}
+	for _, server := range servers {
+		names = append(names, server.Server)
+	}
+
+	return names
+}
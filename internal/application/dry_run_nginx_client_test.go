@@ -0,0 +1,146 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+func TestDryRunNginxClient_UpdateHTTPServers(t *testing.T) {
+	weight := 2
+	reader := &fakeNginxReader{
+		httpServers: []nginxClient.UpstreamServer{
+			{Server: "10.0.0.1:30080"},
+			{Server: "10.0.0.2:30080"},
+			{Server: "10.0.0.3:30080"},
+		},
+	}
+
+	client := NewDryRunNginxClient(reader, "https://localhost:8080")
+	added, deleted, updated, err := client.UpdateHTTPServers(context.Background(), "upstream", []nginxClient.UpstreamServer{
+		{Server: "10.0.0.1:30080"},
+		{Server: "10.0.0.2:30080", Weight: &weight},
+		{Server: "10.0.0.4:30080"},
+	})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(added) != 1 || added[0].Server != "10.0.0.4:30080" {
+		t.Errorf(`expected 10.0.0.4:30080 to be added, got %v`, added)
+	}
+
+	if len(updated) != 1 || updated[0].Server != "10.0.0.2:30080" {
+		t.Errorf(`expected 10.0.0.2:30080 to be updated, got %v`, updated)
+	}
+
+	if len(deleted) != 1 || deleted[0].Server != "10.0.0.3:30080" {
+		t.Errorf(`expected 10.0.0.3:30080 to be deleted, got %v`, deleted)
+	}
+}
+
+func TestDryRunNginxClient_UpdateStreamServersTreatsDefaultsAsUnchanged(t *testing.T) {
+	weight := 1
+	reader := &fakeNginxReader{
+		streamServers: []nginxClient.StreamUpstreamServer{
+			{Server: "10.0.0.1:30080", Weight: &weight, FailTimeout: "10s"},
+		},
+	}
+
+	client := NewDryRunNginxClient(reader, "https://localhost:8080")
+	added, deleted, updated, err := client.UpdateStreamServers(context.Background(), "upstream", []nginxClient.StreamUpstreamServer{
+		{Server: "10.0.0.1:30080"},
+	})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(added)+len(deleted)+len(updated) != 0 {
+		t.Errorf(`expected no changes, got added=%v deleted=%v updated=%v`, added, deleted, updated)
+	}
+}
+
+func TestDryRunNginxClient_UpdateStreamServersUpdatesTheBackupFlag(t *testing.T) {
+	backup, primary := true, false
+	reader := &fakeNginxReader{
+		streamServers: []nginxClient.StreamUpstreamServer{
+			{Server: "10.0.0.1:30080", Backup: &primary},
+			{Server: "10.0.0.2:30080", Backup: &primary},
+			{Server: "10.0.0.3:30080", Backup: &backup},
+		},
+	}
+
+	client := NewDryRunNginxClient(reader, "https://localhost:8080")
+	_, _, updated, err := client.UpdateStreamServers(context.Background(), "upstream", []nginxClient.StreamUpstreamServer{
+		{Server: "10.0.0.1:30080"},
+		{Server: "10.0.0.2:30080", Backup: &backup},
+		{Server: "10.0.0.3:30080", Backup: &primary},
+	})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(updated) != 2 || updated[0].Server != "10.0.0.2:30080" || updated[1].Server != "10.0.0.3:30080" {
+		t.Errorf(`expected the servers whose backup flag changed to be updated, got %v`, updated)
+	}
+}
+
+func TestDryRunNginxClient_DeleteHTTPServerDoesNotChangeNginx(t *testing.T) {
+	reader := &fakeNginxReader{
+		httpServers: []nginxClient.UpstreamServer{{Server: "10.0.0.1:30080"}},
+	}
+
+	client := NewDryRunNginxClient(reader, "https://localhost:8080")
+	if err := client.DeleteHTTPServer(context.Background(), "upstream", "10.0.0.1:30080"); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if reader.calls != 1 {
+		t.Errorf(`expected the servers to be read once, got %d calls`, reader.calls)
+	}
+}
+
+func TestDryRunNginxClient_UpdateKeyValsDoesNotChangeNginx(t *testing.T) {
+	keyVals := newKeyValsClient("allowed_nodes", nginxClient.KeyValPairs{"10.0.0.3": "nginx-ingress/nginx-ingress"})
+	event := buildKeyValsEvent(createEventType, &core.KeyValZone{Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"}, "10.0.0.1:30080")
+
+	reader := struct {
+		*fakeNginxReader
+		*keyValsClient
+	}{&fakeNginxReader{}, keyVals}
+
+	client := NewDryRunNginxClient(reader, "https://localhost:8080")
+	if err := updateKeyVals(context.Background(), client, event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	assertKeyValPairs(t, keyVals.zones["allowed_nodes"], nginxClient.KeyValPairs{"10.0.0.3": "nginx-ingress/nginx-ingress"})
+
+	if len(keyVals.deleted) != 0 {
+		t.Errorf(`expected no key to be deleted, got %v`, keyVals.deleted)
+	}
+}
+
+// fakeNginxReader returns the configured upstream servers.
+type fakeNginxReader struct {
+	httpServers   []nginxClient.UpstreamServer
+	streamServers []nginxClient.StreamUpstreamServer
+	calls         int
+}
+
+func (f *fakeNginxReader) GetHTTPServers(_ context.Context, _ string) ([]nginxClient.UpstreamServer, error) {
+	f.calls++
+	return f.httpServers, nil
+}
+
+func (f *fakeNginxReader) GetStreamServers(_ context.Context, _ string) ([]nginxClient.StreamUpstreamServer, error) {
+	f.calls++
+	return f.streamServers, nil
+}
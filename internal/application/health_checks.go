@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+const (
+	// peerStateChecking and peerStateUnhealthy are the states of the servers of an upstream that only exist with active health checks.
+	peerStateChecking  = "checking"
+	peerStateUnhealthy = "unhealthy"
+)
+
+// NginxUpstreamsReaderInterface defines the function of the NGINX Plus client returning the state of the HTTP upstreams,
+// including the health checks of their servers. It is optional, the HealthCheckReporter cannot tell without it.
+type NginxUpstreamsReaderInterface interface {
+	// GetUpstreams returns the state of the HTTP upstreams.
+	GetUpstreams(ctx context.Context) (*nginxClient.Upstreams, error)
+}
+
+// HealthCheckReporter is implemented by the Border Clients that can tell whether NGINX Plus actively health checks the
+// servers of an upstream, see core.HealthCheckHint.
+type HealthCheckReporter interface {
+
+	// HealthChecked returns whether NGINX Plus actively health checks the servers of the upstream of the event, as the
+	// servers were last updated by the Border Client. known is false when it cannot be told, e.g. every server has just
+	// been added and not checked yet.
+	HealthChecked(ctx context.Context, event *core.ServerUpdateEvent) (checked bool, known bool, err error)
+}
+
+// HealthChecked returns whether NGINX Plus actively health checks the servers of the HTTP upstream of the event. A server
+// is health checked once it has been checked, or while its state is checking or unhealthy; the servers added by the last
+// Update have not been checked yet, so they do not tell. The health check of the healthCheckNodePort cannot be told apart
+// from a health check of the upstream port, as the NGINX Plus API does not report the port that is probed.
+func (hbc *NginxHttpBorderClient) HealthChecked(ctx context.Context, event *core.ServerUpdateEvent) (bool, bool, error) {
+	reader, ok := hbc.nginxClient.(NginxUpstreamsReaderInterface)
+	if !ok {
+		return false, false, nil
+	}
+
+	upstreams, err := reader.GetUpstreams(ctx)
+	if err != nil {
+		return false, false, fmt.Errorf(`error occurred retrieving the nginx+ upstreams: %w`, newUpstreamError(event.NginxHost, OperationList, event.UpstreamName, "", err))
+	}
+
+	upstream, found := (*upstreams)[event.UpstreamName]
+	if !found {
+		return false, false, nil
+	}
+
+	known := false
+	for _, peer := range upstream.Peers {
+		if peer.HealthChecks.Checks > 0 || peer.State == peerStateChecking || peer.State == peerStateUnhealthy {
+			return true, true, nil
+		}
+
+		if !hbc.added[peer.Server] {
+			known = true
+		}
+	}
+
+	return false, known, nil
+}
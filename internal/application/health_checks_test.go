@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// upstreamsNginxClient is a MockNginxClient that reports the state of the upstreams, and the servers added by UpdateHTTPServers.
+type upstreamsNginxClient struct {
+	*mocks.MockNginxClient
+	upstreams nginxClient.Upstreams
+	added     []nginxClient.UpstreamServer
+}
+
+func (c *upstreamsNginxClient) UpdateHTTPServers(_ context.Context, _ string, _ []nginxClient.UpstreamServer) ([]nginxClient.UpstreamServer, []nginxClient.UpstreamServer, []nginxClient.UpstreamServer, error) {
+	return c.added, nil, nil, nil
+}
+
+func (c *upstreamsNginxClient) GetUpstreams(_ context.Context) (*nginxClient.Upstreams, error) {
+	return &c.upstreams, nil
+}
+
+func TestHttpBorderClient_HealthChecked(t *testing.T) {
+	tests := []struct {
+		name    string
+		peers   []nginxClient.Peer
+		added   []string
+		checked bool
+		known   bool
+	}{
+		{"checked", []nginxClient.Peer{{Server: "10.0.0.1:30080", HealthChecks: nginxClient.HealthChecks{Checks: 12}}}, nil, true, true},
+		{"checking", []nginxClient.Peer{{Server: "10.0.0.1:30080", State: "checking"}}, []string{"10.0.0.1:30080"}, true, true},
+		{"not checked", []nginxClient.Peer{{Server: "10.0.0.1:30080", State: "up"}, {Server: "10.0.0.2:30080", State: "up"}}, []string{"10.0.0.2:30080"}, false, true},
+		{"just added", []nginxClient.Peer{{Server: "10.0.0.1:30080", State: "up"}}, []string{"10.0.0.1:30080"}, false, false},
+		{"unknown upstream", nil, nil, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &upstreamsNginxClient{MockNginxClient: mocks.NewMockNginxClient(), upstreams: nginxClient.Upstreams{}}
+			if test.peers != nil {
+				client.upstreams[upstreamName] = nginxClient.Upstream{Peers: test.peers}
+			}
+
+			for _, server := range test.added {
+				client.added = append(client.added, nginxClient.UpstreamServer{Server: server})
+			}
+
+			borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+			if err != nil {
+				t.Fatalf(`error occurred creating a new border client: %v`, err)
+			}
+
+			event := buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)
+			if err = borderClient.Update(context.Background(), event); err != nil {
+				t.Fatalf(`error occurred updating the nginx+ upstream server: %v`, err)
+			}
+
+			checked, known, err := borderClient.(HealthCheckReporter).HealthChecked(context.Background(), event)
+			if err != nil {
+				t.Fatalf(`unexpected error: %v`, err)
+			}
+
+			if checked != test.checked || known != test.known {
+				t.Errorf(`expected checked %v and known %v, got %v and %v`, test.checked, test.known, checked, known)
+			}
+		})
+	}
+}
+
+func TestHttpBorderClient_HealthCheckedIsUnknownWithoutTheUpstreams(t *testing.T) {
+	borderClient, _, err := buildBorderClient(ClientTypeNginxHttp)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	_, known, err := borderClient.(HealthCheckReporter).HealthChecked(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxHttp))
+	if err != nil || known {
+		t.Fatalf(`expected the health checks to be unknown, got %v, %v`, known, err)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// keyValKeyExistsCode is how the NGINX Plus client reports that a key being added already exists, e.g. when it was added
+	// by the update of another upstream of the Service.
+	keyValKeyExistsCode = "error.code=KeyvalKeyExists"
+
+	// keyValKeyNotFoundCode is how the NGINX Plus client reports that a key being deleted does not exist.
+	keyValKeyNotFoundCode = "error.code=KeyvalKeyNotFound"
+)
+
+// NginxKeyValsReaderInterface defines the functions of the NGINX Plus client returning the key-value pairs of a keyval zone.
+type NginxKeyValsReaderInterface interface {
+	// GetKeyValPairs returns the key-value pairs of an HTTP keyval zone.
+	GetKeyValPairs(ctx context.Context, zone string) (nginxClient.KeyValPairs, error)
+
+	// GetStreamKeyValPairs returns the key-value pairs of a stream keyval zone.
+	GetStreamKeyValPairs(ctx context.Context, zone string) (nginxClient.KeyValPairs, error)
+}
+
+// NginxKeyValsInterface defines the functions of the NGINX Plus client used to write the node addresses to a keyval zone,
+// see core.KeyValZone. It is optional, the keyval zones of the clients that do not implement it are not written.
+type NginxKeyValsInterface interface {
+	NginxKeyValsReaderInterface
+
+	// AddKeyValPair adds a key-value pair to an HTTP keyval zone.
+	AddKeyValPair(ctx context.Context, zone string, key string, val string) error
+
+	// AddStreamKeyValPair adds a key-value pair to a stream keyval zone.
+	AddStreamKeyValPair(ctx context.Context, zone string, key string, val string) error
+
+	// DeleteKeyValuePair deletes a key from an HTTP keyval zone.
+	DeleteKeyValuePair(ctx context.Context, zone string, key string) error
+
+	// DeleteStreamKeyValuePair deletes a key from a stream keyval zone.
+	DeleteStreamKeyValuePair(ctx context.Context, zone string, key string) error
+}
+
+// KeyValUpdater is implemented by the Border Clients that write the node addresses of the upstream servers to the keyval
+// zone of the event, see core.KeyValZone.
+type KeyValUpdater interface {
+
+	// UpdateKeyVals adds the node addresses of the servers of the event to its keyval zone, and deletes the keys added
+	// for the Service whose node is no longer a server.
+	UpdateKeyVals(ctx context.Context, event *core.ServerUpdateEvent) error
+
+	// DeleteKeyVals deletes the node addresses of the servers of the event from its keyval zone, if they were added for the Service.
+	DeleteKeyVals(ctx context.Context, event *core.ServerUpdateEvent) error
+}
+
+// UpdateKeyVals adds the node addresses of the servers of the event to its keyval zone, see updateKeyVals.
+func (hbc *NginxHttpBorderClient) UpdateKeyVals(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxHttpBorderClient::UpdateKeyVals")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	return updateKeyVals(ctx, hbc.nginxClient, event)
+}
+
+// DeleteKeyVals deletes the node addresses of the servers of the event from its keyval zone, see deleteKeyVals.
+func (hbc *NginxHttpBorderClient) DeleteKeyVals(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxHttpBorderClient::DeleteKeyVals")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	return deleteKeyVals(ctx, hbc.nginxClient, event)
+}
+
+// UpdateKeyVals adds the node addresses of the servers of the event to its keyval zone, see updateKeyVals.
+func (sbc *NginxStreamBorderClient) UpdateKeyVals(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxStreamBorderClient::UpdateKeyVals")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	return updateKeyVals(ctx, sbc.nginxClient, event)
+}
+
+// DeleteKeyVals deletes the node addresses of the servers of the event from its keyval zone, see deleteKeyVals.
+func (sbc *NginxStreamBorderClient) DeleteKeyVals(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxStreamBorderClient::DeleteKeyVals")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	return deleteKeyVals(ctx, sbc.nginxClient, event)
+}
+
+// updateKeyVals reconciles the keys of the keyval zone of the event that are owned by its Service with the node addresses
+// of its servers. A key that already exists with another value was not added by NLK for the Service, so it is left alone.
+func updateKeyVals(ctx context.Context, client NginxClientInterface, event *core.ServerUpdateEvent) error {
+	zone := event.KeyValZone
+	if zone == nil {
+		return nil
+	}
+
+	keyValsClient, ok := client.(NginxKeyValsInterface)
+	if !ok {
+		return nil
+	}
+
+	current, err := getKeyValPairs(ctx, keyValsClient, event)
+	if err != nil {
+		return err
+	}
+
+	desired := nodeAddresses(event.UpstreamServers)
+
+	var added, deleted []string
+	for _, address := range desired {
+		value, found := current[address]
+		if found {
+			if value != zone.Owner {
+				logrus.WithFields(event.LogFields()).WithFields(logrus.Fields{"zone": zone.Name, "key": address, "value": value}).
+					Debug(`updateKeyVals: the key was not added for the service, leaving it alone`)
+			}
+			continue
+		}
+
+		if err = addKeyValPair(ctx, keyValsClient, event, address); err != nil {
+			return err
+		}
+		added = append(added, address)
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, address := range desired {
+		wanted[address] = true
+	}
+
+	for key, value := range current {
+		if value != zone.Owner || wanted[key] {
+			continue
+		}
+
+		if err = deleteKeyValPair(ctx, keyValsClient, event, key); err != nil {
+			return err
+		}
+		deleted = append(deleted, key)
+	}
+
+	logrus.WithFields(event.LogFields()).WithFields(logrus.Fields{"zone": zone.Name, "added": added, "deleted": deleted}).Debug(`updateKeyVals`)
+
+	return nil
+}
+
+// deleteKeyVals deletes the node addresses of the servers of the event from its keyval zone, the keys that do not exist
+// or were not added for the Service are left alone.
+func deleteKeyVals(ctx context.Context, client NginxClientInterface, event *core.ServerUpdateEvent) error {
+	zone := event.KeyValZone
+	if zone == nil {
+		return nil
+	}
+
+	keyValsClient, ok := client.(NginxKeyValsInterface)
+	if !ok {
+		return nil
+	}
+
+	current, err := getKeyValPairs(ctx, keyValsClient, event)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range nodeAddresses(event.UpstreamServers) {
+		if current[address] != zone.Owner {
+			continue
+		}
+
+		if err = deleteKeyValPair(ctx, keyValsClient, event, address); err != nil {
+			return err
+		}
+
+		logrus.WithFields(event.LogFields()).WithFields(logrus.Fields{"zone": zone.Name, "key": address}).Debug(`deleteKeyVals`)
+	}
+
+	return nil
+}
+
+func getKeyValPairs(ctx context.Context, client NginxKeyValsInterface, event *core.ServerUpdateEvent) (nginxClient.KeyValPairs, error) {
+	zone := event.KeyValZone
+
+	var pairs nginxClient.KeyValPairs
+	var err error
+
+	if zone.Stream {
+		pairs, err = client.GetStreamKeyValPairs(ctx, zone.Name)
+	} else {
+		pairs, err = client.GetKeyValPairs(ctx, zone.Name)
+	}
+
+	if err != nil {
+		return nil, newKeyValError(event.NginxHost, OperationList, event.UpstreamName, zone.Name, "", err)
+	}
+
+	return pairs, nil
+}
+
+func addKeyValPair(ctx context.Context, client NginxKeyValsInterface, event *core.ServerUpdateEvent, key string) error {
+	zone := event.KeyValZone
+
+	var err error
+
+	if zone.Stream {
+		err = client.AddStreamKeyValPair(ctx, zone.Name, key, zone.Owner)
+	} else {
+		err = client.AddKeyValPair(ctx, zone.Name, key, zone.Owner)
+	}
+
+	if err != nil && !strings.Contains(err.Error(), keyValKeyExistsCode) {
+		return newKeyValError(event.NginxHost, OperationAdd, event.UpstreamName, zone.Name, key, err)
+	}
+
+	return nil
+}
+
+func deleteKeyValPair(ctx context.Context, client NginxKeyValsInterface, event *core.ServerUpdateEvent, key string) error {
+	zone := event.KeyValZone
+
+	var err error
+
+	if zone.Stream {
+		err = client.DeleteStreamKeyValuePair(ctx, zone.Name, key)
+	} else {
+		err = client.DeleteKeyValuePair(ctx, zone.Name, key)
+	}
+
+	if err != nil && !strings.Contains(err.Error(), keyValKeyNotFoundCode) {
+		return newKeyValError(event.NginxHost, OperationDelete, event.UpstreamName, zone.Name, key, err)
+	}
+
+	return nil
+}
+
+// nodeAddresses returns the sorted, distinct addresses of the nodes of the servers, without the ports.
+func nodeAddresses(servers core.UpstreamServers) []string {
+	distinct := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		host, _, err := net.SplitHostPort(server.Host)
+		if err != nil {
+			host = server.Host
+		}
+
+		distinct[host] = true
+	}
+
+	addresses := make([]string, 0, len(distinct))
+	for address := range distinct {
+		addresses = append(addresses, address)
+	}
+
+	sort.Strings(addresses)
+
+	return addresses
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// keyValsClient is a MockNginxClient holding the key-value pairs of the keyval zones in memory.
+type keyValsClient struct {
+	*mocks.MockNginxClient
+	zones   map[string]nginxClient.KeyValPairs
+	addErr  error
+	deleted []string
+}
+
+func newKeyValsClient(zone string, pairs nginxClient.KeyValPairs) *keyValsClient {
+	return &keyValsClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		zones:           map[string]nginxClient.KeyValPairs{zone: pairs},
+	}
+}
+
+func (c *keyValsClient) GetKeyValPairs(_ context.Context, zone string) (nginxClient.KeyValPairs, error) {
+	pairs := nginxClient.KeyValPairs{}
+	for key, value := range c.zones[zone] {
+		pairs[key] = value
+	}
+
+	return pairs, nil
+}
+
+func (c *keyValsClient) GetStreamKeyValPairs(ctx context.Context, zone string) (nginxClient.KeyValPairs, error) {
+	return c.GetKeyValPairs(ctx, "stream:"+zone)
+}
+
+func (c *keyValsClient) AddKeyValPair(_ context.Context, zone string, key string, val string) error {
+	if c.addErr != nil {
+		return c.addErr
+	}
+
+	c.zones[zone][key] = val
+
+	return nil
+}
+
+func (c *keyValsClient) AddStreamKeyValPair(ctx context.Context, zone string, key string, val string) error {
+	return c.AddKeyValPair(ctx, "stream:"+zone, key, val)
+}
+
+func (c *keyValsClient) DeleteKeyValuePair(_ context.Context, zone string, key string) error {
+	delete(c.zones[zone], key)
+	c.deleted = append(c.deleted, key)
+
+	return nil
+}
+
+func (c *keyValsClient) DeleteStreamKeyValuePair(ctx context.Context, zone string, key string) error {
+	return c.DeleteKeyValuePair(ctx, "stream:"+zone, key)
+}
+
+func TestUpdateKeyVals_ReconcilesTheKeysOfTheService(t *testing.T) {
+	client := newKeyValsClient("allowed_nodes", nginxClient.KeyValPairs{
+		"10.0.0.1": "nginx-ingress/nginx-ingress",
+		"10.0.0.3": "nginx-ingress/nginx-ingress",
+		"10.0.0.4": "added-by-hand",
+	})
+	event := buildKeyValsEvent(createEventType, &core.KeyValZone{Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"},
+		"10.0.0.1:30080", "10.0.0.2:30080", "10.0.0.4:30080")
+
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	if err = borderClient.(KeyValUpdater).UpdateKeyVals(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	expected := nginxClient.KeyValPairs{
+		"10.0.0.1": "nginx-ingress/nginx-ingress",
+		"10.0.0.2": "nginx-ingress/nginx-ingress",
+		"10.0.0.4": "added-by-hand",
+	}
+	assertKeyValPairs(t, client.zones["allowed_nodes"], expected)
+}
+
+func TestUpdateKeyVals_ToleratesTheKeysAddedConcurrently(t *testing.T) {
+	client := newKeyValsClient("stream:allowed_nodes", nginxClient.KeyValPairs{})
+	client.addErr = errors.New(`failed to add key value pair for stream allowed_nodes zone: expected 201 response, got 409. error.status=409; error.text=key already exists; error.code=KeyvalKeyExists`)
+	event := buildKeyValsEvent(createEventType, &core.KeyValZone{Name: "allowed_nodes", Stream: true, Owner: "nginx-ingress/nginx-ingress"}, "10.0.0.1:30443")
+
+	borderClient, err := NewBorderClient(ClientTypeNginxStream, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	if err = borderClient.(KeyValUpdater).UpdateKeyVals(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
+
+func TestUpdateKeyVals_ReturnsTheOtherErrors(t *testing.T) {
+	client := newKeyValsClient("allowed_nodes", nginxClient.KeyValPairs{})
+	client.addErr = errors.New(`failed to add key value pair for http allowed_nodes zone: expected 201 response, got 404. error.code=KeyvalZoneNotFound`)
+	event := buildKeyValsEvent(createEventType, &core.KeyValZone{Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"}, "10.0.0.1:30080")
+
+	err := updateKeyVals(context.Background(), client, event)
+	if err == nil {
+		t.Fatal(`expected the missing zone to be reported`)
+	}
+}
+
+func TestDeleteKeyVals_DeletesOnlyTheKeysOfTheService(t *testing.T) {
+	client := newKeyValsClient("allowed_nodes", nginxClient.KeyValPairs{
+		"10.0.0.1": "nginx-ingress/nginx-ingress",
+		"10.0.0.2": "nginx-ingress/other-service",
+		"10.0.0.3": "nginx-ingress/nginx-ingress",
+	})
+	event := buildKeyValsEvent(deletedEventType, &core.KeyValZone{Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"},
+		"10.0.0.1:30080", "10.0.0.2:30080")
+
+	if err := deleteKeyVals(context.Background(), client, event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	expected := nginxClient.KeyValPairs{
+		"10.0.0.2": "nginx-ingress/other-service",
+		"10.0.0.3": "nginx-ingress/nginx-ingress",
+	}
+	assertKeyValPairs(t, client.zones["allowed_nodes"], expected)
+}
+
+func TestUpdateKeyVals_IgnoresTheClientsWithoutKeyVals(t *testing.T) {
+	event := buildKeyValsEvent(createEventType, &core.KeyValZone{Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"}, "10.0.0.1:30080")
+
+	if err := updateKeyVals(context.Background(), mocks.NewMockNginxClient(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
+
+func buildKeyValsEvent(eventType core.EventType, zone *core.KeyValZone, hosts ...string) *core.ServerUpdateEvent {
+	servers := core.UpstreamServers{}
+	for _, host := range hosts {
+		servers = append(servers, core.NewUpstreamServer(host))
+	}
+
+	event := core.NewServerUpdateEvent(eventType, upstreamName, ClientTypeNginxHttp, servers)
+	event.KeyValZone = zone
+
+	return event
+}
+
+func assertKeyValPairs(t *testing.T, actual nginxClient.KeyValPairs, expected nginxClient.KeyValPairs) {
+	t.Helper()
+
+	if len(actual) != len(expected) {
+		t.Fatalf(`expected the keys %v, got %v`, expected, actual)
+	}
+
+	for key, value := range expected {
+		if actual[key] != value {
+			t.Fatalf(`expected the keys %v, got %v`, expected, actual)
+		}
+	}
+}
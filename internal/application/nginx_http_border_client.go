@@ -7,17 +7,57 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
 )
 
 // NginxHttpBorderClient implements the BorderClient interface for HTTP upstreams.
 type NginxHttpBorderClient struct {
 	BorderClient
 	nginxClient NginxClientInterface
-	ctx         context.Context
+
+	// added are the servers added by the last Update, see HealthChecked.
+	added map[string]bool
+}
+
+// slowStartRejections records the upstreams of each host that rejected the slow_start parameter, so each is reported once
+// and their later updates leave the slow_start out, rather than being rejected on every sync, until NLK restarts;
+// the Border Clients are created for each event, so the record is shared.
+var slowStartRejections = &rejectedUpstreams{reported: make(map[string]bool)}
+
+// rejectedUpstreams is a set of upstreams, by host, safe for concurrent use.
+type rejectedUpstreams struct {
+	lock     sync.Mutex
+	reported map[string]bool
+}
+
+// add adds the upstream of the host, and returns true if it was not in the set.
+func (r *rejectedUpstreams) add(host string, upstream string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := host + "/" + upstream
+	if r.reported[key] {
+		return false
+	}
+
+	r.reported[key] = true
+
+	return true
+}
+
+// contains determines whether the upstream of the host is in the set.
+func (r *rejectedUpstreams) contains(host string, upstream string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.reported[host+"/"+upstream]
 }
 
 // NewNginxHttpBorderClient is the Factory function for creating an NginxHttpBorderClient.
@@ -29,35 +69,99 @@ func NewNginxHttpBorderClient(client interface{}) (Interface, error) {
 
 	return &NginxHttpBorderClient{
 		nginxClient: ngxClient,
-		ctx:         context.Background(),
 	}, nil
 }
 
-// Update manages the Upstream servers for the Upstream Name given in the ServerUpdateEvent.
-func (hbc *NginxHttpBorderClient) Update(event *core.ServerUpdateEvent) error {
-	httpUpstreamServers := asNginxHttpUpstreamServers(event.UpstreamServers)
-	_, _, _, err := hbc.nginxClient.UpdateHTTPServers(hbc.ctx, event.UpstreamName, httpUpstreamServers)
+// Update manages the Upstream servers for the Upstream Name given in the ServerUpdateEvent, see updateHttpServers.
+// When the upstream does not support the slow_start of the servers, the servers are updated again without it, and without
+// it from then on, see slowStartRejections.
+// With an ownership, the servers NLK does not own are kept.
+func (hbc *NginxHttpBorderClient) Update(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxHttpBorderClient::Update")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	servers := event.UpstreamServers
+	if slowStartRejections.contains(event.NginxHost, event.UpstreamName) {
+		servers = withoutSlowStart(servers)
+	}
+
+	unowned, err := unownedHttpServers(ctx, hbc.nginxClient, event.NginxHost, event.UpstreamName, newOwnership(event), asNginxHttpUpstreamServers(servers))
+	if err != nil {
+		return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, classifyError(err))
+	}
+
+	httpUpstreamServers := append(asNginxHttpUpstreamServers(servers), unowned...)
+	added, deleted, updated, err := updateHttpServers(ctx, hbc.nginxClient, event.NginxHost, event.UpstreamName, httpUpstreamServers)
+	if err = classifyError(err); errors.Is(err, ErrSlowStartNotSupported) && hasSlowStart(servers) {
+		if slowStartRejections.add(event.NginxHost, event.UpstreamName) {
+			logrus.WithFields(event.LogFields()).
+				Warnf("NginxHttpBorderClient::Update: the upstream does not support slow_start, the servers are updated without it: %v", err)
+		}
+
+		httpUpstreamServers = append(asNginxHttpUpstreamServers(withoutSlowStart(servers)), unowned...)
+		added, deleted, updated, err = updateHttpServers(ctx, hbc.nginxClient, event.NginxHost, event.UpstreamName, httpUpstreamServers)
+		err = classifyError(err)
+	}
+
 	if err != nil {
 		return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, err)
 	}
 
+	hbc.added = make(map[string]bool, len(added))
+	for _, server := range added {
+		hbc.added[server.Server] = true
+	}
+
+	logger := logrus.WithFields(event.LogFields()).
+		WithFields(logrus.Fields{"added": len(added), "deleted": len(deleted), "updated": len(updated), "unowned": len(unowned)})
+
+	if event.HealthCheck != nil {
+		logger = logger.WithFields(logrus.Fields{
+			"externalTrafficPolicy": event.HealthCheck.ExternalTrafficPolicy,
+			"healthCheckNodePort":   event.HealthCheck.HealthCheckNodePort,
+		})
+	}
+
+	logger.Debug(`NginxHttpBorderClient::Update`)
+
 	return nil
 }
 
-// Delete deletes the Upstream server for the Upstream Name given in the ServerUpdateEvent.
-func (hbc *NginxHttpBorderClient) Delete(event *core.ServerUpdateEvent) error {
-	err := hbc.nginxClient.DeleteHTTPServer(hbc.ctx, event.UpstreamName, event.UpstreamServers[0].Host)
+// Delete deletes the Upstream server for the Upstream Name given in the ServerUpdateEvent; a server NLK does not own is
+// left alone.
+func (hbc *NginxHttpBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxHttpBorderClient::Delete")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	if !newOwnership(event).owns(event.UpstreamServers[0].Host) {
+		logrus.WithFields(event.LogFields()).WithField("server", event.UpstreamServers[0].Host).
+			Info(`NginxHttpBorderClient::Delete: the server is not owned by NLK, leaving it alone`)
+		return nil
+	}
+
+	err = hbc.nginxClient.DeleteHTTPServer(ctx, event.UpstreamName, event.UpstreamServers[0].Host)
 	if err != nil {
-		return fmt.Errorf(`error occurred deleting the nginx+ upstream server: %w`, err)
+		return fmt.Errorf(`error occurred deleting the nginx+ upstream server: %w`, newUpstreamError(event.NginxHost, OperationDelete, event.UpstreamName, event.UpstreamServers[0].Host, err))
 	}
 
+	logrus.WithFields(event.LogFields()).WithField("server", event.UpstreamServers[0].Host).Debug(`NginxHttpBorderClient::Delete`)
+
 	return nil
 }
 
 // asNginxHttpUpstreamServer converts a core.UpstreamServer to a nginxClient.UpstreamServer.
 func asNginxHttpUpstreamServer(server *core.UpstreamServer) nginxClient.UpstreamServer {
 	return nginxClient.UpstreamServer{
-		Server: server.Host,
+		Server:      server.Host,
+		Weight:      server.Weight,
+		MaxFails:    server.MaxFails,
+		FailTimeout: server.FailTimeout,
+		Route:       server.Route,
+		Service:     server.Service,
+		SlowStart:   server.SlowStart,
+		Backup:      server.Backup,
+		Drain:       server.Drain,
+		Down:        server.Down,
 	}
 }
 
@@ -71,3 +175,27 @@ func asNginxHttpUpstreamServers(servers core.UpstreamServers) []nginxClient.Upst
 
 	return upstreamServers
 }
+
+// hasSlowStart returns true if any of the servers sets a slow_start.
+func hasSlowStart(servers core.UpstreamServers) bool {
+	for _, server := range servers {
+		if server.SlowStart != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutSlowStart returns copies of the servers without their slow_start.
+func withoutSlowStart(servers core.UpstreamServers) core.UpstreamServers {
+	var copies core.UpstreamServers
+
+	for _, server := range servers {
+		copied := *server
+		copied.SlowStart = ""
+		copies = append(copies, &copied)
+	}
+
+	return copies
+}
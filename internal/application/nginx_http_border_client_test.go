@@ -6,9 +6,37 @@
 package application
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
 )
 
+// slowStartError is the error returned by the NGINX Plus client when the balancing method of the upstream does not support slow_start.
+var slowStartError = errors.New(`failed to update servers of upstreamName upstream: failed to add 10.0.0.1:30080 server to upstreamName upstream: ` +
+	`expected 201 response, got 400. error.status=400; error.text=invalid "slow_start" parameter; error.code=UpstreamConfFormatError; request_id=abc; href=https://nginx.org/en/docs/http/ngx_http_api_module.html`)
+
+// slowStartRejectingClient is a MockNginxClient whose upstreams reject the slow_start of the servers, as with hash balancing.
+type slowStartRejectingClient struct {
+	*mocks.MockNginxClient
+	calls [][]nginxClient.UpstreamServer
+}
+
+func (c *slowStartRejectingClient) UpdateHTTPServers(_ context.Context, _ string, servers []nginxClient.UpstreamServer) ([]nginxClient.UpstreamServer, []nginxClient.UpstreamServer, []nginxClient.UpstreamServer, error) {
+	c.calls = append(c.calls, servers)
+
+	for _, server := range servers {
+		if server.SlowStart != "" {
+			return nil, nil, nil, slowStartError
+		}
+	}
+
+	return servers, nil, nil, nil
+}
+
 func TestHttpBorderClient_Delete(t *testing.T) {
 	event := buildServerUpdateEvent(deletedEventType, ClientTypeNginxHttp)
 	borderClient, nginxClient, err := buildBorderClient(ClientTypeNginxHttp)
@@ -16,7 +44,7 @@ func TestHttpBorderClient_Delete(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Delete(event)
+	err = borderClient.Delete(context.Background(), event)
 	if err != nil {
 		t.Fatalf(`error occurred deleting the nginx+ upstream server: %v`, err)
 	}
@@ -33,7 +61,7 @@ func TestHttpBorderClient_Update(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Update(event)
+	err = borderClient.Update(context.Background(), event)
 	if err != nil {
 		t.Fatalf(`error occurred deleting the nginx+ upstream server: %v`, err)
 	}
@@ -58,7 +86,7 @@ func TestHttpBorderClient_DeleteReturnsError(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Delete(event)
+	err = borderClient.Delete(context.Background(), event)
 
 	if err == nil {
 		t.Fatalf(`expected an error to occur when deleting the nginx+ upstream server`)
@@ -72,9 +100,120 @@ func TestHttpBorderClient_UpdateReturnsError(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Update(event)
+	err = borderClient.Update(context.Background(), event)
 
 	if err == nil {
 		t.Fatalf(`expected an error to occur when deleting the nginx+ upstream server`)
 	}
 }
+
+func TestAsNginxHttpUpstreamServer_CarriesParameters(t *testing.T) {
+	server := buildParameterizedUpstreamServer()
+
+	converted := asNginxHttpUpstreamServer(server)
+
+	if converted.Server != server.Host || *converted.Weight != 3 || *converted.MaxFails != 2 || converted.FailTimeout != "15s" {
+		t.Fatalf(`expected the upstream server parameters to be carried over, got %#v`, converted)
+	}
+}
+
+func TestAsNginxHttpUpstreamServer_CarriesRouteAndService(t *testing.T) {
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+	server.Route = "worker-1"
+	server.Service = "_http._tcp"
+
+	converted := asNginxHttpUpstreamServer(server)
+
+	if converted.Route != "worker-1" || converted.Service != "_http._tcp" {
+		t.Fatalf(`expected the route and service to be carried over, got %#v`, converted)
+	}
+}
+
+func TestAsNginxHttpUpstreamServer_OmitsUnsetParameters(t *testing.T) {
+	converted := asNginxHttpUpstreamServer(core.NewUpstreamServer("10.0.0.1:30080"))
+
+	if converted.Weight != nil || converted.MaxFails != nil || converted.FailTimeout != "" {
+		t.Fatalf(`expected the unset parameters to be omitted, got %#v`, converted)
+	}
+}
+
+func TestAsNginxHttpUpstreamServer_CarriesSlowStart(t *testing.T) {
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+	server.SlowStart = "30s"
+
+	if converted := asNginxHttpUpstreamServer(server); converted.SlowStart != "30s" {
+		t.Fatalf(`expected the slow start to be carried over, got %#v`, converted)
+	}
+}
+
+func TestHttpBorderClient_UpdateRetriesWithoutTheRejectedSlowStart(t *testing.T) {
+	slowStartRejections = &rejectedUpstreams{reported: make(map[string]bool)}
+
+	client := &slowStartRejectingClient{MockNginxClient: mocks.NewMockNginxClient()}
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)
+	event.UpstreamServers[0].SlowStart = "30s"
+
+	if err = borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`expected the servers to be updated without slow start, got %v`, err)
+	}
+
+	if len(client.calls) != 2 || client.calls[1][0].SlowStart != "" {
+		t.Fatalf(`expected a second update without slow start, got %#v`, client.calls)
+	}
+
+	if event.UpstreamServers[0].SlowStart != "30s" {
+		t.Fatalf(`expected the servers of the event to keep their slow start`)
+	}
+}
+
+func TestHttpBorderClient_UpdateLeavesTheRejectedSlowStartOut(t *testing.T) {
+	slowStartRejections = &rejectedUpstreams{reported: make(map[string]bool)}
+
+	client := &slowStartRejectingClient{MockNginxClient: mocks.NewMockNginxClient()}
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)
+	event.UpstreamServers[0].SlowStart = "30s"
+
+	for range 2 {
+		if err = borderClient.Update(context.Background(), event); err != nil {
+			t.Fatalf(`expected the servers to be updated without slow start, got %v`, err)
+		}
+	}
+
+	// the second sync does not issue the rejected call again
+	if len(client.calls) != 3 || client.calls[2][0].SlowStart != "" {
+		t.Fatalf(`expected a single update without slow start for the second sync, got %#v`, client.calls)
+	}
+}
+
+func TestHttpBorderClient_UpdateDoesNotRetryWithoutSlowStart(t *testing.T) {
+	client := mocks.NewErroringMockClient(slowStartError)
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	// the servers have no slow start, so the error cannot be worked around
+	if err = borderClient.Update(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)); !errors.Is(err, ErrSlowStartNotSupported) {
+		t.Fatalf(`expected the slow start error, got %v`, err)
+	}
+}
+
+func TestAsNginxHttpUpstreamServer_CarriesBackup(t *testing.T) {
+	backup := true
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+	server.Backup = &backup
+
+	if converted := asNginxHttpUpstreamServer(server); converted.Backup == nil || !*converted.Backup {
+		t.Fatalf(`expected the backup flag to be carried over, got %#v`, converted)
+	}
+}
@@ -10,14 +10,15 @@ import (
 	"fmt"
 
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
 )
 
 // NginxStreamBorderClient implements the BorderClient interface for stream upstreams.
 type NginxStreamBorderClient struct {
 	BorderClient
 	nginxClient NginxClientInterface
-	ctx         context.Context
 }
 
 // NewNginxStreamBorderClient is the Factory function for creating an NginxStreamBorderClient.
@@ -29,34 +30,66 @@ func NewNginxStreamBorderClient(client interface{}) (Interface, error) {
 
 	return &NginxStreamBorderClient{
 		nginxClient: ngxClient,
-		ctx:         context.Background(),
 	}, nil
 }
 
-// Update manages the Upstream servers for the Upstream Name given in the ServerUpdateEvent.
-func (tbc *NginxStreamBorderClient) Update(event *core.ServerUpdateEvent) error {
-	streamUpstreamServers := asNginxStreamUpstreamServers(event.UpstreamServers)
-	_, _, _, err := tbc.nginxClient.UpdateStreamServers(tbc.ctx, event.UpstreamName, streamUpstreamServers)
+// Update manages the Upstream servers for the Upstream Name given in the ServerUpdateEvent, see updateStreamServers.
+// With an ownership, the servers NLK does not own are kept.
+func (tbc *NginxStreamBorderClient) Update(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxStreamBorderClient::Update")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	ignoreHttpParameters(event.UpstreamName, event.UpstreamServers)
+
+	streamUpstreamServers := asNginxStreamUpstreamServers(withDrainingServersDown(event.UpstreamName, event.UpstreamServers))
+
+	unowned, err := unownedStreamServers(ctx, tbc.nginxClient, event.NginxHost, event.UpstreamName, newOwnership(event), streamUpstreamServers)
+	if err != nil {
+		return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, classifyError(err))
+	}
+
+	added, deleted, updated, err := updateStreamServers(ctx, tbc.nginxClient, event.NginxHost, event.UpstreamName, append(streamUpstreamServers, unowned...))
 	if err != nil {
-		return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, err)
+		return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, classifyError(err))
 	}
 
+	logrus.WithFields(event.LogFields()).
+		WithFields(logrus.Fields{"added": len(added), "deleted": len(deleted), "updated": len(updated), "unowned": len(unowned)}).
+		Debug(`NginxStreamBorderClient::Update`)
+
 	return nil
 }
 
-// Delete deletes the Upstream server for the Upstream Name given in the ServerUpdateEvent.
-func (tbc *NginxStreamBorderClient) Delete(event *core.ServerUpdateEvent) error {
-	err := tbc.nginxClient.DeleteStreamServer(tbc.ctx, event.UpstreamName, event.UpstreamServers[0].Host)
+// Delete deletes the Upstream server for the Upstream Name given in the ServerUpdateEvent; a server NLK does not own is
+// left alone.
+func (tbc *NginxStreamBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "NginxStreamBorderClient::Delete")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	if !newOwnership(event).owns(event.UpstreamServers[0].Host) {
+		logrus.WithFields(event.LogFields()).WithField("server", event.UpstreamServers[0].Host).
+			Info(`NginxStreamBorderClient::Delete: the server is not owned by NLK, leaving it alone`)
+		return nil
+	}
+
+	err = tbc.nginxClient.DeleteStreamServer(ctx, event.UpstreamName, event.UpstreamServers[0].Host)
 	if err != nil {
-		return fmt.Errorf(`error occurred deleting the nginx+ upstream server: %w`, err)
+		return fmt.Errorf(`error occurred deleting the nginx+ upstream server: %w`, newUpstreamError(event.NginxHost, OperationDelete, event.UpstreamName, event.UpstreamServers[0].Host, err))
 	}
 
+	logrus.WithFields(event.LogFields()).WithField("server", event.UpstreamServers[0].Host).Debug(`NginxStreamBorderClient::Delete`)
+
 	return nil
 }
 
 func asNginxStreamUpstreamServer(server *core.UpstreamServer) nginxClient.StreamUpstreamServer {
 	return nginxClient.StreamUpstreamServer{
-		Server: server.Host,
+		Server:      server.Host,
+		Weight:      server.Weight,
+		MaxFails:    server.MaxFails,
+		FailTimeout: server.FailTimeout,
+		Backup:      server.Backup,
+		Down:        server.Down,
 	}
 }
 
@@ -69,3 +102,37 @@ func asNginxStreamUpstreamServers(servers core.UpstreamServers) []nginxClient.St
 
 	return upstreamServers
 }
+
+// withDrainingServersDown marks the draining servers down. Stream upstreams do not support the drain state, and a weight of
+// zero is not valid; a server marked down receives no new connection, while the connections already open through it are
+// kept until they close, so the servers of a cordoned or deleted node are drained nonetheless.
+func withDrainingServersDown(upstreamName string, servers core.UpstreamServers) core.UpstreamServers {
+	var result core.UpstreamServers
+
+	for _, server := range servers {
+		if server.Drain && !server.IsDown() {
+			logrus.WithFields(logrus.Fields{"upstream": upstreamName, "server": server.Host}).
+				Debug("NginxStreamBorderClient::Update: stream upstreams do not support drain, marking the server down")
+
+			isDown := true
+			down := *server
+			down.Down = &isDown
+			server = &down
+		}
+
+		result = append(result, server)
+	}
+
+	return result
+}
+
+// ignoreHttpParameters logs the route, service, and slow_start of the servers, which only apply to HTTP upstreams and are not
+// sent to stream upstreams.
+func ignoreHttpParameters(upstreamName string, servers core.UpstreamServers) {
+	for _, server := range servers {
+		if server.Route != "" || server.Service != "" || server.SlowStart != "" {
+			logrus.WithFields(logrus.Fields{"upstream": upstreamName, "server": server.Host, "route": server.Route, "service": server.Service, "slowStart": server.SlowStart}).
+				Debug("NginxStreamBorderClient::Update: the route, service, and slow_start only apply to HTTP upstreams, ignoring them")
+		}
+	}
+}
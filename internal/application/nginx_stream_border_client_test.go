@@ -6,7 +6,10 @@
 package application
 
 import (
+	"context"
 	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 )
 
 func TestTcpBorderClient_Delete(t *testing.T) {
@@ -16,7 +19,7 @@ func TestTcpBorderClient_Delete(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Delete(event)
+	err = borderClient.Delete(context.Background(), event)
 	if err != nil {
 		t.Fatalf(`error occurred deleting the nginx+ upstream server: %v`, err)
 	}
@@ -33,7 +36,7 @@ func TestTcpBorderClient_Update(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Update(event)
+	err = borderClient.Update(context.Background(), event)
 	if err != nil {
 		t.Fatalf(`error occurred deleting the nginx+ upstream server: %v`, err)
 	}
@@ -58,7 +61,7 @@ func TestTcpBorderClient_DeleteReturnsError(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Delete(event)
+	err = borderClient.Delete(context.Background(), event)
 
 	if err == nil {
 		t.Fatalf(`expected an error to occur when deleting the nginx+ upstream server`)
@@ -72,9 +75,47 @@ func TestTcpBorderClient_UpdateReturnsError(t *testing.T) {
 		t.Fatalf(`error occurred creating a new border client: %v`, err)
 	}
 
-	err = borderClient.Update(event)
+	err = borderClient.Update(context.Background(), event)
 
 	if err == nil {
 		t.Fatalf(`expected an error to occur when deleting the nginx+ upstream server`)
 	}
 }
+
+func TestAsNginxStreamUpstreamServer_CarriesParameters(t *testing.T) {
+	server := buildParameterizedUpstreamServer()
+
+	converted := asNginxStreamUpstreamServer(server)
+
+	if converted.Server != server.Host || *converted.Weight != 3 || *converted.MaxFails != 2 || converted.FailTimeout != "15s" {
+		t.Fatalf(`expected the upstream server parameters to be carried over, got %#v`, converted)
+	}
+}
+
+func TestAsNginxStreamUpstreamServer_IgnoresRouteAndService(t *testing.T) {
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+	server.Route = "worker-1"
+	server.Service = "_http._tcp"
+
+	converted := asNginxStreamUpstreamServer(server)
+
+	if converted.Service != "" {
+		t.Fatalf(`expected the service to be ignored, got %#v`, converted)
+	}
+}
+
+func TestWithDrainingServersDown_MarksDrainingServersDown(t *testing.T) {
+	draining := core.NewUpstreamServer("10.0.0.2:30080")
+	draining.Drain = true
+	servers := core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080"), draining}
+
+	result := withDrainingServersDown(upstreamName, servers)
+
+	if len(result) != 2 || result[0].IsDown() || !result[1].IsDown() {
+		t.Fatalf(`expected the draining server to be kept, and marked down, got %#v`, result)
+	}
+
+	if draining.Down != nil {
+		t.Fatalf(`expected the server of the event to be left unchanged`)
+	}
+}
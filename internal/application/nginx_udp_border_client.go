@@ -0,0 +1,28 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"fmt"
+)
+
+// NginxUdpBorderClient implements the BorderClient interface for stream upstreams proxied with `proto udp`.
+// The NGINX Plus API does not distinguish UDP from TCP stream upstreams, so the stream calls are reused.
+type NginxUdpBorderClient struct {
+	*NginxStreamBorderClient
+}
+
+// NewNginxUdpBorderClient is the Factory function for creating an NginxUdpBorderClient.
+func NewNginxUdpBorderClient(client interface{}) (Interface, error) {
+	streamBorderClient, err := NewNginxStreamBorderClient(client)
+	if err != nil {
+		return nil, fmt.Errorf(`error occurred creating the udp border client: %w`, err)
+	}
+
+	return &NginxUdpBorderClient{
+		NginxStreamBorderClient: streamBorderClient.(*NginxStreamBorderClient),
+	}, nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUdpBorderClient_Delete(t *testing.T) {
+	event := buildServerUpdateEvent(deletedEventType, ClientTypeNginxUdp)
+	borderClient, nginxClient, err := buildBorderClient(ClientTypeNginxUdp)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	err = borderClient.Delete(context.Background(), event)
+	if err != nil {
+		t.Fatalf(`error occurred deleting the nginx+ upstream server: %v`, err)
+	}
+
+	if !nginxClient.CalledFunctions["DeleteStreamServer"] {
+		t.Fatalf(`expected DeleteStreamServer to be called`)
+	}
+}
+
+func TestUdpBorderClient_Update(t *testing.T) {
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxUdp)
+	borderClient, nginxClient, err := buildBorderClient(ClientTypeNginxUdp)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	err = borderClient.Update(context.Background(), event)
+	if err != nil {
+		t.Fatalf(`error occurred updating the nginx+ upstream server: %v`, err)
+	}
+
+	if !nginxClient.CalledFunctions["UpdateStreamServers"] {
+		t.Fatalf(`expected UpdateStreamServers to be called`)
+	}
+}
+
+func TestUdpBorderClient_BadNginxClient(t *testing.T) {
+	var emptyInterface interface{}
+	_, err := NewBorderClient(ClientTypeNginxUdp, emptyInterface)
+	if err == nil {
+		t.Fatalf(`expected an error to occur when creating a new border client`)
+	}
+}
+
+func TestUdpBorderClient_UpdateReturnsError(t *testing.T) {
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxUdp)
+	borderClient, _, err := buildTerrorizingBorderClient(ClientTypeNginxUdp)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	err = borderClient.Update(context.Background(), event)
+	if err == nil {
+		t.Fatalf(`expected an error to occur`)
+	}
+}
@@ -6,6 +6,8 @@
 package application
 
 import (
+	"context"
+
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/sirupsen/logrus"
 )
@@ -21,13 +23,13 @@ func NewNullBorderClient() (Interface, error) {
 }
 
 // Update logs a Warning. It is, after all, a NullObject Pattern implementation.
-func (nbc *NullBorderClient) Update(_ *core.ServerUpdateEvent) error {
+func (nbc *NullBorderClient) Update(_ context.Context, _ *core.ServerUpdateEvent) error {
 	logrus.Warn("NullBorderClient.Update called")
 	return nil
 }
 
 // Delete logs a Warning. It is, after all, a NullObject Pattern implementation.
-func (nbc *NullBorderClient) Delete(_ *core.ServerUpdateEvent) error {
+func (nbc *NullBorderClient) Delete(_ context.Context, _ *core.ServerUpdateEvent) error {
 	logrus.Warn("NullBorderClient.Delete called")
 	return nil
 }
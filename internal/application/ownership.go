@@ -0,0 +1,102 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// ownership determines which servers of an upstream NLK may delete, see core.ServerOwnership.
+type ownership struct {
+	owned map[string]bool
+}
+
+// newOwnership creates the ownership of the event, nil when NLK may delete every server of the upstream.
+func newOwnership(event *core.ServerUpdateEvent) *ownership {
+	if event.Ownership == nil || event.Ownership.Force {
+		return nil
+	}
+
+	owned := make(map[string]bool, len(event.Ownership.Owned))
+	for address := range event.Ownership.Owned {
+		owned[normalizeServerAddress(address)] = true
+	}
+
+	return &ownership{owned: owned}
+}
+
+// owns determines whether NLK manages the server, by its address.
+func (o *ownership) owns(address string) bool {
+	return o == nil || o.owned[normalizeServerAddress(address)]
+}
+
+// unownedHttpServers returns the current servers of the HTTP upstream that are neither desired nor owned by NLK, which
+// are kept as they are by the update of the upstream.
+func unownedHttpServers(ctx context.Context, client NginxClientInterface, host string, upstream string, owner *ownership, servers []nginxClient.UpstreamServer) ([]nginxClient.UpstreamServer, error) {
+	if owner == nil {
+		return nil, nil
+	}
+
+	reader, ok := client.(NginxReaderInterface)
+	if !ok {
+		return nil, fmt.Errorf(`the servers of upstream %s NLK does not own cannot be listed`, upstream)
+	}
+
+	current, err := reader.GetHTTPServers(ctx, upstream)
+	if err != nil {
+		return nil, newUpstreamError(host, OperationList, upstream, "", err)
+	}
+
+	desired := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		desired[normalizeServerAddress(server.Server)] = true
+	}
+
+	var unowned []nginxClient.UpstreamServer
+	for _, server := range current {
+		if !desired[normalizeServerAddress(server.Server)] && !owner.owns(server.Server) {
+			unowned = append(unowned, server)
+		}
+	}
+
+	return unowned, nil
+}
+
+// unownedStreamServers returns the current servers of the stream upstream that are neither desired nor owned by NLK, see
+// unownedHttpServers.
+func unownedStreamServers(ctx context.Context, client NginxClientInterface, host string, upstream string, owner *ownership, servers []nginxClient.StreamUpstreamServer) ([]nginxClient.StreamUpstreamServer, error) {
+	if owner == nil {
+		return nil, nil
+	}
+
+	reader, ok := client.(NginxReaderInterface)
+	if !ok {
+		return nil, fmt.Errorf(`the servers of stream upstream %s NLK does not own cannot be listed`, upstream)
+	}
+
+	current, err := reader.GetStreamServers(ctx, upstream)
+	if err != nil {
+		return nil, newUpstreamError(host, OperationList, upstream, "", err)
+	}
+
+	desired := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		desired[normalizeServerAddress(server.Server)] = true
+	}
+
+	var unowned []nginxClient.StreamUpstreamServer
+	for _, server := range current {
+		if !desired[normalizeServerAddress(server.Server)] && !owner.owns(server.Server) {
+			unowned = append(unowned, server)
+		}
+	}
+
+	return unowned, nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+func TestHttpBorderClient_UpdateKeepsTheServersNotOwned(t *testing.T) {
+	testCases := []struct {
+		name     string
+		force    bool
+		expected []string
+	}{
+		{name: "owned", expected: []string{"add 10.0.0.3:30080", "delete 10.0.0.2:30080"}},
+		{name: "forced", force: true, expected: []string{"add 10.0.0.3:30080", "delete 10.0.0.1:30080", "delete 10.0.0.2:30080", "delete 192.168.1.1:8080"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &serversNginxClient{
+				MockNginxClient: mocks.NewMockNginxClient(),
+				httpServers: []nginxClient.UpstreamServer{
+					{ID: 1, Server: "10.0.0.1:30080", Route: "node-1"},
+					{ID: 2, Server: "10.0.0.2:30080"},
+					{ID: 3, Server: "192.168.1.1:8080", Route: "manual"},
+				},
+			}
+
+			borderClient, _ := NewBorderClient(ClientTypeNginxHttp, client)
+
+			event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{core.NewUpstreamServer("10.0.0.3:30080")})
+			event.Ownership = &core.ServerOwnership{Owned: map[string]bool{"10.0.0.2:30080": true}, Force: tc.force}
+
+			if err := borderClient.Update(context.Background(), event); err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			if !reflect.DeepEqual(client.calls, tc.expected) {
+				t.Fatalf(`expected the calls %v, got %v`, tc.expected, client.calls)
+			}
+		})
+	}
+}
+
+func TestHttpBorderClient_UpdateLeavesTheRoutesOfTheServersAlone(t *testing.T) {
+	var added []nginxClient.UpstreamServer
+	client := &routeRecordingClient{serversNginxClient: &serversNginxClient{MockNginxClient: mocks.NewMockNginxClient()}, added: &added}
+
+	borderClient, _ := NewBorderClient(ClientTypeNginxHttp, client)
+
+	routed := core.NewUpstreamServer("10.0.0.2:30080")
+	routed.Route = "node-2"
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080"), routed})
+	event.Ownership = &core.ServerOwnership{}
+
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(added) != 2 || added[0].Route != "" || added[1].Route != "node-2" {
+		t.Fatalf(`expected the routes of the servers to be left alone, got %v`, added)
+	}
+}
+
+func TestStreamBorderClient_UpdateKeepsTheServersNotOwned(t *testing.T) {
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		streamServers: []nginxClient.StreamUpstreamServer{
+			{ID: 1, Server: "10.0.0.1:30443"},
+			{ID: 2, Server: "192.168.1.1:443"},
+		},
+	}
+
+	borderClient, _ := NewBorderClient(ClientTypeNginxStream, client)
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxStream, core.UpstreamServers{core.NewUpstreamServer("10.0.0.2:30443")})
+	event.Ownership = &core.ServerOwnership{Owned: map[string]bool{"10.0.0.1:30443": true}}
+
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	expected := []string{"add 10.0.0.2:30443", "delete 10.0.0.1:30443"}
+	if !reflect.DeepEqual(client.calls, expected) {
+		t.Fatalf(`expected the calls %v, got %v`, expected, client.calls)
+	}
+}
+
+func TestBorderClients_DeleteOnlyTheServersOwned(t *testing.T) {
+	testCases := []struct {
+		name       string
+		clientType string
+		server     string
+		ownership  *core.ServerOwnership
+		deleted    bool
+	}{
+		{name: "http without ownership", clientType: ClientTypeNginxHttp, server: "192.168.1.1:8080", deleted: true},
+		{name: "http recorded", clientType: ClientTypeNginxHttp, server: "10.0.0.2:30080", ownership: &core.ServerOwnership{Owned: map[string]bool{"10.0.0.2:30080": true}}, deleted: true},
+		{name: "http not owned", clientType: ClientTypeNginxHttp, server: "192.168.1.1:8080", ownership: &core.ServerOwnership{}},
+		{name: "http forced", clientType: ClientTypeNginxHttp, server: "192.168.1.1:8080", ownership: &core.ServerOwnership{Force: true}, deleted: true},
+		{name: "stream recorded", clientType: ClientTypeNginxStream, server: "10.0.0.1:30443", ownership: &core.ServerOwnership{Owned: map[string]bool{"10.0.0.1:30443": true}}, deleted: true},
+		{name: "stream not owned", clientType: ClientTypeNginxStream, server: "192.168.1.1:443", ownership: &core.ServerOwnership{}},
+		{name: "stream forced", clientType: ClientTypeNginxStream, server: "192.168.1.1:443", ownership: &core.ServerOwnership{Force: true}, deleted: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &serversNginxClient{
+				MockNginxClient: mocks.NewMockNginxClient(),
+				httpServers: []nginxClient.UpstreamServer{
+					{ID: 1, Server: "10.0.0.1:30080"},
+					{ID: 2, Server: "10.0.0.2:30080"},
+					{ID: 3, Server: "192.168.1.1:8080"},
+				},
+			}
+
+			borderClient, _ := NewBorderClient(tc.clientType, client)
+
+			event := core.NewServerUpdateEvent(core.Deleted, upstreamName, tc.clientType, core.UpstreamServers{core.NewUpstreamServer(tc.server)})
+			event.Ownership = tc.ownership
+
+			if err := borderClient.Delete(context.Background(), event); err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			if deleted := len(client.calls) == 1; deleted != tc.deleted {
+				t.Fatalf(`expected the server to be deleted: %t, got the calls %v`, tc.deleted, client.calls)
+			}
+		})
+	}
+}
+
+// routeRecordingClient is a serversNginxClient recording the servers added.
+type routeRecordingClient struct {
+	*serversNginxClient
+	added *[]nginxClient.UpstreamServer
+}
+
+func (c *routeRecordingClient) AddHTTPServer(ctx context.Context, upstream string, server nginxClient.UpstreamServer) error {
+	*c.added = append(*c.added, server)
+	return c.serversNginxClient.AddHTTPServer(ctx, upstream, server)
+}
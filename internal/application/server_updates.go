@@ -0,0 +1,209 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// defaultServerPort is the port NGINX Plus uses for a server address without one.
+const defaultServerPort = "80"
+
+// NginxServersInterface defines the functions of the NGINX Plus client used to update the servers of an upstream in place.
+// The NGINX Plus client implements it; the clients that do not, e.g. the DryRunNginxClient, are updated with
+// UpdateHTTPServers and UpdateStreamServers instead.
+type NginxServersInterface interface {
+	// GetHTTPServers returns the servers of an HTTP upstream, with their IDs.
+	GetHTTPServers(ctx context.Context, upstream string) ([]nginxClient.UpstreamServer, error)
+
+	// AddHTTPServer adds a server to an HTTP upstream.
+	AddHTTPServer(ctx context.Context, upstream string, server nginxClient.UpstreamServer) error
+
+	// UpdateHTTPServer changes the parameters of the server of an HTTP upstream with the ID of the server.
+	UpdateHTTPServer(ctx context.Context, upstream string, server nginxClient.UpstreamServer) error
+
+	// GetStreamServers returns the servers of a stream upstream, with their IDs.
+	GetStreamServers(ctx context.Context, upstream string) ([]nginxClient.StreamUpstreamServer, error)
+
+	// AddStreamServer adds a server to a stream upstream.
+	AddStreamServer(ctx context.Context, upstream string, server nginxClient.StreamUpstreamServer) error
+
+	// UpdateStreamServer changes the parameters of the server of a stream upstream with the ID of the server.
+	UpdateStreamServer(ctx context.Context, upstream string, server nginxClient.StreamUpstreamServer) error
+}
+
+// updateHttpServers reconciles the servers of the HTTP upstream with the desired servers. The servers are matched by address,
+// so a server whose parameters changed, e.g. its weight, drain, or backup flag, is updated in place rather than deleted and
+// added again, which would reset its health state and connection counts; servers are only added and deleted when the
+// membership of the upstream changes.
+func updateHttpServers(ctx context.Context, client NginxClientInterface, host string, upstream string, servers []nginxClient.UpstreamServer) ([]nginxClient.UpstreamServer, []nginxClient.UpstreamServer, []nginxClient.UpstreamServer, error) {
+	serversClient, ok := client.(NginxServersInterface)
+	if !ok {
+		added, deleted, updated, err := client.UpdateHTTPServers(ctx, upstream, servers)
+		if err != nil {
+			return nil, nil, nil, newUpstreamError(host, OperationUpdate, upstream, "", err)
+		}
+
+		return added, deleted, updated, nil
+	}
+
+	current, err := serversClient.GetHTTPServers(ctx, upstream)
+	if err != nil {
+		return nil, nil, nil, newUpstreamError(host, OperationList, upstream, "", err)
+	}
+
+	currentByAddress := make(map[string]nginxClient.UpstreamServer, len(current))
+	for _, server := range current {
+		currentByAddress[normalizeServerAddress(server.Server)] = server
+	}
+
+	var added, deleted, updated []nginxClient.UpstreamServer
+	desired := make(map[string]bool, len(servers))
+
+	for _, server := range servers {
+		address := normalizeServerAddress(server.Server)
+		if desired[address] {
+			continue
+		}
+
+		desired[address] = true
+
+		existing, found := currentByAddress[address]
+		switch {
+		case !found:
+			if err = serversClient.AddHTTPServer(ctx, upstream, server); err != nil {
+				return nil, nil, nil, newUpstreamError(host, OperationAdd, upstream, server.Server, err)
+			}
+			added = append(added, server)
+
+		case !sameHttpServerParameters(server, existing):
+			// the address as NGINX Plus reports it, so the server is not seen as changed on the next update
+			server.ID = existing.ID
+			server.Server = existing.Server
+			if err = serversClient.UpdateHTTPServer(ctx, upstream, server); err != nil {
+				return nil, nil, nil, newUpstreamError(host, OperationUpdate, upstream, server.Server, err)
+			}
+			updated = append(updated, server)
+		}
+	}
+
+	for _, server := range current {
+		if desired[normalizeServerAddress(server.Server)] {
+			continue
+		}
+
+		if err = client.DeleteHTTPServer(ctx, upstream, server.Server); err != nil {
+			return nil, nil, nil, newUpstreamError(host, OperationDelete, upstream, server.Server, err)
+		}
+		deleted = append(deleted, server)
+	}
+
+	return added, deleted, updated, nil
+}
+
+// updateStreamServers reconciles the servers of the stream upstream with the desired servers, see updateHttpServers.
+func updateStreamServers(ctx context.Context, client NginxClientInterface, host string, upstream string, servers []nginxClient.StreamUpstreamServer) ([]nginxClient.StreamUpstreamServer, []nginxClient.StreamUpstreamServer, []nginxClient.StreamUpstreamServer, error) {
+	serversClient, ok := client.(NginxServersInterface)
+	if !ok {
+		added, deleted, updated, err := client.UpdateStreamServers(ctx, upstream, servers)
+		if err != nil {
+			return nil, nil, nil, newUpstreamError(host, OperationUpdate, upstream, "", err)
+		}
+
+		return added, deleted, updated, nil
+	}
+
+	current, err := serversClient.GetStreamServers(ctx, upstream)
+	if err != nil {
+		return nil, nil, nil, newUpstreamError(host, OperationList, upstream, "", err)
+	}
+
+	currentByAddress := make(map[string]nginxClient.StreamUpstreamServer, len(current))
+	for _, server := range current {
+		currentByAddress[normalizeServerAddress(server.Server)] = server
+	}
+
+	var added, deleted, updated []nginxClient.StreamUpstreamServer
+	desired := make(map[string]bool, len(servers))
+
+	for _, server := range servers {
+		address := normalizeServerAddress(server.Server)
+		if desired[address] {
+			continue
+		}
+
+		desired[address] = true
+
+		existing, found := currentByAddress[address]
+		switch {
+		case !found:
+			if err = serversClient.AddStreamServer(ctx, upstream, server); err != nil {
+				return nil, nil, nil, newUpstreamError(host, OperationAdd, upstream, server.Server, err)
+			}
+			added = append(added, server)
+
+		case !sameStreamServerParameters(server, existing):
+			server.ID = existing.ID
+			server.Server = existing.Server
+			if err = serversClient.UpdateStreamServer(ctx, upstream, server); err != nil {
+				return nil, nil, nil, newUpstreamError(host, OperationUpdate, upstream, server.Server, err)
+			}
+			updated = append(updated, server)
+		}
+	}
+
+	for _, server := range current {
+		if desired[normalizeServerAddress(server.Server)] {
+			continue
+		}
+
+		if err = client.DeleteStreamServer(ctx, upstream, server.Server); err != nil {
+			return nil, nil, nil, newUpstreamError(host, OperationDelete, upstream, server.Server, err)
+		}
+		deleted = append(deleted, server)
+	}
+
+	return added, deleted, updated, nil
+}
+
+// normalizeServerAddress returns the address of a server in a canonical form, so the desired servers match the servers
+// reported by NGINX Plus: the port defaults to 80, IP addresses are formatted by net.IP, and host names are lowercased.
+func normalizeServerAddress(address string) string {
+	if strings.HasPrefix(address, "unix:") {
+		return address
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = strings.Trim(address, "[]"), defaultServerPort
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	} else {
+		host = strings.ToLower(host)
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// sameHttpServerParameters compares the parameters NLK manages of the desired and current HTTP servers.
+func sameHttpServerParameters(server nginxClient.UpstreamServer, current nginxClient.UpstreamServer) bool {
+	return sameParameters(server.Weight, server.MaxFails, server.FailTimeout, server.Drain, current.Weight, current.MaxFails, current.FailTimeout, current.Drain) &&
+		server.Route == current.Route && server.Service == current.Service &&
+		stringOr(server.SlowStart, defaultSlowStart) == stringOr(current.SlowStart, defaultSlowStart) &&
+		sameBackup(server.Backup, current.Backup) && sameDown(server.Down, current.Down)
+}
+
+// sameStreamServerParameters compares the parameters NLK manages of the desired and current stream servers.
+func sameStreamServerParameters(server nginxClient.StreamUpstreamServer, current nginxClient.StreamUpstreamServer) bool {
+	return sameParameters(server.Weight, server.MaxFails, server.FailTimeout, false, current.Weight, current.MaxFails, current.FailTimeout, false) &&
+		sameBackup(server.Backup, current.Backup) && sameDown(server.Down, current.Down)
+}
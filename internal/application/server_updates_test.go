@@ -0,0 +1,238 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// serversNginxClient is a MockNginxClient holding the servers of an upstream, and recording the calls changing them.
+type serversNginxClient struct {
+	*mocks.MockNginxClient
+	httpServers   []nginxClient.UpstreamServer
+	streamServers []nginxClient.StreamUpstreamServer
+	calls         []string
+}
+
+func (c *serversNginxClient) GetHTTPServers(_ context.Context, _ string) ([]nginxClient.UpstreamServer, error) {
+	return c.httpServers, nil
+}
+
+func (c *serversNginxClient) AddHTTPServer(_ context.Context, _ string, server nginxClient.UpstreamServer) error {
+	c.calls = append(c.calls, "add "+server.Server)
+	return nil
+}
+
+func (c *serversNginxClient) UpdateHTTPServer(_ context.Context, _ string, server nginxClient.UpstreamServer) error {
+	c.calls = append(c.calls, "update "+server.Server)
+	return nil
+}
+
+func (c *serversNginxClient) DeleteHTTPServer(_ context.Context, _ string, server string) error {
+	c.calls = append(c.calls, "delete "+server)
+	return nil
+}
+
+func (c *serversNginxClient) GetStreamServers(_ context.Context, _ string) ([]nginxClient.StreamUpstreamServer, error) {
+	return c.streamServers, nil
+}
+
+func (c *serversNginxClient) AddStreamServer(_ context.Context, _ string, server nginxClient.StreamUpstreamServer) error {
+	c.calls = append(c.calls, "add "+server.Server)
+	return nil
+}
+
+func (c *serversNginxClient) UpdateStreamServer(_ context.Context, _ string, server nginxClient.StreamUpstreamServer) error {
+	c.calls = append(c.calls, "update "+server.Server)
+	return nil
+}
+
+func (c *serversNginxClient) DeleteStreamServer(_ context.Context, _ string, server string) error {
+	c.calls = append(c.calls, "delete "+server)
+	return nil
+}
+
+func TestHttpBorderClient_UpdateChangesTheParametersInPlace(t *testing.T) {
+	weight := 5
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		httpServers: []nginxClient.UpstreamServer{
+			{ID: 3, Server: "10.0.0.1:30080"},
+			{ID: 4, Server: "[fd00::2]:30080"},
+			{ID: 5, Server: "10.0.0.3:30080"},
+		},
+	}
+
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	draining := core.NewUpstreamServer("[fd00:0:0::2]:30080")
+	draining.Drain = true
+	weighted := core.NewUpstreamServer("10.0.0.1:30080")
+	weighted.Weight = &weight
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{
+		weighted, draining, core.NewUpstreamServer("10.0.0.4:30080"),
+	})
+
+	if err = borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the servers are updated with the addresses reported by NGINX Plus
+	expected := []string{"update 10.0.0.1:30080", "update [fd00::2]:30080", "add 10.0.0.4:30080", "delete 10.0.0.3:30080"}
+	if !reflect.DeepEqual(client.calls, expected) {
+		t.Fatalf(`expected the calls %v, got %v`, expected, client.calls)
+	}
+}
+
+func TestHttpBorderClient_UpdateLeavesTheUnchangedServersAlone(t *testing.T) {
+	weight := 1
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		httpServers:     []nginxClient.UpstreamServer{{ID: 3, Server: "10.0.0.1:30080", Weight: &weight, FailTimeout: "10s", SlowStart: "0s"}},
+	}
+
+	borderClient, _ := NewBorderClient(ClientTypeNginxHttp, client)
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")})
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(client.calls) != 0 {
+		t.Fatalf(`expected the servers with the default parameters to be left alone, got %v`, client.calls)
+	}
+}
+
+func TestStreamBorderClient_UpdateChangesTheBackupFlagInPlace(t *testing.T) {
+	primary := false
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		streamServers:   []nginxClient.StreamUpstreamServer{{ID: 7, Server: "10.0.0.1:30432", Backup: &primary}},
+	}
+
+	borderClient, _ := NewBorderClient(ClientTypeNginxStream, client)
+
+	backup := true
+	server := core.NewUpstreamServer("10.0.0.1:30432")
+	server.Backup = &backup
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxStream, core.UpstreamServers{server})
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(client.calls, []string{"update 10.0.0.1:30432"}) {
+		t.Fatalf(`expected the server to be updated in place, got %v`, client.calls)
+	}
+}
+
+func TestBorderClients_UpdateBringTheDownServersUpInPlace(t *testing.T) {
+	down := true
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		httpServers:     []nginxClient.UpstreamServer{{ID: 3, Server: "10.0.0.1:30080", Down: &down}, {ID: 4, Server: "10.0.0.2:30080"}},
+		streamServers:   []nginxClient.StreamUpstreamServer{{ID: 7, Server: "10.0.0.1:30432", Down: &down}},
+	}
+
+	httpBorderClient, _ := NewBorderClient(ClientTypeNginxHttp, client)
+
+	// the node of the first server is no longer marked down, the node of the second server now is
+	up := false
+	brought := core.NewUpstreamServer("10.0.0.1:30080")
+	brought.Down = &up
+
+	pending := core.NewUpstreamServer("10.0.0.2:30080")
+	pending.Down = &down
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{brought, pending})
+	if err := httpBorderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	streamBorderClient, _ := NewBorderClient(ClientTypeNginxStream, client)
+
+	streamBrought := core.NewUpstreamServer("10.0.0.1:30432")
+	streamBrought.Down = &up
+
+	event = core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxStream, core.UpstreamServers{streamBrought})
+	if err := streamBorderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	expected := []string{"update 10.0.0.1:30080", "update 10.0.0.2:30080", "update 10.0.0.1:30432"}
+	if !reflect.DeepEqual(client.calls, expected) {
+		t.Fatalf(`expected the down flags to be changed in place, got %v`, client.calls)
+	}
+}
+
+func TestAsNginxUpstreamServer_OnlySendsTheManagedDownFlag(t *testing.T) {
+	server := core.NewUpstreamServer("10.0.0.1:30080")
+
+	if down := asNginxHttpUpstreamServer(server).Down; down != nil {
+		t.Fatalf(`expected the down flag of an unmanaged server to be left alone, got %v`, *down)
+	}
+
+	down := true
+	server.Down = &down
+
+	if down := asNginxStreamUpstreamServer(server).Down; down == nil || !*down {
+		t.Fatalf(`expected the stream server to be sent down, got %v`, down)
+	}
+}
+
+func TestBorderClients_UpdateLeavesTheServersMarkedDownOutsideNlkAlone(t *testing.T) {
+	down := true
+	client := &serversNginxClient{
+		MockNginxClient: mocks.NewMockNginxClient(),
+		httpServers:     []nginxClient.UpstreamServer{{ID: 3, Server: "10.0.0.1:30080", Down: &down}},
+	}
+
+	borderClient, _ := NewBorderClient(ClientTypeNginxHttp, client)
+
+	event := core.NewServerUpdateEvent(core.Updated, upstreamName, ClientTypeNginxHttp, core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")})
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(client.calls) != 0 {
+		t.Fatalf(`expected the server marked down through the dashboard to be left down, got %v`, client.calls)
+	}
+}
+
+func TestNormalizeServerAddress(t *testing.T) {
+	tests := map[string]string{
+		"10.0.0.1:30080":         "10.0.0.1:30080",
+		"10.0.0.1":               "10.0.0.1:80",
+		"[fd00:0:0::1]:30080":    "[fd00::1]:30080",
+		"fd00::1":                "[fd00::1]:80",
+		"Node-1.Example.com":     "node-1.example.com:80",
+		"unix:/var/run/app.sock": "unix:/var/run/app.sock",
+	}
+
+	for address, expected := range tests {
+		if normalized := normalizeServerAddress(address); normalized != expected {
+			t.Errorf(`expected %s to be normalized to %s, got %s`, address, expected, normalized)
+		}
+	}
+}
+
+func TestNginxClient_ImplementsNginxServersInterface(t *testing.T) {
+	var client interface{} = &nginxClient.NginxClient{}
+
+	if _, ok := client.(NginxServersInterface); !ok {
+		t.Fatalf(`expected the NGINX Plus client to update the servers in place`)
+	}
+}
@@ -0,0 +1,23 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startApiSpan starts the span of the NGINX Plus API calls made for the event on its host, a child of the span of the
+// Kubernetes event the event stems from; the calls made with the returned context carry the trace headers.
+func startApiSpan(ctx context.Context, event *core.ServerUpdateEvent, name string) (context.Context, trace.Span) {
+	return instrumentation.StartSpan(ctx, event.SpanContext, name,
+		instrumentation.HostOf(event.NginxHost),
+		instrumentation.UpstreamAttribute.String(event.UpstreamName),
+	)
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+
+	// upstreamNotFoundCode is how the NGINX Plus client reports the UpstreamNotFound error code of the NGINX Plus API,
+	// the client does not export its API errors.
+	upstreamNotFoundCode = "error.code=UpstreamNotFound"
+
+	// unknownVersionCode is how the NGINX Plus client reports the UnknownVersion error code of the NGINX Plus API,
+	// returned when the host does not serve the version of the API used for it.
+	unknownVersionCode = "error.code=UnknownVersion"
+
+	// badRequestStatus is how the NGINX Plus client reports a request rejected by the NGINX Plus API.
+	badRequestStatus = "error.status=400"
+
+	// slowStartParameter is named in the text of the error returned when the NGINX Plus API rejects the slow_start of a server,
+	// as the balancing method of the upstream, e.g. hash or random, does not support it.
+	slowStartParameter = "slow_start"
+)
+
+// statusPattern matches the HTTP status of the response reported by the NGINX Plus client, e.g. "expected 200 response, got 404".
+var statusPattern = regexp.MustCompile(`response, got ([0-9]{3})`)
+
+// ErrUpstreamNotFound is returned by the Border Clients when the upstream is not defined in the NGINX Plus configuration.
+// NOTE: upstreams cannot be created with the NGINX Plus API, they must be defined, with a shared memory zone, in the configuration.
+var ErrUpstreamNotFound = errors.New("the upstream is not defined in the NGINX Plus configuration")
+
+// ErrUnsupportedApiVersion is returned when the version of the NGINX Plus API set for a host, see
+// configuration.ParseNginxPlusHost, is not supported by the host or by the NGINX Plus client.
+var ErrUnsupportedApiVersion = errors.New("the NGINX Plus API version is not supported")
+
+// ErrSlowStartNotSupported is returned when the NGINX Plus API rejects the slow_start parameter of the servers of an upstream,
+// which is not supported with the hash, ip_hash, and random balancing methods.
+var ErrSlowStartNotSupported = errors.New("the slow_start parameter is not supported by the upstream")
+
+// ErrNotFound is returned when the NGINX Plus API reports that the object of the call, other than the upstream, does not exist,
+// e.g. a keyval zone.
+var ErrNotFound = errors.New("the NGINX Plus API object was not found")
+
+// ErrInvalidParameter is returned when the NGINX Plus API rejects the call as invalid, e.g. a server parameter.
+var ErrInvalidParameter = errors.New("the NGINX Plus API rejected the call as invalid")
+
+// ErrUnauthorized is returned when the NGINX Plus API, or a proxy in front of it, refuses the credentials of the call.
+var ErrUnauthorized = errors.New("the NGINX Plus API call is not authorized")
+
+// ErrTransient is returned when the NGINX Plus API call failed in a way that retrying may fix, e.g. a 5xx response or a network error.
+var ErrTransient = errors.New("the NGINX Plus API call failed transiently")
+
+// ErrTimeout is returned when the NGINX Plus API call did not complete in time, e.g. once the time allowed to update the
+// upstream has elapsed; it also wraps ErrTransient, as the call may succeed when it is retried.
+var ErrTimeout = errors.New("the NGINX Plus API call timed out")
+
+// The operations of the NGINX Plus API calls reported by an UpstreamError.
+const (
+	OperationList   = "list"
+	OperationAdd    = "add"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// UpstreamError is returned by the Border Clients when an NGINX Plus API call fails. It names the host, the upstream, the
+// operation, and the server or keyval key of the call, so that the failures of the hosts can be told apart; its Err is
+// classified, see classifyError, so the errors above match it with errors.Is.
+type UpstreamError struct {
+
+	// Host is the NGINX Plus host the call was made to.
+	Host string
+
+	// Upstream is the name of the upstream of the call.
+	Upstream string
+
+	// Operation is the operation of the call, e.g. OperationAdd.
+	Operation string
+
+	// Server is the address of the server of the call; empty when the call concerns every server of the upstream.
+	Server string
+
+	// Zone is the keyval zone of the call, Server then being the key; empty for the calls on the servers of the upstream.
+	Zone string
+
+	// Err is the classified error of the NGINX Plus client.
+	Err error
+}
+
+// newUpstreamError creates the UpstreamError of the failed call, with the classified error of the NGINX Plus client.
+func newUpstreamError(host string, operation string, upstream string, server string, err error) *UpstreamError {
+	return &UpstreamError{
+		Host:      host,
+		Upstream:  upstream,
+		Operation: operation,
+		Server:    server,
+		Err:       classifyError(err),
+	}
+}
+
+// newKeyValError creates the UpstreamError of the failed call on the key of the keyval zone of the upstream.
+func newKeyValError(host string, operation string, upstream string, zone string, key string, err error) *UpstreamError {
+	upstreamError := newUpstreamError(host, operation, upstream, key, err)
+	upstreamError.Zone = zone
+
+	return upstreamError
+}
+
+// Call describes the failed call without its host, e.g. "add server 10.0.0.1:30080 of upstream web".
+func (e *UpstreamError) Call() string {
+	switch {
+	case e.Zone != "" && e.Server != "":
+		return fmt.Sprintf(`%s key %s of keyval zone %s of upstream %s`, e.Operation, e.Server, e.Zone, e.Upstream)
+	case e.Zone != "":
+		return fmt.Sprintf(`%s keys of keyval zone %s of upstream %s`, e.Operation, e.Zone, e.Upstream)
+	case e.Server != "":
+		return fmt.Sprintf(`%s server %s of upstream %s`, e.Operation, e.Server, e.Upstream)
+	default:
+		return fmt.Sprintf(`%s servers of upstream %s`, e.Operation, e.Upstream)
+	}
+}
+
+// Error names the host and the call, followed by the error of the NGINX Plus client.
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf(`nginx+ host %s: %s: %v`, e.Host, e.Call(), e.Err)
+}
+
+// Unwrap returns the classified error of the NGINX Plus client.
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError wraps the error with ErrUpstreamNotFound when the NGINX Plus API reports that the upstream does not exist,
+// with ErrUnsupportedApiVersion when it reports that the version of the API is unknown, and with ErrSlowStartNotSupported
+// when it rejects the slow_start parameter. The other errors are wrapped by the status of the response: ErrInvalidParameter
+// for a 400, ErrUnauthorized for a 401 or 403, ErrNotFound for a 404, ErrTimeout for a call that timed out, and ErrTransient
+// for a 429, a 5xx, or a network error. An UpstreamError is already classified.
+func classifyError(err error) error {
+	var upstreamError *UpstreamError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &upstreamError):
+		return err
+	case errors.Is(err, ErrUpstreamNotFound), errors.Is(err, ErrUnsupportedApiVersion), errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrInvalidParameter), errors.Is(err, ErrUnauthorized), errors.Is(err, ErrTransient):
+		return err
+	case strings.Contains(err.Error(), upstreamNotFoundCode):
+		return fmt.Errorf(`%w: %w`, ErrUpstreamNotFound, err)
+	case strings.Contains(err.Error(), unknownVersionCode):
+		return fmt.Errorf(`%w: %w`, ErrUnsupportedApiVersion, err)
+	case strings.Contains(err.Error(), badRequestStatus) && strings.Contains(err.Error(), slowStartParameter):
+		return fmt.Errorf(`%w: %w: %w`, ErrSlowStartNotSupported, ErrInvalidParameter, err)
+	}
+
+	status := responseStatus(err)
+
+	var urlError *url.Error
+
+	switch {
+	case status == 400:
+		return fmt.Errorf(`%w: %w`, ErrInvalidParameter, err)
+	case status == 401 || status == 403:
+		return fmt.Errorf(`%w: %w`, ErrUnauthorized, err)
+	case status == 404:
+		return fmt.Errorf(`%w: %w`, ErrNotFound, err)
+	case status == 429 || status >= 500:
+		return fmt.Errorf(`%w: %w`, ErrTransient, err)
+	case status == 0 && isTimeout(err):
+		return fmt.Errorf(`%w: %w: %w`, ErrTimeout, ErrTransient, err)
+	case status == 0 && errors.As(err, &urlError):
+		return fmt.Errorf(`%w: %w`, ErrTransient, err)
+	}
+
+	return err
+}
+
+// IsPermanent determines whether the error will not be fixed by retrying the call, e.g. an invalid parameter.
+// A missing upstream is not permanent, as it may be added to the NGINX Plus configuration at any time.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrInvalidParameter) || errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrUnsupportedApiVersion)
+}
+
+// IsHostFailure determines whether the error shows that the host is failing: the call got no response, e.g. a network
+// error or a timeout, or a 5xx response. Any other response, e.g. a 4xx for a missing upstream, shows that the host is up.
+func IsHostFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if status := responseStatus(err); status != 0 {
+		return status >= 500
+	}
+
+	return !errors.Is(err, ErrUpstreamNotFound) && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidParameter) &&
+		!errors.Is(err, ErrUnauthorized) && !errors.Is(err, ErrUnsupportedApiVersion)
+}
+
+// isTimeout determines whether the call timed out, as the deadline of its context was exceeded or the connection timed out.
+func isTimeout(err error) bool {
+	var netError net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout())
+}
+
+// responseStatus returns the HTTP status of the response reported by the error of the NGINX Plus client, zero if there is none.
+func responseStatus(err error) int {
+	match := statusPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+
+	status, _ := strconv.Atoi(match[1])
+
+	return status
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+// upstreamNotFoundError is the error returned by the NGINX Plus client for an upstream missing from the configuration.
+var upstreamNotFoundError = errors.New(`failed to get the HTTP servers of upstream upstreamName: failed to get http/upstreams/upstreamName/servers: ` +
+	`expected 200 response, got 404. error.status=404; error.text=upstream not found; error.code=UpstreamNotFound; request_id=abc; href=https://nginx.org/en/docs/http/ngx_http_api_module.html`)
+
+func TestBorderClients_ClassifyMissingUpstreams(t *testing.T) {
+	for _, clientType := range []string{ClientTypeNginxHttp, ClientTypeNginxStream, ClientTypeNginxUdp} {
+		t.Run(clientType, func(t *testing.T) {
+			borderClient, err := NewBorderClient(clientType, mocks.NewErroringMockClient(upstreamNotFoundError))
+			if err != nil {
+				t.Fatalf(`error occurred creating a new border client: %v`, err)
+			}
+
+			if err := borderClient.Update(context.Background(), buildServerUpdateEvent(createEventType, clientType)); !errors.Is(err, ErrUpstreamNotFound) {
+				t.Errorf(`expected the update to report a missing upstream, got %v`, err)
+			}
+
+			if err := borderClient.Delete(context.Background(), buildServerUpdateEvent(deletedEventType, clientType)); !errors.Is(err, ErrUpstreamNotFound) {
+				t.Errorf(`expected the delete to report a missing upstream, got %v`, err)
+			}
+		})
+	}
+}
+
+func TestClassifyError_UnknownApiVersion(t *testing.T) {
+	err := errors.New(`failed to get http/upstreams/upstreamName/servers: expected 200 response, got 404. ` +
+		`error.status=404; error.text=unknown version; error.code=UnknownVersion; request_id=abc; href=https://nginx.org/en/docs/http/ngx_http_api_module.html`)
+
+	if classified := classifyError(err); !errors.Is(classified, ErrUnsupportedApiVersion) {
+		t.Errorf(`expected the error to report an unsupported API version, got %v`, classified)
+	}
+}
+
+func TestClassifyError_LeavesOtherErrorsAlone(t *testing.T) {
+	err := errors.New(`something went horribly horribly wrong`)
+
+	if classified := classifyError(err); classified != err {
+		t.Errorf(`expected the error to be unchanged, got %v`, classified)
+	}
+
+	if classifyError(nil) != nil {
+		t.Errorf(`expected no error`)
+	}
+}
+
+func TestBorderClients_ClassifyTheNginxPlusApiErrors(t *testing.T) {
+	testCases := map[string]struct {
+		status      int
+		code        string
+		expected    error
+		permanent   bool
+		hostFailure bool
+	}{
+		"invalid parameter": {netHttp.StatusBadRequest, "UpstreamConfFormatError", ErrInvalidParameter, true, false},
+		"unauthorized":      {netHttp.StatusUnauthorized, "", ErrUnauthorized, true, false},
+		"forbidden":         {netHttp.StatusForbidden, "", ErrUnauthorized, true, false},
+		"not found":         {netHttp.StatusNotFound, "PathNotFound", ErrNotFound, true, false},
+		"missing upstream":  {netHttp.StatusNotFound, "UpstreamNotFound", ErrUpstreamNotFound, false, false},
+		"too many requests": {netHttp.StatusTooManyRequests, "", ErrTransient, false, false},
+		"server error":      {netHttp.StatusBadGateway, "", ErrTransient, false, true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(netHttp.HandlerFunc(func(writer netHttp.ResponseWriter, _ *netHttp.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				writer.WriteHeader(testCase.status)
+				_, _ = fmt.Fprintf(writer, `{"error":{"status":%d,"text":"%s","code":"%s"},"request_id":"abc","href":"https://nginx.org/en/docs/http/ngx_http_api_module.html"}`,
+					testCase.status, netHttp.StatusText(testCase.status), testCase.code)
+			}))
+			defer server.Close()
+
+			err := updateThroughStubbedApi(t, server.URL)
+
+			if !errors.Is(err, testCase.expected) {
+				t.Fatalf(`expected the error to be classified as %v, got %v`, testCase.expected, err)
+			}
+
+			if IsPermanent(err) != testCase.permanent {
+				t.Fatalf(`expected the error to be permanent: %t, got %v`, testCase.permanent, err)
+			}
+
+			if IsHostFailure(err) != testCase.hostFailure {
+				t.Fatalf(`expected the error to be a host failure: %t, got %v`, testCase.hostFailure, err)
+			}
+		})
+	}
+}
+
+func TestBorderClients_ClassifyNetworkErrorsAsTransient(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	endpoint := server.URL
+	server.Close()
+
+	err := updateThroughStubbedApi(t, endpoint)
+
+	if !errors.Is(err, ErrTransient) || IsPermanent(err) || !IsHostFailure(err) {
+		t.Fatalf(`expected the connection error to be a transient host failure, got %v`, err)
+	}
+}
+
+func TestBorderClients_ClassifyTimeoutsAsTransient(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(_ netHttp.ResponseWriter, request *netHttp.Request) {
+		<-request.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := updateThroughStubbedApiWithContext(t, ctx, server.URL)
+
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, ErrTransient) || IsPermanent(err) || !IsHostFailure(err) {
+		t.Fatalf(`expected the timeout to be a transient host failure, got %v`, err)
+	}
+}
+
+func TestClassifyError_KeepsTheClassification(t *testing.T) {
+	err := fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, classifyError(slowStartError))
+
+	classified := classifyError(err)
+	if classified != err {
+		t.Errorf(`expected the classified error to be unchanged, got %v`, classified)
+	}
+
+	if !errors.Is(classified, ErrSlowStartNotSupported) || !errors.Is(classified, ErrInvalidParameter) {
+		t.Errorf(`expected the slow_start error to be an invalid parameter, got %v`, classified)
+	}
+}
+
+func TestBorderClients_NameTheFailedCall(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(writer netHttp.ResponseWriter, request *netHttp.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if request.Method == netHttp.MethodGet {
+			_, _ = fmt.Fprint(writer, `[]`)
+			return
+		}
+
+		writer.WriteHeader(netHttp.StatusBadRequest)
+		_, _ = fmt.Fprint(writer, `{"error":{"status":400,"text":"invalid \"weight\"","code":"UpstreamConfFormatError"},"request_id":"abc"}`)
+	}))
+	defer server.Close()
+
+	client, err := nginxClient.NewNginxClient(server.URL+"/api", nginxClient.WithHTTPClient(&netHttp.Client{}))
+	if err != nil {
+		t.Fatalf(`error occurred creating the NGINX Plus client: %v`, err)
+	}
+
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)
+	event.NginxHost = server.URL
+
+	err = borderClient.Update(context.Background(), event)
+
+	var upstreamError *UpstreamError
+	if !errors.As(err, &upstreamError) {
+		t.Fatalf(`expected an UpstreamError, got %v`, err)
+	}
+
+	if upstreamError.Host != server.URL || upstreamError.Upstream != upstreamName || upstreamError.Operation != OperationAdd || upstreamError.Server != event.UpstreamServers[0].Host {
+		t.Fatalf(`expected the add of server %s of upstream %s on %s to be named, got %+v`, event.UpstreamServers[0].Host, upstreamName, server.URL, upstreamError)
+	}
+
+	if !errors.Is(err, ErrInvalidParameter) || !IsPermanent(err) {
+		t.Fatalf(`expected the error to remain classified as an invalid parameter, got %v`, err)
+	}
+
+	if expected := fmt.Sprintf(`add server %s of upstream %s`, event.UpstreamServers[0].Host, upstreamName); upstreamError.Call() != expected {
+		t.Fatalf(`expected the call to be described as %q, got %q`, expected, upstreamError.Call())
+	}
+}
+
+// updateThroughStubbedApi updates an HTTP upstream through an NGINX Plus client of the endpoint, and returns the error.
+func updateThroughStubbedApi(t *testing.T, endpoint string) error {
+	return updateThroughStubbedApiWithContext(t, context.Background(), endpoint)
+}
+
+// updateThroughStubbedApiWithContext updates an HTTP upstream through an NGINX Plus client of the endpoint with the context,
+// and returns the error.
+func updateThroughStubbedApiWithContext(t *testing.T, ctx context.Context, endpoint string) error {
+	client, err := nginxClient.NewNginxClient(endpoint+"/api", nginxClient.WithHTTPClient(&netHttp.Client{}))
+	if err != nil {
+		t.Fatalf(`error occurred creating the NGINX Plus client: %v`, err)
+	}
+
+	borderClient, err := NewBorderClient(ClientTypeNginxHttp, client)
+	if err != nil {
+		t.Fatalf(`error occurred creating a new border client: %v`, err)
+	}
+
+	err = borderClient.Update(ctx, buildServerUpdateEvent(createEventType, ClientTypeNginxHttp))
+	if err == nil {
+		t.Fatal(`expected an error`)
+	}
+
+	return err
+}
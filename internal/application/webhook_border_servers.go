@@ -0,0 +1,277 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// WebhookActionUpdate is the action of a webhook request that sets the servers of the upstream to the servers listed.
+	WebhookActionUpdate = "update"
+
+	// WebhookActionDelete is the action of a webhook request that removes the servers listed from the upstream.
+	WebhookActionDelete = "delete"
+
+	// IdempotencyKeyHeader carries the id of the event in each webhook request, the retries of a request carry the same key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// defaultWebhookAttempts is the number of times a webhook request is sent before the failure is returned to the
+	// Synchronizer, which retries the event according to its own retry settings.
+	defaultWebhookAttempts = 3
+
+	// defaultWebhookRetryDelay is the delay before the first retry of a webhook request, it doubles with each retry.
+	defaultWebhookRetryDelay = time.Millisecond * 500
+
+	// maxWebhookRetryDelay caps the delay before a retry, including the delay requested by the Retry-After header.
+	maxWebhookRetryDelay = time.Second * 5
+
+	// maxWebhookErrorLength is the maximum length of the response body included in the error of a rejected request.
+	maxWebhookErrorLength = 512
+)
+
+func init() {
+	RegisterBorderServers(core.BorderTypeWebhook, NewWebhookBorderServers)
+}
+
+// WebhookRequest is the JSON document POSTed to the URL of a host for each change of an upstream.
+type WebhookRequest struct {
+
+	// Action is either WebhookActionUpdate or WebhookActionDelete.
+	Action string `json:"action"`
+
+	// Upstream is the name of the upstream.
+	Upstream string `json:"upstream"`
+
+	// ClientType is the client type of the upstream, e.g. http, stream, or udp, see application_constants.go.
+	ClientType string `json:"clientType"`
+
+	// Servers are the desired servers of the upstream for an update, or the servers to remove for a delete.
+	Servers []WebhookServer `json:"servers"`
+}
+
+// WebhookServer is an upstream server in a WebhookRequest, the parameters left to their default are omitted.
+type WebhookServer struct {
+	Server      string `json:"server"`
+	Weight      *int   `json:"weight,omitempty"`
+	MaxFails    *int   `json:"maxFails,omitempty"`
+	FailTimeout string `json:"failTimeout,omitempty"`
+	Route       string `json:"route,omitempty"`
+	Service     string `json:"service,omitempty"`
+	SlowStart   string `json:"slowStart,omitempty"`
+	Backup      *bool  `json:"backup,omitempty"`
+	Drain       bool   `json:"drain,omitempty"`
+	Down        *bool  `json:"down,omitempty"`
+}
+
+// WebhookBorderServers implements the BorderServers that POST a WebhookRequest to the URL of each host, e.g. an agent
+// programming an NGINX OSS fleet. The host acknowledges a request with a 2xx response, 202 Accepted included; a 429, a 5xx,
+// or a network error is retried, after the delay of the Retry-After header if any, up to defaultWebhookAttempts times;
+// the other responses are failures that retrying will not fix, see webhookError.
+type WebhookBorderServers struct {
+	httpClient *http.Client
+
+	// attempts is the number of times a request is sent before the failure is returned.
+	attempts int
+
+	// retryDelay is the delay before the first retry, it doubles with each retry.
+	retryDelay time.Duration
+}
+
+// NewWebhookBorderServers is the Factory function for creating the WebhookBorderServers, see RegisterBorderServers.
+func NewWebhookBorderServers(httpClient *http.Client) (BorderServers, error) {
+	return &WebhookBorderServers{
+		httpClient: httpClient,
+		attempts:   defaultWebhookAttempts,
+		retryDelay: defaultWebhookRetryDelay,
+	}, nil
+}
+
+// BorderClient creates the WebhookBorderClient of the host, the same requests are sent for every client type.
+func (w *WebhookBorderServers) BorderClient(host string, _ string) (Interface, error) {
+	return &WebhookBorderClient{servers: w, url: host}, nil
+}
+
+// Ping calls the URL of the host with a GET, any response other than a 2xx is a failure.
+func (w *WebhookBorderServers) Ping(ctx context.Context, host string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, host, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := w.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(`unexpected status %s`, response.Status)
+	}
+
+	return nil
+}
+
+// WebhookBorderClient implements the BorderClient interface for a host of the WebhookBorderServers.
+type WebhookBorderClient struct {
+	BorderClient
+	servers *WebhookBorderServers
+	url     string
+}
+
+// Update POSTs the servers of the upstream given in the ServerUpdateEvent, the host replaces the servers of the upstream with them.
+func (wbc *WebhookBorderClient) Update(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "WebhookBorderClient::Update")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	if err = wbc.post(ctx, event, WebhookActionUpdate); err != nil {
+		return fmt.Errorf(`error occurred updating the webhook upstream servers: %w`, err)
+	}
+
+	logrus.WithFields(event.LogFields()).WithField("servers", len(event.UpstreamServers)).Debug(`WebhookBorderClient::Update`)
+
+	return nil
+}
+
+// Delete POSTs the servers given in the ServerUpdateEvent, the host removes them from the upstream.
+func (wbc *WebhookBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) (err error) {
+	ctx, span := startApiSpan(ctx, event, "WebhookBorderClient::Delete")
+	defer func() { instrumentation.EndSpan(span, err) }()
+
+	if err = wbc.post(ctx, event, WebhookActionDelete); err != nil {
+		return fmt.Errorf(`error occurred deleting the webhook upstream servers: %w`, err)
+	}
+
+	logrus.WithFields(event.LogFields()).WithField("servers", len(event.UpstreamServers)).Debug(`WebhookBorderClient::Delete`)
+
+	return nil
+}
+
+// post sends the WebhookRequest of the event until the host acknowledges it, the request is retried when the failure is
+// transient, until the attempts are exhausted or the context is done.
+func (wbc *WebhookBorderClient) post(ctx context.Context, event *core.ServerUpdateEvent, action string) error {
+	body, err := json.Marshal(newWebhookRequest(event, action))
+	if err != nil {
+		return fmt.Errorf(`error occurred serializing the webhook request: %w`, err)
+	}
+
+	delay := wbc.servers.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := wbc.send(ctx, event.Id, body)
+		if err == nil || !errors.Is(err, ErrTransient) || attempt >= wbc.servers.attempts {
+			return err
+		}
+
+		wait := min(max(delay, retryAfter), maxWebhookRetryDelay)
+		logrus.WithFields(event.LogFields()).WithError(err).Debugf(`WebhookBorderClient::post: attempt %d failed, retrying in %v`, attempt, wait)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+	}
+}
+
+// send POSTs the body to the URL of the host once, and returns the delay requested by the Retry-After header of the response.
+func (wbc *WebhookBorderClient) send(ctx context.Context, id string, body []byte) (time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, wbc.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf(`%w: %w`, ErrInvalidParameter, err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(IdempotencyKeyHeader, id)
+
+	response, err := wbc.servers.httpClient.Do(request)
+	if err != nil {
+		if isTimeout(err) {
+			return 0, fmt.Errorf(`%w: %w: %w`, ErrTimeout, ErrTransient, err)
+		}
+
+		return 0, fmt.Errorf(`%w: %w`, ErrTransient, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return 0, nil
+	}
+
+	text, _ := io.ReadAll(io.LimitReader(response.Body, maxWebhookErrorLength))
+
+	return retryAfter(response), webhookError(response.StatusCode, fmt.Errorf(`expected a 2xx response, got %s: %s`, response.Status, bytes.TrimSpace(text)))
+}
+
+// webhookError wraps the error of a rejected request by the status of the response: ErrUpstreamNotFound for a 404, as
+// the host does not know the upstream, ErrUnauthorized for a 401 or 403, ErrTransient for a 408, a 429, or a 5xx, and
+// ErrInvalidParameter for the other statuses.
+func webhookError(status int, err error) error {
+	switch {
+	case status == http.StatusNotFound:
+		return fmt.Errorf(`%w: %w`, ErrUpstreamNotFound, err)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return fmt.Errorf(`%w: %w`, ErrUnauthorized, err)
+	case status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return fmt.Errorf(`%w: %w`, ErrTransient, err)
+	default:
+		return fmt.Errorf(`%w: %w`, ErrInvalidParameter, err)
+	}
+}
+
+// retryAfter returns the delay, in seconds, requested by the Retry-After header of the response, zero if there is none.
+func retryAfter(response *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// newWebhookRequest creates the WebhookRequest of the action for the event.
+func newWebhookRequest(event *core.ServerUpdateEvent, action string) *WebhookRequest {
+	request := &WebhookRequest{
+		Action:     action,
+		Upstream:   event.UpstreamName,
+		ClientType: event.ClientType,
+		Servers:    make([]WebhookServer, 0, len(event.UpstreamServers)),
+	}
+
+	for _, server := range event.UpstreamServers {
+		request.Servers = append(request.Servers, WebhookServer{
+			Server:      server.Host,
+			Weight:      server.Weight,
+			MaxFails:    server.MaxFails,
+			FailTimeout: server.FailTimeout,
+			Route:       server.Route,
+			Service:     server.Service,
+			SlowStart:   server.SlowStart,
+			Backup:      server.Backup,
+			Drain:       server.Drain,
+			Down:        server.Down,
+		})
+	}
+
+	return request
+}
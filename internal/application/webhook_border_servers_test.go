@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestWebhookBorderServers_IsRegistered(t *testing.T) {
+	borderServers, err := NewBorderServers(core.BorderTypeWebhook, http.DefaultClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if _, ok := borderServers.(*WebhookBorderServers); !ok {
+		t.Fatalf(`expected the WebhookBorderServers, got %T`, borderServers)
+	}
+
+	if _, err = NewBorderServers("unknown", http.DefaultClient); err == nil {
+		t.Fatal(`expected an error for an unknown border type`)
+	}
+}
+
+func TestWebhookBorderClient_PostsTheServersOfTheUpstream(t *testing.T) {
+	var received WebhookRequest
+	var idempotencyKey string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey = r.Header.Get(IdempotencyKeyHeader)
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	borderClient := buildWebhookBorderClient(t, server.URL)
+
+	event := buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)
+	event.Id = "event-id"
+	event.UpstreamServers = append(event.UpstreamServers, buildParameterizedUpstreamServer())
+
+	if err := borderClient.Update(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if received.Action != WebhookActionUpdate || received.Upstream != upstreamName || received.ClientType != ClientTypeNginxHttp {
+		t.Errorf(`expected an update of the upstream, got %+v`, received)
+	}
+
+	if len(received.Servers) != 2 || received.Servers[1].Weight == nil || *received.Servers[1].Weight != 3 {
+		t.Errorf(`expected the servers and their parameters, got %+v`, received.Servers)
+	}
+
+	if idempotencyKey != "event-id" {
+		t.Errorf(`expected the id of the event as the idempotency key, got %q`, idempotencyKey)
+	}
+
+	if err := borderClient.Delete(context.Background(), event); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if received.Action != WebhookActionDelete {
+		t.Errorf(`expected a delete, got %q`, received.Action)
+	}
+}
+
+func TestWebhookBorderClient_RetriesTheTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	borderClient := buildWebhookBorderClient(t, server.URL)
+
+	if err := borderClient.Update(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxHttp)); err != nil {
+		t.Fatalf(`expected the update to be acknowledged once retried, %v`, err)
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf(`expected 3 calls, got %d`, calls.Load())
+	}
+}
+
+func TestWebhookBorderClient_ClassifiesTheRejections(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		expected error
+		calls    int32
+	}{
+		{"unknown upstream", http.StatusNotFound, ErrUpstreamNotFound, 1},
+		{"invalid request", http.StatusUnprocessableEntity, ErrInvalidParameter, 1},
+		{"forbidden", http.StatusForbidden, ErrUnauthorized, 1},
+		{"unavailable", http.StatusServiceUnavailable, ErrTransient, defaultWebhookAttempts},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				http.Error(w, "rejected", test.status)
+			}))
+			defer server.Close()
+
+			err := buildWebhookBorderClient(t, server.URL).Update(context.Background(), buildServerUpdateEvent(createEventType, ClientTypeNginxHttp))
+			if !errors.Is(err, test.expected) {
+				t.Fatalf(`expected %v, got %v`, test.expected, err)
+			}
+
+			if calls.Load() != test.calls {
+				t.Fatalf(`expected %d call(s), got %d`, test.calls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestWebhookBorderServers_Ping(t *testing.T) {
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	borderServers, _ := NewWebhookBorderServers(server.Client())
+
+	if err := borderServers.Ping(context.Background(), server.URL); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	status = http.StatusBadGateway
+	if err := borderServers.Ping(context.Background(), server.URL); err == nil {
+		t.Fatal(`expected an error when the host does not respond with a 2xx`)
+	}
+}
+
+func buildWebhookBorderClient(t *testing.T, url string) Interface {
+	borderServers, _ := NewWebhookBorderServers(http.DefaultClient)
+	borderServers.(*WebhookBorderServers).retryDelay = 0
+
+	borderClient, err := borderServers.BorderClient(url, ClientTypeNginxHttp)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return borderClient
+}
@@ -12,65 +12,124 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"time"
+
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/sirupsen/logrus"
 )
 
+// NewTlsConfig builds the tls.Config of the configured TLS mode, with the minimum TLS version and the cipher suites
+// set by the tls-min-version and tls-cipher-suites keys, which apply to every mode, including no-tls with https hosts.
 func NewTlsConfig(settings *configuration.Settings) (*tls.Config, error) {
-	logrus.Debugf("authentication::NewTlsConfig Creating TLS config for mode: '%s'", settings.TlsMode)
-	switch settings.TlsMode {
+	tlsMode := settings.CurrentTlsMode()
+	logrus.Debugf("authentication::NewTlsConfig Creating TLS config for mode: '%s'", tlsMode)
+
+	tlsConfig, err := buildTlsConfig(settings, tlsMode)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.MinVersion = settings.TlsMinVersion
+	tlsConfig.CipherSuites = settings.TlsCipherSuites
+
+	return tlsConfig, nil
+}
 
-	case configuration.NoTLS:
+// buildTlsConfig builds the tls.Config of the TLS mode.
+func buildTlsConfig(settings *configuration.Settings, tlsMode configuration.TLSMode) (*tls.Config, error) {
+	switch tlsMode {
+
+	case configuration.NoTLS: // the only mode that skips verification
 		return buildBasicTlsConfig(true), nil
 
 	case configuration.SelfSignedTLS: // needs ca cert
-		return buildSelfSignedTlsConfig(settings.Certificates)
+		return buildSelfSignedTlsConfig(settings.CertificateSource(), settings.CrlFailOpen)
 
 	case configuration.SelfSignedMutualTLS: // needs ca cert and client cert
-		return buildSelfSignedMtlsConfig(settings.Certificates)
+		return buildSelfSignedMtlsConfig(settings.CertificateSource(), settings.CrlFailOpen)
 
 	case configuration.CertificateAuthorityTLS: // needs nothing
 		return buildBasicTlsConfig(false), nil
 
 	case configuration.CertificateAuthorityMutualTLS: // needs client cert
-		return buildCaTlsConfig(settings.Certificates)
+		return buildCaTlsConfig(settings.CertificateSource())
+
+	case configuration.CertificateAuthorityPinnedMutualTLS: // needs ca cert and client cert
+		return buildCaPinnedMtlsConfig(settings.CertificateSource())
 
 	default:
-		return nil, fmt.Errorf("unknown TLS mode: %s", settings.TlsMode)
+		return nil, fmt.Errorf("unknown TLS mode: %d, valid modes are: %s", tlsMode, configuration.TLSModeNames())
 	}
 }
 
-func buildSelfSignedTlsConfig(certificates *certification.Certificates) (*tls.Config, error) {
+// buildSelfSignedTlsConfig trusts only the CA certificate(s), and rejects the server certificates revoked by the CRL(s)
+// of the CA, if any, see buildRevocationVerifier.
+func buildSelfSignedTlsConfig(certificates certification.CertificateSource, crlFailOpen bool) (*tls.Config, error) {
 	logrus.Debug("authentication::buildSelfSignedTlsConfig Building self-signed TLS config")
 	certPool, err := buildCaCertificatePool(certificates.GetCACertificate())
 	if err != nil {
 		return nil, err
 	}
 
+	verifier, err := buildRevocationVerifier(certificates, crlFailOpen)
+	if err != nil {
+		return nil, err
+	}
+
 	return &tls.Config{
-		InsecureSkipVerify: false,
-		RootCAs:            certPool,
+		InsecureSkipVerify:    false,
+		RootCAs:               certPool,
+		VerifyPeerCertificate: verifier,
 	}, nil
 }
 
-func buildSelfSignedMtlsConfig(certificates *certification.Certificates) (*tls.Config, error) {
+// buildSelfSignedMtlsConfig is buildSelfSignedTlsConfig, presenting the client certificate.
+func buildSelfSignedMtlsConfig(certificates certification.CertificateSource, crlFailOpen bool) (*tls.Config, error) {
 	logrus.Debug("authentication::buildSelfSignedMtlsConfig Building self-signed mTLS config")
 	certPool, err := buildCaCertificatePool(certificates.GetCACertificate())
 	if err != nil {
 		return nil, err
 	}
 
+	verifier, err := buildRevocationVerifier(certificates, crlFailOpen)
+	if err != nil {
+		return nil, err
+	}
+
 	certificate, err := buildCertificates(certificates.GetClientCertificate())
 	if err != nil {
 		return nil, err
 	}
 	logrus.Debugf("buildSelfSignedMtlsConfig Certificate: %v", certificate)
 
+	return &tls.Config{
+		InsecureSkipVerify:    false,
+		RootCAs:               certPool,
+		ClientAuth:            tls.RequireAndVerifyClientCert,
+		Certificates:          []tls.Certificate{certificate},
+		VerifyPeerCertificate: verifier,
+	}, nil
+}
+
+// buildCaPinnedMtlsConfig trusts only the CA certificate(s) in the CA Secret, typically a private intermediate and its root,
+// rather than the public CAs, and presents the client certificate.
+func buildCaPinnedMtlsConfig(certificates certification.CertificateSource) (*tls.Config, error) {
+	logrus.Debug("authentication::buildCaPinnedMtlsConfig Building pinned CA mTLS config")
+
+	certPool, err := buildCaCertificatePool(certificates.GetCACertificate())
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := buildCertificates(certificates.GetClientCertificate())
+	if err != nil {
+		return nil, err
+	}
+
 	return &tls.Config{
 		InsecureSkipVerify: false,
 		RootCAs:            certPool,
-		ClientAuth:         tls.RequireAndVerifyClientCert,
 		Certificates:       []tls.Certificate{certificate},
 	}, nil
 }
@@ -82,7 +141,7 @@ func buildBasicTlsConfig(skipVerify bool) *tls.Config {
 	}
 }
 
-func buildCaTlsConfig(certificates *certification.Certificates) (*tls.Config, error) {
+func buildCaTlsConfig(certificates certification.CertificateSource) (*tls.Config, error) {
 	logrus.Debug("authentication::buildCaTlsConfig")
 	certificate, err := buildCertificates(certificates.GetClientCertificate())
 	if err != nil {
@@ -95,25 +154,100 @@ func buildCaTlsConfig(certificates *certification.Certificates) (*tls.Config, er
 	}, nil
 }
 
+// buildCertificates builds the client certificate from the PEM-encoded key and certificate. The certificate may be a bundle
+// of the leaf followed by its intermediates, as cert-manager writes tls.crt; the whole chain is presented to NGINX Plus,
+// which cannot otherwise verify a client certificate issued by an intermediate CA.
 func buildCertificates(privateKeyPEM []byte, certificatePEM []byte) (tls.Certificate, error) {
 	logrus.Debug("authentication::buildCertificates")
-	return tls.X509KeyPair(certificatePEM, privateKeyPEM)
+
+	certificate, err := tls.X509KeyPair(certificatePEM, privateKeyPEM)
+	if err != nil {
+		return certificate, err
+	}
+
+	chain := make([]*x509.Certificate, 0, len(certificate.Certificate))
+	for _, der := range certificate.Certificate {
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf(`error parsing client certificate chain: %w`, err)
+		}
+
+		chain = append(chain, parsed)
+	}
+
+	certificate.Leaf = chain[0]
+	validateCertificateChain(chain)
+
+	return certificate, nil
 }
 
+// validateCertificateChain logs the subject, issuer, and expiry of the leaf, and warns about an expired leaf,
+// or a chain that is out of order, where a certificate is not issued by the certificate that follows it.
+func validateCertificateChain(chain []*x509.Certificate) {
+	leaf := chain[0]
+
+	logrus.WithFields(logrus.Fields{
+		"subject":       leaf.Subject.String(),
+		"issuer":        leaf.Issuer.String(),
+		"notAfter":      leaf.NotAfter,
+		"intermediates": len(chain) - 1,
+	}).Info("authentication::validateCertificateChain: loaded client certificate")
+
+	if time.Now().After(leaf.NotAfter) {
+		logrus.Warnf("authentication::validateCertificateChain: client certificate '%s' expired at %s", leaf.Subject, leaf.NotAfter)
+	}
+
+	for index := 0; index < len(chain)-1; index++ {
+		if err := chain[index].CheckSignatureFrom(chain[index+1]); err != nil {
+			logrus.Warnf("authentication::validateCertificateChain: certificate '%s' is not issued by the next certificate in the chain, '%s': %v",
+				chain[index].Subject, chain[index+1].Subject, err)
+		}
+	}
+}
+
+// buildCaCertificatePool builds a certificate pool from a PEM bundle; every CERTIFICATE block in the bundle is added,
+// which allows a Secret to carry intermediate as well as root certificates. Other block types are skipped.
+// An error is returned only if no certificate could be added to the pool.
 func buildCaCertificatePool(caCert []byte) (*x509.CertPool, error) {
 	logrus.Debug("authentication::buildCaCertificatePool")
-	block, _ := pem.Decode(caCert)
-	if block == nil {
-		return nil, fmt.Errorf("failed to decode PEM block containing CA certificate")
+
+	caCertPool := x509.NewCertPool()
+	certificateCount := 0
+	var parseErr error
+
+	remaining := caCert
+	for {
+		var block *pem.Block
+		block, remaining = pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			logrus.Warnf("authentication::buildCaCertificatePool: skipping PEM block of type '%s' in CA certificate", block.Type)
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			logrus.Warnf("authentication::buildCaCertificatePool: skipping unparseable CA certificate: %v", err)
+			parseErr = fmt.Errorf("error parsing certificate: %w", err)
+			continue
+		}
+
+		caCertPool.AddCert(cert)
+		certificateCount++
 	}
 
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing certificate: %w", err)
+	if certificateCount == 0 {
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		return nil, fmt.Errorf("failed to decode PEM block containing CA certificate")
 	}
 
-	caCertPool := x509.NewCertPool()
-	caCertPool.AddCert(cert)
+	logrus.Debugf("authentication::buildCaCertificatePool: added %d CA certificate(s)", certificateCount)
 
 	return caCertPool, nil
 }
@@ -6,7 +6,23 @@
 package authentication
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
@@ -35,6 +51,25 @@ func TestTlsFactory_UnspecifiedModeDefaultsToNoTls(t *testing.T) {
 	}
 }
 
+func TestTlsFactory_UnknownModeReturnsError(t *testing.T) {
+	settings := configuration.Settings{
+		TlsMode: configuration.TLSMode(42),
+	}
+
+	tlsConfig, err := NewTlsConfig(&settings)
+	if err == nil {
+		t.Fatalf(`Expected an error`)
+	}
+
+	if tlsConfig != nil {
+		t.Fatalf(`tlsConfig should be nil`)
+	}
+
+	if !strings.Contains(err.Error(), configuration.TLSModeNames()) {
+		t.Fatalf(`Expected the error to list the valid modes, got: %v`, err)
+	}
+}
+
 func TestTlsFactory_SelfSignedTlsMode(t *testing.T) {
 	certificates := make(map[string]map[string]core.SecretBytes)
 	certificates[CaCertificateSecretKey] = buildCaCertificateEntry(caCertificatePEM())
@@ -283,6 +318,222 @@ func TestTlsFactory_CaMtlsModeClientCertificateError(t *testing.T) {
 // caCertificatePEM returns a PEM-encoded CA certificate.
 // Note: The certificate is self-signed and generated explicitly for tests,
 // it is not used anywhere else.
+func TestTlsFactory_CaPinnedMtlsMode(t *testing.T) {
+	certificates := make(map[string]map[string]core.SecretBytes)
+	certificates[CaCertificateSecretKey] = buildCaCertificateEntry(caCertificatePEM())
+	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(clientKeyPEM(), clientCertificatePEM())
+
+	settings := configuration.Settings{
+		TlsMode: configuration.CertificateAuthorityPinnedMutualTLS,
+		Certificates: &certification.Certificates{
+			Certificates:               certificates,
+			CaCertificateSecretKey:     CaCertificateSecretKey,
+			ClientCertificateSecretKey: ClientCertificateSecretKey,
+		},
+	}
+
+	tlsConfig, err := NewTlsConfig(&settings)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if tlsConfig.InsecureSkipVerify {
+		t.Fatalf(`tlsConfig.InsecureSkipVerify should be false`)
+	}
+
+	if tlsConfig.RootCAs == nil {
+		t.Fatalf(`tlsConfig.RootCAs should not be nil`)
+	}
+
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf(`tlsConfig.Certificates should have 1 element, got %d`, len(tlsConfig.Certificates))
+	}
+}
+
+func TestTlsFactory_CaPinnedMtlsModeCertPoolError(t *testing.T) {
+	certificates := make(map[string]map[string]core.SecretBytes)
+	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(clientKeyPEM(), clientCertificatePEM())
+
+	settings := configuration.Settings{
+		TlsMode: configuration.CertificateAuthorityPinnedMutualTLS,
+		Certificates: &certification.Certificates{
+			Certificates:               certificates,
+			CaCertificateSecretKey:     CaCertificateSecretKey,
+			ClientCertificateSecretKey: ClientCertificateSecretKey,
+		},
+	}
+
+	_, err := NewTlsConfig(&settings)
+	if err == nil {
+		t.Fatalf(`Expected an error`)
+	}
+}
+
+func TestBuildCaCertificatePool_Bundle(t *testing.T) {
+	bundle := caCertificatePEM() + clientCertificatePEM()
+
+	pool, err := buildCaCertificatePool([]byte(bundle))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if len(pool.Subjects()) != 2 {
+		t.Fatalf(`Expected 2 certificates in the pool, got %d`, len(pool.Subjects()))
+	}
+}
+
+func TestBuildCaCertificatePool_BundleWithPrivateKey(t *testing.T) {
+	bundle := caCertificatePEM() + clientKeyPEM()
+
+	pool, err := buildCaCertificatePool([]byte(bundle))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if len(pool.Subjects()) != 1 {
+		t.Fatalf(`Expected 1 certificate in the pool, got %d`, len(pool.Subjects()))
+	}
+}
+
+func TestBuildCaCertificatePool_Garbage(t *testing.T) {
+	_, err := buildCaCertificatePool([]byte("this is not a certificate"))
+	if err == nil {
+		t.Fatalf(`Expected an error`)
+	}
+}
+
+func TestBuildCaCertificatePool_OnlyPrivateKey(t *testing.T) {
+	_, err := buildCaCertificatePool([]byte(clientKeyPEM()))
+	if err == nil {
+		t.Fatalf(`Expected an error`)
+	}
+}
+
+func TestBuildCertificates_PresentsTheIntermediates(t *testing.T) {
+	chain := generateCertificateChain(t)
+
+	certificate, err := buildCertificates([]byte(chain.leafKeyPEM), []byte(chain.leafPEM+chain.intermediatePEM))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if len(certificate.Certificate) != 2 {
+		t.Fatalf(`Expected the leaf and the intermediate in the chain, got %d certificate(s)`, len(certificate.Certificate))
+	}
+
+	if certificate.Leaf == nil || certificate.Leaf.Subject.CommonName != "nlk-client" {
+		t.Fatalf(`Expected the leaf to be the client certificate, got %v`, certificate.Leaf)
+	}
+}
+
+func TestTlsFactory_CaPinnedMtlsModeHandshakeWithIntermediate(t *testing.T) {
+	chain := generateCertificateChain(t)
+
+	certificates := make(map[string]map[string]core.SecretBytes)
+	certificates[CaCertificateSecretKey] = buildCaCertificateEntry(chain.rootPEM)
+	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(chain.leafKeyPEM, chain.leafPEM+chain.intermediatePEM)
+
+	settings := configuration.Settings{
+		TlsMode: configuration.CertificateAuthorityPinnedMutualTLS,
+		Certificates: &certification.Certificates{
+			Certificates:               certificates,
+			CaCertificateSecretKey:     CaCertificateSecretKey,
+			ClientCertificateSecretKey: ClientCertificateSecretKey,
+		},
+	}
+
+	tlsConfig, err := NewTlsConfig(&settings)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	// the server trusts only the root, as NGINX Plus does with ssl_client_certificate, so it needs the intermediate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  tlsConfig.RootCAs,
+		Certificates: []tls.Certificate{
+			chain.serverCertificate,
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf(`Expected the handshake to succeed, %v`, err)
+	}
+	_ = response.Body.Close()
+}
+
+func TestTlsFactory_CaPinnedMtlsModeFromFiles(t *testing.T) {
+	chain := generateCertificateChain(t)
+	directory := t.TempDir()
+
+	paths := certification.CertificatePaths{
+		CaCertificate:     filepath.Join(directory, "bundle.0.pem"),
+		ClientCertificate: filepath.Join(directory, "svid.0.pem"),
+		ClientKey:         filepath.Join(directory, "svid.0.key"),
+	}
+
+	for path, contents := range map[string]string{
+		paths.CaCertificate:     chain.rootPEM,
+		paths.ClientCertificate: chain.leafPEM + chain.intermediatePEM,
+		paths.ClientKey:         chain.leafKeyPEM,
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatalf(`Unexpected error: %v`, err)
+		}
+	}
+
+	settings := configuration.Settings{
+		TlsMode:          configuration.CertificateAuthorityPinnedMutualTLS,
+		Certificates:     &certification.Certificates{},
+		CertificateFiles: certification.NewFileCertificates(context.Background()),
+	}
+	settings.CertificateFiles.SetPaths(paths)
+
+	tlsConfig, err := NewTlsConfig(&settings)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if tlsConfig.RootCAs == nil {
+		t.Fatalf(`tlsConfig.RootCAs should not be nil`)
+	}
+
+	if len(tlsConfig.Certificates) != 1 || len(tlsConfig.Certificates[0].Certificate) != 2 {
+		t.Fatalf(`Expected the client certificate and its intermediate to be read from the files`)
+	}
+}
+
+func TestTlsFactory_AppliesTheTlsOptionsToEveryMode(t *testing.T) {
+	for _, mode := range []configuration.TLSMode{configuration.NoTLS, configuration.CertificateAuthorityTLS} {
+		settings := configuration.Settings{
+			TlsMode:         mode,
+			TlsMinVersion:   tls.VersionTLS13,
+			TlsCipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+		}
+
+		tlsConfig, err := NewTlsConfig(&settings)
+		if err != nil {
+			t.Fatalf(`Unexpected error: %v`, err)
+		}
+
+		if tlsConfig.MinVersion != tls.VersionTLS13 {
+			t.Errorf(`%s: expected TLS 1.3, got %x`, mode, tlsConfig.MinVersion)
+		}
+
+		if len(tlsConfig.CipherSuites) != 1 || tlsConfig.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 {
+			t.Errorf(`%s: expected the configured cipher suite, got %v`, mode, tlsConfig.CipherSuites)
+		}
+	}
+}
+
 func caCertificatePEM() string {
 	return `
 -----BEGIN CERTIFICATE-----
@@ -425,3 +676,95 @@ func buildCaCertificateEntry(certificatePEM string) map[string]core.SecretBytes
 		certification.CertificateKey: core.SecretBytes([]byte(certificatePEM)),
 	}
 }
+
+// certificateChain is a three-tier chain generated for a test: a root, an intermediate, and a client leaf issued by the
+// intermediate, with a server certificate issued by the root.
+type certificateChain struct {
+	rootPEM           string
+	intermediatePEM   string
+	leafPEM           string
+	leafKeyPEM        string
+	serverCertificate tls.Certificate
+}
+
+func generateCertificateChain(t *testing.T) certificateChain {
+	rootKey, root := generateCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "nlk-test-root"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	intermediateKey, intermediate := generateCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "nlk-test-intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, root, rootKey)
+
+	leafKey, leaf := generateCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "nlk-client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, intermediate, intermediateKey)
+
+	serverKey, server := generateCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root, rootKey)
+
+	leafKeyDER, err := x509.MarshalPKCS8PrivateKey(leafKey)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return certificateChain{
+		rootPEM:         encodePEM("CERTIFICATE", root.Raw),
+		intermediatePEM: encodePEM("CERTIFICATE", intermediate.Raw),
+		leafPEM:         encodePEM("CERTIFICATE", leaf.Raw),
+		leafKeyPEM:      encodePEM("PRIVATE KEY", leafKeyDER),
+		serverCertificate: tls.Certificate{
+			Certificate: [][]byte{server.Raw},
+			PrivateKey:  serverKey,
+		},
+	}
+}
+
+// generateCertificate generates a key and a certificate from the template, issued by the parent, or self-signed without one.
+func generateCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	template.SerialNumber = serialNumber
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return key, certificate
+}
+
+func encodePEM(blockType string, bytes []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}))
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package authentication
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+// peerCertificateVerifier is the signature of tls.Config.VerifyPeerCertificate.
+type peerCertificateVerifier func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+// buildRevocationVerifier returns a VerifyPeerCertificate callback that rejects a server certificate revoked by one of the
+// CRLs issued by the CA, or nil if there is no CRL. The callback runs after the chain has been verified, so it only adds to
+// the verification. Once a CRL has passed its next update, the certificates are rejected unless failOpen is set; either way
+// an error is logged, once per TLS config.
+func buildRevocationVerifier(certificates certification.CertificateSource, failOpen bool) (peerCertificateVerifier, error) {
+	crl := certificates.GetCaCrl()
+	if len(crl) == 0 {
+		return nil, nil
+	}
+
+	lists, err := certification.ParseRevocationLists(crl, certificates.GetCACertificate())
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]bool)
+	for _, list := range lists {
+		logrus.WithFields(logrus.Fields{
+			"issuer":     list.Issuer.String(),
+			"thisUpdate": list.ThisUpdate,
+			"nextUpdate": list.NextUpdate,
+			"revoked":    len(list.RevokedCertificateEntries),
+		}).Info("authentication::buildRevocationVerifier: loaded CRL")
+
+		for _, entry := range list.RevokedCertificateEntries {
+			revoked[revocationKey(list.RawIssuer, entry.SerialNumber.String())] = true
+		}
+	}
+
+	var reportExpired sync.Once
+
+	return func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, list := range lists {
+			if list.NextUpdate.IsZero() || time.Now().Before(list.NextUpdate) {
+				continue
+			}
+
+			reportExpired.Do(func() {
+				logrus.Errorf("authentication::buildRevocationVerifier: the CRL issued by '%s' expired at %s, the revoked certificates may not be rejected; %s is '%s'",
+					list.Issuer, list.NextUpdate, configuration.CrlExpiredPolicyKey, crlExpiredPolicyName(failOpen))
+			})
+
+			if !failOpen {
+				return fmt.Errorf(`the CRL issued by '%s' expired at %s, and %s is '%s'`, list.Issuer, list.NextUpdate, configuration.CrlExpiredPolicyKey, configuration.CrlFailClosed)
+			}
+		}
+
+		for _, chain := range verifiedChains {
+			for _, certificate := range chain {
+				if revoked[revocationKey(certificate.RawIssuer, certificate.SerialNumber.String())] {
+					return fmt.Errorf(`the certificate '%s' (serial %s) has been revoked by '%s'`, certificate.Subject, certificate.SerialNumber, certificate.Issuer)
+				}
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// revocationKey identifies a certificate by its issuer and serial number.
+func revocationKey(rawIssuer []byte, serialNumber string) string {
+	return string(rawIssuer) + "/" + serialNumber
+}
+
+// crlExpiredPolicyName returns the name of the ca-crl-expired-policy value.
+func crlExpiredPolicyName(failOpen bool) string {
+	if failOpen {
+		return configuration.CrlFailOpen
+	}
+
+	return configuration.CrlFailClosed
+}
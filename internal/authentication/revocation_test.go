@@ -0,0 +1,197 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestTlsFactory_SelfSignedTlsModeRejectsARevokedServerCertificate(t *testing.T) {
+	authority := generateCrlAuthority(t)
+
+	for _, test := range []struct {
+		name     string
+		revoked  []*big.Int
+		succeeds bool
+	}{
+		{"not revoked", []*big.Int{big.NewInt(42)}, true},
+		{"revoked", []*big.Int{authority.server.SerialNumber}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			crl := authority.revocationList(t, time.Now().Add(time.Hour), test.revoked...)
+
+			for _, mode := range []configuration.TLSMode{configuration.SelfSignedTLS, configuration.SelfSignedMutualTLS} {
+				err := authority.handshake(t, mode, crl, false)
+				if test.succeeds && err != nil {
+					t.Fatalf(`Expected the handshake to succeed in mode '%s', %v`, mode, err)
+				}
+
+				if !test.succeeds && (err == nil || !strings.Contains(err.Error(), "has been revoked")) {
+					t.Fatalf(`Expected the revoked certificate to be rejected in mode '%s', got %v`, mode, err)
+				}
+			}
+		})
+	}
+}
+
+func TestTlsFactory_ExpiredCrlPolicy(t *testing.T) {
+	authority := generateCrlAuthority(t)
+	crl := authority.revocationList(t, time.Now().Add(-time.Minute))
+
+	if err := authority.handshake(t, configuration.SelfSignedTLS, crl, false); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf(`Expected the handshake to fail closed with an expired CRL, got %v`, err)
+	}
+
+	if err := authority.handshake(t, configuration.SelfSignedTLS, crl, true); err != nil {
+		t.Fatalf(`Expected the handshake to fail open with an expired CRL, %v`, err)
+	}
+}
+
+func TestTlsFactory_RejectsAnInvalidCrl(t *testing.T) {
+	authority := generateCrlAuthority(t)
+	other := generateCrlAuthority(t)
+
+	for name, crl := range map[string]string{
+		"malformed":        "this is not a CRL",
+		"another issuer":   other.revocationList(t, time.Now().Add(time.Hour)),
+		"malformed in PEM": encodePEM("X509 CRL", []byte("this is not a CRL")),
+	} {
+		settings := authority.settings(configuration.SelfSignedTLS, crl, false)
+		if _, err := NewTlsConfig(settings); err == nil {
+			t.Errorf(`Expected an error for a %s CRL`, name)
+		}
+	}
+}
+
+// crlAuthority is a root allowed to sign CRLs, with a server certificate and a client certificate it issued.
+type crlAuthority struct {
+	key    *ecdsa.PrivateKey
+	root   *x509.Certificate
+	server *x509.Certificate
+
+	serverKey *ecdsa.PrivateKey
+	clientPEM string
+	keyPEM    string
+}
+
+func generateCrlAuthority(t *testing.T) crlAuthority {
+	rootKey, root := generateCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "nlk-test-root"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+
+	serverKey, server := generateCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root, rootKey)
+
+	clientKey, client := generateCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "nlk-client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, root, rootKey)
+
+	clientKeyDER, err := x509.MarshalPKCS8PrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return crlAuthority{
+		key:       rootKey,
+		root:      root,
+		server:    server,
+		serverKey: serverKey,
+		clientPEM: encodePEM("CERTIFICATE", client.Raw),
+		keyPEM:    encodePEM("PRIVATE KEY", clientKeyDER),
+	}
+}
+
+// revocationList returns a PEM-encoded CRL of the root, revoking the serial numbers, whose next update is at nextUpdate.
+func (a crlAuthority) revocationList(t *testing.T, nextUpdate time.Time, serialNumbers ...*big.Int) string {
+	template := &x509.RevocationList{
+		Number:     big.NewInt(time.Now().UnixNano()),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: nextUpdate,
+	}
+
+	for _, serialNumber := range serialNumbers {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   serialNumber,
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, a.root, a.key)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return encodePEM("X509 CRL", der)
+}
+
+func (a crlAuthority) settings(mode configuration.TLSMode, crl string, failOpen bool) *configuration.Settings {
+	caEntry := buildCaCertificateEntry(encodePEM("CERTIFICATE", a.root.Raw))
+	caEntry[certification.CaCrlKey] = core.SecretBytes(crl)
+
+	certificates := make(map[string]map[string]core.SecretBytes)
+	certificates[CaCertificateSecretKey] = caEntry
+	certificates[ClientCertificateSecretKey] = buildClientCertificateEntry(a.keyPEM, a.clientPEM)
+
+	return &configuration.Settings{
+		TlsMode:     mode,
+		CrlFailOpen: failOpen,
+		Certificates: &certification.Certificates{
+			Certificates:               certificates,
+			CaCertificateSecretKey:     CaCertificateSecretKey,
+			ClientCertificateSecretKey: ClientCertificateSecretKey,
+		},
+	}
+}
+
+// handshake sends a request to a server presenting the server certificate, with the TLS config of the mode.
+func (a crlAuthority) handshake(t *testing.T, mode configuration.TLSMode, crl string, failOpen bool) error {
+	tlsConfig, err := NewTlsConfig(a.settings(mode, crl, failOpen))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{a.server.Raw}, PrivateKey: a.serverKey}},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
@@ -11,6 +11,8 @@ package certification
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -30,8 +32,35 @@ const (
 
 	// CertificateKeyKey is the key for the certificate key in the Secret.
 	CertificateKeyKey = "tls.key"
+
+	// CaCrlKey is the key for the optional certificate revocation list(s) in the CA Secret, see ParseRevocationLists.
+	CaCrlKey = "ca-crl"
+
+	// ApiAuthUserKey is the key for the NGINX Plus API basic auth user in the API credentials Secret.
+	ApiAuthUserKey = "api-auth-user"
+
+	// ApiAuthPasswordKey is the key for the NGINX Plus API basic auth password in the API credentials Secret.
+	ApiAuthPasswordKey = "api-auth-password"
+
+	// ApiAuthTokenKey is the key for the NGINX Plus API bearer token in the API credentials Secret.
+	ApiAuthTokenKey = "api-auth-token"
 )
 
+// CertificateSource provides the certificates and keys used to build a tls.Config, see authentication.NewTlsConfig.
+// Certificates reads them from Secrets, FileCertificates from mounted files.
+type CertificateSource interface {
+
+	// GetCACertificate returns the PEM-encoded CA certificate(s).
+	GetCACertificate() core.SecretBytes
+
+	// GetClientCertificate returns the PEM-encoded client key, and the client certificate followed by any intermediates.
+	GetClientCertificate() (core.SecretBytes, core.SecretBytes)
+
+	// GetCaCrl returns the certificate revocation list(s) issued by the CA, if any.
+	GetCaCrl() core.SecretBytes
+}
+
+// Certificates is the CertificateSource that reads the certificates and keys from the Secrets named by the ConfigMap.
 type Certificates struct {
 	Certificates map[string]map[string]core.SecretBytes
 
@@ -44,6 +73,9 @@ type Certificates struct {
 	// ClientCertificateSecretKey is the name of the Secret that contains the Client certificate.
 	ClientCertificateSecretKey string
 
+	// ApiAuthSecretKey is the name of the Secret that contains the credentials for the NGINX Plus API.
+	ApiAuthSecretKey string
+
 	// informer is the SharedInformer used to watch for changes to the Secrets .
 	informer cache.SharedInformer
 
@@ -52,32 +84,103 @@ type Certificates struct {
 
 	// eventHandlerRegistration is the object used to track the event handlers with the SharedInformer.
 	eventHandlerRegistration cache.ResourceEventHandlerRegistration
+
+	// lock guards the Certificates map and the subscribers.
+	lock sync.RWMutex
+
+	// subscribers are the callbacks invoked after the Secrets have changed.
+	subscribers []func()
+
+	// requiredSecrets are the names of the Secrets required by the current TLS mode.
+	requiredSecrets map[string]bool
+
+	// ExpiryCheckInterval is the interval at which the expiry of the certificates is checked by Run, see CheckExpiry;
+	// the expiry is also checked each time the Secrets change.
+	ExpiryCheckInterval time.Duration
+
+	// expiry tracks the expiry of the CA and client certificates.
+	expiry *expiryTracker
 }
 
 // NewCertificates factory method that returns a new Certificates object.
 func NewCertificates(ctx context.Context, k8sClient kubernetes.Interface) *Certificates {
 	return &Certificates{
-		k8sClient:    k8sClient,
-		Context:      ctx,
-		Certificates: nil,
+		k8sClient:           k8sClient,
+		Context:             ctx,
+		Certificates:        nil,
+		ExpiryCheckInterval: DefaultExpiryCheckInterval,
+		expiry:              newExpiryTracker(),
 	}
 }
 
 // GetCACertificate returns the Certificate Authority certificate.
 func (c *Certificates) GetCACertificate() core.SecretBytes {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	bytes := c.Certificates[c.CaCertificateSecretKey][CertificateKey]
 
 	return bytes
 }
 
+// GetCaCrl returns the certificate revocation list(s) of the CA Secret, if any.
+func (c *Certificates) GetCaCrl() core.SecretBytes {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.Certificates[c.CaCertificateSecretKey][CaCrlKey]
+}
+
 // GetClientCertificate returns the Client certificate and key.
 func (c *Certificates) GetClientCertificate() (core.SecretBytes, core.SecretBytes) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	keyBytes := c.Certificates[c.ClientCertificateSecretKey][CertificateKeyKey]
 	certificateBytes := c.Certificates[c.ClientCertificateSecretKey][CertificateKey]
 
 	return keyBytes, certificateBytes
 }
 
+// SetCertificateSecretKeys sets the names of the Secrets of the CA certificate and of the client certificate, which the
+// getters read while the ConfigMap changes.
+func (c *Certificates) SetCertificateSecretKeys(caCertificateSecretKey string, clientCertificateSecretKey string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.CaCertificateSecretKey = caCertificateSecretKey
+	c.ClientCertificateSecretKey = clientCertificateSecretKey
+}
+
+// SetApiAuthSecretKey sets the name of the Secret of the NGINX Plus API credentials, which GetApiCredentials reads while
+// the ConfigMap changes.
+func (c *Certificates) SetApiAuthSecretKey(apiAuthSecretKey string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ApiAuthSecretKey = apiAuthSecretKey
+}
+
+// SecretKeys returns the names of the Secrets of the CA certificate, of the client certificate, and of the NGINX Plus API
+// credentials.
+func (c *Certificates) SecretKeys() (string, string, string) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.CaCertificateSecretKey, c.ClientCertificateSecretKey, c.ApiAuthSecretKey
+}
+
+// GetApiCredentials returns the credentials for the NGINX Plus API: a basic auth user and password, and a bearer token.
+// Values that are not present in the Secret are empty.
+func (c *Certificates) GetApiCredentials() (core.SecretBytes, core.SecretBytes, core.SecretBytes) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	secret := c.Certificates[c.ApiAuthSecretKey]
+
+	return secret[ApiAuthUserKey], secret[ApiAuthPasswordKey], secret[ApiAuthTokenKey]
+}
+
 // Initialize initializes the Certificates object. Sets up a SharedInformer for the Secrets Resource.
 func (c *Certificates) Initialize() error {
 	logrus.Info("Certificates::Initialize")
@@ -101,7 +204,7 @@ func (c *Certificates) Initialize() error {
 	return nil
 }
 
-// Run starts the SharedInformer.
+// Run starts the SharedInformer, and checks the expiry of the certificates periodically.
 func (c *Certificates) Run() error {
 	logrus.Info("Certificates::Run")
 
@@ -109,6 +212,8 @@ func (c *Certificates) Run() error {
 		return fmt.Errorf(`initialize must be called before Run`)
 	}
 
+	go c.checkExpiryPeriodically()
+
 	c.informer.Run(c.Context.Done())
 
 	<-c.Context.Done()
@@ -116,6 +221,25 @@ func (c *Certificates) Run() error {
 	return nil
 }
 
+// checkExpiryPeriodically calls CheckExpiry every ExpiryCheckInterval until the Context is done.
+func (c *Certificates) checkExpiryPeriodically() {
+	if c.ExpiryCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.ExpiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Context.Done():
+			return
+		case <-ticker.C:
+			c.CheckExpiry()
+		}
+	}
+}
+
 func (c *Certificates) buildInformer() (cache.SharedInformer, error) {
 	logrus.Debug("Certificates::buildInformer")
 
@@ -154,6 +278,8 @@ func (c *Certificates) handleAddEvent(obj interface{}) {
 		return
 	}
 
+	c.lock.Lock()
+
 	c.Certificates[secret.Name] = map[string]core.SecretBytes{}
 
 	// Input from the secret comes in the form
@@ -165,22 +291,44 @@ func (c *Certificates) handleAddEvent(obj interface{}) {
 	}
 
 	logrus.Debugf("Certificates::handleAddEvent: certificates (%d)", len(c.Certificates))
+
+	c.lock.Unlock()
+
+	c.CheckExpiry()
+	c.notifySubscribers()
 }
 
 func (c *Certificates) handleDeleteEvent(obj interface{}) {
 	logrus.Debug("Certificates::handleDeleteEvent")
 
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
 	secret, ok := obj.(*corev1.Secret)
 	if !ok {
 		logrus.Errorf("Certificates::handleDeleteEvent: unable to cast object to Secret")
 		return
 	}
 
+	c.lock.Lock()
+
+	if c.requiredSecrets[secret.Name] {
+		c.lock.Unlock()
+		logrus.Errorf("Certificates::handleDeleteEvent: Secret %s is required by the current TLS mode, keeping the last known good certificates", secret.Name)
+		return
+	}
+
 	if c.Certificates[secret.Name] != nil {
 		delete(c.Certificates, secret.Name)
 	}
 
 	logrus.Debugf("Certificates::handleDeleteEvent: certificates (%d)", len(c.Certificates))
+
+	c.lock.Unlock()
+
+	c.CheckExpiry()
+	c.notifySubscribers()
 }
 
 func (c *Certificates) handleUpdateEvent(_ interface{}, newValue interface{}) {
@@ -192,9 +340,57 @@ func (c *Certificates) handleUpdateEvent(_ interface{}, newValue interface{}) {
 		return
 	}
 
+	c.lock.Lock()
+
+	if c.Certificates[secret.Name] == nil {
+		c.Certificates[secret.Name] = map[string]core.SecretBytes{}
+	}
+
 	for k, v := range secret.Data {
 		c.Certificates[secret.Name][k] = v
 	}
 
 	logrus.Debugf("Certificates::handleUpdateEvent: certificates (%d)", len(c.Certificates))
+
+	c.lock.Unlock()
+
+	c.CheckExpiry()
+	c.notifySubscribers()
+}
+
+// SetRequiredSecrets records the names of the Secrets required by the current TLS mode, whose expiry is checked.
+// Deleting a required Secret does not remove its certificates, so the last known good material remains in use.
+func (c *Certificates) SetRequiredSecrets(names ...string) {
+	c.lock.Lock()
+
+	c.requiredSecrets = make(map[string]bool)
+	for _, name := range names {
+		if name != "" {
+			c.requiredSecrets[name] = true
+		}
+	}
+
+	c.lock.Unlock()
+
+	c.CheckExpiry()
+}
+
+// Subscribe registers a callback that is invoked each time the Secrets change.
+// Callbacks are invoked from the informer goroutine and should return quickly.
+func (c *Certificates) Subscribe(callback func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.subscribers = append(c.subscribers, callback)
+}
+
+// notifySubscribers invokes each of the registered callbacks.
+func (c *Certificates) notifySubscribers() {
+	c.lock.RLock()
+	subscribers := append([]func(){}, c.subscribers...)
+	c.lock.RUnlock()
+
+	for _, callback := range subscribers {
+		callback()
+	}
 }
@@ -7,6 +7,7 @@ package certification
 
 import (
 	"context"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -88,7 +89,7 @@ func TestCertificates_ExerciseHandlers(t *testing.T) {
 	go func() {
 		err := certificates.Run()
 		if err != nil {
-			t.Fatalf("error running Certificates: %v", err)
+			t.Errorf("error running Certificates: %v", err)
 		}
 	}()
 
@@ -144,6 +145,51 @@ func TestCertificates_ExerciseHandlers(t *testing.T) {
 	}
 }
 
+func TestCertificates_DeletingRequiredSecretKeepsLastKnownGood(t *testing.T) {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.Certificates = make(map[string]map[string]core.SecretBytes)
+	certificates.CaCertificateSecretKey = CaCertificateSecretKey
+
+	notifications := 0
+	certificates.Subscribe(func() { notifications++ })
+
+	secret := buildSecret()
+	certificates.handleAddEvent(secret)
+	certificates.SetRequiredSecrets(CaCertificateSecretKey)
+
+	certificates.handleDeleteEvent(secret)
+
+	if certificates.GetCACertificate() == nil {
+		t.Fatalf(`Expected the CA certificate to be kept after deleting a required Secret`)
+	}
+
+	if notifications != 1 {
+		t.Fatalf(`Expected only the add to notify subscribers, got %d notifications`, notifications)
+	}
+
+	certificates.SetRequiredSecrets()
+	certificates.handleDeleteEvent(secret)
+
+	if certificates.GetCACertificate() != nil {
+		t.Fatalf(`Expected the CA certificate to be removed once the Secret is no longer required`)
+	}
+}
+
+func TestCertificates_DeletingTombstoneRemovesTheCertificates(t *testing.T) {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.Certificates = make(map[string]map[string]core.SecretBytes)
+	certificates.CaCertificateSecretKey = CaCertificateSecretKey
+
+	secret := buildSecret()
+	certificates.handleAddEvent(secret)
+
+	certificates.handleDeleteEvent(cache.DeletedFinalStateUnknown{Key: SecretsNamespace + "/" + secret.Name, Obj: secret})
+
+	if certificates.GetCACertificate() != nil {
+		t.Fatalf(`Expected the CA certificate to be removed when the deletion is delivered as a tombstone`)
+	}
+}
+
 func buildSecret() *corev1.Secret {
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -225,3 +271,45 @@ z/3KkMx4uqJXZyvQrmkolSg=
 -----END PRIVATE KEY-----
 `
 }
+
+func TestCertificates_SetCertificateSecretKeysWhileTheCertificatesAreRead(t *testing.T) {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.Certificates = map[string]map[string]core.SecretBytes{
+		"ca-a": {CertificateKey: core.SecretBytes("a")},
+		"ca-b": {CertificateKey: core.SecretBytes("b")},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			certificates.SetCertificateSecretKeys("ca-b", "")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = certificates.GetCACertificate()
+	}
+	<-done
+
+	if string(certificates.GetCACertificate()) != "b" {
+		t.Fatalf(`expected the certificate of the Secret set last, got %q`, certificates.GetCACertificate())
+	}
+}
+
+func TestCertificates_SetApiAuthSecretKey(t *testing.T) {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.Certificates = map[string]map[string]core.SecretBytes{
+		"nlk-api-auth": {ApiAuthTokenKey: core.SecretBytes("token")},
+	}
+
+	certificates.SetApiAuthSecretKey("nlk-api-auth")
+	if _, _, token := certificates.GetApiCredentials(); string(token) != "token" {
+		t.Fatalf(`expected the token of the Secret, got %q`, token)
+	}
+
+	certificates.SetApiAuthSecretKey("")
+	if _, _, token := certificates.GetApiCredentials(); len(token) != 0 {
+		t.Fatalf(`expected no token once the Secret is unset, got %q`, token)
+	}
+}
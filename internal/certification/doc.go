@@ -4,7 +4,8 @@
  */
 
 /*
-Package certification includes functionality to access the Secrets containing the TLS Certificates.
+Package certification includes functionality to access the TLS Certificates, from the Secrets containing them,
+or from mounted files, e.g. delivered by the SPIFFE CSI driver; both implement CertificateSource.
 */
 
 package certification
@@ -0,0 +1,226 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package certification
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+)
+
+const (
+	// CaCertificateRole identifies the CA certificate, see CaCertificateSecretKey.
+	CaCertificateRole = "ca"
+
+	// ClientCertificateRole identifies the client certificate, see ClientCertificateSecretKey.
+	ClientCertificateRole = "client"
+
+	// DefaultExpiryCheckInterval is the default interval at which the expiry of the certificates is checked.
+	DefaultExpiryCheckInterval = time.Hour
+)
+
+// DefaultExpiryWarnings are the durations before the expiry of a certificate at which a warning is logged: 30, 7, and 1 day(s).
+var DefaultExpiryWarnings = []time.Duration{time.Hour * 24 * 30, time.Hour * 24 * 7, time.Hour * 24}
+
+// expiryWarning records the smallest threshold a certificate has been warned about, so each threshold is logged once.
+type expiryWarning struct {
+
+	// notAfter identifies the certificate; a renewed certificate starts over.
+	notAfter time.Time
+
+	// threshold is the smallest threshold that has been logged.
+	threshold time.Duration
+}
+
+// certificateBundle is the PEM bundle of a certificate role, with the Secret or file it was read from.
+type certificateBundle struct {
+
+	// origin names the Secret or file, for the logs.
+	origin string
+
+	// bundle is the PEM-encoded certificate, followed by any intermediates.
+	bundle []byte
+}
+
+// expiryTracker reports the expiry of the certificates of a source in the nkl_certificate_expiry_seconds gauge,
+// and logs the warnings as the certificates cross the thresholds.
+type expiryTracker struct {
+
+	// lock guards the warnings and the state.
+	lock sync.Mutex
+
+	// warnings are the durations before the expiry of a certificate at which a warning is logged, largest first.
+	warnings []time.Duration
+
+	// state records the warnings already logged for each certificate role reported by the tracker.
+	state map[string]expiryWarning
+}
+
+// newExpiryTracker creates an expiryTracker with the DefaultExpiryWarnings.
+func newExpiryTracker() *expiryTracker {
+	return &expiryTracker{
+		warnings: DefaultExpiryWarnings,
+		state:    make(map[string]expiryWarning),
+	}
+}
+
+// EarliestExpiring returns the certificate of the PEM bundle that expires first, as the chain is invalid once any of its
+// certificates has expired. Blocks other than CERTIFICATE are skipped.
+func EarliestExpiring(bundle []byte) (*x509.Certificate, error) {
+	var earliest *x509.Certificate
+
+	remaining := bundle
+	for {
+		var block *pem.Block
+		block, remaining = pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf(`error parsing certificate: %w`, err)
+		}
+
+		if earliest == nil || certificate.NotAfter.Before(earliest.NotAfter) {
+			earliest = certificate
+		}
+	}
+
+	if earliest == nil {
+		return nil, fmt.Errorf(`no certificate found in the PEM data`)
+	}
+
+	return earliest, nil
+}
+
+// SetExpiryWarnings sets the durations before the expiry of a certificate at which a warning is logged;
+// the warning for the smallest one is logged as an error.
+func (c *Certificates) SetExpiryWarnings(thresholds []time.Duration) {
+	c.expiry.setWarnings(thresholds)
+}
+
+// CheckExpiry reports the time left before the CA and client certificates required by the current TLS mode expire,
+// in the nkl_certificate_expiry_seconds gauge, and logs a warning each time a certificate crosses one of the thresholds.
+// An expired certificate is logged as an error at each check.
+func (c *Certificates) CheckExpiry() {
+	c.lock.RLock()
+	secretNames := map[string]string{
+		CaCertificateRole:     c.CaCertificateSecretKey,
+		ClientCertificateRole: c.ClientCertificateSecretKey,
+	}
+
+	bundles := make(map[string]certificateBundle)
+	for role, secretName := range secretNames {
+		if secretName != "" && c.requiredSecrets[secretName] {
+			bundles[role] = certificateBundle{
+				origin: fmt.Sprintf("Secret '%s'", secretName),
+				bundle: c.Certificates[secretName][CertificateKey],
+			}
+		}
+	}
+	c.lock.RUnlock()
+
+	c.expiry.check(bundles)
+}
+
+// setWarnings sets the thresholds, sorted largest first.
+func (t *expiryTracker) setWarnings(thresholds []time.Duration) {
+	sorted := append([]time.Duration{}, thresholds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.warnings = sorted
+}
+
+// check reports the expiry of the bundles by role. The roles the tracker has reported that are no longer in the bundles,
+// or whose bundle is empty, are forgotten, so that sources do not remove the expiry reported by another.
+func (t *expiryTracker) check(bundles map[string]certificateBundle) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, role := range []string{CaCertificateRole, ClientCertificateRole} {
+		bundle, found := bundles[role]
+		if !found || len(bundle.bundle) == 0 {
+			t.forget(role)
+			continue
+		}
+
+		certificate, err := EarliestExpiring(bundle.bundle)
+		if err != nil {
+			logrus.Warnf("Certificates::CheckExpiry: unable to read the %s certificate in %s: %v", role, bundle.origin, err)
+			t.forget(role)
+			continue
+		}
+
+		remaining := time.Until(certificate.NotAfter)
+		instrumentation.ObserveCertificateExpiry(role, remaining)
+
+		t.warn(role, bundle.origin, certificate, remaining)
+	}
+}
+
+// forget drops the expiry of a role reported by the tracker. lock must be held.
+func (t *expiryTracker) forget(role string) {
+	if _, tracked := t.state[role]; tracked {
+		instrumentation.ForgetCertificateExpiry(role)
+		delete(t.state, role)
+	}
+}
+
+// warn logs the smallest threshold crossed by the certificate, if it has not been logged yet. lock must be held.
+func (t *expiryTracker) warn(role string, origin string, certificate *x509.Certificate, remaining time.Duration) {
+	state, found := t.state[role]
+	if !found || !state.notAfter.Equal(certificate.NotAfter) {
+		state = expiryWarning{notAfter: certificate.NotAfter}
+	}
+
+	defer func() { t.state[role] = state }()
+
+	entry := logrus.WithFields(logrus.Fields{
+		"role":     role,
+		"origin":   origin,
+		"subject":  certificate.Subject.String(),
+		"notAfter": certificate.NotAfter,
+	})
+
+	if remaining <= 0 {
+		entry.Errorf("Certificates::CheckExpiry: the %s certificate has expired, the connections to NGINX Plus will fail", role)
+		return
+	}
+
+	for index := len(t.warnings) - 1; index >= 0; index-- {
+		threshold := t.warnings[index]
+		if remaining > threshold {
+			continue
+		}
+
+		if state.threshold == 0 || threshold < state.threshold {
+			state.threshold = threshold
+
+			if index == len(t.warnings)-1 {
+				entry.Errorf("Certificates::CheckExpiry: the %s certificate expires in less than %v", role, threshold)
+			} else {
+				entry.Warnf("Certificates::CheckExpiry: the %s certificate expires in less than %v", role, threshold)
+			}
+		}
+
+		break
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package certification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	ClientCertificateSecretKey = "nlk-tls-client-secret"
+)
+
+func TestEarliestExpiring_ReturnsTheFirstCertificateToExpire(t *testing.T) {
+	bundle := generateCertificatePEM(t, "nlk-client", time.Hour*24*90) +
+		generateCertificatePEM(t, "nlk-intermediate", time.Hour*24*10) +
+		generateCertificatePEM(t, "nlk-root", time.Hour*24*365)
+
+	certificate, err := EarliestExpiring([]byte(bundle))
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if certificate.Subject.CommonName != "nlk-intermediate" {
+		t.Fatalf(`Expected the intermediate to expire first, got %s`, certificate.Subject.CommonName)
+	}
+
+	if _, err = EarliestExpiring([]byte("this is not a certificate")); err == nil {
+		t.Fatalf(`Expected an error`)
+	}
+}
+
+func TestCertificates_CheckExpiryReportsTheRequiredCertificates(t *testing.T) {
+	certificates := buildExpiringCertificates(t, time.Hour*24*20)
+	certificates.SetRequiredSecrets(ClientCertificateSecretKey)
+
+	remaining := testutil.ToFloat64(instrumentation.CertificateExpiry.WithLabelValues(ClientCertificateRole))
+	if remaining < (time.Hour*24*19).Seconds() || remaining > (time.Hour*24*20).Seconds() {
+		t.Fatalf(`Expected about 20 days left on the client certificate, got %vs`, remaining)
+	}
+
+	certificates.SetRequiredSecrets()
+
+	if count := testutil.CollectAndCount(instrumentation.CertificateExpiry); count != 0 {
+		t.Fatalf(`Expected the expiry to be forgotten once the certificate is no longer required, got %d series`, count)
+	}
+}
+
+func TestCertificates_CheckExpiryEscalatesTheWarnings(t *testing.T) {
+	certificates := buildExpiringCertificates(t, time.Hour*24*20)
+	certificates.SetRequiredSecrets(ClientCertificateSecretKey)
+
+	if threshold := certificates.expiry.state[ClientCertificateRole].threshold; threshold != time.Hour*24*30 {
+		t.Fatalf(`Expected the 30 days warning, got %v`, threshold)
+	}
+
+	// a certificate closer to its expiry crosses the 1 day threshold, the 7 days warning is skipped
+	certificates.handleUpdateEvent(nil, buildClientSecret(t, time.Hour*12))
+
+	if threshold := certificates.expiry.state[ClientCertificateRole].threshold; threshold != time.Hour*24 {
+		t.Fatalf(`Expected the 1 day warning, got %v`, threshold)
+	}
+
+	// a renewed certificate starts over
+	certificates.handleUpdateEvent(nil, buildClientSecret(t, time.Hour*24*90))
+
+	if threshold := certificates.expiry.state[ClientCertificateRole].threshold; threshold != 0 {
+		t.Fatalf(`Expected no warning for the renewed certificate, got %v`, threshold)
+	}
+}
+
+func TestCertificates_SetExpiryWarningsSortsTheThresholds(t *testing.T) {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.SetExpiryWarnings([]time.Duration{time.Hour, time.Hour * 24 * 14, time.Hour * 24})
+
+	expected := []time.Duration{time.Hour * 24 * 14, time.Hour * 24, time.Hour}
+	for index, threshold := range expected {
+		if certificates.expiry.warnings[index] != threshold {
+			t.Fatalf(`Expected the thresholds %v, got %v`, expected, certificates.expiry.warnings)
+		}
+	}
+}
+
+// buildExpiringCertificates returns Certificates holding a client certificate that expires after the given duration.
+func buildExpiringCertificates(t *testing.T, validity time.Duration) *Certificates {
+	certificates := NewCertificates(context.Background(), nil)
+	certificates.Certificates = make(map[string]map[string]core.SecretBytes)
+	certificates.ClientCertificateSecretKey = ClientCertificateSecretKey
+
+	certificates.handleAddEvent(buildClientSecret(t, validity))
+
+	return certificates
+}
+
+func buildClientSecret(t *testing.T, validity time.Duration) *corev1.Secret {
+	secret := buildSecret()
+	secret.Name = ClientCertificateSecretKey
+	secret.Data[CertificateKey] = []byte(generateCertificatePEM(t, "nlk-client", validity))
+
+	return secret
+}
+
+// generateCertificatePEM generates a self-signed certificate that expires after the given duration.
+func generateCertificatePEM(t *testing.T, commonName string, validity time.Duration) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
@@ -0,0 +1,342 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ *
+ * Reads the certificates and keys used to generate a tls.Config object from mounted files, and reloads them when they change.
+ */
+
+package certification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// CertificatePaths are the paths of the mounted certificate files, set by the ca-certificate-path, client-certificate-path,
+// client-key-path, and ca-crl-path ConfigMap keys.
+type CertificatePaths struct {
+
+	// CaCertificate is the path of the PEM-encoded CA certificate(s).
+	CaCertificate string
+
+	// ClientCertificate is the path of the PEM-encoded client certificate, followed by any intermediates.
+	ClientCertificate string
+
+	// ClientKey is the path of the PEM-encoded client key.
+	ClientKey string
+
+	// CaCrl is the path of the certificate revocation list(s) issued by the CA, PEM- or DER-encoded.
+	CaCrl string
+}
+
+// IsEmpty returns true if none of the paths is set.
+func (p CertificatePaths) IsEmpty() bool {
+	return p == CertificatePaths{}
+}
+
+// list returns the paths that are set.
+func (p CertificatePaths) list() []string {
+	var paths []string
+	for _, path := range []string{p.CaCertificate, p.ClientCertificate, p.ClientKey, p.CaCrl} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// FileCertificates is the CertificateSource that reads the certificates and keys from mounted files, such as the SVIDs
+// written by the SPIFFE CSI driver. The directories of the files are watched, so rotated files are reloaded; the directories
+// rather than the files are watched as the kubelet and most CSI drivers replace a file atomically by swapping a symlink.
+type FileCertificates struct {
+
+	// Context is the context used to control the application.
+	Context context.Context
+
+	// ExpiryCheckInterval is the interval at which the expiry of the certificates is checked by Run, see CheckExpiry;
+	// the expiry is also checked each time the files change.
+	ExpiryCheckInterval time.Duration
+
+	// lock guards the paths, the contents, the watcher, and the subscribers.
+	lock sync.RWMutex
+
+	// paths are the paths of the files.
+	paths CertificatePaths
+
+	// contents are the last contents read from each file; a file that cannot be read keeps its last known good contents.
+	contents map[string]core.SecretBytes
+
+	// watcher watches the directories of the files while Run is running.
+	watcher *fsnotify.Watcher
+
+	// watchedDirectories are the directories added to the watcher.
+	watchedDirectories map[string]bool
+
+	// subscribers are the callbacks invoked after the contents of the files have changed.
+	subscribers []func()
+
+	// expiry tracks the expiry of the CA and client certificates.
+	expiry *expiryTracker
+}
+
+// NewFileCertificates factory method that returns a new FileCertificates object, with no paths.
+func NewFileCertificates(ctx context.Context) *FileCertificates {
+	return &FileCertificates{
+		Context:             ctx,
+		ExpiryCheckInterval: DefaultExpiryCheckInterval,
+		contents:            make(map[string]core.SecretBytes),
+		watchedDirectories:  make(map[string]bool),
+		expiry:              newExpiryTracker(),
+	}
+}
+
+// IsConfigured returns true if any of the paths is set, in which case the files are used instead of the Secrets.
+func (f *FileCertificates) IsConfigured() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return !f.paths.IsEmpty()
+}
+
+// Paths returns the paths of the files.
+func (f *FileCertificates) Paths() CertificatePaths {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.paths
+}
+
+// SetPaths sets the paths of the files, reads them, and watches their directories. It returns true if the paths have changed.
+func (f *FileCertificates) SetPaths(paths CertificatePaths) bool {
+	f.lock.Lock()
+
+	if f.paths == paths {
+		f.lock.Unlock()
+		return false
+	}
+
+	f.paths = paths
+	f.contents = make(map[string]core.SecretBytes)
+	f.readFiles()
+	f.updateWatches()
+
+	f.lock.Unlock()
+
+	logrus.Infof("FileCertificates::SetPaths: ca-certificate-path: '%s', client-certificate-path: '%s', client-key-path: '%s', ca-crl-path: '%s'",
+		paths.CaCertificate, paths.ClientCertificate, paths.ClientKey, paths.CaCrl)
+
+	f.CheckExpiry()
+
+	return true
+}
+
+// GetCACertificate returns the Certificate Authority certificate.
+func (f *FileCertificates) GetCACertificate() core.SecretBytes {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.contents[f.paths.CaCertificate]
+}
+
+// GetClientCertificate returns the Client certificate and key.
+func (f *FileCertificates) GetClientCertificate() (core.SecretBytes, core.SecretBytes) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.contents[f.paths.ClientKey], f.contents[f.paths.ClientCertificate]
+}
+
+// GetCaCrl returns the certificate revocation list(s) issued by the CA, if any.
+func (f *FileCertificates) GetCaCrl() core.SecretBytes {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.contents[f.paths.CaCrl]
+}
+
+// SetExpiryWarnings sets the durations before the expiry of a certificate at which a warning is logged;
+// the warning for the smallest one is logged as an error.
+func (f *FileCertificates) SetExpiryWarnings(thresholds []time.Duration) {
+	f.expiry.setWarnings(thresholds)
+}
+
+// CheckExpiry reports the time left before the CA and client certificates read from the files expire, see Certificates::CheckExpiry.
+func (f *FileCertificates) CheckExpiry() {
+	f.lock.RLock()
+	bundles := make(map[string]certificateBundle)
+
+	if f.paths.CaCertificate != "" {
+		bundles[CaCertificateRole] = certificateBundle{
+			origin: fmt.Sprintf("file '%s'", f.paths.CaCertificate),
+			bundle: f.contents[f.paths.CaCertificate],
+		}
+	}
+
+	if f.paths.ClientCertificate != "" {
+		bundles[ClientCertificateRole] = certificateBundle{
+			origin: fmt.Sprintf("file '%s'", f.paths.ClientCertificate),
+			bundle: f.contents[f.paths.ClientCertificate],
+		}
+	}
+	f.lock.RUnlock()
+
+	f.expiry.check(bundles)
+}
+
+// Subscribe registers a callback that is invoked each time the contents of the files change.
+func (f *FileCertificates) Subscribe(callback func()) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.subscribers = append(f.subscribers, callback)
+}
+
+// Run watches the directories of the files, and reloads the files when they change, until the Context is done;
+// the expiry of the certificates is checked periodically.
+func (f *FileCertificates) Run() error {
+	logrus.Info("FileCertificates::Run")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf(`error occurred creating the certificate files watcher: %w`, err)
+	}
+
+	defer func() {
+		f.lock.Lock()
+		f.watcher = nil
+		f.watchedDirectories = make(map[string]bool)
+		f.lock.Unlock()
+
+		_ = watcher.Close()
+	}()
+
+	f.lock.Lock()
+	f.watcher = watcher
+	f.updateWatches()
+	f.lock.Unlock()
+
+	// files written before the watches were added are picked up
+	f.reload()
+
+	var ticks <-chan time.Time
+	if f.ExpiryCheckInterval > 0 {
+		ticker := time.NewTicker(f.ExpiryCheckInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-f.Context.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			logrus.Debugf("FileCertificates::Run: %s", event)
+			f.reload()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			logrus.Warnf("FileCertificates::Run: error watching the certificate files: %v", err)
+
+		case <-ticks:
+			f.CheckExpiry()
+		}
+	}
+}
+
+// reload reads the files, and notifies the subscribers if their contents have changed.
+func (f *FileCertificates) reload() {
+	f.lock.Lock()
+	changed := f.readFiles()
+	f.lock.Unlock()
+
+	if !changed {
+		return
+	}
+
+	logrus.Info("FileCertificates::reload: the certificate files have changed")
+
+	f.CheckExpiry()
+	f.notifySubscribers()
+}
+
+// readFiles reads the files and returns true if their contents have changed. lock must be held.
+func (f *FileCertificates) readFiles() bool {
+	changed := false
+
+	for _, path := range f.paths.list() {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			logrus.Errorf("FileCertificates::readFiles: unable to read '%s', keeping the last known good contents: %v", path, err)
+			continue
+		}
+
+		if !bytes.Equal(contents, f.contents[path]) {
+			f.contents[path] = contents
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// updateWatches watches the directories of the current paths, and stops watching the others. lock must be held.
+func (f *FileCertificates) updateWatches() {
+	if f.watcher == nil {
+		return
+	}
+
+	directories := make(map[string]bool)
+	for _, path := range f.paths.list() {
+		directories[filepath.Dir(path)] = true
+	}
+
+	for directory := range f.watchedDirectories {
+		if !directories[directory] {
+			_ = f.watcher.Remove(directory)
+			delete(f.watchedDirectories, directory)
+		}
+	}
+
+	for directory := range directories {
+		if f.watchedDirectories[directory] {
+			continue
+		}
+
+		if err := f.watcher.Add(directory); err != nil {
+			logrus.Errorf("FileCertificates::updateWatches: unable to watch '%s', the changes to its files will not be reloaded: %v", directory, err)
+			continue
+		}
+
+		f.watchedDirectories[directory] = true
+	}
+}
+
+// notifySubscribers invokes each of the registered callbacks.
+func (f *FileCertificates) notifySubscribers() {
+	f.lock.RLock()
+	subscribers := append([]func(){}, f.subscribers...)
+	f.lock.RUnlock()
+
+	for _, callback := range subscribers {
+		callback()
+	}
+}
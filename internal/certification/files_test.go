@@ -0,0 +1,127 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package certification
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCertificates_ReadsTheFiles(t *testing.T) {
+	paths := writeCertificateFiles(t, t.TempDir(), "nlk-client")
+
+	certificates := NewFileCertificates(context.Background())
+	if certificates.IsConfigured() {
+		t.Fatalf(`Expected no paths`)
+	}
+
+	if !certificates.SetPaths(paths) {
+		t.Fatalf(`Expected the paths to have changed`)
+	}
+
+	if certificates.SetPaths(paths) {
+		t.Fatalf(`Expected the same paths not to be reported as changed`)
+	}
+
+	key, certificate := certificates.GetClientCertificate()
+	if string(key) != keyPEM() {
+		t.Fatalf(`Expected the client key to be read from %s`, paths.ClientKey)
+	}
+
+	parsed, err := EarliestExpiring(certificate)
+	if err != nil || parsed.Subject.CommonName != "nlk-client" {
+		t.Fatalf(`Expected the client certificate to be read from %s, %v`, paths.ClientCertificate, err)
+	}
+
+	if len(certificates.GetCACertificate()) == 0 {
+		t.Fatalf(`Expected the CA certificate to be read from %s`, paths.CaCertificate)
+	}
+
+	certificates.SetPaths(CertificatePaths{})
+
+	if certificates.IsConfigured() || certificates.GetCACertificate() != nil {
+		t.Fatalf(`Expected the files to be dropped once the paths are cleared`)
+	}
+}
+
+func TestFileCertificates_ReloadsRotatedFiles(t *testing.T) {
+	directory := t.TempDir()
+	paths := writeCertificateFiles(t, directory, "nlk-client")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	certificates := NewFileCertificates(ctx)
+	certificates.SetPaths(paths)
+
+	notifications := make(chan struct{}, 10)
+	certificates.Subscribe(func() { notifications <- struct{}{} })
+
+	go func() {
+		_ = certificates.Run()
+	}()
+
+	// the rotated certificate replaces the file atomically, as the SPIFFE helper does
+	deadline := time.After(5 * time.Second)
+	for {
+		writeFileAtomically(t, paths.ClientCertificate, generateCertificatePEM(t, "nlk-client-rotated", time.Hour))
+
+		select {
+		case <-notifications:
+		case <-time.After(100 * time.Millisecond):
+			continue
+		case <-deadline:
+			t.Fatalf(`Expected the rotated certificate to be reloaded`)
+		}
+
+		break
+	}
+
+	_, certificate := certificates.GetClientCertificate()
+	if parsed, err := EarliestExpiring(certificate); err != nil || parsed.Subject.CommonName != "nlk-client-rotated" {
+		t.Fatalf(`Expected the rotated certificate, %v`, err)
+	}
+
+	// a file that is removed keeps its last known good contents
+	if err := os.Remove(paths.ClientKey); err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	certificates.reload()
+
+	if key, _ := certificates.GetClientCertificate(); string(key) != keyPEM() {
+		t.Fatalf(`Expected the last known good client key`)
+	}
+}
+
+// writeCertificateFiles writes a CA certificate, a client certificate, and a client key to the directory.
+func writeCertificateFiles(t *testing.T, directory string, commonName string) CertificatePaths {
+	paths := CertificatePaths{
+		CaCertificate:     filepath.Join(directory, "bundle.0.pem"),
+		ClientCertificate: filepath.Join(directory, "svid.0.pem"),
+		ClientKey:         filepath.Join(directory, "svid.0.key"),
+	}
+
+	writeFileAtomically(t, paths.CaCertificate, generateCertificatePEM(t, "nlk-root", time.Hour))
+	writeFileAtomically(t, paths.ClientCertificate, generateCertificatePEM(t, commonName, time.Hour))
+	writeFileAtomically(t, paths.ClientKey, keyPEM())
+
+	return paths
+}
+
+func writeFileAtomically(t *testing.T, path string, contents string) {
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, []byte(contents), 0o600); err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	if err := os.Rename(temporary, path); err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+}
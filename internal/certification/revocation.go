@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package certification
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// ParseRevocationLists parses the certificate revocation list(s), PEM-encoded X509 CRL blocks or a single DER-encoded CRL,
+// and ensures each of them is signed by one of the certificates of the PEM-encoded CA bundle. Other PEM blocks are skipped.
+func ParseRevocationLists(crl []byte, caBundle []byte) ([]*x509.RevocationList, error) {
+	var derLists [][]byte
+
+	remaining := crl
+	for {
+		var block *pem.Block
+		block, remaining = pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "X509 CRL" {
+			derLists = append(derLists, block.Bytes)
+		}
+	}
+
+	if len(derLists) == 0 {
+		derLists = append(derLists, crl)
+	}
+
+	issuers, err := parseCertificates(caBundle)
+	if err != nil {
+		return nil, fmt.Errorf(`error parsing the CA certificate the CRL is verified against: %w`, err)
+	}
+
+	var lists []*x509.RevocationList
+	for _, der := range derLists {
+		list, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, fmt.Errorf(`error parsing CRL: %w`, err)
+		}
+
+		if !signedByAny(list, issuers) {
+			return nil, fmt.Errorf(`the CRL issued by '%s' is not signed by any of the CA certificates`, list.Issuer)
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, nil
+}
+
+// signedByAny returns true if the CRL is signed by one of the issuers.
+func signedByAny(list *x509.RevocationList, issuers []*x509.Certificate) bool {
+	for _, issuer := range issuers {
+		if list.CheckSignatureFrom(issuer) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCertificates parses the CERTIFICATE blocks of a PEM bundle, other blocks are skipped.
+func parseCertificates(bundle []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	remaining := bundle
+	for {
+		var block *pem.Block
+		block, remaining = pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf(`error parsing certificate: %w`, err)
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf(`no certificate found in the PEM data`)
+	}
+
+	return certificates, nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package certification
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseRevocationLists_AcceptsPemAndDer(t *testing.T) {
+	caPEM, crlDER := generateRevocationList(t)
+	crlPEM := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDER})
+
+	for name, crl := range map[string][]byte{"PEM": crlPEM, "DER": crlDER} {
+		lists, err := ParseRevocationLists(crl, caPEM)
+		if err != nil {
+			t.Fatalf(`Unexpected error parsing the %s CRL: %v`, name, err)
+		}
+
+		if len(lists) != 1 || len(lists[0].RevokedCertificateEntries) != 1 {
+			t.Fatalf(`Expected one CRL revoking one certificate from the %s data, got %d`, name, len(lists))
+		}
+	}
+}
+
+func TestParseRevocationLists_RejectsACrlOfAnotherCa(t *testing.T) {
+	_, crl := generateRevocationList(t)
+	otherCaPEM, _ := generateRevocationList(t)
+
+	if _, err := ParseRevocationLists(crl, otherCaPEM); err == nil {
+		t.Fatalf(`Expected an error for a CRL not signed by the CA`)
+	}
+
+	if _, err := ParseRevocationLists([]byte("this is not a CRL"), otherCaPEM); err == nil {
+		t.Fatalf(`Expected an error for a malformed CRL`)
+	}
+}
+
+// generateRevocationList generates a CA, and a DER-encoded CRL it signed revoking one certificate.
+func generateRevocationList(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "nlk-root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(42), RevocationTime: time.Now().Add(-time.Minute)},
+		},
+	}, ca, key)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), crl
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	netHttp "net/http"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+)
+
+// AuthRoundTripper adds the NGINX Plus API credentials to each request before passing it on to the wrapped RoundTripper.
+// The credentials are read from the Secret named by the api-auth-secret ConfigMap key on every request,
+// so a rotated Secret takes effect without a restart. A bearer token takes precedence over basic auth credentials.
+type AuthRoundTripper struct {
+	RoundTripper netHttp.RoundTripper
+	settings     *configuration.Settings
+}
+
+// NewAuthRoundTripper is a factory method to create a new AuthRoundTripper.
+func NewAuthRoundTripper(settings *configuration.Settings, transport netHttp.RoundTripper) *AuthRoundTripper {
+	return &AuthRoundTripper{
+		RoundTripper: transport,
+		settings:     settings,
+	}
+}
+
+// RoundTrip adds the Authorization header, if credentials are configured, and passes the request on.
+func (roundTripper *AuthRoundTripper) RoundTrip(req *netHttp.Request) (*netHttp.Response, error) {
+	if roundTripper.settings.Certificates == nil {
+		return roundTripper.RoundTripper.RoundTrip(req)
+	}
+
+	user, password, token := roundTripper.settings.Certificates.GetApiCredentials()
+
+	switch {
+	case len(token) > 0:
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+string(token))
+
+	case len(user) > 0:
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(string(user), string(password))
+	}
+
+	return roundTripper.RoundTripper.RoundTrip(req)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"context"
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+const apiAuthSecretName = "nlk-api-auth"
+
+func TestAuthRoundTripper_NoCredentials(t *testing.T) {
+	settings := buildAuthSettings(t, nil)
+
+	authorization := roundTripAuthorization(t, settings)
+	if authorization != "" {
+		t.Fatalf(`expected no Authorization header, got %q`, authorization)
+	}
+}
+
+func TestAuthRoundTripper_BasicAuth(t *testing.T) {
+	settings := buildAuthSettings(t, map[string]core.SecretBytes{
+		certification.ApiAuthUserKey:     core.SecretBytes("nlk"),
+		certification.ApiAuthPasswordKey: core.SecretBytes("s3cr3t"),
+	})
+
+	authorization := roundTripAuthorization(t, settings)
+	if authorization != "Basic bmxrOnMzY3IzdA==" {
+		t.Fatalf(`expected a basic Authorization header, got %q`, authorization)
+	}
+}
+
+func TestAuthRoundTripper_BearerTokenTakesPrecedence(t *testing.T) {
+	settings := buildAuthSettings(t, map[string]core.SecretBytes{
+		certification.ApiAuthUserKey:  core.SecretBytes("nlk"),
+		certification.ApiAuthTokenKey: core.SecretBytes("t0k3n"),
+	})
+
+	authorization := roundTripAuthorization(t, settings)
+	if authorization != "Bearer t0k3n" {
+		t.Fatalf(`expected a bearer Authorization header, got %q`, authorization)
+	}
+}
+
+func TestAuthRoundTripper_RotatedSecretTakesEffect(t *testing.T) {
+	settings := buildAuthSettings(t, map[string]core.SecretBytes{
+		certification.ApiAuthTokenKey: core.SecretBytes("t0k3n"),
+	})
+
+	roundTripAuthorization(t, settings)
+
+	settings.Certificates.Certificates[apiAuthSecretName] = map[string]core.SecretBytes{
+		certification.ApiAuthTokenKey: core.SecretBytes("r0t4t3d"),
+	}
+
+	authorization := roundTripAuthorization(t, settings)
+	if authorization != "Bearer r0t4t3d" {
+		t.Fatalf(`expected the rotated token, got %q`, authorization)
+	}
+}
+
+func buildAuthSettings(t *testing.T, secret map[string]core.SecretBytes) *configuration.Settings {
+	settings, err := configuration.NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+	settings.Certificates.ApiAuthSecretKey = apiAuthSecretName
+	settings.Certificates.Certificates = map[string]map[string]core.SecretBytes{}
+
+	if secret != nil {
+		settings.Certificates.Certificates[apiAuthSecretName] = secret
+	}
+
+	return settings
+}
+
+// roundTripAuthorization sends a request through an AuthRoundTripper and returns the Authorization header received by the server.
+func roundTripAuthorization(t *testing.T, settings *configuration.Settings) string {
+	var authorization string
+
+	server := httptest.NewServer(netHttp.HandlerFunc(func(writer netHttp.ResponseWriter, request *netHttp.Request) {
+		authorization = request.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := &netHttp.Client{Transport: NewAuthRoundTripper(settings, netHttp.DefaultTransport)}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	response.Body.Close()
+
+	return authorization
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	netHttp "net/http"
+	"net/http/httptrace"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+)
+
+// ConnectionRoundTripper records whether each request reused a pooled connection to its host or opened a new one, see
+// instrumentation.ApiConnections, so the effect of the pool settings of the HttpClientSettings can be observed.
+type ConnectionRoundTripper struct {
+	RoundTripper netHttp.RoundTripper
+}
+
+// NewConnectionRoundTripper is a factory method to create a new ConnectionRoundTripper.
+func NewConnectionRoundTripper(transport netHttp.RoundTripper) *ConnectionRoundTripper {
+	return &ConnectionRoundTripper{
+		RoundTripper: transport,
+	}
+}
+
+// RoundTrip traces the connection the request gets, keyed by host:port, and passes the request on.
+func (roundTripper *ConnectionRoundTripper) RoundTrip(req *netHttp.Request) (*netHttp.Response, error) {
+	host := req.URL.Host
+	clientTrace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			instrumentation.ObserveApiConnection(host, info.Reused)
+		},
+	}
+
+	return roundTripper.RoundTripper.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace)))
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestConnectionRoundTripper_CountsTheNewAndReusedConnections(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	defer server.Close()
+
+	host := hostOf(t, server.URL)
+	transport := netHttp.DefaultTransport.(*netHttp.Transport).Clone()
+	defer transport.CloseIdleConnections()
+
+	client := &netHttp.Client{Transport: NewConnectionRoundTripper(transport)}
+	for i := 0; i < 3; i++ {
+		sendRequest(t, client, netHttp.MethodGet, server.URL)
+	}
+
+	if opened := testutil.ToFloat64(instrumentation.ApiConnections.WithLabelValues(host, "false")); opened != 1 {
+		t.Fatalf(`expected a single connection to be opened, got %v`, opened)
+	}
+
+	if reused := testutil.ToFloat64(instrumentation.ApiConnections.WithLabelValues(host, "true")); reused != 2 {
+		t.Fatalf(`expected the connection to be reused twice, got %v`, reused)
+	}
+}
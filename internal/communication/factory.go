@@ -10,24 +10,27 @@ import (
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/authentication"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/sirupsen/logrus"
+	"net"
 	netHttp "net/http"
-	"time"
 )
 
 // NewHttpClient is a factory method to create a new Http Client with a default configuration.
 // RoundTripper is a wrapper around the default net/communication Transport to add additional headers, in this case,
-// the Headers are configured for JSON.
+// the Headers are configured for JSON. The trace headers are added by the TracingRoundTripper, the NGINX Plus API calls are
+// rate limited per host by the RateLimitingRoundTripper, the credentials, if any, are added by the AuthRoundTripper, and the
+// connections the calls are sent on are counted by the ConnectionRoundTripper.
+// The underlying Transport is rebuilt whenever the TLS mode or certificates change, see ReloadingTransport.
 func NewHttpClient(settings *configuration.Settings) (*netHttp.Client, error) {
 	headers := NewHeaders()
-	tlsConfig := NewTlsConfig(settings)
-	transport := NewTransport(tlsConfig)
-	roundTripper := NewRoundTripper(headers, transport)
+	transport := NewReloadingTransport(settings)
+	settings.SubscribeToTlsChanges(transport.Invalidate)
+	roundTripper := NewRoundTripper(headers, NewTracingRoundTripper(NewRateLimitingRoundTripper(settings, NewAuthRoundTripper(settings, NewConnectionRoundTripper(transport)))))
 
 	return &netHttp.Client{
 		Transport:     roundTripper,
 		CheckRedirect: nil,
 		Jar:           nil,
-		Timeout:       time.Second * 10,
+		Timeout:       settings.HttpClient.RequestTimeout,
 	}, nil
 }
 
@@ -40,21 +43,54 @@ func NewHeaders() []string {
 }
 
 // NewTlsConfig is a factory method to create a new basic Tls Config.
-// More attention should be given to the use of `InsecureSkipVerify: true`, as it is not recommended for production use.
+// If the config for the configured mode cannot be built, e.g. the certificates have not been loaded yet, a config that
+// verifies the server against the system roots is returned; verification is never silently skipped.
 func NewTlsConfig(settings *configuration.Settings) *tls.Config {
 	tlsConfig, err := authentication.NewTlsConfig(settings)
 	if err != nil {
-		logrus.Warnf("Failed to create TLS config: %v", err)
-		return &tls.Config{InsecureSkipVerify: true}
+		return fallbackTlsConfig(settings, err)
 	}
 
 	return tlsConfig
 }
 
-// NewTransport is a factory method to create a new basic Http Transport.
-func NewTransport(config *tls.Config) *netHttp.Transport {
-	transport := netHttp.DefaultTransport.(*netHttp.Transport)
+// fallbackTlsConfig returns the config used when the config for the configured mode cannot be built, see NewTlsConfig.
+func fallbackTlsConfig(settings *configuration.Settings, err error) *tls.Config {
+	logrus.Warnf("Failed to create TLS config, falling back to verifying against the system roots: %v", err)
+
+	return &tls.Config{
+		InsecureSkipVerify: false,
+		MinVersion:         settings.TlsMinVersion,
+		CipherSuites:       settings.TlsCipherSuites,
+	}
+}
+
+// NewTransport is a factory method to create a new basic Http Transport, configured by the HttpClientSettings.
+// The default Transport is cloned so that each TLS configuration gets its own connection pool.
+// The proxy is taken from the HTTPS_PROXY and NO_PROXY environment variables. HTTP/2 is negotiated through ALPN with the
+// hosts that support it, unless disabled, so the calls to such a host are multiplexed on a single connection.
+func NewTransport(settings *configuration.Settings, config *tls.Config) *netHttp.Transport {
+	dialer := &net.Dialer{
+		Timeout:   settings.HttpClient.DialTimeout,
+		KeepAlive: settings.HttpClient.KeepAlive,
+	}
+
+	transport := netHttp.DefaultTransport.(*netHttp.Transport).Clone()
 	transport.TLSClientConfig = config
+	transport.Proxy = netHttp.ProxyFromEnvironment
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = settings.HttpClient.TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = settings.HttpClient.ResponseHeaderTimeout
+	transport.IdleConnTimeout = settings.HttpClient.IdleConnTimeout
+	transport.MaxIdleConns = settings.HttpClient.MaxIdleConns
+	transport.MaxIdleConnsPerHost = settings.HttpClient.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = settings.HttpClient.MaxConnsPerHost
+	transport.ForceAttemptHTTP2 = settings.HttpClient.EnableHTTP2
+
+	// a non-nil, empty, TLSNextProto keeps the Transport from upgrading to HTTP/2
+	if !settings.HttpClient.EnableHTTP2 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) netHttp.RoundTripper)
+	}
 
 	return transport
 }
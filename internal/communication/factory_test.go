@@ -7,9 +7,13 @@ package communication
 
 import (
 	"context"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"k8s.io/client-go/kubernetes/fake"
+	netHttp "net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNewHttpClient(t *testing.T) {
@@ -50,7 +54,7 @@ func TestNewTransport(t *testing.T) {
 	k8sClient := fake.NewSimpleClientset()
 	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
 	config := NewTlsConfig(settings)
-	transport := NewTransport(config)
+	transport := NewTransport(settings, config)
 
 	if transport == nil {
 		t.Fatalf(`transport should not be nil`)
@@ -68,3 +72,69 @@ func TestNewTransport(t *testing.T) {
 		t.Fatalf(`transport.TLSClientConfig.InsecureSkipVerify should be true`)
 	}
 }
+
+func TestNewTlsConfig_FailureDoesNotSkipVerification(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	settings.TlsMode = configuration.SelfSignedTLS
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+
+	config := NewTlsConfig(settings)
+	if config.InsecureSkipVerify {
+		t.Fatalf(`config.InsecureSkipVerify should be false when the TLS config cannot be built`)
+	}
+}
+
+func TestNewTransport_UsesHttpClientSettings(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), nil)
+	settings.HttpClient.TLSHandshakeTimeout = time.Second * 3
+	settings.HttpClient.ResponseHeaderTimeout = time.Second * 4
+	settings.HttpClient.MaxIdleConnsPerHost = 2
+
+	transport := NewTransport(settings, NewTlsConfig(settings))
+
+	if transport.TLSHandshakeTimeout != time.Second*3 {
+		t.Fatalf(`expected a 3s TLS handshake timeout, got %v`, transport.TLSHandshakeTimeout)
+	}
+
+	if transport.ResponseHeaderTimeout != time.Second*4 {
+		t.Fatalf(`expected a 4s response header timeout, got %v`, transport.ResponseHeaderTimeout)
+	}
+
+	if transport.MaxIdleConnsPerHost != 2 {
+		t.Fatalf(`expected 2 idle connections per host, got %d`, transport.MaxIdleConnsPerHost)
+	}
+
+	if transport.Proxy == nil {
+		t.Fatalf(`expected the proxy to be taken from the environment`)
+	}
+}
+
+func TestNewTransport_NegotiatesHTTP2UnlessDisabled(t *testing.T) {
+	server := httptest.NewUnstartedServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, enabled := range []bool{true, false} {
+		settings, _ := configuration.NewSettings(context.Background(), nil)
+		settings.HttpClient.EnableHTTP2 = enabled
+		settings.HttpClient.MaxConnsPerHost = 4
+
+		transport := NewTransport(settings, NewTlsConfig(settings))
+		if transport.MaxConnsPerHost != 4 {
+			t.Fatalf(`expected 4 connections per host, got %d`, transport.MaxConnsPerHost)
+		}
+
+		response, err := (&netHttp.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+		response.Body.Close()
+		transport.CloseIdleConnections()
+
+		if expected := map[bool]int{true: 2, false: 1}[enabled]; response.ProtoMajor != expected {
+			t.Fatalf(`expected HTTP/%d with HTTP/2 enabled %t, got %s`, expected, enabled, response.Proto)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"fmt"
+	netHttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// readKind identifies the read-only NGINX Plus API calls, e.g. GET.
+	readKind = "read"
+
+	// writeKind identifies the NGINX Plus API calls that change the configuration, e.g. POST, PATCH, or DELETE.
+	writeKind = "write"
+)
+
+// hostLimiters are the rate limiters of an NGINX Plus host.
+type hostLimiters struct {
+	read  *rate.Limiter
+	write *rate.Limiter
+}
+
+// RateLimitingRoundTripper delays the NGINX Plus API calls over the limits of the HttpClientSettings before passing them
+// on to the wrapped RoundTripper. Each host has its own token buckets, and the read-only calls are limited separately from
+// the writes, so the diffing done by the reconciliation is not starved by the writes. A call is delayed until a token is
+// available, or fails if its context is done first.
+type RateLimitingRoundTripper struct {
+	RoundTripper netHttp.RoundTripper
+	settings     *configuration.Settings
+
+	// lock guards the limiters.
+	lock sync.Mutex
+
+	// limiters holds the rate limiters of each host, keyed by host:port.
+	limiters map[string]*hostLimiters
+}
+
+// NewRateLimitingRoundTripper is a factory method to create a new RateLimitingRoundTripper.
+func NewRateLimitingRoundTripper(settings *configuration.Settings, transport netHttp.RoundTripper) *RateLimitingRoundTripper {
+	return &RateLimitingRoundTripper{
+		RoundTripper: transport,
+		settings:     settings,
+		limiters:     make(map[string]*hostLimiters),
+	}
+}
+
+// RoundTrip waits for a token of the limiter of the host and kind of the request, and passes the request on.
+func (roundTripper *RateLimitingRoundTripper) RoundTrip(req *netHttp.Request) (*netHttp.Response, error) {
+	kind := writeKind
+	if req.Method == netHttp.MethodGet || req.Method == netHttp.MethodHead {
+		kind = readKind
+	}
+
+	limiter := roundTripper.limiterOf(req.URL.Host, kind)
+	if limiter == nil {
+		return roundTripper.RoundTripper.RoundTrip(req)
+	}
+
+	reservation := limiter.Reserve()
+
+	delay := reservation.Delay()
+	if delay > 0 {
+		instrumentation.ObserveApiThrottled(req.URL.Host, kind, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			reservation.Cancel()
+			return nil, fmt.Errorf(`the %s call to %s was throttled by the client-side rate limit: %w`, kind, req.URL.Host, req.Context().Err())
+		}
+	}
+
+	return roundTripper.RoundTripper.RoundTrip(req)
+}
+
+// limiterOf returns the limiter of the kind for the host, creating the limiters of the host if needed;
+// nil if the calls of the kind are not limited.
+func (roundTripper *RateLimitingRoundTripper) limiterOf(host string, kind string) *rate.Limiter {
+	roundTripper.lock.Lock()
+	defer roundTripper.lock.Unlock()
+
+	limiters, found := roundTripper.limiters[host]
+	if !found {
+		limiters = &hostLimiters{
+			read:  newLimiter(roundTripper.settings.HttpClient.ReadRateLimiter),
+			write: newLimiter(roundTripper.settings.HttpClient.WriteRateLimiter),
+		}
+		roundTripper.limiters[host] = limiters
+	}
+
+	if kind == readKind {
+		return limiters.read
+	}
+
+	return limiters.write
+}
+
+// newLimiter creates the token bucket of the settings, nil if the rate is zero.
+func newLimiter(settings configuration.RateLimiterSettings) *rate.Limiter {
+	if settings.Rate <= 0 {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(settings.Rate), settings.Burst)
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"context"
+	netHttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRateLimitingRoundTripper_DelaysTheWritesOverTheLimit(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	defer server.Close()
+
+	client := buildRateLimitingClient(t, configuration.RateLimiterSettings{Rate: 10, Burst: 1}, configuration.RateLimiterSettings{Rate: 1000, Burst: 10})
+	host := hostOf(t, server.URL)
+
+	start := time.Now()
+	sendRequest(t, client, netHttp.MethodPost, server.URL)
+	sendRequest(t, client, netHttp.MethodPost, server.URL)
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf(`expected the second write to be delayed, took %v`, elapsed)
+	}
+
+	if throttled := testutil.ToFloat64(instrumentation.ApiThrottled.WithLabelValues(host, writeKind)); throttled != 1 {
+		t.Fatalf(`expected a throttled write, got %v`, throttled)
+	}
+}
+
+func TestRateLimitingRoundTripper_LimitsTheReadsSeparately(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	defer server.Close()
+
+	client := buildRateLimitingClient(t, configuration.RateLimiterSettings{Rate: 0.01, Burst: 1}, configuration.RateLimiterSettings{Rate: 1000, Burst: 10})
+
+	sendRequest(t, client, netHttp.MethodDelete, server.URL)
+
+	// the write bucket is empty for the next hundred seconds, the reads are not affected
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		sendRequest(t, client, netHttp.MethodGet, server.URL)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf(`expected the reads not to wait for the writes, took %v`, elapsed)
+	}
+}
+
+func TestRateLimitingRoundTripper_FailsWhenTheContextIsDoneFirst(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	defer server.Close()
+
+	client := buildRateLimitingClient(t, configuration.RateLimiterSettings{Rate: 0.01, Burst: 1}, configuration.RateLimiterSettings{})
+	client.Timeout = 50 * time.Millisecond
+
+	sendRequest(t, client, netHttp.MethodPatch, server.URL)
+
+	request, _ := netHttp.NewRequestWithContext(context.Background(), netHttp.MethodPatch, server.URL, nil)
+	if response, err := client.Do(request); err == nil {
+		response.Body.Close()
+		t.Fatal(`expected the throttled write to fail once the timeout elapsed`)
+	}
+}
+
+func TestRateLimitingRoundTripper_ZeroRateDisablesTheLimit(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(netHttp.ResponseWriter, *netHttp.Request) {}))
+	defer server.Close()
+
+	client := buildRateLimitingClient(t, configuration.RateLimiterSettings{Rate: 0, Burst: 1}, configuration.RateLimiterSettings{Rate: 0, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		sendRequest(t, client, netHttp.MethodPost, server.URL)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf(`expected the writes not to be limited, took %v`, elapsed)
+	}
+}
+
+func buildRateLimitingClient(t *testing.T, write configuration.RateLimiterSettings, read configuration.RateLimiterSettings) *netHttp.Client {
+	settings, err := configuration.NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.HttpClient.WriteRateLimiter = write
+	settings.HttpClient.ReadRateLimiter = read
+
+	return &netHttp.Client{Transport: NewRateLimitingRoundTripper(settings, netHttp.DefaultTransport)}
+}
+
+func sendRequest(t *testing.T, client *netHttp.Client, method string, target string) {
+	request, err := netHttp.NewRequestWithContext(context.Background(), method, target, nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response.Body.Close()
+}
+
+func hostOf(t *testing.T, target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return parsed.Host
+}
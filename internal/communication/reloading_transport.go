@@ -0,0 +1,165 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/authentication"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadingTransport is a RoundTripper that rebuilds its underlying Transport when the TLS settings change.
+// The rebuild happens lazily on the first request after Invalidate is called; requests already in flight complete on
+// the Transport they started with, and a failed rebuild keeps the previous, working, Transport in place.
+// The requests to a host whose nginx-hosts entry sets a server name, see configuration.ServerNameSuffix, or the insecure
+// option, see configuration.InsecureSuffix, use a clone of the Transport whose tls.Config verifies the certificate of the host
+// against that name instead of the host of the URL, or does not verify it.
+type ReloadingTransport struct {
+
+	// settings is the configuration used to build the tls.Config.
+	settings *configuration.Settings
+
+	// current is the Transport used for new requests.
+	current atomic.Pointer[http.Transport]
+
+	// stale indicates the TLS settings have changed since the current Transport was built.
+	stale atomic.Bool
+
+	// lock guards hostTransports.
+	lock sync.Mutex
+
+	// hostTransports are the clones of the current Transport used for the hosts with a server name or the insecure option,
+	// by their hostTlsOptions.
+	hostTransports map[hostTlsOptions]hostTransport
+}
+
+// hostTlsOptions are the options of an nginx-hosts entry that change the tls.Config of the connections to the host.
+type hostTlsOptions struct {
+
+	// serverName is the name the certificate of the host is verified against, empty uses the host of the URL.
+	serverName string
+
+	// insecure skips the verification of the certificate of the host.
+	insecure bool
+}
+
+// hostTransport is a clone of a Transport whose tls.Config has the hostTlsOptions of a host applied.
+type hostTransport struct {
+
+	// base is the Transport the clone was made from; the clone is rebuilt when the current Transport changes.
+	base *http.Transport
+
+	// transport is the clone.
+	transport *http.Transport
+}
+
+// NewReloadingTransport is a factory method to create a new ReloadingTransport.
+// The initial Transport is built immediately; if the TLS config for the configured mode cannot be built yet the
+// verifying fallback from NewTlsConfig is used and the Transport is rebuilt on the next request.
+func NewReloadingTransport(settings *configuration.Settings) *ReloadingTransport {
+	transport := &ReloadingTransport{
+		settings:       settings,
+		hostTransports: make(map[hostTlsOptions]hostTransport),
+	}
+
+	tlsConfig, err := authentication.NewTlsConfig(settings)
+	if err != nil {
+		transport.stale.Store(true)
+		tlsConfig = fallbackTlsConfig(settings, err)
+	}
+
+	transport.current.Store(NewTransport(settings, tlsConfig))
+
+	return transport
+}
+
+// Invalidate marks the current Transport as stale; the next request will rebuild it.
+func (rt *ReloadingTransport) Invalidate() {
+	logrus.Debug("ReloadingTransport::Invalidate")
+	rt.stale.Store(true)
+}
+
+// RoundTrip rebuilds the Transport if needed, then passes the request on to it, or to its clone for the TLS options of the host.
+func (rt *ReloadingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if rt.stale.CompareAndSwap(true, false) {
+		rt.reload()
+	}
+
+	transport := rt.current.Load()
+
+	options := hostTlsOptions{
+		serverName: rt.settings.ServerName(request.URL.Host),
+		insecure:   rt.settings.InsecureSkipVerify(request.URL.Host),
+	}
+	if options != (hostTlsOptions{}) {
+		transport = rt.forHost(transport, options)
+	}
+
+	return transport.RoundTrip(request)
+}
+
+// forHost returns the clone of the Transport for the TLS options of a host, building it if the Transport has changed.
+// Only the server name, or the verification, changes; the other settings of the tls.Config, e.g. the client certificate, are kept.
+func (rt *ReloadingTransport) forHost(base *http.Transport, options hostTlsOptions) *http.Transport {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	current, found := rt.hostTransports[options]
+	if found && current.base == base {
+		return current.transport
+	}
+
+	if found {
+		current.transport.CloseIdleConnections()
+	}
+
+	transport := base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	if options.serverName != "" {
+		transport.TLSClientConfig.ServerName = options.serverName
+	}
+
+	if options.insecure {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		transport.TLSClientConfig.VerifyPeerCertificate = nil
+		transport.TLSClientConfig.VerifyConnection = nil
+	}
+
+	rt.hostTransports[options] = hostTransport{base: base, transport: transport}
+
+	logrus.Debugf("ReloadingTransport::forHost: Transport built for server name '%s', insecure: %t", options.serverName, options.insecure)
+
+	return transport
+}
+
+// reload builds a new Transport from the current settings and swaps it in; the clones for the hosts are dropped.
+func (rt *ReloadingTransport) reload() {
+	tlsConfig, err := authentication.NewTlsConfig(rt.settings)
+	if err != nil {
+		logrus.Errorf("ReloadingTransport::reload: failed to rebuild TLS config for mode '%s', keeping the previous config: %v", rt.settings.CurrentTlsMode(), err)
+		return
+	}
+
+	previous := rt.current.Swap(NewTransport(rt.settings, tlsConfig))
+	previous.CloseIdleConnections()
+
+	rt.lock.Lock()
+	for options, transport := range rt.hostTransports {
+		transport.transport.CloseIdleConnections()
+		delete(rt.hostTransports, options)
+	}
+	rt.lock.Unlock()
+
+	logrus.Infof("ReloadingTransport::reload: TLS config rebuilt for mode '%s'", rt.settings.CurrentTlsMode())
+}
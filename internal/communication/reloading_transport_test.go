@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	netHttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReloadingTransport_RebuildsAfterInvalidate(t *testing.T) {
+	settings := buildReloadingSettings(t)
+	transport := NewReloadingTransport(settings)
+	initial := transport.current.Load()
+
+	transport.Invalidate()
+	roundTrip(t, transport)
+
+	if transport.current.Load() == initial {
+		t.Fatalf(`expected the Transport to be rebuilt after Invalidate`)
+	}
+}
+
+func TestReloadingTransport_KeepsTransportUntilInvalidated(t *testing.T) {
+	settings := buildReloadingSettings(t)
+	transport := NewReloadingTransport(settings)
+	initial := transport.current.Load()
+
+	roundTrip(t, transport)
+
+	if transport.current.Load() != initial {
+		t.Fatalf(`expected the Transport to be reused`)
+	}
+}
+
+func TestReloadingTransport_FailedReloadKeepsPreviousTransport(t *testing.T) {
+	settings := buildReloadingSettings(t)
+	transport := NewReloadingTransport(settings)
+	initial := transport.current.Load()
+
+	// There is no CA certificate available, so building the self-signed TLS config fails.
+	settings.TlsMode = configuration.SelfSignedTLS
+	transport.Invalidate()
+	roundTrip(t, transport)
+
+	if transport.current.Load() != initial {
+		t.Fatalf(`expected the previous Transport to be kept after a failed reload`)
+	}
+}
+
+func TestReloadingTransport_VerifiesTheServerNameOfTheHost(t *testing.T) {
+	serverName := "plus-1.internal.example.com"
+	certificatePEM, certificate := generateServerCertificate(t, serverName)
+
+	server := httptest.NewUnstartedServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, _ *netHttp.Request) {
+		w.WriteHeader(netHttp.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	server.StartTLS()
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caPath, certificatePEM, 0o600); err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	settings := buildReloadingSettings(t)
+	settings.TlsMode = configuration.SelfSignedTLS
+	settings.CertificateFiles.SetPaths(certification.CertificatePaths{CaCertificate: caPath})
+	transport := NewReloadingTransport(settings)
+
+	// the server is reached through its IP address, which its certificate does not carry
+	settings.SetHosts([]string{server.URL + "/api"})
+	if err := get(transport, server.URL); err == nil {
+		t.Fatalf(`expected the certificate to be rejected for the IP address`)
+	}
+
+	settings.SetHosts([]string{server.URL + "/api" + configuration.ServerNameSuffix + serverName})
+	if err := get(transport, server.URL); err != nil {
+		t.Fatalf(`expected the certificate to be verified against %s: %v`, serverName, err)
+	}
+
+	settings.SetHosts([]string{server.URL + "/api" + configuration.ServerNameSuffix + "plus-2.internal.example.com"})
+	if err := get(transport, server.URL); err == nil {
+		t.Fatalf(`expected the certificate to be rejected for another server name`)
+	}
+}
+
+func TestReloadingTransport_SkipsTheVerificationOfTheInsecureHost(t *testing.T) {
+	server := httptest.NewTLSServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, _ *netHttp.Request) {
+		w.WriteHeader(netHttp.StatusOK)
+	}))
+	defer server.Close()
+
+	settings := buildReloadingSettings(t)
+	settings.TlsMode = configuration.CertificateAuthorityTLS
+	transport := NewReloadingTransport(settings)
+
+	// the certificate of the test server is self-signed
+	settings.SetHosts([]string{server.URL + "/api"})
+	if err := get(transport, server.URL); err == nil {
+		t.Fatalf(`expected the self-signed certificate to be rejected`)
+	}
+
+	settings.SetHosts([]string{server.URL + "/api" + configuration.InsecureSuffix + "true", "https://10.0.0.1:9000/api"})
+	if err := get(transport, server.URL); err != nil {
+		t.Fatalf(`expected the certificate of the insecure host not to be verified: %v`, err)
+	}
+
+	if settings.InsecureSkipVerify("10.0.0.1:9000") {
+		t.Fatalf(`expected the certificates of the other hosts to be verified`)
+	}
+}
+
+func buildReloadingSettings(t *testing.T) *configuration.Settings {
+	settings, err := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf(`Unexpected error creating settings: %v`, err)
+	}
+
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+
+	return settings
+}
+
+func roundTrip(t *testing.T, transport netHttp.RoundTripper) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, _ *netHttp.Request) {
+		w.WriteHeader(netHttp.StatusOK)
+	}))
+	defer server.Close()
+
+	request, err := netHttp.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	_ = response.Body.Close()
+}
+
+func get(transport netHttp.RoundTripper, url string) error {
+	request, err := netHttp.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// generateServerCertificate generates a self-signed server certificate that only carries the DNS name.
+func generateServerCertificate(t *testing.T, dnsName string) ([]byte, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf(`Unexpected error: %v`, err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
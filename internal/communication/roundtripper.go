@@ -18,7 +18,7 @@ type RoundTripper struct {
 }
 
 // NewRoundTripper is a factory method to create a new RoundTripper.
-func NewRoundTripper(headers []string, transport *netHttp.Transport) *RoundTripper {
+func NewRoundTripper(headers []string, transport netHttp.RoundTripper) *RoundTripper {
 	return &RoundTripper{
 		Headers:      headers,
 		RoundTripper: transport,
@@ -21,7 +21,7 @@ func TestNewRoundTripper(t *testing.T) {
 	k8sClient := fake.NewSimpleClientset()
 	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
 	headers := NewHeaders()
-	transport := NewTransport(NewTlsConfig(settings))
+	transport := NewTransport(settings, NewTlsConfig(settings))
 	roundTripper := NewRoundTripper(headers, transport)
 
 	if roundTripper == nil {
@@ -67,7 +67,7 @@ func TestRoundTripperRoundTrip(t *testing.T) {
 	}
 
 	headers := NewHeaders()
-	transport := NewTransport(NewTlsConfig(settings))
+	transport := NewTransport(settings, NewTlsConfig(settings))
 	roundTripper := NewRoundTripper(headers, transport)
 
 	// Use the mock server URL
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	netHttp "net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingRoundTripper adds the trace headers of the span of the request context, e.g. traceparent, to each request before
+// passing it on to the wrapped RoundTripper, so the NGINX Plus API calls can be correlated with the traces of the events.
+// The headers are only added while tracing is enabled, see instrumentation.StartTracing.
+type TracingRoundTripper struct {
+	RoundTripper netHttp.RoundTripper
+}
+
+// NewTracingRoundTripper is a factory method to create a new TracingRoundTripper.
+func NewTracingRoundTripper(transport netHttp.RoundTripper) *TracingRoundTripper {
+	return &TracingRoundTripper{
+		RoundTripper: transport,
+	}
+}
+
+// RoundTrip adds the trace headers, if the request is part of a trace, and passes the request on.
+func (roundTripper *TracingRoundTripper) RoundTrip(req *netHttp.Request) (*netHttp.Response, error) {
+	if !trace.SpanContextFromContext(req.Context()).IsValid() {
+		return roundTripper.RoundTripper.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	return roundTripper.RoundTripper.RoundTrip(req)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package communication
+
+import (
+	"context"
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingRoundTripper_AddsTheTraceHeaders(t *testing.T) {
+	var traceparents []string
+	server := httptest.NewServer(netHttp.HandlerFunc(func(_ netHttp.ResponseWriter, request *netHttp.Request) {
+		traceparents = append(traceparents, request.Header.Get("traceparent"))
+	}))
+	defer server.Close()
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previous)
+
+	client := &netHttp.Client{Transport: NewTracingRoundTripper(netHttp.DefaultTransport)}
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	for _, ctx := range []context.Context{trace.ContextWithSpanContext(context.Background(), spanContext), context.Background()} {
+		request, _ := netHttp.NewRequestWithContext(ctx, netHttp.MethodGet, server.URL, nil)
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+
+		_ = response.Body.Close()
+	}
+
+	if traceparents[0] != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf(`expected the traceparent of the span, got %q`, traceparents[0])
+	}
+
+	if traceparents[1] != "" {
+		t.Errorf(`expected no traceparent outside of a trace, got %q`, traceparents[1])
+	}
+}
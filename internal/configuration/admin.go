@@ -0,0 +1,202 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAdminAddress is the default address of the admin server, only reachable from within the pod.
+const DefaultAdminAddress = "127.0.0.1:6060"
+
+// AdminSettings contains the configuration values needed by the admin server, which serves the pprof handlers and
+// runtime diagnostics.
+type AdminSettings struct {
+
+	// Enabled turns on the admin server; it is disabled by default.
+	Enabled bool
+
+	// Address is the host and port the admin server listens on, localhost by default so it is not reachable from other pods.
+	Address string
+}
+
+// logFields returns the settings of the admin server as log fields.
+func (s AdminSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"enabled": s.Enabled,
+		"address": s.Address,
+	}
+}
+
+// RedactedSettings is the view of the Settings served by the admin server. It holds no secret: the TLS and API
+// credential Secrets are listed by name only, and the passwords in the nginx-hosts URLs are redacted.
+// Durations are in nanoseconds, as they are encoded by encoding/json.
+type RedactedSettings struct {
+	ConfigMapNamespace   string                    `json:"configMapNamespace"`
+	ConfigMapName        string                    `json:"configMapName"`
+	ConfigFilePath       string                    `json:"configFilePath,omitempty"`
+	NginxPlusHosts       []string                  `json:"nginxPlusHosts"`
+	SecondaryHosts       []string                  `json:"secondaryHosts,omitempty"`
+	HostsSrv             string                    `json:"hostsSrv,omitempty"`
+	HostsSrvInterval     time.Duration             `json:"hostsSrvInterval"`
+	HostsSrvRemovalDelay time.Duration             `json:"hostsSrvRemovalDelay"`
+	HostsRetention       time.Duration             `json:"hostsRetention"`
+	LogFormat            string                    `json:"logFormat"`
+	LogLevel             string                    `json:"logLevel"`
+	DryRun               bool                      `json:"dryRun"`
+	TlsMode              string                    `json:"tlsMode"`
+	TlsMinVersion        string                    `json:"tlsMinVersion"`
+	TlsCipherSuites      []string                  `json:"tlsCipherSuites,omitempty"`
+	CrlExpiredPolicy     string                    `json:"crlExpiredPolicy"`
+	Secrets              RedactedSecrets           `json:"secrets"`
+	Handler              HandlerSettings           `json:"handler"`
+	Synchronizer         SynchronizerSettings      `json:"synchronizer"`
+	Watcher              RedactedWatcher           `json:"watcher"`
+	LeaderElection       LeaderElectionSettings    `json:"leaderElection"`
+	KubeApi              KubeApiSettings           `json:"kubeApi"`
+	Readiness            ReadinessSettings         `json:"readiness"`
+	HttpClient           HttpClientSettings        `json:"httpClient"`
+	Admin                AdminSettings             `json:"admin"`
+	Tracing              TracingSettings           `json:"tracing"`
+	CertificateExpiry    CertificateExpirySettings `json:"certificateExpiry"`
+}
+
+// RedactedSecrets names the Secrets and the certificate files set by the ConfigMap, never their contents.
+type RedactedSecrets struct {
+	CaCertificate         string `json:"caCertificate,omitempty"`
+	ClientCertificate     string `json:"clientCertificate,omitempty"`
+	ApiAuth               string `json:"apiAuth,omitempty"`
+	CaCertificatePath     string `json:"caCertificatePath,omitempty"`
+	ClientCertificatePath string `json:"clientCertificatePath,omitempty"`
+	ClientKeyPath         string `json:"clientKeyPath,omitempty"`
+	CaCrlPath             string `json:"caCrlPath,omitempty"`
+}
+
+// RedactedWatcher is the view of the WatcherSettings, with the label selectors as strings.
+type RedactedWatcher struct {
+	NginxIngressNamespaces      []string      `json:"nginxIngressNamespaces"`
+	ServiceSelector             string        `json:"serviceSelector"`
+	UpstreamNameTemplate        string        `json:"upstreamNameTemplate"`
+	ResyncPeriod                time.Duration `json:"resyncPeriod"`
+	DrainTimeout                time.Duration `json:"drainTimeout"`
+	DeletedNodeDrainTimeout     time.Duration `json:"deletedNodeDrainTimeout"`
+	DrainedConnectionsThreshold int           `json:"drainedConnectionsThreshold"`
+	NotReadyGracePeriod         time.Duration `json:"notReadyGracePeriod"`
+	TargetMode                  string        `json:"targetMode"`
+	NodeSelector                string        `json:"nodeSelector"`
+	BackupNodeSelector          string        `json:"backupNodeSelector"`
+	AddressFamily               string        `json:"addressFamily"`
+	NodeAddressTypes            []string      `json:"nodeAddressTypes"`
+	ExcludeControlPlaneNodes    bool          `json:"excludeControlPlaneNodes"`
+	ExcludedTaintKeys           []string      `json:"excludedTaintKeys"`
+}
+
+// Redacted returns the current Settings without their secrets, for the admin server.
+func (s *Settings) Redacted() *RedactedSettings {
+	hosts := s.Hosts()
+
+	redacted := &RedactedSettings{
+		ConfigMapNamespace:   s.ConfigMapNamespace,
+		ConfigMapName:        s.ConfigMapName,
+		ConfigFilePath:       s.ConfigFilePath,
+		NginxPlusHosts:       make([]string, 0, len(hosts)),
+		HostsSrv:             s.hostsDiscovery.current().name,
+		HostsSrvInterval:     s.HostsSrvInterval,
+		HostsSrvRemovalDelay: s.HostsSrvRemovalDelay,
+		HostsRetention:       s.HostsRetention,
+		LogFormat:            s.LogFormat,
+		LogLevel:             s.LogLevel.String(),
+		DryRun:               s.IsDryRun(),
+		TlsMode:              s.CurrentTlsMode().String(),
+		TlsMinVersion:        tlsVersionName(s.TlsMinVersion),
+		TlsCipherSuites:      cipherSuiteNames(s.TlsCipherSuites),
+		CrlExpiredPolicy:     CrlFailClosed,
+		Handler:              s.Handler,
+		Synchronizer:         s.Synchronizer,
+		Watcher: RedactedWatcher{
+			NginxIngressNamespaces:      s.Watcher.NginxIngressNamespaces,
+			ServiceSelector:             s.Watcher.ServiceSelector.String(),
+			UpstreamNameTemplate:        s.Watcher.UpstreamNameTemplate,
+			ResyncPeriod:                s.Watcher.ResyncPeriod,
+			DrainTimeout:                s.Watcher.DrainTimeout,
+			DeletedNodeDrainTimeout:     s.Watcher.DeletedNodeDrainTimeout,
+			DrainedConnectionsThreshold: s.Watcher.DrainedConnectionsThreshold,
+			NotReadyGracePeriod:         s.Watcher.NotReadyGracePeriod,
+			TargetMode:                  s.Watcher.TargetMode,
+			NodeSelector:                s.Watcher.NodeSelector.String(),
+			BackupNodeSelector:          selectorString(s.Watcher.BackupNodeSelector),
+			AddressFamily:               s.Watcher.AddressFamily,
+			ExcludeControlPlaneNodes:    s.Watcher.ExcludeControlPlaneNodes,
+			ExcludedTaintKeys:           s.Watcher.ExcludedTaintKeys,
+		},
+		LeaderElection:    s.LeaderElection,
+		KubeApi:           s.KubeApi,
+		Readiness:         s.Readiness,
+		HttpClient:        s.HttpClient,
+		Admin:             s.Admin,
+		Tracing:           s.Tracing,
+		CertificateExpiry: s.CertificateExpiry,
+	}
+
+	if s.CrlFailOpen {
+		redacted.CrlExpiredPolicy = CrlFailOpen
+	}
+
+	for _, host := range hosts {
+		redacted.NginxPlusHosts = append(redacted.NginxPlusHosts, RedactHost(host))
+
+		if s.IsSecondaryHost(host) {
+			redacted.SecondaryHosts = append(redacted.SecondaryHosts, RedactHost(host))
+		}
+	}
+
+	for _, addressType := range s.Watcher.NodeAddressTypes {
+		redacted.Watcher.NodeAddressTypes = append(redacted.Watcher.NodeAddressTypes, string(addressType))
+	}
+
+	if s.Certificates != nil {
+		caCertificate, clientCertificate, apiAuth := s.Certificates.SecretKeys()
+		redacted.Secrets = RedactedSecrets{
+			CaCertificate:     caCertificate,
+			ClientCertificate: clientCertificate,
+			ApiAuth:           apiAuth,
+		}
+	}
+
+	if s.CertificateFiles != nil {
+		paths := s.CertificateFiles.Paths()
+		redacted.Secrets.CaCertificatePath = paths.CaCertificate
+		redacted.Secrets.ClientCertificatePath = paths.ClientCertificate
+		redacted.Secrets.ClientKeyPath = paths.ClientKey
+		redacted.Secrets.CaCrlPath = paths.CaCrl
+	}
+
+	return redacted
+}
+
+// RedactHost replaces the password of a host URL with "xxxxx", see core.RedactHost.
+func RedactHost(host string) string {
+	return core.RedactHost(host)
+}
+
+// RedactHostsIn replaces each of the hosts found in the message with the host redacted by RedactHost, see core.RedactHostsIn.
+func RedactHostsIn(message string, hosts []string) string {
+	return core.RedactHostsIn(message, hosts)
+}
+
+// validateAdminAddress returns an error if the address is not a host and port, e.g. "127.0.0.1:6060".
+func validateAdminAddress(address string) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf(`admin address must be a host and port, e.g. %s: %w`, DefaultAdminAddress, err)
+	}
+
+	return nil
+}
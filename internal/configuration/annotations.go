@@ -0,0 +1,86 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+const (
+	// WeightAnnotation is the Service Annotation suffix used to set the weight of the upstream servers, e.g.:
+	//   nginxinc.io/weight: "2"           applies to all the ports of the Service
+	//   nginxinc.io/nlk-http.weight: "2"   applies to the nlk-http port only, and takes precedence
+	WeightAnnotation = "weight"
+
+	// MaxFailsAnnotation is the Service Annotation suffix used to set the max_fails of the upstream servers.
+	MaxFailsAnnotation = "max-fails"
+
+	// FailTimeoutAnnotation is the Service Annotation suffix used to set the fail_timeout of the upstream servers.
+	FailTimeoutAnnotation = "fail-timeout"
+
+	// RouteTemplateAnnotation is the Service Annotation suffix used to set the route of the HTTP upstream servers, used for
+	// sticky routing. The {node} and {address} placeholders are replaced with the name and address of the node of each
+	// server, e.g.: nginxinc.io/route-template: "{node}"
+	RouteTemplateAnnotation = "route-template"
+
+	// ServiceAnnotation is the Service Annotation suffix used to set the service of the HTTP upstream servers.
+	ServiceAnnotation = "service"
+
+	// SlowStartAnnotation is the Service Annotation suffix used to set the slow_start of the HTTP upstream servers, the time
+	// over which the weight of a server added to the upstream recovers from zero, e.g.: nginxinc.io/slow-start: "30s"
+	SlowStartAnnotation = "slow-start"
+
+	// LoadBalancerIngressIpsAnnotation is the annotation of a Service of type LoadBalancer listing, comma-separated, the IPs
+	// written to its status.loadBalancer.ingress once its upstreams are synced, e.g. the virtual IPs of the NGINX Plus hosts;
+	// it overrides SynchronizerSettings::LoadBalancerIngressIps.
+	LoadBalancerIngressIpsAnnotation = "nkl.nginx.com/lb-ingress-ips"
+
+	// UseHostPortsAnnotation is the annotation of a Service whose upstream servers are on the targetPort of each port rather
+	// than its nodePort, e.g. the Service of an NGINX Ingress Controller DaemonSet declaring hostPorts equal to its
+	// containerPorts: nkl.nginx.com/use-host-ports: "true". A named targetPort is resolved with the EndpointSlices of the
+	// Service, in the TargetModeEndpointSlices.
+	UseHostPortsAnnotation = "nkl.nginx.com/use-host-ports"
+
+	// UpstreamMapAnnotation is the Service Annotation suffix used to map port names to upstream names, e.g.:
+	//   nginxinc.io/upstream-map: "http=prod-http-upstream,https=prod-https-upstream"
+	UpstreamMapAnnotation = "upstream-map"
+
+	// PortsAnnotation is the Service Annotation suffix used to map Service port numbers to upstream names and client types,
+	// for ports whose names cannot follow the NlkPrefix convention, e.g.:
+	//   nginxinc.io/ports: "8443:my-tls-upstream:stream,8080:my-http-upstream:http"
+	PortsAnnotation = "ports"
+
+	// KeyValZoneAnnotation is the Service Annotation suffix naming the NGINX Plus key-value zone in which the node addresses
+	// of the upstream servers are written as keys, e.g. for an allow-list: nginxinc.io/keyval-zone: "allowed_nodes";
+	// the "stream:" prefix names a zone of the stream context, e.g. "stream:allowed_nodes".
+	KeyValZoneAnnotation = "keyval-zone"
+
+	// KeyValZoneStreamPrefix is the prefix of the KeyValZoneAnnotation naming a zone of the stream context.
+	KeyValZoneStreamPrefix = "stream:"
+
+	// EmptyServerPolicyAnnotation is the Service Annotation suffix used to override the EmptyServerPolicy of the upstreams
+	// of the Service, or of the upstream of a single port, e.g.: nginxinc.io/nlk-http.empty-server-policy: "retain"
+	EmptyServerPolicyAnnotation = "empty-server-policy"
+
+	// DrainOnCordonAnnotation is the Service Annotation suffix used to drain the upstream servers of unschedulable nodes,
+	// which are kept otherwise, and those of NotReady nodes, rather than remove them, e.g.: nginxinc.io/drain-on-cordon: "true"
+	DrainOnCordonAnnotation = "drain-on-cordon"
+
+	// IgnoreAnnotation is the Service Annotation suffix used to opt a Service out of synchronization, e.g. a metrics or
+	// admission webhook Service whose port names match the NlkPrefix: nginxinc.io/ignore: "true"
+	IgnoreAnnotation = "ignore"
+
+	// LastSyncedAnnotation is the Service Annotation suffix written by NLK with the time of the last successful sync
+	// of the Service, in RFC 3339 format, see SynchronizerSettings::StatusAnnotationInterval.
+	LastSyncedAnnotation = "last-synced"
+
+	// SyncedHostsAnnotation is the Service Annotation suffix written by NLK with the number of NGINX Plus hosts each upstream
+	// of the Service was synced to, e.g.: nginxinc.io/synced-hosts: "nginx-ingress-http=2,nginx-ingress-https=2"
+	SyncedHostsAnnotation = "synced-hosts"
+
+	// ServerCountAnnotation is the Service Annotation suffix written by NLK with the number of servers of each upstream.
+	ServerCountAnnotation = "server-count"
+
+	// SyncErrorAnnotation is the Service Annotation suffix written by NLK with the errors of the upstreams of the Service
+	// that did not converge after RetryCount attempts; it is removed once they are synced.
+	SyncErrorAnnotation = "sync-error"
+)
@@ -0,0 +1,25 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// validateBorderType returns an error if the border type is neither the NGINX Plus type nor a registered one,
+// see core.RegisterBorderType.
+func validateBorderType(borderType string) error {
+	borderTypes := core.BorderTypes()
+	if !slices.Contains(borderTypes, borderType) {
+		return fmt.Errorf(`border type %q is unknown, expected one of %s`, borderType, strings.Join(borderTypes, ", "))
+	}
+
+	return nil
+}
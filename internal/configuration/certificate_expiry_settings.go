@@ -0,0 +1,30 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CertificateExpirySettings contains the configuration values needed to warn ahead of the expiry of the certificates.
+type CertificateExpirySettings struct {
+
+	// Warnings are the durations before the expiry of a certificate at which a warning is logged, the smallest as an error.
+	Warnings []time.Duration
+
+	// CheckInterval is the interval between the checks of the expiry, which is also checked each time the Secrets change.
+	CheckInterval time.Duration
+}
+
+// logFields returns the settings of the expiry warnings as log fields.
+func (s CertificateExpirySettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"warnings":      s.Warnings,
+		"checkInterval": s.CheckInterval,
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+)
+
+const (
+	// CaCertificatePathKey is the ConfigMap key naming a mounted file holding the CA certificate(s), instead of ca-certificate.
+	CaCertificatePathKey = "ca-certificate-path"
+
+	// ClientCertificatePathKey is the ConfigMap key naming a mounted file holding the client certificate, instead of client-certificate.
+	ClientCertificatePathKey = "client-certificate-path"
+
+	// ClientKeyPathKey is the ConfigMap key naming a mounted file holding the client key, it is required with client-certificate-path.
+	ClientKeyPathKey = "client-key-path"
+)
+
+// CertificateSource returns the source of the certificates and keys used to build a tls.Config: the mounted files when
+// any of the path keys is set in the ConfigMap, the Secrets otherwise.
+func (s *Settings) CertificateSource() certification.CertificateSource {
+	if s.usesCertificateFiles() {
+		return s.CertificateFiles
+	}
+
+	return s.Certificates
+}
+
+// usesCertificateFiles returns true if the certificates are read from the mounted files rather than the Secrets.
+func (s *Settings) usesCertificateFiles() bool {
+	return s.CertificateFiles != nil && s.CertificateFiles.IsConfigured()
+}
+
+// applyCertificatePaths sets the paths of the mounted certificate files from the ConfigMap, and returns true if they have
+// changed. Invalid paths are not applied, the current paths are kept.
+func (s *Settings) applyCertificatePaths(configMap *corev1.ConfigMap) bool {
+	if s.CertificateFiles == nil {
+		return false
+	}
+
+	paths := certification.CertificatePaths{
+		CaCertificate:     configMap.Data[CaCertificatePathKey],
+		ClientCertificate: configMap.Data[ClientCertificatePathKey],
+		ClientKey:         configMap.Data[ClientKeyPathKey],
+		CaCrl:             configMap.Data[CaCrlPathKey],
+	}
+
+	if err := validateCertificatePaths(paths); err != nil {
+		logrus.Errorf("Settings::applyCertificatePaths: the certificate paths have NOT been changed: %v", err)
+		s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the certificate paths have not been changed: %v", err))
+		return false
+	}
+
+	if !paths.IsEmpty() && (s.Certificates.CaCertificateSecretKey != "" || s.Certificates.ClientCertificateSecretKey != "") {
+		logrus.Warnf("Settings::applyCertificatePaths: both the certificate Secrets and the certificate paths are set, using the paths")
+	}
+
+	return s.CertificateFiles.SetPaths(paths)
+}
+
+// validateCertificatePaths returns an error if a path is not absolute, or if only one of the client certificate and key is set.
+func validateCertificatePaths(paths certification.CertificatePaths) error {
+	for key, path := range map[string]string{
+		CaCertificatePathKey:     paths.CaCertificate,
+		ClientCertificatePathKey: paths.ClientCertificate,
+		ClientKeyPathKey:         paths.ClientKey,
+		CaCrlPathKey:             paths.CaCrl,
+	} {
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf(`%s must be an absolute path, got %q`, key, path)
+		}
+	}
+
+	if (paths.ClientCertificate == "") != (paths.ClientKey == "") {
+		return fmt.Errorf(`%s and %s must be set together`, ClientCertificatePathKey, ClientKeyPathKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettings_CertificatePathsSelectTheFileSource(t *testing.T) {
+	settings := buildSettings(t)
+	directory := t.TempDir()
+
+	for _, name := range []string{"bundle.0.pem", "svid.0.pem", "svid.0.key"} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(name), 0o600); err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+	}
+
+	notifications := 0
+	settings.SubscribeToTlsChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = CertificateAuthorityMutualTLSString
+	configMap.Data["client-certificate"] = "nlk-tls-client-secret"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CertificateSource() != settings.Certificates {
+		t.Fatalf(`expected the Secrets to be used without the path keys`)
+	}
+
+	configMap.Data[ClientCertificatePathKey] = filepath.Join(directory, "svid.0.pem")
+	configMap.Data[ClientKeyPathKey] = filepath.Join(directory, "svid.0.key")
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CertificateSource() != settings.CertificateFiles {
+		t.Fatalf(`expected the files to be used once the path keys are set`)
+	}
+
+	if key, certificate := settings.CertificateSource().GetClientCertificate(); string(key) != "svid.0.key" || string(certificate) != "svid.0.pem" {
+		t.Fatalf(`expected the client certificate and key to be read from the files, got %q and %q`, certificate, key)
+	}
+
+	if secrets := settings.requiredSecrets(); len(secrets) != 0 {
+		t.Fatalf(`expected no Secret to be required with the files, got %v`, secrets)
+	}
+
+	if notifications != 2 {
+		t.Fatalf(`expected a notification for the mode and one for the paths, got %d`, notifications)
+	}
+
+	// the client certificate and key must be set together, the current paths are kept
+	delete(configMap.Data, ClientKeyPathKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CertificateFiles.Paths().ClientKey == "" || notifications != 2 {
+		t.Fatalf(`expected the current paths to be kept, got %v`, settings.CertificateFiles.Paths())
+	}
+
+	delete(configMap.Data, ClientCertificatePathKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CertificateSource() != settings.Certificates || notifications != 3 {
+		t.Fatalf(`expected the Secrets to be used once the path keys are removed`)
+	}
+}
+
+func TestValidateCertificatePaths(t *testing.T) {
+	invalid := map[string]map[string]string{
+		"relative CA path":         {CaCertificatePathKey: "bundle.0.pem"},
+		"client certificate alone": {ClientCertificatePathKey: "/run/spiffe/svid.0.pem"},
+		"client key alone":         {ClientKeyPathKey: "/run/spiffe/svid.0.key"},
+		"relative client key path": {ClientCertificatePathKey: "/run/spiffe/svid.0.pem", ClientKeyPathKey: "svid.0.key"},
+	}
+
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+			for key, value := range data {
+				configMap.Data[key] = value
+			}
+
+			settings := buildSettings(t)
+			if settings.applyCertificatePaths(configMap) || settings.CertificateFiles.IsConfigured() {
+				t.Errorf(`expected the paths %v to be rejected`, data)
+			}
+		})
+	}
+}
+
+func TestSettings_InitializePinnedModeRequiresCaFileWithTheFiles(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = CertificateAuthorityPinnedMutualTLSString
+	configMap.Data[ClientCertificatePathKey] = "/run/spiffe/svid.0.pem"
+	configMap.Data[ClientKeyPathKey] = "/run/spiffe/svid.0.key"
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error when the ca-certificate-path key is missing`)
+	}
+
+	configMap.Data[CaCertificatePathKey] = "/run/spiffe/bundle.0.pem"
+
+	if err := initializeSettings(t, configMap); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
@@ -0,0 +1,567 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigFileKey is the ConfigMap key that may hold a structured configuration document.
+const ConfigFileKey = "config.yaml"
+
+// ConfigFile is the structured configuration document that may be provided in the ConfigMap under ConfigFileKey,
+// or mounted as a file and named with the --config-file flag. Values that are present override the defaults;
+// values that are omitted leave the current settings unchanged. For example:
+//
+//	nginx-hosts:
+//	  - https://10.0.0.1:9000/api
+//	  - https://10.0.0.2:9000/api
+//	nginx-hosts-secondary:
+//	  - https://10.0.1.1:9000/api
+//	nginx-hosts-srv: _plusapi._tcp.lb.example.com
+//	synchronizer:
+//	  threads: 4
+//	  work-queue:
+//	    rate-limiter-base: 500ms
+type ConfigFile struct {
+
+	// NginxHosts is the list of NGINX Plus hosts, replacing the comma-separated nginx-hosts ConfigMap key.
+	// Each host is an entry or an object, see NginxHostList.
+	NginxHosts NginxHostList `json:"nginx-hosts,omitempty"`
+
+	// NginxHostsSecondary is the list of secondary NGINX Plus hosts, replacing the nginx-hosts-secondary ConfigMap key.
+	// They are kept in sync like the other hosts, but their failures do not affect the readiness.
+	NginxHostsSecondary NginxHostList `json:"nginx-hosts-secondary,omitempty"`
+
+	// NginxHostsSrv names a DNS SRV record whose targets are NGINX Plus hosts, merged with the other hosts; it replaces
+	// the nginx-hosts-srv ConfigMap key, see parseHostsSrv.
+	NginxHostsSrv string `json:"nginx-hosts-srv,omitempty"`
+
+	// HostsRetention overrides Settings::HostsRetention.
+	HostsRetention *metav1.Duration `json:"hosts-retention,omitempty"`
+
+	// Handler overrides the HandlerSettings.
+	Handler *HandlerConfig `json:"handler,omitempty"`
+
+	// Synchronizer overrides the SynchronizerSettings.
+	Synchronizer *SynchronizerConfig `json:"synchronizer,omitempty"`
+
+	// Watcher overrides the WatcherSettings.
+	Watcher *WatcherConfig `json:"watcher,omitempty"`
+}
+
+// WorkQueueConfig overrides the WorkQueueSettings.
+type WorkQueueConfig struct {
+	RateLimiterBase *metav1.Duration `json:"rate-limiter-base,omitempty"`
+	RateLimiterMax  *metav1.Duration `json:"rate-limiter-max,omitempty"`
+}
+
+// HandlerConfig overrides the HandlerSettings.
+type HandlerConfig struct {
+	RetryCount     *int             `json:"retry-count,omitempty"`
+	Threads        *int             `json:"threads,omitempty"`
+	WorkQueue      *WorkQueueConfig `json:"work-queue,omitempty"`
+	DeleteDeferral *metav1.Duration `json:"delete-deferral,omitempty"`
+}
+
+// SynchronizerConfig overrides the SynchronizerSettings.
+type SynchronizerConfig struct {
+	BorderType                   *string          `json:"border-type,omitempty"`
+	MaxMillisecondsJitter        *int             `json:"max-jitter-ms,omitempty"`
+	MinMillisecondsJitter        *int             `json:"min-jitter-ms,omitempty"`
+	RetryCount                   *int             `json:"retry-count,omitempty"`
+	Threads                      *int             `json:"threads,omitempty"`
+	WorkQueue                    *WorkQueueConfig `json:"work-queue,omitempty"`
+	CoalesceWindow               *metav1.Duration `json:"coalesce-window,omitempty"`
+	Prune                        *bool            `json:"prune,omitempty"`
+	OwnershipTag                 *string          `json:"ownership-tag,omitempty"`
+	ForcePrune                   *bool            `json:"force-prune,omitempty"`
+	ReconcileInterval            *metav1.Duration `json:"reconcile-interval,omitempty"`
+	HostStagger                  *metav1.Duration `json:"host-stagger,omitempty"`
+	EmptyServerPolicy            *string          `json:"empty-server-policy,omitempty"`
+	DriftPolicy                  *string          `json:"drift-policy,omitempty"`
+	ServerAdmissionMaxWait       *metav1.Duration `json:"server-admission-max-wait,omitempty"`
+	ServerAdmissionPolicy        *string          `json:"server-admission-policy,omitempty"`
+	LoadBalancerIngressIps       []string         `json:"lb-ingress-ips,omitempty"`
+	DnsServiceName               *string          `json:"dns-service-name,omitempty"`
+	DnsServiceNamespace          *string          `json:"dns-service-namespace,omitempty"`
+	MissingUpstreamRetryInterval *metav1.Duration `json:"missing-upstream-retry-interval,omitempty"`
+	UpstreamTimeout              *metav1.Duration `json:"upstream-timeout,omitempty"`
+	ErrorLogWindow               *metav1.Duration `json:"error-log-window,omitempty"`
+	ConvergenceWarningThreshold  *metav1.Duration `json:"convergence-warning-threshold,omitempty"`
+	CircuitBreakerThreshold      *int             `json:"circuit-breaker-threshold,omitempty"`
+	CircuitBreakerBackoff        *metav1.Duration `json:"circuit-breaker-backoff,omitempty"`
+	CircuitBreakerMaxBackoff     *metav1.Duration `json:"circuit-breaker-max-backoff,omitempty"`
+	PersistState                 *bool            `json:"persist-state,omitempty"`
+	StateConfigMapName           *string          `json:"state-configmap-name,omitempty"`
+	StatePersistDebounce         *metav1.Duration `json:"state-persist-debounce,omitempty"`
+	StatusAnnotationInterval     *metav1.Duration `json:"status-annotation-interval,omitempty"`
+	StatusConfigMapName          *string          `json:"status-configmap-name,omitempty"`
+	StatusConfigMapInterval      *metav1.Duration `json:"status-configmap-interval,omitempty"`
+}
+
+// WatcherConfig overrides the WatcherSettings.
+type WatcherConfig struct {
+	NginxIngressNamespace       *string          `json:"nginx-ingress-namespace,omitempty"`
+	ServiceSelector             *string          `json:"service-selector,omitempty"`
+	UpstreamNameTemplate        *string          `json:"upstream-name-template,omitempty"`
+	ResyncPeriod                *metav1.Duration `json:"resync-period,omitempty"`
+	CacheSyncTimeout            *metav1.Duration `json:"cache-sync-timeout,omitempty"`
+	DrainTimeout                *metav1.Duration `json:"drain-timeout,omitempty"`
+	DeletedNodeDrainTimeout     *metav1.Duration `json:"deleted-node-drain-timeout,omitempty"`
+	DrainedConnectionsThreshold *int             `json:"drained-connections-threshold,omitempty"`
+	NotReadyGracePeriod         *metav1.Duration `json:"not-ready-grace-period,omitempty"`
+	TargetMode                  *string          `json:"target-mode,omitempty"`
+	RbacMode                    *string          `json:"rbac-mode,omitempty"`
+	AddressFamily               *string          `json:"address-family,omitempty"`
+	NodeAddressType             *string          `json:"node-address-type,omitempty"`
+	NodeSelector                *string          `json:"node-selector,omitempty"`
+	BackupNodeSelector          *string          `json:"backup-node-selector,omitempty"`
+	ExcludeControlPlaneNodes    *bool            `json:"exclude-control-plane-nodes,omitempty"`
+	ExcludedTaintKeys           []string         `json:"excluded-taint-keys,omitempty"`
+}
+
+// parseConfigFile parses a structured configuration document; unknown keys are rejected to catch typos.
+func parseConfigFile(data []byte) (*ConfigFile, error) {
+	config := &ConfigFile{}
+
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf(`error parsing the configuration document: %w`, err)
+	}
+
+	return config, nil
+}
+
+// readConfigFile reads and parses the configuration document at the given path.
+func readConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(`error reading the configuration file %s: %w`, path, err)
+	}
+
+	return parseConfigFile(data)
+}
+
+// setsNamespaces determines whether the configuration document sets the watched namespaces; a nil document sets none.
+func (c *ConfigFile) setsNamespaces() bool {
+	return c != nil && c.Watcher != nil && c.Watcher.NginxIngressNamespace != nil
+}
+
+// applyConfigFile overrides the HostsRetention, Handler, Synchronizer, and Watcher settings with the values in the configuration document.
+// The settings are only changed if all the values are valid.
+// NOTE: thread counts, work queue settings, the reconcile interval, the cache sync timeout, the target mode, the node and
+// service selectors, and the upstream name template are read at startup; changing them at runtime has no effect until restart. The watched namespaces are applied at runtime.
+func (s *Settings) applyConfigFile(config *ConfigFile) error {
+	hostsRetention := s.HostsRetention
+	handler := s.Handler
+	synchronizer := s.Synchronizer
+	watcher := s.Watcher
+
+	if _, err := parseHostsSrv(config.NginxHostsSrv); err != nil {
+		return err
+	}
+
+	if config.HostsRetention != nil {
+		if config.HostsRetention.Duration < 0 {
+			return fmt.Errorf(`hosts-retention must not be negative, got %v`, config.HostsRetention.Duration)
+		}
+		hostsRetention = config.HostsRetention.Duration
+	}
+
+	if config.Handler != nil {
+		if err := applyCounts("handler", config.Handler.Threads, config.Handler.RetryCount, &handler.Threads, &handler.RetryCount); err != nil {
+			return err
+		}
+
+		if err := applyWorkQueueConfig("handler", config.Handler.WorkQueue, &handler.WorkQueueSettings); err != nil {
+			return err
+		}
+
+		if config.Handler.DeleteDeferral != nil {
+			if config.Handler.DeleteDeferral.Duration < 0 {
+				return fmt.Errorf(`handler delete-deferral must not be negative, got %v`, config.Handler.DeleteDeferral.Duration)
+			}
+			handler.DeleteDeferral = config.Handler.DeleteDeferral.Duration
+		}
+	}
+
+	if config.Synchronizer != nil {
+		if err := applyCounts("synchronizer", config.Synchronizer.Threads, config.Synchronizer.RetryCount, &synchronizer.Threads, &synchronizer.RetryCount); err != nil {
+			return err
+		}
+
+		if config.Synchronizer.MinMillisecondsJitter != nil {
+			synchronizer.MinMillisecondsJitter = *config.Synchronizer.MinMillisecondsJitter
+		}
+
+		if config.Synchronizer.MaxMillisecondsJitter != nil {
+			synchronizer.MaxMillisecondsJitter = *config.Synchronizer.MaxMillisecondsJitter
+		}
+
+		if synchronizer.MinMillisecondsJitter < 0 || synchronizer.MaxMillisecondsJitter < synchronizer.MinMillisecondsJitter {
+			return fmt.Errorf(`synchronizer jitter must satisfy 0 <= min-jitter-ms (%d) <= max-jitter-ms (%d)`, synchronizer.MinMillisecondsJitter, synchronizer.MaxMillisecondsJitter)
+		}
+
+		if err := applyWorkQueueConfig("synchronizer", config.Synchronizer.WorkQueue, &synchronizer.WorkQueueSettings); err != nil {
+			return err
+		}
+
+		if config.Synchronizer.CoalesceWindow != nil {
+			if config.Synchronizer.CoalesceWindow.Duration < 0 {
+				return fmt.Errorf(`synchronizer coalesce-window must not be negative, got %v`, config.Synchronizer.CoalesceWindow.Duration)
+			}
+			synchronizer.CoalesceWindow = config.Synchronizer.CoalesceWindow.Duration
+		}
+
+		if config.Synchronizer.Prune != nil {
+			synchronizer.Prune = *config.Synchronizer.Prune
+		}
+
+		if config.Synchronizer.OwnershipTag != nil {
+			synchronizer.OwnershipTag = *config.Synchronizer.OwnershipTag
+		}
+
+		if config.Synchronizer.ForcePrune != nil {
+			synchronizer.ForcePrune = *config.Synchronizer.ForcePrune
+		}
+
+		if config.Synchronizer.ReconcileInterval != nil {
+			if config.Synchronizer.ReconcileInterval.Duration <= 0 {
+				return fmt.Errorf(`synchronizer reconcile-interval must be greater than zero, got %v`, config.Synchronizer.ReconcileInterval.Duration)
+			}
+			synchronizer.ReconcileInterval = config.Synchronizer.ReconcileInterval.Duration
+		}
+
+		if config.Synchronizer.HostStagger != nil {
+			if config.Synchronizer.HostStagger.Duration < 0 {
+				return fmt.Errorf(`synchronizer host-stagger must not be negative, got %v`, config.Synchronizer.HostStagger.Duration)
+			}
+			synchronizer.HostStagger = config.Synchronizer.HostStagger.Duration
+		}
+
+		if config.Synchronizer.EmptyServerPolicy != nil {
+			if err := ValidateEmptyServerPolicy(*config.Synchronizer.EmptyServerPolicy); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.EmptyServerPolicy = *config.Synchronizer.EmptyServerPolicy
+		}
+
+		if config.Synchronizer.ServerAdmissionMaxWait != nil {
+			if config.Synchronizer.ServerAdmissionMaxWait.Duration < 0 {
+				return fmt.Errorf(`synchronizer server-admission-max-wait must not be negative, got %v`, config.Synchronizer.ServerAdmissionMaxWait.Duration)
+			}
+			synchronizer.ServerAdmissionMaxWait = config.Synchronizer.ServerAdmissionMaxWait.Duration
+		}
+
+		if config.Synchronizer.ServerAdmissionPolicy != nil {
+			if err := ValidateServerAdmissionPolicy(*config.Synchronizer.ServerAdmissionPolicy); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.ServerAdmissionPolicy = *config.Synchronizer.ServerAdmissionPolicy
+		}
+
+		if config.Synchronizer.DriftPolicy != nil {
+			if err := ValidateDriftPolicy(*config.Synchronizer.DriftPolicy); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.DriftPolicy = *config.Synchronizer.DriftPolicy
+		}
+
+		if config.Synchronizer.LoadBalancerIngressIps != nil {
+			if err := ValidateLoadBalancerIngressIps(config.Synchronizer.LoadBalancerIngressIps); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.LoadBalancerIngressIps = config.Synchronizer.LoadBalancerIngressIps
+		}
+
+		if config.Synchronizer.DnsServiceName != nil {
+			if err := validateDnsServiceName(*config.Synchronizer.DnsServiceName); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.DnsServiceName = *config.Synchronizer.DnsServiceName
+		}
+
+		if config.Synchronizer.DnsServiceNamespace != nil {
+			if err := validateDnsServiceNamespace(*config.Synchronizer.DnsServiceNamespace); err != nil {
+				return fmt.Errorf(`synchronizer %w`, err)
+			}
+			synchronizer.DnsServiceNamespace = *config.Synchronizer.DnsServiceNamespace
+		}
+
+		if config.Synchronizer.MissingUpstreamRetryInterval != nil {
+			if config.Synchronizer.MissingUpstreamRetryInterval.Duration <= 0 {
+				return fmt.Errorf(`synchronizer missing-upstream-retry-interval must be greater than zero, got %v`, config.Synchronizer.MissingUpstreamRetryInterval.Duration)
+			}
+			synchronizer.MissingUpstreamRetryInterval = config.Synchronizer.MissingUpstreamRetryInterval.Duration
+		}
+
+		if config.Synchronizer.UpstreamTimeout != nil {
+			if config.Synchronizer.UpstreamTimeout.Duration <= 0 {
+				return fmt.Errorf(`synchronizer upstream-timeout must be greater than zero, got %v`, config.Synchronizer.UpstreamTimeout.Duration)
+			}
+			synchronizer.UpstreamTimeout = config.Synchronizer.UpstreamTimeout.Duration
+		}
+
+		if config.Synchronizer.ErrorLogWindow != nil {
+			if config.Synchronizer.ErrorLogWindow.Duration < 0 {
+				return fmt.Errorf(`synchronizer error-log-window must not be negative, got %v`, config.Synchronizer.ErrorLogWindow.Duration)
+			}
+			synchronizer.ErrorLogWindow = config.Synchronizer.ErrorLogWindow.Duration
+		}
+
+		if config.Synchronizer.ConvergenceWarningThreshold != nil {
+			if config.Synchronizer.ConvergenceWarningThreshold.Duration < 0 {
+				return fmt.Errorf(`synchronizer convergence-warning-threshold must not be negative, got %v`, config.Synchronizer.ConvergenceWarningThreshold.Duration)
+			}
+			synchronizer.ConvergenceWarningThreshold = config.Synchronizer.ConvergenceWarningThreshold.Duration
+		}
+
+		if config.Synchronizer.CircuitBreakerThreshold != nil {
+			if *config.Synchronizer.CircuitBreakerThreshold < 1 {
+				return fmt.Errorf(`synchronizer circuit-breaker-threshold must be greater than zero, got %d`, *config.Synchronizer.CircuitBreakerThreshold)
+			}
+			synchronizer.CircuitBreakerThreshold = *config.Synchronizer.CircuitBreakerThreshold
+		}
+
+		if config.Synchronizer.CircuitBreakerBackoff != nil {
+			if config.Synchronizer.CircuitBreakerBackoff.Duration <= 0 {
+				return fmt.Errorf(`synchronizer circuit-breaker-backoff must be greater than zero, got %v`, config.Synchronizer.CircuitBreakerBackoff.Duration)
+			}
+			synchronizer.CircuitBreakerBackoff = config.Synchronizer.CircuitBreakerBackoff.Duration
+		}
+
+		if config.Synchronizer.CircuitBreakerMaxBackoff != nil {
+			synchronizer.CircuitBreakerMaxBackoff = config.Synchronizer.CircuitBreakerMaxBackoff.Duration
+		}
+
+		if synchronizer.CircuitBreakerMaxBackoff < synchronizer.CircuitBreakerBackoff {
+			return fmt.Errorf(`synchronizer circuit-breaker-max-backoff (%v) must not be less than circuit-breaker-backoff (%v)`, synchronizer.CircuitBreakerMaxBackoff, synchronizer.CircuitBreakerBackoff)
+		}
+
+		if config.Synchronizer.BorderType != nil {
+			if err := validateBorderType(*config.Synchronizer.BorderType); err != nil {
+				return fmt.Errorf(`synchronizer border-type: %w`, err)
+			}
+			synchronizer.BorderType = *config.Synchronizer.BorderType
+		}
+
+		if config.Synchronizer.PersistState != nil {
+			synchronizer.PersistState = *config.Synchronizer.PersistState
+		}
+
+		if config.Synchronizer.StateConfigMapName != nil {
+			if err := validateConfigMapName(*config.Synchronizer.StateConfigMapName); err != nil {
+				return fmt.Errorf(`synchronizer state-configmap-name: %w`, err)
+			}
+			synchronizer.StateConfigMapName = *config.Synchronizer.StateConfigMapName
+		}
+
+		if config.Synchronizer.StatePersistDebounce != nil {
+			if config.Synchronizer.StatePersistDebounce.Duration <= 0 {
+				return fmt.Errorf(`synchronizer state-persist-debounce must be greater than zero, got %v`, config.Synchronizer.StatePersistDebounce.Duration)
+			}
+			synchronizer.StatePersistDebounce = config.Synchronizer.StatePersistDebounce.Duration
+		}
+
+		if config.Synchronizer.StatusAnnotationInterval != nil {
+			if config.Synchronizer.StatusAnnotationInterval.Duration < 0 {
+				return fmt.Errorf(`synchronizer status-annotation-interval must not be negative, got %v`, config.Synchronizer.StatusAnnotationInterval.Duration)
+			}
+			synchronizer.StatusAnnotationInterval = config.Synchronizer.StatusAnnotationInterval.Duration
+		}
+
+		if config.Synchronizer.StatusConfigMapName != nil {
+			if err := validateConfigMapName(*config.Synchronizer.StatusConfigMapName); err != nil {
+				return fmt.Errorf(`synchronizer status-configmap-name: %w`, err)
+			}
+			synchronizer.StatusConfigMapName = *config.Synchronizer.StatusConfigMapName
+		}
+
+		if config.Synchronizer.StatusConfigMapInterval != nil {
+			if config.Synchronizer.StatusConfigMapInterval.Duration < 0 {
+				return fmt.Errorf(`synchronizer status-configmap-interval must not be negative, got %v`, config.Synchronizer.StatusConfigMapInterval.Duration)
+			}
+			synchronizer.StatusConfigMapInterval = config.Synchronizer.StatusConfigMapInterval.Duration
+		}
+	}
+
+	if config.Watcher != nil {
+		if config.Watcher.NginxIngressNamespace != nil {
+			namespaces, err := parseNamespaces(*config.Watcher.NginxIngressNamespace)
+			if err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.NginxIngressNamespaces = namespaces
+		}
+
+		if config.Watcher.ServiceSelector != nil {
+			serviceSelector, err := parseServiceSelector(*config.Watcher.ServiceSelector)
+			if err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.ServiceSelector = serviceSelector
+		}
+
+		if config.Watcher.UpstreamNameTemplate != nil {
+			if err := validateUpstreamNameTemplate(*config.Watcher.UpstreamNameTemplate); err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.UpstreamNameTemplate = *config.Watcher.UpstreamNameTemplate
+		}
+
+		if config.Watcher.ResyncPeriod != nil {
+			if config.Watcher.ResyncPeriod.Duration < 0 {
+				return fmt.Errorf(`watcher resync-period must not be negative, got %v`, config.Watcher.ResyncPeriod.Duration)
+			}
+			watcher.ResyncPeriod = config.Watcher.ResyncPeriod.Duration
+		}
+
+		if config.Watcher.CacheSyncTimeout != nil {
+			if config.Watcher.CacheSyncTimeout.Duration <= 0 {
+				return fmt.Errorf(`watcher cache-sync-timeout must be greater than zero, got %v`, config.Watcher.CacheSyncTimeout.Duration)
+			}
+			watcher.CacheSyncTimeout = config.Watcher.CacheSyncTimeout.Duration
+		}
+
+		if config.Watcher.DrainTimeout != nil {
+			if config.Watcher.DrainTimeout.Duration <= 0 {
+				return fmt.Errorf(`watcher drain-timeout must be greater than zero, got %v`, config.Watcher.DrainTimeout.Duration)
+			}
+			watcher.DrainTimeout = config.Watcher.DrainTimeout.Duration
+		}
+
+		if config.Watcher.DeletedNodeDrainTimeout != nil {
+			if config.Watcher.DeletedNodeDrainTimeout.Duration < 0 {
+				return fmt.Errorf(`watcher deleted-node-drain-timeout must not be negative, got %v`, config.Watcher.DeletedNodeDrainTimeout.Duration)
+			}
+			watcher.DeletedNodeDrainTimeout = config.Watcher.DeletedNodeDrainTimeout.Duration
+		}
+
+		if config.Watcher.DrainedConnectionsThreshold != nil {
+			if *config.Watcher.DrainedConnectionsThreshold < 0 {
+				return fmt.Errorf(`watcher drained-connections-threshold must not be negative, got %d`, *config.Watcher.DrainedConnectionsThreshold)
+			}
+			watcher.DrainedConnectionsThreshold = *config.Watcher.DrainedConnectionsThreshold
+		}
+
+		if config.Watcher.NotReadyGracePeriod != nil {
+			if config.Watcher.NotReadyGracePeriod.Duration <= 0 {
+				return fmt.Errorf(`watcher not-ready-grace-period must be greater than zero, got %v`, config.Watcher.NotReadyGracePeriod.Duration)
+			}
+			watcher.NotReadyGracePeriod = config.Watcher.NotReadyGracePeriod.Duration
+		}
+
+		if config.Watcher.TargetMode != nil {
+			if err := validateTargetMode(*config.Watcher.TargetMode); err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.TargetMode = *config.Watcher.TargetMode
+		}
+
+		if config.Watcher.RbacMode != nil {
+			if err := validateRbacMode(*config.Watcher.RbacMode); err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.RbacMode = *config.Watcher.RbacMode
+		}
+
+		if config.Watcher.AddressFamily != nil {
+			if err := validateAddressFamily(*config.Watcher.AddressFamily); err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.AddressFamily = *config.Watcher.AddressFamily
+		}
+
+		if config.Watcher.NodeAddressType != nil {
+			nodeAddressTypes, err := parseNodeAddressTypes(*config.Watcher.NodeAddressType)
+			if err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.NodeAddressTypes = nodeAddressTypes
+		}
+
+		if config.Watcher.NodeSelector != nil {
+			nodeSelector, err := parseNodeSelector(*config.Watcher.NodeSelector)
+			if err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.NodeSelector = nodeSelector
+		}
+
+		if config.Watcher.BackupNodeSelector != nil {
+			backupNodeSelector, err := parseBackupNodeSelector(*config.Watcher.BackupNodeSelector)
+			if err != nil {
+				return fmt.Errorf(`watcher %w`, err)
+			}
+			watcher.BackupNodeSelector = backupNodeSelector
+		}
+
+		if config.Watcher.ExcludeControlPlaneNodes != nil {
+			watcher.ExcludeControlPlaneNodes = *config.Watcher.ExcludeControlPlaneNodes
+		}
+
+		if config.Watcher.ExcludedTaintKeys != nil {
+			watcher.ExcludedTaintKeys = config.Watcher.ExcludedTaintKeys
+		}
+	}
+
+	s.HostsRetention = hostsRetention
+	s.Handler = handler
+	s.Synchronizer = synchronizer
+	s.Watcher = watcher
+
+	return nil
+}
+
+// applyCounts validates and applies the threads and retry-count values of a section.
+func applyCounts(section string, threadsValue *int, retryCountValue *int, threads *int, retryCount *int) error {
+	if threadsValue != nil {
+		if *threadsValue <= 0 {
+			return fmt.Errorf(`%s threads must be greater than zero, got %d`, section, *threadsValue)
+		}
+		*threads = *threadsValue
+	}
+
+	if retryCountValue != nil {
+		if *retryCountValue <= 0 {
+			return fmt.Errorf(`%s retry-count must be greater than zero, got %d`, section, *retryCountValue)
+		}
+		*retryCount = *retryCountValue
+	}
+
+	return nil
+}
+
+// applyWorkQueueConfig validates and applies the work-queue values of a section.
+func applyWorkQueueConfig(section string, config *WorkQueueConfig, settings *WorkQueueSettings) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.RateLimiterBase != nil {
+		if config.RateLimiterBase.Duration <= 0 {
+			return fmt.Errorf(`%s rate-limiter-base must be greater than zero, got %v`, section, config.RateLimiterBase.Duration)
+		}
+		settings.RateLimiterBase = config.RateLimiterBase.Duration
+	}
+
+	if config.RateLimiterMax != nil {
+		if config.RateLimiterMax.Duration <= 0 {
+			return fmt.Errorf(`%s rate-limiter-max must be greater than zero, got %v`, section, config.RateLimiterMax.Duration)
+		}
+		settings.RateLimiterMax = config.RateLimiterMax.Duration
+	}
+
+	if settings.RateLimiterMax < settings.RateLimiterBase {
+		return fmt.Errorf(`%s rate-limiter-max (%v) must not be less than rate-limiter-base (%v)`, section, settings.RateLimiterMax, settings.RateLimiterBase)
+	}
+
+	return nil
+}
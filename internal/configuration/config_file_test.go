@@ -0,0 +1,216 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+)
+
+const testConfigDocument = `
+nginx-hosts:
+  - https://10.0.0.1:9000/api
+  - https://10.0.0.2:9000/api
+hosts-retention: 10m
+handler:
+  threads: 2
+synchronizer:
+  retry-count: 7
+  work-queue:
+    rate-limiter-base: 500ms
+    rate-limiter-max: 30s
+watcher:
+  nginx-ingress-namespace: acme-ingress
+  target-mode: endpointslices
+  node-selector: node-role.kubernetes.io/ingress=true
+`
+
+func TestParseConfigFile(t *testing.T) {
+	config, err := parseConfigFile([]byte(testConfigDocument))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(config.NginxHosts) != 2 {
+		t.Fatalf(`expected two hosts, got %v`, config.NginxHosts)
+	}
+
+	if config.Synchronizer.WorkQueue.RateLimiterBase.Duration != 500*time.Millisecond {
+		t.Fatalf(`expected a rate-limiter-base of 500ms, got %v`, config.Synchronizer.WorkQueue.RateLimiterBase.Duration)
+	}
+}
+
+func TestParseConfigFile_AcceptsHostObjects(t *testing.T) {
+	document := "nginx-hosts:\n  - https://10.0.0.1:9000/api\n  - url: https://10.0.1.1:9000/api\n    api-version: 8\n    site: west\n    role: secondary\n"
+
+	config, err := parseConfigFile([]byte(document))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	expected := NginxHostList{"https://10.0.0.1:9000/api", "https://10.0.1.1:9000/api;version=8;site=west;role=secondary"}
+	if !reflect.DeepEqual(config.NginxHosts, expected) {
+		t.Fatalf(`expected %v, got %v`, expected, config.NginxHosts)
+	}
+
+	if _, err = parseConfigFile([]byte("nginx-hosts:\n  - site: west\n")); err == nil {
+		t.Fatalf(`expected an error for a host without a url`)
+	}
+}
+
+func TestParseConfigFile_RejectsUnknownKeys(t *testing.T) {
+	_, err := parseConfigFile([]byte("synchronizer:\n  thread: 2\n"))
+	if err == nil {
+		t.Fatalf(`expected an error for an unknown key`)
+	}
+}
+
+func TestSettings_ApplyConfigFile(t *testing.T) {
+	settings := buildSettings(t)
+
+	config, _ := parseConfigFile([]byte(testConfigDocument))
+	if err := settings.applyConfigFile(config); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.HostsRetention != 10*time.Minute {
+		t.Errorf(`expected a hosts-retention of 10m, got %v`, settings.HostsRetention)
+	}
+
+	if settings.Handler.Threads != 2 {
+		t.Errorf(`expected 2 handler threads, got %d`, settings.Handler.Threads)
+	}
+
+	if settings.Handler.RetryCount != 5 {
+		t.Errorf(`expected the default handler retry count to be unchanged, got %d`, settings.Handler.RetryCount)
+	}
+
+	if settings.Synchronizer.RetryCount != 7 {
+		t.Errorf(`expected 7 synchronizer retries, got %d`, settings.Synchronizer.RetryCount)
+	}
+
+	if settings.Synchronizer.WorkQueueSettings.RateLimiterMax != 30*time.Second {
+		t.Errorf(`expected a rate-limiter-max of 30s, got %v`, settings.Synchronizer.WorkQueueSettings.RateLimiterMax)
+	}
+
+	if !reflect.DeepEqual(settings.Watcher.NginxIngressNamespaces, []string{"acme-ingress"}) {
+		t.Errorf(`expected the acme-ingress namespace, got %v`, settings.Watcher.NginxIngressNamespaces)
+	}
+
+	if settings.Watcher.TargetMode != TargetModeEndpointSlices {
+		t.Errorf(`expected the %s target mode, got %s`, TargetModeEndpointSlices, settings.Watcher.TargetMode)
+	}
+
+	if settings.Watcher.NodeSelector.String() != "node-role.kubernetes.io/ingress=true" {
+		t.Errorf(`expected the ingress node selector, got %q`, settings.Watcher.NodeSelector.String())
+	}
+}
+
+func TestSettings_ApplyConfigFileInvalidLeavesSettingsUnchanged(t *testing.T) {
+	settings := buildSettings(t)
+
+	config, _ := parseConfigFile([]byte("handler:\n  threads: 3\nsynchronizer:\n  threads: 0\n"))
+	if err := settings.applyConfigFile(config); err == nil {
+		t.Fatalf(`expected an error for zero threads`)
+	}
+
+	if settings.Handler.Threads != 1 {
+		t.Fatalf(`expected the handler threads to be unchanged, got %d`, settings.Handler.Threads)
+	}
+}
+
+func TestSettings_ConfigFileKeyOverridesFlatHosts(t *testing.T) {
+	settings := buildSettings(t)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://legacy:9000/api")
+	configMap.Data[ConfigFileKey] = testConfigDocument
+	settings.handleUpdateEvent(nil, configMap)
+
+	if len(settings.Hosts()) != 2 || settings.Hosts()[0] != "https://10.0.0.1:9000/api" {
+		t.Fatalf(`expected the hosts from the %s key, got %v`, ConfigFileKey, settings.Hosts())
+	}
+}
+
+func TestSettings_InvalidConfigFileKeyRecordsEvent(t *testing.T) {
+	settings := buildSettings(t)
+	recorder := record.NewFakeRecorder(1)
+	settings.EventRecorder = recorder
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://legacy:9000/api")
+	configMap.Data[ConfigFileKey] = "nginx-hosts: [unterminated"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if len(settings.Hosts()) != 1 || settings.Hosts()[0] != "https://legacy:9000/api" {
+		t.Fatalf(`expected to fall back to the nginx-hosts key, got %v`, settings.Hosts())
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, InvalidConfigurationReason) {
+			t.Fatalf(`expected an %s Event, got %s`, InvalidConfigurationReason, event)
+		}
+	default:
+		t.Fatalf(`expected an Event to be recorded`)
+	}
+}
+
+func TestSettings_InitializeAppliesConfigFilePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfigDocument), 0o600); err != nil {
+		t.Fatalf(`error writing the configuration file: %v`, err)
+	}
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "")
+	delete(configMap.Data, "nginx-hosts")
+
+	settings, err := initializeSettingsWith(t, configMap, func(settings *Settings) {
+		settings.ConfigFilePath = path
+	})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(settings.Hosts()) != 2 {
+		t.Fatalf(`expected the hosts from the configuration file, got %v`, settings.Hosts())
+	}
+}
+
+func TestSettings_SecondaryHostsAreReevaluatedOnUpdate(t *testing.T) {
+	settings := buildSettings(t)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://primary:9000/api")
+	configMap.Data[SecondaryHostsKey] = "https://standby:9000/api"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if len(settings.Hosts()) != 2 || !settings.IsSecondaryHost("https://standby:9000/api") || settings.IsSecondaryHost("https://primary:9000/api") {
+		t.Fatalf(`expected a primary and a secondary host, got %v`, settings.Hosts())
+	}
+
+	configMap.Data[ConfigFileKey] = "nginx-hosts:\n  - https://standby:9000/api\nnginx-hosts-secondary:\n  - https://primary:9000/api\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if primaryHosts := settings.PrimaryHosts(); len(primaryHosts) != 1 || primaryHosts[0] != "https://standby:9000/api" {
+		t.Fatalf(`expected the groups of the %s key, got %v`, ConfigFileKey, primaryHosts)
+	}
+
+	if !settings.IsSecondaryHost("https://primary:9000/api") {
+		t.Fatalf(`expected the former primary host to be secondary`)
+	}
+
+	delete(configMap.Data, ConfigFileKey)
+	delete(configMap.Data, SecondaryHostsKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if len(settings.Hosts()) != 1 || settings.IsSecondaryHost("https://standby:9000/api") {
+		t.Fatalf(`expected the secondary host to be removed, got %v`, settings.Hosts())
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+)
+
+const (
+	// CaCrlPathKey is the ConfigMap key naming a mounted file holding the certificate revocation list(s) of the CA,
+	// instead of the ca-crl key of the CA Secret.
+	CaCrlPathKey = "ca-crl-path"
+
+	// CrlExpiredPolicyKey is the ConfigMap key determining whether the connections to NGINX Plus are refused once the CRL
+	// has passed its next update, CrlFailClosed, or still allowed, CrlFailOpen.
+	CrlExpiredPolicyKey = "ca-crl-expired-policy"
+
+	// CrlFailClosed refuses the connections to NGINX Plus while the CRL has expired; this is the default.
+	CrlFailClosed = "fail-closed"
+
+	// CrlFailOpen allows the connections to NGINX Plus while the CRL has expired, rejecting the certificates it lists.
+	CrlFailOpen = "fail-open"
+)
+
+// parseCrlExpiredPolicy parses the ca-crl-expired-policy value, and returns true for CrlFailOpen.
+func parseCrlExpiredPolicy(value string) (bool, error) {
+	switch strings.TrimSpace(value) {
+	case CrlFailClosed:
+		return false, nil
+	case CrlFailOpen:
+		return true, nil
+	default:
+		return false, fmt.Errorf(`invalid %s value: %q, valid values are: %s, %s`, CrlExpiredPolicyKey, value, CrlFailClosed, CrlFailOpen)
+	}
+}
+
+// applyCrlExpiredPolicy sets the policy for an expired CRL from the ConfigMap, and returns true if it has changed.
+// CrlFailClosed is restored when the key is removed, and the current policy is kept when the key is invalid.
+func (s *Settings) applyCrlExpiredPolicy(configMap *corev1.ConfigMap) bool {
+	failOpen := false
+
+	if value, found := configMap.Data[CrlExpiredPolicyKey]; found {
+		var err error
+		if failOpen, err = parseCrlExpiredPolicy(value); err != nil {
+			logrus.Errorf("Settings::applyCrlExpiredPolicy: the CRL policy has NOT been changed: %v", err)
+			s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the CRL policy has not been changed: %v", err))
+			return false
+		}
+	}
+
+	if failOpen == s.CrlFailOpen {
+		return false
+	}
+
+	s.CrlFailOpen = failOpen
+
+	logrus.Infof("Settings::applyCrlExpiredPolicy: %s: '%s'", CrlExpiredPolicyKey, configMap.Data[CrlExpiredPolicyKey])
+
+	return true
+}
+
+// validateCaCrl returns an error if the CRL of the self-signed TLS modes cannot be parsed, or is not signed by the CA,
+// as every connection to NGINX Plus would fail. An expired CRL is only reported, see ca-crl-expired-policy.
+func (s *Settings) validateCaCrl() error {
+	var crl, caCertificate []byte
+	var origin string
+
+	if s.usesCertificateFiles() {
+		crl, caCertificate = s.CertificateFiles.GetCaCrl(), s.CertificateFiles.GetCACertificate()
+		origin = fmt.Sprintf("the file '%s'", s.CertificateFiles.Paths().CaCrl)
+	} else {
+		secretName := s.Certificates.CaCertificateSecretKey
+		if secretName == "" {
+			return nil
+		}
+
+		secret, err := s.K8sClient.CoreV1().Secrets(certification.SecretsNamespace).Get(s.Context, secretName, metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+
+		crl, caCertificate = secret.Data[certification.CaCrlKey], secret.Data[certification.CertificateKey]
+		origin = fmt.Sprintf("the %s key of the Secret '%s/%s'", certification.CaCrlKey, certification.SecretsNamespace, secretName)
+	}
+
+	if len(crl) == 0 {
+		return nil
+	}
+
+	lists, err := certification.ParseRevocationLists(crl, caCertificate)
+	if err != nil {
+		return fmt.Errorf(`tls-mode '%s': the CRL in %s is invalid: %w`, s.TlsMode, origin, err)
+	}
+
+	for _, list := range lists {
+		if !list.NextUpdate.IsZero() && time.Now().After(list.NextUpdate) {
+			logrus.Errorf("Settings::validateCaCrl: the CRL issued by '%s' in %s expired at %s", list.Issuer, origin, list.NextUpdate)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+)
+
+func TestSettings_CrlExpiredPolicyFollowsTheConfigMap(t *testing.T) {
+	settings := buildSettings(t)
+
+	notifications := 0
+	settings.SubscribeToTlsChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data[CrlExpiredPolicyKey] = CrlFailOpen
+	settings.handleUpdateEvent(nil, configMap)
+
+	if !settings.CrlFailOpen || notifications != 1 {
+		t.Fatalf(`expected the CRL policy to fail open, %d notification(s)`, notifications)
+	}
+
+	// an invalid key keeps the current policy
+	configMap.Data[CrlExpiredPolicyKey] = "open"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if !settings.CrlFailOpen || notifications != 1 {
+		t.Fatalf(`expected the current policy to be kept`)
+	}
+
+	delete(configMap.Data, CrlExpiredPolicyKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CrlFailOpen || notifications != 2 {
+		t.Fatalf(`expected the policy to fail closed once the key is removed, %d notification(s)`, notifications)
+	}
+}
+
+func TestSettings_InitializeRejectsAnInvalidCrl(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = SelfSignedTLSString
+	configMap.Data["ca-certificate"] = "nlk-tls-ca-secret"
+
+	secret := buildCertificateSecret(t, "nlk-tls-ca-secret", time.Hour)
+	if err := initializeSettings(t, configMap, secret); err != nil {
+		t.Fatalf(`should have been no error without a CRL, %v`, err)
+	}
+
+	secret.Data[certification.CaCrlKey] = []byte("this is not a CRL")
+	if err := initializeSettings(t, configMap, secret); err == nil {
+		t.Fatalf(`expected an error for a malformed CRL`)
+	}
+
+	// the CRL is not used by the CA modes
+	configMap.Data["tls-mode"] = CertificateAuthorityTLSString
+	if err := initializeSettings(t, configMap, secret); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	configMap.Data[CrlExpiredPolicyKey] = "open"
+	if err := initializeSettings(t, configMap, secret); err == nil {
+		t.Fatalf(`expected an error for an invalid %s`, CrlExpiredPolicyKey)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ManagedByLabel is the label of the objects NLK creates, e.g. the DNS Service, set to ManagedByNlk so that they can be
+	// listed, and deleted once they are no longer configured.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// OwnerAnnotation is the annotation of the objects NLK creates, set to the namespace and name of the ConfigMap of the
+	// instance of NLK that created them; NLK only changes, or deletes, the objects that carry its own value, never an object
+	// of the same name created by something else, or by another instance of NLK.
+	OwnerAnnotation = "nginxinc.io/owner"
+
+	// ManagedByNlk is the value of the ManagedByLabel of the objects NLK creates.
+	ManagedByNlk = "nginx-loadbalancer-kubernetes"
+)
+
+// validateDnsServiceName returns an error if the name is not a valid Service name; an empty name disables the DNS Service.
+func validateDnsServiceName(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if problems := validation.IsDNS1035Label(name); len(problems) > 0 {
+		return fmt.Errorf(`dns-service-name %q is invalid: %s`, name, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateDnsServiceNamespace returns an error if the namespace is not a valid namespace; an empty namespace is the
+// ConfigMap namespace.
+func validateDnsServiceNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+
+	if problems := validation.IsDNS1123Label(namespace); len(problems) > 0 {
+		return fmt.Errorf(`dns-service-namespace %q is invalid: %s`, namespace, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
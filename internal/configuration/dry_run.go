@@ -0,0 +1,43 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DryRunKey is the ConfigMap key used to turn the dry-run mode on or off at runtime, e.g. "true".
+const DryRunKey = "dry-run"
+
+// IsDryRun determines whether the changes to the NGINX Plus upstreams are only logged, rather than applied.
+func (s *Settings) IsDryRun() bool {
+	return s.dryRun.Load()
+}
+
+// applyDryRun sets the dry-run mode from the dry-run key of the ConfigMap. The DryRun setting is restored
+// when the key is removed, and the current mode is kept when the value is invalid.
+func (s *Settings) applyDryRun(configMap *corev1.ConfigMap) {
+	dryRun := s.DryRun
+
+	if value, found := configMap.Data[DryRunKey]; found {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			logrus.Warnf("Settings::applyDryRun: the %s key has an invalid value %q, keeping dry-run=%t", DryRunKey, value, s.IsDryRun())
+			s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the %s key must be true or false, got %q", DryRunKey, value))
+			return
+		}
+
+		dryRun = parsed
+	}
+
+	if previous := s.dryRun.Swap(dryRun); previous != dryRun {
+		logrus.Infof("Settings::applyDryRun: dry-run changed from %t to %t", previous, dryRun)
+	}
+}
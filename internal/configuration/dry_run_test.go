@@ -0,0 +1,37 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyDryRun(t *testing.T) {
+	settings := &Settings{DryRun: true}
+
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected bool
+	}{
+		{"missing key uses the flag", map[string]string{}, true},
+		{"key turns dry-run off", map[string]string{DryRunKey: "false"}, false},
+		{"invalid value keeps the current mode", map[string]string{DryRunKey: "maybe"}, false},
+		{"key turns dry-run on", map[string]string{DryRunKey: "true"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings.applyDryRun(&corev1.ConfigMap{Data: test.data})
+
+			if settings.IsDryRun() != test.expected {
+				t.Errorf(`expected dry-run=%t, got %t`, test.expected, settings.IsDryRun())
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TlsModeSourceDefault is the source of a TLS mode that was set by none of the sources below.
+	TlsModeSourceDefault = "default"
+
+	// TlsModeSourceEnvironment is the source of a TLS mode set with NKL_TLS_MODE.
+	TlsModeSourceEnvironment = "environment"
+
+	// TlsModeSourceFlag is the source of a TLS mode set with the --tls-mode flag.
+	TlsModeSourceFlag = "flag"
+
+	// TlsModeSourceConfigMap is the source of a TLS mode set by the tls-mode key of the ConfigMap.
+	TlsModeSourceConfigMap = "configmap"
+)
+
+// EffectiveSettings is the view of the Settings printed by the config dump subcommand: the RedactedSettings, along with
+// the values derived while they were loaded, and the problems that would keep the controller from starting.
+type EffectiveSettings struct {
+	RedactedSettings
+
+	// TlsModeSource is where the TLS mode was set, one of the TlsModeSource values.
+	TlsModeSource string `json:"tlsModeSource"`
+
+	// ConfigMapFound is set when the ConfigMap could be retrieved.
+	ConfigMapFound bool `json:"configMapFound"`
+
+	// ConfigMapError is why the ConfigMap could not be retrieved, the other settings are then those of the environment,
+	// the flags, and the configuration file. It is not one of the Errors, the ConfigMap may only be out of reach.
+	ConfigMapError string `json:"configMapError,omitempty"`
+
+	// Hosts are the NGINX Plus hosts as parsed from their nginx-hosts entries, in the order of the NginxPlusHosts.
+	Hosts []EffectiveHost `json:"hosts,omitempty"`
+
+	// SecretsFound tells whether each of the Secrets named by the ConfigMap exists, by name.
+	SecretsFound map[string]bool `json:"secretsFound,omitempty"`
+
+	// Errors are the problems found, the controller would not start, or would not reach the NGINX Plus hosts, with them.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// EffectiveHost is an NGINX Plus host as parsed from its nginx-hosts entry, without its credentials, see NginxPlusHost.
+type EffectiveHost struct {
+	Endpoint   string `json:"endpoint"`
+	ApiVersion int    `json:"apiVersion,omitempty"`
+	ServerName string `json:"serverName,omitempty"`
+	Site       string `json:"site,omitempty"`
+	Role       string `json:"role"`
+	Insecure   bool   `json:"insecure,omitempty"`
+}
+
+// Valid determines whether no problem was found.
+func (e *EffectiveSettings) Valid() bool {
+	return len(e.Errors) == 0
+}
+
+// Dump loads the Settings as Initialize does, from the configuration file and the ConfigMap, but builds no informer and
+// starts nothing, then checks that the Secrets named by the ConfigMap exist. The Settings are only meant to be printed,
+// and discarded; a ConfigMap that cannot be retrieved is reported in the ConfigMapError, the Settings are then those of
+// the environment, the flags, and the configuration file.
+func (s *Settings) Dump() *EffectiveSettings {
+	if s.Certificates == nil {
+		s.Certificates = certification.NewCertificates(s.Context, s.K8sClient)
+	}
+
+	effective := &EffectiveSettings{}
+
+	configMap, err := s.load()
+	switch {
+	case errors.Is(err, errRetrievingConfigMap):
+		effective.ConfigMapError = err.Error()
+
+	case err != nil:
+		effective.Errors = append(effective.Errors, err.Error())
+	}
+
+	effective.ConfigMapFound = configMap != nil
+	effective.TlsModeSource = s.tlsModeSource(configMap)
+
+	for _, name := range s.namedSecrets() {
+		_, err := s.K8sClient.CoreV1().Secrets(certification.SecretsNamespace).Get(s.Context, name, metav1.GetOptions{})
+		if effective.SecretsFound == nil {
+			effective.SecretsFound = make(map[string]bool)
+		}
+
+		effective.SecretsFound[name] = err == nil
+		if err != nil {
+			effective.Errors = append(effective.Errors, fmt.Sprintf(`error occurred retrieving the Secret %s/%s: %v`, certification.SecretsNamespace, name, err))
+		}
+	}
+
+	effective.RedactedSettings = *s.Redacted()
+	effective.Hosts = s.effectiveHosts()
+
+	return effective
+}
+
+// effectiveHosts returns the parsed nginx-hosts entries of the Hosts, without their credentials, and the role they are
+// given: a host is secondary when listed in nginx-hosts-secondary, or given the secondary role, see IsSecondaryHost.
+func (s *Settings) effectiveHosts() []EffectiveHost {
+	var hosts []EffectiveHost
+
+	for _, host := range s.Hosts() {
+		nginxPlusHost, found := s.NginxPlusHost(host)
+		if !found {
+			continue
+		}
+
+		role := HostRolePrimary
+		if s.IsSecondaryHost(host) {
+			role = HostRoleSecondary
+		}
+
+		hosts = append(hosts, EffectiveHost{
+			Endpoint:   RedactHost(nginxPlusHost.Endpoint),
+			ApiVersion: nginxPlusHost.ApiVersion,
+			ServerName: nginxPlusHost.ServerName,
+			Site:       nginxPlusHost.Site,
+			Role:       role,
+			Insecure:   nginxPlusHost.Insecure,
+		})
+	}
+
+	return hosts
+}
+
+// tlsModeSource returns where the current TLS mode was set: the ConfigMap when it sets a valid tls-mode, otherwise
+// wherever the DefaultTlsMode was set.
+func (s *Settings) tlsModeSource(configMap *corev1.ConfigMap) string {
+	if configMap != nil {
+		if _, found := configMap.Data["tls-mode"]; found {
+			if _, err := validateTlsMode(configMap); err == nil {
+				return TlsModeSourceConfigMap
+			}
+		}
+	}
+
+	return s.defaultTlsModeSource
+}
+
+// namedSecrets returns the names of the Secrets required by the TLS mode, and of the API credentials Secret, sorted.
+func (s *Settings) namedSecrets() []string {
+	names := s.requiredSecrets()
+	if s.Certificates.ApiAuthSecretKey != "" {
+		names = append(names, s.Certificates.ApiAuthSecretKey)
+	}
+
+	names = slices.DeleteFunc(names, func(name string) bool { return name == "" })
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
@@ -0,0 +1,1014 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigMapNamespaceEnv overrides Settings::ConfigMapNamespace.
+	ConfigMapNamespaceEnv = "NKL_CONFIGMAP_NAMESPACE"
+
+	// ConfigMapNameEnv overrides Settings::ConfigMapName.
+	ConfigMapNameEnv = "NKL_CONFIGMAP_NAME"
+
+	// LogFormatEnv overrides Settings::LogFormat, "text" or "json".
+	LogFormatEnv = "NKL_LOG_FORMAT"
+
+	// LogLevelEnv overrides Settings::LogLevel, e.g. "debug".
+	LogLevelEnv = "NKL_LOG_LEVEL"
+
+	// TlsModeEnv overrides Settings::DefaultTlsMode, e.g. "ca-tls".
+	TlsModeEnv = "NKL_TLS_MODE"
+
+	// DryRunEnv overrides Settings::DryRun, e.g. "true".
+	DryRunEnv = "NKL_DRY_RUN"
+
+	// HostsRetentionEnv overrides Settings::HostsRetention, e.g. "10m"; "0s" clears the hosts as soon as the ConfigMap is deleted.
+	HostsRetentionEnv = "NKL_HOSTS_RETENTION"
+
+	// HostsSrvIntervalEnv overrides Settings::HostsSrvInterval, e.g. "1m".
+	HostsSrvIntervalEnv = "NKL_HOSTS_SRV_INTERVAL"
+
+	// HostsSrvRemovalDelayEnv overrides Settings::HostsSrvRemovalDelay, e.g. "5m".
+	HostsSrvRemovalDelayEnv = "NKL_HOSTS_SRV_REMOVAL_DELAY"
+
+	// HandlerThreadsEnv overrides HandlerSettings::Threads.
+	HandlerThreadsEnv = "NKL_HANDLER_THREADS"
+
+	// HandlerRetryCountEnv overrides HandlerSettings::RetryCount.
+	HandlerRetryCountEnv = "NKL_HANDLER_RETRY_COUNT"
+
+	// DeleteDeferralEnv overrides HandlerSettings::DeleteDeferral, e.g. "10s"; "0s" deletes the servers at once.
+	DeleteDeferralEnv = "NKL_DELETE_DEFERRAL"
+
+	// SynchronizerThreadsEnv overrides SynchronizerSettings::Threads.
+	SynchronizerThreadsEnv = "NKL_SYNCHRONIZER_THREADS"
+
+	// SynchronizerRetryCountEnv overrides SynchronizerSettings::RetryCount.
+	SynchronizerRetryCountEnv = "NKL_SYNCHRONIZER_RETRY_COUNT"
+
+	// CoalesceWindowEnv overrides SynchronizerSettings::CoalesceWindow, e.g. "5s"; "0s" only applies the jitter.
+	CoalesceWindowEnv = "NKL_COALESCE_WINDOW"
+
+	// PruneEnv overrides SynchronizerSettings::Prune.
+	PruneEnv = "NKL_PRUNE"
+
+	// OwnershipTagEnv overrides SynchronizerSettings::OwnershipTag, e.g. "nlk"; empty manages every server of the upstreams.
+	OwnershipTagEnv = "NKL_OWNERSHIP_TAG"
+
+	// ForcePruneEnv overrides SynchronizerSettings::ForcePrune.
+	ForcePruneEnv = "NKL_FORCE_PRUNE"
+
+	// ReconcileIntervalEnv overrides SynchronizerSettings::ReconcileInterval, e.g. "10m".
+	ReconcileIntervalEnv = "NKL_RECONCILE_INTERVAL"
+
+	// HostStaggerEnv overrides SynchronizerSettings::HostStagger, e.g. "30s"; "0s" disables the stagger.
+	HostStaggerEnv = "NKL_HOST_STAGGER"
+
+	// EmptyServerPolicyEnv overrides SynchronizerSettings::EmptyServerPolicy, one of "apply", "retain", or "fail".
+	EmptyServerPolicyEnv = "NKL_EMPTY_SERVER_POLICY"
+
+	// ServerAdmissionMaxWaitEnv overrides SynchronizerSettings::ServerAdmissionMaxWait, e.g. "1m", or "0s" to add the servers at once.
+	ServerAdmissionMaxWaitEnv = "NKL_SERVER_ADMISSION_MAX_WAIT"
+
+	// ServerAdmissionPolicyEnv overrides SynchronizerSettings::ServerAdmissionPolicy, one of "add" or "skip".
+	ServerAdmissionPolicyEnv = "NKL_SERVER_ADMISSION_POLICY"
+
+	// DriftPolicyEnv overrides SynchronizerSettings::DriftPolicy, one of "overwrite", "log", or "next-event".
+	DriftPolicyEnv = "NKL_DRIFT_POLICY"
+
+	// LoadBalancerIngressIpsEnv overrides SynchronizerSettings::LoadBalancerIngressIps, as a comma-separated list.
+	LoadBalancerIngressIpsEnv = "NKL_LB_INGRESS_IPS"
+
+	// DnsServiceNameEnv overrides SynchronizerSettings::DnsServiceName, e.g. "nlk-dns"; empty disables the DNS Service.
+	DnsServiceNameEnv = "NKL_DNS_SERVICE_NAME"
+
+	// DnsServiceNamespaceEnv overrides SynchronizerSettings::DnsServiceNamespace.
+	DnsServiceNamespaceEnv = "NKL_DNS_SERVICE_NAMESPACE"
+
+	// MissingUpstreamRetryIntervalEnv overrides SynchronizerSettings::MissingUpstreamRetryInterval, e.g. "1m".
+	MissingUpstreamRetryIntervalEnv = "NKL_MISSING_UPSTREAM_RETRY_INTERVAL"
+
+	// UpstreamTimeoutEnv overrides SynchronizerSettings::UpstreamTimeout, e.g. "10s".
+	UpstreamTimeoutEnv = "NKL_UPSTREAM_TIMEOUT"
+
+	// ErrorLogWindowEnv overrides SynchronizerSettings::ErrorLogWindow, e.g. "5m", or "0s" to log every failed sync.
+	ErrorLogWindowEnv = "NKL_ERROR_LOG_WINDOW"
+
+	// ConvergenceWarningThresholdEnv overrides SynchronizerSettings::ConvergenceWarningThreshold, e.g. "10s", or "0s" to log none.
+	ConvergenceWarningThresholdEnv = "NKL_CONVERGENCE_WARNING_THRESHOLD"
+
+	// CircuitBreakerThresholdEnv overrides SynchronizerSettings::CircuitBreakerThreshold.
+	CircuitBreakerThresholdEnv = "NKL_CIRCUIT_BREAKER_THRESHOLD"
+
+	// CircuitBreakerBackoffEnv overrides SynchronizerSettings::CircuitBreakerBackoff, e.g. "1m".
+	CircuitBreakerBackoffEnv = "NKL_CIRCUIT_BREAKER_BACKOFF"
+
+	// CircuitBreakerMaxBackoffEnv overrides SynchronizerSettings::CircuitBreakerMaxBackoff, e.g. "10m".
+	CircuitBreakerMaxBackoffEnv = "NKL_CIRCUIT_BREAKER_MAX_BACKOFF"
+
+	// BorderTypeEnv overrides SynchronizerSettings::BorderType, e.g. "webhook".
+	BorderTypeEnv = "NKL_BORDER_TYPE"
+
+	// PersistStateEnv overrides SynchronizerSettings::PersistState, e.g. "false".
+	PersistStateEnv = "NKL_PERSIST_STATE"
+
+	// StateConfigMapNameEnv overrides SynchronizerSettings::StateConfigMapName.
+	StateConfigMapNameEnv = "NKL_STATE_CONFIGMAP_NAME"
+
+	// StatePersistDebounceEnv overrides SynchronizerSettings::StatePersistDebounce, e.g. "30s".
+	StatePersistDebounceEnv = "NKL_STATE_PERSIST_DEBOUNCE"
+
+	// StatusAnnotationIntervalEnv overrides SynchronizerSettings::StatusAnnotationInterval, e.g. "5m"; "0s" disables the annotations.
+	StatusAnnotationIntervalEnv = "NKL_STATUS_ANNOTATION_INTERVAL"
+
+	// StatusConfigMapNameEnv overrides SynchronizerSettings::StatusConfigMapName.
+	StatusConfigMapNameEnv = "NKL_STATUS_CONFIGMAP_NAME"
+
+	// StatusConfigMapIntervalEnv overrides SynchronizerSettings::StatusConfigMapInterval, e.g. "1m"; "0s" disables the ConfigMap.
+	StatusConfigMapIntervalEnv = "NKL_STATUS_CONFIGMAP_INTERVAL"
+
+	// RateLimiterBaseEnv overrides WorkQueueSettings::RateLimiterBase for both work queues, e.g. "500ms".
+	RateLimiterBaseEnv = "NKL_RATE_LIMITER_BASE"
+
+	// RateLimiterMaxEnv overrides WorkQueueSettings::RateLimiterMax for both work queues, e.g. "2m".
+	RateLimiterMaxEnv = "NKL_RATE_LIMITER_MAX"
+
+	// QueueMaxDepthEnv overrides WorkQueueSettings::MaxDepth for both work queues, e.g. "500".
+	QueueMaxDepthEnv = "NKL_QUEUE_MAX_DEPTH"
+
+	// QueueDegradedAgeEnv overrides WorkQueueSettings::DegradedAge for both work queues, e.g. "5m".
+	QueueDegradedAgeEnv = "NKL_QUEUE_DEGRADED_AGE"
+
+	// DrainTimeoutEnv overrides WatcherSettings::DrainTimeout.
+	DrainTimeoutEnv = "NKL_DRAIN_TIMEOUT"
+
+	// DeletedNodeDrainTimeoutEnv overrides WatcherSettings::DeletedNodeDrainTimeout, e.g. "0s" to remove the servers at once.
+	DeletedNodeDrainTimeoutEnv = "NKL_DELETED_NODE_DRAIN_TIMEOUT"
+
+	// DrainedConnectionsThresholdEnv overrides WatcherSettings::DrainedConnectionsThreshold.
+	DrainedConnectionsThresholdEnv = "NKL_DRAINED_CONNECTIONS_THRESHOLD"
+
+	// NotReadyGracePeriodEnv overrides WatcherSettings::NotReadyGracePeriod.
+	NotReadyGracePeriodEnv = "NKL_NOT_READY_GRACE_PERIOD"
+
+	// ResyncPeriodEnv overrides WatcherSettings::ResyncPeriod, e.g. "10m".
+	ResyncPeriodEnv = "NKL_RESYNC_PERIOD"
+
+	// CacheSyncTimeoutEnv overrides WatcherSettings::CacheSyncTimeout, e.g. "5m".
+	CacheSyncTimeoutEnv = "NKL_CACHE_SYNC_TIMEOUT"
+
+	// NginxIngressNamespacesEnv overrides WatcherSettings::NginxIngressNamespaces, as a comma-separated list.
+	NginxIngressNamespacesEnv = "NKL_NGINX_INGRESS_NAMESPACES"
+
+	// ServiceSelectorEnv overrides WatcherSettings::ServiceSelector, e.g. "nkl.nginx.com/managed=true".
+	ServiceSelectorEnv = "NKL_SERVICE_SELECTOR"
+
+	// UpstreamNameTemplateEnv overrides WatcherSettings::UpstreamNameTemplate, e.g. "{namespace}-{name}".
+	UpstreamNameTemplateEnv = "NKL_UPSTREAM_NAME_TEMPLATE"
+
+	// TargetModeEnv overrides WatcherSettings::TargetMode.
+	TargetModeEnv = "NKL_TARGET_MODE"
+
+	// RbacModeEnv overrides WatcherSettings::RbacMode.
+	RbacModeEnv = "NKL_RBAC_MODE"
+
+	// AddressFamilyEnv overrides WatcherSettings::AddressFamily.
+	AddressFamilyEnv = "NKL_ADDRESS_FAMILY"
+
+	// NodeAddressTypeEnv overrides WatcherSettings::NodeAddressTypes, as a comma-separated, ordered list.
+	NodeAddressTypeEnv = "NKL_NODE_ADDRESS_TYPE"
+
+	// NodeSelectorEnv overrides WatcherSettings::NodeSelector.
+	NodeSelectorEnv = "NKL_NODE_SELECTOR"
+
+	// BackupNodeSelectorEnv overrides WatcherSettings::BackupNodeSelector.
+	BackupNodeSelectorEnv = "NKL_BACKUP_NODE_SELECTOR"
+
+	// ExcludeControlPlaneNodesEnv overrides WatcherSettings::ExcludeControlPlaneNodes.
+	ExcludeControlPlaneNodesEnv = "NKL_EXCLUDE_CONTROL_PLANE_NODES"
+
+	// ExcludedTaintKeysEnv overrides WatcherSettings::ExcludedTaintKeys, as a comma-separated list.
+	ExcludedTaintKeysEnv = "NKL_EXCLUDED_TAINT_KEYS"
+
+	// HttpDialTimeoutEnv overrides HttpClientSettings::DialTimeout, e.g. "5s".
+	HttpDialTimeoutEnv = "NKL_HTTP_DIAL_TIMEOUT"
+
+	// HttpKeepAliveEnv overrides HttpClientSettings::KeepAlive, e.g. "30s".
+	HttpKeepAliveEnv = "NKL_HTTP_KEEPALIVE"
+
+	// HttpTLSHandshakeTimeoutEnv overrides HttpClientSettings::TLSHandshakeTimeout, e.g. "5s".
+	HttpTLSHandshakeTimeoutEnv = "NKL_HTTP_TLS_HANDSHAKE_TIMEOUT"
+
+	// HttpResponseHeaderTimeoutEnv overrides HttpClientSettings::ResponseHeaderTimeout, e.g. "10s".
+	HttpResponseHeaderTimeoutEnv = "NKL_HTTP_RESPONSE_HEADER_TIMEOUT"
+
+	// HttpRequestTimeoutEnv overrides HttpClientSettings::RequestTimeout, e.g. "10s".
+	HttpRequestTimeoutEnv = "NKL_HTTP_REQUEST_TIMEOUT"
+
+	// HttpIdleConnTimeoutEnv overrides HttpClientSettings::IdleConnTimeout, e.g. "90s".
+	HttpIdleConnTimeoutEnv = "NKL_HTTP_IDLE_CONN_TIMEOUT"
+
+	// HttpMaxIdleConnsEnv overrides HttpClientSettings::MaxIdleConns.
+	HttpMaxIdleConnsEnv = "NKL_HTTP_MAX_IDLE_CONNS"
+
+	// HttpMaxIdleConnsPerHostEnv overrides HttpClientSettings::MaxIdleConnsPerHost.
+	HttpMaxIdleConnsPerHostEnv = "NKL_HTTP_MAX_IDLE_CONNS_PER_HOST"
+
+	// HttpMaxConnsPerHostEnv overrides HttpClientSettings::MaxConnsPerHost; "0" does not limit the connections.
+	HttpMaxConnsPerHostEnv = "NKL_HTTP_MAX_CONNS_PER_HOST"
+
+	// HttpEnableHTTP2Env overrides HttpClientSettings::EnableHTTP2, e.g. "false".
+	HttpEnableHTTP2Env = "NKL_HTTP_ENABLE_HTTP2"
+
+	// HttpWriteRateLimitEnv overrides RateLimiterSettings::Rate of HttpClientSettings::WriteRateLimiter, e.g. "20"; "0" disables it.
+	HttpWriteRateLimitEnv = "NKL_HTTP_WRITE_RATE_LIMIT"
+
+	// HttpWriteBurstEnv overrides RateLimiterSettings::Burst of HttpClientSettings::WriteRateLimiter.
+	HttpWriteBurstEnv = "NKL_HTTP_WRITE_BURST"
+
+	// HttpReadRateLimitEnv overrides RateLimiterSettings::Rate of HttpClientSettings::ReadRateLimiter, e.g. "100"; "0" disables it.
+	HttpReadRateLimitEnv = "NKL_HTTP_READ_RATE_LIMIT"
+
+	// HttpReadBurstEnv overrides RateLimiterSettings::Burst of HttpClientSettings::ReadRateLimiter.
+	HttpReadBurstEnv = "NKL_HTTP_READ_BURST"
+
+	// ReadinessRequiredHostsEnv overrides ReadinessSettings::RequiredHosts, "any" or "all".
+	ReadinessRequiredHostsEnv = "NKL_READINESS_REQUIRED_HOSTS"
+
+	// ReadinessCheckIntervalEnv overrides ReadinessSettings::CheckInterval, e.g. "10s".
+	ReadinessCheckIntervalEnv = "NKL_READINESS_CHECK_INTERVAL"
+
+	// LeaderElectionEnv overrides LeaderElectionSettings::Enabled, e.g. "true".
+	LeaderElectionEnv = "NKL_LEADER_ELECTION"
+
+	// LeaseNameEnv overrides LeaderElectionSettings::LeaseName.
+	LeaseNameEnv = "NKL_LEASE_NAME"
+
+	// LeaseNamespaceEnv overrides LeaderElectionSettings::LeaseNamespace.
+	LeaseNamespaceEnv = "NKL_LEASE_NAMESPACE"
+
+	// LeaseDurationEnv overrides LeaderElectionSettings::LeaseDuration, e.g. "15s".
+	LeaseDurationEnv = "NKL_LEASE_DURATION"
+
+	// LeaseRenewDeadlineEnv overrides LeaderElectionSettings::RenewDeadline, e.g. "10s".
+	LeaseRenewDeadlineEnv = "NKL_LEASE_RENEW_DEADLINE"
+
+	// LeaseRetryPeriodEnv overrides LeaderElectionSettings::RetryPeriod, e.g. "2s".
+	LeaseRetryPeriodEnv = "NKL_LEASE_RETRY_PERIOD"
+
+	// AdminEnabledEnv overrides AdminSettings::Enabled, e.g. "true".
+	AdminEnabledEnv = "NKL_ADMIN_ENABLED"
+
+	// AdminAddressEnv overrides AdminSettings::Address, e.g. "127.0.0.1:6060".
+	AdminAddressEnv = "NKL_ADMIN_ADDRESS"
+
+	// TracingEndpointEnv overrides TracingSettings::Endpoint, e.g. "http://otel-collector:4318".
+	TracingEndpointEnv = "NKL_TRACING_ENDPOINT"
+
+	// TracingSampleRatioEnv overrides TracingSettings::SampleRatio, e.g. "0.1".
+	TracingSampleRatioEnv = "NKL_TRACING_SAMPLE_RATIO"
+
+	// CertificateExpiryWarningsEnv overrides CertificateExpirySettings::Warnings, e.g. "720h,168h,24h".
+	CertificateExpiryWarningsEnv = "NKL_CERTIFICATE_EXPIRY_WARNINGS"
+
+	// CertificateExpiryCheckIntervalEnv overrides CertificateExpirySettings::CheckInterval, e.g. "1h".
+	CertificateExpiryCheckIntervalEnv = "NKL_CERTIFICATE_EXPIRY_CHECK_INTERVAL"
+
+	// KubeApiQpsEnv overrides KubeApiSettings::QPS, e.g. "50".
+	KubeApiQpsEnv = "NKL_KUBE_API_QPS"
+
+	// KubeApiBurstEnv overrides KubeApiSettings::Burst, e.g. "100".
+	KubeApiBurstEnv = "NKL_KUBE_API_BURST"
+)
+
+// EnvironmentVariable describes an environment variable read by NewSettings, for the --help output.
+type EnvironmentVariable struct {
+
+	// Name is the name of the variable, e.g. NKL_LOG_LEVEL.
+	Name string
+
+	// Usage is a short description of the variable.
+	Usage string
+}
+
+// EnvironmentVariables lists the environment variables read by NewSettings, see the README for the defaults.
+var EnvironmentVariables = []EnvironmentVariable{
+	{ConfigMapNamespaceEnv, "namespace of the nlk-config ConfigMap"},
+	{ConfigMapNameEnv, "name of the ConfigMap holding the configuration"},
+	{LogFormatEnv, "log format, text or json"},
+	{LogLevelEnv, "log level at startup, e.g. debug"},
+	{TlsModeEnv, "TLS mode used when the ConfigMap does not set tls-mode"},
+	{DryRunEnv, "log the changes to the NGINX Plus upstreams without applying them"},
+	{HostsRetentionEnv, "how long the NGINX Plus hosts are kept once the ConfigMap is deleted"},
+	{HostsSrvIntervalEnv, "interval between the resolutions of the nginx-hosts-srv record"},
+	{HostsSrvRemovalDelayEnv, "how long a host may be missing from the nginx-hosts-srv record before it is removed"},
+	{HandlerThreadsEnv, "number of Handler workers"},
+	{HandlerRetryCountEnv, "attempts made by the Handler before an event is dropped"},
+	{DeleteDeferralEnv, "how long the deletion of a Service is held in case it is recreated, 0s deletes its servers at once"},
+	{SynchronizerThreadsEnv, "number of Synchronizer workers"},
+	{SynchronizerRetryCountEnv, "attempts made by the Synchronizer before an event is dropped"},
+	{CoalesceWindowEnv, "how long an update waits so the changes to the same upstream are merged into it"},
+	{PruneEnv, "periodically delete the orphaned servers"},
+	{OwnershipTagEnv, "only delete the servers NLK has applied, the others are left alone; empty manages every server"},
+	{ForcePruneEnv, "delete the servers NLK does not own despite the ownership tag"},
+	{ReconcileIntervalEnv, "interval between the reconciliations that prune orphaned servers"},
+	{HostStaggerEnv, "spread of the full syncs of the hosts, after a start and at each reconciliation"},
+	{EmptyServerPolicyEnv, "apply, retain, or fail the updates that leave an upstream without servers"},
+	{ServerAdmissionMaxWaitEnv, "how long the new servers are held back while their NodePort does not answer, 0s adds them at once"},
+	{ServerAdmissionPolicyEnv, "add or skip the new servers that still do not answer after the max wait"},
+	{DriftPolicyEnv, "overwrite, log, or leave to the next event the servers changed outside NLK"},
+	{LoadBalancerIngressIpsEnv, "comma-separated IPs written to the status.loadBalancer.ingress of the synced LoadBalancer Services"},
+	{DnsServiceNameEnv, "headless Service whose EndpointSlices are the IPs of the NGINX Plus hosts, e.g. for external-dns; empty disables it"},
+	{DnsServiceNamespaceEnv, "namespace of the DNS Service, the ConfigMap namespace by default"},
+	{MissingUpstreamRetryIntervalEnv, "retry interval of the updates whose upstream is not defined in NGINX Plus"},
+	{UpstreamTimeoutEnv, "time allowed for the NGINX Plus API calls updating an upstream on a host"},
+	{ErrorLogWindowEnv, "how long the repeats of a sync error are suppressed, 0s logs every failed sync"},
+	{ConvergenceWarningThresholdEnv, "time to converge above which a change is logged as a warning, 0s logs none"},
+	{CircuitBreakerThresholdEnv, "consecutive failures after which an NGINX Plus host is skipped"},
+	{CircuitBreakerBackoffEnv, "how long a failing host is skipped before it is first probed"},
+	{CircuitBreakerMaxBackoffEnv, "cap of the probe backoff of a failing host"},
+	{BorderTypeEnv, "kind of Border Server the upstreams are synced to, nginx-plus or webhook"},
+	{PersistStateEnv, "persist the desired state in a ConfigMap"},
+	{StateConfigMapNameEnv, "name of the ConfigMap holding the persisted desired state"},
+	{StatePersistDebounceEnv, "delay between a successful sync and the persistence of the desired state"},
+	{StatusAnnotationIntervalEnv, "minimum interval between the writes of the sync status annotations of a Service"},
+	{StatusConfigMapNameEnv, "name of the ConfigMap holding the summary of the health of NLK"},
+	{StatusConfigMapIntervalEnv, "interval between the updates of the status ConfigMap"},
+	{RateLimiterBaseEnv, "base delay of the exponential backoff of both queues"},
+	{RateLimiterMaxEnv, "maximum delay of the exponential backoff of both queues"},
+	{QueueMaxDepthEnv, "depth of both queues after which the events of a Service or upstream are merged"},
+	{QueueDegradedAgeEnv, "how long the oldest event may wait in either queue before the replica is degraded"},
+	{DrainTimeoutEnv, "how long the servers of a cordoned node are drained before removal"},
+	{DeletedNodeDrainTimeoutEnv, "how long the servers of a deleted node are drained at most, 0s removes them at once"},
+	{DrainedConnectionsThresholdEnv, "active connections at or below which the servers of a deleted node are drained"},
+	{NotReadyGracePeriodEnv, "how long a node must be NotReady before its servers are removed"},
+	{ResyncPeriodEnv, "how often the informers redeliver the Services and Nodes, 0s disables the resync"},
+	{CacheSyncTimeoutEnv, "how long the informers may take to sync at startup before NLK fails to start"},
+	{NginxIngressNamespacesEnv, "comma-separated namespaces of the Services to watch"},
+	{ServiceSelectorEnv, "label selector of the Services to watch in every namespace"},
+	{UpstreamNameTemplateEnv, "template naming the upstreams, e.g. {namespace}-{name}"},
+	{TargetModeEnv, "nodes or endpointslices"},
+	{RbacModeEnv, "auto, cluster, or scoped"},
+	{AddressFamilyEnv, "ipv4, ipv6, or dual"},
+	{NodeAddressTypeEnv, "ordered node address types, e.g. ExternalIP,InternalIP"},
+	{NodeSelectorEnv, "label selector of the nodes used as upstream servers"},
+	{BackupNodeSelectorEnv, "label selector of the nodes whose upstream servers are backup"},
+	{ExcludeControlPlaneNodesEnv, "exclude the control-plane nodes"},
+	{ExcludedTaintKeysEnv, "comma-separated taint keys excluding the nodes"},
+	{HttpDialTimeoutEnv, "time allowed to connect to an NGINX Plus host"},
+	{HttpKeepAliveEnv, "interval between TCP keep-alive probes"},
+	{HttpTLSHandshakeTimeoutEnv, "time allowed for the TLS handshake"},
+	{HttpResponseHeaderTimeoutEnv, "time allowed to receive the response headers"},
+	{HttpRequestTimeoutEnv, "overall time allowed for an NGINX Plus API call"},
+	{HttpIdleConnTimeoutEnv, "how long idle connections are kept open"},
+	{HttpMaxIdleConnsEnv, "maximum idle connections across all hosts"},
+	{HttpMaxIdleConnsPerHostEnv, "maximum idle connections per host"},
+	{HttpMaxConnsPerHostEnv, "maximum connections per host, 0 does not limit them"},
+	{HttpEnableHTTP2Env, "negotiate HTTP/2 with the NGINX Plus hosts that support it"},
+	{HttpWriteRateLimitEnv, "NGINX Plus API writes per second per host, 0 disables the limit"},
+	{HttpWriteBurstEnv, "NGINX Plus API writes allowed at once above the rate"},
+	{HttpReadRateLimitEnv, "NGINX Plus API reads per second per host, 0 disables the limit"},
+	{HttpReadBurstEnv, "NGINX Plus API reads allowed at once above the rate"},
+	{ReadinessRequiredHostsEnv, "NGINX Plus hosts that must be reachable for /readyz to pass, any or all"},
+	{ReadinessCheckIntervalEnv, "how long /readyz caches the result of calling the hosts"},
+	{LeaderElectionEnv, "elect a leader so multiple replicas can run"},
+	{LeaseNameEnv, "name of the leader election Lease"},
+	{LeaseNamespaceEnv, "namespace of the leader election Lease"},
+	{LeaseDurationEnv, "duration of the leader election Lease"},
+	{LeaseRenewDeadlineEnv, "time allowed to renew the Lease before the leadership is lost"},
+	{LeaseRetryPeriodEnv, "interval between the attempts to acquire or renew the Lease"},
+	{AdminEnabledEnv, "serve the pprof handlers and runtime diagnostics on the admin address"},
+	{AdminAddressEnv, "host and port of the admin server, localhost by default"},
+	{TracingEndpointEnv, "URL of the OTLP/HTTP collector the traces are exported to, tracing is disabled when empty"},
+	{TracingSampleRatioEnv, "ratio of the traces recorded, between 0 and 1"},
+	{CertificateExpiryWarningsEnv, "comma-separated durations before a certificate expires at which a warning is logged"},
+	{CertificateExpiryCheckIntervalEnv, "interval between the checks of the certificate expiry"},
+	{KubeApiQpsEnv, "Kubernetes API calls per second allowed by the client-side rate limit"},
+	{KubeApiBurstEnv, "Kubernetes API calls allowed at once above the rate"},
+}
+
+// applyEnvironment overrides the default Settings values with any values found in the environment.
+func (s *Settings) applyEnvironment() error {
+	var err error
+
+	s.ConfigMapNamespace = stringFromEnv(ConfigMapNamespaceEnv, s.ConfigMapNamespace)
+	s.ConfigMapName = stringFromEnv(ConfigMapNameEnv, s.ConfigMapName)
+
+	s.LogFormat = stringFromEnv(LogFormatEnv, s.LogFormat)
+	if err = validateLogFormat(s.LogFormat); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, LogFormatEnv, err)
+	}
+
+	if logLevel, found := os.LookupEnv(LogLevelEnv); found && logLevel != "" {
+		if s.LogLevel, err = parseLogLevel(logLevel); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, LogLevelEnv, err)
+		}
+	}
+
+	if tlsMode, found := os.LookupEnv(TlsModeEnv); found && tlsMode != "" {
+		if s.DefaultTlsMode, err = parseTlsMode(tlsMode); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, TlsModeEnv, err)
+		}
+
+		s.TlsMode = s.DefaultTlsMode
+		s.defaultTlsModeSource = TlsModeSourceEnvironment
+	}
+
+	if s.DryRun, err = boolFromEnv(DryRunEnv, s.DryRun); err != nil {
+		return err
+	}
+
+	if s.HostsRetention, err = nonNegativeDurationFromEnv(HostsRetentionEnv, s.HostsRetention); err != nil {
+		return err
+	}
+
+	if s.HostsSrvInterval, err = positiveDurationFromEnv(HostsSrvIntervalEnv, s.HostsSrvInterval); err != nil {
+		return err
+	}
+
+	if s.HostsSrvRemovalDelay, err = positiveDurationFromEnv(HostsSrvRemovalDelayEnv, s.HostsSrvRemovalDelay); err != nil {
+		return err
+	}
+
+	if s.Handler.Threads, err = positiveIntFromEnv(HandlerThreadsEnv, s.Handler.Threads); err != nil {
+		return err
+	}
+
+	if s.Handler.RetryCount, err = positiveIntFromEnv(HandlerRetryCountEnv, s.Handler.RetryCount); err != nil {
+		return err
+	}
+
+	if s.Handler.DeleteDeferral, err = nonNegativeDurationFromEnv(DeleteDeferralEnv, s.Handler.DeleteDeferral); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.Threads, err = positiveIntFromEnv(SynchronizerThreadsEnv, s.Synchronizer.Threads); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.RetryCount, err = positiveIntFromEnv(SynchronizerRetryCountEnv, s.Synchronizer.RetryCount); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.CoalesceWindow, err = nonNegativeDurationFromEnv(CoalesceWindowEnv, s.Synchronizer.CoalesceWindow); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.Prune, err = boolFromEnv(PruneEnv, s.Synchronizer.Prune); err != nil {
+		return err
+	}
+
+	s.Synchronizer.OwnershipTag = stringFromEnv(OwnershipTagEnv, s.Synchronizer.OwnershipTag)
+
+	if s.Synchronizer.ForcePrune, err = boolFromEnv(ForcePruneEnv, s.Synchronizer.ForcePrune); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.ReconcileInterval, err = positiveDurationFromEnv(ReconcileIntervalEnv, s.Synchronizer.ReconcileInterval); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.HostStagger, err = nonNegativeDurationFromEnv(HostStaggerEnv, s.Synchronizer.HostStagger); err != nil {
+		return err
+	}
+
+	s.Synchronizer.EmptyServerPolicy = stringFromEnv(EmptyServerPolicyEnv, s.Synchronizer.EmptyServerPolicy)
+	if err = ValidateEmptyServerPolicy(s.Synchronizer.EmptyServerPolicy); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, EmptyServerPolicyEnv, err)
+	}
+
+	if s.Synchronizer.ServerAdmissionMaxWait, err = nonNegativeDurationFromEnv(ServerAdmissionMaxWaitEnv, s.Synchronizer.ServerAdmissionMaxWait); err != nil {
+		return err
+	}
+
+	s.Synchronizer.ServerAdmissionPolicy = stringFromEnv(ServerAdmissionPolicyEnv, s.Synchronizer.ServerAdmissionPolicy)
+	if err = ValidateServerAdmissionPolicy(s.Synchronizer.ServerAdmissionPolicy); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, ServerAdmissionPolicyEnv, err)
+	}
+
+	s.Synchronizer.DriftPolicy = stringFromEnv(DriftPolicyEnv, s.Synchronizer.DriftPolicy)
+	if err = ValidateDriftPolicy(s.Synchronizer.DriftPolicy); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, DriftPolicyEnv, err)
+	}
+
+	s.Synchronizer.LoadBalancerIngressIps = stringListFromEnv(LoadBalancerIngressIpsEnv, s.Synchronizer.LoadBalancerIngressIps)
+	if err = ValidateLoadBalancerIngressIps(s.Synchronizer.LoadBalancerIngressIps); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, LoadBalancerIngressIpsEnv, err)
+	}
+
+	s.Synchronizer.DnsServiceName = stringFromEnv(DnsServiceNameEnv, s.Synchronizer.DnsServiceName)
+	if err = validateDnsServiceName(s.Synchronizer.DnsServiceName); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, DnsServiceNameEnv, err)
+	}
+
+	s.Synchronizer.DnsServiceNamespace = stringFromEnv(DnsServiceNamespaceEnv, s.Synchronizer.DnsServiceNamespace)
+	if err = validateDnsServiceNamespace(s.Synchronizer.DnsServiceNamespace); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, DnsServiceNamespaceEnv, err)
+	}
+
+	if s.Synchronizer.MissingUpstreamRetryInterval, err = positiveDurationFromEnv(MissingUpstreamRetryIntervalEnv, s.Synchronizer.MissingUpstreamRetryInterval); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.UpstreamTimeout, err = positiveDurationFromEnv(UpstreamTimeoutEnv, s.Synchronizer.UpstreamTimeout); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.ErrorLogWindow, err = nonNegativeDurationFromEnv(ErrorLogWindowEnv, s.Synchronizer.ErrorLogWindow); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.ConvergenceWarningThreshold, err = nonNegativeDurationFromEnv(ConvergenceWarningThresholdEnv, s.Synchronizer.ConvergenceWarningThreshold); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.CircuitBreakerThreshold, err = positiveIntFromEnv(CircuitBreakerThresholdEnv, s.Synchronizer.CircuitBreakerThreshold); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.CircuitBreakerBackoff, err = positiveDurationFromEnv(CircuitBreakerBackoffEnv, s.Synchronizer.CircuitBreakerBackoff); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.CircuitBreakerMaxBackoff, err = positiveDurationFromEnv(CircuitBreakerMaxBackoffEnv, s.Synchronizer.CircuitBreakerMaxBackoff); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.CircuitBreakerMaxBackoff < s.Synchronizer.CircuitBreakerBackoff {
+		return fmt.Errorf(`%s (%v) must not be less than %s (%v)`, CircuitBreakerMaxBackoffEnv, s.Synchronizer.CircuitBreakerMaxBackoff, CircuitBreakerBackoffEnv, s.Synchronizer.CircuitBreakerBackoff)
+	}
+
+	s.Synchronizer.BorderType = stringFromEnv(BorderTypeEnv, s.Synchronizer.BorderType)
+	if err = validateBorderType(s.Synchronizer.BorderType); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, BorderTypeEnv, err)
+	}
+
+	if s.Synchronizer.PersistState, err = boolFromEnv(PersistStateEnv, s.Synchronizer.PersistState); err != nil {
+		return err
+	}
+
+	s.Synchronizer.StateConfigMapName = stringFromEnv(StateConfigMapNameEnv, s.Synchronizer.StateConfigMapName)
+	if err = validateConfigMapName(s.Synchronizer.StateConfigMapName); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, StateConfigMapNameEnv, err)
+	}
+
+	if s.Synchronizer.StatePersistDebounce, err = positiveDurationFromEnv(StatePersistDebounceEnv, s.Synchronizer.StatePersistDebounce); err != nil {
+		return err
+	}
+
+	if s.Synchronizer.StatusAnnotationInterval, err = nonNegativeDurationFromEnv(StatusAnnotationIntervalEnv, s.Synchronizer.StatusAnnotationInterval); err != nil {
+		return err
+	}
+
+	s.Synchronizer.StatusConfigMapName = stringFromEnv(StatusConfigMapNameEnv, s.Synchronizer.StatusConfigMapName)
+	if err = validateConfigMapName(s.Synchronizer.StatusConfigMapName); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, StatusConfigMapNameEnv, err)
+	}
+
+	if s.Synchronizer.StatusConfigMapInterval, err = nonNegativeDurationFromEnv(StatusConfigMapIntervalEnv, s.Synchronizer.StatusConfigMapInterval); err != nil {
+		return err
+	}
+
+	for _, workQueueSettings := range []*WorkQueueSettings{&s.Handler.WorkQueueSettings, &s.Synchronizer.WorkQueueSettings} {
+		if workQueueSettings.RateLimiterBase, err = positiveDurationFromEnv(RateLimiterBaseEnv, workQueueSettings.RateLimiterBase); err != nil {
+			return err
+		}
+
+		if workQueueSettings.RateLimiterMax, err = positiveDurationFromEnv(RateLimiterMaxEnv, workQueueSettings.RateLimiterMax); err != nil {
+			return err
+		}
+
+		if workQueueSettings.RateLimiterMax < workQueueSettings.RateLimiterBase {
+			return fmt.Errorf(`%s (%v) must not be less than %s (%v)`, RateLimiterMaxEnv, workQueueSettings.RateLimiterMax, RateLimiterBaseEnv, workQueueSettings.RateLimiterBase)
+		}
+
+		if workQueueSettings.MaxDepth, err = positiveIntFromEnv(QueueMaxDepthEnv, workQueueSettings.MaxDepth); err != nil {
+			return err
+		}
+
+		if workQueueSettings.DegradedAge, err = positiveDurationFromEnv(QueueDegradedAgeEnv, workQueueSettings.DegradedAge); err != nil {
+			return err
+		}
+	}
+
+	if s.Watcher.DrainTimeout, err = positiveDurationFromEnv(DrainTimeoutEnv, s.Watcher.DrainTimeout); err != nil {
+		return err
+	}
+
+	if s.Watcher.DeletedNodeDrainTimeout, err = nonNegativeDurationFromEnv(DeletedNodeDrainTimeoutEnv, s.Watcher.DeletedNodeDrainTimeout); err != nil {
+		return err
+	}
+
+	if s.Watcher.DrainedConnectionsThreshold, err = nonNegativeIntFromEnv(DrainedConnectionsThresholdEnv, s.Watcher.DrainedConnectionsThreshold); err != nil {
+		return err
+	}
+
+	if s.Watcher.NotReadyGracePeriod, err = positiveDurationFromEnv(NotReadyGracePeriodEnv, s.Watcher.NotReadyGracePeriod); err != nil {
+		return err
+	}
+
+	if s.Watcher.ResyncPeriod, err = nonNegativeDurationFromEnv(ResyncPeriodEnv, s.Watcher.ResyncPeriod); err != nil {
+		return err
+	}
+
+	if s.Watcher.CacheSyncTimeout, err = positiveDurationFromEnv(CacheSyncTimeoutEnv, s.Watcher.CacheSyncTimeout); err != nil {
+		return err
+	}
+
+	if namespaces, found := os.LookupEnv(NginxIngressNamespacesEnv); found {
+		if s.Watcher.NginxIngressNamespaces, err = parseNamespaces(namespaces); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, NginxIngressNamespacesEnv, err)
+		}
+	}
+
+	if serviceSelector, found := os.LookupEnv(ServiceSelectorEnv); found {
+		if s.Watcher.ServiceSelector, err = parseServiceSelector(serviceSelector); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, ServiceSelectorEnv, err)
+		}
+	}
+
+	s.Watcher.UpstreamNameTemplate = stringFromEnv(UpstreamNameTemplateEnv, s.Watcher.UpstreamNameTemplate)
+	if err = validateUpstreamNameTemplate(s.Watcher.UpstreamNameTemplate); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, UpstreamNameTemplateEnv, err)
+	}
+
+	s.Watcher.TargetMode = stringFromEnv(TargetModeEnv, s.Watcher.TargetMode)
+	if err = validateTargetMode(s.Watcher.TargetMode); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, TargetModeEnv, err)
+	}
+
+	s.Watcher.RbacMode = stringFromEnv(RbacModeEnv, s.Watcher.RbacMode)
+	if err = validateRbacMode(s.Watcher.RbacMode); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, RbacModeEnv, err)
+	}
+
+	s.Watcher.AddressFamily = stringFromEnv(AddressFamilyEnv, s.Watcher.AddressFamily)
+	if err = validateAddressFamily(s.Watcher.AddressFamily); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, AddressFamilyEnv, err)
+	}
+
+	if nodeAddressTypes, found := os.LookupEnv(NodeAddressTypeEnv); found {
+		if s.Watcher.NodeAddressTypes, err = parseNodeAddressTypes(nodeAddressTypes); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, NodeAddressTypeEnv, err)
+		}
+	}
+
+	if nodeSelector, found := os.LookupEnv(NodeSelectorEnv); found {
+		if s.Watcher.NodeSelector, err = parseNodeSelector(nodeSelector); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, NodeSelectorEnv, err)
+		}
+	}
+
+	if backupNodeSelector, found := os.LookupEnv(BackupNodeSelectorEnv); found {
+		if s.Watcher.BackupNodeSelector, err = parseBackupNodeSelector(backupNodeSelector); err != nil {
+			return fmt.Errorf(`invalid value for %s: %w`, BackupNodeSelectorEnv, err)
+		}
+	}
+
+	if s.Watcher.ExcludeControlPlaneNodes, err = boolFromEnv(ExcludeControlPlaneNodesEnv, s.Watcher.ExcludeControlPlaneNodes); err != nil {
+		return err
+	}
+
+	s.Watcher.ExcludedTaintKeys = stringListFromEnv(ExcludedTaintKeysEnv, s.Watcher.ExcludedTaintKeys)
+
+	if err = s.applyHttpClientEnvironment(); err != nil {
+		return err
+	}
+
+	s.Readiness.RequiredHosts = stringFromEnv(ReadinessRequiredHostsEnv, s.Readiness.RequiredHosts)
+	if err = validateReadinessRequiredHosts(s.Readiness.RequiredHosts); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, ReadinessRequiredHostsEnv, err)
+	}
+
+	if s.Readiness.CheckInterval, err = positiveDurationFromEnv(ReadinessCheckIntervalEnv, s.Readiness.CheckInterval); err != nil {
+		return err
+	}
+
+	if s.Admin.Enabled, err = boolFromEnv(AdminEnabledEnv, s.Admin.Enabled); err != nil {
+		return err
+	}
+
+	s.Admin.Address = stringFromEnv(AdminAddressEnv, s.Admin.Address)
+	if err = validateAdminAddress(s.Admin.Address); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, AdminAddressEnv, err)
+	}
+
+	s.Tracing.Endpoint = stringFromEnv(TracingEndpointEnv, s.Tracing.Endpoint)
+	if err = validateTracingEndpoint(s.Tracing.Endpoint); err != nil {
+		return fmt.Errorf(`invalid value for %s: %w`, TracingEndpointEnv, err)
+	}
+
+	if s.Tracing.SampleRatio, err = nonNegativeFloatFromEnv(TracingSampleRatioEnv, s.Tracing.SampleRatio); err != nil {
+		return err
+	}
+
+	if s.Tracing.SampleRatio > 1 {
+		return fmt.Errorf(`invalid value for %s: %v must not be greater than 1`, TracingSampleRatioEnv, s.Tracing.SampleRatio)
+	}
+
+	if s.CertificateExpiry.Warnings, err = positiveDurationListFromEnv(CertificateExpiryWarningsEnv, s.CertificateExpiry.Warnings); err != nil {
+		return err
+	}
+
+	if s.CertificateExpiry.CheckInterval, err = positiveDurationFromEnv(CertificateExpiryCheckIntervalEnv, s.CertificateExpiry.CheckInterval); err != nil {
+		return err
+	}
+
+	return s.applyLeaderElectionEnvironment()
+}
+
+// applyHttpClientEnvironment overrides the HttpClientSettings with any values found in the environment.
+func (s *Settings) applyHttpClientEnvironment() error {
+	var err error
+	httpClient := &s.HttpClient
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{HttpDialTimeoutEnv, &httpClient.DialTimeout},
+		{HttpKeepAliveEnv, &httpClient.KeepAlive},
+		{HttpTLSHandshakeTimeoutEnv, &httpClient.TLSHandshakeTimeout},
+		{HttpResponseHeaderTimeoutEnv, &httpClient.ResponseHeaderTimeout},
+		{HttpRequestTimeoutEnv, &httpClient.RequestTimeout},
+		{HttpIdleConnTimeoutEnv, &httpClient.IdleConnTimeout},
+	}
+
+	for _, duration := range durations {
+		if *duration.value, err = positiveDurationFromEnv(duration.name, *duration.value); err != nil {
+			return err
+		}
+	}
+
+	if httpClient.MaxIdleConns, err = positiveIntFromEnv(HttpMaxIdleConnsEnv, httpClient.MaxIdleConns); err != nil {
+		return err
+	}
+
+	if httpClient.MaxIdleConnsPerHost, err = positiveIntFromEnv(HttpMaxIdleConnsPerHostEnv, httpClient.MaxIdleConnsPerHost); err != nil {
+		return err
+	}
+
+	if httpClient.MaxConnsPerHost, err = nonNegativeIntFromEnv(HttpMaxConnsPerHostEnv, httpClient.MaxConnsPerHost); err != nil {
+		return err
+	}
+
+	if httpClient.EnableHTTP2, err = boolFromEnv(HttpEnableHTTP2Env, httpClient.EnableHTTP2); err != nil {
+		return err
+	}
+
+	rateLimiters := []struct {
+		rateName  string
+		burstName string
+		value     *RateLimiterSettings
+	}{
+		{HttpWriteRateLimitEnv, HttpWriteBurstEnv, &httpClient.WriteRateLimiter},
+		{HttpReadRateLimitEnv, HttpReadBurstEnv, &httpClient.ReadRateLimiter},
+	}
+
+	for _, rateLimiter := range rateLimiters {
+		if rateLimiter.value.Rate, err = nonNegativeFloatFromEnv(rateLimiter.rateName, rateLimiter.value.Rate); err != nil {
+			return err
+		}
+
+		if rateLimiter.value.Burst, err = positiveIntFromEnv(rateLimiter.burstName, rateLimiter.value.Burst); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyLeaderElectionEnvironment overrides the LeaderElectionSettings with any values found in the environment.
+func (s *Settings) applyLeaderElectionEnvironment() error {
+	var err error
+	leaderElection := &s.LeaderElection
+
+	if leaderElection.Enabled, err = boolFromEnv(LeaderElectionEnv, leaderElection.Enabled); err != nil {
+		return err
+	}
+
+	leaderElection.LeaseName = stringFromEnv(LeaseNameEnv, leaderElection.LeaseName)
+	leaderElection.LeaseNamespace = stringFromEnv(LeaseNamespaceEnv, s.ConfigMapNamespace)
+
+	if leaderElection.LeaseDuration, err = positiveDurationFromEnv(LeaseDurationEnv, leaderElection.LeaseDuration); err != nil {
+		return err
+	}
+
+	if leaderElection.RenewDeadline, err = positiveDurationFromEnv(LeaseRenewDeadlineEnv, leaderElection.RenewDeadline); err != nil {
+		return err
+	}
+
+	if leaderElection.RetryPeriod, err = positiveDurationFromEnv(LeaseRetryPeriodEnv, leaderElection.RetryPeriod); err != nil {
+		return err
+	}
+
+	if leaderElection.RenewDeadline >= leaderElection.LeaseDuration {
+		return fmt.Errorf(`%s (%v) must be less than %s (%v)`, LeaseRenewDeadlineEnv, leaderElection.RenewDeadline, LeaseDurationEnv, leaderElection.LeaseDuration)
+	}
+
+	if leaderElection.RetryPeriod >= leaderElection.RenewDeadline {
+		return fmt.Errorf(`%s (%v) must be less than %s (%v)`, LeaseRetryPeriodEnv, leaderElection.RetryPeriod, LeaseRenewDeadlineEnv, leaderElection.RenewDeadline)
+	}
+
+	return nil
+}
+
+// applyKubeApiEnvironment overrides the KubeApiSettings with any values found in the environment.
+func applyKubeApiEnvironment(kubeApi *KubeApiSettings) error {
+	qps, err := nonNegativeFloatFromEnv(KubeApiQpsEnv, float64(kubeApi.QPS))
+	if err != nil {
+		return err
+	}
+
+	// client-go falls back to its own default of 5 calls per second when the QPS is zero
+	if qps == 0 {
+		return fmt.Errorf(`invalid value for %s: 0 must be positive`, KubeApiQpsEnv)
+	}
+
+	kubeApi.QPS = float32(qps)
+
+	if kubeApi.Burst, err = positiveIntFromEnv(KubeApiBurstEnv, kubeApi.Burst); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stringFromEnv returns the value of the named environment variable, or the default value if the variable is not set.
+func stringFromEnv(name string, defaultValue string) string {
+	if value, found := os.LookupEnv(name); found && value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+// stringListFromEnv returns the value of the named environment variable as a list of the comma-separated, non-empty values,
+// or the default value if the variable is not set.
+func stringListFromEnv(name string, defaultValue []string) []string {
+	raw, found := os.LookupEnv(name)
+	if !found {
+		return defaultValue
+	}
+
+	values := []string{}
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// boolFromEnv returns the value of the named environment variable as a bool,
+// or the default value if the variable is not set.
+func boolFromEnv(name string, defaultValue bool) (bool, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not a boolean`, name, raw)
+	}
+
+	return value, nil
+}
+
+// positiveIntFromEnv returns the value of the named environment variable as a positive integer,
+// or the default value if the variable is not set.
+func positiveIntFromEnv(name string, defaultValue int) (int, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not an integer`, name, raw)
+	}
+
+	if value <= 0 {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %d must be greater than zero`, name, value)
+	}
+
+	return value, nil
+}
+
+// nonNegativeIntFromEnv returns the value of the named environment variable as an integer that may be zero,
+// or the default value if the variable is not set.
+func nonNegativeIntFromEnv(name string, defaultValue int) (int, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not an integer`, name, raw)
+	}
+
+	if value < 0 {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %d must not be negative`, name, value)
+	}
+
+	return value, nil
+}
+
+// nonNegativeFloatFromEnv returns the value of the named environment variable as a number that may be zero,
+// or the default value if the variable is not set.
+func nonNegativeFloatFromEnv(name string, defaultValue float64) (float64, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not a number`, name, raw)
+	}
+
+	if value < 0 {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %v must not be negative`, name, value)
+	}
+
+	return value, nil
+}
+
+// positiveDurationFromEnv returns the value of the named environment variable as a positive time.Duration,
+// or the default value if the variable is not set.
+func positiveDurationFromEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not a duration`, name, raw)
+	}
+
+	if value <= 0 {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %v must be greater than zero`, name, value)
+	}
+
+	return value, nil
+}
+
+// positiveDurationListFromEnv returns the value of the named environment variable as a list of the comma-separated,
+// positive time.Duration values, or the default value if the variable is not set.
+func positiveDurationListFromEnv(name string, defaultValue []time.Duration) ([]time.Duration, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	values := []time.Duration{}
+	for _, entry := range strings.Split(raw, ",") {
+		value, err := time.ParseDuration(strings.TrimSpace(entry))
+		if err != nil {
+			return defaultValue, fmt.Errorf(`invalid value for %s: %q is not a duration`, name, entry)
+		}
+
+		if value <= 0 {
+			return defaultValue, fmt.Errorf(`invalid value for %s: %v must be greater than zero`, name, value)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// nonNegativeDurationFromEnv returns the value of the named environment variable as a time.Duration that may be zero,
+// or the default value if the variable is not set.
+func nonNegativeDurationFromEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	raw, found := os.LookupEnv(name)
+	if !found || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %q is not a duration`, name, raw)
+	}
+
+	if value < 0 {
+		return defaultValue, fmt.Errorf(`invalid value for %s: %v must not be negative`, name, value)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,361 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSettings_Defaults(t *testing.T) {
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.Handler.Threads != 1 {
+		t.Errorf(`expected 1 handler thread, got %d`, settings.Handler.Threads)
+	}
+
+	if settings.Synchronizer.WorkQueueSettings.RateLimiterBase != time.Second*2 {
+		t.Errorf(`expected a 2s rate limiter base, got %v`, settings.Synchronizer.WorkQueueSettings.RateLimiterBase)
+	}
+
+	if !settings.Watcher.NodeSelector.Empty() {
+		t.Errorf(`expected the node selector to select every node, got %q`, settings.Watcher.NodeSelector.String())
+	}
+
+	if settings.Watcher.BackupNodeSelector != nil {
+		t.Errorf(`expected no backup node selector, got %q`, settings.Watcher.BackupNodeSelector.String())
+	}
+
+	if !settings.Watcher.ServiceSelector.Empty() {
+		t.Errorf(`expected the namespaces to be watched by default, got the %q service selector`, settings.Watcher.ServiceSelector.String())
+	}
+}
+
+func TestNewSettings_EnvironmentOverrides(t *testing.T) {
+	t.Setenv(HandlerThreadsEnv, "4")
+	t.Setenv(HandlerRetryCountEnv, "7")
+	t.Setenv(SynchronizerThreadsEnv, "8")
+	t.Setenv(RateLimiterBaseEnv, "250ms")
+	t.Setenv(RateLimiterMaxEnv, "30s")
+	t.Setenv(DrainTimeoutEnv, "90s")
+	t.Setenv(DeletedNodeDrainTimeoutEnv, "0s")
+	t.Setenv(NotReadyGracePeriodEnv, "30s")
+	t.Setenv(ResyncPeriodEnv, "10m")
+	t.Setenv(HostsRetentionEnv, "0s")
+	t.Setenv(NodeSelectorEnv, "node-role.kubernetes.io/ingress=true")
+	t.Setenv(BackupNodeSelectorEnv, "nkl.nginx.com/backup=true")
+	t.Setenv(NodeAddressTypeEnv, "ExternalIP, InternalIP")
+	t.Setenv(ExcludeControlPlaneNodesEnv, "false")
+	t.Setenv(ExcludedTaintKeysEnv, "node.kubernetes.io/unreachable, node.kubernetes.io/not-ready")
+
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.Handler.Threads != 4 {
+		t.Errorf(`expected 4 handler threads, got %d`, settings.Handler.Threads)
+	}
+
+	if settings.Handler.RetryCount != 7 {
+		t.Errorf(`expected 7 handler retries, got %d`, settings.Handler.RetryCount)
+	}
+
+	if settings.Synchronizer.Threads != 8 {
+		t.Errorf(`expected 8 synchronizer threads, got %d`, settings.Synchronizer.Threads)
+	}
+
+	if settings.Watcher.DrainTimeout != time.Second*90 {
+		t.Errorf(`expected a 90s drain timeout, got %v`, settings.Watcher.DrainTimeout)
+	}
+
+	if settings.Watcher.DeletedNodeDrainTimeout != 0 {
+		t.Errorf(`expected the deleted nodes not to be drained, got %v`, settings.Watcher.DeletedNodeDrainTimeout)
+	}
+
+	if settings.Watcher.NotReadyGracePeriod != time.Second*30 {
+		t.Errorf(`expected a 30s not ready grace period, got %v`, settings.Watcher.NotReadyGracePeriod)
+	}
+
+	if settings.HostsRetention != 0 {
+		t.Errorf(`expected the hosts retention to be disabled, got %v`, settings.HostsRetention)
+	}
+
+	if settings.Watcher.ResyncPeriod != time.Minute*10 {
+		t.Errorf(`expected a 10m resync period, got %v`, settings.Watcher.ResyncPeriod)
+	}
+
+	if settings.Watcher.NodeSelector.String() != "node-role.kubernetes.io/ingress=true" {
+		t.Errorf(`expected the ingress node selector, got %q`, settings.Watcher.NodeSelector.String())
+	}
+
+	if settings.Watcher.BackupNodeSelector == nil || settings.Watcher.BackupNodeSelector.String() != "nkl.nginx.com/backup=true" {
+		t.Errorf(`expected the backup node selector, got %q`, selectorString(settings.Watcher.BackupNodeSelector))
+	}
+
+	if settings.Watcher.ExcludeControlPlaneNodes {
+		t.Errorf(`expected the control-plane nodes to be included`)
+	}
+
+	if len(settings.Watcher.NodeAddressTypes) != 2 || settings.Watcher.NodeAddressTypes[0] != "ExternalIP" {
+		t.Errorf(`expected ExternalIP then InternalIP, got %v`, settings.Watcher.NodeAddressTypes)
+	}
+
+	if len(settings.Watcher.ExcludedTaintKeys) != 2 || settings.Watcher.ExcludedTaintKeys[1] != "node.kubernetes.io/not-ready" {
+		t.Errorf(`expected two excluded taint keys, got %v`, settings.Watcher.ExcludedTaintKeys)
+	}
+
+	for _, workQueueSettings := range []WorkQueueSettings{settings.Handler.WorkQueueSettings, settings.Synchronizer.WorkQueueSettings} {
+		if workQueueSettings.RateLimiterBase != time.Millisecond*250 {
+			t.Errorf(`expected a 250ms rate limiter base for %s, got %v`, workQueueSettings.Name, workQueueSettings.RateLimiterBase)
+		}
+
+		if workQueueSettings.RateLimiterMax != time.Second*30 {
+			t.Errorf(`expected a 30s rate limiter max for %s, got %v`, workQueueSettings.Name, workQueueSettings.RateLimiterMax)
+		}
+	}
+}
+
+func TestNewSettings_RejectsInvalidEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"non-numeric threads", HandlerThreadsEnv, "many"},
+		{"zero threads", SynchronizerThreadsEnv, "0"},
+		{"negative retries", HandlerRetryCountEnv, "-1"},
+		{"unparseable duration", RateLimiterBaseEnv, "soon"},
+		{"negative duration", RateLimiterMaxEnv, "-5s"},
+		{"max less than base", RateLimiterMaxEnv, "1s"},
+		{"zero drain timeout", DrainTimeoutEnv, "0s"},
+		{"negative deleted node drain timeout", DeletedNodeDrainTimeoutEnv, "-1m"},
+		{"negative drained connections threshold", DrainedConnectionsThresholdEnv, "-1"},
+		{"negative resync period", ResyncPeriodEnv, "-1m"},
+		{"zero cache sync timeout", CacheSyncTimeoutEnv, "0s"},
+		{"negative delete deferral", DeleteDeferralEnv, "-10s"},
+		{"zero kube API QPS", KubeApiQpsEnv, "0"},
+		{"negative max connections per host", HttpMaxConnsPerHostEnv, "-1"},
+		{"non-boolean HTTP/2", HttpEnableHTTP2Env, "sometimes"},
+		{"zero kube API burst", KubeApiBurstEnv, "0"},
+		{"negative hosts retention", HostsRetentionEnv, "-5m"},
+		{"zero hosts srv interval", HostsSrvIntervalEnv, "0s"},
+		{"zero upstream timeout", UpstreamTimeoutEnv, "0s"},
+		{"negative error log window", ErrorLogWindowEnv, "-1m"},
+		{"negative convergence warning threshold", ConvergenceWarningThresholdEnv, "-5s"},
+		{"invalid hosts srv removal delay", HostsSrvRemovalDelayEnv, "soon"},
+		{"invalid namespace", NginxIngressNamespacesEnv, "nginx-ingress,Public"},
+		{"no namespaces", NginxIngressNamespacesEnv, " , "},
+		{"unparseable service selector", ServiceSelectorEnv, "nkl.nginx.com/managed in (true"},
+		{"upstream name template without the name", UpstreamNameTemplateEnv, "{namespace}"},
+		{"unknown target mode", TargetModeEnv, "pods"},
+		{"unknown RBAC mode", RbacModeEnv, "namespaced"},
+		{"unknown address family", AddressFamilyEnv, "ipx"},
+		{"unknown node address type", NodeAddressTypeEnv, "ExternalIP,Hostname"},
+		{"unparseable node selector", NodeSelectorEnv, "role in (ingress"},
+		{"unparseable backup node selector", BackupNodeSelectorEnv, "nkl.nginx.com/backup in (true"},
+		{"non-boolean control-plane exclusion", ExcludeControlPlaneNodesEnv, "sometimes"},
+		{"unknown log format", LogFormatEnv, "xml"},
+		{"unknown log level", LogLevelEnv, "verbose"},
+		{"unknown tls mode", TlsModeEnv, "ca-mlts"},
+		{"non-boolean dry run", DryRunEnv, "perhaps"},
+		{"unknown readiness required hosts", ReadinessRequiredHostsEnv, "most"},
+		{"zero readiness check interval", ReadinessCheckIntervalEnv, "0s"},
+		{"negative coalesce window", CoalesceWindowEnv, "-1s"},
+		{"non-boolean prune", PruneEnv, "maybe"},
+		{"non-boolean force prune", ForcePruneEnv, "always"},
+		{"zero reconcile interval", ReconcileIntervalEnv, "0s"},
+		{"negative host stagger", HostStaggerEnv, "-1s"},
+		{"unknown empty server policy", EmptyServerPolicyEnv, "ignore"},
+		{"unknown drift policy", DriftPolicyEnv, "revert"},
+		{"negative server admission max wait", ServerAdmissionMaxWaitEnv, "-1m"},
+		{"unknown server admission policy", ServerAdmissionPolicyEnv, "wait"},
+		{"load balancer ingress hostname", LoadBalancerIngressIpsEnv, "192.0.2.10,nginx.example.com"},
+		{"dns service name with a dot", DnsServiceNameEnv, "nlk.dns"},
+		{"dns service namespace in upper case", DnsServiceNamespaceEnv, "NLK"},
+		{"zero missing upstream retry interval", MissingUpstreamRetryIntervalEnv, "0s"},
+		{"zero circuit breaker threshold", CircuitBreakerThresholdEnv, "0"},
+		{"zero circuit breaker backoff", CircuitBreakerBackoffEnv, "0s"},
+		{"circuit breaker max backoff less than the backoff", CircuitBreakerMaxBackoffEnv, "1s"},
+		{"unknown border type", BorderTypeEnv, "nginx-oss"},
+		{"non-boolean persist state", PersistStateEnv, "maybe"},
+		{"invalid state ConfigMap name", StateConfigMapNameEnv, "nlk_state"},
+		{"zero state persist debounce", StatePersistDebounceEnv, "0s"},
+		{"negative status annotation interval", StatusAnnotationIntervalEnv, "-1m"},
+		{"invalid status ConfigMap name", StatusConfigMapNameEnv, "nlk_status"},
+		{"negative status ConfigMap interval", StatusConfigMapIntervalEnv, "-30s"},
+		{"non-numeric write rate limit", HttpWriteRateLimitEnv, "fast"},
+		{"negative read rate limit", HttpReadRateLimitEnv, "-1"},
+		{"zero write burst", HttpWriteBurstEnv, "0"},
+		{"zero queue max depth", QueueMaxDepthEnv, "0"},
+		{"zero queue degraded age", QueueDegradedAgeEnv, "0s"},
+		{"non-boolean admin enabled", AdminEnabledEnv, "on"},
+		{"admin address without a port", AdminAddressEnv, "127.0.0.1"},
+		{"tracing endpoint without a scheme", TracingEndpointEnv, "otel-collector:4318"},
+		{"tracing sample ratio greater than 1", TracingSampleRatioEnv, "1.5"},
+		{"unparseable certificate expiry warning", CertificateExpiryWarningsEnv, "720h,7d"},
+		{"zero certificate expiry warning", CertificateExpiryWarningsEnv, "720h,0s"},
+		{"zero certificate expiry check interval", CertificateExpiryCheckIntervalEnv, "0s"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.key, test.value)
+
+			if _, err := NewSettings(context.Background(), nil); err == nil {
+				t.Errorf(`expected an error for %s=%q`, test.key, test.value)
+			}
+		})
+	}
+}
+
+func TestNewSettings_LeaderElectionOverrides(t *testing.T) {
+	t.Setenv(ConfigMapNamespaceEnv, "acme-nlk")
+	t.Setenv(LeaderElectionEnv, "true")
+	t.Setenv(LeaseNameEnv, "acme-leader")
+	t.Setenv(LeaseDurationEnv, "30s")
+
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !settings.LeaderElection.Enabled {
+		t.Errorf(`expected leader election to be enabled`)
+	}
+
+	if settings.LeaderElection.LeaseName != "acme-leader" {
+		t.Errorf(`expected the acme-leader Lease, got %s`, settings.LeaderElection.LeaseName)
+	}
+
+	if settings.LeaderElection.LeaseNamespace != "acme-nlk" {
+		t.Errorf(`expected the Lease namespace to default to the ConfigMap namespace, got %s`, settings.LeaderElection.LeaseNamespace)
+	}
+
+	if settings.LeaderElection.LeaseDuration != time.Second*30 {
+		t.Errorf(`expected a 30s lease duration, got %v`, settings.LeaderElection.LeaseDuration)
+	}
+}
+
+func TestNewSettings_InvalidLeaderElection(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"not a bool":                    {LeaderElectionEnv: "maybe"},
+		"renew deadline exceeds lease":  {LeaseRenewDeadlineEnv: "20s"},
+		"retry period exceeds deadline": {LeaseRetryPeriodEnv: "10s"},
+		"non-positive lease duration":   {LeaseDurationEnv: "0s"},
+	}
+
+	for name, environment := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range environment {
+				t.Setenv(key, value)
+			}
+
+			if _, err := NewSettings(context.Background(), nil); err == nil {
+				t.Fatalf(`expected an error`)
+			}
+		})
+	}
+}
+
+func TestNewSettings_HttpClientOverrides(t *testing.T) {
+	t.Setenv(HttpRequestTimeoutEnv, "3s")
+	t.Setenv(HttpMaxIdleConnsPerHostEnv, "4")
+	t.Setenv(HttpMaxConnsPerHostEnv, "8")
+	t.Setenv(HttpEnableHTTP2Env, "false")
+
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.HttpClient.RequestTimeout != time.Second*3 {
+		t.Errorf(`expected a 3s request timeout, got %v`, settings.HttpClient.RequestTimeout)
+	}
+
+	if settings.HttpClient.MaxIdleConnsPerHost != 4 {
+		t.Errorf(`expected 4 idle connections per host, got %d`, settings.HttpClient.MaxIdleConnsPerHost)
+	}
+
+	if settings.HttpClient.MaxConnsPerHost != 8 || settings.HttpClient.EnableHTTP2 {
+		t.Errorf(`expected 8 connections per host over HTTP/1.1, got %d, HTTP/2 %t`, settings.HttpClient.MaxConnsPerHost, settings.HttpClient.EnableHTTP2)
+	}
+
+	if settings.HttpClient.DialTimeout != time.Second*5 {
+		t.Errorf(`expected the default 5s dial timeout, got %v`, settings.HttpClient.DialTimeout)
+	}
+}
+
+func TestNewSettings_HttpRateLimiterOverrides(t *testing.T) {
+	t.Setenv(HttpWriteRateLimitEnv, "2.5")
+	t.Setenv(HttpWriteBurstEnv, "5")
+	t.Setenv(HttpReadRateLimitEnv, "0")
+
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if limiter := settings.HttpClient.WriteRateLimiter; limiter.Rate != 2.5 || limiter.Burst != 5 {
+		t.Errorf(`expected 2.5 writes per second with a burst of 5, got %#v`, limiter)
+	}
+
+	if limiter := settings.HttpClient.ReadRateLimiter; limiter.Rate != 0 || limiter.Burst != 200 {
+		t.Errorf(`expected the reads to be unlimited with the default burst, got %#v`, limiter)
+	}
+}
+
+func TestNewSettings_InvalidHttpClientTimeout(t *testing.T) {
+	t.Setenv(HttpDialTimeoutEnv, "soon")
+
+	if _, err := NewSettings(context.Background(), nil); err == nil {
+		t.Fatalf(`expected an error`)
+	}
+}
+
+func TestEnvironmentVariables_ListsEveryVariable(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "environment.go", nil, 0)
+	if err != nil {
+		t.Fatalf(`error occurred parsing environment.go: %v`, err)
+	}
+
+	listed := make(map[string]bool)
+	for _, variable := range EnvironmentVariables {
+		if listed[variable.Name] {
+			t.Errorf(`%s is listed twice`, variable.Name)
+		}
+		listed[variable.Name] = true
+	}
+
+	declared := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || !strings.HasSuffix(spec.Names[0].Name, "Env") || len(spec.Values) != 1 {
+			return true
+		}
+
+		if literal, ok := spec.Values[0].(*ast.BasicLit); ok {
+			declared++
+			if name := strings.Trim(literal.Value, `"`); !listed[name] {
+				t.Errorf(`%s is not listed in EnvironmentVariables`, name)
+			}
+		}
+
+		return true
+	})
+
+	if declared != len(EnvironmentVariables) {
+		t.Errorf(`expected %d variables to be listed, got %d`, declared, len(EnvironmentVariables))
+	}
+}
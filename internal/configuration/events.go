@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventSourceComponent identifies NLK as the source of the Kubernetes Events it records.
+	EventSourceComponent = "nginx-loadbalancer-kubernetes"
+
+	// InvalidConfigurationReason is the reason used for Events recorded when the configuration cannot be parsed.
+	InvalidConfigurationReason = "InvalidConfiguration"
+
+	// InvalidAnnotationReason is the reason used for Events recorded when a Service annotation cannot be parsed.
+	InvalidAnnotationReason = "InvalidAnnotation"
+
+	// SyncedReason is the reason used for Events recorded on a Service when its upstream has been updated on the NGINX Plus hosts.
+	SyncedReason = "Synced"
+
+	// SyncFailedReason is the reason used for Events recorded on a Service when an NGINX Plus host could not be updated
+	// after RetryCount attempts.
+	SyncFailedReason = "SyncFailed"
+
+	// SecondarySyncFailedReason is the reason used for Events recorded on a Service when a secondary NGINX Plus host
+	// could not be updated after RetryCount attempts, which does not affect the readiness.
+	SecondarySyncFailedReason = "SecondarySyncFailed"
+
+	// SyncRejectedReason is the reason used for Events recorded on a Service when an NGINX Plus host rejected the update
+	// of its upstream with an error that retrying will not fix, e.g. an invalid parameter; the update is not retried.
+	SyncRejectedReason = "SyncRejected"
+
+	// EmptyServersRetainedReason is the reason used for Events recorded on a Service when an update that would leave its
+	// upstream without servers was not applied, as the EmptyServerPolicy is retain.
+	EmptyServersRetainedReason = "EmptyServersRetained"
+
+	// EmptyServersRejectedReason is the reason used for Events recorded on a Service when an update that would leave its
+	// upstream without servers was rejected, as the EmptyServerPolicy is fail.
+	EmptyServersRejectedReason = "EmptyServersRejected"
+
+	// UpstreamNotFoundReason is the reason used for Events recorded on a Service when its upstream is not defined in the
+	// NGINX Plus configuration of a host.
+	UpstreamNotFoundReason = "UpstreamNotFound"
+
+	// HealthCheckNotAlignedReason is the reason used for Events recorded on a Service whose externalTrafficPolicy is Local
+	// when NGINX Plus does not actively health check the servers of its upstream, so the nodes without a ready endpoint
+	// keep receiving traffic they drop.
+	HealthCheckNotAlignedReason = "HealthCheckNotAligned"
+
+	// DriftDetectedReason is the reason used for Events recorded on a Service when the servers of its upstream on an NGINX Plus
+	// host differ from the servers last applied, e.g. a server added or removed through the NGINX Plus dashboard.
+	DriftDetectedReason = "DriftDetected"
+
+	// UnresolvedPortReason is the reason used for Events recorded on a Service when the port of the upstream servers of one
+	// of its ports cannot be resolved: the Service has no nodePort, and no host port is declared, or the named targetPort
+	// of a Service using host ports has no endpoint; the port is skipped.
+	UnresolvedPortReason = "UnresolvedPort"
+
+	// eventBurstSize and eventQPS limit the Events recorded per object, so a flapping host cannot flood the API with Events;
+	// up to eventBurstSize Events are recorded at once, then one every 30 seconds.
+	eventBurstSize = 10
+	eventQPS       = 1.0 / 30
+)
+
+// buildEventRecorder creates the recorder used to record Events; the broadcaster is shut down with the Context.
+// Events are rate limited per object, and repeated Events are aggregated by the broadcaster.
+func (s *Settings) buildEventRecorder() record.EventRecorder {
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize: eventBurstSize,
+		QPS:       eventQPS,
+	})
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: s.K8sClient.CoreV1().Events("")})
+
+	go func() {
+		<-s.Context.Done()
+		broadcaster.Shutdown()
+	}()
+
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: EventSourceComponent})
+}
+
+// recordWarning records a Warning Event on the ConfigMap.
+func (s *Settings) recordWarning(configMap *corev1.ConfigMap, reason string, message string) {
+	if s.EventRecorder == nil {
+		return
+	}
+
+	s.EventRecorder.Event(configMap, corev1.EventTypeWarning, reason, message)
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HandlerSettings contains the configuration values needed by the Handler.
+type HandlerSettings struct {
+
+	// RetryCount is the number of times the Handler will attempt to process a message before giving up.
+	RetryCount int
+
+	// Threads is the number of threads that will be used to process messages.
+	Threads int
+
+	// WorkQueueSettings is the configuration for the Handler's queue.
+	WorkQueueSettings WorkQueueSettings
+
+	// DeleteDeferral is how long the deletion of a Service is held, so that a Service deleted then recreated with the
+	// same name, e.g. by a CI pipeline, replaces its servers rather than removing them until it is recreated; zero
+	// deletes the servers at once. The deletions are not held while NLK shuts down.
+	DeleteDeferral time.Duration
+}
+
+// logFields returns the settings of the Handler, along with those of its queue, as log fields.
+func (s HandlerSettings) logFields() logrus.Fields {
+	fields := s.WorkQueueSettings.logFields()
+	fields["retries"] = s.RetryCount
+	fields["threads"] = s.Threads
+	fields["deleteDeferral"] = s.DeleteDeferral
+
+	return fields
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecondaryHostsKey is the ConfigMap key listing the secondary NGINX Plus hosts, comma-separated like nginx-hosts.
+	SecondaryHostsKey = "nginx-hosts-secondary"
+
+	// HostsKeyPrefix is the prefix of the ConfigMap keys listing primary NGINX Plus hosts alongside nginx-hosts, e.g.
+	// nginx-hosts-canary, so the fleets edited by different teams are listed in different keys, see hostKeysOf.
+	HostsKeyPrefix = "nginx-hosts-"
+
+	// HostsSrvKey is the ConfigMap key naming a DNS SRV record whose targets are NGINX Plus hosts, see parseHostsSrv.
+	HostsSrvKey = "nginx-hosts-srv"
+)
+
+// applyHostKeys applies the hosts listed in the nginx-hosts keys, merged in the order of hostKeysOf, and in the
+// SecondaryHostsKey. A host listed in several keys is kept once, tagged with the first key listing it, so removing a key
+// only removes the hosts no other key lists. The current hosts are kept, and a Warning Event is recorded on the ConfigMap,
+// when the format of one of the values cannot be determined.
+func (s *Settings) applyHostKeys(configMap *corev1.ConfigMap, hostKeys []string, secondaryHosts string) {
+	var primary []string
+	keys := make(map[string]string)
+
+	for _, key := range hostKeys {
+		newHosts, errorCount, err := s.parseHosts(configMap.Data[key])
+		if err != nil {
+			s.rejectHostKey(configMap, key, err)
+			return
+		}
+
+		if errorCount > 0 {
+			logrus.Warnf("Settings::applyHostKeys: %d invalid %s entries were skipped, using %d host(s): %v", errorCount, key, len(newHosts), entriesOf(newHosts))
+		}
+
+		for _, host := range entriesOf(newHosts) {
+			if listedIn, found := keys[host]; found {
+				logrus.Infof("Settings::applyHostKeys: the host %s is listed in both the %s and %s keys, it is tagged with %s", host, listedIn, key, listedIn)
+				continue
+			}
+
+			keys[host] = key
+			primary = append(primary, host)
+		}
+	}
+
+	newSecondaryHosts, errorCount, err := s.parseHosts(secondaryHosts)
+	if err != nil {
+		s.rejectHostKey(configMap, SecondaryHostsKey, err)
+		return
+	}
+
+	if errorCount > 0 {
+		logrus.Warnf("Settings::applyHostKeys: %d invalid %s entries were skipped, using %d host(s): %v", errorCount, SecondaryHostsKey, len(newSecondaryHosts), entriesOf(newSecondaryHosts))
+	}
+
+	for _, host := range entriesOf(newSecondaryHosts) {
+		if _, found := keys[host]; !found {
+			keys[host] = SecondaryHostsKey
+		}
+	}
+
+	s.setHostKeys(keys)
+	s.SetHostGroups(primary, entriesOf(newSecondaryHosts))
+}
+
+// hostKeysOf returns the ConfigMap keys listing primary NGINX Plus hosts: nginx-hosts first, then the keys named with the
+// HostsKeyPrefix in alphabetical order, so the hosts are merged in the same order on every update and restart. The
+// SecondaryHostsKey and the HostsSrvKey share the prefix, but do not list primary hosts.
+func hostKeysOf(configMap *corev1.ConfigMap) []string {
+	var keys []string
+	for key := range configMap.Data {
+		if strings.HasPrefix(key, HostsKeyPrefix) && key != SecondaryHostsKey && key != HostsSrvKey {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	if _, found := configMap.Data["nginx-hosts"]; found {
+		keys = append([]string{"nginx-hosts"}, keys...)
+	}
+
+	return keys
+}
+
+// hostKeysByEntry tags each of the hosts with the key.
+func hostKeysByEntry(key string, hosts []string) map[string]string {
+	keys := make(map[string]string, len(hosts))
+	for _, host := range hosts {
+		keys[host] = key
+	}
+
+	return keys
+}
+
+// setHostKeys records the keys the static hosts are listed in, logs the hosts added to and removed from each key, and
+// exposes them in the nkl_host_key metric, so the hosts of a key can be told apart, e.g. the canary fleet.
+func (s *Settings) setHostKeys(keys map[string]string) {
+	var names []string
+	for _, key := range slices.Concat(slices.Collect(maps.Values(s.hostKeys)), slices.Collect(maps.Values(keys))) {
+		if !slices.Contains(names, key) {
+			names = append(names, key)
+		}
+	}
+
+	slices.Sort(names)
+
+	for _, key := range names {
+		previous, current := hostsOfKey(s.hostKeys, key), hostsOfKey(keys, key)
+		added, removed := missingFrom(previous, current), missingFrom(current, previous)
+
+		if len(added) > 0 || len(removed) > 0 {
+			logrus.WithField("key", key).Infof("Settings::setHostKeys: added %v, removed %v", added, removed)
+		}
+	}
+
+	s.hostKeys = keys
+	instrumentation.ObserveHostKeys(keys)
+}
+
+// hostsOfKey returns the hosts tagged with the key, sorted.
+func hostsOfKey(keys map[string]string, key string) []string {
+	var hosts []string
+	for host, hostKey := range keys {
+		if hostKey == key {
+			hosts = append(hosts, host)
+		}
+	}
+
+	slices.Sort(hosts)
+
+	return hosts
+}
+
+// rejectHostKey reports a value of the nginx-hosts keys that cannot be parsed, the current hosts are kept.
+func (s *Settings) rejectHostKey(configMap *corev1.ConfigMap, key string, err error) {
+	logrus.Errorf("Settings::applyHostKeys: the %s key is invalid, the NGINX Plus hosts have NOT been changed: %v", key, err)
+	s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the %s key is invalid and has not been applied: %v", key, err))
+}
+
+// parseHosts splits the nginx-hosts value into a list of hosts, see splitHosts and parseHostList. An error is returned,
+// rather than the hosts, when the format of the value cannot be determined.
+func (s *Settings) parseHosts(hosts string) ([]NginxPlusHost, int, error) {
+	entries, err := splitHosts(hosts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	parsedHosts, errorCount := s.parseHostList(entries)
+	return parsedHosts, errorCount, nil
+}
+
+// parseHostList parses a list of hosts.
+// Whitespace is trimmed, empty and duplicate entries are dropped, and entries that are not http(s) URLs are skipped.
+// The number of invalid entries is returned alongside the hosts.
+func (s *Settings) parseHostList(hosts []string) ([]NginxPlusHost, int) {
+	var parsedHosts []NginxPlusHost
+	errorCount := 0
+	seen := make(map[string]bool)
+
+	for position, entry := range hosts {
+		host := strings.TrimSpace(entry)
+		if host == "" {
+			continue
+		}
+
+		nginxPlusHost, err := ParseNginxPlusHost(host)
+		if err != nil {
+			logrus.Warnf("Settings::parseHosts: skipping nginx-hosts entry %d (%q): %v", position, host, err)
+			errorCount++
+			continue
+		}
+
+		if seen[host] {
+			logrus.Warnf("Settings::parseHosts: skipping duplicate nginx-hosts entry %d (%q)", position, host)
+			continue
+		}
+
+		seen[host] = true
+		parsedHosts = append(parsedHosts, nginxPlusHost)
+	}
+
+	return parsedHosts, errorCount
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultHostsRetention is how long the NGINX Plus hosts are kept once the ConfigMap is deleted.
+	DefaultHostsRetention = time.Minute * 5
+
+	// HostsRetainedReason is the reason used for Events recorded on the ConfigMap when it is deleted and the NGINX Plus hosts
+	// are kept until it is recreated, or the Settings::HostsRetention has elapsed.
+	HostsRetainedReason = "HostsRetained"
+
+	// HostsClearedReason is the reason used for Events recorded on the ConfigMap when the NGINX Plus hosts are cleared,
+	// as the ConfigMap was not recreated within the Settings::HostsRetention.
+	HostsClearedReason = "HostsCleared"
+)
+
+// RetainedHostsDeadline returns when the retained NGINX Plus hosts are cleared, or false unless the ConfigMap has been deleted
+// and the hosts are kept until it is recreated.
+func (s *Settings) RetainedHostsDeadline() (time.Time, bool) {
+	s.hostsRetentionLock.Lock()
+	defer s.hostsRetentionLock.Unlock()
+
+	return s.hostsClearedAt, s.hostsClearTimer != nil
+}
+
+// retainHosts keeps the NGINX Plus hosts for the Settings::HostsRetention once the ConfigMap is deleted, so an accidental
+// deletion does not stop the synchronization at once; the hosts are cleared unless the ConfigMap is recreated in the meantime.
+// A zero HostsRetention clears the hosts immediately.
+func (s *Settings) retainHosts(configMap *corev1.ConfigMap) {
+	if s.HostsRetention == 0 {
+		s.SetHosts([]string{})
+		return
+	}
+
+	s.hostsRetentionLock.Lock()
+	defer s.hostsRetentionLock.Unlock()
+
+	if s.hostsClearTimer != nil {
+		return
+	}
+
+	s.hostsClearedAt = time.Now().Add(s.HostsRetention)
+	s.hostsClearTimer = time.AfterFunc(s.HostsRetention, func() { s.clearRetainedHosts(configMap) })
+
+	message := fmt.Sprintf("the ConfigMap was deleted, the NGINX Plus hosts are kept until %s unless it is recreated", s.hostsClearedAt.Format(time.RFC3339))
+	logrus.Warnf("Settings::retainHosts: %s", message)
+	s.recordWarning(configMap, HostsRetainedReason, message)
+}
+
+// clearRetainedHosts clears the NGINX Plus hosts once the Settings::HostsRetention has elapsed without the ConfigMap being recreated.
+func (s *Settings) clearRetainedHosts(configMap *corev1.ConfigMap) {
+	s.eventLock.Lock()
+	defer s.eventLock.Unlock()
+
+	s.hostsRetentionLock.Lock()
+	retained := s.hostsClearTimer != nil
+	s.hostsClearTimer = nil
+	s.hostsRetentionLock.Unlock()
+
+	// the ConfigMap was recreated while the timer fired
+	if !retained || s.hostsPinned {
+		return
+	}
+
+	message := fmt.Sprintf("the ConfigMap was not recreated within %v, the NGINX Plus hosts are cleared", s.HostsRetention)
+	logrus.Errorf("Settings::clearRetainedHosts: %s", message)
+	s.recordWarning(configMap, HostsClearedReason, message)
+
+	_ = s.applyHostsSrv("")
+	s.SetHosts([]string{})
+}
+
+// cancelHostsClear cancels the pending clear of the retained NGINX Plus hosts, when the ConfigMap is recreated.
+func (s *Settings) cancelHostsClear() {
+	s.hostsRetentionLock.Lock()
+	defer s.hostsRetentionLock.Unlock()
+
+	if s.hostsClearTimer == nil {
+		return
+	}
+
+	s.hostsClearTimer.Stop()
+	s.hostsClearTimer = nil
+
+	logrus.Info("Settings::cancelHostsClear: the ConfigMap was recreated, the NGINX Plus hosts are no longer cleared")
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSettings_DeletingTheConfigMapRetainsTheHosts(t *testing.T) {
+	settings := buildSettings(t)
+	settings.HostsRetention = 50 * time.Millisecond
+	recorder := record.NewFakeRecorder(2)
+	settings.EventRecorder = recorder
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	settings.handleAddEvent(configMap)
+	settings.handleDeleteEvent(configMap)
+
+	if len(settings.Hosts()) != 1 {
+		t.Fatalf(`expected the hosts to be retained, got %v`, settings.Hosts())
+	}
+
+	if _, retained := settings.RetainedHostsDeadline(); !retained {
+		t.Fatal(`expected the hosts to be reported as retained`)
+	}
+
+	if event := <-recorder.Events; !strings.Contains(event, HostsRetainedReason) {
+		t.Fatalf(`expected an %s Event, got %s`, HostsRetainedReason, event)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, HostsClearedReason) {
+			t.Fatalf(`expected an %s Event, got %s`, HostsClearedReason, event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal(`expected the hosts to be cleared once the retention has elapsed`)
+	}
+
+	if len(settings.Hosts()) != 0 {
+		t.Fatalf(`expected the hosts to be cleared, got %v`, settings.Hosts())
+	}
+
+	if _, retained := settings.RetainedHostsDeadline(); retained {
+		t.Fatal(`expected the hosts to no longer be reported as retained`)
+	}
+}
+
+func TestSettings_RecreatingTheConfigMapCancelsTheHostsClear(t *testing.T) {
+	settings := buildSettings(t)
+	settings.HostsRetention = 50 * time.Millisecond
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	settings.handleAddEvent(configMap)
+	settings.handleDeleteEvent(configMap)
+	settings.handleAddEvent(buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+
+	if _, retained := settings.RetainedHostsDeadline(); retained {
+		t.Fatal(`expected the pending clear to be cancelled`)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if len(settings.Hosts()) != 1 {
+		t.Fatalf(`expected the hosts to be kept, got %v`, settings.Hosts())
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HttpClientSettings contains the configuration values needed by the HTTP client used to communicate with the Border Servers.
+// The proxy is taken from the HTTPS_PROXY and NO_PROXY environment variables.
+type HttpClientSettings struct {
+
+	// DialTimeout limits the time spent establishing a TCP connection.
+	DialTimeout time.Duration
+
+	// KeepAlive is the interval between keep-alive probes on an active connection.
+	KeepAlive time.Duration
+
+	// TLSHandshakeTimeout limits the time spent performing the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout limits the time spent waiting for the response headers after the request has been written.
+	ResponseHeaderTimeout time.Duration
+
+	// RequestTimeout limits the overall time of a request, including reading the response body.
+	RequestTimeout time.Duration
+
+	// IdleConnTimeout is how long an idle connection is kept in the pool.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConns limits the number of idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the number of idle connections per host.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the number of connections per host, whether in use or idle; zero does not limit them.
+	// The calls over the limit wait for a connection, rather than opening one that is closed as soon as it is idle.
+	MaxConnsPerHost int
+
+	// EnableHTTP2 negotiates HTTP/2 with the hosts that support it, multiplexing the calls to a host on a single connection.
+	EnableHTTP2 bool
+
+	// WriteRateLimiter limits the NGINX Plus API calls that change the configuration, per host, e.g. adding a server.
+	WriteRateLimiter RateLimiterSettings
+
+	// ReadRateLimiter limits the read-only NGINX Plus API calls, per host, e.g. reading the servers to diff them;
+	// it is separate from, and more generous than, the WriteRateLimiter so the reconciliation isn't starved by the writes.
+	ReadRateLimiter RateLimiterSettings
+}
+
+// logFields returns the settings of the HTTP client as log fields.
+func (s HttpClientSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"dialTimeout":           s.DialTimeout,
+		"keepAlive":             s.KeepAlive,
+		"tlsHandshakeTimeout":   s.TLSHandshakeTimeout,
+		"responseHeaderTimeout": s.ResponseHeaderTimeout,
+		"requestTimeout":        s.RequestTimeout,
+		"idleConnTimeout":       s.IdleConnTimeout,
+		"maxIdleConns":          s.MaxIdleConns,
+		"maxIdleConnsPerHost":   s.MaxIdleConnsPerHost,
+		"maxConnsPerHost":       s.MaxConnsPerHost,
+		"enableHttp2":           s.EnableHTTP2,
+		"writeRate":             s.WriteRateLimiter.Rate,
+		"writeBurst":            s.WriteRateLimiter.Burst,
+		"readRate":              s.ReadRateLimiter.Rate,
+		"readBurst":             s.ReadRateLimiter.Burst,
+	}
+}
+
+// RateLimiterSettings contains the configuration values of a token bucket rate limiter, applied to the NGINX Plus API calls
+// so the management proxies in front of NGINX Plus are not overwhelmed, e.g. by large rolling node replacements.
+// The calls over the limit are delayed, not dropped.
+type RateLimiterSettings struct {
+
+	// Rate is the number of calls per second allowed on average; zero, the default, disables the limit.
+	Rate float64
+
+	// Burst is the number of calls allowed at once above the Rate.
+	Burst int
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import "github.com/sirupsen/logrus"
+
+const (
+	// DefaultKubeApiQPS is the default number of Kubernetes API calls per second, ten times the client-go default.
+	DefaultKubeApiQPS = 50
+
+	// DefaultKubeApiBurst is the default number of Kubernetes API calls allowed at once above the rate.
+	DefaultKubeApiBurst = 100
+)
+
+// KubeApiSettings contains the client-side rate limit of the calls to the Kubernetes API. The informers, the Events, and
+// the annotation and status writes share it, so the client-go default of 5 calls per second delays the reconciliation in
+// large clusters. The Kubernetes client is built with them before the Settings, see NewKubeApiSettings, so they are
+// read from the environment and the command line flags only.
+type KubeApiSettings struct {
+
+	// QPS is the number of calls per second allowed to the Kubernetes API.
+	QPS float32
+
+	// Burst is the number of calls allowed at once above the QPS.
+	Burst int
+}
+
+// logFields returns the rate limit of the Kubernetes client as log fields.
+func (s KubeApiSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"qps":   s.QPS,
+		"burst": s.Burst,
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LeaderElectionSettings contains the configuration values needed to elect a leader when multiple replicas are running.
+// Only the leader watches for changes and updates the Border Servers; the other replicas wait to take over.
+type LeaderElectionSettings struct {
+
+	// Enabled determines whether leader election is used; when disabled, the default, the replica always runs.
+	Enabled bool
+
+	// LeaseName is the name of the Lease used to hold the leadership.
+	LeaseName string
+
+	// LeaseNamespace is the namespace of the Lease, defaults to the ConfigMap namespace.
+	LeaseNamespace string
+
+	// LeaseDuration is how long standby replicas wait before attempting to acquire a lease that has not been renewed.
+	LeaseDuration time.Duration
+
+	// RenewDeadline is how long the leader retries renewing the lease before giving up leadership.
+	RenewDeadline time.Duration
+
+	// RetryPeriod is the interval between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+}
+
+// logFields returns the settings of the leader election as log fields.
+func (s LeaderElectionSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"enabled":       s.Enabled,
+		"lease":         s.LeaseNamespace + "/" + s.LeaseName,
+		"leaseDuration": s.LeaseDuration,
+		"renewDeadline": s.RenewDeadline,
+		"retryPeriod":   s.RetryPeriod,
+	}
+}
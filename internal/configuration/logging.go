@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LogFormatText logs in the logrus text format, e.g.: time="..." level=info msg="..." upstream=nginx-http
+	LogFormatText = "text"
+
+	// LogFormatJson logs one JSON object per line, for log pipelines that parse structured logs.
+	LogFormatJson = "json"
+
+	// LogLevelKey is the ConfigMap key used to change the log level at runtime.
+	LogLevelKey = "log-level"
+)
+
+// validateLogFormat returns an error if the log format is not one of the supported log formats.
+func validateLogFormat(logFormat string) error {
+	if logFormat != LogFormatText && logFormat != LogFormatJson {
+		return fmt.Errorf(`log format must be %s or %s, got %q`, LogFormatText, LogFormatJson, logFormat)
+	}
+
+	return nil
+}
+
+// parseLogLevel parses a log level, e.g.: "warn", or "debug".
+func parseLogLevel(logLevel string) (logrus.Level, error) {
+	level, err := logrus.ParseLevel(logLevel)
+	if err != nil {
+		return logrus.WarnLevel, fmt.Errorf(`log level must be one of panic, fatal, error, warn, info, debug, or trace, got %q`, logLevel)
+	}
+
+	return level, nil
+}
+
+// ConfigureLogging sets the logrus formatter and level from the LogFormat and LogLevel settings.
+// It is called by NewSettings; the level may then be changed with the log-level key of the ConfigMap.
+func (s *Settings) ConfigureLogging() {
+	if s.LogFormat == LogFormatJson {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	}
+
+	logrus.SetLevel(s.LogLevel)
+}
+
+// applyLogLevel sets the level from the log-level key of the ConfigMap. The startup LogLevel is restored
+// when the key is removed, and kept when the value is invalid.
+func (s *Settings) applyLogLevel(logLevel string) {
+	level := s.LogLevel
+
+	if logLevel != "" {
+		parsed, err := parseLogLevel(logLevel)
+		if err != nil {
+			logrus.Warnf("Settings::applyLogLevel: %v, using %s", err, level)
+		} else {
+			level = parsed
+		}
+	}
+
+	if level != logrus.GetLevel() {
+		logrus.Infof("Settings::applyLogLevel: log level changed from %s to %s", logrus.GetLevel(), level)
+		logrus.SetLevel(level)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewSettings_ConfiguresLogging(t *testing.T) {
+	t.Setenv(LogFormatEnv, LogFormatJson)
+	t.Setenv(LogLevelEnv, "debug")
+	t.Cleanup(resetLogging)
+
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.LogLevel != logrus.DebugLevel || logrus.GetLevel() != logrus.DebugLevel {
+		t.Errorf(`expected the debug level, got %s`, logrus.GetLevel())
+	}
+
+	if _, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); !ok {
+		t.Errorf(`expected the JSON formatter, got %T`, logrus.StandardLogger().Formatter)
+	}
+}
+
+func TestApplyLogLevel(t *testing.T) {
+	t.Cleanup(resetLogging)
+
+	settings := &Settings{LogLevel: logrus.InfoLevel}
+
+	tests := []struct {
+		name     string
+		logLevel string
+		expected logrus.Level
+	}{
+		{"valid level", "debug", logrus.DebugLevel},
+		{"removed key restores the startup level", "", logrus.InfoLevel},
+		{"warning alias", "warning", logrus.WarnLevel},
+		{"invalid level uses the startup level", "verbose", logrus.InfoLevel},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings.applyLogLevel(test.logLevel)
+
+			if logrus.GetLevel() != test.expected {
+				t.Errorf(`expected the %s level, got %s`, test.expected, logrus.GetLevel())
+			}
+		})
+	}
+}
+
+func resetLogging() {
+	logrus.SetFormatter(&logrus.TextFormatter{})
+	logrus.SetLevel(logrus.InfoLevel)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// UpstreamNameTemplateName is replaced with the upstream name derived from the port name or the upstream map.
+	UpstreamNameTemplateName = "{name}"
+
+	// UpstreamNameTemplateNamespace is replaced with the namespace of the Service.
+	UpstreamNameTemplateNamespace = "{namespace}"
+)
+
+// parseNamespaces parses a comma-separated list of namespaces, e.g. "nginx-ingress-public,nginx-ingress-internal".
+// Duplicates are removed; the list must name at least one namespace.
+func parseNamespaces(value string) ([]string, error) {
+	var namespaces []string
+
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace == "" {
+			continue
+		}
+
+		if problems := validation.IsDNS1123Label(namespace); len(problems) > 0 {
+			return nil, fmt.Errorf(`nginx-ingress-namespace %q is invalid: %s`, namespace, strings.Join(problems, "; "))
+		}
+
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf(`nginx-ingress-namespace must name at least one namespace`)
+	}
+
+	return namespaces, nil
+}
+
+// validateConfigMapName returns an error if the name is not a valid ConfigMap name.
+func validateConfigMapName(name string) error {
+	if problems := validation.IsDNS1123Subdomain(name); len(problems) > 0 {
+		return fmt.Errorf(`ConfigMap name %q is invalid: %s`, name, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// parseServiceSelector parses a label selector, e.g. "nkl.nginx.com/managed=true"; an empty selector watches the namespaces instead.
+func parseServiceSelector(serviceSelector string) (labels.Selector, error) {
+	selector, err := labels.Parse(serviceSelector)
+	if err != nil {
+		return nil, fmt.Errorf(`service selector %q could not be parsed: %w`, serviceSelector, err)
+	}
+
+	return selector, nil
+}
+
+// validateUpstreamNameTemplate returns an error if the template does not include the upstream name.
+func validateUpstreamNameTemplate(template string) error {
+	if !strings.Contains(template, UpstreamNameTemplateName) {
+		return fmt.Errorf(`upstream-name-template must include %s, got %q`, UpstreamNameTemplateName, template)
+	}
+
+	return nil
+}
+
+// SubscribeToNamespaceChanges registers a callback that is invoked when the watched namespaces change.
+func (s *Settings) SubscribeToNamespaceChanges(callback func()) {
+	s.namespaceSubscribersLock.Lock()
+	defer s.namespaceSubscribersLock.Unlock()
+
+	s.namespaceSubscribers = append(s.namespaceSubscribers, callback)
+}
+
+// notifyNamespaceSubscribers invokes each of the callbacks registered with SubscribeToNamespaceChanges.
+func (s *Settings) notifyNamespaceSubscribers() {
+	s.namespaceSubscribersLock.Lock()
+	subscribers := append([]func(){}, s.namespaceSubscribers...)
+	s.namespaceSubscribersLock.Unlock()
+
+	for _, callback := range subscribers {
+		callback()
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	namespaces, err := parseNamespaces(" nginx-ingress-public, nginx-ingress-internal,,nginx-ingress-public ")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(namespaces, []string{"nginx-ingress-public", "nginx-ingress-internal"}) {
+		t.Errorf(`expected the namespaces without duplicates, got %v`, namespaces)
+	}
+
+	for _, value := range []string{"", " , ", "nginx_ingress"} {
+		if _, err := parseNamespaces(value); err == nil {
+			t.Errorf(`expected an error for %q`, value)
+		}
+	}
+}
+
+func TestSettings_NotifiesNamespaceSubscribersOnChange(t *testing.T) {
+	settings := buildSettings(t)
+	notifications := 0
+	settings.SubscribeToNamespaceChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data[ConfigFileKey] = "watcher:\n  nginx-ingress-namespace: nginx-ingress\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 0 {
+		t.Fatalf(`expected no notifications when the namespaces are unchanged, got %d`, notifications)
+	}
+
+	configMap.Data[ConfigFileKey] = "watcher:\n  nginx-ingress-namespace: nginx-ingress,nginx-ingress-internal\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 1 {
+		t.Fatalf(`expected one notification after the namespaces changed, got %d`, notifications)
+	}
+
+	if !reflect.DeepEqual(settings.Watcher.NginxIngressNamespaces, []string{"nginx-ingress", "nginx-ingress-internal"}) {
+		t.Errorf(`expected both namespaces to be watched, got %v`, settings.Watcher.NginxIngressNamespaces)
+	}
+}
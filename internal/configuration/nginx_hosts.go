@@ -0,0 +1,596 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// ApiVersionSuffix introduces the version of the NGINX Plus API used for an nginx-hosts entry, e.g.:
+//
+//	https://10.0.0.1:9000/nginx-api;version=8
+//
+// Without it the default version of the NGINX Plus client is used.
+const ApiVersionSuffix = ";version="
+
+// ServerNameSuffix introduces the server name sent and verified in the TLS handshake with an nginx-hosts entry, e.g.:
+//
+//	https://10.0.0.5:443/api;sni=plus-1.internal.example.com
+//
+// It allows a host reached through an IP address to present a certificate that only carries DNS names.
+// Without it the host of the URL is used. The suffixes may be combined, in any order.
+const ServerNameSuffix = ";sni="
+
+// SiteSuffix introduces the site of an nginx-hosts entry, a DNS label such as the data center of the host, e.g.:
+//
+//	https://10.0.0.1:9000/api;site=east
+//
+// The site is logged, as the site field, with the updates to the host.
+const SiteSuffix = ";site="
+
+// RoleSuffix introduces the role of an nginx-hosts entry, HostRolePrimary or HostRoleSecondary, e.g.:
+//
+//	https://10.0.1.1:9000/api;role=secondary
+//
+// A secondary host is handled like the hosts of the nginx-hosts-secondary setting. Without it the host is primary,
+// unless it is listed in the nginx-hosts-secondary setting.
+const RoleSuffix = ";role="
+
+// InsecureSuffix skips the verification of the certificate of an nginx-hosts entry, whatever the TLS mode, e.g.:
+//
+//	https://10.0.9.1:9000/api;insecure=true
+//
+// It is meant for a host with a self-signed certificate, such as a lab instance, among hosts whose certificates are verified
+// as the TLS mode sets. The insecure hosts are logged as a warning each time they change, and counted in nkl_insecure_hosts.
+const InsecureSuffix = ";insecure="
+
+const (
+	// HostRolePrimary is the role of the hosts whose failures affect the readiness.
+	HostRolePrimary = "primary"
+
+	// HostRoleSecondary is the role of the hosts whose failures are reported as warnings, see Settings::IsSecondaryHost.
+	HostRoleSecondary = "secondary"
+)
+
+// NginxPlusHost is an entry of the nginx-hosts setting.
+type NginxPlusHost struct {
+
+	// Entry is the nginx-hosts entry, including its options, it identifies the host, e.g. in the metrics.
+	Entry string
+
+	// Endpoint is the base URL of the NGINX Plus API, including its path, e.g. https://10.0.0.1:9000/api.
+	Endpoint string
+
+	// ApiVersion is the version of the NGINX Plus API to use, zero uses the default version of the NGINX Plus client.
+	ApiVersion int
+
+	// ServerName is the name the certificate of the host is verified against, empty uses the host of the Endpoint.
+	ServerName string
+
+	// Site is the site of the host, e.g. its data center, empty when not set.
+	Site string
+
+	// Role is HostRolePrimary or HostRoleSecondary, empty when not set.
+	Role string
+
+	// Insecure skips the verification of the certificate of the host, see InsecureSuffix.
+	Insecure bool
+}
+
+// NginxHostList is a list of nginx-hosts entries, in the config.yaml key or as the value of the nginx-hosts keys.
+// Each item is an entry, or an object with the url of the entry and its options, e.g.:
+//
+//	nginx-hosts:
+//	  - https://10.0.0.1:9000/api;site=east
+//	  - url: https://10.0.1.1:9000/api
+//	    api-version: 8
+//	    site: west
+//	    role: secondary
+//	  - url: https://10.0.9.1:9000/api
+//	    insecure: true
+type NginxHostList []string
+
+// nginxHostObject is an item of an NginxHostList given as an object.
+type nginxHostObject struct {
+	Url        string `json:"url"`
+	ApiVersion int    `json:"api-version,omitempty"`
+	ServerName string `json:"sni,omitempty"`
+	Site       string `json:"site,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty"`
+}
+
+// entry returns the nginx-hosts entry of the object, the url followed by the options that are set.
+func (o nginxHostObject) entry() string {
+	entry := o.Url
+	if o.ApiVersion != 0 {
+		entry += ApiVersionSuffix + strconv.Itoa(o.ApiVersion)
+	}
+
+	if o.ServerName != "" {
+		entry += ServerNameSuffix + o.ServerName
+	}
+
+	if o.Site != "" {
+		entry += SiteSuffix + o.Site
+	}
+
+	if o.Role != "" {
+		entry += RoleSuffix + o.Role
+	}
+
+	if o.Insecure {
+		entry += InsecureSuffix + "true"
+	}
+
+	return entry
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the NginxHostList type, the items that are objects are turned into entries.
+func (l *NginxHostList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf(`the hosts must be a list: %w`, err)
+	}
+
+	list := make(NginxHostList, 0, len(items))
+	for position, item := range items {
+		var entry string
+		if err := json.Unmarshal(item, &entry); err == nil {
+			list = append(list, entry)
+			continue
+		}
+
+		var object nginxHostObject
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&object); err != nil {
+			return fmt.Errorf(`host %d must be a URL or an object with the url, api-version, sni, site, role, and insecure keys: %w`, position, err)
+		}
+
+		if object.Url == "" {
+			return fmt.Errorf(`host %d is missing its url`, position)
+		}
+
+		list = append(list, object.entry())
+	}
+
+	*l = list
+	return nil
+}
+
+// splitHosts splits the value of an nginx-hosts key into entries. The value is either comma-separated, or a YAML or
+// JSON list, see NginxHostList. A value that mixes both formats is rejected, as which hosts were meant is ambiguous.
+func splitHosts(value string) ([]string, error) {
+	trimmed := strings.TrimSpace(value)
+
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "-") {
+		var list NginxHostList
+		if err := yaml.Unmarshal([]byte(trimmed), &list); err != nil {
+			return nil, fmt.Errorf(`the list of hosts cannot be parsed: %w`, err)
+		}
+
+		for _, entry := range list {
+			if strings.Contains(entry, ",") {
+				return nil, fmt.Errorf(`the host %q of the list contains a comma, list the hosts one per item`, entry)
+			}
+		}
+
+		return list, nil
+	}
+
+	entries := strings.Split(value, ",")
+	for _, entry := range entries {
+		trimmed := strings.TrimSpace(entry)
+		if strings.ContainsAny(trimmed, "[]{}") || strings.HasPrefix(trimmed, "-") {
+			return nil, fmt.Errorf(`the host %q looks like a list item, the comma-separated and list formats cannot be mixed`, trimmed)
+		}
+	}
+
+	return entries, nil
+}
+
+// entriesOf returns the entries of the hosts, in order.
+func entriesOf(hosts []NginxPlusHost) []string {
+	entries := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		entries = append(entries, host.Entry)
+	}
+
+	return entries
+}
+
+// Address returns the host and port of the Endpoint, the Host of the requests sent to the NGINX Plus API.
+func (h NginxPlusHost) Address() string {
+	hostUrl, err := url.Parse(h.Endpoint)
+	if err != nil {
+		return ""
+	}
+
+	return hostUrl.Host
+}
+
+// ParseNginxPlusHost splits an nginx-hosts entry into the API base URL, the optional API version, server name, site, role,
+// and insecure flag. The URL must be absolute with an http or https scheme, the version must be a positive integer, the server
+// name must be a DNS name, the site a DNS label, the role HostRolePrimary or HostRoleSecondary, and the insecure flag a boolean;
+// the server name and the insecure flag are set on an https URL only. Whether the version is supported is only known once the host is called,
+// see application.ErrUnsupportedApiVersion.
+func ParseNginxPlusHost(host string) (NginxPlusHost, error) {
+	parsed := NginxPlusHost{Entry: host, Endpoint: host}
+
+	if start := optionsStart(host); start >= 0 {
+		parsed.Endpoint = host[:start]
+
+		for _, option := range strings.Split(host[start+1:], ";") {
+			if err := parsed.setOption(";" + option); err != nil {
+				return NginxPlusHost{}, err
+			}
+		}
+	}
+
+	hostUrl, err := url.Parse(parsed.Endpoint)
+	if err != nil {
+		return NginxPlusHost{}, fmt.Errorf(`not a valid URL: %w`, err)
+	}
+
+	if hostUrl.Scheme != "http" && hostUrl.Scheme != "https" {
+		return NginxPlusHost{}, fmt.Errorf(`scheme must be http or https, got %q`, hostUrl.Scheme)
+	}
+
+	if hostUrl.Host == "" {
+		return NginxPlusHost{}, fmt.Errorf(`missing host`)
+	}
+
+	if parsed.ServerName != "" && hostUrl.Scheme != "https" {
+		return NginxPlusHost{}, fmt.Errorf(`a server name requires an https URL, got %q`, hostUrl.Scheme)
+	}
+
+	if parsed.Insecure && hostUrl.Scheme != "https" {
+		return NginxPlusHost{}, fmt.Errorf(`the insecure option requires an https URL, got %q`, hostUrl.Scheme)
+	}
+
+	return parsed, nil
+}
+
+// optionsStart returns the position of the first of the ApiVersionSuffix, ServerNameSuffix, SiteSuffix, RoleSuffix, and
+// InsecureSuffix options of an nginx-hosts entry, -1 if there is none.
+func optionsStart(host string) int {
+	start := -1
+	for _, suffix := range []string{ApiVersionSuffix, ServerNameSuffix, SiteSuffix, RoleSuffix, InsecureSuffix} {
+		if index := strings.Index(host, suffix); index >= 0 && (start < 0 || index < start) {
+			start = index
+		}
+	}
+
+	return start
+}
+
+// setOption sets the API version, the server name, the site, the role, or the insecure flag from an option of an nginx-hosts entry, e.g. ";version=8".
+func (h *NginxPlusHost) setOption(option string) error {
+	switch {
+	case strings.HasPrefix(option, ApiVersionSuffix):
+		version := strings.TrimPrefix(option, ApiVersionSuffix)
+		apiVersion, err := strconv.Atoi(version)
+		if err != nil || apiVersion < 1 {
+			return fmt.Errorf(`API version must be a positive integer, got %q`, version)
+		}
+
+		h.ApiVersion = apiVersion
+
+	case strings.HasPrefix(option, ServerNameSuffix):
+		serverName := strings.ToLower(strings.TrimPrefix(option, ServerNameSuffix))
+		if errs := validation.IsDNS1123Subdomain(serverName); len(errs) > 0 {
+			return fmt.Errorf(`server name must be a DNS name, got %q: %s`, serverName, strings.Join(errs, ", "))
+		}
+
+		h.ServerName = serverName
+
+	case strings.HasPrefix(option, SiteSuffix):
+		site := strings.TrimPrefix(option, SiteSuffix)
+		if errs := validation.IsDNS1123Label(site); len(errs) > 0 {
+			return fmt.Errorf(`site must be a DNS label, got %q: %s`, site, strings.Join(errs, ", "))
+		}
+
+		h.Site = site
+
+	case strings.HasPrefix(option, RoleSuffix):
+		role := strings.TrimPrefix(option, RoleSuffix)
+		if role != HostRolePrimary && role != HostRoleSecondary {
+			return fmt.Errorf(`role must be %s or %s, got %q`, HostRolePrimary, HostRoleSecondary, role)
+		}
+
+		h.Role = role
+
+	case strings.HasPrefix(option, InsecureSuffix):
+		value := strings.TrimPrefix(option, InsecureSuffix)
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf(`insecure must be true or false, got %q`, value)
+		}
+
+		h.Insecure = insecure
+
+	default:
+		return fmt.Errorf(`unknown option %q, valid options are %s, %s, %s, %s, and %s`, option, ApiVersionSuffix, ServerNameSuffix, SiteSuffix, RoleSuffix, InsecureSuffix)
+	}
+
+	return nil
+}
+
+// ServerName returns the server name set with the ServerNameSuffix for the hosts at the address, the host and port of
+// a request, or an empty string if there is none. The server name applies to every connection to the address.
+func (s *Settings) ServerName(address string) string {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	return s.serverNames[address]
+}
+
+// InsecureSkipVerify determines whether the verification of the certificate of the hosts at the address, the host and port
+// of a request, is skipped, see InsecureSuffix. It applies to every connection to the address.
+func (s *Settings) InsecureSkipVerify(address string) bool {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	return s.insecureAddresses[address]
+}
+
+// Hosts returns a copy of the nginx-hosts entries currently configured, the primary hosts followed by the secondary hosts.
+func (s *Settings) Hosts() []string {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	return slices.Clone(s.nginxPlusHosts)
+}
+
+// PrimaryHosts returns the hosts that are not secondary, the hosts whose failures affect the readiness.
+func (s *Settings) PrimaryHosts() []string {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	var hosts []string
+	for _, host := range s.nginxPlusHosts {
+		if !s.secondaryHosts[host] {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// IsSecondaryHost determines whether the host is a secondary host, whose failures are reported as warnings.
+func (s *Settings) IsSecondaryHost(host string) bool {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	return s.secondaryHosts[host]
+}
+
+// SetHosts replaces the nginx-hosts entries with primary hosts, see SetHostGroups.
+func (s *Settings) SetHosts(hosts []string) {
+	s.SetHostGroups(hosts, nil)
+}
+
+// NginxPlusHost returns the parsed nginx-hosts entry of a host, so the components using the hosts do not parse the entries again.
+// Returns false if the host is not configured; a host removed in the meantime may be parsed with ParseNginxPlusHost.
+func (s *Settings) NginxPlusHost(host string) (NginxPlusHost, bool) {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	nginxPlusHost, found := s.parsedHosts[host]
+	return nginxPlusHost, found
+}
+
+// PrimaryNginxPlusHosts returns the parsed nginx-hosts entries of the PrimaryHosts.
+func (s *Settings) PrimaryNginxPlusHosts() []NginxPlusHost {
+	s.hostsLock.RLock()
+	defer s.hostsLock.RUnlock()
+
+	var hosts []NginxPlusHost
+	for _, host := range s.nginxPlusHosts {
+		if nginxPlusHost, found := s.parsedHosts[host]; found && !s.secondaryHosts[host] {
+			hosts = append(hosts, nginxPlusHost)
+		}
+	}
+
+	return hosts
+}
+
+// SetHostGroups replaces the static nginx-hosts entries with the primary and secondary hosts, and notifies the subscribers
+// of the hosts added and removed, if any. The Synchronizer updates both; a host listed in both groups is a primary host,
+// and a primary host with the secondary role, see RoleSuffix, is a secondary host. The hosts discovered through the
+// nginx-hosts-srv record, if any, are kept alongside, see applyHostGroups.
+func (s *Settings) SetHostGroups(primary []string, secondary []string) {
+	s.hostSourcesLock.Lock()
+	defer s.hostSourcesLock.Unlock()
+
+	s.staticPrimaryHosts = slices.Clone(primary)
+	s.staticSecondaryHosts = slices.Clone(secondary)
+
+	s.applyHostGroups()
+}
+
+// applyHostGroups replaces the nginx-hosts entries with the static hosts, see SetHostGroups, merged with the hosts
+// discovered through the nginx-hosts-srv record, which are primary unless their entries set the secondary role.
+// The caller must hold the hostSourcesLock.
+func (s *Settings) applyHostGroups() {
+	var discovered []string
+	if s.hostsDiscovery != nil {
+		discovered = s.hostsDiscovery.hosts()
+	}
+
+	s.replaceHostGroups(slices.Concat(s.staticPrimaryHosts, discovered), s.staticSecondaryHosts)
+}
+
+// replaceHostGroups replaces the nginx-hosts entries with the primary and secondary hosts, and notifies the subscribers
+// of the hosts added and removed, if any.
+func (s *Settings) replaceHostGroups(primary []string, secondary []string) {
+	parsedHosts := make(map[string]NginxPlusHost)
+	for _, host := range slices.Concat(primary, secondary) {
+		if nginxPlusHost, err := ParseNginxPlusHost(host); err == nil {
+			parsedHosts[host] = nginxPlusHost
+		}
+	}
+
+	var hosts []string
+	secondaryHosts := make(map[string]bool)
+
+	for _, host := range primary {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+
+			if parsedHosts[host].Role == HostRoleSecondary {
+				secondaryHosts[host] = true
+			}
+		}
+	}
+
+	for _, host := range secondary {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+			secondaryHosts[host] = true
+		}
+	}
+
+	serverNames := serverNamesByAddress(hosts, parsedHosts)
+	insecureAddresses, insecureHosts := insecureAddressesOf(hosts, parsedHosts)
+
+	s.hostsLock.Lock()
+	added := missingFrom(s.nginxPlusHosts, hosts)
+	removed := missingFrom(hosts, s.nginxPlusHosts)
+	regrouped := !maps.Equal(s.secondaryHosts, secondaryHosts)
+	s.nginxPlusHosts = hosts
+	s.secondaryHosts = secondaryHosts
+	s.serverNames = serverNames
+	insecureChanged := !maps.Equal(s.insecureAddresses, insecureAddresses)
+	s.insecureAddresses = insecureAddresses
+	s.parsedHosts = parsedHosts
+	s.hostsLock.Unlock()
+
+	if insecureChanged && len(insecureHosts) > 0 {
+		logrus.Warnf("Settings::SetHostGroups: the certificates of the NGINX Plus hosts %v are NOT verified, as their %strue option sets, whatever the TLS mode", insecureHosts, InsecureSuffix)
+	}
+
+	instrumentation.ObserveInsecureHosts(len(insecureHosts))
+	instrumentation.ObserveHostsConfigured(len(hosts))
+
+	if len(added) > 0 || len(removed) > 0 || regrouped {
+		logrus.Infof("Settings::SetHostGroups: added %v, removed %v, secondary hosts: %v", added, removed, slices.Sorted(maps.Keys(secondaryHosts)))
+	}
+
+	// the hosts that changed group are updated like before, only the handling of their failures changes
+	if len(added) > 0 || len(removed) > 0 {
+		s.notifyHostSubscribers(added, removed)
+	}
+}
+
+// SubscribeToHostChanges registers a callback invoked with the hosts added and removed each time the list of hosts changes.
+// The callbacks are invoked on the informer goroutine, so they should not block.
+func (s *Settings) SubscribeToHostChanges(callback func(added []string, removed []string)) {
+	s.hostSubscribersLock.Lock()
+	defer s.hostSubscribersLock.Unlock()
+
+	s.hostSubscribers = append(s.hostSubscribers, callback)
+}
+
+// notifyHostSubscribers invokes each of the callbacks registered with SubscribeToHostChanges.
+func (s *Settings) notifyHostSubscribers(added []string, removed []string) {
+	s.hostSubscribersLock.Lock()
+	subscribers := append([]func([]string, []string){}, s.hostSubscribers...)
+	s.hostSubscribersLock.Unlock()
+
+	for _, callback := range subscribers {
+		callback(added, removed)
+	}
+}
+
+// serverNamesByAddress returns the server names of the hosts, by the address of their Endpoint. When hosts at the same
+// address set different server names, the first one is used, as the connections to an address share their TLS config.
+func serverNamesByAddress(hosts []string, parsedHosts map[string]NginxPlusHost) map[string]string {
+	serverNames := make(map[string]string)
+	for _, host := range hosts {
+		nginxPlusHost, found := parsedHosts[host]
+		if !found || nginxPlusHost.ServerName == "" {
+			continue
+		}
+
+		address := nginxPlusHost.Address()
+		if current, found := serverNames[address]; found {
+			if current != nginxPlusHost.ServerName {
+				logrus.Warnf("Settings::SetHostGroups: the hosts at %s set different server names, using %q rather than %q", address, current, nginxPlusHost.ServerName)
+			}
+
+			continue
+		}
+
+		serverNames[address] = nginxPlusHost.ServerName
+	}
+
+	return serverNames
+}
+
+// insecureAddressesOf returns the addresses of the Endpoints of the hosts whose certificates are not verified, see InsecureSuffix,
+// along with those hosts. When hosts at the same address disagree, the certificate is verified, as the connections to an address
+// share their TLS config.
+func insecureAddressesOf(hosts []string, parsedHosts map[string]NginxPlusHost) (map[string]bool, []string) {
+	insecureAddresses := make(map[string]bool)
+	verifiedAddresses := make(map[string]bool)
+
+	for _, host := range hosts {
+		nginxPlusHost, found := parsedHosts[host]
+		if !found {
+			continue
+		}
+
+		if nginxPlusHost.Insecure {
+			insecureAddresses[nginxPlusHost.Address()] = true
+		} else {
+			verifiedAddresses[nginxPlusHost.Address()] = true
+		}
+	}
+
+	var insecureHosts []string
+	for _, host := range hosts {
+		address := parsedHosts[host].Address()
+		if !parsedHosts[host].Insecure || !insecureAddresses[address] {
+			continue
+		}
+
+		if verifiedAddresses[address] {
+			logrus.Warnf("Settings::SetHostGroups: the hosts at %s disagree on the %s option, the certificate of %q is verified", address, InsecureSuffix, host)
+			delete(insecureAddresses, address)
+			continue
+		}
+
+		insecureHosts = append(insecureHosts, host)
+	}
+
+	return insecureAddresses, insecureHosts
+}
+
+// missingFrom returns the hosts that are not in the list, in order.
+func missingFrom(list []string, hosts []string) []string {
+	var missing []string
+	for _, host := range hosts {
+		if !slices.Contains(list, host) {
+			missing = append(missing, host)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,307 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultHostsSrvInterval is how often the nginx-hosts-srv record is resolved.
+	DefaultHostsSrvInterval = time.Second * 30
+
+	// DefaultHostsSrvRemovalDelay is how long a discovered host may be missing from the nginx-hosts-srv record before it is removed.
+	DefaultHostsSrvRemovalDelay = time.Minute * 2
+
+	// hostsSrvLookupTimeout bounds each resolution of the nginx-hosts-srv record.
+	hostsSrvLookupTimeout = time.Second * 5
+
+	// defaultHostsSrvScheme and defaultHostsSrvPath complete the entries of the targets of an nginx-hosts-srv record given as a bare name.
+	defaultHostsSrvScheme = "https"
+	defaultHostsSrvPath   = "/api"
+)
+
+// hostsSrv is a parsed nginx-hosts-srv value, the name of a DNS SRV record, e.g. _plusapi._tcp.lb.example.com, whose
+// targets and ports are turned into nginx-hosts entries. The value may also be written as an entry whose host is the name
+// of the record, e.g. http://_plusapi._tcp.lb.example.com/nginx-api;version=8, to set the scheme, the path, and the options
+// of the entries; a bare name uses https and the /api path.
+type hostsSrv struct {
+
+	// name is the name of the SRV record, empty when the discovery is disabled.
+	name string
+
+	// scheme is the scheme of the entries, http or https.
+	scheme string
+
+	// userinfo are the credentials of the entries followed by @, if any.
+	userinfo string
+
+	// suffix is the path of the entries, followed by their options, if any.
+	suffix string
+}
+
+// parseHostsSrv parses an nginx-hosts-srv value, an empty value disables the discovery.
+func parseHostsSrv(value string) (hostsSrv, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return hostsSrv{}, nil
+	}
+
+	if !strings.Contains(value, "://") {
+		value = defaultHostsSrvScheme + "://" + value + defaultHostsSrvPath
+	}
+
+	endpoint, options, _ := strings.Cut(value, ";")
+
+	srvUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return hostsSrv{}, fmt.Errorf(`%s is not a valid URL: %w`, HostsSrvKey, err)
+	}
+
+	if srvUrl.Port() != "" {
+		return hostsSrv{}, fmt.Errorf(`%s must not set a port, the ports are those of the SRV record, got %q`, HostsSrvKey, srvUrl.Host)
+	}
+
+	srv := hostsSrv{
+		name:   srvUrl.Hostname(),
+		scheme: srvUrl.Scheme,
+		suffix: srvUrl.EscapedPath(),
+	}
+
+	if srvUrl.User != nil {
+		srv.userinfo = srvUrl.User.String() + "@"
+	}
+
+	if options != "" {
+		srv.suffix += ";" + options
+	}
+
+	// the entries of the targets must be valid nginx-hosts entries
+	if _, err = ParseNginxPlusHost(srv.entry("nginx-plus.example.com", 443)); err != nil {
+		return hostsSrv{}, fmt.Errorf(`%s does not result in valid nginx-hosts entries: %w`, HostsSrvKey, err)
+	}
+
+	return srv, nil
+}
+
+// entry returns the nginx-hosts entry of a target of the SRV record.
+func (h hostsSrv) entry(target string, port uint16) string {
+	return h.scheme + "://" + h.userinfo + net.JoinHostPort(strings.TrimSuffix(target, "."), strconv.Itoa(int(port))) + h.suffix
+}
+
+// hostsDiscovery holds the NGINX Plus hosts discovered through the nginx-hosts-srv record. A host is added as soon as it
+// appears in the record, and removed once it has been missing for the Settings::HostsSrvRemovalDelay, so a transient DNS
+// failure or a partial answer does not remove and add the hosts back; a failed resolution leaves the hosts unchanged.
+type hostsDiscovery struct {
+
+	// lock guards the fields below.
+	lock sync.Mutex
+
+	// srv is the current nginx-hosts-srv value.
+	srv hostsSrv
+
+	// discovered are the entries of the targets of the record, in the order they were discovered.
+	discovered []string
+
+	// missingSince holds when each discovered host was first missing from the record, until it reappears or is removed.
+	missingSince map[string]time.Time
+
+	// changes wakes the discovery loop up when the nginx-hosts-srv value changes.
+	changes chan struct{}
+}
+
+// newHostsDiscovery creates a new, disabled hostsDiscovery.
+func newHostsDiscovery() *hostsDiscovery {
+	return &hostsDiscovery{
+		missingSince: make(map[string]time.Time),
+		changes:      make(chan struct{}, 1),
+	}
+}
+
+// setSrv replaces the nginx-hosts-srv value, the hosts discovered through the previous value are dropped at once.
+// Returns false if the value is unchanged.
+func (d *hostsDiscovery) setSrv(srv hostsSrv) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.srv == srv {
+		return false
+	}
+
+	d.srv = srv
+	d.discovered = nil
+	d.missingSince = make(map[string]time.Time)
+
+	select {
+	case d.changes <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// current returns the current nginx-hosts-srv value.
+func (d *hostsDiscovery) current() hostsSrv {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.srv
+}
+
+// hosts returns a copy of the discovered entries.
+func (d *hostsDiscovery) hosts() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return slices.Clone(d.discovered)
+}
+
+// update applies the entries resolved for the srv at the time, and returns the entries added and removed. The entries are
+// ignored if the nginx-hosts-srv value has changed during the resolution.
+func (d *hostsDiscovery) update(srv hostsSrv, entries []string, now time.Time, removalDelay time.Duration) ([]string, []string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.srv != srv {
+		return nil, nil
+	}
+
+	var added []string
+	for _, entry := range entries {
+		delete(d.missingSince, entry)
+
+		if !slices.Contains(d.discovered, entry) {
+			d.discovered = append(d.discovered, entry)
+			added = append(added, entry)
+		}
+	}
+
+	var removed []string
+	kept := d.discovered[:0]
+
+	for _, host := range d.discovered {
+		if slices.Contains(entries, host) {
+			kept = append(kept, host)
+			continue
+		}
+
+		missingSince, missing := d.missingSince[host]
+		if !missing {
+			d.missingSince[host] = now
+			logrus.Warnf("hostsDiscovery::update: %s is missing from the %s record, it is removed unless it reappears within %v", host, srv.name, removalDelay)
+		}
+
+		if missing && now.Sub(missingSince) >= removalDelay {
+			delete(d.missingSince, host)
+			removed = append(removed, host)
+			continue
+		}
+
+		kept = append(kept, host)
+	}
+
+	d.discovered = kept
+
+	return added, removed
+}
+
+// applyHostsSrv replaces the nginx-hosts-srv value, an empty value stops the discovery and removes the discovered hosts;
+// the hosts of a new record are discovered right away. The current value is kept if the value is invalid.
+func (s *Settings) applyHostsSrv(value string) error {
+	srv, err := parseHostsSrv(value)
+	if err != nil {
+		return err
+	}
+
+	s.hostSourcesLock.Lock()
+	defer s.hostSourcesLock.Unlock()
+
+	if !s.hostsDiscovery.setSrv(srv) {
+		return nil
+	}
+
+	if srv.name == "" {
+		logrus.Info("Settings::applyHostsSrv: the NGINX Plus hosts are no longer discovered through DNS SRV")
+	} else {
+		logrus.Infof("Settings::applyHostsSrv: discovering the NGINX Plus hosts through the %s SRV record every %v", srv.name, s.HostsSrvInterval)
+	}
+
+	s.applyHostGroups()
+
+	return nil
+}
+
+// runHostsDiscovery resolves the nginx-hosts-srv record every HostsSrvInterval, and as soon as it changes, until the Context is done.
+func (s *Settings) runHostsDiscovery() {
+	ticker := time.NewTicker(s.HostsSrvInterval)
+	defer ticker.Stop()
+
+	for {
+		s.discoverHosts()
+
+		select {
+		case <-s.Context.Done():
+			return
+		case <-ticker.C:
+		case <-s.hostsDiscovery.changes:
+		}
+	}
+}
+
+// discoverHosts resolves the nginx-hosts-srv record, and merges the hosts added or removed with the static hosts, see
+// applyHostGroups; the added hosts receive the servers of every upstream from the Synchronizer, like any added host.
+// A record that does not exist has no targets, while any other resolution error leaves the discovered hosts unchanged.
+func (s *Settings) discoverHosts() {
+	srv := s.hostsDiscovery.current()
+	if srv.name == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.Context, hostsSrvLookupTimeout)
+	defer cancel()
+
+	records, err := s.lookupSrv(ctx, srv.name)
+
+	var dnsError *net.DNSError
+	if err != nil && !(errors.As(err, &dnsError) && dnsError.IsNotFound) {
+		logrus.Warnf("Settings::discoverHosts: error resolving the %s SRV record, the discovered hosts are unchanged: %v", srv.name, err)
+		return
+	}
+
+	entries := make([]string, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, srv.entry(record.Target, record.Port))
+	}
+
+	added, removed := s.hostsDiscovery.update(srv, entries, time.Now(), s.HostsSrvRemovalDelay)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	logrus.Infof("Settings::discoverHosts: the %s SRV record added %v, removed %v", srv.name, added, removed)
+
+	s.hostSourcesLock.Lock()
+	defer s.hostSourcesLock.Unlock()
+
+	s.applyHostGroups()
+}
+
+// lookupSrvRecords resolves the SRV record of the name with the default resolver.
+func lookupSrvRecords(ctx context.Context, name string) ([]*net.SRV, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	return records, err
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestParseNginxPlusHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		expected NginxPlusHost
+	}{
+		{"default version", "https://nginx:9000/api", NginxPlusHost{Endpoint: "https://nginx:9000/api"}},
+		{"path prefix", "https://nginx:9000/nginx-api", NginxPlusHost{Endpoint: "https://nginx:9000/nginx-api"}},
+		{"api version", "http://10.0.0.1:8080/nginx-api;version=8", NginxPlusHost{Endpoint: "http://10.0.0.1:8080/nginx-api", ApiVersion: 8}},
+		{"server name", "https://10.0.0.5:443/api;sni=plus-1.internal.example.com", NginxPlusHost{Endpoint: "https://10.0.0.5:443/api", ServerName: "plus-1.internal.example.com"}},
+		{"api version and server name", "https://10.0.0.5/api;sni=Plus-1.example.com;version=8", NginxPlusHost{Endpoint: "https://10.0.0.5/api", ApiVersion: 8, ServerName: "plus-1.example.com"}},
+		{"insecure", "https://10.0.9.1:9000/api;insecure=true", NginxPlusHost{Endpoint: "https://10.0.9.1:9000/api", Insecure: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := ParseNginxPlusHost(test.host)
+			if err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			test.expected.Entry = test.host
+			if parsed != test.expected {
+				t.Errorf(`expected %#v, got %#v`, test.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestParseNginxPlusHost_RejectsInvalidHosts(t *testing.T) {
+	for _, host := range []string{
+		"https://nginx:9000/api;version=",
+		"https://nginx:9000/api;version=0",
+		"https://nginx:9000/api;version=eight",
+		"nginx:9000/api;version=8",
+		"https:///api",
+		"https://10.0.0.5/api;sni=",
+		"https://10.0.0.5/api;sni=plus_1.example.com",
+		"http://10.0.0.5/api;sni=plus-1.example.com",
+		"https://10.0.0.5/api;version=8;port=9000",
+		"https://10.0.9.1/api;insecure=maybe",
+		"http://10.0.9.1/api;insecure=true",
+	} {
+		if _, err := ParseNginxPlusHost(host); err == nil {
+			t.Errorf(`expected an error for %q`, host)
+		}
+	}
+}
+
+func TestSettings_SetHostsNotifiesTheChanges(t *testing.T) {
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	var added, removed [][]string
+	settings.SubscribeToHostChanges(func(addedHosts []string, removedHosts []string) {
+		added = append(added, addedHosts)
+		removed = append(removed, removedHosts)
+	})
+
+	settings.SetHosts([]string{"https://nginx-1:9000/api", "https://nginx-2:9000/api"})
+	settings.SetHosts([]string{"https://nginx-2:9000/api", "https://nginx-1:9000/api"})
+	settings.SetHosts([]string{"https://nginx-2:9000/api", "https://nginx-3:9000/api"})
+
+	if len(added) != 2 {
+		t.Fatalf(`expected a notification for each change of the hosts, got %d`, len(added))
+	}
+
+	if !slices.Equal(added[1], []string{"https://nginx-3:9000/api"}) || !slices.Equal(removed[1], []string{"https://nginx-1:9000/api"}) {
+		t.Errorf(`expected nginx-3 to be added and nginx-1 removed, got %v and %v`, added[1], removed[1])
+	}
+
+	hosts := settings.Hosts()
+	hosts[0] = "https://changed:9000/api"
+
+	if settings.Hosts()[0] != "https://nginx-2:9000/api" {
+		t.Errorf(`expected Hosts to return a copy, got %v`, settings.Hosts())
+	}
+}
+
+func TestSettings_ServerNameIsLookedUpByAddress(t *testing.T) {
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.SetHostGroups(
+		[]string{"https://10.0.0.5:443/api;sni=plus-1.example.com", "https://10.0.0.6:443/api"},
+		[]string{"https://10.0.0.7/api;version=8;sni=plus-3.example.com"},
+	)
+
+	for address, expected := range map[string]string{
+		"10.0.0.5:443": "plus-1.example.com",
+		"10.0.0.6:443": "",
+		"10.0.0.7":     "plus-3.example.com",
+	} {
+		if serverName := settings.ServerName(address); serverName != expected {
+			t.Errorf(`expected the server name of %s to be %q, got %q`, address, expected, serverName)
+		}
+	}
+
+	settings.SetHosts([]string{"https://10.0.0.6:443/api"})
+
+	if serverName := settings.ServerName("10.0.0.5:443"); serverName != "" {
+		t.Errorf(`expected the server name to be dropped with its host, got %q`, serverName)
+	}
+}
+
+func TestSettings_HostsWithTheSecondaryRoleAreSecondary(t *testing.T) {
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.SetHosts([]string{"https://10.0.0.1:9000/api;site=east", "https://10.0.1.1:9000/api;site=west;role=secondary"})
+
+	if !slices.Equal(settings.PrimaryHosts(), []string{"https://10.0.0.1:9000/api;site=east"}) {
+		t.Errorf(`expected the east host to be the only primary host, got %v`, settings.PrimaryHosts())
+	}
+
+	primary := settings.PrimaryNginxPlusHosts()
+	if len(primary) != 1 || primary[0].Endpoint != "https://10.0.0.1:9000/api" || primary[0].Site != "east" {
+		t.Errorf(`expected the parsed east host, got %#v`, primary)
+	}
+
+	host, found := settings.NginxPlusHost("https://10.0.1.1:9000/api;site=west;role=secondary")
+	if !found || host.Site != "west" || host.Role != HostRoleSecondary {
+		t.Errorf(`expected the parsed west host, got %#v`, host)
+	}
+
+	if _, found = settings.NginxPlusHost("https://10.0.2.1:9000/api"); found {
+		t.Errorf(`expected a host that is not configured not to be found`)
+	}
+}
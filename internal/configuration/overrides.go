@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Overrides holds the values of the command line flags that mirror a setting. The flags take precedence over the
+// environment, which takes precedence over the defaults; a nil value leaves the setting to the environment or the default.
+// The ConfigMap keys of the same settings, e.g. tls-mode and log-level, still apply once NLK is running.
+type Overrides struct {
+
+	// TlsMode overrides the TLS mode used when the ConfigMap does not set tls-mode, see NKL_TLS_MODE.
+	TlsMode *string
+
+	// WatchNamespaces overrides WatcherSettings::NginxIngressNamespaces, as a comma-separated list, see NKL_NGINX_INGRESS_NAMESPACES.
+	WatchNamespaces *string
+
+	// LogLevel overrides Settings::LogLevel, see NKL_LOG_LEVEL.
+	LogLevel *string
+
+	// DryRun overrides Settings::DryRun, see NKL_DRY_RUN.
+	DryRun *bool
+
+	// ForcePrune overrides SynchronizerSettings::ForcePrune, see NKL_FORCE_PRUNE.
+	ForcePrune *bool
+
+	// KubeApiQPS overrides KubeApiSettings::QPS, see NKL_KUBE_API_QPS.
+	KubeApiQPS *float64
+
+	// KubeApiBurst overrides KubeApiSettings::Burst, see NKL_KUBE_API_BURST.
+	KubeApiBurst *int
+
+	// NginxHosts pins the NGINX Plus hosts, the nginx-hosts settings of the ConfigMap and the configuration file are ignored,
+	// e.g. to point NLK at the fake NGINX Plus API of the --mock-nginx flag.
+	NginxHosts []string
+}
+
+// NewSettingsWithOverrides creates a new Settings object like NewSettings, with the values set by the command line flags
+// overriding both the defaults and the environment.
+func NewSettingsWithOverrides(ctx context.Context, k8sClient kubernetes.Interface, overrides Overrides) (*Settings, error) {
+	return newSettings(ctx, k8sClient, overrides)
+}
+
+// NewKubeApiSettings returns the KubeApiSettings: the defaults, overridden by any values found in the environment, then
+// by the command line flags. The Kubernetes client is built with them before the Settings, which need the client.
+func NewKubeApiSettings(overrides Overrides) (KubeApiSettings, error) {
+	kubeApi := KubeApiSettings{
+		QPS:   DefaultKubeApiQPS,
+		Burst: DefaultKubeApiBurst,
+	}
+
+	if err := applyKubeApiEnvironment(&kubeApi); err != nil {
+		return kubeApi, fmt.Errorf(`error occurred reading settings from the environment: %w`, err)
+	}
+
+	if overrides.KubeApiQPS != nil {
+		if *overrides.KubeApiQPS <= 0 || math.IsNaN(*overrides.KubeApiQPS) || math.IsInf(*overrides.KubeApiQPS, 0) {
+			return kubeApi, fmt.Errorf(`error occurred reading settings from the command line: invalid value for --kube-api-qps: %v must be positive`, *overrides.KubeApiQPS)
+		}
+
+		kubeApi.QPS = float32(*overrides.KubeApiQPS)
+	}
+
+	if overrides.KubeApiBurst != nil {
+		if *overrides.KubeApiBurst <= 0 {
+			return kubeApi, fmt.Errorf(`error occurred reading settings from the command line: invalid value for --kube-api-burst: %d must be positive`, *overrides.KubeApiBurst)
+		}
+
+		kubeApi.Burst = *overrides.KubeApiBurst
+	}
+
+	return kubeApi, nil
+}
+
+// applyOverrides overrides the Settings values with the values set by the command line flags.
+func (s *Settings) applyOverrides(overrides Overrides) error {
+	var err error
+
+	if overrides.TlsMode != nil {
+		if s.DefaultTlsMode, err = parseTlsMode(*overrides.TlsMode); err != nil {
+			return fmt.Errorf(`invalid value for --tls-mode: %w`, err)
+		}
+
+		s.TlsMode = s.DefaultTlsMode
+		s.defaultTlsModeSource = TlsModeSourceFlag
+	}
+
+	if overrides.WatchNamespaces != nil {
+		if s.Watcher.NginxIngressNamespaces, err = parseNamespaces(*overrides.WatchNamespaces); err != nil {
+			return fmt.Errorf(`invalid value for --watch-namespace: %w`, err)
+		}
+	}
+
+	if overrides.LogLevel != nil {
+		if s.LogLevel, err = parseLogLevel(*overrides.LogLevel); err != nil {
+			return fmt.Errorf(`invalid value for --log-level: %w`, err)
+		}
+	}
+
+	if overrides.DryRun != nil {
+		s.DryRun = *overrides.DryRun
+	}
+
+	if overrides.ForcePrune != nil {
+		s.Synchronizer.ForcePrune = *overrides.ForcePrune
+	}
+
+	if overrides.NginxHosts != nil {
+		hosts, errorCount := s.parseHostList(overrides.NginxHosts)
+		if errorCount > 0 {
+			return fmt.Errorf(`invalid NGINX Plus hosts: %v`, overrides.NginxHosts)
+		}
+
+		s.SetHosts(entriesOf(hosts))
+		s.hostsPinned = true
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewSettingsWithOverrides_FlagsOverrideTheEnvironmentWhichOverridesTheDefaults(t *testing.T) {
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	settings, err := NewSettingsWithOverrides(context.Background(), nil, Overrides{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.LogLevel != logrus.WarnLevel || settings.DefaultTlsMode != NoTLS || settings.DryRun || settings.Watcher.NginxIngressNamespaces[0] != "nginx-ingress" {
+		t.Fatalf(`expected the defaults, got level=%s, tlsMode=%s, dryRun=%t, namespaces=%v`, settings.LogLevel, settings.DefaultTlsMode, settings.DryRun, settings.Watcher.NginxIngressNamespaces)
+	}
+
+	t.Setenv(LogLevelEnv, "error")
+	t.Setenv(TlsModeEnv, CertificateAuthorityTLSString)
+	t.Setenv(DryRunEnv, "true")
+	t.Setenv(NginxIngressNamespacesEnv, "nginx-ingress-public")
+
+	settings, err = NewSettingsWithOverrides(context.Background(), nil, Overrides{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.LogLevel != logrus.ErrorLevel || settings.DefaultTlsMode != CertificateAuthorityTLS || !settings.DryRun || settings.Watcher.NginxIngressNamespaces[0] != "nginx-ingress-public" {
+		t.Fatalf(`expected the environment, got level=%s, tlsMode=%s, dryRun=%t, namespaces=%v`, settings.LogLevel, settings.DefaultTlsMode, settings.DryRun, settings.Watcher.NginxIngressNamespaces)
+	}
+
+	logLevel, tlsMode, dryRun, namespaces := "debug", SelfSignedTLSString, false, "nginx-ingress-internal"
+
+	settings, err = NewSettingsWithOverrides(context.Background(), nil, Overrides{TlsMode: &tlsMode, WatchNamespaces: &namespaces, LogLevel: &logLevel, DryRun: &dryRun})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings.LogLevel != logrus.DebugLevel || settings.DefaultTlsMode != SelfSignedTLS || settings.TlsMode != SelfSignedTLS || settings.DryRun || settings.Watcher.NginxIngressNamespaces[0] != "nginx-ingress-internal" {
+		t.Fatalf(`expected the flags, got level=%s, tlsMode=%s, dryRun=%t, namespaces=%v`, settings.LogLevel, settings.DefaultTlsMode, settings.DryRun, settings.Watcher.NginxIngressNamespaces)
+	}
+}
+
+func TestNewSettingsWithOverrides_RejectsInvalidFlags(t *testing.T) {
+	invalid := "verbose"
+
+	if _, err := NewSettingsWithOverrides(context.Background(), nil, Overrides{LogLevel: &invalid}); err == nil {
+		t.Error(`expected an error for an invalid log level`)
+	}
+
+	if _, err := NewSettingsWithOverrides(context.Background(), nil, Overrides{TlsMode: &invalid}); err == nil {
+		t.Error(`expected an error for an invalid TLS mode`)
+	}
+}
+
+func TestNewSettingsWithOverrides_PinsTheHosts(t *testing.T) {
+	settings, err := NewSettingsWithOverrides(context.Background(), nil, Overrides{NginxHosts: []string{"http://127.0.0.1:8080/api"}})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+
+	settings.handleUpdateEvent(nil, buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+	settings.handleDeleteEvent(buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+
+	if hosts := settings.Hosts(); len(hosts) != 1 || hosts[0] != "http://127.0.0.1:8080/api" {
+		t.Fatalf(`expected the pinned host, got %v`, hosts)
+	}
+
+	if _, err = NewSettingsWithOverrides(context.Background(), nil, Overrides{NginxHosts: []string{"127.0.0.1:8080"}}); err == nil {
+		t.Error(`expected an error for an invalid host`)
+	}
+}
+
+func TestNewKubeApiSettings_FlagsOverrideTheEnvironmentWhichOverridesTheDefaults(t *testing.T) {
+	kubeApi, err := NewKubeApiSettings(Overrides{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if kubeApi.QPS != DefaultKubeApiQPS || kubeApi.Burst != DefaultKubeApiBurst {
+		t.Fatalf(`expected the defaults, got %+v`, kubeApi)
+	}
+
+	t.Setenv(KubeApiQpsEnv, "20.5")
+	t.Setenv(KubeApiBurstEnv, "40")
+
+	if kubeApi, err = NewKubeApiSettings(Overrides{}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if kubeApi.QPS != 20.5 || kubeApi.Burst != 40 {
+		t.Fatalf(`expected the environment, got %+v`, kubeApi)
+	}
+
+	qps, burst := 200.0, 400
+
+	if kubeApi, err = NewKubeApiSettings(Overrides{KubeApiQPS: &qps, KubeApiBurst: &burst}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if kubeApi.QPS != 200 || kubeApi.Burst != 400 {
+		t.Fatalf(`expected the flags, got %+v`, kubeApi)
+	}
+
+	zero := 0.0
+	if _, err = NewKubeApiSettings(Overrides{KubeApiQPS: &zero}); err == nil {
+		t.Error(`expected an error for a zero QPS, which client-go replaces with its default`)
+	}
+}
+
+func TestSettings_RemovingTheConfigMapKeysRestoresTheFlags(t *testing.T) {
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	t.Setenv(TlsModeEnv, CertificateAuthorityTLSString)
+	tlsMode, namespaces := SelfSignedTLSString, "nginx-ingress-internal"
+
+	settings, err := NewSettingsWithOverrides(context.Background(), nil, Overrides{TlsMode: &tlsMode, WatchNamespaces: &namespaces})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = NoTLSString
+	configMap.Data[ConfigFileKey] = "watcher:\n  nginx-ingress-namespace: nginx-ingress-public\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CurrentTlsMode() != NoTLS || settings.Watcher.NginxIngressNamespaces[0] != "nginx-ingress-public" {
+		t.Fatalf(`expected the ConfigMap, got tlsMode=%s, namespaces=%v`, settings.CurrentTlsMode(), settings.Watcher.NginxIngressNamespaces)
+	}
+
+	delete(configMap.Data, "tls-mode")
+	configMap.Data[ConfigFileKey] = "watcher:\n  resync-period: 1m\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.CurrentTlsMode() != SelfSignedTLS || !reflect.DeepEqual(settings.Watcher.NginxIngressNamespaces, []string{"nginx-ingress-internal"}) {
+		t.Fatalf(`expected the flags, got tlsMode=%s, namespaces=%v`, settings.CurrentTlsMode(), settings.Watcher.NginxIngressNamespaces)
+	}
+
+	configMap.Data[ConfigFileKey] = "watcher:\n  nginx-ingress-namespace: nginx-ingress-public\n"
+	settings.handleUpdateEvent(nil, configMap)
+	delete(configMap.Data, ConfigFileKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if !reflect.DeepEqual(settings.Watcher.NginxIngressNamespaces, []string{"nginx-ingress-internal"}) {
+		t.Errorf(`expected the namespaces of the flag once the configuration document is removed, got %v`, settings.Watcher.NginxIngressNamespaces)
+	}
+}
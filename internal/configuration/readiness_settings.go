@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ReadinessRequiredHostsAny reports ready when at least one of the NGINX Plus hosts can be reached.
+	ReadinessRequiredHostsAny = "any"
+
+	// ReadinessRequiredHostsAll reports ready only when every NGINX Plus host can be reached.
+	ReadinessRequiredHostsAll = "all"
+)
+
+// ReadinessSettings contains the configuration values needed by the readiness probe.
+type ReadinessSettings struct {
+
+	// RequiredHosts determines how many NGINX Plus hosts must be reachable for the replica to be ready,
+	// ReadinessRequiredHostsAny or ReadinessRequiredHostsAll.
+	RequiredHosts string
+
+	// CheckInterval is how long the result of calling the NGINX Plus hosts is cached by the readiness probe.
+	CheckInterval time.Duration
+}
+
+// logFields returns the settings of the readiness probe as log fields.
+func (s ReadinessSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"requiredHosts": s.RequiredHosts,
+		"checkInterval": s.CheckInterval,
+	}
+}
+
+// validateReadinessRequiredHosts returns an error if the value is not one of the supported readiness modes.
+func validateReadinessRequiredHosts(requiredHosts string) error {
+	if requiredHosts != ReadinessRequiredHostsAny && requiredHosts != ReadinessRequiredHostsAll {
+		return fmt.Errorf(`readiness required hosts must be %s or %s, got %q`, ReadinessRequiredHostsAny, ReadinessRequiredHostsAll, requiredHosts)
+	}
+
+	return nil
+}
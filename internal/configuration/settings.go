@@ -7,33 +7,41 @@ package configuration
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	"strings"
+	"k8s.io/client-go/tools/record"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	// ConfigMapsNamespace is the value used to filter the ConfigMaps Resource in the Informer.
-	ConfigMapsNamespace = "nlk"
+	// DefaultConfigMapNamespace is the default namespace of the ConfigMap that contains the configuration for the application.
+	DefaultConfigMapNamespace = "nlk"
 
-	// ConfigMapName is the name of the ConfigMap that contains the configuration for the application.
-	ConfigMapName = "nlk-config"
+	// DefaultConfigMapName is the default name of the ConfigMap that contains the configuration for the application.
+	DefaultConfigMapName = "nlk-config"
 
-	// ResyncPeriod is the value used to set the resync period for the Informer.
+	// ResyncPeriod is the value used to set the resync period for the ConfigMap Informer.
 	ResyncPeriod = 0
 
 	// NlkPrefix is used to determine if a Port definition should be handled and used to update a Border Server.
 	// The Port name () must start with this prefix, e.g.:
 	//   nlk-<my-upstream-name>
-	NlkPrefix = ConfigMapsNamespace + "-"
+	NlkPrefix = "nlk-"
 
 	// PortAnnotationPrefix defines the prefix used when looking up a Port in the Service Annotations.
 	// The value of the annotation determines which BorderServer implementation will be used.
@@ -41,80 +49,135 @@ const (
 	PortAnnotationPrefix = "nginxinc.io"
 )
 
-// WorkQueueSettings contains the configuration values needed by the Work Queues.
-// There are two work queues in the application:
-// 1. nlk-handler queue, used to move messages between the Watcher and the Handler.
-// 2. nlk-synchronizer queue, used to move message between the Handler and the Synchronizer.
-// The queues are NamedDelayingQueue objects that use an ItemExponentialFailureRateLimiter as the underlying rate limiter.
-type WorkQueueSettings struct {
-	// Name is the name of the queue.
-	Name string
-
-	// RateLimiterBase is the value used to calculate the exponential backoff rate limiter.
-	// The formula is: RateLimiterBase * 2 ^ (num_retries - 1)
-	RateLimiterBase time.Duration
-
-	// RateLimiterMax limits the amount of time retries are allowed to be attempted.
-	RateLimiterMax time.Duration
-}
+// Settings contains the configuration values needed by the application.
+type Settings struct {
 
-// HandlerSettings contains the configuration values needed by the Handler.
-type HandlerSettings struct {
+	// Context is the context used to control the application.
+	Context context.Context
 
-	// RetryCount is the number of times the Handler will attempt to process a message before giving up.
-	RetryCount int
+	// ConfigMapNamespace is the namespace of the ConfigMap that contains the configuration for the application.
+	ConfigMapNamespace string
 
-	// Threads is the number of threads that will be used to process messages.
-	Threads int
+	// ConfigMapName is the name of the ConfigMap that contains the configuration for the application.
+	ConfigMapName string
 
-	// WorkQueueSettings is the configuration for the Handler's queue.
-	WorkQueueSettings WorkQueueSettings
-}
+	// ConfigFilePath is the optional path to a mounted configuration document (see ConfigFile), applied during Initialize.
+	ConfigFilePath string
 
-// WatcherSettings contains the configuration values needed by the Watcher.
-type WatcherSettings struct {
+	// nginxPlusHosts is a list of Nginx Plus hosts that will be used to update the Border Servers, see Hosts and SetHosts.
+	nginxPlusHosts []string
 
-	// NginxIngressNamespace is the namespace used to filter Services in the Watcher.
-	NginxIngressNamespace string
+	// secondaryHosts are the hosts of nginxPlusHosts whose failures are not fatal, see SetHostGroups.
+	secondaryHosts map[string]bool
 
-	// ResyncPeriod is the value used to set the resync period for the underlying SharedInformer.
-	ResyncPeriod time.Duration
-}
+	// serverNames are the server names set on the nginxPlusHosts, by the address of their Endpoint, see ServerName.
+	serverNames map[string]string
 
-// SynchronizerSettings contains the configuration values needed by the Synchronizer.
-type SynchronizerSettings struct {
+	// insecureAddresses are the addresses of the Endpoints of the nginxPlusHosts whose certificates are not verified,
+	// see InsecureSkipVerify.
+	insecureAddresses map[string]bool
 
-	// MaxMillisecondsJitter is the maximum number of milliseconds that will be applied when adding an event to the queue.
-	MaxMillisecondsJitter int
+	// parsedHosts are the parsed nginxPlusHosts, by entry, see NginxPlusHost.
+	parsedHosts map[string]NginxPlusHost
 
-	// MinMillisecondsJitter is the minimum number of milliseconds that will be applied when adding an event to the queue.
-	MinMillisecondsJitter int
+	// staticPrimaryHosts and staticSecondaryHosts are the hosts last set with SetHostGroups, from the ConfigMap, the
+	// configuration file, or the command line, merged with the hosts of the hostsDiscovery.
+	staticPrimaryHosts   []string
+	staticSecondaryHosts []string
 
-	// RetryCount is the number of times the Synchronizer will attempt to process a message before giving up.
-	RetryCount int
+	// hostKeys are the ConfigMap keys the static hosts were listed in, by entry, see applyHostKeys; they are only accessed
+	// by the ConfigMap informer.
+	hostKeys map[string]string
 
-	// Threads is the number of threads that will be used to process messages.
-	Threads int
+	// hostsDiscovery holds the hosts discovered through the nginx-hosts-srv record, see applyHostsSrv.
+	hostsDiscovery *hostsDiscovery
 
-	// WorkQueueSettings is the configuration for the Synchronizer's queue.
-	WorkQueueSettings WorkQueueSettings
-}
+	// hostSourcesLock guards the static hosts, and serializes the replacements of the hosts from their sources; it is
+	// acquired before the hostsLock.
+	hostSourcesLock sync.Mutex
 
-// Settings contains the configuration values needed by the application.
-type Settings struct {
+	// lookupSrv resolves an SRV record, replaced in the tests.
+	lookupSrv func(ctx context.Context, name string) ([]*net.SRV, error)
 
-	// Context is the context used to control the application.
-	Context context.Context
+	// HostsSrvInterval is how often the nginx-hosts-srv record is resolved.
+	HostsSrvInterval time.Duration
+
+	// HostsSrvRemovalDelay is how long a discovered host may be missing from the nginx-hosts-srv record before it is
+	// removed, so a transient resolution failure does not remove the host and add it back.
+	HostsSrvRemovalDelay time.Duration
+
+	// hostsPinned is set when the hosts are set by Overrides::NginxHosts, the ConfigMap and the configuration file do not change them.
+	hostsPinned bool
+
+	// HostsRetention is how long the NGINX Plus hosts are kept once the ConfigMap is deleted, so an accidental deletion
+	// does not stop the synchronization at once; zero clears them immediately. See RetainedHostsDeadline.
+	HostsRetention time.Duration
+
+	// hostsClearTimer clears the retained hosts once the HostsRetention has elapsed, nil unless the ConfigMap was deleted.
+	hostsClearTimer *time.Timer
+
+	// hostsClearedAt is when the hostsClearTimer fires.
+	hostsClearedAt time.Time
+
+	// hostsRetentionLock guards the hostsClearTimer and hostsClearedAt.
+	hostsRetentionLock sync.Mutex
+
+	// hostsLock guards the nginxPlusHosts, secondaryHosts, serverNames, and parsedHosts, they are replaced by the informer while the Synchronizer reads them.
+	hostsLock sync.RWMutex
+
+	// hostSubscribers are the callbacks invoked when the list of hosts changes.
+	hostSubscribers []func(added []string, removed []string)
+
+	// hostSubscribersLock guards the hostSubscribers.
+	hostSubscribersLock sync.Mutex
+
+	// LogFormat is the format of the logs, LogFormatText or LogFormatJson.
+	// NOTE: the log format is read at startup; changing it at runtime has no effect until restart.
+	LogFormat string
+
+	// LogLevel is the log level at startup, and the level used when the ConfigMap does not set a valid log-level; warn by default.
+	LogLevel logrus.Level
 
-	// NginxPlusHosts is a list of Nginx Plus hosts that will be used to update the Border Servers.
-	NginxPlusHosts []string
+	// DryRun is the dry-run mode at startup, set with the --dry-run flag or NKL_DRY_RUN, and the mode used when the ConfigMap does not set dry-run.
+	// See IsDryRun for the current mode.
+	DryRun bool
+
+	// dryRun is the current dry-run mode.
+	dryRun atomic.Bool
+
+	// DefaultTlsMode is the TLS mode at startup, set with the --tls-mode flag or NKL_TLS_MODE, and the mode used when the ConfigMap does not set tls-mode.
+	DefaultTlsMode TLSMode
+
+	// defaultTlsModeSource is where the DefaultTlsMode was set, one of the TlsModeSource values, see EffectiveSettings.
+	defaultTlsModeSource string
+
+	// DefaultNginxIngressNamespaces are the watched namespaces at startup, set with the --watch-namespace flag,
+	// NKL_NGINX_INGRESS_NAMESPACES, or the --config-file, and the namespaces watched when the configuration document does not set nginx-ingress-namespace.
+	DefaultNginxIngressNamespaces []string
 
 	// TlsMode is the value used to determine which of the five TLS modes will be used to communicate with the Border Servers (see: ../../docs/tls/README.md).
+	// It is changed by the informer while the TLS config is rebuilt, which reads it with CurrentTlsMode.
 	TlsMode TLSMode
 
+	// tlsModeLock guards the TlsMode once the informer is running.
+	tlsModeLock sync.RWMutex
+
+	// TlsMinVersion is the minimum TLS version of the connections to the Border Servers, set by the tls-min-version key; zero for the Go default.
+	TlsMinVersion uint16
+
+	// TlsCipherSuites are the cipher suites of the connections to the Border Servers, set by the tls-cipher-suites key; nil for the Go defaults.
+	TlsCipherSuites []uint16
+
+	// CrlFailOpen allows the connections to the Border Servers once the CRL of the CA has expired, set by the ca-crl-expired-policy key.
+	CrlFailOpen bool
+
 	// Certificates is the object used to retrieve the certificates and keys used to communicate with the Border Servers.
 	Certificates *certification.Certificates
 
+	// CertificateFiles reads the certificates and keys from mounted files instead of the Secrets, when the ConfigMap sets
+	// the path keys, see CertificateSource.
+	CertificateFiles *certification.FileCertificates
+
 	// K8sClient is the Kubernetes client used to communicate with the Kubernetes API.
 	K8sClient kubernetes.Interface
 
@@ -124,6 +187,19 @@ type Settings struct {
 	// eventHandlerRegistration is the object used to track the event handlers with the SharedInformer.
 	eventHandlerRegistration cache.ResourceEventHandlerRegistration
 
+	// informerStop stops the informer, it is nil while the informer is not running.
+	informerStop chan struct{}
+
+	// informerLock guards the informer, its event handler registration, and informerStop.
+	informerLock sync.Mutex
+
+	// handlersGeneration is incremented when the event handlers are removed, so that the events a removed registration
+	// still delivers are dropped; client-go does not wait for them.
+	handlersGeneration atomic.Uint64
+
+	// eventLock serializes the handling of the ConfigMap events.
+	eventLock sync.Mutex
+
 	// Handler contains the configuration values needed by the Handler.
 	Handler HandlerSettings
 
@@ -132,25 +208,91 @@ type Settings struct {
 
 	// Watcher contains the configuration values needed by the Watcher.
 	Watcher WatcherSettings
+
+	// LeaderElection contains the configuration values needed for leader election.
+	LeaderElection LeaderElectionSettings
+
+	// KubeApi contains the client-side rate limit of the Kubernetes client.
+	KubeApi KubeApiSettings
+
+	// Readiness contains the configuration values needed by the readiness probe.
+	Readiness ReadinessSettings
+
+	// HttpClient contains the configuration values needed by the HTTP client.
+	HttpClient HttpClientSettings
+
+	// Admin contains the configuration values needed by the admin server.
+	Admin AdminSettings
+
+	// Tracing contains the configuration values needed to export the traces of the event pipeline.
+	Tracing TracingSettings
+
+	// CertificateExpiry contains the configuration values needed to warn ahead of the expiry of the certificates.
+	CertificateExpiry CertificateExpirySettings
+
+	// EventRecorder is used to record Kubernetes Events, e.g.: on the ConfigMap when the configuration cannot be parsed,
+	// or on a Service when its annotations are invalid or its upstreams have been synced. Tests may set a record.FakeRecorder.
+	EventRecorder record.EventRecorder
+
+	// tlsSubscribers are the callbacks invoked when the TLS mode or certificates change.
+	tlsSubscribers []func()
+
+	// tlsSubscribersLock guards the tlsSubscribers.
+	tlsSubscribersLock sync.Mutex
+
+	// namespaceSubscribers are the callbacks invoked when the watched namespaces change.
+	namespaceSubscribers []func()
+
+	// namespaceSubscribersLock guards the namespaceSubscribers.
+	namespaceSubscribersLock sync.Mutex
+
+	// resyncPeriodSubscribers are the callbacks invoked when the WatcherSettings::ResyncPeriod changes.
+	resyncPeriodSubscribers []func()
+
+	// resyncPeriodSubscribersLock guards the resyncPeriodSubscribers.
+	resyncPeriodSubscribersLock sync.Mutex
 }
 
-// NewSettings creates a new Settings object with default values.
+// NewSettings creates a new Settings object with default values, overridden by any values found in the environment,
+// and configures the log format and level.
 func NewSettings(ctx context.Context, k8sClient kubernetes.Interface) (*Settings, error) {
+	return newSettings(ctx, k8sClient, Overrides{})
+}
+
+// newSettings creates a new Settings object with default values, overridden by any values found in the environment,
+// themselves overridden by the command line flags, and configures the log format and level.
+func newSettings(ctx context.Context, k8sClient kubernetes.Interface, overrides Overrides) (*Settings, error) {
 	settings := &Settings{
-		Context:      ctx,
-		K8sClient:    k8sClient,
-		TlsMode:      NoTLS,
-		Certificates: nil,
+		Context:              ctx,
+		ConfigMapNamespace:   DefaultConfigMapNamespace,
+		ConfigMapName:        DefaultConfigMapName,
+		K8sClient:            k8sClient,
+		DefaultTlsMode:       NoTLS,
+		defaultTlsModeSource: TlsModeSourceDefault,
+		TlsMode:              NoTLS,
+		Certificates:         nil,
+		CertificateFiles:     certification.NewFileCertificates(ctx),
+		LogFormat:            LogFormatText,
+		LogLevel:             logrus.WarnLevel,
+		HostsRetention:       DefaultHostsRetention,
+		HostsSrvInterval:     DefaultHostsSrvInterval,
+		HostsSrvRemovalDelay: DefaultHostsSrvRemovalDelay,
+		hostsDiscovery:       newHostsDiscovery(),
+		lookupSrv:            lookupSrvRecords,
 		Handler: HandlerSettings{
 			RetryCount: 5,
 			Threads:    1,
 			WorkQueueSettings: WorkQueueSettings{
 				RateLimiterBase: time.Second * 2,
 				RateLimiterMax:  time.Second * 60,
+				MaxDepth:        DefaultQueueMaxDepth,
+				DegradedAge:     DefaultQueueDegradedAge,
 				Name:            "nlk-handler",
 			},
+			DeleteDeferral: time.Second * 10,
 		},
 		Synchronizer: SynchronizerSettings{
+			BorderType:            core.BorderTypeNginxPlus,
 			MaxMillisecondsJitter: 750,
 			MinMillisecondsJitter: 250,
 			RetryCount:            5,
@@ -158,18 +300,141 @@ func NewSettings(ctx context.Context, k8sClient kubernetes.Interface) (*Settings
 			WorkQueueSettings: WorkQueueSettings{
 				RateLimiterBase: time.Second * 2,
 				RateLimiterMax:  time.Second * 60,
+				MaxDepth:        DefaultQueueMaxDepth,
+				DegradedAge:     DefaultQueueDegradedAge,
 				Name:            "nlk-synchronizer",
 			},
+			CoalesceWindow:               time.Second * 2,
+			Prune:                        false,
+			ReconcileInterval:            time.Minute * 5,
+			HostStagger:                  0,
+			EmptyServerPolicy:            EmptyServerPolicyApply,
+			DriftPolicy:                  DriftPolicyLog,
+			ServerAdmissionPolicy:        ServerAdmissionPolicyAdd,
+			LoadBalancerIngressIps:       []string{},
+			MissingUpstreamRetryInterval: time.Minute * 5,
+			UpstreamTimeout:              time.Second * 30,
+			ErrorLogWindow:               time.Minute,
+			ConvergenceWarningThreshold:  time.Second * 5,
+			CircuitBreakerThreshold:      5,
+			CircuitBreakerBackoff:        time.Second * 30,
+			CircuitBreakerMaxBackoff:     time.Minute * 5,
+			PersistState:                 true,
+			StateConfigMapName:           DefaultStateConfigMapName,
+			StatePersistDebounce:         time.Second * 10,
+			StatusAnnotationInterval:     0,
+			StatusConfigMapName:          DefaultStatusConfigMapName,
+			StatusConfigMapInterval:      time.Second * 30,
 		},
 		Watcher: WatcherSettings{
-			NginxIngressNamespace: "nginx-ingress",
-			ResyncPeriod:          0,
+			NginxIngressNamespaces:   []string{"nginx-ingress"},
+			ServiceSelector:          labels.Everything(),
+			UpstreamNameTemplate:     UpstreamNameTemplateName,
+			ResyncPeriod:             0,
+			CacheSyncTimeout:         time.Minute * 2,
+			DrainTimeout:             time.Minute * 5,
+			DeletedNodeDrainTimeout:  time.Minute * 2,
+			NotReadyGracePeriod:      time.Second * 10,
+			TargetMode:               TargetModeNodes,
+			RbacMode:                 RbacModeAuto,
+			NodeSelector:             labels.Everything(),
+			AddressFamily:            AddressFamilyDual,
+			NodeAddressTypes:         []corev1.NodeAddressType{corev1.NodeInternalIP},
+			ExcludeControlPlaneNodes: true,
+			ExcludedTaintKeys:        []string{},
+		},
+		HttpClient: HttpClientSettings{
+			DialTimeout:           time.Second * 5,
+			KeepAlive:             time.Second * 30,
+			TLSHandshakeTimeout:   time.Second * 5,
+			ResponseHeaderTimeout: time.Second * 10,
+			RequestTimeout:        time.Second * 10,
+			IdleConnTimeout:       time.Second * 90,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   10,
+			EnableHTTP2:           true,
+			WriteRateLimiter: RateLimiterSettings{
+				Rate:  0,
+				Burst: 50,
+			},
+			ReadRateLimiter: RateLimiterSettings{
+				Rate:  0,
+				Burst: 200,
+			},
+		},
+		Readiness: ReadinessSettings{
+			RequiredHosts: ReadinessRequiredHostsAny,
+			CheckInterval: time.Second * 10,
+		},
+		LeaderElection: LeaderElectionSettings{
+			Enabled:       false,
+			LeaseName:     "nlk-leader",
+			LeaseDuration: time.Second * 15,
+			RenewDeadline: time.Second * 10,
+			RetryPeriod:   time.Second * 2,
+		},
+		Admin: AdminSettings{
+			Enabled: false,
+			Address: DefaultAdminAddress,
+		},
+		Tracing: TracingSettings{
+			SampleRatio: 1,
+		},
+		CertificateExpiry: CertificateExpirySettings{
+			Warnings:      certification.DefaultExpiryWarnings,
+			CheckInterval: certification.DefaultExpiryCheckInterval,
 		},
 	}
 
+	if err := settings.applyEnvironment(); err != nil {
+		return nil, fmt.Errorf(`error occurred reading settings from the environment: %w`, err)
+	}
+
+	if err := settings.applyOverrides(overrides); err != nil {
+		return nil, fmt.Errorf(`error occurred reading settings from the command line: %w`, err)
+	}
+
+	settings.DefaultNginxIngressNamespaces = settings.Watcher.NginxIngressNamespaces
+
+	kubeApi, err := NewKubeApiSettings(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.KubeApi = kubeApi
+
+	settings.ConfigureLogging()
+
+	settings.logSettings()
+
 	return settings, nil
 }
 
+// logSettings logs the effective settings at startup, one line per settings struct, so each line stays readable.
+func (s *Settings) logSettings() {
+	logrus.WithFields(logrus.Fields{
+		"configMap":            s.ConfigMapNamespace + "/" + s.ConfigMapName,
+		"logFormat":            s.LogFormat,
+		"logLevel":             s.LogLevel,
+		"tlsMode":              s.DefaultTlsMode,
+		"dryRun":               s.DryRun,
+		"hostsRetention":       s.HostsRetention,
+		"hostsSrvInterval":     s.HostsSrvInterval,
+		"hostsSrvRemovalDelay": s.HostsSrvRemovalDelay,
+	}).Info("Settings::NewSettings")
+
+	logrus.WithFields(s.Handler.logFields()).Info("Settings::NewSettings: handler")
+	logrus.WithFields(s.Synchronizer.logFields()).Info("Settings::NewSettings: synchronizer")
+	logrus.WithFields(s.Watcher.logFields()).Info("Settings::NewSettings: watcher")
+	logrus.WithFields(s.HttpClient.logFields()).Info("Settings::NewSettings: httpClient")
+	logrus.WithFields(s.Readiness.logFields()).Info("Settings::NewSettings: readiness")
+	logrus.WithFields(s.LeaderElection.logFields()).Info("Settings::NewSettings: leaderElection")
+	logrus.WithFields(s.KubeApi.logFields()).Info("Settings::NewSettings: kubeApi")
+	logrus.WithFields(s.Admin.logFields()).Info("Settings::NewSettings: admin")
+	logrus.WithFields(s.Tracing.logFields()).Info("Settings::NewSettings: tracing")
+	logrus.WithFields(s.CertificateExpiry.logFields()).Info("Settings::NewSettings: certificateExpiry")
+}
+
 // Initialize initializes the Settings object. Sets up a SharedInformer to watch for changes to the ConfigMap.
 // This method must be called before the Run method.
 func (s *Settings) Initialize() error {
@@ -178,6 +443,8 @@ func (s *Settings) Initialize() error {
 	var err error
 
 	certificates := certification.NewCertificates(s.Context, s.K8sClient)
+	certificates.ExpiryCheckInterval = s.CertificateExpiry.CheckInterval
+	certificates.SetExpiryWarnings(s.CertificateExpiry.Warnings)
 
 	err = certificates.Initialize()
 	if err != nil {
@@ -185,17 +452,27 @@ func (s *Settings) Initialize() error {
 	}
 
 	s.Certificates = certificates
+	certificates.Subscribe(s.notifyTlsSubscribers)
 
 	go certificates.Run()
 
-	logrus.Debug(">>>>>>>>>> Settings::Initialize: retrieving nlk-config ConfigMap")
-	configMap, err := s.K8sClient.CoreV1().ConfigMaps(ConfigMapsNamespace).Get(s.Context, "nlk-config", metav1.GetOptions{})
-	if err != nil {
-		return err
+	s.CertificateFiles.ExpiryCheckInterval = s.CertificateExpiry.CheckInterval
+	s.CertificateFiles.SetExpiryWarnings(s.CertificateExpiry.Warnings)
+	s.CertificateFiles.Subscribe(s.notifyTlsSubscribers)
+
+	go func() {
+		if err := s.CertificateFiles.Run(); err != nil {
+			logrus.Errorf("Settings::Initialize: the certificate files will not be reloaded: %v", err)
+		}
+	}()
+
+	if s.EventRecorder == nil {
+		s.EventRecorder = s.buildEventRecorder()
 	}
 
-	s.handleUpdateEvent(nil, configMap)
-	logrus.Debug(">>>>>>>>>> Settings::Initialize: retrieved nlk-config ConfigMap")
+	if _, err = s.load(); err != nil {
+		return err
+	}
 
 	informer, err := s.buildInformer()
 	if err != nil {
@@ -212,20 +489,199 @@ func (s *Settings) Initialize() error {
 	return nil
 }
 
-// Run starts the SharedInformer and waits for the Context to be cancelled.
+// errRetrievingConfigMap is returned by load when the ConfigMap cannot be retrieved, the configuration file is applied.
+var errRetrievingConfigMap = errors.New(`error occurred retrieving the ConfigMap`)
+
+// load applies the configuration file, then the ConfigMap, and validates the TLS settings, as they are at startup.
+// The ConfigMap is returned once it has been retrieved, even when its TLS settings are invalid.
+func (s *Settings) load() (*corev1.ConfigMap, error) {
+	if s.ConfigFilePath != "" {
+		if err := s.applyConfigFilePath(); err != nil {
+			return nil, fmt.Errorf(`error occurred applying the configuration file: %w`, err)
+		}
+	}
+
+	logrus.Debugf(">>>>>>>>>> Settings::load: retrieving %s/%s ConfigMap", s.ConfigMapNamespace, s.ConfigMapName)
+	configMap, err := s.K8sClient.CoreV1().ConfigMaps(s.ConfigMapNamespace).Get(s.Context, s.ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf(`%w %s/%s: %w`, errRetrievingConfigMap, s.ConfigMapNamespace, s.ConfigMapName, err)
+	}
+
+	s.handleUpdateEvent(nil, configMap)
+	logrus.Debugf(">>>>>>>>>> Settings::load: retrieved %s/%s ConfigMap", s.ConfigMapNamespace, s.ConfigMapName)
+
+	if err = s.validateInitialTlsSettings(configMap); err != nil {
+		return configMap, fmt.Errorf(`error occurred validating the TLS settings: %w`, err)
+	}
+
+	return configMap, nil
+}
+
+// Run starts the SharedInformer and waits for the Context to be cancelled, then shuts the informer down.
 func (s *Settings) Run() {
 	logrus.Debug("Settings::Run")
 
 	defer utilruntime.HandleCrash()
 
-	go s.informer.Run(s.Context.Done())
+	s.informerLock.Lock()
+	s.startInformer()
+	s.informerLock.Unlock()
+
+	go s.runHostsDiscovery()
 
 	<-s.Context.Done()
+
+	s.Shutdown()
 }
 
+// WaitForCacheSync waits for the informer of the ConfigMap to sync, so the hosts and the settings of the ConfigMap are
+// applied, at most the WatcherSettings::CacheSyncTimeout; Run must be called for the informer to start. Returns an error
+// once the timeout has elapsed, or when NLK shuts down first.
+func (s *Settings) WaitForCacheSync() error {
+	ctx, cancel := context.WithTimeout(s.Context, s.Watcher.CacheSyncTimeout)
+	defer cancel()
+
+	hasSynced := func() bool {
+		s.informerLock.Lock()
+		defer s.informerLock.Unlock()
+
+		return s.informer == nil || s.informer.HasSynced()
+	}
+
+	if !cache.WaitForNamedCacheSync("configmap", ctx.Done(), hasSynced) {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf(`the cache of the %s/%s ConfigMap did not sync within %v`, s.ConfigMapNamespace, s.ConfigMapName, s.Watcher.CacheSyncTimeout)
+		}
+
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Shutdown removes the event handlers from the SharedInformer and stops it, so that the changes to the ConfigMap are no
+// longer applied. It is safe to call more than once; Reload restarts the informer.
+func (s *Settings) Shutdown() {
+	logrus.Debug("Settings::Shutdown")
+
+	s.informerLock.Lock()
+	defer s.informerLock.Unlock()
+
+	s.stopInformer()
+}
+
+// Reload stops the informer, then rebuilds it against the namespaced ConfigMap and starts it, e.g. when the ConfigMap
+// namespace has changed at runtime. The ConfigMap is applied once the informer has listed it.
+func (s *Settings) Reload(namespace string, name string) error {
+	logrus.Infof("Settings::Reload: watching the %s/%s ConfigMap", namespace, name)
+
+	s.informerLock.Lock()
+	defer s.informerLock.Unlock()
+
+	s.stopInformer()
+
+	s.ConfigMapNamespace = namespace
+	s.ConfigMapName = name
+
+	informer, err := s.buildInformer()
+	if err != nil {
+		return fmt.Errorf(`error occurred building ConfigMap informer: %w`, err)
+	}
+
+	s.informer = informer
+
+	err = s.initializeEventListeners()
+	if err != nil {
+		return fmt.Errorf(`error occurred initializing event listeners: %w`, err)
+	}
+
+	s.startInformer()
+
+	return nil
+}
+
+// startInformer runs the informer until stopInformer, unless it is already running or the Context is done; Run stops
+// the informer with the Context. The caller must hold the informerLock.
+func (s *Settings) startInformer() {
+	if s.informerStop != nil || s.informer == nil || s.Context.Err() != nil {
+		return
+	}
+
+	s.informerStop = make(chan struct{})
+
+	go s.informer.Run(s.informerStop)
+}
+
+// stopInformer removes the event handler registration and stops the informer, then waits for the event being handled,
+// if any. The caller must hold the informerLock.
+func (s *Settings) stopInformer() {
+	s.handlersGeneration.Add(1)
+
+	s.eventLock.Lock()
+	defer s.eventLock.Unlock()
+
+	if s.informer != nil && s.eventHandlerRegistration != nil {
+		if err := s.informer.RemoveEventHandler(s.eventHandlerRegistration); err != nil {
+			logrus.WithError(err).Warn(`Settings::stopInformer: error occurred removing the event handlers`)
+		}
+
+		s.eventHandlerRegistration = nil
+	}
+
+	if s.informerStop != nil {
+		close(s.informerStop)
+		s.informerStop = nil
+	}
+}
+
+// applyConfigFilePath applies the configuration document named by ConfigFilePath.
+func (s *Settings) applyConfigFilePath() error {
+	config, err := readConfigFile(s.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err = s.applyConfigFile(config); err != nil {
+		return fmt.Errorf(`invalid configuration file %s: %w`, s.ConfigFilePath, err)
+	}
+
+	if s.hostsPinned {
+		logrus.Debugf("Settings::applyConfigFilePath: the NGINX Plus hosts are pinned, ignoring the nginx-hosts lists")
+	} else if len(config.NginxHosts) > 0 || len(config.NginxHostsSecondary) > 0 {
+		hosts, errorCount := s.parseHostList(config.NginxHosts)
+		if errorCount > 0 {
+			logrus.Warnf("Settings::applyConfigFilePath: %d invalid nginx-hosts entries were skipped, using %d host(s): %v", errorCount, len(hosts), entriesOf(hosts))
+		}
+
+		secondaryHosts, errorCount := s.parseHostList(config.NginxHostsSecondary)
+		if errorCount > 0 {
+			logrus.Warnf("Settings::applyConfigFilePath: %d invalid %s entries were skipped, using %d host(s): %v", errorCount, SecondaryHostsKey, len(secondaryHosts), entriesOf(secondaryHosts))
+		}
+
+		s.SetHostGroups(entriesOf(hosts), entriesOf(secondaryHosts))
+	}
+
+	if !s.hostsPinned && config.NginxHostsSrv != "" {
+		// the value was validated by applyConfigFile
+		_ = s.applyHostsSrv(config.NginxHostsSrv)
+	}
+
+	// the namespaces of the configuration file are restored when the ConfigMap no longer sets them
+	s.DefaultNginxIngressNamespaces = s.Watcher.NginxIngressNamespaces
+
+	logrus.Infof("Settings::applyConfigFilePath: applied the configuration file %s", s.ConfigFilePath)
+
+	return nil
+}
+
+// buildInformer creates the informer used to watch for changes to the configured ConfigMap.
+// A field selector restricts the watch to the named ConfigMap so other ConfigMaps in the namespace do not raise events.
 func (s *Settings) buildInformer() (cache.SharedInformer, error) {
-	options := informers.WithNamespace(ConfigMapsNamespace)
-	factory := informers.NewSharedInformerFactoryWithOptions(s.K8sClient, ResyncPeriod, options)
+	namespace := informers.WithNamespace(s.ConfigMapNamespace)
+	name := informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", s.ConfigMapName).String()
+	})
+	factory := informers.NewSharedInformerFactoryWithOptions(s.K8sClient, ResyncPeriod, namespace, name)
 	informer := factory.Core().V1().ConfigMaps().Informer()
 
 	return informer, nil
@@ -236,10 +692,20 @@ func (s *Settings) initializeEventListeners() error {
 
 	var err error
 
+	generation := s.handlersGeneration.Load()
+	guard := func(handle func()) {
+		s.eventLock.Lock()
+		defer s.eventLock.Unlock()
+
+		if s.handlersGeneration.Load() == generation {
+			handle()
+		}
+	}
+
 	handlers := cache.ResourceEventHandlerFuncs{
-		AddFunc:    s.handleAddEvent,
-		UpdateFunc: s.handleUpdateEvent,
-		DeleteFunc: s.handleDeleteEvent,
+		AddFunc:    func(obj interface{}) { guard(func() { s.handleAddEvent(obj) }) },
+		UpdateFunc: func(oldObj, newObj interface{}) { guard(func() { s.handleUpdateEvent(oldObj, newObj) }) },
+		DeleteFunc: func(obj interface{}) { guard(func() { s.handleDeleteEvent(obj) }) },
 	}
 
 	s.eventHandlerRegistration, err = s.informer.AddEventHandler(handlers)
@@ -253,117 +719,195 @@ func (s *Settings) initializeEventListeners() error {
 func (s *Settings) handleAddEvent(obj interface{}) {
 	logrus.Debug("Settings::handleAddEvent")
 
-	if _, yes := isOurConfig(obj); yes {
+	if _, yes := s.isOurConfig(obj); yes {
 		s.handleUpdateEvent(nil, obj)
 	}
 }
 
+// handleDeleteEvent clears the NGINX Plus hosts when the ConfigMap is deleted, once the HostsRetention has elapsed, see retainHosts;
+// including when the deletion was missed by the watch and the informer delivers a tombstone with the last known ConfigMap.
 func (s *Settings) handleDeleteEvent(obj interface{}) {
 	logrus.Debug("Settings::handleDeleteEvent")
 
-	if _, yes := isOurConfig(obj); yes {
-		s.updateHosts([]string{})
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	if configMap, yes := s.isOurConfig(obj); yes && !s.hostsPinned {
+		s.retainHosts(configMap)
 	}
 }
 
 func (s *Settings) handleUpdateEvent(_ interface{}, newValue interface{}) {
 	logrus.Debug("Settings::handleUpdateEvent")
 
-	configMap, yes := isOurConfig(newValue)
+	configMap, yes := s.isOurConfig(newValue)
 	if !yes {
 		return
 	}
 
-	hosts, found := configMap.Data["nginx-hosts"]
-	if found {
-		newHosts := s.parseHosts(hosts)
-		s.updateHosts(newHosts)
+	// the ConfigMap was recreated within the HostsRetention
+	s.cancelHostsClear()
+
+	previousTlsMode := s.TlsMode
+	previousNamespaces := s.Watcher.NginxIngressNamespaces
+	previousResyncPeriod := s.Watcher.ResyncPeriod
+	previousCaCertificateSecretKey := s.Certificates.CaCertificateSecretKey
+	previousClientCertificateSecretKey := s.Certificates.ClientCertificateSecretKey
+
+	var config *ConfigFile
+	if document, found := configMap.Data[ConfigFileKey]; found {
+		parsed, err := parseConfigFile([]byte(document))
+		if err == nil {
+			err = s.applyConfigFile(parsed)
+		}
+
+		if err != nil {
+			logrus.Errorf("Settings::handleUpdateEvent: the %s key is invalid and has NOT been applied: %v", ConfigFileKey, err)
+			s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the %s key is invalid and has not been applied: %v", ConfigFileKey, err))
+		} else {
+			config = parsed
+		}
+	}
+
+	// the DefaultNginxIngressNamespaces are restored when the key is removed, the current ones are kept while the configuration document is invalid
+	if _, configFileFound := configMap.Data[ConfigFileKey]; (config != nil || !configFileFound) && !config.setsNamespaces() {
+		s.Watcher.NginxIngressNamespaces = s.DefaultNginxIngressNamespaces
+	}
+
+	hostKeys := hostKeysOf(configMap)
+	found := len(hostKeys) > 0
+	secondaryHosts, secondaryFound := configMap.Data[SecondaryHostsKey]
+
+	hostsSrv := configMap.Data[HostsSrvKey]
+	if config != nil && config.NginxHostsSrv != "" {
+		hostsSrv = config.NginxHostsSrv
+	}
+
+	if s.hostsPinned {
+		logrus.Debugf("Settings::handleUpdateEvent: the NGINX Plus hosts are pinned, ignoring the nginx-hosts keys")
+	} else if config != nil && (len(config.NginxHosts) > 0 || len(config.NginxHostsSecondary) > 0) {
+		if found || secondaryFound {
+			logrus.Warnf("Settings::handleUpdateEvent: both the nginx-hosts keys and the nginx-hosts lists in %s are set, using the lists", ConfigFileKey)
+		}
+
+		newHosts, errorCount := s.parseHostList(config.NginxHosts)
+		if errorCount > 0 {
+			logrus.Warnf("Settings::handleUpdateEvent: %d invalid nginx-hosts entries were skipped, using %d host(s): %v", errorCount, len(newHosts), entriesOf(newHosts))
+		}
+
+		newSecondaryHosts, errorCount := s.parseHostList(config.NginxHostsSecondary)
+		if errorCount > 0 {
+			logrus.Warnf("Settings::handleUpdateEvent: %d invalid %s entries were skipped, using %d host(s): %v", errorCount, SecondaryHostsKey, len(newSecondaryHosts), entriesOf(newSecondaryHosts))
+		}
+
+		s.setHostKeys(hostKeysByEntry(ConfigFileKey, slices.Concat(entriesOf(newHosts), entriesOf(newSecondaryHosts))))
+		s.SetHostGroups(entriesOf(newHosts), entriesOf(newSecondaryHosts))
+	} else if found || secondaryFound {
+		logrus.Warnf("Settings::handleUpdateEvent: the nginx-hosts key is deprecated, use the nginx-hosts list in the %s key instead", ConfigFileKey)
+		s.applyHostKeys(configMap, hostKeys, secondaryHosts)
+	} else if hostsSrv != "" {
+		// the hosts are only discovered through the nginx-hosts-srv record
+		s.setHostKeys(nil)
+		s.SetHostGroups(nil, nil)
 	} else {
 		logrus.Warnf("Settings::handleUpdateEvent: nginx-hosts key not found in ConfigMap")
 	}
 
-	tlsMode, err := validateTlsMode(configMap)
-	if err != nil {
-		// NOTE: the TLSMode defaults to NoTLS on startup, or the last known good value if previously set.
+	// the current record is kept while the configuration document is invalid
+	if _, configFileFound := configMap.Data[ConfigFileKey]; !s.hostsPinned && (config != nil || !configFileFound) {
+		if err := s.applyHostsSrv(hostsSrv); err != nil {
+			s.rejectHostKey(configMap, HostsSrvKey, err)
+		}
+	}
+
+	if _, found := configMap.Data["tls-mode"]; !found {
+		// the DefaultTlsMode is restored when the key is removed
+		s.setTlsMode(s.DefaultTlsMode)
+		logrus.Debugf("Settings::handleUpdateEvent: tls-mode key not found in ConfigMap, using '%v'", s.TlsMode)
+	} else if tlsMode, err := validateTlsMode(configMap); err != nil {
+		// NOTE: the last known good value is kept.
 		logrus.Errorf("There was an error with the configured TLS Mode. TLS Mode has NOT been changed. The current mode is: '%v'. Error: %v. ", s.TlsMode, err)
 	} else {
-		s.TlsMode = tlsMode
+		s.setTlsMode(tlsMode)
 	}
 
 	caCertificateSecretKey, found := configMap.Data["ca-certificate"]
 	if found {
-		s.Certificates.CaCertificateSecretKey = caCertificateSecretKey
-		logrus.Debugf("Settings::handleUpdateEvent: ca-certificate: %s", s.Certificates.CaCertificateSecretKey)
+		logrus.Debugf("Settings::handleUpdateEvent: ca-certificate: %s", caCertificateSecretKey)
 	} else {
-		s.Certificates.CaCertificateSecretKey = ""
 		logrus.Warnf("Settings::handleUpdateEvent: ca-certificate key not found in ConfigMap")
 	}
 
 	clientCertificateSecretKey, found := configMap.Data["client-certificate"]
 	if found {
-		s.Certificates.ClientCertificateSecretKey = clientCertificateSecretKey
-		logrus.Debugf("Settings::handleUpdateEvent: client-certificate: %s", s.Certificates.ClientCertificateSecretKey)
+		logrus.Debugf("Settings::handleUpdateEvent: client-certificate: %s", clientCertificateSecretKey)
 	} else {
-		s.Certificates.ClientCertificateSecretKey = ""
 		logrus.Warnf("Settings::handleUpdateEvent: client-certificate key not found in ConfigMap")
 	}
 
-	setLogLevel(configMap.Data["log-level"])
+	s.Certificates.SetCertificateSecretKeys(caCertificateSecretKey, clientCertificateSecretKey)
 
-	logrus.Debugf("Settings::handleUpdateEvent: \n\tHosts: %v,\n\tSettings: %v ", s.NginxPlusHosts, configMap)
-}
-
-func validateTlsMode(configMap *corev1.ConfigMap) (TLSMode, error) {
-	tlsConfigMode, tlsConfigModeFound := configMap.Data["tls-mode"]
-	if !tlsConfigModeFound {
-		return NoTLS, fmt.Errorf(`tls-mode key not found in ConfigMap`)
-	}
-
-	if tlsMode, tlsModeFound := TLSModeMap[tlsConfigMode]; tlsModeFound {
-		return tlsMode, nil
+	apiAuthSecretKey, found := configMap.Data["api-auth-secret"]
+	if found {
+		logrus.Debugf("Settings::handleUpdateEvent: api-auth-secret: %s", apiAuthSecretKey)
 	}
 
-	return NoTLS, fmt.Errorf(`invalid tls-mode value: %s`, tlsConfigMode)
-}
+	s.Certificates.SetApiAuthSecretKey(apiAuthSecretKey)
 
-func (s *Settings) parseHosts(hosts string) []string {
-	return strings.Split(hosts, ",")
-}
+	certificatePathsChanged := s.applyCertificatePaths(configMap)
+	tlsOptionsChanged := s.applyTlsOptions(configMap)
+	crlPolicyChanged := s.applyCrlExpiredPolicy(configMap)
 
-func (s *Settings) updateHosts(hosts []string) {
-	s.NginxPlusHosts = hosts
-}
+	s.Certificates.SetRequiredSecrets(s.requiredSecrets()...)
 
-func isOurConfig(obj interface{}) (*corev1.ConfigMap, bool) {
-	configMap, ok := obj.(*corev1.ConfigMap)
-	return configMap, ok && configMap.Name == ConfigMapName && configMap.Namespace == ConfigMapsNamespace
-}
+	if s.TlsMode != previousTlsMode ||
+		certificatePathsChanged ||
+		tlsOptionsChanged ||
+		crlPolicyChanged ||
+		s.Certificates.CaCertificateSecretKey != previousCaCertificateSecretKey ||
+		s.Certificates.ClientCertificateSecretKey != previousClientCertificateSecretKey {
+		logrus.Infof("Settings::handleUpdateEvent: TLS settings changed, tls-mode: '%v'", s.TlsMode)
+		s.notifyTlsSubscribers()
+	}
 
-func setLogLevel(logLevel string) {
-	logrus.Debugf("Settings::setLogLevel: %s", logLevel)
-	switch logLevel {
-	case "panic":
-		logrus.SetLevel(logrus.PanicLevel)
+	if !slices.Equal(s.Watcher.NginxIngressNamespaces, previousNamespaces) {
+		logrus.Infof("Settings::handleUpdateEvent: watched namespaces changed from %v to %v", previousNamespaces, s.Watcher.NginxIngressNamespaces)
+		s.notifyNamespaceSubscribers()
+	}
 
-	case "fatal":
-		logrus.SetLevel(logrus.FatalLevel)
+	if s.Watcher.ResyncPeriod != previousResyncPeriod {
+		logrus.Infof("Settings::handleUpdateEvent: the resync period changed from %v to %v", previousResyncPeriod, s.Watcher.ResyncPeriod)
+		s.notifyResyncPeriodSubscribers()
+	}
 
-	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
+	s.applyLogLevel(configMap.Data[LogLevelKey])
+	s.applyDryRun(configMap)
 
-	case "warn":
-		logrus.SetLevel(logrus.WarnLevel)
+	logrus.Debugf("Settings::handleUpdateEvent: \n\tHosts: %v,\n\tSettings: %v ", s.Hosts(), configMap)
+}
 
-	case "info":
-		logrus.SetLevel(logrus.InfoLevel)
+// SubscribeToResyncPeriodChanges registers a callback that is invoked when the WatcherSettings::ResyncPeriod changes.
+func (s *Settings) SubscribeToResyncPeriodChanges(callback func()) {
+	s.resyncPeriodSubscribersLock.Lock()
+	defer s.resyncPeriodSubscribersLock.Unlock()
 
-	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
+	s.resyncPeriodSubscribers = append(s.resyncPeriodSubscribers, callback)
+}
 
-	case "trace":
-		logrus.SetLevel(logrus.TraceLevel)
+// notifyResyncPeriodSubscribers invokes each of the callbacks registered with SubscribeToResyncPeriodChanges.
+func (s *Settings) notifyResyncPeriodSubscribers() {
+	s.resyncPeriodSubscribersLock.Lock()
+	subscribers := append([]func(){}, s.resyncPeriodSubscribers...)
+	s.resyncPeriodSubscribersLock.Unlock()
 
-	default:
-		logrus.SetLevel(logrus.WarnLevel)
+	for _, callback := range subscribers {
+		callback()
 	}
 }
+
+// isOurConfig determines if the object is the ConfigMap named by ConfigMapNamespace and ConfigMapName.
+func (s *Settings) isOurConfig(obj interface{}) (*corev1.ConfigMap, bool) {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	return configMap, ok && configMap.Name == s.ConfigMapName && configMap.Namespace == s.ConfigMapNamespace
+}
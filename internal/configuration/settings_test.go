@@ -0,0 +1,554 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	testConfigMapNamespace = "acme-nlk"
+	testConfigMapName      = "acme-nlk-config"
+)
+
+func TestSettings_ConfigMapFromEnvironment(t *testing.T) {
+	t.Setenv(ConfigMapNamespaceEnv, testConfigMapNamespace)
+	t.Setenv(ConfigMapNameEnv, testConfigMapName)
+
+	settings := buildSettings(t)
+
+	if settings.ConfigMapNamespace != testConfigMapNamespace {
+		t.Errorf(`expected namespace %s, got %s`, testConfigMapNamespace, settings.ConfigMapNamespace)
+	}
+
+	if settings.ConfigMapName != testConfigMapName {
+		t.Errorf(`expected name %s, got %s`, testConfigMapName, settings.ConfigMapName)
+	}
+}
+
+func TestSettings_HandleUpdateEventIgnoresUnrelatedConfigMaps(t *testing.T) {
+	settings := buildSettings(t)
+
+	settings.handleUpdateEvent(nil, buildConfigMap(DefaultConfigMapNamespace, "some-other-config", "https://unrelated:9000/api"))
+
+	if len(settings.Hosts()) != 0 {
+		t.Errorf(`expected no hosts, got %v`, settings.Hosts())
+	}
+
+	settings.handleUpdateEvent(nil, buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+
+	if len(settings.Hosts()) != 1 {
+		t.Errorf(`expected one host, got %v`, settings.Hosts())
+	}
+}
+
+func TestSettings_HandleDeleteEvent(t *testing.T) {
+	settings := buildSettings(t)
+	settings.HostsRetention = 0
+	settings.handleAddEvent(buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+
+	settings.handleDeleteEvent(buildConfigMap(DefaultConfigMapNamespace, "some-other-config", ""))
+
+	if len(settings.Hosts()) != 1 {
+		t.Fatalf(`deleting an unrelated ConfigMap should not clear the hosts, got %v`, settings.Hosts())
+	}
+
+	settings.handleDeleteEvent(buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, ""))
+
+	if len(settings.Hosts()) != 0 {
+		t.Fatalf(`deleting the configured ConfigMap should clear the hosts, got %v`, settings.Hosts())
+	}
+}
+
+func TestSettings_HandleDeleteEventUnwrapsTombstones(t *testing.T) {
+	settings := buildSettings(t)
+	settings.HostsRetention = 0
+	settings.handleAddEvent(buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "")
+	settings.handleDeleteEvent(cache.DeletedFinalStateUnknown{Key: DefaultConfigMapNamespace + "/" + DefaultConfigMapName, Obj: configMap})
+
+	if len(settings.Hosts()) != 0 {
+		t.Fatalf(`a tombstone of the configured ConfigMap should clear the hosts, got %v`, settings.Hosts())
+	}
+}
+
+func buildSettings(t *testing.T) *Settings {
+	settings, err := NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Certificates = certification.NewCertificates(context.Background(), nil)
+
+	return settings
+}
+
+func buildConfigMap(namespace string, name string, hosts string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			"nginx-hosts": hosts,
+			"tls-mode":    NoTLSString,
+		},
+	}
+}
+
+func TestSettings_ParseHosts(t *testing.T) {
+	tests := []struct {
+		name               string
+		hosts              string
+		expectedHosts      []string
+		expectedErrorCount int
+	}{
+		{"single host", "https://nginx:9000/api", []string{"https://nginx:9000/api"}, 0},
+		{"trailing comma", "https://nginx-1:9000/api,https://nginx-2:9000/api,", []string{"https://nginx-1:9000/api", "https://nginx-2:9000/api"}, 0},
+		{"whitespace", " https://nginx-1:9000/api , https://nginx-2:9000/api ", []string{"https://nginx-1:9000/api", "https://nginx-2:9000/api"}, 0},
+		{"duplicated hosts", "https://nginx:9000/api,https://nginx:9000/api", []string{"https://nginx:9000/api"}, 0},
+		{"no scheme", "nginx:9000/api,http://nginx-2:9000/api", []string{"http://nginx-2:9000/api"}, 1},
+		{"bare address", "10.0.0.1,https://nginx:9000/api", []string{"https://nginx:9000/api"}, 1},
+		{"unsupported scheme", "ftp://nginx:9000/api", nil, 1},
+		{"api versions", "https://nginx-1:9000/api;version=8,https://nginx-2:9000/nginx-api", []string{"https://nginx-1:9000/api;version=8", "https://nginx-2:9000/nginx-api"}, 0},
+		{"invalid api version", "https://nginx:9000/api;version=latest", nil, 1},
+		{"server name", "https://10.0.0.5:443/api;sni=plus-1.example.com", []string{"https://10.0.0.5:443/api;sni=plus-1.example.com"}, 0},
+		{"invalid server name", "http://10.0.0.5/api;sni=plus-1.example.com", nil, 1},
+		{"empty", "", nil, 0},
+		{"site and role", "https://nginx-1:9000/api;site=east,https://nginx-2:9000/api;role=secondary", []string{"https://nginx-1:9000/api;site=east", "https://nginx-2:9000/api;role=secondary"}, 0},
+		{"invalid role", "https://nginx:9000/api;role=standby", nil, 1},
+		{"json list", `["https://nginx-1:9000/api", "https://nginx-2:9000/api;version=8"]`, []string{"https://nginx-1:9000/api", "https://nginx-2:9000/api;version=8"}, 0},
+		{"yaml list", "- https://nginx-1:9000/api\n- https://nginx-2:9000/api\n", []string{"https://nginx-1:9000/api", "https://nginx-2:9000/api"}, 0},
+		{"yaml list of objects", "- url: https://nginx-1:9000/api\n  api-version: 8\n  site: east\n- url: https://nginx-2:9000/api\n  role: secondary\n  insecure: true\n", []string{"https://nginx-1:9000/api;version=8;site=east", "https://nginx-2:9000/api;role=secondary;insecure=true"}, 0},
+	}
+
+	settings := buildSettings(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hosts, errorCount, err := settings.parseHosts(test.hosts)
+			if err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			if errorCount != test.expectedErrorCount {
+				t.Errorf(`expected %d errors, got %d`, test.expectedErrorCount, errorCount)
+			}
+
+			if len(hosts) != len(test.expectedHosts) {
+				t.Fatalf(`expected hosts %v, got %v`, test.expectedHosts, hosts)
+			}
+
+			for i, host := range hosts {
+				if host.Entry != test.expectedHosts[i] {
+					t.Errorf(`expected host %d to be %s, got %s`, i, test.expectedHosts[i], host.Entry)
+				}
+			}
+		})
+	}
+}
+
+func TestSettings_ParseHostsRejectsAmbiguousValues(t *testing.T) {
+	settings := buildSettings(t)
+
+	for _, hosts := range []string{
+		`https://nginx-1:9000/api, ["https://nginx-2:9000/api"]`,
+		`["https://nginx-1:9000/api, https://nginx-2:9000/api"]`,
+		"- https://nginx-1:9000/api, https://nginx-2:9000/api",
+		`["https://nginx-1:9000/api"`,
+		"- url: https://nginx-1:9000/api\n  weight: 2\n",
+		"- site: east\n",
+	} {
+		if _, _, err := settings.parseHosts(hosts); err == nil {
+			t.Errorf(`expected an error for %q`, hosts)
+		}
+	}
+}
+
+func TestSettings_AmbiguousHostsKeyRecordsEvent(t *testing.T) {
+	settings := buildSettings(t)
+	recorder := record.NewFakeRecorder(1)
+	settings.EventRecorder = recorder
+
+	settings.handleUpdateEvent(nil, buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api"))
+	settings.handleUpdateEvent(nil, buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, `https://nginx-1:9000/api, ["https://nginx-2:9000/api"]`))
+
+	if !slices.Equal(settings.Hosts(), []string{"https://nginx:9000/api"}) {
+		t.Fatalf(`expected the hosts to be kept, got %v`, settings.Hosts())
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, InvalidConfigurationReason) || !strings.Contains(event, "nginx-hosts") {
+			t.Fatalf(`expected an %s Event for the nginx-hosts key, got %s`, InvalidConfigurationReason, event)
+		}
+	default:
+		t.Fatalf(`expected an Event to be recorded`)
+	}
+}
+
+func TestSettings_MergesTheHostsOfEveryHostsKey(t *testing.T) {
+	settings := buildSettings(t)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://prod-1:9000/api,https://prod-2:9000/api")
+	configMap.Data["nginx-hosts-canary"] = "https://canary-1:9000/api,https://prod-2:9000/api"
+	configMap.Data["nginx-hosts-blue"] = "https://blue-1:9000/api"
+	configMap.Data[SecondaryHostsKey] = "https://standby:9000/api"
+	configMap.Data[HostsSrvKey] = ""
+	settings.handleUpdateEvent(nil, configMap)
+
+	expected := []string{"https://prod-1:9000/api", "https://prod-2:9000/api", "https://blue-1:9000/api", "https://canary-1:9000/api", "https://standby:9000/api"}
+	if !slices.Equal(settings.Hosts(), expected) {
+		t.Fatalf(`expected the hosts of nginx-hosts, then of the other keys in order, got %v`, settings.Hosts())
+	}
+
+	if !maps.Equal(settings.hostKeys, map[string]string{
+		"https://prod-1:9000/api":   "nginx-hosts",
+		"https://prod-2:9000/api":   "nginx-hosts",
+		"https://blue-1:9000/api":   "nginx-hosts-blue",
+		"https://canary-1:9000/api": "nginx-hosts-canary",
+		"https://standby:9000/api":  SecondaryHostsKey,
+	}) {
+		t.Fatalf(`expected each host to be tagged with the first key listing it, got %v`, settings.hostKeys)
+	}
+
+	if testutil.ToFloat64(instrumentation.HostKey.WithLabelValues("https://canary-1:9000/api", "nginx-hosts-canary")) != 1 {
+		t.Fatalf(`expected the key of the host to be exposed`)
+	}
+
+	// the canary team removes its key
+	delete(configMap.Data, "nginx-hosts-canary")
+	settings.handleUpdateEvent(nil, configMap)
+
+	expected = []string{"https://prod-1:9000/api", "https://prod-2:9000/api", "https://blue-1:9000/api", "https://standby:9000/api"}
+	if !slices.Equal(settings.Hosts(), expected) {
+		t.Fatalf(`expected only the hosts listed in the removed key alone to be removed, got %v`, settings.Hosts())
+	}
+
+	if count := testutil.CollectAndCount(instrumentation.HostKey); count != 4 {
+		t.Fatalf(`expected the removed host to be forgotten by the metric, got %d series`, count)
+	}
+}
+
+func TestSettings_NotifiesTlsSubscribersOnChange(t *testing.T) {
+	settings := buildSettings(t)
+	notifications := 0
+	settings.SubscribeToTlsChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 0 {
+		t.Fatalf(`expected no notifications when the TLS settings are unchanged, got %d`, notifications)
+	}
+
+	configMap.Data["tls-mode"] = CertificateAuthorityTLSString
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 1 {
+		t.Fatalf(`expected one notification after the tls-mode changed, got %d`, notifications)
+	}
+
+	configMap.Data["client-certificate"] = "nlk-tls-client-secret"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 2 {
+		t.Fatalf(`expected two notifications after the client-certificate changed, got %d`, notifications)
+	}
+}
+
+func TestSettings_RequiredSecretsFollowTlsMode(t *testing.T) {
+	settings := buildSettings(t)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["ca-certificate"] = "nlk-tls-ca-secret"
+	configMap.Data["client-certificate"] = "nlk-tls-client-secret"
+
+	testCases := map[string][]string{
+		NoTLSString:                               nil,
+		SelfSignedTLSString:                       {"nlk-tls-ca-secret"},
+		SelfSignedMutualTLSString:                 {"nlk-tls-ca-secret", "nlk-tls-client-secret"},
+		CertificateAuthorityTLSString:             nil,
+		CertificateAuthorityMutualTLSString:       {"nlk-tls-client-secret"},
+		CertificateAuthorityPinnedMutualTLSString: {"nlk-tls-ca-secret", "nlk-tls-client-secret"},
+	}
+
+	for mode, expected := range testCases {
+		configMap.Data["tls-mode"] = mode
+		settings.handleUpdateEvent(nil, configMap)
+
+		actual := settings.requiredSecrets()
+		if len(actual) != len(expected) {
+			t.Fatalf(`%s: expected required secrets %v, got %v`, mode, expected, actual)
+		}
+
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf(`%s: expected required secrets %v, got %v`, mode, expected, actual)
+			}
+		}
+	}
+}
+
+func TestSettings_ApiAuthSecret(t *testing.T) {
+	settings := buildSettings(t)
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["api-auth-secret"] = "nlk-api-auth"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.Certificates.ApiAuthSecretKey != "nlk-api-auth" {
+		t.Fatalf(`expected the nlk-api-auth Secret, got %q`, settings.Certificates.ApiAuthSecretKey)
+	}
+
+	delete(configMap.Data, "api-auth-secret")
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.Certificates.ApiAuthSecretKey != "" {
+		t.Fatalf(`expected the Secret to be cleared, got %q`, settings.Certificates.ApiAuthSecretKey)
+	}
+}
+
+func TestSettings_InitializeRejectsUnknownTlsMode(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = "ca-mlts"
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error for an unknown tls-mode`)
+	}
+}
+
+func TestSettings_InitializePinnedModeRequiresCaSecret(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = CertificateAuthorityPinnedMutualTLSString
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error when the ca-certificate key is missing`)
+	}
+
+	configMap.Data["ca-certificate"] = "nlk-tls-ca-secret"
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error when the CA Secret does not exist`)
+	}
+
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nlk-tls-ca-secret",
+			Namespace: certification.SecretsNamespace,
+		},
+	}
+
+	if err := initializeSettings(t, configMap, caSecret); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
+
+func TestSettings_InitializeRejectsAnExpiredMutualTlsCertificate(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data["tls-mode"] = CertificateAuthorityMutualTLSString
+	configMap.Data["client-certificate"] = "nlk-tls-client-secret"
+
+	if err := initializeSettings(t, configMap, buildCertificateSecret(t, "nlk-tls-client-secret", -time.Hour)); err == nil {
+		t.Fatalf(`expected an error for an expired client certificate`)
+	}
+
+	if err := initializeSettings(t, configMap, buildCertificateSecret(t, "nlk-tls-client-secret", time.Hour)); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the certificate is not used by the one-way TLS modes
+	configMap.Data["tls-mode"] = CertificateAuthorityTLSString
+
+	if err := initializeSettings(t, configMap, buildCertificateSecret(t, "nlk-tls-client-secret", -time.Hour)); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
+
+// buildCertificateSecret builds a Secret holding a self-signed certificate that expires after the given duration, or has
+// expired when it is negative.
+func buildCertificateSecret(t *testing.T, name string, validity time.Duration) *corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nlk-client"},
+		NotBefore:    time.Now().Add(-time.Hour * 24),
+		NotAfter:     time.Now().Add(validity),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: certification.SecretsNamespace,
+		},
+		Data: map[string][]byte{
+			certification.CertificateKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+func initializeSettings(t *testing.T, configMap *corev1.ConfigMap, secrets ...*corev1.Secret) error {
+	_, err := initializeSettingsWith(t, configMap, nil, secrets...)
+	return err
+}
+
+// initializeSettingsWith initializes Settings against a fake clientset; configure, if given, is called before Initialize.
+func initializeSettingsWith(t *testing.T, configMap *corev1.ConfigMap, configure func(*Settings), secrets ...*corev1.Secret) (*Settings, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	k8sClient := fake.NewSimpleClientset(configMap)
+	for _, secret := range secrets {
+		if _, err := k8sClient.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf(`error creating the Secret: %v`, err)
+		}
+	}
+
+	settings, err := NewSettings(ctx, k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if configure != nil {
+		configure(settings)
+	}
+
+	return settings, settings.Initialize()
+}
+
+func TestSettings_ShutdownAndReload(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+
+	settings, err := initializeSettingsWith(t, configMap, nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// changing the name of the CA Secret notifies the TLS subscribers, which shows the event handlers fired
+	changes := make(chan string, 10)
+	settings.SubscribeToTlsChanges(func() { changes <- settings.Certificates.CaCertificateSecretKey })
+
+	go settings.Run()
+
+	updateCaSecret := func(configMap *corev1.ConfigMap, secretName string) {
+		configMap.Data["ca-certificate"] = secretName
+		if _, err := settings.K8sClient.CoreV1().ConfigMaps(configMap.Namespace).Update(context.Background(), configMap, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf(`error updating the ConfigMap: %v`, err)
+		}
+	}
+
+	expectChange := func(secretName string) {
+		select {
+		case change := <-changes:
+			if change != secretName {
+				t.Fatalf(`expected the %s CA Secret, got %s`, secretName, change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf(`expected the event handlers to apply the %s CA Secret`, secretName)
+		}
+	}
+
+	updateCaSecret(configMap, "nlk-ca-1")
+	expectChange("nlk-ca-1")
+
+	settings.Shutdown()
+	settings.Shutdown()
+
+	updateCaSecret(configMap, "nlk-ca-2")
+
+	select {
+	case change := <-changes:
+		t.Fatalf(`expected no event after Shutdown, got %s`, change)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	reloaded := buildConfigMap(testConfigMapNamespace, testConfigMapName, "https://nginx:9000/api")
+	reloaded.Data["ca-certificate"] = "nlk-ca-3"
+	if _, err = settings.K8sClient.CoreV1().ConfigMaps(testConfigMapNamespace).Create(context.Background(), reloaded, metav1.CreateOptions{}); err != nil {
+		t.Fatalf(`error creating the ConfigMap: %v`, err)
+	}
+
+	if err = settings.Reload(testConfigMapNamespace, testConfigMapName); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the informer lists the ConfigMap of the new namespace, then watches it
+	expectChange("nlk-ca-3")
+
+	updateCaSecret(reloaded, "nlk-ca-4")
+	expectChange("nlk-ca-4")
+
+	// the ConfigMap of the previous namespace is no longer watched
+	updateCaSecret(configMap, "nlk-ca-5")
+
+	select {
+	case change := <-changes:
+		t.Fatalf(`expected no event from the previous ConfigMap, got %s`, change)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSettings_NotifiesResyncPeriodSubscribersOnChange(t *testing.T) {
+	settings := buildSettings(t)
+	notifications := 0
+	settings.SubscribeToResyncPeriodChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data[ConfigFileKey] = "watcher:\n  resync-period: 0s\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 0 {
+		t.Fatalf(`expected no notifications when the resync period is unchanged, got %d`, notifications)
+	}
+
+	configMap.Data[ConfigFileKey] = "watcher:\n  resync-period: 90s\n"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if notifications != 1 || settings.Watcher.ResyncPeriod != 90*time.Second {
+		t.Fatalf(`expected one notification and a 90s resync period, got %d and %v`, notifications, settings.Watcher.ResyncPeriod)
+	}
+}
+
+func TestWorkQueueSettings_BackoffDoublesUpToTheMax(t *testing.T) {
+	settings := WorkQueueSettings{RateLimiterBase: time.Second * 2, RateLimiterMax: time.Second * 60}
+
+	for requeues, expected := range map[int]time.Duration{0: time.Second * 2, 1: time.Second * 4, 4: time.Second * 32, 5: time.Second * 60, 100: time.Second * 60} {
+		if backoff := settings.Backoff(requeues); backoff != expected {
+			t.Errorf(`expected a backoff of %v after %d requeues, got %v`, expected, requeues, backoff)
+		}
+	}
+}
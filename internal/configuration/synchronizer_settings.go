@@ -0,0 +1,272 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultStateConfigMapName is the default name of the ConfigMap that holds the persisted desired state.
+	DefaultStateConfigMapName = "nlk-state"
+
+	// DefaultStatusConfigMapName is the default name of the ConfigMap that holds the summary of the health of NLK.
+	DefaultStatusConfigMapName = "nlk-status"
+
+	// EmptyServerPolicyApply applies the updates that leave an upstream without servers, as any other update.
+	EmptyServerPolicyApply = "apply"
+
+	// EmptyServerPolicyRetain keeps the previous servers of an upstream on the NGINX Plus hosts when an update would leave
+	// it without servers, and records a Warning Event on the Service.
+	EmptyServerPolicyRetain = "retain"
+
+	// EmptyServerPolicyFail rejects the updates that would leave an upstream without servers, so the sync of the upstream
+	// is reported as failed; the previous servers are kept on the NGINX Plus hosts.
+	EmptyServerPolicyFail = "fail"
+
+	// ServerAdmissionPolicyAdd adds the servers that still do not answer once the ServerAdmissionMaxWait has elapsed.
+	ServerAdmissionPolicyAdd = "add"
+
+	// ServerAdmissionPolicySkip leaves out the servers that still do not answer once the ServerAdmissionMaxWait has elapsed,
+	// until an event for the upstream finds them answering.
+	ServerAdmissionPolicySkip = "skip"
+
+	// DriftPolicyOverwrite applies the servers last applied to an upstream again as soon as its drift is detected.
+	DriftPolicyOverwrite = "overwrite"
+
+	// DriftPolicyLog only reports the drift of an upstream; the next changes of its servers are applied over the drift.
+	DriftPolicyLog = "log"
+
+	// DriftPolicyNextEvent reports the drift of an upstream, and has the next event for the upstream, e.g. the resync of its
+	// Service, apply its servers even when they did not change, which corrects the drift.
+	DriftPolicyNextEvent = "next-event"
+)
+
+// SynchronizerSettings contains the configuration values needed by the Synchronizer.
+type SynchronizerSettings struct {
+
+	// BorderType selects the kind of Border Server the upstreams are synced to, core.BorderTypeNginxPlus by default,
+	// or a type registered with application.RegisterBorderServers, e.g. core.BorderTypeWebhook.
+	// NOTE: the border type is read at startup.
+	BorderType string
+
+	// MaxMillisecondsJitter is the maximum number of milliseconds that will be applied when adding an event to the queue.
+	MaxMillisecondsJitter int
+
+	// MinMillisecondsJitter is the minimum number of milliseconds that will be applied when adding an event to the queue.
+	MinMillisecondsJitter int
+
+	// RetryCount is the number of times the Synchronizer will attempt to process a message before giving up.
+	RetryCount int
+
+	// Threads is the number of threads that will be used to process messages.
+	Threads int
+
+	// WorkQueueSettings is the configuration for the Synchronizer's queue.
+	WorkQueueSettings WorkQueueSettings
+
+	// CoalesceWindow is how long Created and Updated events wait in the queue, in addition to the jitter, so that the events
+	// for the same upstream that follow within the window are merged into a single update; zero only applies the jitter.
+	CoalesceWindow time.Duration
+
+	// Prune enables the periodic removal of upstream servers that are on the address of a cluster node
+	// but are no longer the target of a watched Service, e.g. after an event was missed while NLK was down.
+	Prune bool
+
+	// OwnershipTag has NLK only delete its own upstream servers, when pruning or updating the upstreams, and leave alone
+	// the servers added by hand or by other tooling. The servers NLK applies to each upstream are recorded, and persisted
+	// with PersistState; the route of the servers is left alone, so sticky routes are kept. Empty, the default, manages
+	// every server of the upstreams.
+	OwnershipTag string
+
+	// ForcePrune lets NLK delete the servers it does not own while an OwnershipTag is set, e.g. to take over the upstreams
+	// managed by another tool.
+	ForcePrune bool
+
+	// ReconcileInterval is the interval between the reconciliations that prune orphaned upstream servers, and detect the
+	// servers changed outside NLK, see DriftPolicy.
+	ReconcileInterval time.Duration
+
+	// HostStagger spreads the full syncs of the NGINX Plus hosts, so they are not all hit at the same time: after NLK starts,
+	// or a host is added, the updates of each host wait a random delay up to HostStagger, and each reconciliation visits
+	// the hosts at random times within HostStagger. The Deleted events are never delayed; zero, the default, disables the
+	// stagger, so existing deployments keep pushing to every host at once.
+	HostStagger time.Duration
+
+	// EmptyServerPolicy determines what happens when an update would leave an upstream without servers, e.g. while no node
+	// hosts a ready endpoint of the Service: EmptyServerPolicyApply, the default, removes every server, EmptyServerPolicyRetain
+	// keeps the previous servers, and EmptyServerPolicyFail keeps them and reports the sync as failed. A Service overrides
+	// it with the EmptyServerPolicyAnnotation. The Deleted events always remove the servers.
+	EmptyServerPolicy string
+
+	// ServerAdmissionMaxWait is how long the servers added to an upstream are held back while their NodePort does not answer,
+	// e.g. a new node before kube-proxy has programmed it: before a server is added, a TCP connection is opened to it in the
+	// background, and the server is added once it answers. Zero adds the servers at once.
+	ServerAdmissionMaxWait time.Duration
+
+	// ServerAdmissionPolicy determines what happens to the servers that still do not answer after the ServerAdmissionMaxWait:
+	// ServerAdmissionPolicyAdd, the default, adds them anyway, and ServerAdmissionPolicySkip leaves them out.
+	ServerAdmissionPolicy string
+
+	// DriftPolicy determines what happens when a reconciliation finds that the servers of an upstream on an NGINX Plus host
+	// differ from the servers last applied, e.g. after a change through the NGINX Plus dashboard: DriftPolicyLog, the default,
+	// only reports the drift, DriftPolicyOverwrite applies the servers again at once, and DriftPolicyNextEvent leaves the
+	// correction to the next event for the upstream. The drift is always reported with a Warning Event and a metric.
+	DriftPolicy string
+
+	// LoadBalancerIngressIps are the IPs written to the status.loadBalancer.ingress of the watched Services of type
+	// LoadBalancer once their upstreams are synced, e.g. the virtual IPs of the NGINX Plus hosts, so the Services are no
+	// longer pending; the IPs are removed when the sync of an upstream of the Service fails. A Service overrides them with
+	// the LoadBalancerIngressIpsAnnotation; the status of a Service is left alone when neither is set, the default.
+	LoadBalancerIngressIps []string
+
+	// DnsServiceName is the name of a headless Service NLK maintains, along with its EndpointSlices, whose addresses are the
+	// IPs of the NGINX Plus hosts, so that external-dns, or any other tool reading the Services, can publish DNS records pointing
+	// at the hosts; it is kept in sync as the hosts change. The Service and its EndpointSlices carry the OwnerAnnotation, and are
+	// deleted once the name is unset or changed. Empty, the default, disables the Service.
+	DnsServiceName string
+
+	// DnsServiceNamespace is the namespace of the DnsServiceName Service; empty is the ConfigMap namespace.
+	DnsServiceNamespace string
+
+	// MissingUpstreamRetryInterval is how long an event waits before it is retried when its upstream is not defined in the
+	// NGINX Plus configuration; these retries do not count against the RetryCount, as the upstream may be added at any time.
+	// After RetryCount of them the event is parked, and queued again once a reconciliation finds the upstream on the host.
+	MissingUpstreamRetryInterval time.Duration
+
+	// UpstreamTimeout is the time allowed for the NGINX Plus API calls updating an upstream on a host, so a hung call does
+	// not block a worker; the update is retried once it times out.
+	UpstreamTimeout time.Duration
+
+	// ErrorLogWindow is how long the repeats of a sync error, of the same class for the same upstream and host, are
+	// suppressed once it has been logged; the repeats are counted and summarized at the end of the window, and a success
+	// resets the suppression. Zero logs every failed sync.
+	ErrorLogWindow time.Duration
+
+	// ConvergenceWarningThreshold is the time from the observation of a Kubernetes change to its acknowledgment by an NGINX Plus
+	// host above which the convergence is logged as a warning, with the details of the event; zero logs none. The time a
+	// change waits for the HostStagger of the host is not counted against it.
+	ConvergenceWarningThreshold time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failures after which the circuit of an NGINX Plus host opens:
+	// the host is skipped, so it does not delay the updates of the other hosts, until it responds to a probe again.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerBackoff is how long the circuit of a host stays open before the host is first probed, the backoff
+	// doubles with each failed probe, up to CircuitBreakerMaxBackoff.
+	CircuitBreakerBackoff time.Duration
+
+	// CircuitBreakerMaxBackoff is the longest the circuit of a host stays open between probes.
+	CircuitBreakerMaxBackoff time.Duration
+
+	// PersistState enables the persistence of the desired state in the StateConfigMapName ConfigMap, in the ConfigMap namespace,
+	// so that the servers whose deletion was missed while NLK was down are deleted when it starts.
+	PersistState bool
+
+	// StateConfigMapName is the name of the ConfigMap holding the persisted desired state.
+	StateConfigMapName string
+
+	// StatePersistDebounce is how long NLK waits after a successful sync before persisting the desired state,
+	// so that a burst of syncs results in a single write.
+	StatePersistDebounce time.Duration
+
+	// StatusAnnotationInterval is the minimum interval between two writes of the sync status annotations of a Service,
+	// e.g. nginxinc.io/last-synced; zero, the default, disables the annotations.
+	StatusAnnotationInterval time.Duration
+
+	// StatusConfigMapName is the name of the ConfigMap, in the ConfigMap namespace, holding the summary of the health of NLK.
+	StatusConfigMapName string
+
+	// StatusConfigMapInterval is the interval between two updates of the StatusConfigMapName ConfigMap, which is only
+	// written when the summary has changed; zero disables the ConfigMap.
+	StatusConfigMapInterval time.Duration
+}
+
+// logFields returns the settings of the Synchronizer, along with those of its queue, as log fields.
+func (s SynchronizerSettings) logFields() logrus.Fields {
+	fields := s.WorkQueueSettings.logFields()
+	maps.Copy(fields, logrus.Fields{
+		"borderType":                   s.BorderType,
+		"minJitterMs":                  s.MinMillisecondsJitter,
+		"maxJitterMs":                  s.MaxMillisecondsJitter,
+		"retries":                      s.RetryCount,
+		"threads":                      s.Threads,
+		"coalesceWindow":               s.CoalesceWindow,
+		"prune":                        s.Prune,
+		"ownershipTag":                 s.OwnershipTag,
+		"forcePrune":                   s.ForcePrune,
+		"reconcileInterval":            s.ReconcileInterval,
+		"hostStagger":                  s.HostStagger,
+		"emptyServerPolicy":            s.EmptyServerPolicy,
+		"serverAdmissionMaxWait":       s.ServerAdmissionMaxWait,
+		"serverAdmissionPolicy":        s.ServerAdmissionPolicy,
+		"driftPolicy":                  s.DriftPolicy,
+		"lbIngressIps":                 s.LoadBalancerIngressIps,
+		"dnsServiceName":               s.DnsServiceName,
+		"dnsServiceNamespace":          s.DnsServiceNamespace,
+		"missingUpstreamRetryInterval": s.MissingUpstreamRetryInterval,
+		"upstreamTimeout":              s.UpstreamTimeout,
+		"errorLogWindow":               s.ErrorLogWindow,
+		"convergenceWarningThreshold":  s.ConvergenceWarningThreshold,
+		"circuitBreakerThreshold":      s.CircuitBreakerThreshold,
+		"circuitBreakerBackoff":        s.CircuitBreakerBackoff,
+		"circuitBreakerMaxBackoff":     s.CircuitBreakerMaxBackoff,
+		"persistState":                 s.PersistState,
+		"stateConfigMap":               s.StateConfigMapName,
+		"statePersistDebounce":         s.StatePersistDebounce,
+		"statusAnnotationInterval":     s.StatusAnnotationInterval,
+		"statusConfigMap":              s.StatusConfigMapName,
+		"statusConfigMapInterval":      s.StatusConfigMapInterval,
+	})
+
+	return fields
+}
+
+// ValidateEmptyServerPolicy returns an error if the policy is not one of the supported empty server policies.
+func ValidateEmptyServerPolicy(policy string) error {
+	switch policy {
+	case EmptyServerPolicyApply, EmptyServerPolicyRetain, EmptyServerPolicyFail:
+		return nil
+	default:
+		return fmt.Errorf(`empty server policy must be %s, %s, or %s, got %q`, EmptyServerPolicyApply, EmptyServerPolicyRetain, EmptyServerPolicyFail, policy)
+	}
+}
+
+// ValidateServerAdmissionPolicy returns an error if the policy is not one of the supported server admission policies.
+func ValidateServerAdmissionPolicy(policy string) error {
+	switch policy {
+	case ServerAdmissionPolicyAdd, ServerAdmissionPolicySkip:
+		return nil
+	default:
+		return fmt.Errorf(`server admission policy must be %s or %s, got %q`, ServerAdmissionPolicyAdd, ServerAdmissionPolicySkip, policy)
+	}
+}
+
+// ValidateDriftPolicy returns an error if the policy is not one of the supported drift policies.
+func ValidateDriftPolicy(policy string) error {
+	switch policy {
+	case DriftPolicyOverwrite, DriftPolicyLog, DriftPolicyNextEvent:
+		return nil
+	default:
+		return fmt.Errorf(`drift policy must be %s, %s, or %s, got %q`, DriftPolicyOverwrite, DriftPolicyLog, DriftPolicyNextEvent, policy)
+	}
+}
+
+// ValidateLoadBalancerIngressIps returns an error if one of the load balancer ingress IPs is not an IP address.
+func ValidateLoadBalancerIngressIps(ips []string) error {
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf(`load balancer ingress IPs must be IP addresses, got %q`, ip)
+		}
+	}
+
+	return nil
+}
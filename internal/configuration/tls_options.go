@@ -0,0 +1,169 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TlsMinVersionKey is the ConfigMap key setting the minimum TLS version of the connections to NGINX Plus, e.g. "1.3".
+	TlsMinVersionKey = "tls-min-version"
+
+	// TlsCipherSuitesKey is the ConfigMap key restricting the cipher suites of the connections to NGINX Plus, as a
+	// comma-separated list of IANA names, e.g. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384".
+	TlsCipherSuitesKey = "tls-cipher-suites"
+)
+
+// tlsVersions are the supported minimum TLS versions, by name; TLS 1.0 and 1.1 are not supported.
+var tlsVersions = map[string]uint16{
+	"1.2":          tls.VersionTLS12,
+	"1.3":          tls.VersionTLS13,
+	"VersionTLS12": tls.VersionTLS12,
+	"VersionTLS13": tls.VersionTLS13,
+}
+
+// TlsVersionNames returns the names of the supported minimum TLS versions as a comma-separated list, suitable for error messages.
+func TlsVersionNames() string {
+	names := make([]string, 0, len(tlsVersions))
+	for name := range tlsVersions {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return strings.Join(names, ", ")
+}
+
+// CipherSuiteNames returns the IANA names of the supported cipher suites, the ones Go considers secure,
+// as a comma-separated list suitable for error messages.
+func CipherSuiteNames() string {
+	var names []string
+	for _, suite := range tls.CipherSuites() {
+		names = append(names, suite.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// parseTlsMinVersion parses the name of a minimum TLS version, e.g. "1.3" or "VersionTLS13".
+func parseTlsMinVersion(value string) (uint16, error) {
+	if version, found := tlsVersions[strings.TrimSpace(value)]; found {
+		return version, nil
+	}
+
+	return 0, fmt.Errorf(`invalid %s value: %q, valid values are: %s`, TlsMinVersionKey, value, TlsVersionNames())
+}
+
+// parseTlsCipherSuites parses a comma-separated list of cipher suite names; the insecure cipher suites are not supported.
+func parseTlsCipherSuites(value string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, entry := range strings.Split(value, ",") {
+		name := strings.TrimSpace(entry)
+		if name == "" {
+			continue
+		}
+
+		id, found := suites[name]
+		if !found {
+			return nil, fmt.Errorf(`invalid %s value: unknown cipher suite %q, valid values are: %s`, TlsCipherSuitesKey, name, CipherSuiteNames())
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf(`invalid %s value: no cipher suite, valid values are: %s`, TlsCipherSuitesKey, CipherSuiteNames())
+	}
+
+	return ids, nil
+}
+
+// validateTlsOptions returns an error if the tls-min-version or tls-cipher-suites key of the ConfigMap is invalid.
+func validateTlsOptions(configMap *corev1.ConfigMap) error {
+	if value, found := configMap.Data[TlsMinVersionKey]; found {
+		if _, err := parseTlsMinVersion(value); err != nil {
+			return err
+		}
+	}
+
+	if value, found := configMap.Data[TlsCipherSuitesKey]; found {
+		if _, err := parseTlsCipherSuites(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyTlsOptions sets the minimum TLS version and the cipher suites from the ConfigMap, and returns true if they have changed.
+// The Go defaults are restored when the keys are removed, and the current values are kept when a key is invalid.
+func (s *Settings) applyTlsOptions(configMap *corev1.ConfigMap) bool {
+	var minVersion uint16
+	var cipherSuites []uint16
+	var err error
+
+	if value, found := configMap.Data[TlsMinVersionKey]; found {
+		if minVersion, err = parseTlsMinVersion(value); err != nil {
+			logrus.Errorf("Settings::applyTlsOptions: the TLS options have NOT been changed: %v", err)
+			s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the TLS options have not been changed: %v", err))
+			return false
+		}
+	}
+
+	if value, found := configMap.Data[TlsCipherSuitesKey]; found {
+		if cipherSuites, err = parseTlsCipherSuites(value); err != nil {
+			logrus.Errorf("Settings::applyTlsOptions: the TLS options have NOT been changed: %v", err)
+			s.recordWarning(configMap, InvalidConfigurationReason, fmt.Sprintf("the TLS options have not been changed: %v", err))
+			return false
+		}
+
+		if minVersion == tls.VersionTLS13 {
+			logrus.Warnf("Settings::applyTlsOptions: the TLS 1.3 cipher suites are not configurable, %s only applies to TLS 1.2", TlsCipherSuitesKey)
+		}
+	}
+
+	if minVersion == s.TlsMinVersion && slices.Equal(cipherSuites, s.TlsCipherSuites) {
+		return false
+	}
+
+	s.TlsMinVersion = minVersion
+	s.TlsCipherSuites = cipherSuites
+
+	logrus.Infof("Settings::applyTlsOptions: tls-min-version: '%s', tls-cipher-suites: %v", tlsVersionName(minVersion), cipherSuiteNames(cipherSuites))
+
+	return true
+}
+
+// tlsVersionName returns the name of a TLS version, "default" for the Go default.
+func tlsVersionName(version uint16) string {
+	if version == 0 {
+		return "default"
+	}
+
+	return tls.VersionName(version)
+}
+
+// cipherSuiteNames returns the names of the cipher suites, nil for the Go defaults.
+func cipherSuiteNames(ids []uint16) []string {
+	var names []string
+	for _, id := range ids {
+		names = append(names, tls.CipherSuiteName(id))
+	}
+
+	return names
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+)
+
+func TestParseTlsMinVersion(t *testing.T) {
+	valid := map[string]uint16{
+		"1.2":          tls.VersionTLS12,
+		"1.3":          tls.VersionTLS13,
+		"VersionTLS13": tls.VersionTLS13,
+	}
+
+	for value, expected := range valid {
+		if version, err := parseTlsMinVersion(value); err != nil || version != expected {
+			t.Errorf(`expected %q to be parsed as %x, got %x, %v`, value, expected, version, err)
+		}
+	}
+
+	for _, value := range []string{"1.1", "TLS13", ""} {
+		if _, err := parseTlsMinVersion(value); err == nil {
+			t.Errorf(`expected an error for %q`, value)
+		}
+	}
+}
+
+func TestParseTlsCipherSuites(t *testing.T) {
+	suites, err := parseTlsCipherSuites("TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(suites) != 2 || suites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 || suites[1] != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+		t.Fatalf(`expected the two cipher suites in order, got %v`, suites)
+	}
+
+	// the insecure cipher suites are not supported
+	_, err = parseTlsCipherSuites("TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_RC4_128_SHA")
+	if err == nil {
+		t.Fatalf(`expected an error for an insecure cipher suite`)
+	}
+
+	if !strings.Contains(err.Error(), "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384") {
+		t.Fatalf(`expected the error to list the supported cipher suites, got %v`, err)
+	}
+
+	if _, err = parseTlsCipherSuites(" , "); err == nil {
+		t.Fatalf(`expected an error for an empty list`)
+	}
+}
+
+func TestSettings_TlsOptionsFollowTheConfigMap(t *testing.T) {
+	settings := buildSettings(t)
+
+	notifications := 0
+	settings.SubscribeToTlsChanges(func() { notifications++ })
+
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data[TlsMinVersionKey] = "1.3"
+	configMap.Data[TlsCipherSuitesKey] = "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.TlsMinVersion != tls.VersionTLS13 || len(settings.TlsCipherSuites) != 1 || notifications != 1 {
+		t.Fatalf(`expected TLS 1.3 and one cipher suite, got %x %v, %d notification(s)`, settings.TlsMinVersion, settings.TlsCipherSuites, notifications)
+	}
+
+	// an invalid key keeps the current options
+	configMap.Data[TlsCipherSuitesKey] = "TLS_ECDHE_ECDSA_WITH_AES_256"
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.TlsMinVersion != tls.VersionTLS13 || len(settings.TlsCipherSuites) != 1 || notifications != 1 {
+		t.Fatalf(`expected the current options to be kept, got %x %v`, settings.TlsMinVersion, settings.TlsCipherSuites)
+	}
+
+	// the Go defaults are restored when the keys are removed
+	delete(configMap.Data, TlsMinVersionKey)
+	delete(configMap.Data, TlsCipherSuitesKey)
+	settings.handleUpdateEvent(nil, configMap)
+
+	if settings.TlsMinVersion != 0 || settings.TlsCipherSuites != nil || notifications != 2 {
+		t.Fatalf(`expected the Go defaults, got %x %v`, settings.TlsMinVersion, settings.TlsCipherSuites)
+	}
+}
+
+func TestSettings_InitializeRejectsUnknownTlsOptions(t *testing.T) {
+	configMap := buildConfigMap(DefaultConfigMapNamespace, DefaultConfigMapName, "https://nginx:9000/api")
+	configMap.Data[TlsCipherSuitesKey] = "TLS_CHACHA20_POLY1305"
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error for an unknown cipher suite`)
+	}
+
+	delete(configMap.Data, TlsCipherSuitesKey)
+	configMap.Data[TlsMinVersionKey] = "1.0"
+
+	if err := initializeSettings(t, configMap); err == nil {
+		t.Fatalf(`expected an error for an unsupported TLS version`)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CurrentTlsMode returns the TlsMode, which the informer changes while the TLS config is rebuilt, e.g. when a Secret changes.
+func (s *Settings) CurrentTlsMode() TLSMode {
+	s.tlsModeLock.RLock()
+	defer s.tlsModeLock.RUnlock()
+
+	return s.TlsMode
+}
+
+// setTlsMode sets the TlsMode, see CurrentTlsMode.
+func (s *Settings) setTlsMode(tlsMode TLSMode) {
+	s.tlsModeLock.Lock()
+	defer s.tlsModeLock.Unlock()
+
+	s.TlsMode = tlsMode
+}
+
+// requiredSecrets returns the names of the Secrets the current TLS mode needs to build a tls.Config,
+// none when the certificates are read from the mounted files.
+func (s *Settings) requiredSecrets() []string {
+	if s.usesCertificateFiles() {
+		return nil
+	}
+
+	switch s.TlsMode {
+	case SelfSignedTLS:
+		return []string{s.Certificates.CaCertificateSecretKey}
+
+	case CertificateAuthorityMutualTLS:
+		return []string{s.Certificates.ClientCertificateSecretKey}
+
+	case SelfSignedMutualTLS, CertificateAuthorityPinnedMutualTLS:
+		return []string{s.Certificates.CaCertificateSecretKey, s.Certificates.ClientCertificateSecretKey}
+
+	default:
+		return nil
+	}
+}
+
+// SubscribeToTlsChanges registers a callback that is invoked when the TLS mode, the configured certificate Secrets,
+// or the contents of those Secrets change.
+func (s *Settings) SubscribeToTlsChanges(callback func()) {
+	s.tlsSubscribersLock.Lock()
+	defer s.tlsSubscribersLock.Unlock()
+
+	s.tlsSubscribers = append(s.tlsSubscribers, callback)
+}
+
+// notifyTlsSubscribers invokes each of the callbacks registered with SubscribeToTlsChanges.
+func (s *Settings) notifyTlsSubscribers() {
+	s.tlsSubscribersLock.Lock()
+	subscribers := append([]func(){}, s.tlsSubscribers...)
+	s.tlsSubscribersLock.Unlock()
+
+	for _, callback := range subscribers {
+		callback()
+	}
+}
+
+func validateTlsMode(configMap *corev1.ConfigMap) (TLSMode, error) {
+	tlsConfigMode, tlsConfigModeFound := configMap.Data["tls-mode"]
+	if !tlsConfigModeFound {
+		return NoTLS, fmt.Errorf(`tls-mode key not found in ConfigMap`)
+	}
+
+	return parseTlsMode(tlsConfigMode)
+}
+
+// parseTlsMode parses the name of a TLS mode, e.g. "ca-tls".
+func parseTlsMode(tlsMode string) (TLSMode, error) {
+	if mode, found := TLSModeMap[tlsMode]; found {
+		return mode, nil
+	}
+
+	return NoTLS, fmt.Errorf(`invalid tls-mode value: %s, valid values are: %s`, tlsMode, TLSModeNames())
+}
+
+// validateInitialTlsSettings is used at startup to fail fast on a tls-mode, tls-min-version, tls-cipher-suites, or
+// ca-crl-expired-policy typo, rather than continuing with the defaults, to ensure the Secrets required by the configured mode
+// exist, that the CRL of the self-signed modes is valid, and that the certificates of the mutual TLS modes have not expired.
+func (s *Settings) validateInitialTlsSettings(configMap *corev1.ConfigMap) error {
+	if _, found := configMap.Data["tls-mode"]; found {
+		if _, err := validateTlsMode(configMap); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTlsOptions(configMap); err != nil {
+		return err
+	}
+
+	if value, found := configMap.Data[CrlExpiredPolicyKey]; found {
+		if _, err := parseCrlExpiredPolicy(value); err != nil {
+			return err
+		}
+	}
+
+	if s.TlsMode == CertificateAuthorityPinnedMutualTLS && s.usesCertificateFiles() {
+		if s.CertificateFiles.Paths().CaCertificate == "" {
+			return fmt.Errorf(`tls-mode '%s' requires the %s key to name the CA certificate file`, s.TlsMode, CaCertificatePathKey)
+		}
+	} else if s.TlsMode == CertificateAuthorityPinnedMutualTLS {
+		caSecretName := s.Certificates.CaCertificateSecretKey
+		if caSecretName == "" {
+			return fmt.Errorf(`tls-mode '%s' requires the ca-certificate key to name the CA Secret`, s.TlsMode)
+		}
+
+		_, err := s.K8sClient.CoreV1().Secrets(certification.SecretsNamespace).Get(s.Context, caSecretName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf(`tls-mode '%s' requires the CA Secret '%s/%s': %w`, s.TlsMode, certification.SecretsNamespace, caSecretName, err)
+		}
+	}
+
+	switch s.TlsMode {
+	case SelfSignedTLS, SelfSignedMutualTLS:
+		if err := s.validateCaCrl(); err != nil {
+			return err
+		}
+	}
+
+	switch s.TlsMode {
+	case SelfSignedMutualTLS, CertificateAuthorityMutualTLS, CertificateAuthorityPinnedMutualTLS:
+		return s.validateCertificatesNotExpired()
+	}
+
+	return nil
+}
+
+// validateCertificatesNotExpired returns an error if a certificate in one of the Secrets or files required by the TLS mode
+// has expired, as every connection to NGINX Plus would fail. A certificate that is missing or cannot be parsed is left to
+// the TLS config factory.
+func (s *Settings) validateCertificatesNotExpired() error {
+	bundles := make(map[string][]byte)
+
+	if s.usesCertificateFiles() {
+		paths := s.CertificateFiles.Paths()
+		if s.TlsMode != CertificateAuthorityMutualTLS {
+			bundles[fmt.Sprintf("the file '%s'", paths.CaCertificate)] = s.CertificateFiles.GetCACertificate()
+		}
+
+		_, bundles[fmt.Sprintf("the file '%s'", paths.ClientCertificate)] = s.CertificateFiles.GetClientCertificate()
+	} else {
+		for _, secretName := range s.requiredSecrets() {
+			secret, err := s.K8sClient.CoreV1().Secrets(certification.SecretsNamespace).Get(s.Context, secretName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+
+			bundles[fmt.Sprintf("the Secret '%s/%s'", certification.SecretsNamespace, secretName)] = secret.Data[certification.CertificateKey]
+		}
+	}
+
+	for origin, bundle := range bundles {
+		if len(bundle) == 0 {
+			continue
+		}
+
+		certificate, err := certification.EarliestExpiring(bundle)
+		if err != nil {
+			continue
+		}
+
+		if time.Now().After(certificate.NotAfter) {
+			return fmt.Errorf(`tls-mode '%s': the certificate '%s' in %s expired at %s`, s.TlsMode, certificate.Subject, origin, certificate.NotAfter)
+		}
+	}
+
+	return nil
+}
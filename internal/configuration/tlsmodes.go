@@ -5,42 +5,58 @@
 
 package configuration
 
+import "strings"
+
 const (
 	NoTLS TLSMode = iota
 	CertificateAuthorityTLS
 	CertificateAuthorityMutualTLS
 	SelfSignedTLS
 	SelfSignedMutualTLS
+	CertificateAuthorityPinnedMutualTLS
 )
 
 const (
-	NoTLSString                         = "no-tls"
-	CertificateAuthorityTLSString       = "ca-tls"
-	CertificateAuthorityMutualTLSString = "ca-mtls"
-	SelfSignedTLSString                 = "ss-tls"
-	SelfSignedMutualTLSString           = "ss-mtls"
+	NoTLSString                               = "no-tls"
+	CertificateAuthorityTLSString             = "ca-tls"
+	CertificateAuthorityMutualTLSString       = "ca-mtls"
+	SelfSignedTLSString                       = "ss-tls"
+	SelfSignedMutualTLSString                 = "ss-mtls"
+	CertificateAuthorityPinnedMutualTLSString = "ca-mtls-pinned"
 )
 
 type TLSMode int
 
 var TLSModeMap = map[string]TLSMode{
-	NoTLSString:                         NoTLS,
-	CertificateAuthorityTLSString:       CertificateAuthorityTLS,
-	CertificateAuthorityMutualTLSString: CertificateAuthorityMutualTLS,
-	SelfSignedTLSString:                 SelfSignedTLS,
-	SelfSignedMutualTLSString:           SelfSignedMutualTLS,
+	NoTLSString:                               NoTLS,
+	CertificateAuthorityTLSString:             CertificateAuthorityTLS,
+	CertificateAuthorityMutualTLSString:       CertificateAuthorityMutualTLS,
+	SelfSignedTLSString:                       SelfSignedTLS,
+	SelfSignedMutualTLSString:                 SelfSignedMutualTLS,
+	CertificateAuthorityPinnedMutualTLSString: CertificateAuthorityPinnedMutualTLS,
+}
+
+// TLSModeNames returns the names of the supported TLS modes as a comma-separated list, suitable for error messages.
+func TLSModeNames() string {
+	return strings.Join(tlsModeStrings(), ", ")
 }
 
 func (t TLSMode) String() string {
-	modes := []string{
+	modes := tlsModeStrings()
+	if t < NoTLS || t > CertificateAuthorityPinnedMutualTLS {
+		return ""
+	}
+	return modes[t]
+}
+
+// tlsModeStrings returns the names of the supported TLS modes, indexed by TLSMode.
+func tlsModeStrings() []string {
+	return []string{
 		NoTLSString,
 		CertificateAuthorityTLSString,
 		CertificateAuthorityMutualTLSString,
 		SelfSignedTLSString,
 		SelfSignedMutualTLSString,
+		CertificateAuthorityPinnedMutualTLSString,
 	}
-	if t < NoTLS || t > SelfSignedMutualTLS {
-		return ""
-	}
-	return modes[t]
 }
@@ -35,9 +35,14 @@ func Test_String(t *testing.T) {
 		t.Errorf("Expected TLSModeSsMTLS to be 'ss-mtls', got '%s',", mode)
 	}
 
-	mode = TLSMode(5).String()
+	mode = CertificateAuthorityPinnedMutualTLS.String()
+	if mode != "ca-mtls-pinned" {
+		t.Errorf("Expected TLSModeCaPinnedMTLS to be 'ca-mtls-pinned', got '%s',", mode)
+	}
+
+	mode = TLSMode(6).String()
 	if mode != "" {
-		t.Errorf("Expected TLSMode(5) to be '', got '%s'", mode)
+		t.Errorf("Expected TLSMode(6) to be '', got '%s'", mode)
 	}
 }
 
@@ -67,6 +72,11 @@ func Test_TLSModeMap(t *testing.T) {
 		t.Errorf("Expected TLSModeMap['ss-mtls'] to be TLSModeSsMTLS, got '%d'", mode)
 	}
 
+	mode = TLSModeMap["ca-mtls-pinned"]
+	if mode != CertificateAuthorityPinnedMutualTLS {
+		t.Errorf("Expected TLSModeMap['ca-mtls-pinned'] to be TLSModeCaPinnedMTLS, got '%d'", mode)
+	}
+
 	mode = TLSModeMap["invalid"]
 	if mode != TLSMode(0) {
 		t.Errorf("Expected TLSModeMap['invalid'] to be TLSMode(0), got '%d'", mode)
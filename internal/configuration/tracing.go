@@ -0,0 +1,55 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TracingSettings contains the configuration values needed to export the traces of the event pipeline.
+type TracingSettings struct {
+
+	// Endpoint is the URL of the OTLP/HTTP collector the spans are exported to, e.g. http://otel-collector:4318;
+	// tracing is disabled while it is empty, the default.
+	Endpoint string
+
+	// SampleRatio is the ratio of the traces recorded, between 0 and 1, all of them by default.
+	SampleRatio float64
+}
+
+// logFields returns the settings of the tracing as log fields.
+func (s TracingSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"endpoint":    s.Endpoint,
+		"sampleRatio": s.SampleRatio,
+	}
+}
+
+// validateTracingEndpoint returns an error unless the endpoint is empty, which disables tracing, or an http or https URL
+// of the OTLP/HTTP collector, e.g. "http://otel-collector:4318".
+func validateTracingEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf(`tracing endpoint is not a valid URL: %w`, err)
+	}
+
+	if endpointUrl.Scheme != "http" && endpointUrl.Scheme != "https" {
+		return fmt.Errorf(`tracing endpoint scheme must be http or https, got %q`, endpointUrl.Scheme)
+	}
+
+	if endpointUrl.Host == "" {
+		return fmt.Errorf(`tracing endpoint is missing the host`)
+	}
+
+	return nil
+}
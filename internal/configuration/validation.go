@@ -0,0 +1,145 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/certification"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateConfigMap returns the errors of the keys of the ConfigMap that would not be applied by handleUpdateEvent: the hosts
+// must parse as http(s) URLs, the tls-mode and the other enumerations must be known values, the durations of the config.yaml
+// key must parse, and its values must be valid. Each error names the key, e.g. data[tls-mode], and the reason.
+// The config.yaml values are validated against the current settings, as they are applied over them.
+func (s *Settings) ValidateConfigMap(configMap *corev1.ConfigMap) field.ErrorList {
+	data := field.NewPath("data")
+
+	var errs field.ErrorList
+
+	if document, found := configMap.Data[ConfigFileKey]; found {
+		errs = append(errs, s.validateConfigFileKey(data.Key(ConfigFileKey), document)...)
+	}
+
+	for _, key := range append(hostKeysOf(configMap), SecondaryHostsKey) {
+		if value, found := configMap.Data[key]; found {
+			errs = append(errs, validateHostsKey(data.Key(key), value)...)
+		}
+	}
+
+	if value, found := configMap.Data[HostsSrvKey]; found {
+		if _, err := parseHostsSrv(value); err != nil {
+			errs = append(errs, field.Invalid(data.Key(HostsSrvKey), value, err.Error()))
+		}
+	}
+
+	if value, found := configMap.Data["tls-mode"]; found {
+		if _, err := parseTlsMode(value); err != nil {
+			errs = append(errs, field.NotSupported(data.Key("tls-mode"), value, tlsModeStrings()))
+		}
+	}
+
+	if value, found := configMap.Data[TlsMinVersionKey]; found {
+		if _, err := parseTlsMinVersion(value); err != nil {
+			errs = append(errs, field.Invalid(data.Key(TlsMinVersionKey), value, err.Error()))
+		}
+	}
+
+	if value, found := configMap.Data[TlsCipherSuitesKey]; found {
+		if _, err := parseTlsCipherSuites(value); err != nil {
+			errs = append(errs, field.Invalid(data.Key(TlsCipherSuitesKey), value, err.Error()))
+		}
+	}
+
+	paths := certification.CertificatePaths{
+		CaCertificate:     configMap.Data[CaCertificatePathKey],
+		ClientCertificate: configMap.Data[ClientCertificatePathKey],
+		ClientKey:         configMap.Data[ClientKeyPathKey],
+		CaCrl:             configMap.Data[CaCrlPathKey],
+	}
+
+	if err := validateCertificatePaths(paths); err != nil {
+		errs = append(errs, field.Invalid(data, field.OmitValueType{}, err.Error()))
+	}
+
+	if value, found := configMap.Data[CrlExpiredPolicyKey]; found {
+		if _, err := parseCrlExpiredPolicy(value); err != nil {
+			errs = append(errs, field.NotSupported(data.Key(CrlExpiredPolicyKey), value, []string{CrlFailClosed, CrlFailOpen}))
+		}
+	}
+
+	if value := configMap.Data[LogLevelKey]; value != "" {
+		if _, err := parseLogLevel(value); err != nil {
+			errs = append(errs, field.Invalid(data.Key(LogLevelKey), value, err.Error()))
+		}
+	}
+
+	if value, found := configMap.Data[DryRunKey]; found {
+		if _, err := strconv.ParseBool(value); err != nil {
+			errs = append(errs, field.Invalid(data.Key(DryRunKey), value, "must be true or false"))
+		}
+	}
+
+	return errs
+}
+
+// validateConfigFileKey returns the errors of the configuration document of the config.yaml key; its values are applied
+// to a copy of the current settings, so the document is rejected when applyConfigFile would reject it.
+func (s *Settings) validateConfigFileKey(path *field.Path, document string) field.ErrorList {
+	config, err := parseConfigFile([]byte(document))
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, field.OmitValueType{}, err.Error())}
+	}
+
+	scratch := &Settings{
+		HostsRetention: s.HostsRetention,
+		Handler:        s.Handler,
+		Synchronizer:   s.Synchronizer,
+		Watcher:        s.Watcher,
+	}
+
+	var errs field.ErrorList
+	if err = scratch.applyConfigFile(config); err != nil {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, err.Error()))
+	}
+
+	errs = append(errs, validateHostEntries(path.Child("nginx-hosts"), config.NginxHosts)...)
+	errs = append(errs, validateHostEntries(path.Child(SecondaryHostsKey), config.NginxHostsSecondary)...)
+
+	return errs
+}
+
+// validateHostsKey returns the errors of the value of an nginx-hosts key, see splitHosts and ParseNginxPlusHost.
+func validateHostsKey(path *field.Path, value string) field.ErrorList {
+	entries, err := splitHosts(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, value, err.Error())}
+	}
+
+	return validateHostEntries(path, entries)
+}
+
+// validateHostEntries returns an error for each entry of a list of hosts that is not an http(s) URL, see ParseNginxPlusHost;
+// the empty entries are ignored, as they are when the hosts are applied.
+func validateHostEntries(path *field.Path, entries []string) field.ErrorList {
+	var errs field.ErrorList
+
+	for position, entry := range entries {
+		host := strings.TrimSpace(entry)
+		if host == "" {
+			continue
+		}
+
+		if _, err := ParseNginxPlusHost(host); err != nil {
+			errs = append(errs, field.Invalid(path.Index(position), host, err.Error()))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSettings_ValidateConfigMapNamesEachInvalidKey(t *testing.T) {
+	settings, _ := NewSettings(context.Background(), nil)
+
+	configMap := &corev1.ConfigMap{Data: map[string]string{
+		"nginx-hosts":        "https://10.0.0.1:9000/api",
+		"nginx-hosts-canary": "10.0.0.4:9000/api",
+		SecondaryHostsKey:    "[https://10.0.0.2:9000/api, ftp://10.0.0.3]",
+		"tls-mode":           "mtls",
+		TlsMinVersionKey:     "1.1",
+		ClientKeyPathKey:     "/etc/nkl/tls.key",
+		CrlExpiredPolicyKey:  "fail-open",
+		LogLevelKey:          "verbose",
+		DryRunKey:            "yes",
+		ConfigFileKey:        "watcher:\n  drain-timeout: -1m\nnginx-hosts:\n  - localhost:9000\n",
+		CaCertificatePathKey: "/etc/nkl/ca.crt",
+		TlsCipherSuitesKey:   "TLS_AES_128_GCM_SHA256",
+	}}
+
+	expected := []string{
+		"data[config.yaml]",
+		"data[config.yaml].nginx-hosts[0]",
+		"data[nginx-hosts-canary][0]",
+		"data[nginx-hosts-secondary][1]",
+		"data[tls-mode]",
+		"data[tls-min-version]",
+		"data",
+		"data[log-level]",
+		"data[dry-run]",
+	}
+
+	errs := settings.ValidateConfigMap(configMap)
+	if len(errs) != len(expected) {
+		t.Fatalf(`expected %d errors, got %v`, len(expected), errs)
+	}
+
+	for i, err := range errs {
+		if err.Field != expected[i] {
+			t.Fatalf(`expected the error %d to name %s, got %v`, i, expected[i], err)
+		}
+	}
+
+	if errs = settings.ValidateConfigMap(&corev1.ConfigMap{Data: map[string]string{"nginx-hosts": "https://10.0.0.1:9000/api"}}); len(errs) != 0 {
+		t.Fatalf(`expected a valid ConfigMap to have no error, got %v`, errs)
+	}
+}
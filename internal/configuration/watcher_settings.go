@@ -0,0 +1,235 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// AddressFamilyIPv4 uses the IPv4 InternalIP of each node.
+	AddressFamilyIPv4 = "ipv4"
+
+	// AddressFamilyIPv6 uses the IPv6 InternalIP of each node.
+	AddressFamilyIPv6 = "ipv6"
+
+	// AddressFamilyDual uses both the IPv4 and IPv6 InternalIPs of each node, so a dual-stack node contributes two upstream servers.
+	AddressFamilyDual = "dual"
+
+	// ControlPlaneNodeLabel is the label of the control-plane nodes, see WatcherSettings::ExcludeControlPlaneNodes.
+	ControlPlaneNodeLabel = "node-role.kubernetes.io/control-plane"
+
+	// NodeStateKey is the label, or annotation, of the nodes whose upstream servers are marked down when set to NodeStateDown,
+	// e.g. while a new node is validated; removing it, or setting another value, brings the servers up in place.
+	NodeStateKey = "nkl.nginx.com/state"
+
+	// NodeStateDown is the value of the NodeStateKey that marks the upstream servers of the node down.
+	NodeStateDown = "down"
+
+	// RbacModeAuto probes the permissions of NLK at startup, with SelfSubjectAccessReviews, and uses RbacModeCluster
+	// when it may list and watch the Services and EndpointSlices of every namespace, RbacModeScoped otherwise.
+	RbacModeAuto = "auto"
+
+	// RbacModeCluster watches the Services matching the WatcherSettings::ServiceSelector in every namespace, and the
+	// Nodes, which requires a ClusterRole.
+	RbacModeCluster = "cluster"
+
+	// RbacModeScoped only watches the namespaced resources in the namespaces NLK is granted a Role in: the Services of the
+	// WatcherSettings::NginxIngressNamespaces, even when they are selected by label. The Nodes are still watched when
+	// NLK may list them; otherwise the upstream servers are the addresses of the ready endpoints of each Service.
+	RbacModeScoped = "scoped"
+
+	// TargetModeNodes sends every schedulable worker node to NGINX Plus as an upstream server.
+	TargetModeNodes = "nodes"
+
+	// TargetModeEndpointSlices sends only the nodes hosting ready endpoints of the Service, found with the EndpointSlices,
+	// to NGINX Plus as upstream servers. Use this mode with `externalTrafficPolicy: Local`.
+	TargetModeEndpointSlices = "endpointslices"
+)
+
+// WatcherSettings contains the configuration values needed by the Watcher.
+type WatcherSettings struct {
+
+	// NginxIngressNamespaces are the namespaces whose Services are watched, e.g. one per NGINX Ingress Controller installation.
+	// Each namespace has its own informers; a namespace removed at runtime has the servers of its Services deleted.
+	NginxIngressNamespaces []string
+
+	// ServiceSelector selects the Services to watch by label in every namespace, e.g. "nkl.nginx.com/managed=true",
+	// instead of every Service of the NginxIngressNamespaces; the default, empty selector watches the NginxIngressNamespaces.
+	// NOTE: a selector requires permission to list and watch Services and EndpointSlices cluster-wide, and is read at startup.
+	ServiceSelector labels.Selector
+
+	// UpstreamNameTemplate names the upstreams, e.g. "{namespace}-{name}" to tell apart the Services of different namespaces;
+	// {name} is replaced with the name derived from the port name or the upstream map, and {namespace} with the namespace of the Service.
+	UpstreamNameTemplate string
+
+	// ResyncPeriod is how often the Service and Node informers redeliver every object as an update, zero disables the resync.
+	// The servers of the resynced Services are pushed even when they are the servers last applied, see core.Event::Resync.
+	// The informers are rebuilt when it changes, see SubscribeToResyncPeriodChanges.
+	ResyncPeriod time.Duration
+
+	// CacheSyncTimeout is how long NLK waits at startup for the informers of the ConfigMap, the Services, and the Nodes to
+	// sync before the Handler and the Synchronizer start; NLK fails to start once it has elapsed, rather than act on partial
+	// caches, e.g. delete the servers of the Services not listed yet. It is read at startup.
+	CacheSyncTimeout time.Duration
+
+	// DrainTimeout is how long the upstream servers of an unschedulable node are drained, for Services annotated
+	// with DrainOnCordonAnnotation, before they are removed.
+	DrainTimeout time.Duration
+
+	// DeletedNodeDrainTimeout is how long the upstream servers of a deleted node are drained, for Services annotated with
+	// DrainOnCordonAnnotation, before they are removed; they are removed earlier once the NGINX Plus hosts report that they
+	// have no active connection left. Zero removes them as soon as the node is deleted.
+	DeletedNodeDrainTimeout time.Duration
+
+	// DrainedConnectionsThreshold is the number of active connections at or below which the draining servers of a deleted
+	// node are considered drained, e.g. to remove the servers of a stream upstream of long-lived connections once most of
+	// them have moved, rather than wait for the last few until the DeletedNodeDrainTimeout. Zero waits for every connection.
+	DrainedConnectionsThreshold int
+
+	// NotReadyGracePeriod is how long a node must be NotReady before its upstream servers are removed, or drained,
+	// so brief readiness blips do not cause upstream churn.
+	NotReadyGracePeriod time.Duration
+
+	// TargetMode determines how the upstream servers are found, one of TargetModeNodes or TargetModeEndpointSlices.
+	// NOTE: the target mode is read at startup; changing it at runtime has no effect until restart.
+	TargetMode string
+
+	// RbacMode determines which resources are watched cluster-wide, one of RbacModeAuto, RbacModeCluster, or RbacModeScoped.
+	// NOTE: the RBAC mode is read at startup; changing it at runtime has no effect until restart.
+	RbacMode string
+
+	// NodeSelector limits the nodes used as upstream servers to those with matching labels; the default selects every node.
+	// NOTE: the node selector is read at startup; changing it at runtime has no effect until restart.
+	NodeSelector labels.Selector
+
+	// BackupNodeSelector selects the nodes whose upstream servers are marked backup, e.g. "nkl.nginx.com/backup=true", so they
+	// only receive traffic when the other servers are unavailable; the default, nil selector marks no server backup.
+	// NOTE: the backup node selector is read at startup, the changes to the labels of the nodes are followed.
+	BackupNodeSelector labels.Selector
+
+	// AddressFamily determines which node addresses are used as upstream servers, one of AddressFamilyIPv4,
+	// AddressFamilyIPv6, or AddressFamilyDual, the default, which keeps the IPv6-only nodes.
+	AddressFamily string
+
+	// NodeAddressTypes is the ordered preference of the node address types used as upstream servers, e.g. ExternalIP
+	// then InternalIP; a node that lacks the first type falls back to the next one.
+	NodeAddressTypes []corev1.NodeAddressType
+
+	// ExcludeControlPlaneNodes excludes the nodes labeled ControlPlaneNodeLabel from the upstream servers.
+	ExcludeControlPlaneNodes bool
+
+	// ExcludedTaintKeys excludes the nodes carrying a NoSchedule or NoExecute taint with one of these keys from
+	// the upstream servers, e.g. node.kubernetes.io/unreachable.
+	ExcludedTaintKeys []string
+}
+
+// logFields returns the settings of the Watcher as log fields.
+func (s WatcherSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"namespaces":                  s.NginxIngressNamespaces,
+		"serviceSelector":             selectorString(s.ServiceSelector),
+		"upstreamNameTemplate":        s.UpstreamNameTemplate,
+		"resyncPeriod":                s.ResyncPeriod,
+		"cacheSyncTimeout":            s.CacheSyncTimeout,
+		"drainTimeout":                s.DrainTimeout,
+		"deletedNodeDrainTimeout":     s.DeletedNodeDrainTimeout,
+		"drainedConnectionsThreshold": s.DrainedConnectionsThreshold,
+		"notReadyGracePeriod":         s.NotReadyGracePeriod,
+		"targetMode":                  s.TargetMode,
+		"rbacMode":                    s.RbacMode,
+		"nodeSelector":                selectorString(s.NodeSelector),
+		"backupNodeSelector":          selectorString(s.BackupNodeSelector),
+		"addressFamily":               s.AddressFamily,
+		"nodeAddressTypes":            s.NodeAddressTypes,
+		"excludeControlPlaneNodes":    s.ExcludeControlPlaneNodes,
+		"excludedTaintKeys":           s.ExcludedTaintKeys,
+	}
+}
+
+// validateTargetMode returns an error if the target mode is not one of the supported target modes.
+func validateTargetMode(targetMode string) error {
+	if targetMode != TargetModeNodes && targetMode != TargetModeEndpointSlices {
+		return fmt.Errorf(`target mode must be %s or %s, got %q`, TargetModeNodes, TargetModeEndpointSlices, targetMode)
+	}
+
+	return nil
+}
+
+// validateRbacMode returns an error if the RBAC mode is not one of the supported RBAC modes.
+func validateRbacMode(rbacMode string) error {
+	switch rbacMode {
+	case RbacModeAuto, RbacModeCluster, RbacModeScoped:
+		return nil
+	default:
+		return fmt.Errorf(`RBAC mode must be %s, %s, or %s, got %q`, RbacModeAuto, RbacModeCluster, RbacModeScoped, rbacMode)
+	}
+}
+
+// validateAddressFamily returns an error if the address family is not one of the supported address families.
+func validateAddressFamily(addressFamily string) error {
+	switch addressFamily {
+	case AddressFamilyIPv4, AddressFamilyIPv6, AddressFamilyDual:
+		return nil
+	default:
+		return fmt.Errorf(`address family must be %s, %s, or %s, got %q`, AddressFamilyIPv4, AddressFamilyIPv6, AddressFamilyDual, addressFamily)
+	}
+}
+
+// parseNodeAddressTypes parses a comma-separated, ordered list of node address types, e.g. "ExternalIP,InternalIP".
+func parseNodeAddressTypes(nodeAddressTypes string) ([]corev1.NodeAddressType, error) {
+	var addressTypes []corev1.NodeAddressType
+
+	for _, value := range strings.Split(nodeAddressTypes, ",") {
+		addressType := corev1.NodeAddressType(strings.TrimSpace(value))
+		if addressType != corev1.NodeInternalIP && addressType != corev1.NodeExternalIP {
+			return nil, fmt.Errorf(`node address type must be %s or %s, got %q`, corev1.NodeInternalIP, corev1.NodeExternalIP, addressType)
+		}
+
+		addressTypes = append(addressTypes, addressType)
+	}
+
+	return addressTypes, nil
+}
+
+// parseNodeSelector parses a label selector, e.g. "node-role.kubernetes.io/ingress=true"; an empty selector selects every node.
+func parseNodeSelector(nodeSelector string) (labels.Selector, error) {
+	selector, err := labels.Parse(nodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf(`node selector %q could not be parsed: %w`, nodeSelector, err)
+	}
+
+	return selector, nil
+}
+
+// parseBackupNodeSelector parses a label selector, e.g. "nkl.nginx.com/backup=true"; an empty selector marks no node backup.
+func parseBackupNodeSelector(backupNodeSelector string) (labels.Selector, error) {
+	if strings.TrimSpace(backupNodeSelector) == "" {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(backupNodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf(`backup node selector %q could not be parsed: %w`, backupNodeSelector, err)
+	}
+
+	return selector, nil
+}
+
+// selectorString returns the label selector as a string, empty for a nil selector.
+func selectorString(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+
+	return selector.String()
+}
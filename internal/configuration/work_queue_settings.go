@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package configuration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultQueueMaxDepth is the default number of queued events after which the events of a Service or upstream are merged.
+	DefaultQueueMaxDepth = 1000
+
+	// DefaultQueueDegradedAge is the default time the oldest event may wait in a queue before the replica is degraded.
+	DefaultQueueDegradedAge = time.Minute * 2
+)
+
+// WorkQueueSettings contains the configuration values needed by the Work Queues.
+// There are two work queues in the application:
+// 1. nlk-handler queue, used to move messages between the Watcher and the Handler.
+// 2. nlk-synchronizer queue, used to move message between the Handler and the Synchronizer.
+// The queues are NamedDelayingQueue objects that use an ItemExponentialFailureRateLimiter as the underlying rate limiter;
+// the Synchronizer computes the delays of its retries itself, with the same backoff jittered down to half its value.
+type WorkQueueSettings struct {
+	// Name is the name of the queue.
+	Name string
+
+	// RateLimiterBase is the value used to calculate the exponential backoff rate limiter.
+	// The formula is: RateLimiterBase * 2 ^ (num_retries - 1)
+	RateLimiterBase time.Duration
+
+	// RateLimiterMax limits the amount of time retries are allowed to be attempted.
+	RateLimiterMax time.Duration
+
+	// MaxDepth is the number of queued events after which a new event is merged into the event queued for the same
+	// Service or upstream, when the intermediate state it supersedes can be dropped, rather than appended.
+	MaxDepth int
+
+	// DegradedAge is how long the oldest event may wait in the queue, once due, before the replica is reported as degraded.
+	DegradedAge time.Duration
+}
+
+// Backoff returns the delay of the retry of an event that has been requeued the number of times, as computed by the
+// exponential backoff rate limiter of the queue.
+func (s WorkQueueSettings) Backoff(requeues int) time.Duration {
+	backoff := s.RateLimiterBase
+	for i := 0; i < requeues && backoff < s.RateLimiterMax; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, s.RateLimiterMax)
+}
+
+// logFields returns the settings of the queue as log fields.
+func (s WorkQueueSettings) logFields() logrus.Fields {
+	return logrus.Fields{
+		"queue":       s.Name,
+		"base":        s.RateLimiterBase,
+		"max":         s.RateLimiterMax,
+		"maxDepth":    s.MaxDepth,
+		"degradedAge": s.DegradedAge,
+	}
+}
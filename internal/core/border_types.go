@@ -0,0 +1,56 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+
+	// BorderTypeNginxPlus selects the NGINX Plus hosts as the Border Servers, the default. Their Border Clients are the
+	// NginxHttpBorderClient, NginxStreamBorderClient, and NginxUdpBorderClient, selected by the client type of the upstream.
+	BorderTypeNginxPlus = "nginx-plus"
+
+	// BorderTypeWebhook selects the WebhookBorderServers, which POST the servers of each upstream to the URL of each host.
+	BorderTypeWebhook = "webhook"
+)
+
+var (
+
+	// borderTypesLock guards borderTypes.
+	borderTypesLock sync.Mutex
+
+	// borderTypes holds the registered border types, see RegisterBorderType.
+	borderTypes = make(map[string]bool)
+)
+
+// RegisterBorderType registers a border type, so the border-type setting accepts it; the Border Servers of the type are
+// registered with application.RegisterBorderServers, which registers the type.
+func RegisterBorderType(borderType string) {
+	borderTypesLock.Lock()
+	defer borderTypesLock.Unlock()
+
+	borderTypes[borderType] = true
+}
+
+// BorderTypes returns the border types, the built-in NGINX Plus type and the registered ones, sorted.
+func BorderTypes() []string {
+	borderTypesLock.Lock()
+	defer borderTypesLock.Unlock()
+
+	types := []string{BorderTypeNginxPlus}
+	for borderType := range borderTypes {
+		if borderType != BorderTypeNginxPlus {
+			types = append(types, borderType)
+		}
+	}
+
+	sort.Strings(types)
+
+	return types
+}
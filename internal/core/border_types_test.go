@@ -0,0 +1,20 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBorderTypes_ListsTheBuiltInAndTheRegisteredTypes(t *testing.T) {
+	RegisterBorderType(BorderTypeWebhook)
+	RegisterBorderType(BorderTypeNginxPlus)
+
+	if borderTypes := BorderTypes(); !reflect.DeepEqual(borderTypes, []string{BorderTypeNginxPlus, BorderTypeWebhook}) {
+		t.Errorf(`expected the NGINX Plus and webhook border types, got %v`, borderTypes)
+	}
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package core
+
+// DesiredState is the state of the NGINX Plus upstreams derived from the watched Services and the cluster nodes.
+// The Synchronizer compares it with the upstreams of each NGINX Plus host to find the orphaned upstream servers.
+type DesiredState struct {
+
+	// HttpUpstreams holds the desired servers, e.g. "10.0.0.1:30080", of each HTTP upstream, keyed by upstream name.
+	HttpUpstreams map[string]map[string]bool
+
+	// StreamUpstreams holds the desired servers of each stream upstream, keyed by upstream name.
+	StreamUpstreams map[string]map[string]bool
+
+	// KnownNodeAddresses are the addresses of the current and previous cluster nodes. Only servers on these addresses
+	// are considered to have been created by NLK, so servers managed by other tooling are left alone.
+	KnownNodeAddresses map[string]bool
+
+	// WithheldUpstreams are the upstreams left without servers whose EmptyServerPolicy keeps their previous servers,
+	// keyed by upstream name; their servers are neither pruned nor deleted when the persisted state is recovered.
+	WithheldUpstreams map[string]bool
+}
+
+// NewDesiredState creates a new, empty DesiredState.
+func NewDesiredState() *DesiredState {
+	return &DesiredState{
+		HttpUpstreams:      make(map[string]map[string]bool),
+		StreamUpstreams:    make(map[string]map[string]bool),
+		KnownNodeAddresses: make(map[string]bool),
+		WithheldUpstreams:  make(map[string]bool),
+	}
+}
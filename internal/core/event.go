@@ -5,7 +5,13 @@
 
 package core
 
-import v1 "k8s.io/api/core/v1"
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+)
 
 type EventType int
 
@@ -37,6 +43,56 @@ type Event struct {
 	// NodeIps represents the list of node IPs in the Cluster. This is populated by the Watcher when an event is created.
 	// The Node IPs are needed by the BorderClient.
 	NodeIps []string
+
+	// PreviousNodeIps are the node IPs, draining ones included, of the previous event of the Service, so the servers of the
+	// upstreams the Service no longer targets are also deleted from the nodes removed since; nil when unknown, e.g. for the
+	// first event of the Service since NLK started.
+	PreviousNodeIps []string
+
+	// DrainingNodeIps represents the list of node IPs of the unavailable nodes, e.g. NotReady, that are still within the
+	// drain timeout. These are drained, rather than removed, for Services annotated with drain-on-cordon.
+	DrainingNodeIps []string
+
+	// CordonedNodeIps are the node IPs, also listed in NodeIps, of the nodes that are cordoned but otherwise available, by
+	// whether they are still within the drain timeout. The cordoned nodes keep serving the Services, except the Services
+	// annotated with drain-on-cordon, which drain their servers within the drain timeout and remove them afterwards.
+	CordonedNodeIps map[string]bool
+
+	// NodeNames maps the node IPs, and the draining node IPs, to the names of their nodes, e.g. to template the routes of the upstream servers.
+	NodeNames map[string]string
+
+	// BackupNodeIps are the node IPs of the nodes whose upstream servers are marked backup, nil when no backup node selector is set.
+	BackupNodeIps map[string]bool
+
+	// DownNodeIps maps the node IPs of the nodes whose state NLK manages, as the nodes are, or were, labeled or annotated with
+	// the configuration.NodeStateKey, to whether their upstream servers are marked down, i.e. the key is set to
+	// configuration.NodeStateDown. The down flag of the servers of the other nodes is left alone.
+	DownNodeIps map[string]bool
+
+	// UpstreamNameTemplate names the upstreams of the Service, e.g. "{namespace}-{name}", so that the Services of several
+	// watched namespaces can target distinct upstreams. The upstream name is used as-is when the template is empty.
+	UpstreamNameTemplate string
+
+	// TargetPorts are the port numbers of the endpoints of the Service, by the name of the Service port, which resolve its
+	// named targetPorts; nil when the EndpointSlices are not watched. They are the ports of the upstream servers of the
+	// Services using host ports, see configuration.UseHostPortsAnnotation.
+	TargetPorts map[string]int32
+
+	// SpanContext is the span of the Kubernetes event received by the Watcher, the parent of the spans of the pipeline;
+	// it is not valid when tracing is disabled.
+	SpanContext trace.SpanContext
+
+	// QueuedAt is when the event was last added to the queue of the Handler, to trace how long it waited.
+	QueuedAt time.Time
+
+	// ObservedAt is when the Watcher received the Kubernetes change the event stems from, the start of the time to
+	// converge; the receipt time is used rather than the timestamps of the object, which are set by other clocks.
+	ObservedAt time.Time
+
+	// Resync is set on the events that push the servers of the Service again, e.g. the periodic resyncs of the informers,
+	// and Watcher::ResyncServices when a host recovers: their servers are applied even when they are the servers last
+	// applied, so the upstreams changed outside NLK, or lost by a restarted host, are brought back in line.
+	Resync bool
 }
 
 // NewEvent factory method to create a new Event
@@ -48,3 +104,41 @@ func NewEvent(eventType EventType, service *v1.Service, previousService *v1.Serv
 		NodeIps:         nodeIps,
 	}
 }
+
+// LogFields returns the structured logging fields that identify the event: the service, and the event type.
+func (e *Event) LogFields() logrus.Fields {
+	fields := logrus.Fields{
+		"eventType": eventTypeName(e.Type),
+	}
+
+	if e.Service != nil {
+		fields["service"] = e.Service.Namespace + "/" + e.Service.Name
+	}
+
+	addTraceFields(fields, e.SpanContext)
+
+	return fields
+}
+
+// addTraceFields adds the trace and span ids of the span context to the logging fields, when it is valid, so the logs
+// can be correlated with the traces.
+func addTraceFields(fields logrus.Fields, spanContext trace.SpanContext) {
+	if spanContext.IsValid() {
+		fields["traceId"] = spanContext.TraceID().String()
+		fields["spanId"] = spanContext.SpanID().String()
+	}
+}
+
+// eventTypeName returns the string representation of the EventType.
+func eventTypeName(eventType EventType) string {
+	switch eventType {
+	case Created:
+		return "Created"
+	case Updated:
+		return "Updated"
+	case Deleted:
+		return "Deleted"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package core
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RedactHost replaces the password of a host URL with "xxxxx"; a host that cannot be parsed is redacted entirely.
+func RedactHost(host string) string {
+	hostUrl, err := url.Parse(host)
+	if err != nil {
+		return "[REDACTED]"
+	}
+
+	return hostUrl.Redacted()
+}
+
+// RedactHostsIn replaces each of the hosts found in the message, e.g. an error naming the host it occurred on, with the
+// host redacted by RedactHost.
+func RedactHostsIn(message string, hosts []string) string {
+	for _, host := range hosts {
+		if host != "" {
+			message = strings.ReplaceAll(message, host, RedactHost(host))
+		}
+	}
+
+	return message
+}
@@ -5,6 +5,14 @@
 
 package core
 
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+)
+
 // ServerUpdateEvent is an internal representation of an event. The Translator produces these events
 // from Events received from the Handler. These are then consumed by the Synchronizer and passed along to
 // the appropriate BorderClient.
@@ -20,6 +28,10 @@ type ServerUpdateEvent struct {
 	// NginxHost is the host name of the NGINX Plus instance that should handle this event.
 	NginxHost string
 
+	// Site is the site of the NginxHost, e.g. its data center, logged with the event, see configuration.SiteSuffix; empty
+	// when the host sets none.
+	Site string
+
 	// Type is the type of event. See EventType for the list of supported types.
 	Type EventType
 
@@ -28,6 +40,81 @@ type ServerUpdateEvent struct {
 
 	// UpstreamServers is the list of servers in the Upstream.
 	UpstreamServers UpstreamServers
+
+	// Service is the Service the event was translated from, Kubernetes Events about the sync are recorded on it. May be nil.
+	Service *v1.Service
+
+	// ResourceVersion is the resourceVersion of the Service the event was translated from, so an event translated from an
+	// older version of the Service than the one applied is not applied over it; empty when the event stems from no Service.
+	ResourceVersion string
+
+	// EmptyServerPolicy is the configuration.EmptyServerPolicyAnnotation of the upstream, which overrides the
+	// SynchronizerSettings::EmptyServerPolicy when the event leaves the upstream without servers; empty when not annotated.
+	EmptyServerPolicy string
+
+	// HealthCheck is set for the http upstreams of a Service whose externalTrafficPolicy is Local, nil otherwise.
+	HealthCheck *HealthCheckHint
+
+	// KeyValZone is the key-value zone that holds the node addresses of the upstream servers, e.g. for an allow-list;
+	// nil when the Service does not name one.
+	KeyValZone *KeyValZone
+
+	// AnyPort is set on the Deleted events whose server is the address of a node only, as the port of the servers could not
+	// be resolved, e.g. the named targetPort of a deleted Service using host ports: the servers of the upstream on that
+	// address are deleted, whatever their port.
+	AnyPort bool
+
+	// Ownership identifies the servers of the upstream NLK manages on the host, the other servers are not deleted; nil when
+	// NLK manages every server of the upstream, see SynchronizerSettings::OwnershipTag.
+	Ownership *ServerOwnership
+
+	// SpanContext is the span of the Kubernetes event the event was translated from, see Event::SpanContext.
+	SpanContext trace.SpanContext
+
+	// ObservedAt is when the Kubernetes change the event stems from was received, see Event::ObservedAt; zero when the
+	// event stems from no Kubernetes change, e.g. a reconciliation.
+	ObservedAt time.Time
+
+	// Resync is set on the events applied even when their servers are the servers last applied to the upstream, see
+	// Event::Resync.
+	Resync bool
+}
+
+// KeyValZone is a key-value zone of NGINX Plus in which NLK writes the address of each node of the upstream servers as a key.
+// The value of the keys is the Owner, so that NLK only deletes the keys it added.
+type KeyValZone struct {
+
+	// Name is the name of the zone.
+	Name string
+
+	// Stream is set for a zone of the stream context, the zone is in the http context otherwise.
+	Stream bool
+
+	// Owner is the value of the keys, the namespace/name of the Service.
+	Owner string
+}
+
+// ServerOwnership identifies the servers of an upstream NLK manages, so that it does not delete the servers added by hand
+// or by other tooling.
+type ServerOwnership struct {
+
+	// Owned holds the addresses of the servers NLK has applied to the upstream on the host.
+	Owned map[string]bool
+
+	// Force lets NLK delete the servers it does not own, see SynchronizerSettings::ForcePrune.
+	Force bool
+}
+
+// HealthCheckHint describes how the nodes of a Service with the Local externalTrafficPolicy should be health checked:
+// only the nodes running a ready endpoint of the Service accept its traffic, the others drop it, and Kubernetes serves
+// the number of local endpoints of each node on the healthCheckNodePort, at the /healthz path, for the load balancers to probe.
+type HealthCheckHint struct {
+
+	// ExternalTrafficPolicy is the externalTrafficPolicy of the Service, always Local.
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicy
+
+	// HealthCheckNodePort is the healthCheckNodePort of the Service.
+	HealthCheckNodePort int32
 }
 
 // ServerUpdateEvents is a list of ServerUpdateEvent.
@@ -46,25 +133,52 @@ func NewServerUpdateEvent(eventType EventType, upstreamName string, clientType s
 // ServerUpdateEventWithIdAndHost creates a new ServerUpdateEvent with the specified Id and Host.
 func ServerUpdateEventWithIdAndHost(event *ServerUpdateEvent, id string, nginxHost string) *ServerUpdateEvent {
 	return &ServerUpdateEvent{
-		ClientType:      event.ClientType,
-		Id:              id,
-		NginxHost:       nginxHost,
-		Type:            event.Type,
-		UpstreamName:    event.UpstreamName,
-		UpstreamServers: event.UpstreamServers,
+		ClientType:        event.ClientType,
+		Id:                id,
+		NginxHost:         nginxHost,
+		Type:              event.Type,
+		UpstreamName:      event.UpstreamName,
+		UpstreamServers:   event.UpstreamServers,
+		Service:           event.Service,
+		ResourceVersion:   event.ResourceVersion,
+		EmptyServerPolicy: event.EmptyServerPolicy,
+		HealthCheck:       event.HealthCheck,
+		KeyValZone:        event.KeyValZone,
+		AnyPort:           event.AnyPort,
+		SpanContext:       event.SpanContext,
+		ObservedAt:        event.ObservedAt,
+		Resync:            event.Resync,
 	}
 }
 
 // TypeName returns the string representation of the EventType.
 func (e *ServerUpdateEvent) TypeName() string {
-	switch e.Type {
-	case Created:
-		return "Created"
-	case Updated:
-		return "Updated"
-	case Deleted:
-		return "Deleted"
-	default:
-		return "Unknown"
+	return eventTypeName(e.Type)
+}
+
+// LogFields returns the structured logging fields that identify the event: the id, service, upstream, host, site, and event
+// type. The host is omitted until the event has been fanned out to a host, and the site when the host sets none; the
+// password of the host is redacted.
+func (e *ServerUpdateEvent) LogFields() logrus.Fields {
+	fields := logrus.Fields{
+		"id":        e.Id,
+		"upstream":  e.UpstreamName,
+		"eventType": e.TypeName(),
+	}
+
+	if e.NginxHost != "" {
+		fields["host"] = RedactHost(e.NginxHost)
+	}
+
+	if e.Site != "" {
+		fields["site"] = e.Site
 	}
+
+	if e.Service != nil {
+		fields["service"] = e.Service.Namespace + "/" + e.Service.Name
+	}
+
+	addTraceFields(fields, e.SpanContext)
+
+	return fields
 }
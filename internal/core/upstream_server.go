@@ -11,6 +11,44 @@ type UpstreamServer struct {
 
 	// Host is the host name or IP address of the upstream server.
 	Host string
+
+	// Weight is the weight of the upstream server, nil uses the NGINX Plus default.
+	Weight *int
+
+	// MaxFails is the number of unsuccessful attempts before the upstream server is considered unavailable,
+	// nil uses the NGINX Plus default.
+	MaxFails *int
+
+	// FailTimeout is the period during which MaxFails must occur, and the period the upstream server is considered
+	// unavailable, e.g. "10s"; empty uses the NGINX Plus default.
+	FailTimeout string
+
+	// Route is the route of the upstream server, used for sticky routing; empty sets none. HTTP upstreams only.
+	Route string
+
+	// Service is the service of the upstream server, as used for DNS SRV service discovery; empty sets none. HTTP upstreams only.
+	Service string
+
+	// SlowStart is the time over which the weight of the upstream server recovers from zero once it is added, e.g. "30s";
+	// empty sets none. HTTP upstreams only.
+	SlowStart string
+
+	// Backup marks the upstream server as a backup server, which only receives traffic when the other servers are unavailable;
+	// nil leaves the NGINX Plus default, so the parameter is only sent when backup nodes are selected.
+	Backup *bool
+
+	// Drain indicates the upstream server should only serve existing connections, e.g. because its node is unschedulable.
+	Drain bool
+
+	// Down marks the upstream server down, so it receives no traffic, e.g. while its node is validated; nil leaves the flag
+	// alone, so it is only sent for the servers of the nodes whose state NLK manages, see Event::DownNodeIps, and a server
+	// marked down outside NLK, e.g. through the dashboard, stays down.
+	Down *bool
+}
+
+// IsDown determines whether the upstream server is marked down, an unset flag is not.
+func (s *UpstreamServer) IsDown() bool {
+	return s.Down != nil && *s.Down
 }
 
 // UpstreamServers is a slice of UpstreamServer.
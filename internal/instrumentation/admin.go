@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GoroutinesPath is the path at which the goroutine counts are served.
+	GoroutinesPath = "/debug/goroutines"
+
+	// SettingsPath is the path at which the current settings are served, with their secrets redacted.
+	SettingsPath = "/debug/settings"
+
+	// NotRunning is the message returned by the settings endpoint when no settings source is set, e.g. on a standby replica.
+	NotRunning = "The controller is not running on this replica"
+
+	// createdByPrefix starts the line of a goroutine stack that names the function which started the goroutine.
+	createdByPrefix = "created by "
+)
+
+// GoroutineCounts is the response of the goroutines endpoint.
+type GoroutineCounts struct {
+
+	// Total is the number of goroutines that currently exist.
+	Total int `json:"total"`
+
+	// ByCreator counts the goroutines by the function that started them, "main" for the main goroutine.
+	// A count that keeps growing points to the leak.
+	ByCreator map[string]int `json:"byCreator"`
+}
+
+// AdminServer is a server that exposes the net/http/pprof handlers, the goroutine counts, and the current settings.
+// It is meant for troubleshooting a running process, and should only listen on localhost, see AdminSettings.
+type AdminServer struct {
+
+	// address is the host and port the server listens on.
+	address string
+
+	// settingsSource provides the settings to serve, see SetSettingsSource.
+	settingsSource atomic.Pointer[func() any]
+
+	// The underlying HTTP server.
+	httpServer *http.Server
+}
+
+// NewAdminServer creates a new AdminServer listening on the address.
+func NewAdminServer(address string) *AdminServer {
+	return &AdminServer{
+		address: address,
+	}
+}
+
+// SetSettingsSource sets the function that provides the settings to serve, typically Settings::Redacted; nil unsets it.
+// The settings must not hold any secret.
+func (as *AdminServer) SetSettingsSource(source func() any) {
+	if source == nil {
+		as.settingsSource.Store(nil)
+		return
+	}
+
+	as.settingsSource.Store(&source)
+}
+
+// Start spins up the admin server; the server is stopped when the context is done.
+func (as *AdminServer) Start(ctx context.Context) error {
+	logrus.Debugf("Starting admin listener on %s", as.address)
+
+	as.httpServer = &http.Server{Addr: as.address, Handler: as.buildMux()}
+
+	listener, err := net.Listen("tcp", as.address)
+	if err != nil {
+		return fmt.Errorf(`unable to start admin listener on %s: %w`, as.address, err)
+	}
+
+	go func() {
+		if err := as.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("unable to serve admin on %s: %v", as.address, err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		as.Stop()
+	}()
+
+	logrus.Info("Started admin listener on ", listener.Addr())
+
+	return nil
+}
+
+// Stop shuts down the admin server.
+func (as *AdminServer) Stop() {
+	if err := as.httpServer.Shutdown(context.Background()); err != nil {
+		logrus.Errorf("unable to stop admin listener on %s: %v", as.address, err)
+	}
+}
+
+// buildMux registers the handlers of the admin server; the pprof handlers are registered on their own mux,
+// rather than on http.DefaultServeMux, so that they are not served by any other server.
+func (as *AdminServer) buildMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc(GoroutinesPath, func(writer http.ResponseWriter, _ *http.Request) {
+		writeJson(writer, countGoroutines())
+	})
+
+	mux.HandleFunc(SettingsPath, func(writer http.ResponseWriter, _ *http.Request) {
+		source := as.settingsSource.Load()
+		if source == nil {
+			http.Error(writer, NotRunning, http.StatusServiceUnavailable)
+			return
+		}
+
+		writeJson(writer, (*source)())
+	})
+
+	return mux
+}
+
+// countGoroutines counts the goroutines, in total and by the function that started them.
+func countGoroutines() GoroutineCounts {
+	stacks := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(stacks, true)
+		if n < len(stacks) {
+			stacks = stacks[:n]
+			break
+		}
+
+		stacks = make([]byte, len(stacks)*2)
+	}
+
+	counts := GoroutineCounts{ByCreator: make(map[string]int)}
+
+	// each goroutine is a header line and its stack, followed by an empty line; the last line of a stack names the creator
+	creator := "main"
+	scanner := bufio.NewScanner(bytes.NewReader(stacks))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(stacks)+1)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			counts.Total++
+			creator = "main"
+		case strings.HasPrefix(line, createdByPrefix):
+			creator = strings.TrimPrefix(line, createdByPrefix)
+			if index := strings.Index(creator, " in goroutine "); index >= 0 {
+				creator = creator[:index]
+			}
+		case line == "":
+			counts.ByCreator[creator]++
+			creator = ""
+		}
+	}
+
+	if creator != "" && counts.Total > 0 {
+		counts.ByCreator[creator]++
+	}
+
+	return counts
+}
+
+// writeJson writes the value as indented JSON.
+func writeJson(writer http.ResponseWriter, value any) {
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error(`AdminServer::writeJson: error occurred encoding the response`)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := writer.Write(body); err != nil {
+		logrus.Error(err)
+	}
+}
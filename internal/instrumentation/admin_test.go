@@ -0,0 +1,130 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdminServer_ServesTheGoroutineCounts(t *testing.T) {
+	server := httptest.NewServer(NewAdminServer("").buildMux())
+	defer server.Close()
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+
+	for i := 0; i < 3; i++ {
+		go func() { <-blocked }()
+	}
+
+	response, err := http.Get(server.URL + GoroutinesPath)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	defer response.Body.Close()
+
+	counts := GoroutineCounts{}
+	if err = json.NewDecoder(response.Body).Decode(&counts); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	total := 0
+	for _, count := range counts.ByCreator {
+		total += count
+	}
+
+	if counts.Total < 3 || total != counts.Total {
+		t.Fatalf(`expected the counts by creator to add up to the total, got %d and %v`, counts.Total, counts.ByCreator)
+	}
+
+	creator := "github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation.TestAdminServer_ServesTheGoroutineCounts"
+	if counts.ByCreator[creator] < 3 {
+		t.Fatalf(`expected the goroutines started by the test to be counted, got %v`, counts.ByCreator)
+	}
+}
+
+func TestAdminServer_ServesTheSettingsOnceSet(t *testing.T) {
+	adminServer := NewAdminServer("")
+	server := httptest.NewServer(adminServer.buildMux())
+	defer server.Close()
+
+	response, err := http.Get(server.URL + SettingsPath)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf(`expected status %d without settings, got %d`, http.StatusServiceUnavailable, response.StatusCode)
+	}
+
+	adminServer.SetSettingsSource(func() any { return map[string]string{"logLevel": "debug"} })
+
+	response, err = http.Get(server.URL + SettingsPath)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	defer response.Body.Close()
+
+	settings := map[string]string{}
+	if err = json.NewDecoder(response.Body).Decode(&settings); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if settings["logLevel"] != "debug" {
+		t.Fatalf(`expected the settings of the source, got %v`, settings)
+	}
+}
+
+func TestAdminServer_ServesPprofAndStopsWithTheContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	address := listener.Addr().String()
+	listener.Close()
+
+	if err = NewAdminServer(address).Start(ctx); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response, err := http.Get("http://" + address + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf(`expected status %d, got %d`, http.StatusOK, response.StatusCode)
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response, err = http.Get("http://" + address + GoroutinesPath)
+		if err != nil {
+			return
+		}
+		response.Body.Close()
+
+		if time.Now().After(deadline) {
+			t.Fatal(`expected the admin server to be shut down with the context`)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BacklogObserveInterval is how often the depth and the age of the oldest event of the work queues are exported.
+const BacklogObserveInterval = time.Second * 5
+
+var (
+	workQueueBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "backlog",
+		Help:      "Number of events waiting in the work queue, including the events whose retry is delayed.",
+	}, []string{NameLabel})
+
+	workQueueOldestItemAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "oldest_item_age_seconds",
+		Help:      "How many seconds the oldest event in the work queue has been due without being handled.",
+	}, []string{NameLabel})
+
+	workQueueDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "degraded",
+		Help:      "Whether the oldest event in the work queue has been due for longer than the degraded age (1) or not (0).",
+	}, []string{NameLabel})
+
+	workQueueCoalesced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "coalesced_total",
+		Help:      "Number of events merged into an event queued for the same key, as the work queue was over its maximum depth.",
+	}, []string{NameLabel})
+)
+
+// QueueBacklog tracks the events waiting in a work queue, from when they are queued until a worker takes them, so the
+// backpressure of slow NGINX Plus hosts is visible before the queue grows without bound: once the queue holds MaxDepth
+// events it is Full, and the new events of a key are merged into those already queued, and once its oldest event has
+// been due for longer than the degraded age the queue is degraded, which fails the readiness probe.
+type QueueBacklog struct {
+
+	// name is the name of the work queue, e.g. "nlk-synchronizer".
+	name string
+
+	// maxDepth is the number of events after which the queue is Full.
+	maxDepth int
+
+	// degradedAge is how long the oldest event may be due before the queue is degraded.
+	degradedAge time.Duration
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	// lock guards dueAt.
+	lock sync.Mutex
+
+	// dueAt holds when each waiting event is due, i.e. when it was queued plus its delay.
+	dueAt map[any]time.Time
+}
+
+// NewQueueBacklog creates a new, empty QueueBacklog for the work queue of the name.
+func NewQueueBacklog(name string, maxDepth int, degradedAge time.Duration) *QueueBacklog {
+	return &QueueBacklog{
+		name:        name,
+		maxDepth:    maxDepth,
+		degradedAge: degradedAge,
+		now:         time.Now,
+		dueAt:       make(map[any]time.Time),
+	}
+}
+
+// Queued records that the event was added to the queue, to be handled after the delay, e.g. the backoff of a retry.
+func (b *QueueBacklog) Queued(item any, delay time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.dueAt[item] = b.now().Add(delay)
+	workQueueBacklog.WithLabelValues(b.name).Set(float64(len(b.dueAt)))
+}
+
+// Taken records that a worker took the event from the queue.
+func (b *QueueBacklog) Taken(item any) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.dueAt, item)
+	workQueueBacklog.WithLabelValues(b.name).Set(float64(len(b.dueAt)))
+}
+
+// Coalesced records that an event was merged into an event queued for the same key, as the queue was Full.
+func (b *QueueBacklog) Coalesced() {
+	workQueueCoalesced.WithLabelValues(b.name).Inc()
+}
+
+// Depth returns the number of events waiting in the queue.
+func (b *QueueBacklog) Depth() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.dueAt)
+}
+
+// Full determines whether the queue holds MaxDepth events or more.
+func (b *QueueBacklog) Full() bool {
+	return b.Depth() >= b.maxDepth
+}
+
+// OldestAge returns how long the oldest event in the queue has been due, zero when no event is due.
+func (b *QueueBacklog) OldestAge() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	oldest := time.Duration(0)
+	for _, dueAt := range b.dueAt {
+		if age := now.Sub(dueAt); age > oldest {
+			oldest = age
+		}
+	}
+
+	return oldest
+}
+
+// Observe exports the depth and the age of the oldest event of the queue, and whether the queue is degraded.
+func (b *QueueBacklog) Observe() {
+	b.observe(b.OldestAge())
+}
+
+// Degradation returns why the queue is degraded, or an empty string while its oldest event has been due for no longer
+// than the degraded age.
+func (b *QueueBacklog) Degradation() string {
+	age := b.OldestAge()
+	b.observe(age)
+
+	if age <= b.degradedAge {
+		return ""
+	}
+
+	return fmt.Sprintf("the %s queue is backed up, its oldest event has been due for %v (more than %v) with %d event(s) waiting",
+		b.name, age.Round(time.Second), b.degradedAge, b.Depth())
+}
+
+// observe exports the depth of the queue, and the age of its oldest event.
+func (b *QueueBacklog) observe(age time.Duration) {
+	workQueueBacklog.WithLabelValues(b.name).Set(float64(b.Depth()))
+	workQueueOldestItemAge.WithLabelValues(b.name).Set(age.Seconds())
+
+	if age > b.degradedAge {
+		workQueueDegraded.WithLabelValues(b.name).Set(1)
+	} else {
+		workQueueDegraded.WithLabelValues(b.name).Set(0)
+	}
+}
+
+// registerBacklogMetrics registers the backlog metrics with the Registry.
+func registerBacklogMetrics() {
+	Registry.MustRegister(
+		workQueueBacklog,
+		workQueueOldestItemAge,
+		workQueueDegraded,
+		workQueueCoalesced,
+	)
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueueBacklog_IsDegradedOnceTheOldestEventIsDueForTooLong(t *testing.T) {
+	now := time.Now()
+	backlog := NewQueueBacklog("nlk-test", 2, time.Minute)
+	backlog.now = func() time.Time { return now }
+
+	backlog.Queued("retried", time.Minute*5)
+	backlog.Queued("added", 0)
+
+	if !backlog.Full() {
+		t.Fatalf(`expected the backlog to be full with %d events`, backlog.Depth())
+	}
+
+	now = now.Add(time.Minute * 2)
+
+	// the retried event is not due yet, only the added event counts
+	if age := backlog.OldestAge(); age != time.Minute*2 {
+		t.Fatalf(`expected the oldest event to be due for 2m, got %v`, age)
+	}
+
+	if reason := backlog.Degradation(); !strings.Contains(reason, "the nlk-test queue is backed up") {
+		t.Fatalf(`expected the backlog to be degraded, got %q`, reason)
+	}
+
+	if degraded := testutil.ToFloat64(workQueueDegraded.WithLabelValues("nlk-test")); degraded != 1 {
+		t.Fatalf(`expected the degraded metric to be set, got %v`, degraded)
+	}
+
+	backlog.Taken("added")
+
+	if reason := backlog.Degradation(); reason != "" || backlog.Full() {
+		t.Fatalf(`expected the backlog to recover once the event is taken, got %q`, reason)
+	}
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReusedLabel is the label identifying whether an NGINX Plus API call reused a connection of the pool, "true" or "false".
+const ReusedLabel = "reused"
+
+// ApiConnections counts the connections the NGINX Plus API calls were sent on, by whether they were reused from the pool
+// or newly opened, i.e. dialed and TLS handshaken; the calls multiplexed on an HTTP/2 connection count as reused.
+var ApiConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "api_connections_total",
+	Help:      "Number of NGINX Plus API calls by whether their connection to the host was reused or newly opened.",
+}, []string{HostLabel, ReusedLabel})
+
+// ObserveApiConnection records the connection an NGINX Plus API call to the host was sent on.
+func ObserveApiConnection(host string, reused bool) {
+	ApiConnections.WithLabelValues(hostLabel(host), strconv.FormatBool(reused)).Inc()
+}
+
+// registerConnectionMetrics registers the connection metrics with the Registry.
+func registerConnectionMetrics() {
+	Registry.MustRegister(ApiConnections)
+}
@@ -0,0 +1,12 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+/*
+Package instrumentation includes support for exposing Prometheus metrics.
+
+The metrics are registered with a dedicated Registry which is served by the MetricsServer.
+*/
+
+package instrumentation
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/metrics"
+)
+
+// VerbLabel is the label identifying the HTTP verb of a Kubernetes API call, e.g. "GET".
+const VerbLabel = "verb"
+
+var kubeApiRateLimiterLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Name:      "kube_api_rate_limiter_duration_seconds",
+	Help:      "Time the Kubernetes API calls waited for the client-side rate limiter, by verb.",
+	Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{VerbLabel})
+
+// kubeApiRateLimiterObserver observes the time the Kubernetes API calls waited for the rate limiter of client-go, see
+// KubeApiSettings; the calls that wait more than 50ms are those client-go logs as throttled.
+type kubeApiRateLimiterObserver struct{}
+
+// Observe records the time a Kubernetes API call waited for the rate limiter.
+func (kubeApiRateLimiterObserver) Observe(_ context.Context, verb string, _ url.URL, latency time.Duration) {
+	kubeApiRateLimiterLatency.WithLabelValues(verb).Observe(latency.Seconds())
+}
+
+// RegisterKubeApiMetrics has client-go report the time the Kubernetes API calls wait for its rate limiter, exported as
+// nkl_kube_api_rate_limiter_duration_seconds. client-go only accepts the first registration, so it must be called before
+// the Kubernetes client is built.
+func RegisterKubeApiMetrics() {
+	metrics.Register(metrics.RegisterOpts{
+		RateLimiterLatency: kubeApiRateLimiterObserver{},
+	})
+}
+
+// registerKubeApiMetrics registers the Kubernetes API metrics with the Registry.
+func registerKubeApiMetrics() {
+	Registry.MustRegister(kubeApiRateLimiterLatency)
+}
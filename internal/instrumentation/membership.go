@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	upstreamServers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "upstream_servers",
+		Help:      "Number of servers NLK last programmed in an upstream on an NGINX Plus host.",
+	}, []string{HostLabel, UpstreamLabel})
+
+	desiredServers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "desired_servers",
+		Help:      "Number of servers an upstream should have, per the Service and its Nodes or Pods, before the hosts are synced.",
+	}, []string{UpstreamLabel})
+
+	hostsConfigured = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "hosts_configured",
+		Help:      "Number of NGINX Plus hosts configured, listed in the ConfigMap or discovered.",
+	})
+
+	hostsReachable = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "hosts_reachable",
+		Help:      "Number of NGINX Plus hosts whose API could be reached when they were last probed or synced.",
+	})
+)
+
+// upstreamMembership tracks the label sets of the membership gauges, so the gauges of the hosts and the upstreams that are
+// no longer managed are deleted rather than left stale, e.g. nkl_upstream_servers of a removed host graphed at its last value.
+type upstreamMembership struct {
+
+	// lock guards upstreams and reachable, the gauges are updated by the Synchronizer workers.
+	lock sync.Mutex
+
+	// upstreams holds the upstreams nkl_upstream_servers was set for, by host.
+	upstreams map[string]map[string]bool
+
+	// reachable holds whether each host could be reached, which nkl_hosts_reachable counts.
+	reachable map[string]bool
+}
+
+var membership = &upstreamMembership{
+	upstreams: make(map[string]map[string]bool),
+	reachable: make(map[string]bool),
+}
+
+// ObserveUpstreamServers records the number of servers programmed in the upstream on the NGINX Plus host by a sync.
+func ObserveUpstreamServers(host string, upstream string, count int) {
+	membership.lock.Lock()
+	defer membership.lock.Unlock()
+
+	if membership.upstreams[host] == nil {
+		membership.upstreams[host] = make(map[string]bool)
+	}
+
+	membership.upstreams[host][upstream] = true
+	upstreamServers.WithLabelValues(hostLabel(host), upstream).Set(float64(count))
+}
+
+// ForgetUpstreamServers drops the servers of an upstream on an NGINX Plus host that NLK no longer manages.
+func ForgetUpstreamServers(host string, upstream string) {
+	membership.lock.Lock()
+	defer membership.lock.Unlock()
+
+	delete(membership.upstreams[host], upstream)
+	if len(membership.upstreams[host]) == 0 {
+		delete(membership.upstreams, host)
+	}
+
+	upstreamServers.DeleteLabelValues(hostLabel(host), upstream)
+}
+
+// ObserveDesiredServers records the number of servers the upstream should have.
+func ObserveDesiredServers(upstream string, count int) {
+	desiredServers.WithLabelValues(upstream).Set(float64(count))
+}
+
+// ForgetDesiredServers drops the desired servers of an upstream that NLK no longer manages.
+func ForgetDesiredServers(upstream string) {
+	desiredServers.DeleteLabelValues(upstream)
+}
+
+// ObserveHostsConfigured records the number of NGINX Plus hosts configured.
+func ObserveHostsConfigured(count int) {
+	hostsConfigured.Set(float64(count))
+}
+
+// observeHostReachable records whether the host could be reached, and exports the number of hosts that could.
+func (m *upstreamMembership) observeHostReachable(host string, reachable bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.reachable[host] = reachable
+	m.observeHostsReachable()
+}
+
+// forgetHost drops the servers of every upstream of the host, and its reachability.
+func (m *upstreamMembership) forgetHost(host string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for upstream := range m.upstreams[host] {
+		upstreamServers.DeleteLabelValues(hostLabel(host), upstream)
+	}
+
+	delete(m.upstreams, host)
+	delete(m.reachable, host)
+	m.observeHostsReachable()
+}
+
+// observeHostsReachable exports the number of hosts that could be reached. The caller must hold the lock.
+func (m *upstreamMembership) observeHostsReachable() {
+	count := 0
+	for _, reachable := range m.reachable {
+		if reachable {
+			count++
+		}
+	}
+
+	hostsReachable.Set(float64(count))
+}
+
+// registerMembershipMetrics registers the membership metrics with the Registry.
+func registerMembershipMetrics() {
+	Registry.MustRegister(
+		upstreamServers,
+		desiredServers,
+		hostsConfigured,
+		hostsReachable,
+	)
+}
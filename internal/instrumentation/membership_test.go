@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveUpstreamServers_ForgetsTheUpstreamsOfRemovedHosts(t *testing.T) {
+	ObserveUpstreamServers("https://membership-1:8080", "http-upstream", 3)
+	ObserveUpstreamServers("https://membership-1:8080", "stream-upstream", 2)
+	ObserveUpstreamServers("https://membership-2:8080", "http-upstream", 3)
+
+	if servers := testutil.ToFloat64(upstreamServers.WithLabelValues("https://membership-1:8080", "stream-upstream")); servers != 2 {
+		t.Fatalf(`expected 2 servers, got %v`, servers)
+	}
+
+	ForgetHost("https://membership-1:8080")
+
+	expected := `
+# HELP nkl_upstream_servers Number of servers NLK last programmed in an upstream on an NGINX Plus host.
+# TYPE nkl_upstream_servers gauge
+nkl_upstream_servers{host="https://membership-2:8080",upstream="http-upstream"} 3
+`
+	if err := testutil.CollectAndCompare(upstreamServers, strings.NewReader(expected)); err != nil {
+		t.Fatalf(`expected only the upstreams of the remaining host, %v`, err)
+	}
+
+	ForgetUpstreamServers("https://membership-2:8080", "http-upstream")
+
+	if count := testutil.CollectAndCount(upstreamServers); count != 0 {
+		t.Fatalf(`expected the servers of the deleted upstream to be dropped, got %d series`, count)
+	}
+}
+
+func TestObserveDesiredServers_ForgetsDeletedUpstreams(t *testing.T) {
+	ObserveDesiredServers("desired-upstream", 4)
+
+	if servers := testutil.ToFloat64(desiredServers.WithLabelValues("desired-upstream")); servers != 4 {
+		t.Fatalf(`expected 4 desired servers, got %v`, servers)
+	}
+
+	ForgetDesiredServers("desired-upstream")
+
+	if count := testutil.CollectAndCount(desiredServers); count != 0 {
+		t.Fatalf(`expected the desired servers of the deleted upstream to be dropped, got %d series`, count)
+	}
+}
+
+func TestObserveHostReachable_CountsTheReachableHosts(t *testing.T) {
+	ObserveHostReachable("https://reachable-1:8080", true)
+	ObserveHostReachable("https://reachable-2:8080", true)
+	before := testutil.ToFloat64(hostsReachable)
+
+	ObserveHostReachable("https://reachable-2:8080", false)
+	if reachable := testutil.ToFloat64(hostsReachable); reachable != before-1 {
+		t.Fatalf(`expected %v reachable hosts, got %v`, before-1, reachable)
+	}
+
+	ForgetHost("https://reachable-1:8080")
+	if reachable := testutil.ToFloat64(hostsReachable); reachable != before-2 {
+		t.Fatalf(`expected %v reachable hosts once the host is removed, got %v`, before-2, reachable)
+	}
+
+	ForgetHost("https://reachable-2:8080")
+}
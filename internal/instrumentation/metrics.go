@@ -0,0 +1,393 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const (
+	// Namespace is the prefix applied to all metrics exposed by NLK.
+	Namespace = "nkl"
+
+	// HostLabel is the label identifying the NGINX Plus host.
+	HostLabel = "host"
+
+	// UpstreamLabel is the label identifying the NGINX Plus upstream.
+	UpstreamLabel = "upstream"
+
+	// NameLabel is the label identifying the work queue.
+	NameLabel = "name"
+
+	// OperationLabel is the label identifying the change to an upstream server, one of "add", "update", or "delete".
+	OperationLabel = "operation"
+
+	// KindLabel is the label identifying the kind of NGINX Plus API call, "read" or "write".
+	KindLabel = "kind"
+
+	// RoleLabel is the label identifying the role of a certificate, "ca" or "client".
+	RoleLabel = "role"
+
+	// EventTypeLabel is the label identifying the type of the change to an upstream, one of "add", "update", or "delete".
+	EventTypeLabel = "type"
+
+	// KeyLabel is the label identifying the ConfigMap key an NGINX Plus host is listed in.
+	KeyLabel = "key"
+)
+
+var (
+	// Registry is the Prometheus registry that holds all the metrics exposed by NLK.
+	Registry = prometheus.NewRegistry()
+
+	// SyncAttempts counts the attempts to synchronize an upstream on an NGINX Plus host.
+	SyncAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_attempts_total",
+			Help:      "Number of attempts to synchronize an upstream on an NGINX Plus host.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// SyncFailures counts the failed attempts to synchronize an upstream on an NGINX Plus host.
+	SyncFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_failures_total",
+			Help:      "Number of failed attempts to synchronize an upstream on an NGINX Plus host.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// SyncLatency observes the duration of the NGINX Plus API calls made to synchronize an upstream.
+	SyncLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "sync_duration_seconds",
+			Help:      "Duration of the NGINX Plus API calls made to synchronize an upstream.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// Convergence observes the time from the observation of a Kubernetes change by the Watcher to its acknowledgment by an
+	// NGINX Plus host, through the queues, the retries, and the NGINX Plus API calls.
+	Convergence = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "convergence_seconds",
+			Help:      "Time from the observation of a Kubernetes change to its acknowledgment by an NGINX Plus host, by type of change.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+		},
+		[]string{EventTypeLabel, HostLabel},
+	)
+
+	// SyncTimeouts counts the attempts to synchronize an upstream on an NGINX Plus host that timed out, they are also
+	// counted as failures, see SynchronizerSettings::UpstreamTimeout.
+	SyncTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_timeouts_total",
+			Help:      "Number of attempts to synchronize an upstream on an NGINX Plus host that timed out.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// SyncStale counts the events for an upstream dropped because they were translated from an older version of the Service
+	// than the version last applied, e.g. an event delivered out of order.
+	SyncStale = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_stale_total",
+			Help:      "Number of events for an upstream on an NGINX Plus host dropped because they stem from an older version of the Service than the one applied.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// SyncSkipped counts the syncs of an upstream skipped because its servers have not changed since they were last applied.
+	SyncSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_skipped_total",
+			Help:      "Number of syncs of an upstream on an NGINX Plus host skipped because the servers were unchanged.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// DriftDetected counts the reconciliations that found the servers of an upstream different from the servers last applied.
+	DriftDetected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "drift_detected_total",
+			Help:      "Number of reconciliations that found the servers of an upstream on an NGINX Plus host changed outside NLK.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// DryRunChanges counts the changes to the upstream servers that would have been made in dry-run mode.
+	DryRunChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "dry_run_changes_total",
+			Help:      "Number of upstream server changes that would have been made on an NGINX Plus host in dry-run mode.",
+		},
+		[]string{HostLabel, UpstreamLabel, OperationLabel},
+	)
+
+	// HostCircuitOpen reports whether the circuit breaker of an NGINX Plus host is open, so the host is skipped.
+	HostCircuitOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "host_circuit_open",
+			Help:      "Whether the circuit breaker of an NGINX Plus host is open (1) or closed (0).",
+		},
+		[]string{HostLabel},
+	)
+
+	// HostReachable reports whether the connectivity probe of an NGINX Plus host, made when the hosts are set or changed,
+	// succeeded; a host that failed the probe is reported as reachable again once a sync of the host succeeds.
+	HostReachable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "host_reachable",
+			Help:      "Whether the NGINX Plus API of a host could be reached (1) or not (0) when it was last probed or synced.",
+		},
+		[]string{HostLabel},
+	)
+
+	// SyncCircuitOpen counts the syncs of an upstream skipped because the circuit breaker of the NGINX Plus host is open.
+	SyncCircuitOpen = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sync_circuit_open_total",
+			Help:      "Number of syncs of an upstream on an NGINX Plus host skipped because the circuit breaker of the host was open.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// SecondarySyncFailures counts the events dropped after RetryCount attempts on a secondary NGINX Plus host,
+	// whose failures are reported as warnings rather than affecting the readiness.
+	SecondarySyncFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "secondary_sync_failures_total",
+			Help:      "Number of upstream updates that a secondary NGINX Plus host did not converge to after the retries.",
+		},
+		[]string{HostLabel, UpstreamLabel},
+	)
+
+	// ApiThrottled counts the NGINX Plus API calls delayed by the client-side rate limiter of the host.
+	ApiThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "api_throttled_requests_total",
+			Help:      "Number of NGINX Plus API calls delayed by the client-side rate limiter of the host, by kind.",
+		},
+		[]string{HostLabel, KindLabel},
+	)
+
+	// ApiThrottleDelay sums the time the NGINX Plus API calls were delayed by the client-side rate limiter of the host.
+	ApiThrottleDelay = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "api_throttle_delay_seconds_total",
+			Help:      "Total time the NGINX Plus API calls were delayed by the client-side rate limiter of the host, by kind.",
+		},
+		[]string{HostLabel, KindLabel},
+	)
+
+	// InsecureHosts reports the number of NGINX Plus hosts whose certificates are not verified, as their nginx-hosts entries
+	// set the insecure option, so that a lab host configuration does not sneak into production unnoticed.
+	InsecureHosts = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "insecure_hosts",
+			Help:      "Number of NGINX Plus hosts whose certificates are not verified, as their nginx-hosts entries set insecure=true.",
+		},
+	)
+
+	// HostKey reports the ConfigMap key each NGINX Plus host is listed in, e.g. nginx-hosts-canary, so the metrics of the
+	// hosts can be grouped by key, e.g. nkl_sync_failures_total * on(host) group_left(key) nkl_host_key.
+	HostKey = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "host_key",
+			Help:      "The ConfigMap key an NGINX Plus host is listed in, always 1.",
+		},
+		[]string{HostLabel, KeyLabel},
+	)
+
+	// CertificateExpiry reports the time left before the CA and client certificates used to connect to NGINX Plus expire,
+	// it is updated each time the certificates are checked, and is negative once a certificate has expired.
+	CertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "certificate_expiry_seconds",
+			Help:      "Seconds left before the certificate used to connect to the NGINX Plus hosts expires, by role.",
+		},
+		[]string{RoleLabel},
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SyncAttempts,
+		SyncFailures,
+		SyncLatency,
+		Convergence,
+		SyncTimeouts,
+		SyncSkipped,
+		SyncStale,
+		DriftDetected,
+		DryRunChanges,
+		HostCircuitOpen,
+		HostReachable,
+		SyncCircuitOpen,
+		SecondarySyncFailures,
+		ApiThrottled,
+		ApiThrottleDelay,
+		CertificateExpiry,
+		InsecureHosts,
+		HostKey,
+	)
+
+	registerWorkQueueMetrics()
+	registerBacklogMetrics()
+	registerMembershipMetrics()
+	registerKubeApiMetrics()
+	registerConnectionMetrics()
+}
+
+// ObserveSync records the outcome of an attempt to synchronize an upstream on an NGINX Plus host.
+func ObserveSync(host string, upstream string, start time.Time, err error) {
+	SyncAttempts.WithLabelValues(hostLabel(host), upstream).Inc()
+	SyncLatency.WithLabelValues(hostLabel(host), upstream).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		SyncFailures.WithLabelValues(hostLabel(host), upstream).Inc()
+	}
+}
+
+// ObserveConvergence records the time a change of the given type took to be acknowledged by an NGINX Plus host.
+func ObserveConvergence(eventType string, host string, duration time.Duration) {
+	Convergence.WithLabelValues(eventType, hostLabel(host)).Observe(duration.Seconds())
+}
+
+// ObserveSyncTimeout records an attempt to synchronize an upstream on an NGINX Plus host that timed out.
+func ObserveSyncTimeout(host string, upstream string) {
+	SyncTimeouts.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveSyncSkipped records a sync of an upstream on an NGINX Plus host skipped because the servers were unchanged.
+func ObserveSyncSkipped(host string, upstream string) {
+	SyncSkipped.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveSyncStale records an event for an upstream on an NGINX Plus host dropped because it stems from an older version of the Service.
+func ObserveSyncStale(host string, upstream string) {
+	SyncStale.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveDriftDetected records the drift of the servers of an upstream on an NGINX Plus host from the servers last applied.
+func ObserveDriftDetected(host string, upstream string) {
+	DriftDetected.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveDryRun records the changes to an upstream that would have been made on an NGINX Plus host in dry-run mode.
+func ObserveDryRun(host string, upstream string, added int, updated int, deleted int) {
+	DryRunChanges.WithLabelValues(hostLabel(host), upstream, "add").Add(float64(added))
+	DryRunChanges.WithLabelValues(hostLabel(host), upstream, "update").Add(float64(updated))
+	DryRunChanges.WithLabelValues(hostLabel(host), upstream, "delete").Add(float64(deleted))
+}
+
+// ObserveCircuit records whether the circuit breaker of an NGINX Plus host is open.
+func ObserveCircuit(host string, open bool) {
+	if open {
+		HostCircuitOpen.WithLabelValues(hostLabel(host)).Set(1)
+	} else {
+		HostCircuitOpen.WithLabelValues(hostLabel(host)).Set(0)
+	}
+}
+
+// ObserveHostReachable records whether the NGINX Plus API of a host could be reached.
+func ObserveHostReachable(host string, reachable bool) {
+	if reachable {
+		HostReachable.WithLabelValues(hostLabel(host)).Set(1)
+	} else {
+		HostReachable.WithLabelValues(hostLabel(host)).Set(0)
+	}
+
+	membership.observeHostReachable(host, reachable)
+}
+
+// ForgetHost drops the circuit breaker state, the reachability, and the upstream servers of an NGINX Plus host that is no
+// longer configured.
+func ForgetHost(host string) {
+	HostCircuitOpen.DeleteLabelValues(hostLabel(host))
+	HostReachable.DeleteLabelValues(hostLabel(host))
+	membership.forgetHost(host)
+}
+
+// ObserveSyncCircuitOpen records a sync of an upstream skipped because the circuit breaker of the NGINX Plus host was open.
+func ObserveSyncCircuitOpen(host string, upstream string) {
+	SyncCircuitOpen.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveSecondarySyncFailure records an update of an upstream that a secondary NGINX Plus host did not converge to.
+func ObserveSecondarySyncFailure(host string, upstream string) {
+	SecondarySyncFailures.WithLabelValues(hostLabel(host), upstream).Inc()
+}
+
+// ObserveApiThrottled records an NGINX Plus API call of the kind delayed by the rate limiter of the host.
+func ObserveApiThrottled(host string, kind string, delay time.Duration) {
+	ApiThrottled.WithLabelValues(hostLabel(host), kind).Inc()
+	ApiThrottleDelay.WithLabelValues(hostLabel(host), kind).Add(delay.Seconds())
+}
+
+// ObserveCertificateExpiry records the time left before the certificate of the role expires.
+func ObserveCertificateExpiry(role string, remaining time.Duration) {
+	CertificateExpiry.WithLabelValues(role).Set(remaining.Seconds())
+}
+
+// ForgetCertificateExpiry drops the expiry of the certificate of a role that is no longer used.
+func ForgetCertificateExpiry(role string) {
+	CertificateExpiry.DeleteLabelValues(role)
+}
+
+// ObserveHostKeys records the ConfigMap key of each NGINX Plus host, by host; the hosts no longer listed are forgotten.
+func ObserveHostKeys(keys map[string]string) {
+	HostKey.Reset()
+
+	for host, key := range keys {
+		HostKey.WithLabelValues(hostLabel(host), key).Set(1)
+	}
+}
+
+// ObserveInsecureHosts records the number of NGINX Plus hosts whose certificates are not verified.
+func ObserveInsecureHosts(count int) {
+	InsecureHosts.Set(float64(count))
+}
+
+// hostLabel returns the host as the value of the HostLabel, without the password of its user info, as the metrics are
+// scraped by whoever reaches the metrics endpoint; a host:port, e.g. of a connection, is returned as it is.
+func hostLabel(host string) string {
+	hostUrl, err := url.Parse(host)
+	switch {
+	case err != nil && strings.Contains(host, "@"):
+		return "[REDACTED]"
+	case err != nil || hostUrl.User == nil:
+		return host
+	}
+
+	return hostUrl.Redacted()
+}
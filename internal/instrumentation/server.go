@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ListenPort is the port on which the metrics server will listen.
+	ListenPort = 9113
+
+	// MetricsPath is the path at which the metrics are served.
+	MetricsPath = "/metrics"
+)
+
+// MetricsServer is a server that exposes the metrics in the Registry.
+type MetricsServer struct {
+
+	// The underlying HTTP server.
+	httpServer *http.Server
+}
+
+// NewMetricsServer creates a new MetricsServer.
+func NewMetricsServer() *MetricsServer {
+	return &MetricsServer{}
+}
+
+// Start spins up the metrics server; the server is stopped when the context is done.
+func (ms *MetricsServer) Start(ctx context.Context) error {
+	logrus.Debugf("Starting metrics listener on port %d", ListenPort)
+
+	address := fmt.Sprintf(":%d", ListenPort)
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	ms.httpServer = &http.Server{Addr: address, Handler: mux}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf(`unable to start metrics listener on %s: %w`, address, err)
+	}
+
+	go func() {
+		if err := ms.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("unable to serve metrics on %s: %v", address, err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		ms.Stop()
+	}()
+
+	logrus.Info("Started metrics listener on ", address)
+
+	return nil
+}
+
+// Stop shuts down the metrics server.
+func (ms *MetricsServer) Stop() {
+	if err := ms.httpServer.Shutdown(context.Background()); err != nil {
+		logrus.Errorf("unable to stop metrics listener on %s: %v", ms.httpServer.Addr, err)
+	}
+}
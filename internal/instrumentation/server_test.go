@@ -0,0 +1,40 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsServer_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := NewMetricsServer()
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response, err := http.Get(fmt.Sprintf("http://localhost:%d%s", ListenPort, MetricsPath))
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf(`expected status %d, got %d`, http.StatusOK, response.StatusCode)
+	}
+
+	body, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(body), "go_goroutines") {
+		t.Fatalf(`expected the metrics to be exposed`)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the name of the tracer of the event pipeline.
+	TracerName = "github.com/nginxinc/kubernetes-nginx-ingress"
+
+	// ServiceName is the service.name of the traces.
+	ServiceName = "nginx-loadbalancer-kubernetes"
+
+	// HostAttribute is the span attribute holding the NGINX Plus host of an API call.
+	HostAttribute = attribute.Key("nlk.host")
+
+	// UpstreamAttribute is the span attribute holding the name of the upstream of an API call.
+	UpstreamAttribute = attribute.Key("nlk.upstream")
+
+	// ServiceAttribute is the span attribute holding the namespace/name of the Service of a Kubernetes event.
+	ServiceAttribute = attribute.Key("nlk.service")
+
+	// NodeAttribute is the span attribute holding the name of the Node of a Kubernetes event.
+	NodeAttribute = attribute.Key("nlk.node")
+
+	// NamespaceAttribute is the span attribute holding the namespace whose Services are no longer watched.
+	NamespaceAttribute = attribute.Key("nlk.namespace")
+)
+
+// StartTracing exports the spans of the event pipeline to the OTLP/HTTP collector at the endpoint, e.g. http://otel-collector:4318,
+// sampling the ratio of the traces, and propagates the trace headers to the NGINX Plus API calls. The spans are not recorded
+// when the endpoint is empty. The returned function flushes the pending spans and stops the export.
+func StartTracing(ctx context.Context, endpoint string, sampleRatio float64, version string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf(`error creating the OTLP exporter: %w`, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName), semconv.ServiceVersion(version))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	logrus.Infof("StartTracing: exporting the traces to %s, sampling %v of them", endpoint, sampleRatio)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the event pipeline, whose spans are not recorded unless StartTracing has enabled the tracing.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// HostOf returns the HostAttribute of the NGINX Plus host, redacted like the HostLabel of the metrics.
+func HostOf(host string) attribute.KeyValue {
+	return HostAttribute.String(hostLabel(host))
+}
+
+// StartSpan starts a span, a child of the parent when it is valid, e.g. of the span of the Kubernetes event an event
+// of the pipeline stems from; the Events and ServerUpdateEvents carry the span context across the work queues.
+func StartSpan(ctx context.Context, parent trace.SpanContext, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if parent.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, parent)
+	}
+
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan records the error, if any, on the span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// RecordQueueWait records the time an event of the parent waited in a work queue, since it was queued, as a span of the name.
+func RecordQueueWait(parent trace.SpanContext, name string, queuedAt time.Time, attributes ...attribute.KeyValue) {
+	if !parent.IsValid() || queuedAt.IsZero() {
+		return
+	}
+
+	_, span := Tracer().Start(trace.ContextWithSpanContext(context.Background(), parent), name,
+		trace.WithTimestamp(queuedAt), trace.WithAttributes(attributes...))
+	span.End()
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpan_IsAChildOfTheParent(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, parent := StartSpan(context.Background(), trace.SpanContext{}, "Watcher::ServiceUpdated")
+	parent.End()
+
+	_, child := StartSpan(context.Background(), parent.SpanContext(), "Handler::translate", UpstreamAttribute.String("nginx-ingress"))
+	EndSpan(child, errors.New("the upstream was not found"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf(`expected two spans, got %d`, len(spans))
+	}
+
+	if spans[1].Parent().SpanID() != parent.SpanContext().SpanID() || spans[1].SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf(`expected the span to be a child of the parent, got %v`, spans[1].Parent())
+	}
+
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf(`expected the span to record the error, got %v`, spans[1].Status())
+	}
+}
+
+func TestRecordQueueWait_StartsWhenTheEventWasQueued(t *testing.T) {
+	recorder := recordSpans(t)
+
+	RecordQueueWait(trace.SpanContext{}, "Handler::queue", time.Now())
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Fatalf(`expected no span for an event that is not traced, got %d`, len(spans))
+	}
+
+	_, parent := StartSpan(context.Background(), trace.SpanContext{}, "Watcher::ServiceUpdated")
+	parent.End()
+
+	queuedAt := time.Now().Add(-time.Second)
+	RecordQueueWait(parent.SpanContext(), "Handler::queue", queuedAt)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || !spans[1].StartTime().Equal(queuedAt) {
+		t.Fatalf(`expected the queue span to start when the event was queued, got %v`, spans)
+	}
+}
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package instrumentation
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const workQueueSubsystem = "workqueue"
+
+var (
+	workQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "depth",
+		Help:      "Current depth of the work queue.",
+	}, []string{NameLabel})
+
+	workQueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "adds_total",
+		Help:      "Number of adds handled by the work queue.",
+	}, []string{NameLabel})
+
+	workQueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "queue_duration_seconds",
+		Help:      "How long an item stays in the work queue before being requested.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{NameLabel})
+
+	workQueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "work_duration_seconds",
+		Help:      "How long processing an item from the work queue takes.",
+		Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{NameLabel})
+
+	workQueueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "unfinished_work_seconds",
+		Help:      "How many seconds of work has been done that is in progress and hasn't been observed by work_duration.",
+	}, []string{NameLabel})
+
+	workQueueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "longest_running_processor_seconds",
+		Help:      "How many seconds the longest running processor of the work queue has been running.",
+	}, []string{NameLabel})
+
+	workQueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: workQueueSubsystem,
+		Name:      "retries_total",
+		Help:      "Number of retries handled by the work queue.",
+	}, []string{NameLabel})
+)
+
+// WorkQueueMetricsProvider implements workqueue.MetricsProvider, exporting the work queue metrics to the Registry.
+// It must be installed with workqueue.SetProvider before any work queues are created.
+type WorkQueueMetricsProvider struct{}
+
+// NewWorkQueueMetricsProvider creates a new WorkQueueMetricsProvider.
+func NewWorkQueueMetricsProvider() *WorkQueueMetricsProvider {
+	return &WorkQueueMetricsProvider{}
+}
+
+func (p *WorkQueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workQueueDepth.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workQueueAdds.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workQueueLatency.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workQueueWorkDuration.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (p *WorkQueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workQueueRetries.WithLabelValues(name)
+}
+
+// registerWorkQueueMetrics registers the work queue metrics with the Registry.
+func registerWorkQueueMetrics() {
+	Registry.MustRegister(
+		workQueueDepth,
+		workQueueAdds,
+		workQueueLatency,
+		workQueueWorkDuration,
+		workQueueUnfinishedWork,
+		workQueueLongestRunningProcessor,
+		workQueueRetries,
+	)
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/synchronization"
+	"github.com/sirupsen/logrus"
+)
+
+// deferredDelete is the Deleted event of a Service held for the HandlerSettings::DeleteDeferral.
+type deferredDelete struct {
+
+	// event is the Deleted event, queued once the deferral has elapsed.
+	event *core.Event
+
+	// heldAt is when the event was held.
+	heldAt time.Time
+
+	// deleteAt is when the event is queued, unless the Service is recreated first.
+	deleteAt time.Time
+
+	// timer queues the event at deleteAt.
+	timer *time.Timer
+}
+
+// deferredDeletes holds the Deleted events of the Services, by namespace/name, so that a Service deleted then recreated
+// within the HandlerSettings::DeleteDeferral has its servers replaced by a single Updated event, rather than removed
+// until the Created event repopulates them.
+type deferredDeletes struct {
+
+	// lock guards held and stopping.
+	lock sync.Mutex
+
+	// held holds the Deleted event of each Service, by namespace/name.
+	held map[string]*deferredDelete
+
+	// stopping is set once the Handler stops, the Deleted events are no longer held from then on.
+	stopping bool
+}
+
+// newDeferredDeletes creates a new deferredDeletes, holding no event.
+func newDeferredDeletes() *deferredDeletes {
+	return &deferredDeletes{
+		held: make(map[string]*deferredDelete),
+	}
+}
+
+// deferDelete holds a Deleted event for the DeleteDeferral, then queues it unless the Service is recreated in the
+// meantime, see replaceDeferredDelete. Returns false if the event is to be queued at once: it is not a Deleted event,
+// the deferral is disabled, or NLK is shutting down.
+func (h *Handler) deferDelete(event *core.Event) bool {
+	window := h.settings.Handler.DeleteDeferral
+	if event.Type != core.Deleted || window <= 0 || h.settings.Context.Err() != nil {
+		return false
+	}
+
+	h.deferredDeletes.lock.Lock()
+	defer h.deferredDeletes.lock.Unlock()
+
+	if h.deferredDeletes.stopping {
+		return false
+	}
+
+	key := pendingEventKey(event)
+	if previous, found := h.deferredDeletes.held[key]; found {
+		previous.timer.Stop()
+	}
+
+	now := time.Now()
+	deferred := &deferredDelete{event: event, heldAt: now, deleteAt: now.Add(window)}
+	deferred.timer = time.AfterFunc(window, func() { h.releaseDeferredDelete(key, deferred) })
+	h.deferredDeletes.held[key] = deferred
+
+	logrus.WithFields(event.LogFields()).Infof(`Handler::deferDelete: holding the deletion of the service for %v in case it is recreated`, window)
+
+	return true
+}
+
+// releaseDeferredDelete queues the held Deleted event once the deferral has elapsed, unless the Service was recreated.
+func (h *Handler) releaseDeferredDelete(key string, deferred *deferredDelete) {
+	h.deferredDeletes.lock.Lock()
+	if h.deferredDeletes.held[key] != deferred {
+		h.deferredDeletes.lock.Unlock()
+		return
+	}
+
+	delete(h.deferredDeletes.held, key)
+	h.deferredDeletes.lock.Unlock()
+
+	logrus.WithFields(deferred.event.LogFields()).Info(`Handler::releaseDeferredDelete: the service was not recreated, deleting its servers`)
+
+	h.addEvent(deferred.event)
+}
+
+// replaceDeferredDelete merges a Created event with the held Deleted event of the same Service into an Updated event
+// from the deleted to the recreated Service, so only the differences are applied, e.g. the servers on a new nodePort
+// replace those on the previous one. Returns the event as it is when no deletion of the Service is held.
+func (h *Handler) replaceDeferredDelete(event *core.Event) *core.Event {
+	if event.Type != core.Created {
+		return event
+	}
+
+	h.deferredDeletes.lock.Lock()
+	key := pendingEventKey(event)
+	deferred, found := h.deferredDeletes.held[key]
+	if found {
+		deferred.timer.Stop()
+		delete(h.deferredDeletes.held, key)
+	}
+	h.deferredDeletes.lock.Unlock()
+
+	if !found {
+		return event
+	}
+
+	logrus.WithFields(event.LogFields()).Infof(`Handler::replaceDeferredDelete: the service was recreated %v after its deletion, replacing its servers`, time.Since(deferred.heldAt).Round(time.Millisecond))
+
+	replaced := *event
+	replaced.Type = core.Updated
+	replaced.PreviousService = deferred.event.Service
+
+	// the time to converge counts from the deletion, the first change of the Service
+	if !deferred.event.ObservedAt.IsZero() {
+		replaced.ObservedAt = deferred.event.ObservedAt
+	}
+
+	return &replaced
+}
+
+// releaseDeferredDeletes applies the held Deleted events at once as the Handler shuts down, and no longer holds the
+// Deleted events from then on. The events are translated and flushed to the synchronizer before the event queues shut
+// down, see synchronization.Synchronizer::FlushEvents, as the queues no longer deliver them from then on.
+func (h *Handler) releaseDeferredDeletes() {
+	h.deferredDeletes.lock.Lock()
+	h.deferredDeletes.stopping = true
+
+	var released []*core.Event
+	for key, deferred := range h.deferredDeletes.held {
+		deferred.timer.Stop()
+		delete(h.deferredDeletes.held, key)
+		released = append(released, deferred.event)
+	}
+	h.deferredDeletes.lock.Unlock()
+
+	for _, event := range released {
+		logrus.WithFields(event.LogFields()).Info(`Handler::releaseDeferredDeletes: NLK is shutting down, deleting the servers of the service`)
+
+		events, err := h.translate(event)
+		if err != nil {
+			logrus.WithFields(event.LogFields()).Warnf(`Handler::releaseDeferredDeletes: error translating, the servers are left in place: %v`, err)
+			continue
+		}
+
+		if len(events) > 0 {
+			h.synchronizer.FlushEvents(events)
+		}
+	}
+}
+
+// DeferredDeletes returns the deletions of the Services held in case they are recreated, sorted by Service, for the
+// debug endpoint. It is safe to call while the Handler is running.
+func (h *Handler) DeferredDeletes() []synchronization.DeferredDeleteSnapshot {
+	h.deferredDeletes.lock.Lock()
+	defer h.deferredDeletes.lock.Unlock()
+
+	snapshots := make([]synchronization.DeferredDeleteSnapshot, 0, len(h.deferredDeletes.held))
+	for key, deferred := range h.deferredDeletes.held {
+		snapshots = append(snapshots, synchronization.DeferredDeleteSnapshot{
+			Service:  key,
+			HeldAt:   deferred.heldAt,
+			DeleteAt: deferred.deleteAt,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Service < snapshots[j].Service })
+
+	return snapshots
+}
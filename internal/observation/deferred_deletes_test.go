@@ -0,0 +1,149 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestHandler_ReplacesTheServersOfARecreatedService(t *testing.T) {
+	_, eventQueue, _, handler, err := buildHandler()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	deleted := buildIgnorableService("false")
+	recreated := deleted.DeepCopy()
+	recreated.Spec.Ports[0].NodePort = 31913
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: deleted, NodeIps: []string{"10.0.0.1"}})
+
+	if eventQueue.Len() != 0 {
+		t.Fatalf(`expected the deletion to be held, got %d events`, eventQueue.Len())
+	}
+
+	if held := handler.DeferredDeletes(); len(held) != 1 || held[0].Service != "nginx-ingress/nginx-ingress-metrics" || !held[0].DeleteAt.After(held[0].HeldAt) {
+		t.Fatalf(`expected the held deletion to be listed, got %+v`, held)
+	}
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Created, Service: recreated, NodeIps: []string{"10.0.0.1"}})
+
+	if eventQueue.Len() != 1 || len(handler.DeferredDeletes()) != 0 {
+		t.Fatalf(`expected a single event once the service is recreated, got %d events`, eventQueue.Len())
+	}
+
+	item, _ := eventQueue.Get()
+	if event := item.(*core.Event); event.Type != core.Updated || event.Service != recreated || event.PreviousService != deleted {
+		t.Fatalf(`expected an update from the deleted to the recreated service, got %#v`, event)
+	}
+}
+
+func TestHandler_DeletesTheServersOnceTheDeferralElapses(t *testing.T) {
+	settings, err := configuration.NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Handler.DeleteDeferral = 50 * time.Millisecond
+	eventQueue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+	defer eventQueue.ShutDown()
+
+	handler := NewHandler(settings, &mocks.MockSynchronizer{}, eventQueue)
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: buildIgnorableService("false"), NodeIps: []string{"10.0.0.1"}})
+
+	for deadline := time.Now().Add(5 * time.Second); eventQueue.Len() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	item, _ := eventQueue.Get()
+	if event := item.(*core.Event); event.Type != core.Deleted {
+		t.Fatalf(`expected the deletion to be queued once the deferral elapsed, got %#v`, event)
+	}
+
+	if held := handler.DeferredDeletes(); len(held) != 0 {
+		t.Fatalf(`expected no held deletion, got %+v`, held)
+	}
+}
+
+func TestHandler_FlushesTheHeldDeletionsBeforeTheQueueShutsDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings, err := configuration.NewSettings(ctx, nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	eventQueue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+	synchronizer := &mocks.MockSynchronizer{}
+	handler := NewHandler(settings, synchronizer, eventQueue)
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: buildIgnorableService("false"), NodeIps: []string{"10.0.0.1"}})
+
+	stopped := make(chan struct{})
+	go func() {
+		handler.Run(ctx.Done())
+		close(stopped)
+	}()
+
+	cancel()
+	<-stopped
+	handler.ShutDown()
+
+	if len(synchronizer.Flushed) == 0 || synchronizer.Flushed[0].Type != core.Deleted || len(handler.DeferredDeletes()) != 0 {
+		t.Fatalf(`expected the held deletion to be flushed as the handler shuts down, got %+v`, synchronizer.Flushed)
+	}
+
+	if !eventQueue.ShuttingDown() {
+		t.Fatalf(`expected the event queue to be shut down`)
+	}
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: buildIgnorableService("true"), NodeIps: []string{"10.0.0.1"}})
+
+	if held := handler.DeferredDeletes(); len(held) != 0 {
+		t.Fatalf(`expected no deletion to be held while shutting down, got %+v`, held)
+	}
+}
+
+func TestHandler_ForgetsTheQueuedEventOnceItsMergedCopyIsHandled(t *testing.T) {
+	settings, err := configuration.NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Handler.WorkQueueSettings.MaxDepth = 1
+	eventQueue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
+	defer eventQueue.ShutDown()
+
+	handler := NewHandler(settings, &mocks.MockSynchronizer{}, eventQueue)
+
+	deleted := buildIgnorableService("false")
+	recreated := deleted.DeepCopy()
+	recreated.Spec.Ports[0].NodePort = 31913
+	rescaled := recreated.DeepCopy()
+	rescaled.ResourceVersion = "2"
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: deleted, NodeIps: []string{"10.0.0.1"}})
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Created, Service: recreated, NodeIps: []string{"10.0.0.1"}})
+	queued := handler.pending["nginx-ingress/nginx-ingress-metrics"].queued
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Updated, Service: rescaled, PreviousService: recreated, NodeIps: []string{"10.0.0.1", "10.0.0.2"}})
+
+	if eventQueue.NumRequeues(queued) == 0 {
+		t.Fatalf(`expected the rate limiter to track the queued event`)
+	}
+
+	handler.handleNextEvent()
+
+	if requeues := eventQueue.NumRequeues(queued); requeues != 0 {
+		t.Fatalf(`expected the queued event to be forgotten once its merged copy was handled, got %d requeues`, requeues)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// deletedNode is a node deleted while its upstream servers were serving or draining, e.g. during a cluster upgrade that
+// cordons, drains, then deletes each node within minutes. Its servers are drained rather than removed with the node, so
+// the connections still open through it are not cut.
+type deletedNode struct {
+	// addresses are the addresses of the node used as upstream servers when it was deleted.
+	addresses []string
+
+	// deletedAt is when the deletion was seen, used to apply the WatcherSettings::DeletedNodeDrainTimeout.
+	deletedAt time.Time
+}
+
+// rememberDeletedNode records the deleted node, so its servers are drained until the NGINX Plus hosts confirm they have no
+// more active connections than the WatcherSettings::DrainedConnectionsThreshold, see ConfirmDrained, or the
+// WatcherSettings::DeletedNodeDrainTimeout has elapsed. The nodes that were excluded, or unavailable for longer than the
+// drain timeout, have no server left to drain.
+func (w *Watcher) rememberDeletedNode(node *v1.Node, now time.Time) {
+	timeout := w.settings.Watcher.DeletedNodeDrainTimeout
+	if timeout <= 0 || w.excludedNode(*node) {
+		return
+	}
+
+	addresses := w.nodeAddresses(*node)
+	if len(addresses) == 0 {
+		return
+	}
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	if unavailableAt, found := w.unavailableNodes[node.Name]; found && now.Sub(unavailableAt) >= w.settings.Watcher.DrainTimeout {
+		return
+	}
+
+	w.deletedNodes[node.Name] = deletedNode{addresses: addresses, deletedAt: now}
+
+	logrus.WithFields(logrus.Fields{"node": node.Name, "addresses": addresses}).
+		Infof(`Watcher::rememberDeletedNode: the upstream servers of the deleted node are drained for up to %v`, timeout)
+
+	time.AfterFunc(timeout, w.ResyncServices)
+}
+
+// deletedNodeAddresses returns the addresses of the deleted nodes still draining, and forgets the nodes whose drain timed
+// out, and the nodes listed again, e.g. a node re-created with the same name, whose servers follow the listed node.
+func (w *Watcher) deletedNodeAddresses(nodes []v1.Node, now time.Time) []string {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	for _, node := range nodes {
+		delete(w.deletedNodes, node.Name)
+	}
+
+	var addresses []string
+	for name, node := range w.deletedNodes {
+		if now.Sub(node.deletedAt) >= w.settings.Watcher.DeletedNodeDrainTimeout {
+			logrus.WithField("node", name).Warnf(`Watcher::deletedNodeAddresses: the upstream servers of the deleted node still had active connections after %v, removing them`,
+				w.settings.Watcher.DeletedNodeDrainTimeout)
+			delete(w.deletedNodes, name)
+			continue
+		}
+
+		addresses = append(addresses, node.addresses...)
+	}
+
+	return addresses
+}
+
+// ConfirmDrained is notified of the addresses whose upstream servers are drained on every NGINX Plus host, typically by
+// the Synchronizer. The deleted nodes whose every address is drained are forgotten, and the Services
+// are resynchronized so their servers are removed without waiting for the WatcherSettings::DeletedNodeDrainTimeout.
+func (w *Watcher) ConfirmDrained(addresses []string) {
+	w.nodesLock.Lock()
+
+	var drained []string
+	for name, node := range w.deletedNodes {
+		if !containsAll(addresses, node.addresses) {
+			continue
+		}
+
+		drained = append(drained, name)
+		delete(w.deletedNodes, name)
+	}
+
+	w.nodesLock.Unlock()
+
+	if len(drained) == 0 {
+		return
+	}
+
+	logrus.WithField("nodes", drained).Info(`Watcher::ConfirmDrained: the upstream servers of the deleted nodes are drained, removing them`)
+
+	w.ResyncServices()
+}
+
+// containsAll determines whether every one of the values is in the list.
+func containsAll(list []string, values []string) bool {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			return false
+		}
+	}
+
+	return true
+}
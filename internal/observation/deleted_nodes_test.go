@@ -0,0 +1,92 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_DeletedNodesAreDrainedUntilConfirmed(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false), buildNode("upgraded", "10.0.0.2", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	deleteNode(t, watcher, "upgraded")
+
+	nodeIps, drainingNodeIps, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1"}) || !reflect.DeepEqual(drainingNodeIps, []string{"10.0.0.2"}) {
+		t.Fatalf(`expected the servers of the deleted node to be draining, got %v and %v`, nodeIps, drainingNodeIps)
+	}
+
+	// an address of another node tells nothing about the deleted node
+	watcher.ConfirmDrained([]string{"10.0.0.1"})
+	if _, drainingNodeIps, _ = watcher.retrieveNodeIps(); len(drainingNodeIps) != 1 {
+		t.Fatalf(`expected the deleted node to be draining until its servers are confirmed, got %v`, drainingNodeIps)
+	}
+
+	watcher.ConfirmDrained([]string{"10.0.0.2"})
+	if _, drainingNodeIps, _ = watcher.retrieveNodeIps(); len(drainingNodeIps) != 0 {
+		t.Fatalf(`expected the servers of the deleted node to be removed once drained, got %v`, drainingNodeIps)
+	}
+}
+
+func TestWatcher_DeletedNodesAreRemovedAfterTheTimeout(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(buildNode("upgraded", "10.0.0.2", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	deleteNode(t, watcher, "upgraded")
+	watcher.deletedNodes["upgraded"] = deletedNode{addresses: []string{"10.0.0.2"}, deletedAt: time.Now().Add(-settings.Watcher.DeletedNodeDrainTimeout)}
+
+	if _, drainingNodeIps, _ := watcher.retrieveNodeIps(); len(drainingNodeIps) != 0 || len(watcher.deletedNodes) != 0 {
+		t.Fatalf(`expected the servers of the deleted node to be removed once the drain timed out, got %v`, drainingNodeIps)
+	}
+}
+
+func TestWatcher_DeletedNodesWithoutServersAreNotDrained(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(buildNode("expired", "10.0.0.2", true), buildNode("worker", "10.0.0.3", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+	watcher.unavailableNodes["expired"] = time.Now().Add(-settings.Watcher.DrainTimeout)
+
+	// the servers of the node were removed once its drain timed out
+	deleteNode(t, watcher, "expired")
+
+	// the deleted nodes are removed at once
+	settings.Watcher.DeletedNodeDrainTimeout = 0
+	deleteNode(t, watcher, "worker")
+
+	if len(watcher.deletedNodes) != 0 {
+		t.Fatalf(`expected no deleted node to be drained, got %v`, watcher.deletedNodes)
+	}
+}
+
+func deleteNode(t *testing.T, watcher *Watcher, name string) {
+	nodes := watcher.settings.K8sClient.CoreV1().Nodes()
+
+	node, err := nodes.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err = nodes.Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	watcher.rememberDeletedNode(node, time.Now())
+}
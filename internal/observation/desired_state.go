@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/translation"
+	v1 "k8s.io/api/core/v1"
+)
+
+// DesiredState returns the servers that the upstreams of the watched Services should have, along with the addresses of
+// the cluster nodes seen since NLK started. An error is returned until the informers have synced, or when the targets of
+// a Service cannot be retrieved, so that servers are never pruned based on an incomplete view of the cluster.
+func (w *Watcher) DesiredState() (*core.DesiredState, error) {
+	if nodeInformer := w.nodes(); !w.endpointNodes && (nodeInformer == nil || !nodeInformer.HasSynced()) {
+		return nil, fmt.Errorf(`the informers have not synced`)
+	}
+
+	namespaces := w.watchedNamespaces()
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf(`no namespaces are watched`)
+	}
+
+	for name, namespaceInformers := range namespaces {
+		if !namespaceInformers.hasSynced() {
+			return nil, fmt.Errorf(`the informers of the %s namespace have not synced`, name)
+		}
+	}
+
+	state := core.NewDesiredState()
+
+	for _, service := range w.watchedServices() {
+		if translation.IsIgnored(service, nil) {
+			continue
+		}
+
+		nodeIps, drainingNodeIps, err := w.retrieveTargetIps(service)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the targets of service %s/%s: %w`, service.Namespace, service.Name, err)
+		}
+
+		event := w.newEvent(core.Updated, service, nil, nodeIps, drainingNodeIps)
+
+		events, err := translation.Translate(&event, nil)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred translating service %s/%s: %w`, service.Namespace, service.Name, err)
+		}
+
+		for _, serverUpdateEvent := range events {
+			if w.withheld(serverUpdateEvent) {
+				state.WithheldUpstreams[serverUpdateEvent.UpstreamName] = true
+				continue
+			}
+
+			addDesiredServers(state, serverUpdateEvent)
+		}
+	}
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	for address := range w.knownNodeAddresses {
+		state.KnownNodeAddresses[address] = true
+	}
+
+	return state, nil
+}
+
+// addDesiredServers adds the servers of the event to the HTTP or stream upstreams of the desired state.
+func addDesiredServers(state *core.DesiredState, event *core.ServerUpdateEvent) {
+	var upstreams map[string]map[string]bool
+
+	switch event.ClientType {
+	case application.ClientTypeNginxHttp:
+		upstreams = state.HttpUpstreams
+	case application.ClientTypeNginxStream, application.ClientTypeNginxUdp:
+		upstreams = state.StreamUpstreams
+	default:
+		return
+	}
+
+	if upstreams[event.UpstreamName] == nil {
+		upstreams[event.UpstreamName] = make(map[string]bool)
+	}
+
+	for _, server := range event.UpstreamServers {
+		upstreams[event.UpstreamName][server.Host] = true
+	}
+}
+
+// withheld determines whether the event leaves its upstream without servers while the EmptyServerPolicy of the upstream
+// keeps the previous servers, see Synchronizer::withholdEmptyServers.
+func (w *Watcher) withheld(event *core.ServerUpdateEvent) bool {
+	if len(event.UpstreamServers) > 0 {
+		return false
+	}
+
+	policy := event.EmptyServerPolicy
+	if policy == "" {
+		policy = w.settings.Synchronizer.EmptyServerPolicy
+	}
+
+	return policy != configuration.EmptyServerPolicyApply
+}
+
+// rememberNodeAddresses records every address of the node, of any type or family, as the address of a cluster node,
+// along with the name of the node. The addresses are kept after the node is deleted, so that its servers can still be pruned.
+func (w *Watcher) rememberNodeAddresses(node *v1.Node) {
+	backup := w.backupNode(*node)
+	down := downNode(*node)
+	managed := managedNodeState(*node)
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	for _, address := range node.Status.Addresses {
+		if ip := net.ParseIP(address.Address); ip != nil {
+			w.knownNodeAddresses[ip.String()] = true
+			w.nodeNames[address.Address] = node.Name
+
+			if backup {
+				w.backupNodeAddresses[address.Address] = true
+			} else {
+				delete(w.backupNodeAddresses, address.Address)
+			}
+
+			// the servers of a node whose NodeStateKey is removed are brought up, the state of the node is still managed
+			if _, found := w.downNodeAddresses[address.Address]; managed || found {
+				w.downNodeAddresses[address.Address] = down
+			}
+		}
+	}
+}
+
+// copyNodeNames returns a copy of the names of the nodes, keyed by address, for an Event.
+func (w *Watcher) copyNodeNames() map[string]string {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	nodeNames := make(map[string]string, len(w.nodeNames))
+	for address, name := range w.nodeNames {
+		nodeNames[address] = name
+	}
+
+	return nodeNames
+}
+
+// copyBackupNodeAddresses returns a copy of the addresses of the backup nodes for an Event, nil when no backup node selector is set.
+func (w *Watcher) copyBackupNodeAddresses() map[string]bool {
+	if w.settings.Watcher.BackupNodeSelector == nil {
+		return nil
+	}
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	backupNodeAddresses := make(map[string]bool, len(w.backupNodeAddresses))
+	for address := range w.backupNodeAddresses {
+		backupNodeAddresses[address] = true
+	}
+
+	return backupNodeAddresses
+}
+
+// copyDownNodeAddresses returns a copy of the addresses of the nodes whose state NLK manages, and whether their upstream
+// servers are marked down, for an Event.
+func (w *Watcher) copyDownNodeAddresses() map[string]bool {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	downNodeAddresses := make(map[string]bool, len(w.downNodeAddresses))
+	for address, down := range w.downNodeAddresses {
+		downNodeAddresses[address] = down
+	}
+
+	return downNodeAddresses
+}
+
+// copyCordonedNodeAddresses returns a copy of the addresses of the cordoned nodes, nil in the TargetModeEndpointSlices,
+// where the nodes hosting the endpoints are the targets whether they are cordoned or not.
+func (w *Watcher) copyCordonedNodeAddresses() map[string]bool {
+	if w.useEndpointSlices {
+		return nil
+	}
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	cordonedNodeAddresses := make(map[string]bool, len(w.cordonedNodeAddresses))
+	for address, draining := range w.cordonedNodeAddresses {
+		cordonedNodeAddresses[address] = draining
+	}
+
+	return cordonedNodeAddresses
+}
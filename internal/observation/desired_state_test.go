@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_DesiredStateRequiresSyncedInformers(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if _, err := watcher.DesiredState(); err == nil {
+		t.Fatal(`expected an error before the informers are built`)
+	}
+
+	watchNamespace(t, watcher, "nginx-ingress")
+	watcher.nodeInformer, _ = watcher.buildNodeInformer()
+
+	if _, err := watcher.DesiredState(); err == nil {
+		t.Fatal(`expected an error before the informers have synced`)
+	}
+}
+
+func TestWatcher_RemembersTheAddressesOfDeletedNodes(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+	watchNamespace(t, watcher, "nginx-ingress")
+
+	node := buildNode("worker", "10.0.0.1", false)
+	node.Status.Addresses = append(node.Status.Addresses, v1.NodeAddress{Type: v1.NodeExternalIP, Address: "203.0.113.1"})
+
+	watcher.buildEventHandlerForNodeAdd()(node)
+	watcher.buildEventHandlerForNodeDelete()(node)
+
+	for _, address := range []string{"10.0.0.1", "203.0.113.1"} {
+		if !watcher.knownNodeAddresses[address] {
+			t.Errorf(`expected %s to be a known node address, got %v`, address, watcher.knownNodeAddresses)
+		}
+	}
+}
+
+func TestAddDesiredServers(t *testing.T) {
+	state := core.NewDesiredState()
+
+	addDesiredServers(state, &core.ServerUpdateEvent{
+		ClientType:      application.ClientTypeNginxHttp,
+		UpstreamName:    "nginx-http",
+		UpstreamServers: core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")},
+	})
+	addDesiredServers(state, &core.ServerUpdateEvent{
+		ClientType:   application.ClientTypeNginxUdp,
+		UpstreamName: "nginx-dns",
+	})
+
+	if !state.HttpUpstreams["nginx-http"]["10.0.0.1:30080"] {
+		t.Errorf(`expected the HTTP server to be desired, got %v`, state.HttpUpstreams)
+	}
+
+	if desired, found := state.StreamUpstreams["nginx-dns"]; !found || len(desired) != 0 {
+		t.Errorf(`expected the UDP upstream to be managed with no servers, got %v`, state.StreamUpstreams)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// endpointSliceResource is the name of the EndpointSlice resource in the discovery.k8s.io/v1 API group.
+const endpointSliceResource = "endpointslices"
+
+// checkEndpointSliceApi returns an error if the EndpointSlice API is not served by the cluster.
+func (w *Watcher) checkEndpointSliceApi() error {
+	logrus.Debug("Watcher::checkEndpointSliceApi")
+
+	groupVersion := discoveryv1.SchemeGroupVersion.String()
+
+	resources, err := w.settings.K8sClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return fmt.Errorf(`the %s target mode requires the %s EndpointSlice API: %w`, configuration.TargetModeEndpointSlices, groupVersion, err)
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == endpointSliceResource {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(`the %s target mode requires the %s EndpointSlice API, which is not served by the cluster`, configuration.TargetModeEndpointSlices, groupVersion)
+}
+
+// handleEndpointSliceEvent generates an Updated event for the Service that owns the EndpointSlice, so EndpointSlice
+// churn flows through the Handler's queue like any other change.
+func (w *Watcher) handleEndpointSliceEvent(obj interface{}) {
+	logrus.Debug("Watcher::handleEndpointSliceEvent")
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	endpointSlice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		logrus.Errorf("Watcher::handleEndpointSliceEvent: unable to cast object to EndpointSlice")
+		return
+	}
+
+	serviceName, found := endpointSlice.Labels[discoveryv1.LabelServiceName]
+	if !found {
+		return
+	}
+
+	namespaceInformers := w.namespaceInformersOf(endpointSlice.Namespace)
+	if namespaceInformers == nil {
+		return
+	}
+
+	obj, exists, err := namespaceInformers.services.GetStore().GetByKey(fmt.Sprintf("%s/%s", endpointSlice.Namespace, serviceName))
+	if err != nil || !exists {
+		return
+	}
+
+	service := obj.(*v1.Service)
+	span := startEventSpan("Watcher::EndpointSliceChanged", serviceAttribute(service))
+	defer span.End()
+
+	w.resyncService(service, span.SpanContext())
+}
+
+// retrieveTargetIps retrieves the IP Addresses of the upstream servers of the Service for the target mode.
+func (w *Watcher) retrieveTargetIps(service *v1.Service) ([]string, []string, error) {
+	if !w.useEndpointSlices {
+		return w.retrieveNodeIps()
+	}
+
+	nodeIps, err := w.retrieveEndpointNodeIps(service)
+
+	return nodeIps, nil, err
+}
+
+// retrieveEndpointNodeIps retrieves the IP Addresses of the nodes hosting a ready endpoint of the Service.
+// The translator pairs these with the Service nodePorts, which with `externalTrafficPolicy: Local` only accept
+// traffic on the nodes hosting a ready endpoint. When the Nodes cannot be watched, the addresses of the ready endpoints
+// are used instead, see endpointAddresses.
+func (w *Watcher) retrieveEndpointNodeIps(service *v1.Service) ([]string, error) {
+	logrus.Debug("Watcher::retrieveEndpointNodeIps")
+
+	endpointSlices, err := w.listEndpointSlices(service)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.endpointNodes {
+		return w.endpointAddresses(endpointSlices), nil
+	}
+
+	nodeNames := make(map[string]bool)
+	for _, endpointSlice := range endpointSlices {
+		for _, endpoint := range endpointSlice.Endpoints {
+			ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+			if ready && endpoint.NodeName != nil {
+				nodeNames[*endpoint.NodeName] = true
+			}
+		}
+	}
+
+	var nodeIps []string
+	for nodeName := range nodeNames {
+		obj, exists, err := w.nodes().GetStore().GetByKey(nodeName)
+		if err != nil || !exists {
+			logrus.WithFields(logrus.Fields{"node": nodeName, "service": service.Namespace + "/" + service.Name}).
+				Debug("Watcher::retrieveEndpointNodeIps: node was not found or does not match the node selector")
+			continue
+		}
+
+		node := obj.(*v1.Node)
+		w.rememberNodeAddresses(node)
+
+		if w.excludedNode(*node) {
+			continue
+		}
+
+		nodeIps = append(nodeIps, w.nodeAddresses(*node)...)
+	}
+
+	sort.Strings(nodeIps)
+
+	return nodeIps, nil
+}
+
+// listEndpointSlices lists the EndpointSlices of the Service from the cache of the informer of its namespace.
+func (w *Watcher) listEndpointSlices(service *v1.Service) ([]*discoveryv1.EndpointSlice, error) {
+	namespaceInformers := w.namespaceInformersOf(service.Namespace)
+	if namespaceInformers == nil || namespaceInformers.endpointSlices == nil {
+		return nil, fmt.Errorf(`the namespace of service %s/%s is not watched`, service.Namespace, service.Name)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.Name})
+
+	endpointSlices, err := discoverylisters.NewEndpointSliceLister(namespaceInformers.endpointSlices.GetIndexer()).EndpointSlices(service.Namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf(`error occurred listing the endpoint slices of service %s/%s: %w`, service.Namespace, service.Name, err)
+	}
+
+	return endpointSlices, nil
+}
+
+// retrieveTargetPorts retrieves the port numbers of the endpoints of the Service, by the name of the Service port, which
+// resolve its named targetPorts, see core.Event::TargetPorts; nil when the EndpointSlices are not watched.
+func (w *Watcher) retrieveTargetPorts(service *v1.Service) map[string]int32 {
+	if !w.useEndpointSlices {
+		return nil
+	}
+
+	endpointSlices, err := w.listEndpointSlices(service)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"service": service.Namespace + "/" + service.Name}).WithError(err).
+			Debug("Watcher::retrieveTargetPorts: the ports of the endpoints are not known")
+		return nil
+	}
+
+	targetPorts := make(map[string]int32)
+	for _, endpointSlice := range endpointSlices {
+		for _, port := range endpointSlice.Ports {
+			if port.Port == nil {
+				continue
+			}
+
+			name := ""
+			if port.Name != nil {
+				name = *port.Name
+			}
+
+			targetPorts[name] = *port.Port
+		}
+	}
+
+	return targetPorts
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_CheckEndpointSliceApi(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	watcher := buildEndpointSliceWatcher(t, k8sClient, &mocks.MockHandler{})
+
+	if err := watcher.checkEndpointSliceApi(); err == nil {
+		t.Fatalf(`expected an error when the EndpointSlice API is not served`)
+	}
+
+	k8sClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: discoveryv1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{{Name: endpointSliceResource}},
+		},
+	}
+
+	if err := watcher.checkEndpointSliceApi(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+}
+
+func TestWatcher_InitializeFallsBackWithoutEndpointSliceApi(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	settings.Watcher.TargetMode = configuration.TargetModeEndpointSlices
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if watcher.useEndpointSlices || watcher.settings.Watcher.TargetMode != configuration.TargetModeNodes {
+		t.Fatalf(`expected the watcher to fall back to the %s target mode`, configuration.TargetModeNodes)
+	}
+}
+
+func TestWatcher_RetrieveEndpointNodeIpsUsesReadyEndpoints(t *testing.T) {
+	watcher := buildEndpointSliceWatcher(t, fake.NewSimpleClientset(), &mocks.MockHandler{})
+	service := buildEndpointSliceService()
+
+	addNodes(t, watcher, buildNode("ready", "10.0.0.1", false), buildNode("not-ready", "10.0.0.2", false), buildNode("idle", "10.0.0.3", false))
+	addEndpointSlice(t, watcher, service, map[string]bool{"ready": true, "not-ready": false})
+
+	nodeIps, err := watcher.retrieveEndpointNodeIps(service)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected only the node hosting a ready endpoint, got %v`, nodeIps)
+	}
+}
+
+func TestWatcher_EndpointSliceEventQueuesServiceUpdate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	watcher := buildEndpointSliceWatcher(t, fake.NewSimpleClientset(), handler)
+	service := buildEndpointSliceService()
+
+	if err := watcher.namespaceInformersOf(service.Namespace).services.GetStore().Add(service); err != nil {
+		t.Fatalf(`error adding the service: %v`, err)
+	}
+
+	addNodes(t, watcher, buildNode("ready", "10.0.0.1", false))
+	endpointSlice := addEndpointSlice(t, watcher, service, map[string]bool{"ready": true})
+
+	name, port := "nlk-https", int32(8443)
+	endpointSlice.Ports = []discoveryv1.EndpointPort{{Name: &name, Port: &port}}
+
+	watcher.handleEndpointSliceEvent(endpointSlice)
+
+	if len(handler.Events) != 1 {
+		t.Fatalf(`expected 1 event, got %d`, len(handler.Events))
+	}
+
+	if !reflect.DeepEqual(handler.Events[0].NodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected the event to target the node hosting the endpoint, got %v`, handler.Events[0].NodeIps)
+	}
+
+	if !reflect.DeepEqual(handler.Events[0].TargetPorts, map[string]int32{"nlk-https": 8443}) {
+		t.Errorf(`expected the event to carry the ports of the endpoints, got %v`, handler.Events[0].TargetPorts)
+	}
+}
+
+func buildEndpointSliceWatcher(t *testing.T, k8sClient *fake.Clientset, handler *mocks.MockHandler) *Watcher {
+	settings, err := configuration.NewSettings(context.Background(), k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Watcher.TargetMode = configuration.TargetModeEndpointSlices
+
+	watcher, _ := NewWatcher(settings, handler)
+	watcher.useEndpointSlices = true
+	watcher.nodeInformer, _ = watcher.buildNodeInformer()
+	watchNamespace(t, watcher, "nginx-ingress")
+
+	return watcher
+}
+
+func buildEndpointSliceService() *v1.Service {
+	return &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}}
+}
+
+func addNodes(t *testing.T, watcher *Watcher, nodes ...*v1.Node) {
+	for _, node := range nodes {
+		if err := watcher.nodeInformer.GetStore().Add(node); err != nil {
+			t.Fatalf(`error adding the node: %v`, err)
+		}
+	}
+}
+
+func addEndpointSlice(t *testing.T, watcher *Watcher, service *v1.Service, readiness map[string]bool) *discoveryv1.EndpointSlice {
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service.Name + "-abcde",
+			Namespace: service.Namespace,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service.Name},
+		},
+	}
+
+	for nodeName, ready := range readiness {
+		nodeName, ready := nodeName, ready
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{
+			NodeName:   &nodeName,
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		})
+	}
+
+	if err := watcher.namespaceInformersOf(service.Namespace).endpointSlices.GetIndexer().Add(endpointSlice); err != nil {
+		t.Fatalf(`error adding the endpoint slice: %v`, err)
+	}
+
+	return endpointSlice
+}
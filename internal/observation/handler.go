@@ -6,9 +6,14 @@
 package observation
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
+
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/synchronization"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/translation"
 	"github.com/sirupsen/logrus"
@@ -43,23 +48,129 @@ type Handler struct {
 
 	// synchronizer is the synchronizer used to synchronize the internal representation with a Border Server
 	synchronizer synchronization.Interface
+
+	// backlog tracks the events waiting in the event queue, see instrumentation.QueueBacklog.
+	backlog *instrumentation.QueueBacklog
+
+	// pendingLock guards pending.
+	pendingLock sync.Mutex
+
+	// pending holds the event of each Service waiting in the event queue, by namespace/name, along with the copy into
+	// which the newer Updated events of the Service are merged once the queue is full, see mergeIntoPendingEvent.
+	pending map[string]*pendingEvent
+
+	// deferredDeletes holds the Deleted events of the Services in case they are recreated, see HandlerSettings::DeleteDeferral.
+	deferredDeletes *deferredDeletes
 }
 
 // NewHandler creates a new event handler
 func NewHandler(settings *configuration.Settings, synchronizer synchronization.Interface, eventQueue workqueue.RateLimitingInterface) *Handler {
+	workQueueSettings := settings.Handler.WorkQueueSettings
+
 	return &Handler{
-		eventQueue:   eventQueue,
-		settings:     settings,
-		synchronizer: synchronizer,
+		eventQueue:      eventQueue,
+		settings:        settings,
+		synchronizer:    synchronizer,
+		backlog:         instrumentation.NewQueueBacklog(workQueueSettings.Name, workQueueSettings.MaxDepth, workQueueSettings.DegradedAge),
+		pending:         make(map[string]*pendingEvent),
+		deferredDeletes: newDeferredDeletes(),
 	}
 }
 
-// AddRateLimitedEvent adds an event to the event queue
+// AddRateLimitedEvent adds an event to the event queue, unless the queue is full and the event is merged into the pending
+// event of its Service, see mergeIntoPendingEvent. The Deleted events are held for the HandlerSettings::DeleteDeferral,
+// and replaced with an Updated event when their Service is recreated meanwhile, see deferDelete.
 func (h *Handler) AddRateLimitedEvent(event *core.Event) {
-	logrus.Debugf(`Handler::AddRateLimitedEvent: %#v`, event)
+	logrus.WithFields(event.LogFields()).Debug(`Handler::AddRateLimitedEvent`)
+
+	if h.deferDelete(event) {
+		return
+	}
+
+	h.addEvent(h.replaceDeferredDelete(event))
+}
+
+// addEvent adds an event to the event queue, or merges it into the pending event of its Service.
+func (h *Handler) addEvent(event *core.Event) {
+	if h.mergeIntoPendingEvent(event) {
+		logrus.WithFields(event.LogFields()).Debug(`Handler::AddRateLimitedEvent: the queue is full, merged into the pending event of the service`)
+		h.backlog.Coalesced()
+		return
+	}
+
+	event.QueuedAt = time.Now()
+	h.backlog.Queued(event, h.settings.Handler.WorkQueueSettings.Backoff(0))
 	h.eventQueue.AddRateLimited(event)
 }
 
+// Degradation returns why the replica is degraded, e.g. the oldest event has waited in the queue for too long, or an
+// empty string.
+func (h *Handler) Degradation() string {
+	return h.backlog.Degradation()
+}
+
+// pendingEvent is the event of a Service waiting in the event queue.
+type pendingEvent struct {
+
+	// queued is the event added to the queue, it is left as it was queued.
+	queued *core.Event
+
+	// merged is the event the worker handles once it takes the queued event, with the newer Updated events merged into it.
+	merged *core.Event
+}
+
+// mergeIntoPendingEvent merges an Updated event into the event of the same Service still waiting in the queue, once the
+// queue holds MaxDepth events: the pending event takes the current state of the Service, and keeps its type and the
+// previous state of the Service, so only the intermediate state is dropped. The events are never merged across a Deleted
+// event. The merged event is a copy, the queued event is never changed, see takePendingEvent. Returns false if the event
+// is to be queued, it is then the pending event of its Service.
+func (h *Handler) mergeIntoPendingEvent(event *core.Event) bool {
+	h.pendingLock.Lock()
+	defer h.pendingLock.Unlock()
+
+	key := pendingEventKey(event)
+
+	if pending, found := h.pending[key]; found && event.Type == core.Updated && pending.merged.Type != core.Deleted && h.backlog.Full() {
+		merged := *event
+		merged.Type = pending.merged.Type
+		merged.PreviousService = pending.merged.PreviousService
+		merged.PreviousNodeIps = pending.merged.PreviousNodeIps
+		merged.SpanContext = pending.merged.SpanContext
+		merged.QueuedAt = pending.merged.QueuedAt
+		merged.ObservedAt = pending.merged.ObservedAt
+		merged.Resync = event.Resync || pending.merged.Resync
+		pending.merged = &merged
+
+		return true
+	}
+
+	h.pending[key] = &pendingEvent{queued: event, merged: event}
+
+	return false
+}
+
+// takePendingEvent records that a worker took the event from the queue, no newer event is merged into it from then on.
+// Returns the event to handle, the copy the newer events of the Service were merged into, if any.
+func (h *Handler) takePendingEvent(event *core.Event) *core.Event {
+	h.pendingLock.Lock()
+	defer h.pendingLock.Unlock()
+
+	h.backlog.Taken(event)
+
+	key := pendingEventKey(event)
+	if pending, found := h.pending[key]; found && pending.queued == event {
+		delete(h.pending, key)
+		return pending.merged
+	}
+
+	return event
+}
+
+// pendingEventKey identifies the Service of the event, by namespace/name.
+func pendingEventKey(event *core.Event) string {
+	return event.Service.Namespace + "/" + event.Service.Name
+}
+
 // Run starts the event handler, spins up Goroutines to process events, and waits for a stop signal
 func (h *Handler) Run(stopCh <-chan struct{}) {
 	logrus.Debug("Handler::Run")
@@ -68,30 +179,88 @@ func (h *Handler) Run(stopCh <-chan struct{}) {
 		go wait.Until(h.worker, 0, stopCh)
 	}
 
+	go wait.Until(h.backlog.Observe, instrumentation.BacklogObserveInterval, stopCh)
+
 	<-stopCh
 }
 
-// ShutDown stops the event handler and shuts down the event queue
+// ShutDown applies the held Deleted events, see releaseDeferredDeletes, then shuts down the event queue
 func (h *Handler) ShutDown() {
 	logrus.Debug("Handler::ShutDown")
+	h.releaseDeferredDeletes()
 	h.eventQueue.ShutDown()
 }
 
 // handleEvent feeds translated events to the synchronizer
 func (h *Handler) handleEvent(e *core.Event) error {
-	logrus.Debugf(`Handler::handleEvent: %#v`, e)
+	logrus.WithFields(e.LogFields()).Debug(`Handler::handleEvent`)
 	// TODO: Add Telemetry
 
-	events, err := translation.Translate(e)
+	events, err := h.translate(e)
 	if err != nil {
 		return fmt.Errorf(`Handler::handleEvent error translating: %v`, err)
 	}
 
-	h.synchronizer.AddEvents(events)
+	if len(events) > 0 {
+		h.synchronizer.AddEvents(events)
+	}
 
 	return nil
 }
 
+// translate translates the event into the events of the upstreams, none for a Service that is ignored.
+func (h *Handler) translate(e *core.Event) (core.ServerUpdateEvents, error) {
+	e = h.withoutIgnoredServices(e)
+	if e == nil {
+		return nil, nil
+	}
+
+	_, span := instrumentation.StartSpan(context.Background(), e.SpanContext, "Handler::translate")
+	events, err := translation.Translate(e, h.settings.EventRecorder)
+	instrumentation.EndSpan(span, err)
+
+	return events, err
+}
+
+// withoutIgnoredServices returns the event to translate for a Service that may be annotated with the IgnoreAnnotation,
+// or nil when there is nothing to synchronize. A Service that becomes ignored has the servers of its previous state
+// deleted, and a Service that is no longer ignored is synchronized in full, as if it had just been created.
+func (h *Handler) withoutIgnoredServices(e *core.Event) *core.Event {
+	ignored := translation.IsIgnored(e.Service, h.settings.EventRecorder)
+
+	// the previous state of the Service was checked when it was handled, so no Warning Event is recorded again
+	wasIgnored := e.PreviousService != nil && translation.IsIgnored(e.PreviousService, nil)
+	wasSynchronized := e.Type == core.Updated && e.PreviousService != nil && !wasIgnored
+
+	switch {
+	case !ignored && wasIgnored:
+		logrus.WithFields(e.LogFields()).Info(`Handler::withoutIgnoredServices: service is no longer ignored, synchronizing it`)
+
+		created := *e
+		created.PreviousService = nil
+
+		return &created
+
+	case !ignored:
+		return e
+
+	case wasSynchronized:
+		logrus.WithFields(e.LogFields()).Info(`Handler::withoutIgnoredServices: service is now ignored, removing its servers`)
+
+		deleted := *e
+		deleted.Type = core.Deleted
+		deleted.Service = e.PreviousService
+		deleted.PreviousService = nil
+
+		return &deleted
+
+	default:
+		logrus.WithFields(e.LogFields()).Debug(`Handler::withoutIgnoredServices: service is ignored`)
+
+		return nil
+	}
+}
+
 // handleNextEvent pulls an event from the event queue and feeds it to the event handler with retry logic
 func (h *Handler) handleNextEvent() bool {
 	logrus.Debug("Handler::handleNextEvent")
@@ -103,9 +272,17 @@ func (h *Handler) handleNextEvent() bool {
 
 	defer h.eventQueue.Done(evt)
 
-	event := evt.(*core.Event)
+	queued := evt.(*core.Event)
+	event := h.takePendingEvent(queued)
+	instrumentation.RecordQueueWait(event.SpanContext, "Handler::queue", event.QueuedAt)
 	h.withRetry(h.handleEvent(event), event)
 
+	// the merged copy is the one requeued on error, e.g. the update replacing a deferred delete with the newer updates
+	// merged into it, so the rate limiter forgets the queued event rather than keep its backoff history
+	if event != queued {
+		h.eventQueue.Forget(queued)
+	}
+
 	return true
 }
 
@@ -122,11 +299,13 @@ func (h *Handler) withRetry(err error, event *core.Event) {
 	if err != nil {
 		// TODO: Add Telemetry
 		if h.eventQueue.NumRequeues(event) < h.settings.Handler.RetryCount {
+			event.QueuedAt = time.Now()
+			h.backlog.Queued(event, h.settings.Handler.WorkQueueSettings.Backoff(h.eventQueue.NumRequeues(event)))
 			h.eventQueue.AddRateLimited(event)
-			logrus.Infof(`Handler::withRetry: requeued event: %#v; error: %v`, event, err)
+			logrus.WithFields(event.LogFields()).WithError(err).Info(`Handler::withRetry: requeued event`)
 		} else {
 			h.eventQueue.Forget(event)
-			logrus.Warnf(`Handler::withRetry: event %#v has been dropped due to too many retries`, event)
+			logrus.WithFields(event.LogFields()).WithError(err).Warn(`Handler::withRetry: event has been dropped due to too many retries`)
 		}
 	} // TODO: Add error logging
 }
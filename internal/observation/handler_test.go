@@ -12,6 +12,7 @@ import (
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/workqueue"
 	"testing"
 )
@@ -44,6 +45,106 @@ func TestHandler_AddsEventToSynchronizer(t *testing.T) {
 	}
 }
 
+func TestHandler_IgnoredServices(t *testing.T) {
+	synchronized := buildIgnorableService("false")
+	ignored := buildIgnorableService("true")
+
+	tests := []struct {
+		name         string
+		event        *core.Event
+		expectedType core.EventType
+		expected     int
+	}{
+		{"created while ignored", &core.Event{Type: core.Created, Service: ignored, NodeIps: []string{"10.0.0.1"}}, core.Created, 0},
+		{"resynchronized while ignored", &core.Event{Type: core.Updated, Service: ignored, PreviousService: ignored, NodeIps: []string{"10.0.0.1"}}, core.Updated, 0},
+		{"deleted while ignored", &core.Event{Type: core.Deleted, Service: ignored, NodeIps: []string{"10.0.0.1"}}, core.Deleted, 0},
+		{"becomes ignored", &core.Event{Type: core.Updated, Service: ignored, PreviousService: synchronized, NodeIps: []string{"10.0.0.1"}}, core.Deleted, 1},
+		{"no longer ignored", &core.Event{Type: core.Updated, Service: synchronized, PreviousService: ignored, NodeIps: []string{"10.0.0.1"}}, core.Updated, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, synchronizer, handler, err := buildHandler()
+			if err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			if err := handler.handleEvent(test.event); err != nil {
+				t.Fatalf(`should have been no error, %v`, err)
+			}
+
+			if len(synchronizer.Events) != test.expected {
+				t.Fatalf(`expected %d events, got %d`, test.expected, len(synchronizer.Events))
+			}
+
+			for _, event := range synchronizer.Events {
+				if event.Type != test.expectedType {
+					t.Errorf(`expected a %v event, got %v`, test.expectedType, event.Type)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_MergesTheUpdatesOfAServiceOnceTheQueueIsFull(t *testing.T) {
+	settings, eventQueue, _, handler, err := buildHandler()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Handler.WorkQueueSettings.MaxDepth = 1
+	settings.Handler.DeleteDeferral = 0
+	handler = NewHandler(settings, &mocks.MockSynchronizer{}, eventQueue)
+
+	original := buildIgnorableService("false")
+	scaled := original.DeepCopy()
+	scaled.ResourceVersion = "2"
+	rescaled := original.DeepCopy()
+	rescaled.ResourceVersion = "3"
+
+	first := &core.Event{Type: core.Updated, Service: scaled, PreviousService: original, NodeIps: []string{"10.0.0.1"}}
+	handler.AddRateLimitedEvent(first)
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Updated, Service: rescaled, PreviousService: scaled, NodeIps: []string{"10.0.0.1", "10.0.0.2"}})
+
+	if eventQueue.Len() != 1 {
+		t.Fatalf(`expected the update to be merged into the pending event, got %d events`, eventQueue.Len())
+	}
+
+	// the queued event is left alone, the worker handles the merged copy
+	if first.Service != scaled || len(first.NodeIps) != 1 {
+		t.Fatalf(`expected the queued event to be left unchanged, got %#v`, first)
+	}
+
+	merged := handler.takePendingEvent(first)
+	if merged == first || merged.Service != rescaled || merged.PreviousService != original || len(merged.NodeIps) != 2 {
+		t.Fatalf(`expected the pending event to go from the original to the latest state, got %#v`, merged)
+	}
+
+	if taken := handler.takePendingEvent(first); taken != first {
+		t.Fatalf(`expected nothing to be merged into the event once taken, got %#v`, taken)
+	}
+
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Deleted, Service: rescaled, NodeIps: []string{"10.0.0.1", "10.0.0.2"}})
+	handler.AddRateLimitedEvent(&core.Event{Type: core.Updated, Service: rescaled, PreviousService: rescaled, NodeIps: []string{"10.0.0.1"}})
+
+	if eventQueue.Len() != 3 {
+		t.Fatalf(`expected the events not to be merged across a deletion, got %d events`, eventQueue.Len())
+	}
+}
+
+func buildIgnorableService(ignore string) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx-ingress-metrics",
+			Namespace:   "nginx-ingress",
+			Annotations: map[string]string{"nginxinc.io/ignore": ignore},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "nlk-metrics", NodePort: 30913}},
+		},
+	}
+}
+
 func buildHandler() (*configuration.Settings, workqueue.RateLimitingInterface, *mocks.MockSynchronizer, *Handler, error) {
 	settings, err := configuration.NewSettings(context.Background(), nil)
 	if err != nil {
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// namespaceInformers are the informers of a watched namespace, e.g. the namespace of an NGINX Ingress Controller installation,
+// or of every namespace when the Services are selected by label.
+type namespaceInformers struct {
+
+	// services is the informer used to watch for changes to the Services of the namespace
+	services cache.SharedIndexInformer
+
+	// endpointSlices is the informer used to watch for changes to the EndpointSlices of the namespace, nil unless the
+	// target mode is TargetModeEndpointSlices
+	endpointSlices cache.SharedIndexInformer
+
+	// ctx is done once the namespace is no longer watched, or NLK is shutting down
+	ctx context.Context
+
+	// cancel stops the informers
+	cancel context.CancelFunc
+}
+
+// run starts the informers and waits for them to sync, the EndpointSlices are synced before the Services so the first
+// events for the Services have their upstream servers. Returns false if the informers were stopped, or the context was
+// done, before they synced; the informers keep running in the latter case.
+func (n *namespaceInformers) run(ctx context.Context, name string) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(n.ctx, cancel)
+	defer stop()
+
+	if n.endpointSlices != nil {
+		go n.endpointSlices.Run(n.ctx.Done())
+
+		if !cache.WaitForNamedCacheSync(name, ctx.Done(), n.endpointSlices.HasSynced) {
+			return false
+		}
+	}
+
+	go n.services.Run(n.ctx.Done())
+
+	return cache.WaitForNamedCacheSync(name, ctx.Done(), n.services.HasSynced)
+}
+
+// hasSynced determines whether the informers of the namespace have synced.
+func (n *namespaceInformers) hasSynced() bool {
+	return n.services.HasSynced() && (n.endpointSlices == nil || n.endpointSlices.HasSynced())
+}
+
+// buildNamespaceInformers creates the informers of the namespace, with their event handlers.
+func (w *Watcher) buildNamespaceInformers(namespace string) (*namespaceInformers, error) {
+	logrus.WithField("namespace", namespace).Debug("Watcher::buildNamespaceInformers")
+
+	options := []informers.SharedInformerOption{informers.WithNamespace(namespace)}
+
+	// the EndpointSlices carry the labels of their Service, so the selector filters both
+	if w.selectsServices() {
+		options = append(options, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = w.serviceSelector.String()
+		}))
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(w.settings.K8sClient, w.resyncPeriod, options...)
+	ctx, cancel := context.WithCancel(w.settings.Context)
+
+	namespaceInformers := &namespaceInformers{
+		services: factory.Core().V1().Services().Informer(),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.buildEventHandlerForAdd(),
+		DeleteFunc: w.buildEventHandlerForDelete(),
+		UpdateFunc: w.buildEventHandlerForUpdate(),
+	}
+
+	if _, err := namespaceInformers.services.AddEventHandler(handlers); err != nil {
+		cancel()
+		return nil, fmt.Errorf(`error occurred adding event handlers: %w`, err)
+	}
+
+	if w.useEndpointSlices {
+		namespaceInformers.endpointSlices = factory.Discovery().V1().EndpointSlices().Informer()
+
+		endpointSliceHandlers := cache.ResourceEventHandlerFuncs{
+			AddFunc:    w.handleEndpointSliceEvent,
+			DeleteFunc: w.handleEndpointSliceEvent,
+			UpdateFunc: func(_, updated interface{}) { w.handleEndpointSliceEvent(updated) },
+		}
+
+		if _, err := namespaceInformers.endpointSlices.AddEventHandler(endpointSliceHandlers); err != nil {
+			cancel()
+			return nil, fmt.Errorf(`error occurred adding endpoint slice event handlers: %w`, err)
+		}
+	}
+
+	return namespaceInformers, nil
+}
+
+// syncNamespaces starts watching the namespaces added to the WatcherSettings::NginxIngressNamespaces setting, and stops
+// watching the namespaces removed from it. The servers of the Services in a removed namespace are deleted, as if the
+// Services had been deleted. It is invoked by the Settings when the watched namespaces change.
+// When the Services are selected by label, a single informer watches every namespace and the setting is ignored, unless
+// the RBAC mode is scoped, the Services matching the selector are then only watched in the namespaces of the setting.
+func (w *Watcher) syncNamespaces() {
+	desired := w.settings.Watcher.NginxIngressNamespaces
+	if w.selectsServices() && !w.scoped {
+		desired = []string{metav1.NamespaceAll}
+		logrus.Infof("Watcher::syncNamespaces: watching the Services matching %q in every namespace", w.serviceSelector.String())
+	} else if w.selectsServices() {
+		logrus.Infof("Watcher::syncNamespaces: watching the Services matching %q in the %v namespace(s)", w.serviceSelector.String(), desired)
+	} else {
+		logrus.Infof("Watcher::syncNamespaces: watching the %v namespace(s)", desired)
+	}
+
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	for name, namespaceInformers := range w.namespaces {
+		if !slices.Contains(desired, name) {
+			delete(w.namespaces, name)
+			w.stopNamespace(name, namespaceInformers)
+		}
+	}
+
+	for _, name := range desired {
+		if _, found := w.namespaces[name]; found {
+			continue
+		}
+
+		namespaceInformers, err := w.buildNamespaceInformers(name)
+		if err != nil {
+			logrus.WithField("namespace", name).WithError(err).Error(`Watcher::syncNamespaces: error occurred building the informers, the namespace is not watched`)
+			continue
+		}
+
+		w.namespaces[name] = namespaceInformers
+
+		// the informers of the initial namespaces are started by Start
+		if w.watching {
+			go w.runNamespace(name, namespaceInformers)
+		}
+	}
+}
+
+// runNamespace starts the informers of a namespace added while watching.
+func (w *Watcher) runNamespace(name string, namespaceInformers *namespaceInformers) {
+	if namespaceInformers.run(w.settings.Context, name) {
+		logrus.WithField("namespace", name).Info("Watcher::runNamespace: the namespace is being watched")
+	}
+}
+
+// stopNamespace stops the informers of a namespace that is no longer watched, and generates a Deleted event for each of its Services.
+func (w *Watcher) stopNamespace(name string, namespaceInformers *namespaceInformers) {
+	namespaceInformers.cancel()
+
+	services := namespaceInformers.services.GetStore().List()
+	logrus.WithField("namespace", name).Infof("Watcher::stopNamespace: the namespace is no longer watched, removing the servers of %d service(s)", len(services))
+
+	if len(services) == 0 {
+		return
+	}
+
+	// every node is used regardless of the target mode, as for a deleted Service
+	nodeIps, drainingNodeIps, err := w.retrieveNodeIps()
+	if err != nil {
+		logrus.WithField("namespace", name).WithError(err).Error(`Watcher::stopNamespace: error occurred retrieving node ips`)
+		return
+	}
+
+	span := startEventSpan("Watcher::NamespaceRemoved", instrumentation.NamespaceAttribute.String(name))
+	defer span.End()
+
+	for _, obj := range services {
+		e := w.newEvent(core.Deleted, obj.(*v1.Service), nil, nodeIps, drainingNodeIps)
+		e.SpanContext = span.SpanContext()
+		w.addEvent(&e)
+	}
+}
+
+// watchedNamespaces returns the informers of the watched namespaces.
+func (w *Watcher) watchedNamespaces() map[string]*namespaceInformers {
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	namespaces := make(map[string]*namespaceInformers, len(w.namespaces))
+	for name, namespaceInformers := range w.namespaces {
+		namespaces[name] = namespaceInformers
+	}
+
+	return namespaces
+}
+
+// namespaceInformersOf returns the informers watching the namespace, or nil if the namespace is not watched.
+func (w *Watcher) namespaceInformersOf(namespace string) *namespaceInformers {
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	if namespaceInformers, found := w.namespaces[namespace]; found {
+		return namespaceInformers
+	}
+
+	return w.namespaces[metav1.NamespaceAll]
+}
+
+// selectsServices determines whether the Services are selected by label in every namespace, rather than by namespace.
+func (w *Watcher) selectsServices() bool {
+	return w.serviceSelector != nil && !w.serviceSelector.Empty()
+}
+
+// watchedServices returns the Services of every watched namespace, sorted by namespace and name.
+func (w *Watcher) watchedServices() []*v1.Service {
+	var services []*v1.Service
+
+	for _, namespaceInformers := range w.watchedNamespaces() {
+		for _, obj := range namespaceInformers.services.GetStore().List() {
+			services = append(services, obj.(*v1.Service))
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Namespace != services[j].Namespace {
+			return services[i].Namespace < services[j].Namespace
+		}
+
+		return services[i].Name < services[j].Name
+	})
+
+	return services
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_SyncNamespacesWatchesEachNamespace(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress-public", "nginx-ingress-internal"}
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	watcher.syncNamespaces()
+
+	for _, namespace := range settings.Watcher.NginxIngressNamespaces {
+		if watcher.namespaceInformersOf(namespace) == nil {
+			t.Errorf(`expected the %s namespace to be watched`, namespace)
+		}
+	}
+}
+
+func TestWatcher_SyncNamespacesDeletesTheServersOfRemovedNamespaces(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false)))
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress-public", "nginx-ingress-internal"}
+	watcher, _ := NewWatcher(settings, handler)
+
+	watcher.syncNamespaces()
+
+	removed := watcher.namespaceInformersOf("nginx-ingress-internal")
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress-internal"}}
+	if err := removed.services.GetStore().Add(service); err != nil {
+		t.Fatalf(`error adding the service: %v`, err)
+	}
+
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress-public"}
+	watcher.syncNamespaces()
+
+	if watcher.namespaceInformersOf("nginx-ingress-internal") != nil || removed.ctx.Err() == nil {
+		t.Fatal(`expected the informers of the removed namespace to be stopped`)
+	}
+
+	if watcher.namespaceInformersOf("nginx-ingress-public") == nil {
+		t.Fatal(`expected the remaining namespace to still be watched`)
+	}
+
+	if len(handler.Events) != 1 {
+		t.Fatalf(`expected 1 event, got %d`, len(handler.Events))
+	}
+
+	event := handler.Events[0]
+	if event.Type != core.Deleted || event.Service.Name != "nginx-ingress" || !reflect.DeepEqual(event.NodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected a Deleted event for the service on every node, got %#v`, event)
+	}
+}
+
+func TestWatcher_SyncNamespacesWatchesEveryNamespaceWithAServiceSelector(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+	watcher.serviceSelector = labels.SelectorFromSet(labels.Set{"nkl.nginx.com/managed": "true"})
+
+	watcher.syncNamespaces()
+
+	if len(watcher.namespaces) != 1 || watcher.namespaces[metav1.NamespaceAll] == nil {
+		t.Fatalf(`expected a single informer for every namespace, got %v`, watcher.namespaces)
+	}
+
+	if watcher.namespaceInformersOf("team-a") != watcher.namespaces[metav1.NamespaceAll] {
+		t.Errorf(`expected the Services of any namespace to be found with the cluster-wide informer`)
+	}
+}
+
+func TestWatcher_EventsCarryTheUpstreamNameTemplate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	watcher, _ := NewWatcher(settings, handler)
+	watcher.upstreamNameTemplate = "{namespace}-{name}"
+
+	watcher.resyncService(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}}, trace.SpanContext{})
+
+	if len(handler.Events) != 1 || handler.Events[0].UpstreamNameTemplate != "{namespace}-{name}" {
+		t.Fatalf(`expected the event to carry the upstream name template, got %#v`, handler.Events)
+	}
+}
+
+// watchNamespace builds the informers of the namespace without starting them, so tests can populate their stores.
+func watchNamespace(t *testing.T, watcher *Watcher, namespace string) *namespaceInformers {
+	namespaceInformers, err := watcher.buildNamespaceInformers(namespace)
+	if err != nil {
+		t.Fatalf(`error building the informers of the %s namespace: %v`, namespace, err)
+	}
+
+	watcher.namespaces[namespace] = namespaceInformers
+
+	return namespaceInformers
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// watchVerbs are the verbs an informer requires.
+var watchVerbs = []string{"list", "watch"}
+
+// accessReviewer determines whether NLK may perform the verb on the resource of the API group in the namespace,
+// metav1.NamespaceAll for every namespace, or for a cluster-scoped resource.
+type accessReviewer func(verb string, group string, resource string, namespace string) (bool, error)
+
+// resolveRbacMode determines, from the WatcherSettings::RbacMode, and from the permissions of NLK for RbacModeAuto,
+// whether the Services are only watched in the NginxIngressNamespaces, and whether the Nodes can be watched at all;
+// without them the upstream servers are the addresses of the ready endpoints of each Service.
+func (w *Watcher) resolveRbacMode() error {
+	rbacMode := w.settings.Watcher.RbacMode
+
+	if rbacMode == configuration.RbacModeAuto {
+		rbacMode = configuration.RbacModeScoped
+		if w.mayWatch(discoveryv1.GroupName, endpointSliceResource, metav1.NamespaceAll) && w.mayWatch("", "services", metav1.NamespaceAll) {
+			rbacMode = configuration.RbacModeCluster
+		}
+	}
+
+	w.scoped = rbacMode == configuration.RbacModeScoped
+	w.endpointNodes = w.scoped && !w.mayWatch("", "nodes", metav1.NamespaceAll)
+
+	if w.endpointNodes {
+		if err := w.checkEndpointSliceApi(); err != nil {
+			return fmt.Errorf(`NLK may not list the Nodes, and cannot use the addresses of the endpoints instead: %w`, err)
+		}
+
+		if w.settings.Watcher.TargetMode != configuration.TargetModeEndpointSlices {
+			logrus.Warnf(`Watcher::resolveRbacMode: the %s target mode requires listing the Nodes, the addresses of the ready endpoints are used instead`, w.settings.Watcher.TargetMode)
+		}
+
+		w.settings.Watcher.TargetMode = configuration.TargetModeEndpointSlices
+		w.useEndpointSlices = true
+	}
+
+	services, nodes := "in every namespace", "the addresses of the Nodes"
+	if w.scoped {
+		services = fmt.Sprintf("in the %v namespace(s)", w.settings.Watcher.NginxIngressNamespaces)
+	}
+
+	if w.endpointNodes {
+		nodes = "the addresses of the ready endpoints, the node selectors and labels are ignored"
+	}
+
+	logrus.Infof(`Watcher::resolveRbacMode: using the %s RBAC mode (%s), watching the Services %s, the upstream servers are %s`,
+		rbacMode, w.settings.Watcher.RbacMode, services, nodes)
+
+	return nil
+}
+
+// mayWatch determines whether NLK may list and watch the resource in the namespace. A permission that cannot be reviewed
+// is assumed granted, as NLK did before the RBAC modes, and the informer reports the failure if it is not.
+func (w *Watcher) mayWatch(group string, resource string, namespace string) bool {
+	for _, verb := range watchVerbs {
+		allowed, err := w.reviewAccess(verb, group, resource, namespace)
+		if err != nil {
+			logrus.WithError(err).Warnf(`Watcher::mayWatch: error occurred reviewing the access to %s, assuming it is granted`, resource)
+			return true
+		}
+
+		if !allowed {
+			logrus.Debugf(`Watcher::mayWatch: NLK may not %s %s in %q`, verb, resource, namespace)
+			return false
+		}
+	}
+
+	return true
+}
+
+// reviewSelfAccess determines whether NLK may perform the verb on the resource, with a SelfSubjectAccessReview.
+func (w *Watcher) reviewSelfAccess(verb string, group string, resource string, namespace string) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Namespace: namespace, Verb: verb, Group: group, Resource: resource},
+		},
+	}
+
+	review, err := w.settings.K8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(w.settings.Context, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
+
+// endpointAddresses returns the addresses, of the address family, of the ready endpoints of the EndpointSlices, and
+// remembers them as the addresses of their node. They are only the addresses of the nodes when the endpoints are
+// pods on the host network, e.g. NGINX Ingress Controller pods with hostNetwork: true.
+func (w *Watcher) endpointAddresses(endpointSlices []*discoveryv1.EndpointSlice) []string {
+	var addresses []string
+
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	for _, endpointSlice := range endpointSlices {
+		for _, endpoint := range endpointSlice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+
+			for _, address := range endpoint.Addresses {
+				ip := net.ParseIP(address)
+				if ip == nil {
+					continue
+				}
+
+				w.knownNodeAddresses[ip.String()] = true
+				if endpoint.NodeName != nil {
+					w.nodeNames[address] = *endpoint.NodeName
+				}
+
+				if w.inAddressFamily(ip) && !slices.Contains(addresses, address) {
+					addresses = append(addresses, address)
+				}
+			}
+		}
+	}
+
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// knownEndpointAddresses returns the addresses, of the address family, of every endpoint seen since NLK started, used
+// instead of the addresses of every node when the Nodes cannot be listed, e.g. to delete the servers of a Service.
+func (w *Watcher) knownEndpointAddresses() []string {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	var addresses []string
+	for address := range w.knownNodeAddresses {
+		if w.inAddressFamily(net.ParseIP(address)) {
+			addresses = append(addresses, address)
+		}
+	}
+
+	sort.Strings(addresses)
+
+	return addresses
+}
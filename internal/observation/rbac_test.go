@@ -0,0 +1,162 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatcher_ResolveRbacModeWithAClusterRole(t *testing.T) {
+	watcher := buildRbacWatcher(t, fake.NewSimpleClientset(), configuration.RbacModeAuto, func(string, string, string) bool { return true })
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if watcher.scoped || watcher.endpointNodes || watcher.nodeInformer == nil {
+		t.Fatalf(`expected the Services of every namespace, and the Nodes, to be watched`)
+	}
+}
+
+func TestWatcher_ResolveRbacModeWithRolesOnly(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	serveEndpointSliceApi(k8sClient)
+
+	// only the namespaced resources of the watched namespaces are granted
+	watcher := buildRbacWatcher(t, k8sClient, configuration.RbacModeAuto, func(_ string, resource string, namespace string) bool {
+		return namespace != metav1.NamespaceAll && resource != "nodes"
+	})
+	watcher.settings.Watcher.ServiceSelector = labels.SelectorFromSet(labels.Set{"nkl.nginx.com/managed": "true"})
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !watcher.scoped || !watcher.endpointNodes || watcher.nodeInformer != nil || !watcher.useEndpointSlices {
+		t.Fatalf(`expected the namespaced informers, and the addresses of the endpoints`)
+	}
+
+	if watcher.namespaceInformersOf("nginx-ingress") == nil || watcher.namespaces[metav1.NamespaceAll] != nil {
+		t.Fatalf(`expected the Services matching the selector to be watched in the nginx-ingress namespace only`)
+	}
+}
+
+func TestWatcher_ResolveRbacModeScopedWithTheNodes(t *testing.T) {
+	watcher := buildRbacWatcher(t, fake.NewSimpleClientset(), configuration.RbacModeScoped, func(string, string, string) bool { return true })
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !watcher.scoped || watcher.endpointNodes || watcher.nodeInformer == nil {
+		t.Fatalf(`expected the namespaced informers, and the Nodes to be watched`)
+	}
+}
+
+func TestWatcher_ResolveRbacModeFailsWithoutTheNodesNorTheEndpointSliceApi(t *testing.T) {
+	watcher := buildRbacWatcher(t, fake.NewSimpleClientset(), configuration.RbacModeScoped, func(_ string, resource string, _ string) bool {
+		return resource != "nodes"
+	})
+
+	if err := watcher.Initialize(); err == nil {
+		t.Fatalf(`expected an error when the upstream servers cannot be found`)
+	}
+}
+
+func TestWatcher_RetrieveEndpointNodeIpsWithoutTheNodes(t *testing.T) {
+	watcher := buildEndpointSliceWatcher(t, fake.NewSimpleClientset(), &mocks.MockHandler{})
+	watcher.endpointNodes = true
+	watcher.settings.Watcher.AddressFamily = configuration.AddressFamilyIPv4
+	service := buildEndpointSliceService()
+
+	endpointSlice := addEndpointSlice(t, watcher, service, map[string]bool{"ready": true, "not-ready": false})
+	for i := range endpointSlice.Endpoints {
+		if *endpointSlice.Endpoints[i].NodeName == "ready" {
+			endpointSlice.Endpoints[i].Addresses = []string{"10.0.0.1", "fd00::1"}
+		} else {
+			endpointSlice.Endpoints[i].Addresses = []string{"10.0.0.2"}
+		}
+	}
+
+	nodeIps, err := watcher.retrieveEndpointNodeIps(service)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected the IPv4 address of the ready endpoint, got %v`, nodeIps)
+	}
+
+	if watcher.nodeNames["10.0.0.1"] != "ready" {
+		t.Errorf(`expected the address to be named after the node of the endpoint, got %v`, watcher.nodeNames)
+	}
+
+	// the deleted Services use the addresses of every endpoint seen
+	nodeIps, _, err = watcher.retrieveNodeIps()
+	if err != nil || !reflect.DeepEqual(nodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected the known endpoint addresses, got %v, %v`, nodeIps, err)
+	}
+}
+
+func TestWatcher_ReviewSelfAccess(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	k8sClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes.Resource == "services" && attributes.Verb == "list" && attributes.Namespace == "nginx-ingress"
+
+		return true, review, nil
+	})
+
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if allowed, err := watcher.reviewSelfAccess("list", "", "services", "nginx-ingress"); err != nil || !allowed {
+		t.Fatalf(`expected the access to be allowed, got %t, %v`, allowed, err)
+	}
+
+	if watcher.mayWatch("", "services", "nginx-ingress") {
+		t.Fatalf(`expected the Services not to be watchable without the watch verb`)
+	}
+}
+
+func buildRbacWatcher(t *testing.T, k8sClient *fake.Clientset, rbacMode string, allowed func(group string, resource string, namespace string) bool) *Watcher {
+	settings, err := configuration.NewSettings(context.Background(), k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Watcher.RbacMode = rbacMode
+
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+	watcher.reviewAccess = func(_ string, group string, resource string, namespace string) (bool, error) {
+		return allowed(group, resource, namespace), nil
+	}
+
+	return watcher
+}
+
+func serveEndpointSliceApi(k8sClient *fake.Clientset) {
+	k8sClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: discoveryv1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{{Name: endpointSliceResource}},
+		},
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// rebuildInformers replaces the informers of the Nodes and of each watched namespace with informers using the new
+// WatcherSettings::ResyncPeriod setting, the resync period of an informer cannot be changed once it is built.
+// While watching, the replaced informers keep running until the new ones have synced, so the Services and Nodes are not
+// missing from the stores in between; the Add events of the new informers push the same servers again, and are skipped
+// by the Synchronizer. It is invoked by the Settings when the resync period changes.
+func (w *Watcher) rebuildInformers() {
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	if w.nodeInformerCtx == nil || w.settings.Watcher.ResyncPeriod == w.resyncPeriod {
+		return
+	}
+
+	logrus.Infof("Watcher::rebuildInformers: rebuilding the informers with a resync period of %v, was %v", w.settings.Watcher.ResyncPeriod, w.resyncPeriod)
+
+	w.resyncPeriod = w.settings.Watcher.ResyncPeriod
+	w.informersGeneration++
+	generation := w.informersGeneration
+
+	if w.nodeInformer != nil {
+		w.rebuildNodeInformer(generation)
+	}
+
+	for name, current := range w.namespaces {
+		rebuilt, err := w.buildNamespaceInformers(name)
+		if err != nil {
+			logrus.WithField("namespace", name).WithError(err).Error(`Watcher::rebuildInformers: error occurred building the informers, the current informers are kept`)
+			continue
+		}
+
+		if w.watching {
+			go w.replaceNamespaceInformers(generation, name, current, rebuilt)
+		} else {
+			current.cancel()
+			w.namespaces[name] = rebuilt
+		}
+	}
+}
+
+// rebuildNodeInformer builds the node informer again, and replaces the current one. namespacesLock must be held.
+func (w *Watcher) rebuildNodeInformer(generation int) {
+	nodeInformer, err := w.buildNodeInformer()
+	if err == nil {
+		err = w.addNodeEventHandlers(nodeInformer)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Error(`Watcher::rebuildInformers: error occurred building the node informer, the current informer is kept`)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(w.settings.Context)
+
+	if w.watching {
+		go w.replaceNodeInformer(generation, nodeInformer, ctx, cancel)
+	} else {
+		w.stopNodeInformer()
+		w.nodeInformer, w.nodeInformerCtx, w.stopNodeInformer = nodeInformer, ctx, cancel
+	}
+}
+
+// replaceNodeInformer runs the rebuilt node informer, and replaces the current one once it has synced, unless the
+// informers were rebuilt again in the meantime.
+func (w *Watcher) replaceNodeInformer(generation int, informer cache.SharedIndexInformer, ctx context.Context, cancel context.CancelFunc) {
+	go informer.Run(ctx.Done())
+
+	synced := cache.WaitForNamedCacheSync("nodes", ctx.Done(), informer.HasSynced)
+
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	if !synced || generation != w.informersGeneration {
+		cancel()
+		return
+	}
+
+	w.stopNodeInformer()
+	w.nodeInformer, w.nodeInformerCtx, w.stopNodeInformer = informer, ctx, cancel
+
+	logrus.Info("Watcher::replaceNodeInformer: the Nodes are watched by the rebuilt informer")
+}
+
+// replaceNamespaceInformers runs the rebuilt informers of a namespace, and replaces the current ones once they have synced,
+// unless the namespace is no longer watched, or the informers were rebuilt again, in the meantime.
+func (w *Watcher) replaceNamespaceInformers(generation int, name string, current *namespaceInformers, rebuilt *namespaceInformers) {
+	synced := rebuilt.run(w.settings.Context, name)
+
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	if !synced || generation != w.informersGeneration || w.namespaces[name] != current {
+		rebuilt.cancel()
+		return
+	}
+
+	current.cancel()
+	w.namespaces[name] = rebuilt
+
+	logrus.WithField("namespace", name).Info("Watcher::replaceNamespaceInformers: the namespace is watched by the rebuilt informers")
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package observation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_RebuildInformersBeforeWatching(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress"}
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	nodeInformer := watcher.nodes()
+	nodeInformerCtx := watcher.nodeInformerCtx
+	namespaceInformers := watcher.namespaceInformersOf("nginx-ingress")
+
+	settings.Watcher.ResyncPeriod = 10 * time.Minute
+	watcher.rebuildInformers()
+
+	if watcher.resyncPeriod != 10*time.Minute {
+		t.Fatalf(`expected the resync period to be 10m, got %v`, watcher.resyncPeriod)
+	}
+
+	if watcher.nodes() == nodeInformer || nodeInformerCtx.Err() == nil {
+		t.Fatal(`expected the node informer to be replaced`)
+	}
+
+	if watcher.namespaceInformersOf("nginx-ingress") == namespaceInformers || namespaceInformers.ctx.Err() == nil {
+		t.Fatal(`expected the informers of the namespace to be replaced`)
+	}
+}
+
+func TestWatcher_RebuildInformersIgnoresAnUnchangedPeriod(t *testing.T) {
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	nodeInformer := watcher.nodes()
+	watcher.rebuildInformers()
+
+	if watcher.nodes() != nodeInformer || watcher.informersGeneration != 0 {
+		t.Fatal(`expected the informers to be kept`)
+	}
+}
+
+func TestWatcher_RebuildInformersWhileWatchingReplacesThemOnceSynced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}}
+	settings, _ := configuration.NewSettings(ctx, fake.NewSimpleClientset(service))
+	settings.Watcher.NginxIngressNamespaces = []string{"nginx-ingress"}
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the current informers are not started, so they would never be replaced by informers that fail to sync
+	current := watcher.namespaceInformersOf("nginx-ingress")
+	watcher.namespacesLock.Lock()
+	watcher.watching = true
+	watcher.namespacesLock.Unlock()
+
+	settings.Watcher.ResyncPeriod = time.Hour
+	watcher.rebuildInformers()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		rebuilt := watcher.namespaceInformersOf("nginx-ingress")
+		if rebuilt == current {
+			continue
+		}
+
+		if current.ctx.Err() == nil {
+			t.Fatal(`expected the replaced informers to be stopped`)
+		}
+
+		if _, exists, _ := rebuilt.services.GetStore().Get(service); !exists {
+			t.Fatal(`expected the rebuilt informers to have synced the service`)
+		}
+
+		return
+	}
+
+	t.Fatal(`expected the informers of the namespace to be replaced`)
+}
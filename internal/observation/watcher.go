@@ -6,44 +6,142 @@
 package observation
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 )
 
 // Watcher is responsible for watching for changes to Kubernetes resources.
-// Particularly, Services in the namespace defined in the WatcherSettings::NginxIngressNamespace setting.
+// Particularly, Services in the namespaces defined in the WatcherSettings::NginxIngressNamespaces setting, or the Services
+// matching the WatcherSettings::ServiceSelector setting in every namespace.
 // When a change is detected, an Event is generated and added to the Handler's queue.
 type Watcher struct {
 
-	// eventHandlerRegistration is used to track the event handlers
-	eventHandlerRegistration interface{}
-
 	// handler is the event handler
 	handler HandlerInterface
 
-	// informer is the informer used to watch for changes to Kubernetes resources
-	informer cache.SharedIndexInformer
+	// namespaces holds the informers of each watched namespace
+	namespaces map[string]*namespaceInformers
+
+	// namespacesLock guards namespaces, watching, nodeInformer, nodeInformerCtx, stopNodeInformer, resyncPeriod, and informersGeneration
+	namespacesLock sync.Mutex
+
+	// watching is set once Watch has started the informers of the initial namespaces
+	watching bool
+
+	// useEndpointSlices determines whether the EndpointSlices are watched, when the target mode is TargetModeEndpointSlices
+	useEndpointSlices bool
+
+	// scoped is set when the Services are only watched in the NginxIngressNamespaces, see resolveRbacMode
+	scoped bool
+
+	// endpointNodes is set when NLK may not watch the Nodes, the upstream servers are then the addresses of the ready
+	// endpoints of each Service, and no node informer is built, see resolveRbacMode
+	endpointNodes bool
+
+	// reviewAccess reviews the permissions of NLK when the RBAC mode is resolved, defaults to reviewSelfAccess
+	reviewAccess accessReviewer
+
+	// upstreamNameTemplate is the WatcherSettings::UpstreamNameTemplate setting, read at startup
+	upstreamNameTemplate string
+
+	// serviceSelector is the WatcherSettings::ServiceSelector setting, read at startup
+	serviceSelector labels.Selector
+
+	// nodeInformer is the informer used to watch for changes to the Nodes, e.g. when a node is cordoned, see nodes
+	nodeInformer cache.SharedIndexInformer
+
+	// nodeInformerCtx is done once the nodeInformer is replaced by rebuildInformers, or NLK is shutting down
+	nodeInformerCtx context.Context
+
+	// stopNodeInformer stops the nodeInformer
+	stopNodeInformer context.CancelFunc
+
+	// resyncPeriod is the WatcherSettings::ResyncPeriod setting the informers were built with
+	resyncPeriod time.Duration
+
+	// informersGeneration is incremented each time the informers are rebuilt, the informers of an earlier rebuild
+	// that synced late are stopped rather than replacing the current ones
+	informersGeneration int
 
 	// settings is the configuration settings
 	settings *configuration.Settings
+
+	// unavailableNodes records when each unschedulable or NotReady node was first seen unavailable, used to apply the drain timeout
+	unavailableNodes map[string]time.Time
+
+	// notReadyNodes records when each node was first seen NotReady, used to apply the grace period
+	notReadyNodes map[string]time.Time
+
+	// knownNodeAddresses are the addresses of every node seen since NLK started, used to identify the servers that may be pruned
+	knownNodeAddresses map[string]bool
+
+	// nodeNames maps the addresses of every node seen since NLK started to the name of the node, used to template the routes
+	nodeNames map[string]string
+
+	// backupNodeAddresses are the addresses of the nodes selected by the WatcherSettings::BackupNodeSelector setting
+	backupNodeAddresses map[string]bool
+
+	// downNodeAddresses maps the addresses of the nodes whose state NLK manages to whether their upstream servers are
+	// marked down, see managedNodeState and downNode
+	downNodeAddresses map[string]bool
+
+	// cordonedNodeAddresses are the addresses of the nodes that are only cordoned, by whether they are within the drain
+	// timeout, as of the last retrieveNodeIps
+	cordonedNodeAddresses map[string]bool
+
+	// deletedNodes are the deleted nodes whose upstream servers are draining, see rememberDeletedNode
+	deletedNodes map[string]deletedNode
+
+	// sentNodeIps records the node IPs, draining ones included, of the last event of each Service added to the handler, see addEvent
+	sentNodeIps map[string][]string
+
+	// sentNodeIpsLock guards sentNodeIps, the events are added by the informers of the Services, the Nodes, and the EndpointSlices
+	sentNodeIpsLock sync.Mutex
+
+	// nodesLock guards unavailableNodes, notReadyNodes, knownNodeAddresses, nodeNames, backupNodeAddresses, downNodeAddresses,
+	// cordonedNodeAddresses, and deletedNodes
+	nodesLock sync.Mutex
 }
 
 // NewWatcher creates a new Watcher
 func NewWatcher(settings *configuration.Settings, handler HandlerInterface) (*Watcher, error) {
-	return &Watcher{
-		handler:  handler,
-		settings: settings,
-	}, nil
+	watcher := &Watcher{
+		handler:             handler,
+		settings:            settings,
+		namespaces:          make(map[string]*namespaceInformers),
+		unavailableNodes:    make(map[string]time.Time),
+		notReadyNodes:       make(map[string]time.Time),
+		knownNodeAddresses:  make(map[string]bool),
+		nodeNames:           make(map[string]string),
+		backupNodeAddresses: make(map[string]bool),
+		downNodeAddresses:   make(map[string]bool),
+		deletedNodes:        make(map[string]deletedNode),
+		sentNodeIps:         make(map[string][]string),
+	}
+
+	watcher.reviewAccess = watcher.reviewSelfAccess
+
+	return watcher, nil
 }
 
 // Initialize initializes the Watcher, must be called before Watch
@@ -51,155 +149,738 @@ func (w *Watcher) Initialize() error {
 	logrus.Debug("Watcher::Initialize")
 	var err error
 
-	w.informer, err = w.buildInformer()
-	if err != nil {
+	w.resyncPeriod = w.settings.Watcher.ResyncPeriod
+
+	if err = w.resolveRbacMode(); err != nil {
 		return fmt.Errorf(`initialization error: %w`, err)
 	}
 
+	if !w.endpointNodes {
+		w.nodeInformer, err = w.buildNodeInformer()
+		if err != nil {
+			return fmt.Errorf(`initialization error: %w`, err)
+		}
+	}
+
+	w.nodeInformerCtx, w.stopNodeInformer = context.WithCancel(w.settings.Context)
+
+	if w.settings.Watcher.TargetMode == configuration.TargetModeEndpointSlices && !w.useEndpointSlices {
+		if err = w.checkEndpointSliceApi(); err != nil {
+			logrus.Errorf(`Watcher::Initialize: falling back to the %s target mode: %v`, configuration.TargetModeNodes, err)
+			w.settings.Watcher.TargetMode = configuration.TargetModeNodes
+		} else {
+			w.useEndpointSlices = true
+		}
+	}
+
+	w.upstreamNameTemplate = w.settings.Watcher.UpstreamNameTemplate
+	w.serviceSelector = w.settings.Watcher.ServiceSelector
+
 	err = w.initializeEventListeners()
 	if err != nil {
 		return fmt.Errorf(`initialization error: %w`, err)
 	}
 
+	w.syncNamespaces()
+	w.settings.SubscribeToNamespaceChanges(w.syncNamespaces)
+	w.settings.SubscribeToResyncPeriodChanges(w.rebuildInformers)
+
+	return nil
+}
+
+// Start starts the informers of the Nodes and of the watched namespaces, and waits for them to sync, at most the
+// WatcherSettings::CacheSyncTimeout, so that the Handler and the Synchronizer, started once it returns, never act on
+// partial caches, e.g. delete the servers of the Services not listed yet. Returns an error once the timeout has elapsed.
+// Initialize must be called before Start, it does nothing once the informers have synced.
+func (w *Watcher) Start() error {
+	logrus.Debug("Watcher::Start")
+
+	w.namespacesLock.Lock()
+	nodeInformer, nodeInformerCtx, watching := w.nodeInformer, w.nodeInformerCtx, w.watching
+	w.namespacesLock.Unlock()
+
+	if nodeInformerCtx == nil {
+		return errors.New("error: Initialize must be called before Start")
+	}
+
+	if watching {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(w.settings.Context, w.settings.Watcher.CacheSyncTimeout)
+	defer cancel()
+
+	// The Nodes and EndpointSlices are synced before the Services, so the first events for the Services have their upstream servers.
+	if nodeInformer != nil {
+		go nodeInformer.Run(nodeInformerCtx.Done())
+
+		if !cache.WaitForNamedCacheSync("nodes", ctx.Done(), nodeInformer.HasSynced) {
+			return fmt.Errorf(`error occurred waiting for the cache of the Nodes to sync: %w`, cacheSyncError(ctx, w.settings.Watcher.CacheSyncTimeout))
+		}
+	}
+
+	// the namespaces added, and the informers rebuilt, from now on are started by syncNamespaces and rebuildInformers
+	w.namespacesLock.Lock()
+	w.watching = true
+	w.namespacesLock.Unlock()
+
+	for name, namespaceInformers := range w.watchedNamespaces() {
+		// a namespace removed while its informers sync is not an error
+		if !namespaceInformers.run(ctx, name) && ctx.Err() != nil {
+			return fmt.Errorf(`error occurred waiting for the cache of the Services of the %q namespace to sync: %w`, name, cacheSyncError(ctx, w.settings.Watcher.CacheSyncTimeout))
+		}
+	}
+
+	logrus.Info("Watcher::Start: the caches of the Services and Nodes have synced")
+
 	return nil
 }
 
-// Watch starts the process of watching for changes to Kubernetes resources.
+// Watch starts the informers, see Start, then watches for changes to Kubernetes resources until NLK shuts down.
 // Initialize must be called before Watch.
 func (w *Watcher) Watch() error {
 	logrus.Debug("Watcher::Watch")
 
-	if w.informer == nil {
-		return errors.New("error: Initialize must be called before Watch")
-	}
-
 	defer utilruntime.HandleCrash()
 	defer w.handler.ShutDown()
 
-	go w.informer.Run(w.settings.Context.Done())
-
-	if !cache.WaitForNamedCacheSync(w.settings.Handler.WorkQueueSettings.Name, w.settings.Context.Done(), w.informer.HasSynced) {
-		return fmt.Errorf(`error occurred waiting for the cache to sync`)
+	if err := w.Start(); err != nil {
+		return err
 	}
 
 	<-w.settings.Context.Done()
 	return nil
 }
 
+// cacheSyncError describes why the wait for a cache to sync ended: NLK is shutting down, or the timeout has elapsed.
+func cacheSyncError(ctx context.Context, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(`the cache did not sync within %v, check the connectivity to the Kubernetes API and the permissions of NLK`, timeout)
+	}
+
+	return ctx.Err()
+}
+
 // buildEventHandlerForAdd creates a function that is used as an event handler for the informer when Add events are raised.
 func (w *Watcher) buildEventHandlerForAdd() func(interface{}) {
 	logrus.Info("Watcher::buildEventHandlerForAdd")
 	return func(obj interface{}) {
-		nodeIps, err := w.retrieveNodeIps()
+		service := obj.(*v1.Service)
+		span := startEventSpan("Watcher::ServiceAdded", serviceAttribute(service))
+		defer span.End()
+
+		nodeIps, drainingNodeIps, err := w.retrieveTargetIps(service)
 		if err != nil {
-			logrus.Errorf(`error occurred retrieving node ips: %v`, err)
+			logrus.WithFields(serviceLogFields(service, core.Created)).WithError(err).Error(`error occurred retrieving node ips`)
 			return
 		}
-		service := obj.(*v1.Service)
 		var previousService *v1.Service
-		e := core.NewEvent(core.Created, service, previousService, nodeIps)
-		w.handler.AddRateLimitedEvent(&e)
+		e := w.newEvent(core.Created, service, previousService, nodeIps, drainingNodeIps)
+		e.SpanContext = span.SpanContext()
+		w.addEvent(&e)
 	}
 }
 
 // buildEventHandlerForDelete creates a function that is used as an event handler for the informer when Delete events are raised.
+// A deletion missed by the watch is delivered as a tombstone, the servers of its last known Service are deleted all the same.
 func (w *Watcher) buildEventHandlerForDelete() func(interface{}) {
 	logrus.Info("Watcher::buildEventHandlerForDelete")
 	return func(obj interface{}) {
-		nodeIps, err := w.retrieveNodeIps()
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		service, ok := obj.(*v1.Service)
+		if !ok {
+			logrus.Errorf("Watcher::buildEventHandlerForDelete: unable to cast object to Service")
+			return
+		}
+
+		span := startEventSpan("Watcher::ServiceDeleted", serviceAttribute(service))
+		defer span.End()
+
+		// every node is used regardless of the target mode, the EndpointSlices of a deleted Service may already be gone
+		nodeIps, drainingNodeIps, err := w.retrieveNodeIps()
 		if err != nil {
-			logrus.Errorf(`error occurred retrieving node ips: %v`, err)
+			logrus.WithFields(serviceLogFields(service, core.Deleted)).WithError(err).Error(`error occurred retrieving node ips`)
 			return
 		}
-		service := obj.(*v1.Service)
 		var previousService *v1.Service
-		e := core.NewEvent(core.Deleted, service, previousService, nodeIps)
-		w.handler.AddRateLimitedEvent(&e)
+		e := w.newEvent(core.Deleted, service, previousService, nodeIps, drainingNodeIps)
+		e.SpanContext = span.SpanContext()
+		w.addEvent(&e)
 	}
 }
 
 // buildEventHandlerForUpdate creates a function that is used as an event handler for the informer when Update events are raised.
+// The updates that only change the sync status annotations written by NLK are ignored, so writing them does not sync the Service again.
 func (w *Watcher) buildEventHandlerForUpdate() func(interface{}, interface{}) {
 	logrus.Info("Watcher::buildEventHandlerForUpdate")
 	return func(previous, updated interface{}) {
-		nodeIps, err := w.retrieveNodeIps()
+		service := updated.(*v1.Service)
+		if onlyStatusAnnotationsChanged(previous.(*v1.Service), service) {
+			return
+		}
+
+		span := startEventSpan("Watcher::ServiceUpdated", serviceAttribute(service))
+		defer span.End()
+
+		nodeIps, drainingNodeIps, err := w.retrieveTargetIps(service)
 		if err != nil {
-			logrus.Errorf(`error occurred retrieving node ips: %v`, err)
+			logrus.WithFields(serviceLogFields(service, core.Updated)).WithError(err).Error(`error occurred retrieving node ips`)
 			return
 		}
-		service := updated.(*v1.Service)
 		previousService := previous.(*v1.Service)
-		e := core.NewEvent(core.Updated, service, previousService, nodeIps)
-		w.handler.AddRateLimitedEvent(&e)
+		e := w.newEvent(core.Updated, service, previousService, nodeIps, drainingNodeIps)
+		e.SpanContext = span.SpanContext()
+		e.Resync = previousService.ResourceVersion == service.ResourceVersion
+		w.addEvent(&e)
+	}
+}
+
+// buildEventHandlerForNodeUpdate creates a function that is used as an event handler for the node informer when Update events are raised.
+// When a node is cordoned or uncordoned the Services are resynchronized, and again once the drain timeout has elapsed.
+// The Services are also resynchronized when a node becomes excluded or included, e.g. when an excluded taint is added or removed,
+// and when a node's readiness changes; a node that becomes NotReady is removed, or drained, once the grace period has elapsed.
+// A node that becomes, or stops being, a backup node has its upstream servers updated in place.
+func (w *Watcher) buildEventHandlerForNodeUpdate() func(interface{}, interface{}) {
+	logrus.Info("Watcher::buildEventHandlerForNodeUpdate")
+	return func(previous, updated interface{}) {
+		previousNode := previous.(*v1.Node)
+		node := updated.(*v1.Node)
+		w.rememberNodeAddresses(node)
+
+		cordoned := previousNode.Spec.Unschedulable != node.Spec.Unschedulable
+		readinessChanged := nodeReady(*previousNode) != nodeReady(*node)
+		backupChanged := w.backupNode(*previousNode) != w.backupNode(*node)
+		downChanged := downNode(*previousNode) != downNode(*node)
+		if !cordoned && !readinessChanged && !backupChanged && !downChanged && w.excludedNode(*previousNode) == w.excludedNode(*node) {
+			return
+		}
+
+		span := startEventSpan("Watcher::NodeUpdated", instrumentation.NodeAttribute.String(node.Name))
+		defer span.End()
+
+		// the node becomes unavailable once the grace period has elapsed, and is drained until the drain timeout has elapsed
+		if readinessChanged && !nodeReady(*node) {
+			time.AfterFunc(w.settings.Watcher.NotReadyGracePeriod, w.ResyncServices)
+			time.AfterFunc(w.settings.Watcher.NotReadyGracePeriod+w.settings.Watcher.DrainTimeout, w.ResyncServices)
+		}
+
+		if cordoned && node.Spec.Unschedulable {
+			time.AfterFunc(w.settings.Watcher.DrainTimeout, w.ResyncServices)
+		}
+
+		if downChanged {
+			logrus.WithField("node", node.Name).Infof(`Watcher::buildEventHandlerForNodeUpdate: the upstream servers of the node are marked %s`, nodeState(*node))
+		}
+
+		w.resyncServices(span.SpanContext())
 	}
 }
 
-// buildInformer creates the informer used to watch for changes to Kubernetes resources.
-func (w *Watcher) buildInformer() (cache.SharedIndexInformer, error) {
-	logrus.Debug("Watcher::buildInformer")
+// buildEventHandlerForNodeAdd creates a function that is used as an event handler for the node informer when Add events are raised,
+// e.g. when a node joins the cluster or the node selector label is added to a node.
+func (w *Watcher) buildEventHandlerForNodeAdd() func(interface{}) {
+	logrus.Info("Watcher::buildEventHandlerForNodeAdd")
+	return func(obj interface{}) {
+		span := startEventSpan("Watcher::NodeAdded")
+		defer span.End()
+
+		if node, ok := obj.(*v1.Node); ok {
+			span.SetAttributes(instrumentation.NodeAttribute.String(node.Name))
+			w.rememberNodeAddresses(node)
+		}
+
+		w.resyncServices(span.SpanContext())
+	}
+}
+
+// buildEventHandlerForNodeDelete creates a function that is used as an event handler for the node informer when Delete events are raised,
+// including the tombstones of the deletions missed by the watch. The servers of the deleted node are drained before they are removed,
+// see rememberDeletedNode.
+func (w *Watcher) buildEventHandlerForNodeDelete() func(interface{}) {
+	logrus.Info("Watcher::buildEventHandlerForNodeDelete")
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		span := startEventSpan("Watcher::NodeDeleted")
+		defer span.End()
+
+		if node, ok := obj.(*v1.Node); ok {
+			span.SetAttributes(instrumentation.NodeAttribute.String(node.Name))
+			w.rememberNodeAddresses(node)
+			w.rememberDeletedNode(node, time.Now())
+		}
+
+		w.resyncServices(span.SpanContext())
+	}
+}
+
+// ResyncServices generates an Updated event for each of the watched Services, so the upstream servers reflect the current nodes.
+// It is also invoked by the Synchronizer when an NGINX Plus host recovers, so the host receives the servers of every upstream;
+// the events are resyncs, see core.Event::Resync, so the servers are pushed even when they are the servers last applied.
+func (w *Watcher) ResyncServices() {
+	logrus.Debug("Watcher::ResyncServices")
 
-	options := informers.WithNamespace(w.settings.Watcher.NginxIngressNamespace)
-	factory := informers.NewSharedInformerFactoryWithOptions(w.settings.K8sClient, w.settings.Watcher.ResyncPeriod, options)
-	informer := factory.Core().V1().Services().Informer()
+	span := startEventSpan("Watcher::ResyncServices")
+	defer span.End()
+
+	for _, service := range w.watchedServices() {
+		w.resyncServiceWith(service, span.SpanContext(), true)
+	}
+}
+
+// resyncServices generates an Updated event for each of the watched Services, traced as children of the span context.
+func (w *Watcher) resyncServices(spanContext trace.SpanContext) {
+	for _, service := range w.watchedServices() {
+		w.resyncService(service, spanContext)
+	}
+}
+
+// resyncService generates an Updated event for the Service, so its upstream servers reflect the current targets.
+// The event is traced as a child of the span context, e.g. of the span of the Node event that caused the resync.
+func (w *Watcher) resyncService(service *v1.Service, spanContext trace.SpanContext) {
+	w.resyncServiceWith(service, spanContext, false)
+}
+
+// resyncServiceWith generates an Updated event for the Service, a resync, see core.Event::Resync, when resync is set.
+func (w *Watcher) resyncServiceWith(service *v1.Service, spanContext trace.SpanContext, resync bool) {
+	if w.settings.Context.Err() != nil {
+		return
+	}
+
+	nodeIps, drainingNodeIps, err := w.retrieveTargetIps(service)
+	if err != nil {
+		logrus.WithFields(serviceLogFields(service, core.Updated)).WithError(err).Error(`error occurred retrieving node ips`)
+		return
+	}
+
+	e := w.newEvent(core.Updated, service, service, nodeIps, drainingNodeIps)
+	e.SpanContext = spanContext
+	e.Resync = resync
+	w.addEvent(&e)
+}
+
+// newEvent creates an Event for the Service, with the upstream name template applied by the translator, and the ports of
+// the endpoints of the Service, which may still be cached when it is deleted.
+func (w *Watcher) newEvent(eventType core.EventType, service *v1.Service, previousService *v1.Service, nodeIps []string, drainingNodeIps []string) core.Event {
+	e := core.NewEvent(eventType, service, previousService, nodeIps)
+	e.DrainingNodeIps = drainingNodeIps
+	e.UpstreamNameTemplate = w.upstreamNameTemplate
+	e.TargetPorts = w.retrieveTargetPorts(service)
+	e.NodeNames = w.copyNodeNames()
+	e.BackupNodeIps = w.copyBackupNodeAddresses()
+	e.DownNodeIps = w.copyDownNodeAddresses()
+	e.CordonedNodeIps = w.copyCordonedNodeAddresses()
+	e.ObservedAt = time.Now()
+
+	return e
+}
+
+// addEvent adds the event to the handler, along with the node IPs of the previous event of its Service, see
+// core.Event::PreviousNodeIps.
+func (w *Watcher) addEvent(e *core.Event) {
+	key := fmt.Sprintf("%s/%s", e.Service.Namespace, e.Service.Name)
+
+	w.sentNodeIpsLock.Lock()
+	e.PreviousNodeIps = w.sentNodeIps[key]
+	if e.Type == core.Deleted {
+		delete(w.sentNodeIps, key)
+	} else {
+		w.sentNodeIps[key] = append(append([]string{}, e.NodeIps...), e.DrainingNodeIps...)
+	}
+	w.sentNodeIpsLock.Unlock()
+
+	w.handler.AddRateLimitedEvent(e)
+}
+
+// startEventSpan starts the span of a Kubernetes event received by the Watcher, the root of the trace of the events
+// generated for it; see instrumentation.StartTracing.
+func startEventSpan(name string, attributes ...attribute.KeyValue) trace.Span {
+	_, span := instrumentation.StartSpan(context.Background(), trace.SpanContext{}, name, attributes...)
+	return span
+}
+
+// serviceAttribute returns the span attribute identifying the Service.
+func serviceAttribute(service *v1.Service) attribute.KeyValue {
+	return instrumentation.ServiceAttribute.String(service.Namespace + "/" + service.Name)
+}
+
+// serviceLogFields returns the structured logging fields for an event of the given type on the Service.
+func serviceLogFields(service *v1.Service, eventType core.EventType) logrus.Fields {
+	e := core.Event{Type: eventType, Service: service}
+	return e.LogFields()
+}
+
+// buildNodeInformer creates the informer used to watch for changes to the Nodes.
+func (w *Watcher) buildNodeInformer() (cache.SharedIndexInformer, error) {
+	logrus.Debug("Watcher::buildNodeInformer")
+
+	// nodes that start or stop matching the node selector are seen as Add and Delete events
+	options := informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.LabelSelector = w.nodeSelector()
+	})
+	factory := informers.NewSharedInformerFactoryWithOptions(w.settings.K8sClient, w.resyncPeriod, options)
+	informer := factory.Core().V1().Nodes().Informer()
 
 	return informer, nil
 }
 
-// initializeEventListeners initializes the event listeners for the informer.
+// nodes returns the informer used to watch for changes to the Nodes, it is replaced when the informers are rebuilt.
+func (w *Watcher) nodes() cache.SharedIndexInformer {
+	w.namespacesLock.Lock()
+	defer w.namespacesLock.Unlock()
+
+	return w.nodeInformer
+}
+
+// initializeEventListeners initializes the event listeners for the node informer, the event listeners for the informers
+// of each namespace are added by buildNamespaceInformers.
 func (w *Watcher) initializeEventListeners() error {
 	logrus.Debug("Watcher::initializeEventListeners")
-	var err error
 
-	handlers := cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.buildEventHandlerForAdd(),
-		DeleteFunc: w.buildEventHandlerForDelete(),
-		UpdateFunc: w.buildEventHandlerForUpdate(),
+	if w.nodeInformer == nil {
+		return nil
+	}
+
+	return w.addNodeEventHandlers(w.nodeInformer)
+}
+
+// addNodeEventHandlers adds the event handlers of the Nodes to the informer.
+func (w *Watcher) addNodeEventHandlers(informer cache.SharedIndexInformer) error {
+	nodeHandlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.buildEventHandlerForNodeAdd(),
+		DeleteFunc: w.buildEventHandlerForNodeDelete(),
+		UpdateFunc: w.buildEventHandlerForNodeUpdate(),
 	}
 
-	w.eventHandlerRegistration, err = w.informer.AddEventHandler(handlers)
+	_, err := informer.AddEventHandler(nodeHandlers)
 	if err != nil {
-		return fmt.Errorf(`error occurred adding event handlers: %w`, err)
+		return fmt.Errorf(`error occurred adding node event handlers: %w`, err)
 	}
 
 	return nil
 }
 
-// notControlPlaneNode retrieves the IP Addresses of the nodes in the cluster. Currently, the master node is excluded. This is
+// retrieveNodeIps retrieves the IP Addresses of the nodes in the cluster. Currently, the master node is excluded. This is
 // because the master node may or may not be a worker node and thus may not be able to route traffic.
-func (w *Watcher) retrieveNodeIps() ([]string, error) {
+// The IP Addresses of the NotReady nodes are returned separately while they are within the drain timeout, and are
+// excluded afterwards, as are the addresses of the deleted nodes whose servers are still draining. The cordoned nodes keep
+// serving, their addresses are returned with the available ones and recorded as cordoned, see core.Event::CordonedNodeIps. When the Nodes cannot be listed, the addresses of every endpoint seen since NLK started are returned.
+func (w *Watcher) retrieveNodeIps() ([]string, []string, error) {
 	started := time.Now()
 	logrus.Debug("Watcher::retrieveNodeIps")
 
+	if w.endpointNodes {
+		return w.knownEndpointAddresses(), nil, nil
+	}
+
 	var nodeIps []string
+	var drainingNodeIps []string
 
-	nodes, err := w.settings.K8sClient.CoreV1().Nodes().List(w.settings.Context, metav1.ListOptions{})
+	nodes, err := w.settings.K8sClient.CoreV1().Nodes().List(w.settings.Context, metav1.ListOptions{LabelSelector: w.nodeSelector()})
 	if err != nil {
 		logrus.Errorf(`error occurred retrieving the list of nodes: %v`, err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	for _, node := range nodes.Items {
+	unavailable, cordoned := w.unavailableNodeNames(nodes.Items, started)
+	draining := w.drainingNodes(unavailable, started)
+	cordonedNodeIps := make(map[string]bool)
 
-		// this is kind of a broad assumption, should probably make this a configurable option
-		if w.notControlPlaneNode(node) {
-			for _, address := range node.Status.Addresses {
-				if address.Type == v1.NodeInternalIP {
-					nodeIps = append(nodeIps, address.Address)
+	for _, node := range nodes.Items {
+		w.rememberNodeAddresses(&node)
+
+		if !w.excludedNode(node) {
+			switch addresses := w.nodeAddresses(node); {
+			case cordoned[node.Name]:
+				nodeIps = append(nodeIps, addresses...)
+				for _, address := range addresses {
+					cordonedNodeIps[address] = draining[node.Name]
 				}
+			case !unavailable[node.Name]:
+				nodeIps = append(nodeIps, addresses...)
+			case draining[node.Name]:
+				drainingNodeIps = append(drainingNodeIps, addresses...)
 			}
 		}
 	}
 
+	w.nodesLock.Lock()
+	w.cordonedNodeAddresses = cordonedNodeIps
+	w.nodesLock.Unlock()
+
+	for _, address := range w.deletedNodeAddresses(nodes.Items, started) {
+		if !slices.Contains(nodeIps, address) && !slices.Contains(drainingNodeIps, address) {
+			drainingNodeIps = append(drainingNodeIps, address)
+		}
+	}
+
+	// sorted so the translated events do not depend on the order of the nodes, or of the changes to their conditions
+	sort.Strings(nodeIps)
+	sort.Strings(drainingNodeIps)
+
 	logrus.Debugf("Watcher::retrieveNodeIps duration: %d", time.Since(started).Nanoseconds())
 
-	return nodeIps, nil
+	return nodeIps, drainingNodeIps, nil
+}
+
+// unavailableNodeNames returns the names of the nodes that are unschedulable, or that have been NotReady for longer
+// than the grace period, and the names of those that are only unschedulable, which only the Services annotated with
+// drain-on-cordon stop sending traffic to. The grace period debounces brief readiness blips, so they do not cause upstream churn.
+func (w *Watcher) unavailableNodeNames(nodes []v1.Node, now time.Time) (map[string]bool, map[string]bool) {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	unavailable := make(map[string]bool)
+	cordoned := make(map[string]bool)
+	notReadyNodes := make(map[string]time.Time)
+
+	for _, node := range nodes {
+		if !nodeReady(node) {
+			notReadySince, found := w.notReadyNodes[node.Name]
+			if !found {
+				notReadySince = notReadyTransitionTime(node, now)
+			}
+
+			notReadyNodes[node.Name] = notReadySince
+			unavailable[node.Name] = now.Sub(notReadySince) >= w.settings.Watcher.NotReadyGracePeriod
+		}
+
+		if node.Spec.Unschedulable {
+			cordoned[node.Name] = !unavailable[node.Name]
+			unavailable[node.Name] = true
+		}
+	}
+
+	w.notReadyNodes = notReadyNodes
+
+	return unavailable, cordoned
+}
+
+// drainingNodes records when each unavailable node was first seen, and returns the names of the unavailable nodes
+// that are still within the drain timeout. NOTE: the times are not persisted, so the drain timeout restarts with NLK.
+func (w *Watcher) drainingNodes(unavailable map[string]bool, now time.Time) map[string]bool {
+	w.nodesLock.Lock()
+	defer w.nodesLock.Unlock()
+
+	draining := make(map[string]bool)
+	unavailableNodes := make(map[string]time.Time)
+
+	for name, isUnavailable := range unavailable {
+		if !isUnavailable {
+			continue
+		}
+
+		unavailableAt, found := w.unavailableNodes[name]
+		if !found {
+			unavailableAt = now
+		}
+
+		unavailableNodes[name] = unavailableAt
+		draining[name] = now.Sub(unavailableAt) < w.settings.Watcher.DrainTimeout
+	}
+
+	w.unavailableNodes = unavailableNodes
+
+	return draining
+}
+
+// nodeReady determines if the node's Ready condition is True; a node that does not report the condition yet is considered ready.
+func nodeReady(node v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return true
 }
 
-// notControlPlaneNode determines if the node is a master node.
-func (w *Watcher) notControlPlaneNode(node v1.Node) bool {
-	logrus.Debug("Watcher::notControlPlaneNode")
+// notReadyTransitionTime returns when the node became NotReady, or now if the transition time is not known.
+func notReadyTransitionTime(node v1.Node, now time.Time) time.Time {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady && !condition.LastTransitionTime.IsZero() && condition.LastTransitionTime.Time.Before(now) {
+			return condition.LastTransitionTime.Time
+		}
+	}
+
+	return now
+}
+
+// nodeAddresses returns the addresses of the node for the address family, using the first of the preferred node
+// address types that the node exposes. A node that exposes none of them contributes no upstream servers.
+func (w *Watcher) nodeAddresses(node v1.Node) []string {
+	addressTypes := w.settings.Watcher.NodeAddressTypes
+	if len(addressTypes) == 0 {
+		addressTypes = []v1.NodeAddressType{v1.NodeInternalIP}
+	}
+
+	for i, addressType := range addressTypes {
+		addresses := w.nodeAddressesOfType(node, addressType)
+		if len(addresses) == 0 {
+			continue
+		}
 
-	_, found := node.Labels["node-role.kubernetes.io/control-plane"]
+		if i > 0 {
+			logrus.WithField("node", node.Name).
+				Warnf("Watcher::nodeAddresses: node has no %s for the %s address family, using its %s", addressTypes[0], w.settings.Watcher.AddressFamily, addressType)
+		}
+
+		return addresses
+	}
+
+	logrus.WithField("node", node.Name).
+		Warnf("Watcher::nodeAddresses: node has no %v for the %s address family, skipping it", addressTypes, w.settings.Watcher.AddressFamily)
+
+	return nil
+}
+
+// nodeAddressesOfType returns the addresses of the given type of the node for the address family.
+func (w *Watcher) nodeAddressesOfType(node v1.Node, addressType v1.NodeAddressType) []string {
+	var addresses []string
+
+	for _, address := range node.Status.Addresses {
+		if address.Type != addressType {
+			continue
+		}
+
+		ip := net.ParseIP(address.Address)
+		if ip == nil {
+			logrus.WithField("node", node.Name).Warnf("Watcher::nodeAddressesOfType: node has an invalid %s %q", addressType, address.Address)
+			continue
+		}
+
+		if w.inAddressFamily(ip) {
+			addresses = append(addresses, address.Address)
+		}
+	}
+
+	return addresses
+}
+
+// inAddressFamily determines whether the IP belongs to the address family, see WatcherSettings::AddressFamily.
+func (w *Watcher) inAddressFamily(ip net.IP) bool {
+	isIPv4 := ip.To4() != nil
+
+	switch w.settings.Watcher.AddressFamily {
+	case configuration.AddressFamilyIPv4:
+		return isIPv4
+	case configuration.AddressFamilyIPv6:
+		return !isIPv4
+	default:
+		return true
+	}
+}
+
+// nodeSelector returns the node selector as a label selector string, empty selects every node.
+func (w *Watcher) nodeSelector() string {
+	if w.settings.Watcher.NodeSelector == nil {
+		return ""
+	}
+
+	return w.settings.Watcher.NodeSelector.String()
+}
+
+// statusAnnotations are the keys of the sync status annotations NLK writes to the Services.
+var statusAnnotations = []string{
+	configuration.LastSyncedAnnotation,
+	configuration.SyncedHostsAnnotation,
+	configuration.ServerCountAnnotation,
+	configuration.SyncErrorAnnotation,
+}
+
+// onlyStatusAnnotationsChanged determines if the Service was updated and only its sync status annotations changed.
+// The periodic resyncs, which do not change the resource version, are not ignored; nor are the changes of the
+// status.loadBalancer, so a load balancer status overwritten by something else is written again.
+func onlyStatusAnnotationsChanged(previous *v1.Service, service *v1.Service) bool {
+	if previous.ResourceVersion == service.ResourceVersion {
+		return false
+	}
+
+	if !reflect.DeepEqual(previous.Status.LoadBalancer, service.Status.LoadBalancer) {
+		return false
+	}
+
+	if !reflect.DeepEqual(previous.Spec, service.Spec) || !reflect.DeepEqual(previous.Labels, service.Labels) {
+		return false
+	}
+
+	return reflect.DeepEqual(withoutStatusAnnotations(previous.Annotations), withoutStatusAnnotations(service.Annotations))
+}
+
+// withoutStatusAnnotations returns a copy of the annotations without the sync status annotations.
+func withoutStatusAnnotations(annotations map[string]string) map[string]string {
+	filtered := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		filtered[key] = value
+	}
+
+	for _, suffix := range statusAnnotations {
+		delete(filtered, fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, suffix))
+	}
+
+	return filtered
+}
+
+// backupNode determines if the upstream servers of the node are marked backup, as it matches the backup node selector.
+func (w *Watcher) backupNode(node v1.Node) bool {
+	selector := w.settings.Watcher.BackupNodeSelector
+
+	return selector != nil && selector.Matches(labels.Set(node.Labels))
+}
+
+// downNode determines if the upstream servers of the node are marked down, as the node is labeled, or annotated, with the
+// NodeStateKey set to NodeStateDown; the label wins when both are set.
+func downNode(node v1.Node) bool {
+	if state, found := node.Labels[configuration.NodeStateKey]; found {
+		return state == configuration.NodeStateDown
+	}
+
+	return node.Annotations[configuration.NodeStateKey] == configuration.NodeStateDown
+}
+
+// managedNodeState determines if NLK manages the down flag of the upstream servers of the node, as the node is labeled,
+// or annotated, with the NodeStateKey, whatever its value; the flag of the servers of the other nodes is left alone.
+func managedNodeState(node v1.Node) bool {
+	_, labeled := node.Labels[configuration.NodeStateKey]
+	_, annotated := node.Annotations[configuration.NodeStateKey]
+
+	return labeled || annotated
+}
+
+// nodeState returns the state of the upstream servers of the node, for the logs.
+func nodeState(node v1.Node) string {
+	if downNode(node) {
+		return "down"
+	}
+
+	return "up"
+}
+
+// excludedNode determines if the node is excluded from the upstream servers: control-plane nodes may or may not be
+// worker nodes and thus may not be able to route traffic, and nodes carrying one of the excluded taints are not healthy.
+func (w *Watcher) excludedNode(node v1.Node) bool {
+	logrus.Debug("Watcher::excludedNode")
+
+	if _, found := node.Labels[configuration.ControlPlaneNodeLabel]; found && w.settings.Watcher.ExcludeControlPlaneNodes {
+		return true
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect != v1.TaintEffectNoSchedule && taint.Effect != v1.TaintEffectNoExecute {
+			continue
+		}
+
+		for _, key := range w.settings.Watcher.ExcludedTaintKeys {
+			if taint.Key == key {
+				return true
+			}
+		}
+	}
 
-	return !found
+	return false
 }
@@ -8,9 +8,17 @@ package observation
 import (
 	"context"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestWatcher_MustInitialize(t *testing.T) {
@@ -20,6 +28,503 @@ func TestWatcher_MustInitialize(t *testing.T) {
 	}
 }
 
+func TestWatcher_RetrieveNodeIpsKeepsCordonedNodes(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(
+		buildNode("worker", "10.0.0.1", false),
+		buildNode("cordoned", "10.0.0.2", true),
+		buildNode("expired", "10.0.0.3", true),
+	)
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+	watcher.unavailableNodes["expired"] = time.Now().Add(-settings.Watcher.DrainTimeout - time.Second)
+
+	nodeIps, drainingNodeIps, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}) {
+		t.Errorf(`expected the cordoned nodes to be kept, got %v`, nodeIps)
+	}
+
+	if len(drainingNodeIps) != 0 {
+		t.Errorf(`expected no draining node, got %v`, drainingNodeIps)
+	}
+
+	expected := map[string]bool{"10.0.0.2": true, "10.0.0.3": false}
+	if cordoned := watcher.copyCordonedNodeAddresses(); !reflect.DeepEqual(cordoned, expected) {
+		t.Errorf(`expected the cordoned nodes by whether they are within the drain timeout %v, got %v`, expected, cordoned)
+	}
+}
+
+func TestWatcher_RetrieveNodeIpsDrainsCordonedNotReadyNodes(t *testing.T) {
+	node := buildNodeWithReadiness("down", "10.0.0.1", v1.ConditionFalse, time.Now().Add(-time.Minute))
+	node.Spec.Unschedulable = true
+
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset(node))
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	nodeIps, drainingNodeIps, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(nodeIps) != 0 || !reflect.DeepEqual(drainingNodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected the cordoned NotReady node to be draining, got %v and %v`, nodeIps, drainingNodeIps)
+	}
+
+	if cordoned := watcher.copyCordonedNodeAddresses(); len(cordoned) != 0 {
+		t.Errorf(`expected the NotReady node not to be recorded as cordoned, got %v`, cordoned)
+	}
+}
+
+func TestWatcher_EventsCarryTheNodeNames(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false), buildNode("cordoned", "10.0.0.2", true))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	nodeIps, drainingNodeIps, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	event := watcher.newEvent(core.Updated, &v1.Service{}, nil, nodeIps, drainingNodeIps)
+
+	expected := map[string]string{"10.0.0.1": "worker", "10.0.0.2": "cordoned"}
+	if !reflect.DeepEqual(event.NodeNames, expected) {
+		t.Errorf(`expected the names of the nodes %v, got %v`, expected, event.NodeNames)
+	}
+}
+
+func TestWatcher_RetrieveNodeIpsAppliesNodeSelector(t *testing.T) {
+	ingressNode := buildNode("ingress", "10.0.0.1", false)
+	ingressNode.Labels = map[string]string{"node-role.kubernetes.io/ingress": "true"}
+
+	k8sClient := fake.NewSimpleClientset(ingressNode, buildNode("worker", "10.0.0.2", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	settings.Watcher.NodeSelector, _ = labels.Parse("node-role.kubernetes.io/ingress=true")
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	nodeIps, _, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected only the node matching the selector, got %v`, nodeIps)
+	}
+}
+
+func TestWatcher_DrainingNodesForgetsUncordonedNodes(t *testing.T) {
+	watcher, _ := buildWatcher()
+	now := time.Now()
+
+	unavailable, _ := watcher.unavailableNodeNames([]v1.Node{*buildNode("node", "10.0.0.1", true)}, now)
+	watcher.drainingNodes(unavailable, now)
+	unavailable, _ = watcher.unavailableNodeNames([]v1.Node{*buildNode("node", "10.0.0.1", false)}, now)
+	watcher.drainingNodes(unavailable, now)
+
+	if len(watcher.unavailableNodes) != 0 {
+		t.Errorf(`expected uncordoned nodes to be forgotten, got %v`, watcher.unavailableNodes)
+	}
+}
+
+func TestWatcher_RetrieveNodeIpsRemovesNotReadyNodesAfterGracePeriod(t *testing.T) {
+	now := time.Now()
+	k8sClient := fake.NewSimpleClientset(
+		buildNodeWithReadiness("ready", "10.0.0.1", v1.ConditionTrue, now.Add(-time.Hour)),
+		buildNodeWithReadiness("blip", "10.0.0.2", v1.ConditionUnknown, now),
+		buildNodeWithReadiness("down", "10.0.0.3", v1.ConditionFalse, now.Add(-time.Minute)),
+	)
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	nodeIps, drainingNodeIps, err := watcher.retrieveNodeIps()
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf(`expected the ready node and the node within the grace period, got %v`, nodeIps)
+	}
+
+	if !reflect.DeepEqual(drainingNodeIps, []string{"10.0.0.3"}) {
+		t.Errorf(`expected the NotReady node to be draining, got %v`, drainingNodeIps)
+	}
+}
+
+func TestWatcher_UnavailableNodeNamesKeepsNotReadySinceAcrossConditionChanges(t *testing.T) {
+	watcher, _ := buildWatcher()
+	now := time.Now()
+
+	watcher.unavailableNodeNames([]v1.Node{*buildNodeWithReadiness("node", "10.0.0.1", v1.ConditionFalse, now.Add(-time.Minute))}, now)
+
+	// a False to Unknown transition resets the LastTransitionTime, but the node has been NotReady all along
+	unavailable, _ := watcher.unavailableNodeNames([]v1.Node{*buildNodeWithReadiness("node", "10.0.0.1", v1.ConditionUnknown, now)}, now)
+	if !unavailable["node"] {
+		t.Errorf(`expected the node to remain unavailable`)
+	}
+
+	unavailable, _ = watcher.unavailableNodeNames([]v1.Node{*buildNodeWithReadiness("node", "10.0.0.1", v1.ConditionTrue, now)}, now)
+	if unavailable["node"] || len(watcher.notReadyNodes) != 0 {
+		t.Errorf(`expected the node to be available once Ready`)
+	}
+}
+
+func TestWatcher_NodeReadinessChangeQueuesServiceUpdate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	settings.Watcher.NotReadyGracePeriod = time.Hour
+	watcher, _ := NewWatcher(settings, handler)
+	_ = watchNamespace(t, watcher, "nginx-ingress").services.GetStore().Add(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}})
+
+	now := time.Now()
+	previous := buildNodeWithReadiness("worker", "10.0.0.1", v1.ConditionTrue, now.Add(-time.Hour))
+	updated := buildNodeWithReadiness("worker", "10.0.0.1", v1.ConditionFalse, now)
+
+	watcher.buildEventHandlerForNodeUpdate()(previous, updated)
+
+	if len(handler.Events) != 1 {
+		t.Fatalf(`expected 1 event when the node readiness changes, got %d`, len(handler.Events))
+	}
+}
+
+func TestWatcher_NodeAddressesForAddressFamily(t *testing.T) {
+	dualStack := buildNode("dual-stack", "10.0.0.1", false)
+	dualStack.Status.Addresses = append(dualStack.Status.Addresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "fd00::1"})
+	ipv6Only := buildNode("ipv6-only", "fd00::2", false)
+	ipv4Only := buildNode("ipv4-only", "10.0.0.3", false)
+
+	tests := []struct {
+		addressFamily string
+		node          *v1.Node
+		expected      []string
+	}{
+		{configuration.AddressFamilyIPv4, dualStack, []string{"10.0.0.1"}},
+		{configuration.AddressFamilyIPv6, dualStack, []string{"fd00::1"}},
+		{configuration.AddressFamilyDual, dualStack, []string{"10.0.0.1", "fd00::1"}},
+		{configuration.AddressFamilyIPv4, ipv6Only, nil},
+		{configuration.AddressFamilyDual, ipv6Only, []string{"fd00::2"}},
+		{configuration.AddressFamilyIPv6, ipv4Only, nil},
+		{configuration.AddressFamilyDual, ipv4Only, []string{"10.0.0.3"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.addressFamily+"/"+test.node.Name, func(t *testing.T) {
+			watcher, _ := buildWatcher()
+			watcher.settings.Watcher.AddressFamily = test.addressFamily
+
+			if actual := watcher.nodeAddresses(*test.node); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf(`expected %v, got %v`, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWatcher_NodeAddressesKeepsIPv6OnlyNodesByDefault(t *testing.T) {
+	watcher, _ := buildWatcher()
+
+	if actual := watcher.nodeAddresses(*buildNode("ipv6-only", "fd00::2", false)); !reflect.DeepEqual(actual, []string{"fd00::2"}) {
+		t.Errorf(`expected the address of the IPv6-only node, got %v`, actual)
+	}
+}
+
+func TestWatcher_NodeAddressesPrefersAddressTypes(t *testing.T) {
+	external := buildNode("external", "10.0.0.1", false)
+	external.Status.Addresses = append(external.Status.Addresses, v1.NodeAddress{Type: v1.NodeExternalIP, Address: "203.0.113.1"})
+	internalOnly := buildNode("internal-only", "10.0.0.2", false)
+	noAddresses := buildNode("no-addresses", "10.0.0.3", false)
+	noAddresses.Status.Addresses = nil
+
+	tests := []struct {
+		name             string
+		nodeAddressTypes []v1.NodeAddressType
+		node             *v1.Node
+		expected         []string
+	}{
+		{"external preferred", []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP}, external, []string{"203.0.113.1"}},
+		{"falls back to internal", []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP}, internalOnly, []string{"10.0.0.2"}},
+		{"external only", []v1.NodeAddressType{v1.NodeExternalIP}, internalOnly, nil},
+		{"no addresses", []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP}, noAddresses, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			watcher, _ := buildWatcher()
+			watcher.settings.Watcher.NodeAddressTypes = test.nodeAddressTypes
+
+			if actual := watcher.nodeAddresses(*test.node); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf(`expected %v, got %v`, test.expected, actual)
+			}
+		})
+	}
+}
+
+func buildNodeWithReadiness(name string, ip string, status v1.ConditionStatus, lastTransitionTime time.Time) *v1.Node {
+	node := buildNode(name, ip, false)
+	node.Status.Conditions = []v1.NodeCondition{
+		{Type: v1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(lastTransitionTime)},
+	}
+
+	return node
+}
+
+func TestWatcher_ExcludedNode(t *testing.T) {
+	controlPlane := buildNode("control-plane", "10.0.0.1", false)
+	controlPlane.Labels = map[string]string{configuration.ControlPlaneNodeLabel: ""}
+
+	unreachable := buildNode("unreachable", "10.0.0.2", false)
+	unreachable.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute}}
+
+	preferNoSchedule := buildNode("prefer", "10.0.0.3", false)
+	preferNoSchedule.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectPreferNoSchedule}}
+
+	tests := []struct {
+		name                string
+		node                *v1.Node
+		excludeControlPlane bool
+		expected            bool
+	}{
+		{"worker node", buildNode("worker", "10.0.0.4", false), true, false},
+		{"control-plane node excluded", controlPlane, true, true},
+		{"control-plane node included", controlPlane, false, false},
+		{"excluded taint", unreachable, false, true},
+		{"excluded taint without a scheduling effect", preferNoSchedule, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			watcher, _ := buildWatcher()
+			watcher.settings.Watcher.ExcludeControlPlaneNodes = test.excludeControlPlane
+			watcher.settings.Watcher.ExcludedTaintKeys = []string{"node.kubernetes.io/unreachable"}
+
+			if actual := watcher.excludedNode(*test.node); actual != test.expected {
+				t.Errorf(`expected excluded to be %t, got %t`, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWatcher_NodeTaintChangeQueuesServiceUpdate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	k8sClient := fake.NewSimpleClientset()
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	settings.Watcher.ExcludedTaintKeys = []string{"node.kubernetes.io/unreachable"}
+	watcher, _ := NewWatcher(settings, handler)
+	_ = watchNamespace(t, watcher, "nginx-ingress").services.GetStore().Add(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}})
+
+	previous := buildNode("worker", "10.0.0.1", false)
+	updated := previous.DeepCopy()
+	updated.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/unreachable", Effect: v1.TaintEffectNoExecute}}
+
+	handle := watcher.buildEventHandlerForNodeUpdate()
+
+	handle(previous, previous.DeepCopy())
+	if len(handler.Events) != 0 {
+		t.Fatalf(`expected no events for an unchanged node, got %d`, len(handler.Events))
+	}
+
+	handle(previous, updated)
+	if len(handler.Events) != 1 {
+		t.Fatalf(`expected 1 event when the node becomes excluded, got %d`, len(handler.Events))
+	}
+}
+
+func TestWatcher_EventsCarryTheBackupNodes(t *testing.T) {
+	backupNode := buildNode("overflow", "10.0.0.2", false)
+	backupNode.Labels = map[string]string{"nkl.nginx.com/backup": "true"}
+
+	k8sClient := fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false), backupNode)
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, &mocks.MockHandler{})
+
+	nodeIps, drainingNodeIps, _ := watcher.retrieveNodeIps()
+	if event := watcher.newEvent(core.Updated, &v1.Service{}, nil, nodeIps, drainingNodeIps); event.BackupNodeIps != nil {
+		t.Fatalf(`expected no backup nodes without a backup node selector, got %v`, event.BackupNodeIps)
+	}
+
+	settings.Watcher.BackupNodeSelector, _ = labels.Parse("nkl.nginx.com/backup=true")
+
+	nodeIps, drainingNodeIps, _ = watcher.retrieveNodeIps()
+	event := watcher.newEvent(core.Updated, &v1.Service{}, nil, nodeIps, drainingNodeIps)
+
+	if !reflect.DeepEqual(nodeIps, []string{"10.0.0.1", "10.0.0.2"}) || !reflect.DeepEqual(event.BackupNodeIps, map[string]bool{"10.0.0.2": true}) {
+		t.Fatalf(`expected both nodes, with the overflow node as backup, got %v and %v`, nodeIps, event.BackupNodeIps)
+	}
+
+	// the label is removed from the live node
+	backupNode.Labels = nil
+	watcher.rememberNodeAddresses(backupNode)
+
+	if backupNodeIps := watcher.copyBackupNodeAddresses(); len(backupNodeIps) != 0 {
+		t.Fatalf(`expected the node to no longer be backup, got %v`, backupNodeIps)
+	}
+}
+
+func TestWatcher_NodeBackupLabelChangeQueuesServiceUpdate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	k8sClient := fake.NewSimpleClientset()
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	settings.Watcher.BackupNodeSelector, _ = labels.Parse("nkl.nginx.com/backup=true")
+	watcher, _ := NewWatcher(settings, handler)
+	_ = watchNamespace(t, watcher, "nginx-ingress").services.GetStore().Add(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}})
+
+	previous := buildNode("overflow", "10.0.0.2", false)
+	updated := previous.DeepCopy()
+	updated.Labels = map[string]string{"nkl.nginx.com/backup": "true"}
+
+	handle := watcher.buildEventHandlerForNodeUpdate()
+
+	handle(previous, updated)
+	if len(handler.Events) != 1 {
+		t.Fatalf(`expected 1 event when the node becomes backup, got %d`, len(handler.Events))
+	}
+}
+
+func TestWatcher_NodeStateChangeQueuesServiceUpdate(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	k8sClient := fake.NewSimpleClientset()
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, handler)
+	_ = watchNamespace(t, watcher, "nginx-ingress").services.GetStore().Add(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}})
+
+	previous := buildNode("onboarding", "10.0.0.2", false)
+	labeled := previous.DeepCopy()
+	labeled.Labels = map[string]string{configuration.NodeStateKey: configuration.NodeStateDown}
+
+	handle := watcher.buildEventHandlerForNodeUpdate()
+
+	handle(previous, labeled)
+	if len(handler.Events) != 1 || !handler.Events[0].DownNodeIps["10.0.0.2"] {
+		t.Fatalf(`expected 1 event with the node down when it is labeled down, got %d`, len(handler.Events))
+	}
+
+	// the label is removed once the node has been validated, its servers are brought up
+	handle(labeled, previous)
+	if down, managed := handler.Events[len(handler.Events)-1].DownNodeIps["10.0.0.2"]; len(handler.Events) != 2 || !managed || down {
+		t.Fatalf(`expected 1 more event with the node up when the label is removed, got %d`, len(handler.Events))
+	}
+
+	annotated := previous.DeepCopy()
+	annotated.Annotations = map[string]string{configuration.NodeStateKey: configuration.NodeStateDown}
+
+	handle(previous, annotated)
+	if len(handler.Events) != 3 || !handler.Events[2].DownNodeIps["10.0.0.2"] {
+		t.Fatalf(`expected 1 more event with the node down when it is annotated down, got %d`, len(handler.Events))
+	}
+}
+
+func TestWatcher_ServiceStatusAnnotationsChangeIsIgnored(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	k8sClient := fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, handler)
+
+	previous := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress", ResourceVersion: "1"}}
+	updated := previous.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Annotations = map[string]string{"nginxinc.io/last-synced": "2023-06-01T10:00:00Z", "nginxinc.io/server-count": "nlk-http=1"}
+
+	handle := watcher.buildEventHandlerForUpdate()
+
+	handle(previous, updated)
+	if len(handler.Events) != 0 {
+		t.Fatalf(`expected no events when only the status annotations change, got %d`, len(handler.Events))
+	}
+
+	// the periodic resyncs are not ignored
+	handle(updated, updated.DeepCopy())
+	if len(handler.Events) != 1 || !handler.Events[0].Resync {
+		t.Fatalf(`expected 1 resync event for a resync, got %d`, len(handler.Events))
+	}
+
+	changed := updated.DeepCopy()
+	changed.ResourceVersion = "3"
+	changed.Annotations["nginxinc.io/weight"] = "5"
+
+	handle(updated, changed)
+	if len(handler.Events) != 2 || handler.Events[1].Resync {
+		t.Fatalf(`expected 1 more event, not a resync, when another annotation changes, got %d`, len(handler.Events))
+	}
+
+	// an overwritten load balancer status is written again
+	overwritten := changed.DeepCopy()
+	overwritten.ResourceVersion = "4"
+	overwritten.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "203.0.113.1"}}
+
+	handle(changed, overwritten)
+	if len(handler.Events) != 3 {
+		t.Fatalf(`expected 1 more event when the load balancer status changes, got %d`, len(handler.Events))
+	}
+}
+
+func TestWatcher_EventsCarryTheNodeIpsOfThePreviousEvent(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	k8sClient := fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false))
+	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
+	watcher, _ := NewWatcher(settings, handler)
+
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress", ResourceVersion: "1"}}
+	watcher.addEvent(&core.Event{Type: core.Created, Service: service, NodeIps: []string{"10.0.0.1", "10.0.0.2"}})
+	watcher.addEvent(&core.Event{Type: core.Updated, Service: service, NodeIps: []string{"10.0.0.1"}})
+
+	if previous := handler.Events[0].PreviousNodeIps; previous != nil {
+		t.Fatalf(`expected no previous node IPs for the first event, got %v`, previous)
+	}
+
+	if previous := handler.Events[1].PreviousNodeIps; len(previous) != 2 || previous[1] != "10.0.0.2" {
+		t.Fatalf(`expected the node IPs of the previous event, got %v`, previous)
+	}
+
+	// the Service is forgotten once it is deleted
+	watcher.addEvent(&core.Event{Type: core.Deleted, Service: service, NodeIps: []string{"10.0.0.1"}})
+	watcher.addEvent(&core.Event{Type: core.Created, Service: service, NodeIps: []string{"10.0.0.1"}})
+
+	if previous := handler.Events[3].PreviousNodeIps; previous != nil {
+		t.Fatalf(`expected no previous node IPs once the Service was deleted, got %v`, previous)
+	}
+}
+
+func TestWatcher_DeleteEventHandlersUnwrapTombstones(t *testing.T) {
+	handler := &mocks.MockHandler{}
+	settings, _ := configuration.NewSettings(context.Background(), fake.NewSimpleClientset(buildNode("worker", "10.0.0.1", false)))
+	watcher, _ := NewWatcher(settings, handler)
+
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"}}
+	watcher.buildEventHandlerForDelete()(cache.DeletedFinalStateUnknown{Key: "nginx-ingress/nginx-ingress", Obj: service})
+
+	if len(handler.Events) != 1 || handler.Events[0].Type != core.Deleted || handler.Events[0].Service.Name != "nginx-ingress" {
+		t.Fatalf(`expected a Deleted event for the service of the tombstone, got %#v`, handler.Events)
+	}
+
+	if !reflect.DeepEqual(handler.Events[0].NodeIps, []string{"10.0.0.1"}) {
+		t.Errorf(`expected the servers on every node to be deleted, got %v`, handler.Events[0].NodeIps)
+	}
+
+	watcher.buildEventHandlerForNodeDelete()(cache.DeletedFinalStateUnknown{Key: "gone", Obj: buildNode("gone", "10.0.0.2", false)})
+
+	if !watcher.knownNodeAddresses["10.0.0.2"] {
+		t.Errorf(`expected the address of the node of the tombstone to be known, got %v`, watcher.knownNodeAddresses)
+	}
+
+	// an unexpected object is ignored rather than panicking
+	watcher.buildEventHandlerForDelete()(cache.DeletedFinalStateUnknown{Key: "unknown", Obj: &v1.Pod{}})
+
+	if len(handler.Events) != 1 {
+		t.Errorf(`expected no event for an unexpected object, got %d events`, len(handler.Events))
+	}
+}
+
+func buildNode(name string, ip string, unschedulable bool) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.NodeSpec{Unschedulable: unschedulable},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: ip}},
+		},
+	}
+}
+
 func buildWatcher() (*Watcher, error) {
 	k8sClient := &kubernetes.Clientset{}
 	settings, _ := configuration.NewSettings(context.Background(), k8sClient)
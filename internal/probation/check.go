@@ -5,6 +5,8 @@
 
 package probation
 
+import "sync/atomic"
+
 // Check defines a single method that can be implemented for various health checks.
 type Check interface {
 	Check() bool
@@ -15,7 +17,9 @@ type LiveCheck struct {
 }
 
 // ReadyCheck is a check that can be used for the k8s "readyz" endpoint.
+// It passes until a HostsCheck is set, then reflects the connectivity to the NGINX Plus hosts.
 type ReadyCheck struct {
+	hostsCheck atomic.Pointer[HostsCheck]
 }
 
 // StartupCheck is a check that can be used for the k8s "startupz" endpoint.
@@ -29,7 +33,27 @@ func (l *LiveCheck) Check() bool {
 
 // Check implements the Check interface for the ReadyCheck type.
 func (r *ReadyCheck) Check() bool {
-	return true
+	hostsCheck := r.hostsCheck.Load()
+	if hostsCheck == nil {
+		return true
+	}
+
+	return hostsCheck.Check()
+}
+
+// Failures returns the NGINX Plus hosts that could not be reached, with their errors.
+func (r *ReadyCheck) Failures() map[string]string {
+	hostsCheck := r.hostsCheck.Load()
+	if hostsCheck == nil {
+		return nil
+	}
+
+	return hostsCheck.Failures()
+}
+
+// SetHostsCheck sets the HostsCheck used by the ReadyCheck, nil restores a check that always passes.
+func (r *ReadyCheck) SetHostsCheck(hostsCheck *HostsCheck) {
+	r.hostsCheck.Store(hostsCheck)
 }
 
 // Check implements the Check interface for the StartupCheck type.
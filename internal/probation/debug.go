@@ -0,0 +1,63 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package probation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NotLeading is the message returned by the debug endpoint when no state source is set, e.g. on a standby replica.
+const NotLeading = "The controller is not running on this replica"
+
+// DebugEndpoint serves the state of the controller as JSON on the "debug" endpoint.
+// It responds with ServiceNotAvailable until a state source is set.
+type DebugEndpoint struct {
+	source atomic.Pointer[func() any]
+}
+
+// SetSource sets the function that provides the state to serve, typically Synchronizer::Snapshot; nil unsets it.
+// The function is called on each request, so it should return a snapshot of the state held in memory.
+func (d *DebugEndpoint) SetSource(source func() any) {
+	if source == nil {
+		d.source.Store(nil)
+		return
+	}
+
+	d.source.Store(&source)
+}
+
+// ServeHTTP writes the state returned by the source as JSON.
+func (d *DebugEndpoint) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	source := d.source.Load()
+	if source == nil {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+
+		if _, err := fmt.Fprintf(writer, "%s\n%s", ServiceNotAvailable, NotLeading); err != nil {
+			logrus.Error(err)
+		}
+
+		return
+	}
+
+	body, err := json.MarshalIndent((*source)(), "", "  ")
+	if err != nil {
+		logrus.WithError(err).Error(`DebugEndpoint::ServeHTTP: error occurred encoding the state`)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := writer.Write(body); err != nil {
+		logrus.Error(err)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package probation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugEndpoint_ServesTheStateAsJson(t *testing.T) {
+	debug := DebugEndpoint{}
+	debug.SetSource(func() any { return map[string][]string{"nginxPlusHosts": {"https://localhost:8080"}} })
+
+	recorder := httptest.NewRecorder()
+	debug.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf(`expected a JSON response, got %d %v`, recorder.Code, recorder.Header())
+	}
+
+	if !strings.Contains(recorder.Body.String(), `"https://localhost:8080"`) {
+		t.Errorf(`expected the state in the body, got %s`, recorder.Body.String())
+	}
+}
+
+func TestDebugEndpoint_IsNotAvailableWithoutASource(t *testing.T) {
+	debug := DebugEndpoint{}
+	debug.SetSource(func() any { return nil })
+	debug.SetSource(nil)
+
+	recorder := httptest.NewRecorder()
+	debug.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf(`expected %d, got %d`, http.StatusServiceUnavailable, recorder.Code)
+	}
+}
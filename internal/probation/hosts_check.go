@@ -0,0 +1,220 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package probation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// NoHostsFailure is the failure reported when no NGINX Plus hosts are configured.
+	NoHostsFailure = "no NGINX Plus hosts are configured"
+
+	// HostCheckTimeout bounds each call to an NGINX Plus host, so the probe answers within the kubelet probe timeout.
+	HostCheckTimeout = time.Second * 3
+)
+
+// HostsCheck is a Check that passes when the NGINX Plus API of the configured hosts can be reached:
+// at least one host, or every host when requireAll is set.
+// The result is cached for the interval, so the probes do not put load on the NGINX Plus hosts.
+type HostsCheck struct {
+
+	// hosts returns the NGINX Plus API endpoints, e.g. https://10.0.0.1:9000/api, to check.
+	hosts func() []string
+
+	// httpClient is used to call the NGINX Plus API, it carries the TLS configuration and credentials.
+	httpClient *http.Client
+
+	// requireAll determines whether every host, rather than at least one host, must be reachable.
+	requireAll bool
+
+	// interval is how long a result is cached.
+	interval time.Duration
+
+	// degradation returns why the replica is degraded, e.g. the ConfigMap was deleted and the hosts are only retained,
+	// or an empty string; nil when not set, see SetDegradation.
+	degradation func() string
+
+	// diagnoses returns why the hosts that failed their connectivity probe could not be reached, by endpoint, e.g. the
+	// DNS lookup failed; nil when not set, see SetDiagnoses.
+	diagnoses func() map[string]string
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	// lock guards the cached result; it is held while the hosts are checked, so concurrent probes share a single check.
+	lock sync.Mutex
+
+	// checkedAt is when the hosts were last checked.
+	checkedAt time.Time
+
+	// ready is the cached result.
+	ready bool
+
+	// failures is the cached error for each host that could not be reached.
+	failures map[string]string
+}
+
+// NewHostsCheck creates a new HostsCheck.
+func NewHostsCheck(hosts func() []string, httpClient *http.Client, requireAll bool, interval time.Duration) *HostsCheck {
+	return &HostsCheck{
+		hosts:      hosts,
+		httpClient: httpClient,
+		requireAll: requireAll,
+		interval:   interval,
+		now:        time.Now,
+	}
+}
+
+// SetDegradation sets the function reporting why the replica is degraded; while it reports a reason the check fails
+// with that reason, whether or not the hosts can be reached.
+func (h *HostsCheck) SetDegradation(degradation func() string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.degradation = degradation
+}
+
+// SetDiagnoses sets the function reporting why the hosts that failed their connectivity probe could not be reached; the
+// diagnosis of a host is added to its failure, so the readiness failures explain the cause, e.g. a 404 on the API path.
+func (h *HostsCheck) SetDiagnoses(diagnoses func() map[string]string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.diagnoses = diagnoses
+}
+
+// Check implements the Check interface for the HostsCheck type.
+func (h *HostsCheck) Check() bool {
+	ready, _ := h.result()
+	return ready
+}
+
+// Failures returns the hosts that could not be reached, with their errors, as of the last check.
+func (h *HostsCheck) Failures() map[string]string {
+	_, failures := h.result()
+	return failures
+}
+
+// result returns the cached result, checking the hosts again once the interval has elapsed.
+func (h *HostsCheck) result() (bool, map[string]string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.checkedAt.IsZero() || h.now().Sub(h.checkedAt) >= h.interval {
+		h.ready, h.failures = h.checkHosts()
+		h.checkedAt = h.now()
+	}
+
+	return h.ready, h.failures
+}
+
+// checkHosts calls the NGINX Plus API of each host concurrently.
+func (h *HostsCheck) checkHosts() (bool, map[string]string) {
+	hosts := h.hosts()
+	failures := make(map[string]string)
+
+	if h.degradation != nil {
+		if reason := h.degradation(); reason != "" {
+			failures[""] = reason
+			return false, failures
+		}
+	}
+
+	if len(hosts) == 0 {
+		failures[""] = NoHostsFailure
+		return false, failures
+	}
+
+	var lock sync.Mutex
+	var group sync.WaitGroup
+
+	for _, host := range hosts {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			if err := h.checkHost(host); err != nil {
+				lock.Lock()
+				failures[host] = err.Error()
+				lock.Unlock()
+			}
+		}()
+	}
+
+	group.Wait()
+
+	if h.diagnoses != nil && len(failures) > 0 {
+		for host, diagnosis := range h.diagnoses() {
+			if failure, failed := failures[host]; failed {
+				failures[host] = fmt.Sprintf("%s (%s)", failure, diagnosis)
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		logrus.WithField("failures", describeFailures(failures)).Warnf("HostsCheck::checkHosts: %d of %d NGINX Plus host(s) could not be reached", len(failures), len(hosts))
+	}
+
+	if h.requireAll {
+		return len(failures) == 0, failures
+	}
+
+	return len(failures) < len(hosts), failures
+}
+
+// checkHost calls the NGINX Plus API of the host, any response other than a 2xx is a failure.
+func (h *HostsCheck) checkHost(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), HostCheckTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, host, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(`unexpected status %s`, response.Status)
+	}
+
+	return nil
+}
+
+// describeFailures formats the failures, sorted by host, one per line.
+func describeFailures(failures map[string]string) string {
+	hosts := make([]string, 0, len(failures))
+	for host := range failures {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+
+	description := ""
+	for _, host := range hosts {
+		if host == "" {
+			description += fmt.Sprintf("%s\n", failures[host])
+		} else {
+			description += fmt.Sprintf("%s: %s\n", host, failures[host])
+		}
+	}
+
+	return description
+}
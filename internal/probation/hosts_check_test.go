@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package probation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+)
+
+func TestHostsCheck_AnyHostReachable(t *testing.T) {
+	reachable := buildApiServer(t, http.StatusOK, nil)
+	unreachable := buildApiServer(t, http.StatusBadGateway, nil)
+
+	check := NewHostsCheck(hostsOf(reachable.URL, unreachable.URL), http.DefaultClient, false, time.Minute)
+
+	if !check.Check() {
+		t.Fatalf(`expected the check to pass when one host is reachable`)
+	}
+
+	failures := check.Failures()
+	if len(failures) != 1 || !strings.Contains(failures[unreachable.URL], "502") {
+		t.Fatalf(`expected the unreachable host to be reported, got %v`, failures)
+	}
+}
+
+func TestHostsCheck_AllHostsRequired(t *testing.T) {
+	reachable := buildApiServer(t, http.StatusOK, nil)
+	unreachable := buildApiServer(t, http.StatusUnauthorized, nil)
+
+	check := NewHostsCheck(hostsOf(reachable.URL, unreachable.URL), http.DefaultClient, true, time.Minute)
+
+	if check.Check() {
+		t.Fatalf(`expected the check to fail when a host is unreachable and all hosts are required`)
+	}
+}
+
+func TestHostsCheck_NoHosts(t *testing.T) {
+	check := NewHostsCheck(hostsOf(), http.DefaultClient, false, time.Minute)
+
+	if check.Check() {
+		t.Fatalf(`expected the check to fail without hosts`)
+	}
+
+	if check.Failures()[""] != NoHostsFailure {
+		t.Fatalf(`expected the missing hosts to be reported, got %v`, check.Failures())
+	}
+}
+
+func TestHostsCheck_Degraded(t *testing.T) {
+	server := buildApiServer(t, http.StatusOK, nil)
+
+	now := time.Now()
+	reason := "the ConfigMap was deleted"
+	check := NewHostsCheck(hostsOf(server.URL), http.DefaultClient, false, time.Second)
+	check.now = func() time.Time { return now }
+	check.SetDegradation(func() string { return reason })
+
+	if check.Check() || check.Failures()[""] != reason {
+		t.Fatalf(`expected the check to fail with the degradation, got %v`, check.Failures())
+	}
+
+	reason = ""
+	now = now.Add(time.Second)
+
+	if !check.Check() {
+		t.Fatalf(`expected the check to pass once no longer degraded, got %v`, check.Failures())
+	}
+}
+
+func TestHostsCheck_FailuresIncludeTheDiagnoses(t *testing.T) {
+	unreachable := buildApiServer(t, http.StatusNotFound, nil)
+
+	check := NewHostsCheck(hostsOf(unreachable.URL), http.DefaultClient, false, time.Minute)
+	check.SetDiagnoses(func() map[string]string {
+		return map[string]string{unreachable.URL: "the API path was not found (404)", "https://10.0.0.9:9000/api": "the DNS lookup failed"}
+	})
+
+	if check.Check() {
+		t.Fatalf(`expected the check to fail`)
+	}
+
+	failures := check.Failures()
+	if len(failures) != 1 || !strings.HasSuffix(failures[unreachable.URL], "(the API path was not found (404))") {
+		t.Fatalf(`expected the failure to include the diagnosis of the host, got %v`, failures)
+	}
+}
+
+func TestHostsCheck_CachesTheResult(t *testing.T) {
+	var calls atomic.Int32
+	server := buildApiServer(t, http.StatusOK, &calls)
+
+	now := time.Now()
+	check := NewHostsCheck(hostsOf(server.URL), http.DefaultClient, false, time.Second*10)
+	check.now = func() time.Time { return now }
+
+	check.Check()
+	check.Check()
+
+	if calls.Load() != 1 {
+		t.Fatalf(`expected the result to be cached, got %d calls`, calls.Load())
+	}
+
+	now = now.Add(time.Second * 10)
+	check.Check()
+
+	if calls.Load() != 2 {
+		t.Fatalf(`expected the hosts to be checked again after the interval, got %d calls`, calls.Load())
+	}
+}
+
+func TestHealthServer_HandleReadyReportsFailingHosts(t *testing.T) {
+	unreachable := buildApiServer(t, http.StatusBadGateway, nil)
+
+	server := NewHealthServer()
+	server.ReadyCheck.SetHostsCheck(NewHostsCheck(hostsOf(unreachable.URL), http.DefaultClient, false, time.Minute))
+	writer := mocks.NewMockResponseWriter()
+
+	server.HandleReady(writer, nil)
+
+	body := string(writer.Body())
+	if !strings.HasPrefix(body, ServiceNotAvailable) || !strings.Contains(body, unreachable.URL) {
+		t.Fatalf(`expected the failing host in the response body, got %q`, body)
+	}
+
+	server.ReadyCheck.SetHostsCheck(nil)
+	if !server.ReadyCheck.Check() {
+		t.Fatalf(`expected the ReadyCheck to pass once the HostsCheck has been removed`)
+	}
+}
+
+func buildApiServer(t *testing.T, status int, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		if calls != nil {
+			calls.Add(1)
+		}
+		writer.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func hostsOf(hosts ...string) func() []string {
+	return func() []string {
+		return hosts
+	}
+}
@@ -8,6 +8,7 @@ package probation
 import (
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 )
 
@@ -37,6 +38,12 @@ type HealthServer struct {
 
 	// Support for the "startupz" endpoint.
 	StartupCheck StartupCheck
+
+	// Support for the "debug" endpoint, which is only served when DebugEnabled is set before Start.
+	Debug DebugEndpoint
+
+	// DebugEnabled serves the "debug" endpoint, see the --debug-endpoint flag.
+	DebugEnabled bool
 }
 
 // NewHealthServer creates a new HealthServer.
@@ -58,10 +65,20 @@ func (hs *HealthServer) Start() {
 	mux.HandleFunc("/livez", hs.HandleLive)
 	mux.HandleFunc("/readyz", hs.HandleReady)
 	mux.HandleFunc("/startupz", hs.HandleStartup)
+	if hs.DebugEnabled {
+		mux.Handle("/debug", &hs.Debug)
+	}
 	hs.httpServer = &http.Server{Addr: address, Handler: mux}
 
+	// Bind synchronously so the endpoints are reachable as soon as Start returns.
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logrus.Errorf("unable to start probe listener on %s: %v", hs.httpServer.Addr, err)
+		return
+	}
+
 	go func() {
-		if err := hs.httpServer.ListenAndServe(); err != nil {
+		if err := hs.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
 			logrus.Errorf("unable to start probe listener on %s: %v", hs.httpServer.Addr, err)
 		}
 	}()
@@ -101,10 +118,20 @@ func (hs *HealthServer) handleProbe(writer http.ResponseWriter, _ *http.Request,
 		}
 
 	} else {
+		body := ServiceNotAvailable
+		if reporter, ok := check.(failureReporter); ok && len(reporter.Failures()) > 0 {
+			body = fmt.Sprintf("%s\n%s", ServiceNotAvailable, describeFailures(reporter.Failures()))
+		}
+
 		writer.WriteHeader(http.StatusServiceUnavailable)
 
-		if _, err := fmt.Fprint(writer, ServiceNotAvailable); err != nil {
+		if _, err := fmt.Fprint(writer, body); err != nil {
 			logrus.Error(err)
 		}
 	}
 }
+
+// failureReporter is implemented by the checks that can describe why they failed, e.g. the ReadyCheck.
+type failureReporter interface {
+	Failures() map[string]string
+}
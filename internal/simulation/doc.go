@@ -0,0 +1,11 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+/*
+Package simulation includes a fake NGINX Plus API, to exercise the Watcher, Handler, Translator, and Synchronizer
+without an NGINX Plus license, in the integration tests and with the --mock-nginx flag.
+*/
+
+package simulation
@@ -0,0 +1,500 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package simulation
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ApiPath is the path the fake serves the NGINX Plus API under, see NginxPlus::Endpoint.
+	ApiPath = "/api"
+
+	// HttpContext identifies the http upstreams and keyval zones.
+	HttpContext = "http"
+
+	// StreamContext identifies the stream upstreams and keyval zones.
+	StreamContext = "stream"
+
+	// Version is the nginx version reported by the fake, e.g. to the connectivity probe of the Synchronizer.
+	Version = "1.27.2"
+
+	// Build is the NGINX Plus release reported by the fake.
+	Build = "nginx-plus-r33"
+
+	// documentationUrl is the href of the error responses, as sent by NGINX Plus.
+	documentationUrl = "https://nginx.org/en/docs/http/ngx_http_api_module.html"
+)
+
+// apiVersions are the versions of the NGINX Plus API served by the fake.
+var apiVersions = []int{4, 5, 6, 7, 8, 9}
+
+// server is an upstream server, holding the parameters as sent by the client, and the id assigned by the fake.
+type server map[string]any
+
+// NginxPlus is a fake NGINX Plus API serving the endpoints used by the Border Clients: the upstreams and their servers,
+// and the keyval zones, of both the http and stream contexts, and the version of NGINX Plus. The state is held in memory, and the errors are reported
+// with the status and code NGINX Plus responds with, so the NGINX Plus client reports them the same way.
+type NginxPlus struct {
+
+	// DefineOnDemand defines the upstreams and keyval zones the first time they are used, for demos. Otherwise, only the
+	// upstreams and zones defined with DefineUpstream and DefineKeyValZone exist, as with an NGINX Plus configuration.
+	DefineOnDemand bool
+
+	// lock guards the upstreams, keyVals, and nextId, the requests are served concurrently.
+	lock sync.Mutex
+
+	// upstreams holds the servers of the upstreams, by context and name.
+	upstreams map[string]map[string][]server
+
+	// keyVals holds the key-value pairs of the keyval zones, by context and zone.
+	keyVals map[string]map[string]map[string]string
+
+	// nextId is the id assigned to the next server added, the ids are unique across the upstreams.
+	nextId int
+
+	// httpServer serves the API once started.
+	httpServer *httptest.Server
+}
+
+// NewNginxPlus is a factory method to create a new NginxPlus, without upstreams or keyval zones.
+func NewNginxPlus() *NginxPlus {
+	return &NginxPlus{
+		upstreams: map[string]map[string][]server{HttpContext: {}, StreamContext: {}},
+		keyVals:   map[string]map[string]map[string]string{HttpContext: {}, StreamContext: {}},
+	}
+}
+
+// Start serves the API on a local port, and returns its Endpoint.
+func (n *NginxPlus) Start() string {
+	n.httpServer = httptest.NewServer(n)
+
+	logrus.Infof("NginxPlus::Start: serving a fake NGINX Plus API at %s", n.Endpoint())
+
+	return n.Endpoint()
+}
+
+// Endpoint returns the base URL of the API, the nginx-hosts entry of the fake.
+func (n *NginxPlus) Endpoint() string {
+	return n.httpServer.URL + ApiPath
+}
+
+// Close stops serving the API.
+func (n *NginxPlus) Close() {
+	n.httpServer.Close()
+}
+
+// DefineUpstream defines an upstream without servers in the context, HttpContext or StreamContext.
+func (n *NginxPlus) DefineUpstream(context string, name string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, found := n.upstreams[context][name]; !found {
+		n.upstreams[context][name] = []server{}
+	}
+}
+
+// DefineKeyValZone defines an empty keyval zone in the context, HttpContext or StreamContext.
+func (n *NginxPlus) DefineKeyValZone(context string, zone string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, found := n.keyVals[context][zone]; !found {
+		n.keyVals[context][zone] = map[string]string{}
+	}
+}
+
+// HttpServers returns the servers of the http upstream, nil if it is not defined.
+func (n *NginxPlus) HttpServers(name string) []nginxClient.UpstreamServer {
+	var servers []nginxClient.UpstreamServer
+	n.decodeServers(HttpContext, name, &servers)
+
+	return servers
+}
+
+// StreamServers returns the servers of the stream upstream, nil if it is not defined.
+func (n *NginxPlus) StreamServers(name string) []nginxClient.StreamUpstreamServer {
+	var servers []nginxClient.StreamUpstreamServer
+	n.decodeServers(StreamContext, name, &servers)
+
+	return servers
+}
+
+// KeyValPairs returns a copy of the key-value pairs of the keyval zone of the context, nil if it is not defined.
+func (n *NginxPlus) KeyValPairs(context string, zone string) map[string]string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return maps.Clone(n.keyVals[context][zone])
+}
+
+// ServeHTTP routes the request to the endpoint of its path, under ApiPath and the API version, e.g. /api/9/http/upstreams.
+func (n *NginxPlus) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	path, found := strings.CutPrefix(request.URL.Path, ApiPath)
+	if !found || (path != "" && !strings.HasPrefix(path, "/")) {
+		writeError(writer, http.StatusNotFound, "PathNotFound", "path not found")
+		return
+	}
+
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		if request.Method != http.MethodGet {
+			writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+			return
+		}
+
+		writeJson(writer, http.StatusOK, apiVersions)
+		return
+	}
+
+	if version, err := strconv.Atoi(segments[0]); err != nil || !slices.Contains(apiVersions, version) {
+		writeError(writer, http.StatusNotFound, "UnknownVersion", "unknown version")
+		return
+	}
+
+	segments = segments[1:]
+	if len(segments) == 1 && segments[0] == "nginx" {
+		n.serveNginx(writer, request)
+		return
+	}
+
+	if len(segments) < 2 || (segments[0] != HttpContext && segments[0] != StreamContext) {
+		writeError(writer, http.StatusNotFound, "PathNotFound", "path not found")
+		return
+	}
+
+	context := segments[0]
+
+	switch {
+	case len(segments) == 2 && segments[1] == "upstreams":
+		n.serveUpstreams(writer, request, context)
+	case len(segments) == 4 && segments[1] == "upstreams" && segments[3] == "servers":
+		n.serveServers(writer, request, context, segments[2])
+	case len(segments) == 5 && segments[1] == "upstreams" && segments[3] == "servers":
+		n.serveServer(writer, request, context, segments[2], segments[4])
+	case len(segments) == 3 && segments[1] == "keyvals":
+		n.serveKeyVals(writer, request, context, segments[2])
+	default:
+		writeError(writer, http.StatusNotFound, "PathNotFound", "path not found")
+	}
+}
+
+// serveNginx returns the version and build of NGINX Plus, e.g. /api/9/nginx.
+func (n *NginxPlus) serveNginx(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+		return
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{"version": Version, "build": Build})
+}
+
+// serveUpstreams returns the upstreams of the context with the state of their peers, e.g. /api/9/http/upstreams.
+func (n *NginxPlus) serveUpstreams(writer http.ResponseWriter, request *http.Request, context string) {
+	if request.Method != http.MethodGet {
+		writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+		return
+	}
+
+	upstreams := make(map[string]any, len(n.upstreams[context]))
+	for name, servers := range n.upstreams[context] {
+		peers := make([]map[string]any, 0, len(servers))
+		for _, upstreamServer := range servers {
+			peers = append(peers, peerOf(upstreamServer))
+		}
+
+		upstreams[name] = map[string]any{"zone": name, "peers": peers, "keepalive": 0, "zombies": 0}
+	}
+
+	writeJson(writer, http.StatusOK, upstreams)
+}
+
+// serveServers returns or adds the servers of an upstream, e.g. /api/9/http/upstreams/backend/servers.
+func (n *NginxPlus) serveServers(writer http.ResponseWriter, request *http.Request, context string, name string) {
+	servers, found := n.upstreamOf(context, name)
+	if !found {
+		writeError(writer, http.StatusNotFound, "UpstreamNotFound", "upstream not found")
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, http.StatusOK, servers)
+
+	case http.MethodPost:
+		var added server
+		if err := json.NewDecoder(request.Body).Decode(&added); err != nil {
+			writeError(writer, http.StatusBadRequest, "UpstreamConfFormatError", "error while parsing json")
+			return
+		}
+
+		address, _ := added["server"].(string)
+		if address == "" {
+			writeError(writer, http.StatusBadRequest, "UpstreamConfFormatError", `missing "server" argument`)
+			return
+		}
+
+		added["id"] = n.nextId
+		n.nextId++
+		n.upstreams[context][name] = append(servers, added)
+
+		logrus.WithFields(logrus.Fields{"context": context, "upstream": name, "server": address, "id": added["id"]}).
+			Info("NginxPlus::serveServers: added the server")
+
+		writeJson(writer, http.StatusCreated, added)
+
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+	}
+}
+
+// serveServer returns, updates, or deletes a server of an upstream by its id, e.g. /api/9/http/upstreams/backend/servers/3.
+func (n *NginxPlus) serveServer(writer http.ResponseWriter, request *http.Request, context string, name string, id string) {
+	servers, found := n.upstreamOf(context, name)
+	if !found {
+		writeError(writer, http.StatusNotFound, "UpstreamNotFound", "upstream not found")
+		return
+	}
+
+	index := -1
+	for position, upstreamServer := range servers {
+		if strconv.Itoa(idOf(upstreamServer)) == id {
+			index = position
+			break
+		}
+	}
+
+	if index < 0 {
+		writeError(writer, http.StatusNotFound, "UpstreamServerNotFound", "server not found")
+		return
+	}
+
+	fields := logrus.Fields{"context": context, "upstream": name, "server": servers[index]["server"], "id": id}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, http.StatusOK, servers[index])
+
+	case http.MethodPatch:
+		var changes server
+		if err := json.NewDecoder(request.Body).Decode(&changes); err != nil {
+			writeError(writer, http.StatusBadRequest, "UpstreamConfFormatError", "error while parsing json")
+			return
+		}
+
+		// the client sends all the parameters of the server, omitting the defaults, so they replace the current ones
+		changes["id"] = servers[index]["id"]
+		if _, found := changes["server"]; !found {
+			changes["server"] = servers[index]["server"]
+		}
+		servers[index] = changes
+
+		logrus.WithFields(fields).WithField("parameters", changes).Info("NginxPlus::serveServer: updated the server")
+
+		writeJson(writer, http.StatusOK, servers[index])
+
+	case http.MethodDelete:
+		servers = slices.Delete(servers, index, index+1)
+		n.upstreams[context][name] = servers
+
+		logrus.WithFields(fields).Info("NginxPlus::serveServer: deleted the server")
+
+		writeJson(writer, http.StatusOK, servers)
+
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+	}
+}
+
+// serveKeyVals returns, adds, updates, or deletes the key-value pairs of a keyval zone, e.g. /api/9/http/keyvals/allowed.
+// A key is deleted by updating it with a null value.
+func (n *NginxPlus) serveKeyVals(writer http.ResponseWriter, request *http.Request, context string, zone string) {
+	pairs, found := n.keyVals[context][zone]
+	if !found && n.DefineOnDemand {
+		pairs = map[string]string{}
+		n.keyVals[context][zone] = pairs
+	} else if !found {
+		writeError(writer, http.StatusNotFound, "KeyvalZoneNotFound", "keyval not found")
+		return
+	}
+
+	fields := logrus.Fields{"context": context, "zone": zone}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, http.StatusOK, pairs)
+
+	case http.MethodPost:
+		var added map[string]string
+		if err := json.NewDecoder(request.Body).Decode(&added); err != nil {
+			writeError(writer, http.StatusBadRequest, "KeyvalFormatError", "error while parsing json")
+			return
+		}
+
+		for key := range added {
+			if _, exists := pairs[key]; exists {
+				writeError(writer, http.StatusConflict, "KeyvalKeyExists", "key already exists")
+				return
+			}
+		}
+
+		maps.Copy(pairs, added)
+
+		logrus.WithFields(fields).WithField("keys", sortedKeys(added)).Info("NginxPlus::serveKeyVals: added the keys")
+
+		writer.WriteHeader(http.StatusCreated)
+
+	case http.MethodPatch:
+		var changes map[string]*string
+		if err := json.NewDecoder(request.Body).Decode(&changes); err != nil {
+			writeError(writer, http.StatusBadRequest, "KeyvalFormatError", "error while parsing json")
+			return
+		}
+
+		for key := range changes {
+			if _, exists := pairs[key]; !exists {
+				writeError(writer, http.StatusNotFound, "KeyvalKeyNotFound", "key not found")
+				return
+			}
+		}
+
+		for key, value := range changes {
+			if value == nil {
+				delete(pairs, key)
+			} else {
+				pairs[key] = *value
+			}
+		}
+
+		logrus.WithFields(fields).WithField("keys", sortedKeys(changes)).Info("NginxPlus::serveKeyVals: updated the keys")
+
+		writer.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		clear(pairs)
+
+		logrus.WithFields(fields).Info("NginxPlus::serveKeyVals: deleted the keys")
+
+		writer.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, "MethodNotSupported", "method not supported")
+	}
+}
+
+// upstreamOf returns the servers of the upstream, defining it when DefineOnDemand is set.
+func (n *NginxPlus) upstreamOf(context string, name string) ([]server, bool) {
+	servers, found := n.upstreams[context][name]
+	if !found && n.DefineOnDemand {
+		servers = []server{}
+		n.upstreams[context][name] = servers
+
+		logrus.WithFields(logrus.Fields{"context": context, "upstream": name}).Info("NginxPlus::upstreamOf: defined the upstream")
+
+		return servers, true
+	}
+
+	return servers, found
+}
+
+// decodeServers converts the servers of the upstream to the type of the NGINX Plus client through their JSON encoding.
+func (n *NginxPlus) decodeServers(context string, name string, servers any) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	upstreamServers, found := n.upstreams[context][name]
+	if !found {
+		return
+	}
+
+	encoded, err := json.Marshal(upstreamServers)
+	if err != nil {
+		logrus.Errorf("NginxPlus::decodeServers: error occurred encoding the servers of the %s upstream: %v", name, err)
+		return
+	}
+
+	if err = json.Unmarshal(encoded, servers); err != nil {
+		logrus.Errorf("NginxPlus::decodeServers: error occurred decoding the servers of the %s upstream: %v", name, err)
+	}
+}
+
+// peerOf returns the peer of the server, as listed by the upstreams endpoint.
+func peerOf(upstreamServer server) map[string]any {
+	state := "up"
+	if down, _ := upstreamServer["down"].(bool); down {
+		state = "down"
+	} else if drain, _ := upstreamServer["drain"].(bool); drain {
+		state = "draining"
+	}
+
+	weight := 1
+	if value, found := upstreamServer["weight"].(float64); found {
+		weight = int(value)
+	}
+
+	backup, _ := upstreamServer["backup"].(bool)
+
+	return map[string]any{
+		"id":     idOf(upstreamServer),
+		"server": upstreamServer["server"],
+		"name":   upstreamServer["server"],
+		"backup": backup,
+		"weight": weight,
+		"state":  state,
+	}
+}
+
+// idOf returns the id assigned to the server.
+func idOf(upstreamServer server) int {
+	id, _ := upstreamServer["id"].(int)
+	return id
+}
+
+// sortedKeys returns the keys of the map in order, for the logs.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// writeJson writes the value as the JSON body of the response.
+func writeJson(writer http.ResponseWriter, status int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		logrus.Errorf("NginxPlus::writeJson: error occurred writing the response: %v", err)
+	}
+}
+
+// writeError writes an error response, with the body NGINX Plus responds with, e.g.:
+//
+//	{"error":{"status":404,"text":"upstream not found","code":"UpstreamNotFound"},"href":"https://nginx.org/..."}
+func writeError(writer http.ResponseWriter, status int, code string, text string) {
+	writeJson(writer, status, map[string]any{
+		"error": map[string]any{"status": status, "text": text, "code": code},
+		"href":  documentationUrl,
+	})
+}
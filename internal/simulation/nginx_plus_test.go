@@ -0,0 +1,180 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package simulation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+func TestNginxPlus_UpdatesTheHttpServers(t *testing.T) {
+	nginxPlus, client := startNginxPlus(t)
+	nginxPlus.DefineUpstream(HttpContext, "backend")
+
+	ctx := context.Background()
+
+	servers := []nginxClient.UpstreamServer{{Server: "10.0.0.1:30080"}, {Server: "10.0.0.2:30080"}}
+	if _, _, _, err := client.UpdateHTTPServers(ctx, "backend", servers); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the second server is deleted, the first one drained, and a third one added
+	servers = []nginxClient.UpstreamServer{{Server: "10.0.0.1:30080", Drain: true}, {Server: "10.0.0.3:30080"}}
+	added, deleted, updated, err := client.UpdateHTTPServers(ctx, "backend", servers)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(added) != 1 || len(deleted) != 1 || len(updated) != 1 {
+		t.Fatalf(`expected a server added, deleted, and updated, got %v, %v, %v`, added, deleted, updated)
+	}
+
+	actual := nginxPlus.HttpServers("backend")
+	if len(actual) != 2 || actual[0].Server != "10.0.0.1:30080" || !actual[0].Drain || actual[1].Server != "10.0.0.3:30080" {
+		t.Fatalf(`expected the drained and added servers, got %+v`, actual)
+	}
+
+	// the servers are left alone when they are up to date
+	added, deleted, updated, err = client.UpdateHTTPServers(ctx, "backend", servers)
+	if err != nil || len(added)+len(deleted)+len(updated) != 0 {
+		t.Fatalf(`expected no changes, got %v, %v, %v, %v`, added, deleted, updated, err)
+	}
+
+	upstreams, err := client.GetUpstreams(ctx)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	peers := (*upstreams)["backend"].Peers
+	if len(peers) != 2 || peers[0].State != "draining" || peers[1].State != "up" {
+		t.Fatalf(`expected the states of the peers, got %+v`, peers)
+	}
+}
+
+func TestNginxPlus_UpdatesTheStreamServers(t *testing.T) {
+	nginxPlus, client := startNginxPlus(t)
+	nginxPlus.DefineUpstream(StreamContext, "backend")
+
+	ctx := context.Background()
+	backup := true
+
+	servers := []nginxClient.StreamUpstreamServer{{Server: "10.0.0.1:30443"}, {Server: "10.0.0.2:30443", Backup: &backup}}
+	if _, _, _, err := client.UpdateStreamServers(ctx, "backend", servers); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if _, _, _, err := client.UpdateStreamServers(ctx, "backend", servers[1:]); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	actual := nginxPlus.StreamServers("backend")
+	if len(actual) != 1 || actual[0].Server != "10.0.0.2:30443" || actual[0].Backup == nil || !*actual[0].Backup {
+		t.Fatalf(`expected the backup server, got %+v`, actual)
+	}
+
+	if len(nginxPlus.HttpServers("backend")) != 0 {
+		t.Fatal(`expected the http upstreams not to be changed`)
+	}
+}
+
+func TestNginxPlus_ReportsTheUndefinedUpstreams(t *testing.T) {
+	_, client := startNginxPlus(t)
+
+	_, err := client.GetHTTPServers(context.Background(), "backend")
+	if err == nil || !strings.Contains(err.Error(), "UpstreamNotFound") || !strings.Contains(err.Error(), "got 404") {
+		t.Fatalf(`expected the upstream not to be found, got %v`, err)
+	}
+}
+
+func TestNginxPlus_DefinesTheUpstreamsOnDemand(t *testing.T) {
+	nginxPlus, client := startNginxPlus(t)
+	nginxPlus.DefineOnDemand = true
+
+	if err := client.AddStreamServer(context.Background(), "backend", nginxClient.StreamUpstreamServer{Server: "10.0.0.1:30443"}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(nginxPlus.StreamServers("backend")) != 1 {
+		t.Fatalf(`expected the server to be added, got %+v`, nginxPlus.StreamServers("backend"))
+	}
+}
+
+func TestNginxPlus_UpdatesTheKeyValZones(t *testing.T) {
+	nginxPlus, client := startNginxPlus(t)
+	nginxPlus.DefineKeyValZone(HttpContext, "allowed")
+
+	ctx := context.Background()
+
+	if err := client.AddKeyValPair(ctx, "allowed", "10.0.0.1", "default/web"); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	err := client.AddKeyValPair(ctx, "allowed", "10.0.0.1", "default/web")
+	if err == nil || !strings.Contains(err.Error(), "error.code=KeyvalKeyExists") {
+		t.Fatalf(`expected the key to exist, got %v`, err)
+	}
+
+	if err = client.AddKeyValPair(ctx, "allowed", "10.0.0.2", "default/web"); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err = client.DeleteKeyValuePair(ctx, "allowed", "10.0.0.1"); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	pairs, err := client.GetKeyValPairs(ctx, "allowed")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(pairs) != 1 || pairs["10.0.0.2"] != "default/web" {
+		t.Fatalf(`expected the remaining key, got %v`, pairs)
+	}
+
+	if _, err = client.GetStreamKeyValPairs(ctx, "allowed"); err == nil || !strings.Contains(err.Error(), "KeyvalZoneNotFound") {
+		t.Fatalf(`expected the stream zone not to be found, got %v`, err)
+	}
+}
+
+func TestNginxPlus_ReportsTheUnsupportedVersions(t *testing.T) {
+	nginxPlus, _ := startNginxPlus(t)
+
+	client, err := nginxClient.NewNginxClient(nginxPlus.Endpoint(), nginxClient.WithAPIVersion(9), nginxClient.WithCheckAPI())
+	if err != nil {
+		t.Fatalf(`expected the version to be supported, %v`, err)
+	}
+
+	if _, err = client.GetStreamUpstreams(context.Background()); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response, err := nginxPlus.httpServer.Client().Get(nginxPlus.Endpoint() + "/3/http/upstreams")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != 404 {
+		t.Fatalf(`expected the version not to be found, got %d`, response.StatusCode)
+	}
+}
+
+func startNginxPlus(t *testing.T) (*NginxPlus, *nginxClient.NginxClient) {
+	nginxPlus := NewNginxPlus()
+	nginxPlus.Start()
+	t.Cleanup(nginxPlus.Close)
+
+	client, err := nginxClient.NewNginxClient(nginxPlus.Endpoint())
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return nginxPlus, client
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package simulation
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/observation"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/synchronization"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// pipelineTimeout is how long the pipeline has to apply a change to the fake.
+	pipelineTimeout = 10 * time.Second
+
+	namespace = "nginx-ingress"
+)
+
+func TestPipeline_AddsTheServersOfTheNodes(t *testing.T) {
+	nginxPlus := NewNginxPlus()
+	nginxPlus.DefineUpstream(HttpContext, "web")
+	nginxPlus.DefineUpstream(StreamContext, "tcp")
+
+	k8sClient := fake.NewSimpleClientset(buildNode("worker-1", "10.0.0.1"), buildNode("worker-2", "10.0.0.2"), buildService())
+	startPipeline(t, nginxPlus, k8sClient)
+
+	awaitHttpServers(t, nginxPlus, "web", "10.0.0.1:30080", "10.0.0.2:30080")
+	awaitStreamServers(t, nginxPlus, "tcp", "10.0.0.1:30443", "10.0.0.2:30443")
+}
+
+func TestPipeline_FollowsTheNodes(t *testing.T) {
+	nginxPlus := NewNginxPlus()
+	nginxPlus.DefineUpstream(HttpContext, "web")
+	nginxPlus.DefineUpstream(StreamContext, "tcp")
+
+	k8sClient := fake.NewSimpleClientset(buildNode("worker-1", "10.0.0.1"), buildService())
+	startPipeline(t, nginxPlus, k8sClient)
+
+	awaitHttpServers(t, nginxPlus, "web", "10.0.0.1:30080")
+
+	ctx := context.Background()
+	if _, err := k8sClient.CoreV1().Nodes().Create(ctx, buildNode("worker-2", "10.0.0.2"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	awaitHttpServers(t, nginxPlus, "web", "10.0.0.1:30080", "10.0.0.2:30080")
+	awaitStreamServers(t, nginxPlus, "tcp", "10.0.0.1:30443", "10.0.0.2:30443")
+
+	if err := k8sClient.CoreV1().Nodes().Delete(ctx, "worker-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	awaitHttpServers(t, nginxPlus, "web", "10.0.0.2:30080")
+	awaitStreamServers(t, nginxPlus, "tcp", "10.0.0.2:30443")
+}
+
+func TestPipeline_DeletesTheServersOfTheDeletedService(t *testing.T) {
+	nginxPlus := NewNginxPlus()
+	nginxPlus.DefineUpstream(HttpContext, "web")
+	nginxPlus.DefineUpstream(StreamContext, "tcp")
+
+	k8sClient := fake.NewSimpleClientset(buildNode("worker-1", "10.0.0.1"), buildService())
+	startPipeline(t, nginxPlus, k8sClient)
+
+	awaitHttpServers(t, nginxPlus, "web", "10.0.0.1:30080")
+
+	if err := k8sClient.CoreV1().Services(namespace).Delete(context.Background(), "nginx-ingress", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	awaitHttpServers(t, nginxPlus, "web")
+	awaitStreamServers(t, nginxPlus, "tcp")
+}
+
+// startPipeline runs the Watcher, Handler, and Synchronizer against the fake NGINX Plus API until the test completes.
+func startPipeline(t *testing.T, nginxPlus *NginxPlus, k8sClient kubernetes.Interface) {
+	t.Helper()
+
+	endpoint := nginxPlus.Start()
+	t.Cleanup(nginxPlus.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings, err := configuration.NewSettingsWithOverrides(ctx, k8sClient, configuration.Overrides{NginxHosts: []string{endpoint}})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Synchronizer.MinMillisecondsJitter = 0
+	settings.Synchronizer.MaxMillisecondsJitter = 1
+	settings.Synchronizer.CoalesceWindow = 0
+	settings.Synchronizer.PersistState = false
+	settings.Handler.DeleteDeferral = 100 * time.Millisecond
+
+	synchronizerQueue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second))
+	synchronizer, err := synchronization.NewSynchronizer(settings, synchronizerQueue)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	handlerQueue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second))
+	handler := observation.NewHandler(settings, synchronizer, handlerQueue)
+
+	watcher, err := observation.NewWatcher(settings, handler)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err = watcher.Initialize(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	watched := make(chan error, 1)
+
+	go handler.Run(ctx.Done())
+	go synchronizer.Run(ctx.Done())
+	go func() { watched <- watcher.Watch() }()
+
+	t.Cleanup(func() {
+		cancel()
+
+		// the informers may still be syncing when the test completes
+		if err := <-watched; err != nil {
+			t.Logf(`the watcher stopped: %v`, err)
+		}
+
+		synchronizer.ShutDown()
+	})
+}
+
+// awaitHttpServers waits for the servers of the http upstream to be the expected ones.
+func awaitHttpServers(t *testing.T, nginxPlus *NginxPlus, upstream string, expected ...string) {
+	t.Helper()
+
+	awaitServers(t, upstream, expected, func() []string {
+		var servers []string
+		for _, server := range nginxPlus.HttpServers(upstream) {
+			servers = append(servers, server.Server)
+		}
+
+		return servers
+	})
+}
+
+// awaitStreamServers waits for the servers of the stream upstream to be the expected ones.
+func awaitStreamServers(t *testing.T, nginxPlus *NginxPlus, upstream string, expected ...string) {
+	t.Helper()
+
+	awaitServers(t, upstream, expected, func() []string {
+		var servers []string
+		for _, server := range nginxPlus.StreamServers(upstream) {
+			servers = append(servers, server.Server)
+		}
+
+		return servers
+	})
+}
+
+func awaitServers(t *testing.T, upstream string, expected []string, servers func() []string) {
+	t.Helper()
+
+	sort.Strings(expected)
+
+	var actual []string
+	for deadline := time.Now().Add(pipelineTimeout); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		actual = servers()
+		sort.Strings(actual)
+
+		if slices.Equal(actual, expected) {
+			return
+		}
+	}
+
+	t.Fatalf(`expected the servers of the %s upstream to be %v, got %v`, upstream, expected, actual)
+}
+
+func buildNode(name string, ip string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: ip}},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+// buildService builds a Service with an http and a stream upstream, web and tcp.
+func buildService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx-ingress",
+			Namespace:   namespace,
+			Annotations: map[string]string{configuration.PortAnnotationPrefix + "/nlk-tcp": application.ClientTypeNginxStream},
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeNodePort,
+			Ports: []v1.ServicePort{
+				{Name: "nlk-web", Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP},
+				{Name: "nlk-tcp", Port: 443, NodePort: 30443, Protocol: v1.ProtocolTCP},
+			},
+		},
+	}
+}
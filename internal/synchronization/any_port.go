@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// handleAnyPortEvent deletes the servers of the upstream on the address of the Deleted event, whatever their port, see
+// core.ServerUpdateEvent::AnyPort: each server found by serversOnAddress is deleted by an event of its own, so it is
+// handled like any other deletion.
+func (s *Synchronizer) handleAnyPortEvent(parent context.Context, event *core.ServerUpdateEvent) error {
+	servers, err := s.serversOnAddress(parent, event)
+	if err != nil {
+		return err
+	}
+
+	if len(servers) == 0 {
+		logrus.WithFields(event.LogFields()).WithField("address", event.UpstreamServers[0].Host).
+			Info(`Synchronizer::handleAnyPortEvent: the upstream has no server on the address, there is nothing to delete`)
+		return nil
+	}
+
+	var errs []error
+	for _, server := range servers {
+		resolved := *event
+		resolved.AnyPort = false
+		resolved.UpstreamServers = core.UpstreamServers{core.NewUpstreamServer(server)}
+
+		if err = s.handleEventWithin(parent, &resolved); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serversOnAddress returns the servers of the upstream of the event on its address: the servers last applied to the
+// upstream, see appliedCache, or, when they are not known, e.g. after a restart, the servers listed by the NGINX Plus host.
+func (s *Synchronizer) serversOnAddress(parent context.Context, event *core.ServerUpdateEvent) ([]string, error) {
+	address := event.UpstreamServers[0].Host
+
+	if applied, found := s.appliedCache.appliedServers(event); found {
+		return onAddress(sortedKeys(applied), address), nil
+	}
+
+	if !s.isNginxPlus() {
+		logrus.WithFields(event.LogFields()).WithField("address", address).
+			Warn(`Synchronizer::serversOnAddress: the port of the servers is not known, and the upstream cannot be listed, the servers are left in place`)
+		return nil, nil
+	}
+
+	lister, err := s.upstreamListerFactory(event.NginxHost)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(parent, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	var servers []string
+	if protocolOf(event.ClientType) == ProtocolHttp {
+		upstreams, err := lister.GetUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the HTTP upstreams: %w`, err)
+		}
+
+		for _, peer := range (*upstreams)[event.UpstreamName].Peers {
+			servers = append(servers, peer.Server)
+		}
+	} else {
+		upstreams, err := lister.GetStreamUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the stream upstreams: %w`, err)
+		}
+
+		for _, peer := range (*upstreams)[event.UpstreamName].Peers {
+			servers = append(servers, peer.Server)
+		}
+	}
+
+	return onAddress(servers, address), nil
+}
+
+// onAddress returns the servers, e.g. "10.0.0.1:8080", on the address, e.g. "10.0.0.1".
+func onAddress(servers []string, address string) []string {
+	ip := net.ParseIP(address)
+
+	var matching []string
+	for _, server := range servers {
+		host, _, err := net.SplitHostPort(server)
+		if err == nil && ip != nil && ip.Equal(net.ParseIP(host)) {
+			matching = append(matching, server)
+		}
+	}
+
+	return matching
+}
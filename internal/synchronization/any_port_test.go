@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+func TestSynchronizer_AnyPortDeletesTheServersLastAppliedOnTheAddress(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, nil)
+
+	applied := deletionEvent(`test`, "https://10.0.0.100:9000/api", "https", application.ClientTypeNginxHttp, "10.0.0.1:443")
+	applied.Type = core.Updated
+	applied.UpstreamServers = append(applied.UpstreamServers, core.NewUpstreamServer("10.0.0.2:443"))
+	synchronizer.appliedCache.store(synchronizer.settings.Hosts(), applied)
+
+	if err := synchronizer.handleEvent(anyPortEvent("10.0.0.1")); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if deleted := deletedServers(borderClient); !slices.Equal(deleted, []string{"10.0.0.1:443"}) {
+		t.Fatalf(`expected the server applied on the address to be deleted, got %v`, deleted)
+	}
+}
+
+func TestSynchronizer_AnyPortListsTheUpstreamWhenTheAppliedServersAreNotKnown(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, nil)
+	synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) {
+		return &fakeUpstreamLister{
+			upstreams: nginxClient.Upstreams{
+				"https": {Peers: []nginxClient.Peer{{Server: "10.0.0.1:443"}, {Server: "10.0.0.1:8443"}, {Server: "10.0.0.2:443"}}},
+			},
+		}, nil
+	}
+
+	if err := synchronizer.handleEvent(anyPortEvent("10.0.0.1")); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if deleted := deletedServers(borderClient); !slices.Equal(deleted, []string{"10.0.0.1:443", "10.0.0.1:8443"}) {
+		t.Fatalf(`expected the servers listed on the address to be deleted, got %v`, deleted)
+	}
+}
+
+func anyPortEvent(address string) *core.ServerUpdateEvent {
+	event := deletionEvent(`test`, "https://10.0.0.100:9000/api", "https", application.ClientTypeNginxHttp, address)
+	event.AnyPort = true
+
+	return event
+}
+
+func deletedServers(borderClient *fakeBorderClient) []string {
+	var deleted []string
+	for _, event := range borderClient.events {
+		deleted = append(deleted, event.UpstreamServers[0].Host)
+	}
+
+	slices.Sort(deleted)
+
+	return deleted
+}
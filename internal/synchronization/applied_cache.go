@@ -0,0 +1,225 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// appliedKey identifies an upstream on an NGINX Plus host.
+type appliedKey struct {
+	host       string
+	clientType string
+	upstream   string
+}
+
+// appliedCache records the servers last applied to each upstream, so that the no-op Service updates, and the updates
+// of every Service following a change of the nodes, that would push the same servers again do not call the NGINX Plus API;
+// the resyncs, see core.ServerUpdateEvent::Resync, are pushed regardless.
+// The cache starts empty with each Synchronizer, and is cleared when the list of NGINX Plus hosts changes.
+type appliedCache struct {
+
+	// lock guards hosts and servers, the cache is shared by the Synchronizer workers.
+	lock sync.Mutex
+
+	// hosts is the sorted list of NGINX Plus hosts the servers were applied for.
+	hosts []string
+
+	// servers holds the servers last applied to each upstream, sorted by Host.
+	servers map[appliedKey][]core.UpstreamServer
+
+	// keyValZones holds the keyval zone the node addresses of each upstream were last written to, so that naming a zone
+	// for an upstream is not skipped as a no-op.
+	keyValZones map[appliedKey]core.KeyValZone
+
+	// events holds the event last applied to each upstream, so its drift can be reported on the Service and corrected.
+	events map[appliedKey]*core.ServerUpdateEvent
+}
+
+// newAppliedCache creates a new, empty appliedCache.
+func newAppliedCache() *appliedCache {
+	return &appliedCache{
+		servers:     make(map[appliedKey][]core.UpstreamServer),
+		keyValZones: make(map[appliedKey]core.KeyValZone),
+		events:      make(map[appliedKey]*core.ServerUpdateEvent),
+	}
+}
+
+// unchanged determines whether the servers of the event are the servers last applied to its upstream on its host.
+func (c *appliedCache) unchanged(hosts []string, event *core.ServerUpdateEvent) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resetIfHostsChanged(hosts)
+
+	applied, found := c.servers[keyOf(event)]
+
+	return found && reflect.DeepEqual(applied, sortedServers(event.UpstreamServers)) && c.keyValZones[keyOf(event)] == keyValZoneOf(event)
+}
+
+// store records the servers of the event as the servers applied to its upstream on its host.
+func (c *appliedCache) store(hosts []string, event *core.ServerUpdateEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resetIfHostsChanged(hosts)
+
+	c.servers[keyOf(event)] = sortedServers(event.UpstreamServers)
+	c.keyValZones[keyOf(event)] = keyValZoneOf(event)
+	c.events[keyOf(event)] = event
+}
+
+// appliedServers returns the Hosts of the servers last applied to the upstream of the event on its host, and whether
+// servers have been applied to it.
+func (c *appliedCache) appliedServers(event *core.ServerUpdateEvent) (map[string]bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	servers, found := c.servers[keyOf(event)]
+	if !found {
+		return nil, false
+	}
+
+	hosts := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		hosts[server.Host] = true
+	}
+
+	return hosts, true
+}
+
+// appliedOn returns the events last applied to the upstreams of the host.
+func (c *appliedCache) appliedOn(host string) []*core.ServerUpdateEvent {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var events []*core.ServerUpdateEvent
+	for key, event := range c.events {
+		if key.host == host {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// stillApplied determines whether the event is still the event last applied to its upstream on its host.
+func (c *appliedCache) stillApplied(event *core.ServerUpdateEvent) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.events[keyOf(event)] == event
+}
+
+// invalidate forgets the servers applied to the upstream of the event, so the next event for the upstream calls the NGINX Plus API.
+func (c *appliedCache) invalidate(event *core.ServerUpdateEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.servers, keyOf(event))
+	delete(c.keyValZones, keyOf(event))
+	delete(c.events, keyOf(event))
+}
+
+// deleted removes the servers of an applied Deleted event from the servers last applied to its upstream, and forgets the
+// upstream once it has no server left; it returns the number of servers left, and whether the upstream is still known.
+// The event last applied to the upstream is replaced by a copy without the deleted servers, so that they are not restored
+// as drift.
+func (c *appliedCache) deleted(event *core.ServerUpdateEvent) (int, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := keyOf(event)
+	applied, found := c.servers[key]
+	if !found {
+		return 0, false
+	}
+
+	deleted := make(map[string]bool, len(event.UpstreamServers))
+	for _, server := range event.UpstreamServers {
+		deleted[server.Host] = true
+	}
+
+	applied = slices.DeleteFunc(slices.Clone(applied), func(server core.UpstreamServer) bool { return deleted[server.Host] })
+	if len(applied) == 0 {
+		delete(c.servers, key)
+		delete(c.keyValZones, key)
+		delete(c.events, key)
+
+		return 0, false
+	}
+
+	c.servers[key] = applied
+
+	if last, found := c.events[key]; found {
+		remaining := *last
+		remaining.UpstreamServers = slices.DeleteFunc(slices.Clone(last.UpstreamServers), func(server *core.UpstreamServer) bool { return deleted[server.Host] })
+		c.events[key] = &remaining
+	}
+
+	return len(applied), true
+}
+
+// invalidateHost forgets the servers applied to every upstream of the host, e.g. while the host is skipped.
+func (c *appliedCache) invalidateHost(host string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.servers {
+		if key.host == host {
+			delete(c.servers, key)
+			delete(c.keyValZones, key)
+			delete(c.events, key)
+		}
+	}
+}
+
+// resetIfHostsChanged clears the cache when the list of NGINX Plus hosts differs from the list the servers were applied for.
+func (c *appliedCache) resetIfHostsChanged(hosts []string) {
+	sortedHosts := slices.Clone(hosts)
+	sort.Strings(sortedHosts)
+
+	if !slices.Equal(c.hosts, sortedHosts) {
+		c.hosts = sortedHosts
+		c.servers = make(map[appliedKey][]core.UpstreamServer)
+		c.keyValZones = make(map[appliedKey]core.KeyValZone)
+		c.events = make(map[appliedKey]*core.ServerUpdateEvent)
+	}
+}
+
+func keyOf(event *core.ServerUpdateEvent) appliedKey {
+	return appliedKey{
+		host:       event.NginxHost,
+		clientType: event.ClientType,
+		upstream:   event.UpstreamName,
+	}
+}
+
+// keyValZoneOf returns the keyval zone of the event, the zero KeyValZone when it has none.
+func keyValZoneOf(event *core.ServerUpdateEvent) core.KeyValZone {
+	if event.KeyValZone == nil {
+		return core.KeyValZone{}
+	}
+
+	return *event.KeyValZone
+}
+
+// sortedServers copies the servers, sorted by Host, so that the order in which they were translated does not matter.
+func sortedServers(servers core.UpstreamServers) []core.UpstreamServer {
+	sorted := make([]core.UpstreamServer, 0, len(servers))
+	for _, server := range servers {
+		sorted = append(sorted, *server)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Host < sorted[j].Host })
+
+	return sorted
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestAppliedCache_ComparesServerParameters(t *testing.T) {
+	hosts := []string{"https://10.0.0.100:9000/api"}
+	cache := newAppliedCache()
+
+	weight := 2
+	event := &core.ServerUpdateEvent{
+		NginxHost:       hosts[0],
+		ClientType:      "http",
+		UpstreamName:    "nginx-http",
+		UpstreamServers: core.UpstreamServers{{Host: "10.0.0.1:30080", Weight: &weight}},
+	}
+
+	if cache.unchanged(hosts, event) {
+		t.Fatal(`expected an empty cache to report a change`)
+	}
+
+	cache.store(hosts, event)
+
+	sameWeight := 2
+	if !cache.unchanged(hosts, &core.ServerUpdateEvent{
+		NginxHost:       hosts[0],
+		ClientType:      "http",
+		UpstreamName:    "nginx-http",
+		UpstreamServers: core.UpstreamServers{{Host: "10.0.0.1:30080", Weight: &sameWeight}},
+	}) {
+		t.Error(`expected equal parameters to be unchanged`)
+	}
+
+	drained := &core.ServerUpdateEvent{
+		NginxHost:       hosts[0],
+		ClientType:      "http",
+		UpstreamName:    "nginx-http",
+		UpstreamServers: core.UpstreamServers{{Host: "10.0.0.1:30080", Weight: &weight, Drain: true}},
+	}
+	if cache.unchanged(hosts, drained) {
+		t.Error(`expected a drained server to be a change`)
+	}
+
+	cache.invalidate(event)
+	if cache.unchanged(hosts, event) {
+		t.Error(`expected an invalidated upstream to report a change`)
+	}
+}
+
+func TestAppliedCache_DeletedKeepsTheRemainingServers(t *testing.T) {
+	hosts := []string{"https://10.0.0.100:9000/api"}
+	cache := newAppliedCache()
+
+	event := &core.ServerUpdateEvent{
+		NginxHost:       hosts[0],
+		ClientType:      "http",
+		UpstreamName:    "nginx-http",
+		UpstreamServers: core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080"), core.NewUpstreamServer("10.0.0.2:30080")},
+	}
+	cache.store(hosts, event)
+
+	deletion := deletionEvent(`test`, hosts[0], "nginx-http", "http", "10.0.0.2:30080")
+	if remaining, known := cache.deleted(deletion); remaining != 1 || !known {
+		t.Fatalf(`expected a single remaining server, got %d, %t`, remaining, known)
+	}
+
+	applied := cache.appliedOn(hosts[0])
+	if len(applied) != 1 || len(applied[0].UpstreamServers) != 1 || applied[0].UpstreamServers[0].Host != "10.0.0.1:30080" {
+		t.Errorf(`expected the event last applied without the deleted server, got %#v`, applied)
+	}
+
+	if len(event.UpstreamServers) != 2 {
+		t.Errorf(`expected the applied event to be left unchanged, got %#v`, event.UpstreamServers)
+	}
+
+	if _, known := cache.deleted(deletionEvent(`test`, hosts[0], "nginx-http", "http", "10.0.0.1:30080")); known {
+		t.Error(`expected the upstream to be forgotten once it has no server left`)
+	}
+}
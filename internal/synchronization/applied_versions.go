@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// versionKey identifies the upstream of a Service on an NGINX Plus host, the resourceVersions of different Services are not compared.
+type versionKey struct {
+	appliedKey
+	service string
+}
+
+// appliedVersions records the resourceVersion of the Service of the last event applied to each upstream on each host, so
+// an event translated from an older version of the Service, e.g. delivered out of order or retried after a newer one was
+// applied, is dropped rather than applied over the newer servers.
+// The resourceVersions are compared as numbers, as the API server backed by etcd sets them; the events whose resourceVersion
+// is not a number are neither recorded nor dropped.
+type appliedVersions struct {
+
+	// lock guards versions and upstreamLocks, the versions are recorded by the Synchronizer workers.
+	lock sync.Mutex
+
+	versions map[versionKey]uint64
+
+	// upstreamLocks serialize the events applied to each upstream on each host, see lockUpstream.
+	upstreamLocks map[appliedKey]*sync.Mutex
+}
+
+// newAppliedVersions creates a new, empty appliedVersions.
+func newAppliedVersions() *appliedVersions {
+	return &appliedVersions{
+		versions:      make(map[versionKey]uint64),
+		upstreamLocks: make(map[appliedKey]*sync.Mutex),
+	}
+}
+
+// lockUpstream serializes the events applied to the upstream of the event on its host, so that no other event is applied
+// between the check of its version, see stale, and the store of its version once it is applied, see store; e.g. a
+// Deleted event is not applied while another worker applies an older Updated event, which would add the server back.
+// Returns the function that releases the upstream.
+func (v *appliedVersions) lockUpstream(event *core.ServerUpdateEvent) func() {
+	v.lock.Lock()
+	upstreamLock, found := v.upstreamLocks[keyOf(event)]
+	if !found {
+		upstreamLock = &sync.Mutex{}
+		v.upstreamLocks[keyOf(event)] = upstreamLock
+	}
+	v.lock.Unlock()
+
+	upstreamLock.Lock()
+
+	return upstreamLock.Unlock
+}
+
+// stale determines whether the event stems from an older version of its Service than the version last applied to its
+// upstream on its host, which is returned.
+func (v *appliedVersions) stale(event *core.ServerUpdateEvent) (uint64, bool) {
+	version, ok := resourceVersionOf(event)
+	if !ok {
+		return 0, false
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	applied, found := v.versions[versionKeyOf(event)]
+
+	return applied, found && version < applied
+}
+
+// store records the version of the Service of the event as applied to its upstream on its host, unless a newer one is.
+func (v *appliedVersions) store(event *core.ServerUpdateEvent) {
+	version, ok := resourceVersionOf(event)
+	if !ok {
+		return
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	key := versionKeyOf(event)
+	v.versions[key] = max(v.versions[key], version)
+}
+
+// forgetHost forgets the versions applied to every upstream of the host, e.g. once it is removed.
+func (v *appliedVersions) forgetHost(host string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	for key := range v.versions {
+		if key.host == host {
+			delete(v.versions, key)
+		}
+	}
+
+	for key := range v.upstreamLocks {
+		if key.host == host {
+			delete(v.upstreamLocks, key)
+		}
+	}
+}
+
+// copy returns the newest version applied to each upstream on each host, whatever the Service.
+func (v *appliedVersions) copy() map[appliedKey]uint64 {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	versions := make(map[appliedKey]uint64, len(v.versions))
+	for key, version := range v.versions {
+		versions[key.appliedKey] = max(versions[key.appliedKey], version)
+	}
+
+	return versions
+}
+
+func versionKeyOf(event *core.ServerUpdateEvent) versionKey {
+	return versionKey{
+		appliedKey: keyOf(event),
+		service:    event.Service.Namespace + "/" + event.Service.Name,
+	}
+}
+
+// resourceVersionOf returns the resourceVersion of the Service of the event as a number, false when the event stems from
+// no Service or its resourceVersion is not a number.
+func resourceVersionOf(event *core.ServerUpdateEvent) (uint64, bool) {
+	if event.Service == nil || event.ResourceVersion == `` {
+		return 0, false
+	}
+
+	version, err := strconv.ParseUint(event.ResourceVersion, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return version, true
+}
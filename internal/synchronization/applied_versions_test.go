@@ -0,0 +1,178 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestSynchronizer_DropsTheEventsOfAnOlderResourceVersion(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, nil)
+
+	// the events are handled in the reverse order of the versions of the Service
+	if err := synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "20", "10.0.0.2:30080")); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err := synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "10", "10.0.0.1:30080")); err != nil {
+		t.Fatalf(`expected the older event to be dropped without an error, %v`, err)
+	}
+
+	if borderClient.callCount() != 1 || borderClient.events[0].ResourceVersion != "20" {
+		t.Fatalf(`expected only the newer event to be applied, got %d calls`, borderClient.callCount())
+	}
+
+	if upstream := synchronizer.Snapshot().Upstreams[0]; upstream.Hosts[0].ResourceVersion != "20" {
+		t.Fatalf(`expected the applied resourceVersion in the snapshot, got %+v`, upstream.Hosts[0])
+	}
+
+	// the same version, e.g. a resync, and the newer versions are not dropped
+	_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "20", "10.0.0.3:30080"))
+	_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "30", "10.0.0.4:30080"))
+
+	if borderClient.callCount() != 3 {
+		t.Fatalf(`expected the same and the newer versions to be applied, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_DropsTheRetryOfAnOlderResourceVersion(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, nil)
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	older := buildVersionedEvent("https://localhost:8081", "10", "10.0.0.1:30080")
+	if err := synchronizer.handleEvent(older); err == nil {
+		t.Fatal(`expected the older event to fail`)
+	}
+
+	// the host recovers, and the newer event is applied before the older one is retried
+	delete(borderClient.failedHosts, "https://localhost:8081")
+
+	if err := synchronizer.handleEvent(buildVersionedEvent("https://localhost:8081", "20", "10.0.0.2:30080")); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err := synchronizer.handleEvent(older); err != nil {
+		t.Fatalf(`expected the retry to be dropped without an error, %v`, err)
+	}
+
+	if borderClient.callCount() != 2 {
+		t.Fatalf(`expected the retry not to call the host, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_DoesNotGuardTheEventsWithoutAResourceVersion(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, nil)
+
+	_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "20", "10.0.0.2:30080"))
+	_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "", "10.0.0.1:30080"))
+	_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "not-a-number", "10.0.0.3:30080"))
+
+	if borderClient.callCount() != 3 {
+		t.Fatalf(`expected every event to be applied, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_ForgetsTheVersionsOfTheRemovedHosts(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, nil)
+
+	event := buildVersionedEvent("https://localhost:8080", "20", "10.0.0.2:30080")
+	_ = synchronizer.handleEvent(event)
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8081"})
+
+	if _, stale := synchronizer.appliedVersions.stale(buildVersionedEvent("https://localhost:8080", "10", "10.0.0.1:30080")); stale {
+		t.Fatal(`expected the versions of the removed host to be forgotten`)
+	}
+}
+
+func TestSynchronizer_AppliesTheEventsOfAnUpstreamOneAtATime(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, nil)
+
+	borderClient := &blockingBorderClient{started: make(chan struct{}), release: make(chan struct{})}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	var handled sync.WaitGroup
+	handled.Add(2)
+
+	go func() {
+		defer handled.Done()
+		_ = synchronizer.handleEvent(buildVersionedEvent("https://localhost:8080", "10", "10.0.0.1:30080"))
+	}()
+
+	<-borderClient.started
+
+	// the Deleted event of a newer version waits for the Updated event being applied, which would add the server back
+	deleted := buildVersionedEvent("https://localhost:8080", "20", "10.0.0.1:30080")
+	deleted.Type = core.Deleted
+
+	go func() {
+		defer handled.Done()
+		_ = synchronizer.handleEvent(deleted)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if types := borderClient.appliedTypes(); len(types) != 1 {
+		t.Fatalf(`expected the Deleted event to wait for the Updated event, got %v`, types)
+	}
+
+	close(borderClient.release)
+	handled.Wait()
+
+	if types := borderClient.appliedTypes(); len(types) != 2 || types[0] != core.Updated || types[1] != core.Deleted {
+		t.Fatalf(`expected the Updated event to be applied before the Deleted event, got %v`, types)
+	}
+}
+
+// blockingBorderClient blocks the first update until it is released, and records the types of the events applied.
+type blockingBorderClient struct {
+	lock    sync.Mutex
+	types   []core.EventType
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	c.lock.Lock()
+	c.types = append(c.types, event.Type)
+	first := len(c.types) == 1
+	c.lock.Unlock()
+
+	if first {
+		close(c.started)
+		<-c.release
+	}
+
+	return nil
+}
+
+func (c *blockingBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return c.Update(ctx, event)
+}
+
+func (c *blockingBorderClient) appliedTypes() []core.EventType {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]core.EventType{}, c.types...)
+}
+
+func buildVersionedEvent(host string, resourceVersion string, server string) *core.ServerUpdateEvent {
+	event := buildUpdateEvents(1)[0]
+	event.Service = buildService()
+	event.ResourceVersion = resourceVersion
+	event.UpstreamServers = core.UpstreamServers{core.NewUpstreamServer(server)}
+
+	return core.ServerUpdateEventWithIdAndHost(event, event.Id, host)
+}
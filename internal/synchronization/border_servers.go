@@ -0,0 +1,63 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+)
+
+// nginxPlusBorderServers implements the application.BorderServers of the NGINX Plus hosts, the built-in border type.
+// The Border Clients use the NGINX Plus client of the host, built for the API base URL and version of its nginx-hosts entry.
+// NOTE: There is an open issue (https://github.com/nginxinc/nginx-loadbalancer-kubernetes/issues/36) to move creation
+// of the underlying Border Server client to the NewBorderClient function.
+type nginxPlusBorderServers struct {
+	synchronizer *Synchronizer
+}
+
+// BorderClient creates the Border Client of the client type for the host; in dry-run mode the NGINX Plus client only
+// reads the upstreams, and logs the changes it would make.
+func (n *nginxPlusBorderServers) BorderClient(host string, clientType string) (application.Interface, error) {
+	ngxClient, err := n.synchronizer.buildNginxClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.synchronizer.settings.IsDryRun() {
+		return application.NewBorderClient(clientType, application.NewDryRunNginxClient(ngxClient, host))
+	}
+
+	return application.NewBorderClient(clientType, ngxClient)
+}
+
+// Ping calls the NGINX Plus API of the host, any response other than a 2xx is a failure.
+func (n *nginxPlusBorderServers) Ping(ctx context.Context, host string) error {
+	nginxPlusHost, err := n.synchronizer.nginxPlusHost(host)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, nginxPlusHost.Endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := n.synchronizer.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(`unexpected status %s`, response.Status)
+	}
+
+	return nil
+}
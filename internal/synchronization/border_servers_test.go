@@ -0,0 +1,56 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestSynchronizer_SyncsTheWebhookBorderServers(t *testing.T) {
+	var received []application.WebhookRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var request application.WebhookRequest
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			received = append(received, request)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{server.URL}, func(settings *configuration.Settings) {
+		settings.Synchronizer.BorderType = core.BorderTypeWebhook
+	})
+	synchronizer.borderClientFactory = synchronizer.buildBorderClient
+
+	event := buildUpdateEvents(1)[0]
+	event.ClientType = application.ClientTypeNginxHttp
+
+	if err := synchronizer.handleEvent(core.ServerUpdateEventWithIdAndHost(event, "id-0", server.URL)); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if len(received) != 1 || received[0].Action != application.WebhookActionUpdate || received[0].Upstream != event.UpstreamName {
+		t.Fatalf(`expected the update to be posted to the webhook, got %+v`, received)
+	}
+
+	if err := synchronizer.probeHost(server.URL); err != nil {
+		t.Fatalf(`expected the webhook to be pinged, %v`, err)
+	}
+
+	if reachability := synchronizer.probeReachability(server.URL); !reachability.reachable {
+		t.Fatalf(`expected the webhook to be reachable, got %+v`, reachability)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+)
+
+// circuitProbeInterval is how often the hosts whose circuit is open are checked for an elapsed backoff.
+const circuitProbeInterval = time.Second
+
+// circuit is the state of the circuit breaker of an NGINX Plus host.
+type circuit struct {
+
+	// consecutiveFailures is the number of syncs that failed in a row, reset by a success.
+	consecutiveFailures int
+
+	// open is set once consecutiveFailures reaches the threshold, the host is skipped until a probe succeeds.
+	open bool
+
+	// backoff is how long the circuit stays open before the next probe.
+	backoff time.Duration
+
+	// probeAt is when the host is next probed, while the circuit is open.
+	probeAt time.Time
+}
+
+// circuitBreaker tracks the consecutive failures of each NGINX Plus host, so that a host that is down does not make
+// every event go through the full retry cycle, delaying the updates of the other hosts.
+// Once a host has failed CircuitBreakerThreshold times in a row, its circuit opens and the host is skipped. The host is
+// probed after CircuitBreakerBackoff, and the backoff doubles with each failed probe up to CircuitBreakerMaxBackoff.
+// The circuit closes when a probe succeeds, and the host then receives the servers of every upstream.
+type circuitBreaker struct {
+
+	// lock guards circuits, the hosts are synced concurrently.
+	lock sync.Mutex
+
+	circuits map[string]*circuit
+}
+
+// newCircuitBreaker creates a new circuitBreaker, with the circuit of every host closed.
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// isOpen determines whether the host should be skipped.
+func (c *circuitBreaker) isOpen(host string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	circuit, found := c.circuits[host]
+
+	return found && circuit.open
+}
+
+// recordSuccess resets the consecutive failures of the host, and returns true if its circuit was open.
+func (c *circuitBreaker) recordSuccess(host string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	circuit, found := c.circuits[host]
+	if !found {
+		return false
+	}
+
+	delete(c.circuits, host)
+
+	return circuit.open
+}
+
+// recordFailure counts a failure of the host, and returns true if it opened the circuit.
+// A failed probe keeps the circuit open and doubles the backoff, up to maxBackoff.
+func (c *circuitBreaker) recordFailure(host string, now time.Time, threshold int, backoff time.Duration, maxBackoff time.Duration) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	hostCircuit, found := c.circuits[host]
+	if !found {
+		hostCircuit = &circuit{}
+		c.circuits[host] = hostCircuit
+	}
+
+	hostCircuit.consecutiveFailures++
+
+	if hostCircuit.open {
+		hostCircuit.backoff = min(hostCircuit.backoff*2, maxBackoff)
+		hostCircuit.probeAt = now.Add(hostCircuit.backoff)
+		return false
+	}
+
+	if hostCircuit.consecutiveFailures < threshold {
+		return false
+	}
+
+	hostCircuit.open = true
+	hostCircuit.backoff = backoff
+	hostCircuit.probeAt = now.Add(backoff)
+
+	return true
+}
+
+// dueForProbe returns the hosts whose circuit is open and whose backoff has elapsed, sorted.
+func (c *circuitBreaker) dueForProbe(now time.Time) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var hosts []string
+	for host, circuit := range c.circuits {
+		if circuit.open && !now.Before(circuit.probeAt) {
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// forget drops the circuits of the hosts that are no longer configured.
+func (c *circuitBreaker) forget(hosts []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	configured := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		configured[host] = true
+	}
+
+	for host := range c.circuits {
+		if !configured[host] {
+			delete(c.circuits, host)
+		}
+	}
+}
+
+// copy returns a copy of the circuits.
+func (c *circuitBreaker) copy() map[string]circuit {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	circuits := make(map[string]circuit, len(c.circuits))
+	for host, hostCircuit := range c.circuits {
+		circuits[host] = *hostCircuit
+	}
+
+	return circuits
+}
+
+// SetResyncer sets the function that generates an Updated event for every watched Service, typically Watcher::ResyncServices.
+// It is invoked when a host recovers, so the host receives the servers of every upstream rather than only the later changes.
+func (s *Synchronizer) SetResyncer(resyncer func()) {
+	s.resyncer = resyncer
+}
+
+// recordHostOutcome updates the circuit of the host with the outcome of a sync. Only the calls that got no response, or a
+// 5xx, count as failures; a 4xx, e.g. for a missing upstream or an invalid call, is a response of the host, see application.IsHostFailure.
+func (s *Synchronizer) recordHostOutcome(host string, err error) {
+	if !application.IsHostFailure(err) {
+		if s.circuitBreaker.recordSuccess(host) {
+			s.recover(host)
+		}
+
+		s.recordHostReachable(host)
+
+		return
+	}
+
+	settings := s.settings.Synchronizer
+	if s.circuitBreaker.recordFailure(host, time.Now(), settings.CircuitBreakerThreshold, settings.CircuitBreakerBackoff, settings.CircuitBreakerMaxBackoff) {
+		instrumentation.ObserveCircuit(host, true)
+
+		// the host will miss the changes made while it is skipped
+		s.appliedCache.invalidateHost(host)
+
+		logrus.WithField("host", host).WithError(err).
+			Warnf(`Synchronizer::recordHostOutcome: the host failed %d times in a row, skipping it until it responds, probing in %v`, settings.CircuitBreakerThreshold, settings.CircuitBreakerBackoff)
+	}
+}
+
+// probeOpenCircuits probes each host whose circuit is open once its backoff has elapsed, and closes the circuit of the hosts that respond.
+func (s *Synchronizer) probeOpenCircuits() {
+	s.circuitBreaker.forget(s.settings.Hosts())
+
+	settings := s.settings.Synchronizer
+
+	for _, host := range s.circuitBreaker.dueForProbe(time.Now()) {
+		if err := s.hostProber(host); application.IsHostFailure(err) {
+			s.circuitBreaker.recordFailure(host, time.Now(), settings.CircuitBreakerThreshold, settings.CircuitBreakerBackoff, settings.CircuitBreakerMaxBackoff)
+			logrus.WithField("host", host).WithError(err).Debug(`Synchronizer::probeOpenCircuits: the host is still failing`)
+			continue
+		}
+
+		if s.circuitBreaker.recordSuccess(host) {
+			s.recover(host)
+		}
+	}
+}
+
+// recover closes the circuit of the host, and pushes the servers of every upstream to it.
+func (s *Synchronizer) recover(host string) {
+	instrumentation.ObserveCircuit(host, false)
+	s.appliedCache.invalidateHost(host)
+
+	logrus.WithField("host", host).Info(`Synchronizer::recover: the host has recovered, pushing the servers of every upstream`)
+
+	if s.resyncer != nil {
+		s.resyncer()
+	}
+}
+
+// probeHost pings the host with the BorderServers, e.g. calls the NGINX Plus API of the host.
+func (s *Synchronizer) probeHost(host string) error {
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	return s.borderServers.Ping(ctx, host)
+}
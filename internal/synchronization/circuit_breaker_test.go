@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+	"time"
+)
+
+const circuitHost = "https://localhost:8080"
+
+func TestCircuitBreaker_OpensAfterThresholdConsecutiveFailures(t *testing.T) {
+	breaker := newCircuitBreaker()
+	now := time.Now()
+
+	if breaker.recordFailure(circuitHost, now, 3, time.Second, time.Minute) || breaker.recordFailure(circuitHost, now, 3, time.Second, time.Minute) {
+		t.Fatal(`should not open before the threshold`)
+	}
+
+	if breaker.isOpen(circuitHost) {
+		t.Fatal(`should be closed before the threshold`)
+	}
+
+	if !breaker.recordFailure(circuitHost, now, 3, time.Second, time.Minute) {
+		t.Fatal(`should open on reaching the threshold`)
+	}
+
+	if !breaker.isOpen(circuitHost) {
+		t.Fatal(`should be open`)
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsTheFailures(t *testing.T) {
+	breaker := newCircuitBreaker()
+	now := time.Now()
+
+	breaker.recordFailure(circuitHost, now, 2, time.Second, time.Minute)
+
+	if breaker.recordSuccess(circuitHost) {
+		t.Fatal(`a closed circuit should not be reported as recovered`)
+	}
+
+	if breaker.recordFailure(circuitHost, now, 2, time.Second, time.Minute) {
+		t.Fatal(`the failures should have been reset by the success`)
+	}
+}
+
+func TestCircuitBreaker_BackoffDoublesUpToTheCap(t *testing.T) {
+	breaker := newCircuitBreaker()
+	now := time.Now()
+
+	breaker.recordFailure(circuitHost, now, 1, time.Second, 3*time.Second)
+
+	if hosts := breaker.dueForProbe(now); len(hosts) != 0 {
+		t.Fatalf(`should not be probed before the backoff, got %v`, hosts)
+	}
+
+	if hosts := breaker.dueForProbe(now.Add(time.Second)); len(hosts) != 1 || hosts[0] != circuitHost {
+		t.Fatalf(`should be probed after the backoff, got %v`, hosts)
+	}
+
+	expected := []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for _, backoff := range expected {
+		if breaker.recordFailure(circuitHost, now, 1, time.Second, 3*time.Second) {
+			t.Fatal(`a failed probe should not reopen the circuit`)
+		}
+
+		if actual := breaker.copy()[circuitHost].backoff; actual != backoff {
+			t.Fatalf(`expected a backoff of %v, got %v`, backoff, actual)
+		}
+	}
+
+	if !breaker.recordSuccess(circuitHost) {
+		t.Fatal(`an open circuit should be reported as recovered`)
+	}
+
+	if breaker.isOpen(circuitHost) {
+		t.Fatal(`should be closed after a success`)
+	}
+}
+
+func TestCircuitBreaker_ForgetsHostsNoLongerConfigured(t *testing.T) {
+	breaker := newCircuitBreaker()
+	breaker.recordFailure(circuitHost, time.Now(), 1, time.Second, time.Minute)
+
+	breaker.forget([]string{"https://localhost:8081"})
+
+	if breaker.isOpen(circuitHost) {
+		t.Fatal(`the circuit of a removed host should be forgotten`)
+	}
+}
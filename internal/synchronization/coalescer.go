@@ -0,0 +1,246 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// coalesceKey identifies an upstream, the events are fanned out to the same NGINX Plus hosts.
+type coalesceKey struct {
+	clientType string
+	upstream   string
+}
+
+// coalescer merges the events queued for the same upstream, so that a burst of changes, e.g. nodes added by
+// the cluster autoscaler, results in a single update per upstream per host that carries the final servers.
+//
+// Created and Updated events carry the complete list of servers, so a newer one replaces an update that is still
+// waiting in the queue, and supersedes the updates being retried and the deletions queued before it.
+// Deleted events carry a single server, they are queued as-is and the server is also removed from the pending update,
+// or from the update being applied before it is retried, so a server deleted after it was added is absent from the final update.
+// Once the queue is full, a Deleted event is not queued when the pending update, which removes the server as it carries the
+// final servers of the upstream, is applied to its hosts, nor when the deletion of the server is already queued; the pending
+// deletion is then applied to the hosts of both. No state other than the superseded intermediate ones is dropped.
+type coalescer struct {
+
+	// lock guards the maps, and the started, superseded, and removedServers fields of the tracked syncEvents,
+	// along with their event until they are started.
+	lock sync.Mutex
+
+	// updates holds the latest Created or Updated event of each upstream, until it is done.
+	updates map[coalesceKey]*syncEvent
+
+	// deletes holds the Deleted events of each upstream queued since the latest update, until they are done.
+	deletes map[coalesceKey][]*syncEvent
+}
+
+// newCoalescer creates a new, empty coalescer.
+func newCoalescer() *coalescer {
+	return &coalescer{
+		updates: make(map[coalesceKey]*syncEvent),
+		deletes: make(map[coalesceKey][]*syncEvent),
+	}
+}
+
+// add tracks the event, and returns false if it was merged into an event already waiting in the queue; the Deleted
+// events are only merged when the queue is full.
+func (c *coalescer) add(event *syncEvent, full bool) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := coalesceKeyOf(event.event)
+
+	if event.event.Type == core.Deleted {
+		if update, found := c.updates[key]; found {
+			if update.started {
+				update.removedServers = append(update.removedServers, event.event.UpstreamServers...)
+			} else {
+				update.event = withoutServers(update.event, event.event.UpstreamServers)
+
+				if full && containsAll(update.pendingHosts, event.pendingHosts) {
+					return false
+				}
+			}
+		}
+
+		if full {
+			if pending := c.pendingDelete(key, event.event.UpstreamServers); pending != nil {
+				pending.pendingHosts = union(pending.pendingHosts, event.pendingHosts)
+				pending.hostCount = len(pending.pendingHosts)
+
+				return false
+			}
+		}
+
+		c.deletes[key] = append(c.deletes[key], event)
+
+		return true
+	}
+
+	if update, found := c.updates[key]; found {
+		if !update.started {
+			// the update converges the changes of both events
+			event.event.ObservedAt = earliestObservation(update.event.ObservedAt, event.event.ObservedAt)
+			event.event.Resync = event.event.Resync || update.event.Resync
+			update.event = event.event
+			update.pendingHosts = event.pendingHosts
+			update.hostCount = event.hostCount
+			c.supersedeDeletes(key)
+
+			return false
+		}
+
+		update.superseded = true
+	}
+
+	c.supersedeDeletes(key)
+	c.updates[key] = event
+
+	return true
+}
+
+// start marks the event as taken from the queue, and returns false if the event has been superseded.
+// From then on the event belongs to the worker, and is no longer merged with the newer events.
+func (c *coalescer) start(event *syncEvent) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	event.started = true
+
+	return !event.superseded
+}
+
+// requeue determines whether the failed event should be retried, which it should not be once it has been superseded.
+// The servers deleted while the event was being applied are removed from it before it is retried.
+func (c *coalescer) requeue(event *syncEvent) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if event.superseded {
+		return false
+	}
+
+	if len(event.removedServers) > 0 {
+		event.event = withoutServers(event.event, event.removedServers)
+		event.removedServers = nil
+	}
+
+	return true
+}
+
+// done stops tracking the event once it has been applied, dropped, or superseded.
+func (c *coalescer) done(event *syncEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := coalesceKeyOf(event.event)
+
+	if c.updates[key] == event {
+		delete(c.updates, key)
+	}
+
+	deletes := c.deletes[key][:0]
+	for _, deleted := range c.deletes[key] {
+		if deleted != event {
+			deletes = append(deletes, deleted)
+		}
+	}
+
+	if len(deletes) == 0 {
+		delete(c.deletes, key)
+	} else {
+		c.deletes[key] = deletes
+	}
+}
+
+// pendingDelete returns the Deleted event of the upstream waiting in the queue for the same servers, or nil.
+func (c *coalescer) pendingDelete(key coalesceKey, servers core.UpstreamServers) *syncEvent {
+	for _, deleted := range c.deletes[key] {
+		if !deleted.started && !deleted.superseded && sameHosts(deleted.event.UpstreamServers, servers) {
+			return deleted
+		}
+	}
+
+	return nil
+}
+
+// supersedeDeletes marks the Deleted events of the upstream as superseded by a newer update.
+func (c *coalescer) supersedeDeletes(key coalesceKey) {
+	for _, deleted := range c.deletes[key] {
+		deleted.superseded = true
+	}
+
+	delete(c.deletes, key)
+}
+
+func coalesceKeyOf(event *core.ServerUpdateEvent) coalesceKey {
+	return coalesceKey{
+		clientType: event.ClientType,
+		upstream:   event.UpstreamName,
+	}
+}
+
+// sameHosts determines whether the servers have the same hosts, in the same order.
+func sameHosts(servers core.UpstreamServers, others core.UpstreamServers) bool {
+	if len(servers) != len(others) {
+		return false
+	}
+
+	for i := range servers {
+		if servers[i].Host != others[i].Host {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsAll determines whether the hosts contain each of the others.
+func containsAll(hosts []string, others []string) bool {
+	for _, other := range others {
+		if !slices.Contains(hosts, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// union returns the hosts followed by the others that they do not contain.
+func union(hosts []string, others []string) []string {
+	merged := slices.Clone(hosts)
+	for _, other := range others {
+		if !slices.Contains(merged, other) {
+			merged = append(merged, other)
+		}
+	}
+
+	return merged
+}
+
+// withoutServers returns a copy of the event without the servers, the event itself may be in use by a worker.
+func withoutServers(event *core.ServerUpdateEvent, servers core.UpstreamServers) *core.ServerUpdateEvent {
+	removed := make(map[string]bool)
+	for _, server := range servers {
+		removed[server.Host] = true
+	}
+
+	remaining := core.UpstreamServers{}
+	for _, server := range event.UpstreamServers {
+		if !removed[server.Host] {
+			remaining = append(remaining, server)
+		}
+	}
+
+	updated := *event
+	updated.UpstreamServers = remaining
+
+	return &updated
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+)
+
+func TestCoalescer_MergesUpdatesForTheSameUpstream(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080", "10.0.0.3:30080")})
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the updates to be merged into 1 event, got %d`, rateLimiter.Len())
+	}
+
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 1 || len(borderClient.events[0].UpstreamServers) != 3 {
+		t.Fatalf(`expected a single update with the final 3 servers, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestCoalescer_DeleteAfterAddRemovesTheServerFromTheUpdate(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.2:30080")})
+
+	drain(synchronizer, rateLimiter)
+
+	for _, event := range borderClient.events {
+		if event.Type == core.Deleted {
+			continue
+		}
+
+		for _, server := range event.UpstreamServers {
+			if server.Host == "10.0.0.2:30080" {
+				t.Fatalf(`expected the deleted server to be absent from the update, got %v`, event.UpstreamServers)
+			}
+		}
+	}
+}
+
+func TestCoalescer_UpdateSupersedesEarlierDeletes(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.1:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 1 || borderClient.events[0].Type != core.Updated {
+		t.Fatalf(`expected only the update to be applied, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestCoalescer_DeleteDuringAnUpdateIsHonoredOnRetry(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+	borderClient.failedHosts["https://localhost:8080"] = true
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+
+	item, _ := rateLimiter.Get()
+	event := item.(*syncEvent)
+	synchronizer.coalescer.start(event)
+
+	// the server is deleted while the update is being applied
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.2:30080")})
+	synchronizer.withRetry(synchronizer.syncHosts(event), event)
+
+	if len(event.event.UpstreamServers) != 1 || event.event.UpstreamServers[0].Host != "10.0.0.1:30080" {
+		t.Fatalf(`expected the retried update to omit the deleted server, got %v`, event.event.UpstreamServers)
+	}
+}
+
+func TestCoalescer_MergesTheDeletesOnceTheQueueIsFull(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.WorkQueueSettings.MaxDepth = 1
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.1:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.1:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.2:30080")})
+
+	if rateLimiter.Len() != 2 {
+		t.Fatalf(`expected the duplicate deletion to be merged, only the deletion of another server to be queued, got %d events`, rateLimiter.Len())
+	}
+
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 2 {
+		t.Fatalf(`expected each server to be deleted once, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestCoalescer_MergesTheDeletesIntoThePendingUpdateOnceTheQueueIsFull(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.WorkQueueSettings.MaxDepth = 1
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.2:30080")})
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the deletion to be merged into the pending update, got %d events`, rateLimiter.Len())
+	}
+
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 1 || len(borderClient.events[0].UpstreamServers) != 1 || borderClient.events[0].UpstreamServers[0].Host != "10.0.0.1:30080" {
+		t.Fatalf(`expected a single update without the deleted server, got %d calls`, borderClient.callCount())
+	}
+}
+
+func buildServerUpdateEvent(eventType core.EventType, servers ...string) *core.ServerUpdateEvent {
+	upstreamServers := core.UpstreamServers{}
+	for _, server := range servers {
+		upstreamServers = append(upstreamServers, core.NewUpstreamServer(server))
+	}
+
+	return core.NewServerUpdateEvent(eventType, "nlk-upstream", "http", upstreamServers)
+}
+
+// drain handles the queued events until the queue is empty.
+func drain(synchronizer *Synchronizer, rateLimiter *mocks.MockRateLimiter) {
+	for rateLimiter.Len() > 0 {
+		synchronizer.handleNextEvent()
+	}
+}
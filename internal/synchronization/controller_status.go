@@ -0,0 +1,258 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+
+	// controllerStatusDataKey is the key of the ControllerStatus in the data of the status ConfigMap.
+	controllerStatusDataKey = "status.json"
+
+	// HealthyCondition is the type of the Condition of the ControllerStatus, True while every NGINX Plus host is in sync.
+	HealthyCondition = "Healthy"
+
+	// maxRecentErrors is the number of errors listed in the ControllerStatus, the most recent first.
+	maxRecentErrors = 10
+
+	// maxRecentErrorLength is the maximum length of each of the errors listed in the ControllerStatus.
+	maxRecentErrorLength = 256
+)
+
+// ControllerStatus is the summary of the health of NLK, written to the status ConfigMap for the dashboards to query.
+type ControllerStatus struct {
+
+	// Leader is the identity of the replica that writes the status, the leader when leader election is enabled.
+	Leader string `json:"leader"`
+
+	// ManagedUpstreams is the number of upstreams that are desired or have been synced.
+	ManagedUpstreams int `json:"managedUpstreams"`
+
+	// HostsInSync are the NGINX Plus hosts whose last sync of every upstream succeeded, sorted.
+	HostsInSync []string `json:"hostsInSync"`
+
+	// HostsOutOfSync are the NGINX Plus hosts whose circuit is open, or whose last sync of an upstream failed, sorted.
+	HostsOutOfSync []string `json:"hostsOutOfSync"`
+
+	// LastReconcile is the time of the last reconciliation of the NGINX Plus hosts with the desired state, see Synchronizer::reconcile.
+	LastReconcile *time.Time `json:"lastReconcile,omitempty"`
+
+	// RecentErrors are the errors of the last failed sync of the upstreams, the most recent first, at most maxRecentErrors.
+	RecentErrors []string `json:"recentErrors"`
+
+	// Conditions holds the HealthyCondition.
+	Conditions []metav1.Condition `json:"conditions"`
+}
+
+// controllerStatusWriter writes the ControllerStatus to a ConfigMap, creating it if needed. The ConfigMap is only updated
+// when the status has changed, and the updates are retried on conflict.
+type controllerStatusWriter struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+
+	// written is the status last written, nil until the first write.
+	written *ControllerStatus
+}
+
+// newControllerStatusWriter creates a new controllerStatusWriter for the named ConfigMap.
+func newControllerStatusWriter(client kubernetes.Interface, namespace string, name string) *controllerStatusWriter {
+	return &controllerStatusWriter{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// write writes the status to the ConfigMap unless it is unchanged since the last write. The transition time of the
+// HealthyCondition is carried over from the last write while its status is unchanged.
+func (w *controllerStatusWriter) write(ctx context.Context, status *ControllerStatus) error {
+	if w.written != nil {
+		carryOverTransitionTimes(status, w.written)
+	}
+
+	if reflect.DeepEqual(status, w.written) {
+		return nil
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf(`error occurred serializing the status: %w`, err)
+	}
+
+	configMaps := w.client.CoreV1().ConfigMaps(w.namespace)
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: w.name, Namespace: w.namespace},
+				Data:       map[string]string{controllerStatusDataKey: string(data)},
+			}
+
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the ConfigMap may hold the status written by a previous leader
+		if configMap.Data[controllerStatusDataKey] == string(data) {
+			return nil
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[controllerStatusDataKey] = string(data)
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf(`error occurred writing the %s/%s ConfigMap: %w`, w.namespace, w.name, err)
+	}
+
+	w.written = status
+
+	return nil
+}
+
+// carryOverTransitionTimes keeps the transition time of each Condition of the status whose status is the same in the
+// previous status, so a Condition only changes when it transitions.
+func carryOverTransitionTimes(status *ControllerStatus, previous *ControllerStatus) {
+	for i, condition := range status.Conditions {
+		for _, previousCondition := range previous.Conditions {
+			if condition.Type == previousCondition.Type && condition.Status == previousCondition.Status {
+				status.Conditions[i].LastTransitionTime = previousCondition.LastTransitionTime
+			}
+		}
+	}
+}
+
+// SetLeaderIdentity sets the identity of the replica reported as the leader in the status ConfigMap.
+func (s *Synchronizer) SetLeaderIdentity(identity string) {
+	s.leaderIdentity = identity
+}
+
+// ControllerStatus returns the summary of the health of NLK, built from the Snapshot, so the passwords of the hosts are
+// redacted, including in the RecentErrors.
+func (s *Synchronizer) ControllerStatus() *ControllerStatus {
+	snapshot := s.Snapshot()
+
+	status := &ControllerStatus{
+		Leader:           s.leaderIdentity,
+		ManagedUpstreams: len(snapshot.Upstreams),
+		HostsInSync:      []string{},
+		HostsOutOfSync:   []string{},
+		RecentErrors:     []string{},
+	}
+
+	if lastReconcile, ok := s.lastReconcile.Load().(time.Time); ok {
+		status.LastReconcile = &lastReconcile
+	}
+
+	outOfSync := make(map[string]bool)
+	for _, host := range snapshot.Hosts {
+		if host.CircuitOpen {
+			outOfSync[host.Host] = true
+		}
+	}
+
+	type recentError struct {
+		at      time.Time
+		message string
+	}
+
+	var recentErrors []recentError
+	for _, upstream := range snapshot.Upstreams {
+		for _, host := range upstream.Hosts {
+			if host.LastError == `` || host.LastSync == nil {
+				continue
+			}
+
+			outOfSync[host.Host] = true
+			recentErrors = append(recentErrors, recentError{
+				at:      *host.LastSync,
+				message: truncate(fmt.Sprintf("%s %s upstream %s: %s", host.Host, upstream.Protocol, upstream.Name, host.LastError), maxRecentErrorLength),
+			})
+		}
+	}
+
+	for _, host := range snapshot.Hosts {
+		if outOfSync[host.Host] {
+			status.HostsOutOfSync = append(status.HostsOutOfSync, host.Host)
+		} else {
+			status.HostsInSync = append(status.HostsInSync, host.Host)
+		}
+	}
+
+	sort.SliceStable(recentErrors, func(i, j int) bool { return recentErrors[i].at.After(recentErrors[j].at) })
+	for i := 0; i < len(recentErrors) && i < maxRecentErrors; i++ {
+		status.RecentErrors = append(status.RecentErrors, recentErrors[i].message)
+	}
+
+	status.Conditions = []metav1.Condition{healthyCondition(status)}
+
+	return status
+}
+
+// healthyCondition returns the HealthyCondition of the status, True while every NGINX Plus host is in sync.
+func healthyCondition(status *ControllerStatus) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               HealthyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "HostsInSync",
+		Message:            fmt.Sprintf("%d NGINX Plus host(s) in sync", len(status.HostsInSync)),
+		LastTransitionTime: metav1.NewTime(time.Now().Truncate(time.Second)),
+	}
+
+	switch {
+	case len(status.HostsInSync) == 0 && len(status.HostsOutOfSync) == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoHosts"
+		condition.Message = "no NGINX Plus hosts are configured"
+
+	case len(status.HostsOutOfSync) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HostsOutOfSync"
+		condition.Message = fmt.Sprintf("%d of %d NGINX Plus host(s) out of sync", len(status.HostsOutOfSync), len(status.HostsInSync)+len(status.HostsOutOfSync))
+	}
+
+	return condition
+}
+
+// writeControllerStatus writes the ControllerStatus to the status ConfigMap; nothing is written in dry-run mode.
+// A failed write is reported and retried at the next interval, it never delays the syncs.
+func (s *Synchronizer) writeControllerStatus() {
+	if s.settings.IsDryRun() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	if err := s.controllerStatus.write(ctx, s.ControllerStatus()); err != nil {
+		logrus.WithError(err).Warn(`Synchronizer::writeControllerStatus: error occurred writing the status ConfigMap`)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+)
+
+// observeConvergence records the time from the observation of the Kubernetes change the event stems from to its
+// acknowledgment by the NGINX Plus host, and logs it, with the details of the event, when it exceeds the
+// SynchronizerSettings::ConvergenceWarningThreshold. The events that stem from no Kubernetes change are not recorded.
+// The time a change was held back by the HostStagger of the host is not counted against the threshold, as the stagger
+// may well exceed it, but it is recorded.
+func (s *Synchronizer) observeConvergence(event *core.ServerUpdateEvent, acknowledgedAt time.Time) {
+	if event.ObservedAt.IsZero() {
+		return
+	}
+
+	convergence := acknowledgedAt.Sub(event.ObservedAt)
+	instrumentation.ObserveConvergence(convergenceType(event.Type), event.NginxHost, convergence)
+
+	threshold := s.settings.Synchronizer.ConvergenceWarningThreshold
+	if threshold <= 0 || s.unstaggeredConvergence(event, acknowledgedAt) <= threshold {
+		return
+	}
+
+	servers := make([]string, 0, len(event.UpstreamServers))
+	for _, server := range event.UpstreamServers {
+		servers = append(servers, server.Host)
+	}
+
+	logrus.WithFields(event.LogFields()).
+		WithFields(logrus.Fields{"clientType": event.ClientType, "resourceVersion": event.ResourceVersion, "servers": servers, "observedAt": event.ObservedAt.Format(time.RFC3339Nano)}).
+		Warnf(`Synchronizer::observeConvergence: the change took %v to converge, above the threshold of %v`, convergence.Round(time.Millisecond), threshold)
+}
+
+// unstaggeredConvergence returns the time from the observation of the change of the event to its acknowledgment, less
+// the time the change was held back by the stagger of the host: from the end of the stagger, when it ended afterward.
+// The Deleted events are never held back.
+func (s *Synchronizer) unstaggeredConvergence(event *core.ServerUpdateEvent, acknowledgedAt time.Time) time.Duration {
+	if readyAt := s.hostStagger.readyAtOf(event.NginxHost); event.Type != core.Deleted && readyAt.After(event.ObservedAt) {
+		return acknowledgedAt.Sub(readyAt)
+	}
+
+	return acknowledgedAt.Sub(event.ObservedAt)
+}
+
+// convergenceType returns the type of the change of the event, as labeled on the convergence metric.
+func convergenceType(eventType core.EventType) string {
+	switch eventType {
+	case core.Created:
+		return "add"
+	case core.Deleted:
+		return "delete"
+	default:
+		return "update"
+	}
+}
+
+// earliestObservation returns the earliest of the observation times that is set, zero when neither is.
+func earliestObservation(observedAt time.Time, other time.Time) time.Time {
+	if observedAt.IsZero() || (!other.IsZero() && other.Before(observedAt)) {
+		return other
+	}
+
+	return observedAt
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSynchronizer_ObservesTheConvergenceOfTheAppliedChanges(t *testing.T) {
+	host := "https://converging:8080"
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{host}, nil)
+
+	series := testutil.CollectAndCount(instrumentation.Convergence)
+
+	events := buildUpdateEvents(2)
+	events[0].ObservedAt = time.Now().Add(-time.Second * 10)
+	events[1].UpstreamName = "reconciled"
+
+	for _, event := range events {
+		if err := synchronizer.handleEvent(core.ServerUpdateEventWithIdAndHost(event, event.Id, host)); err != nil {
+			t.Fatalf(`should have been no error, %v`, err)
+		}
+	}
+
+	// the event that stems from no Kubernetes change is not observed
+	if count := testutil.CollectAndCount(instrumentation.Convergence); count != series+1 {
+		t.Fatalf(`expected the convergence of the change to the host to be observed once, got %d series after %d`, count, series)
+	}
+}
+
+func TestSynchronizer_DoesNotCountTheStaggerAgainstTheConvergenceThreshold(t *testing.T) {
+	host := "https://staggered:8080"
+
+	synchronizer, _, _ := buildSynchronizer(t, nil, nil)
+
+	acknowledgedAt := time.Now()
+	synchronizer.hostStagger.readyAt[host] = acknowledgedAt.Add(-time.Second)
+
+	event := buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")
+	event.NginxHost = host
+	event.ObservedAt = acknowledgedAt.Add(-time.Second * 10)
+
+	if convergence := synchronizer.unstaggeredConvergence(event, acknowledgedAt); convergence != time.Second {
+		t.Errorf(`expected the change to converge from the end of the stagger, got %v`, convergence)
+	}
+
+	// the deletions are not held back by the stagger
+	event.Type = core.Deleted
+	if convergence := synchronizer.unstaggeredConvergence(event, acknowledgedAt); convergence != time.Second*10 {
+		t.Errorf(`expected the deletion to converge from its observation, got %v`, convergence)
+	}
+
+	// a change observed once the stagger has ended converges from its observation
+	event.Type = core.Updated
+	event.ObservedAt = acknowledgedAt.Add(-time.Millisecond * 500)
+	if convergence := synchronizer.unstaggeredConvergence(event, acknowledgedAt); convergence != time.Millisecond*500 {
+		t.Errorf(`expected the change to converge from its observation, got %v`, convergence)
+	}
+}
+
+func TestCoalescer_KeepsTheEarliestObservation(t *testing.T) {
+	coalescer := newCoalescer()
+	observedAt := time.Now().Add(-time.Second)
+
+	first := newSyncEvent(buildUpdateEvents(1)[0], []string{"https://localhost:8080"})
+	first.event.ObservedAt = observedAt
+	coalescer.add(first, false)
+
+	second := newSyncEvent(buildUpdateEvents(1)[0], []string{"https://localhost:8080"})
+	second.event.ObservedAt = time.Now()
+	coalescer.add(second, false)
+
+	if !first.event.ObservedAt.Equal(observedAt) {
+		t.Fatalf(`expected the merged update to converge from the earliest change, got %v`, first.event.ObservedAt)
+	}
+}
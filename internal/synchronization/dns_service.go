@@ -0,0 +1,417 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// dnsServiceWriter maintains the DNS Service, see SynchronizerSettings::DnsServiceName: a headless Service without a
+// selector, and its EndpointSlices, one per address family, whose addresses are the IPs of the NGINX Plus hosts. The
+// objects are created, then merge patched when they differ, so writing them again changes nothing. The DNS Services this
+// instance of NLK created under another name or namespace, or while the DNS Service was enabled, are deleted. Only the
+// objects whose OwnerAnnotation names this instance, see dnsServiceOwner, are changed or deleted; a Service of the same
+// name created by something else, or by another instance of NLK, is reported and left alone.
+type dnsServiceWriter struct {
+	client   kubernetes.Interface
+	settings *configuration.Settings
+
+	// lock orders the writes, which follow the changes of the hosts and the reconciliations.
+	lock sync.Mutex
+}
+
+// newDnsServiceWriter creates a new dnsServiceWriter.
+func newDnsServiceWriter(client kubernetes.Interface, settings *configuration.Settings) *dnsServiceWriter {
+	return &dnsServiceWriter{
+		client:   client,
+		settings: settings,
+	}
+}
+
+// write writes the DNS Service and its EndpointSlices with the IPs of the current hosts, if enabled, then deletes the DNS
+// Services that are no longer configured.
+func (w *dnsServiceWriter) write() {
+	if w == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	name := w.settings.Synchronizer.DnsServiceName
+	namespace := w.namespace()
+	fields := logrus.Fields{"namespace": namespace, "service": name}
+
+	if w.settings.IsDryRun() {
+		logrus.WithFields(fields).Debug(`dnsServiceWriter::write: dry-run, the DNS Service is not written`)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.settings.Context, w.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	if name != "" {
+		ips, unresolved := dnsServiceIps(w.settings)
+		if len(unresolved) > 0 {
+			logrus.WithFields(fields).WithField("hosts", unresolved).
+				Warn(`dnsServiceWriter::write: the hosts not addressed by an IP are left out of the DNS Service`)
+		}
+
+		if err := w.apply(ctx, namespace, name, ips); err != nil {
+			logrus.WithFields(fields).WithError(err).Warn(`dnsServiceWriter::write: error occurred writing the DNS Service`)
+		}
+	}
+
+	if err := w.collect(ctx, namespace, name); err != nil {
+		logrus.WithFields(fields).WithError(err).Warn(`dnsServiceWriter::write: error occurred deleting the DNS Services no longer configured`)
+	}
+}
+
+// namespace returns the namespace of the DNS Service, the ConfigMap namespace unless set.
+func (w *dnsServiceWriter) namespace() string {
+	if w.settings.Synchronizer.DnsServiceNamespace != "" {
+		return w.settings.Synchronizer.DnsServiceNamespace
+	}
+
+	return w.settings.ConfigMapNamespace
+}
+
+// owner returns the value of the OwnerAnnotation of the objects this instance of NLK creates.
+func (w *dnsServiceWriter) owner() string {
+	return dnsServiceOwner(w.settings)
+}
+
+// apply creates the Service and its EndpointSlices with the IPs, or patches them when they differ.
+func (w *dnsServiceWriter) apply(ctx context.Context, namespace string, name string, ips []string) error {
+	services := w.client.CoreV1().Services(namespace)
+
+	service, err := services.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		service = &corev1.Service{
+			ObjectMeta: dnsServiceMeta(namespace, name, w.owner()),
+			Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+		}
+
+		if service, err = services.Create(ctx, service, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf(`error occurred creating the Service: %w`, err)
+		}
+
+		logrus.WithFields(logrus.Fields{"namespace": namespace, "service": name}).Info(`dnsServiceWriter::apply: created the DNS Service`)
+
+	case err != nil:
+		return fmt.Errorf(`error occurred getting the Service: %w`, err)
+
+	case !w.ownedBy(service.ObjectMeta):
+		return fmt.Errorf(`the Service %s/%s was not created by this instance of NLK, its %s annotation is not %q`, namespace, name, configuration.OwnerAnnotation, w.owner())
+
+	case service.Labels[configuration.ManagedByLabel] != configuration.ManagedByNlk:
+		patch, err := dnsServicePatch(w.owner(), nil, nil)
+		if err == nil {
+			_, err = services.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+
+		if err != nil {
+			return fmt.Errorf(`error occurred patching the Service: %w`, err)
+		}
+	}
+
+	changed := false
+	for _, addressType := range []discoveryv1.AddressType{discoveryv1.AddressTypeIPv4, discoveryv1.AddressTypeIPv6} {
+		sliceChanged, err := w.applySlice(ctx, service, addressType, familyIps(ips, addressType))
+		if err != nil {
+			return err
+		}
+
+		changed = changed || sliceChanged
+	}
+
+	if changed {
+		logrus.WithFields(logrus.Fields{"namespace": namespace, "service": name, "ips": ips}).Info(`dnsServiceWriter::apply: wrote the addresses of the DNS Service`)
+	}
+
+	return nil
+}
+
+// applySlice creates the EndpointSlice of the address family of the Service with the IPs, patches it when it differs, or
+// deletes it when there is no IP of the family; it returns whether the EndpointSlice was changed.
+func (w *dnsServiceWriter) applySlice(ctx context.Context, service *corev1.Service, addressType discoveryv1.AddressType, ips []string) (bool, error) {
+	endpointSlices := w.client.DiscoveryV1().EndpointSlices(service.Namespace)
+	name := dnsServiceSliceName(service.Name, addressType)
+
+	existing, err := endpointSlices.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if len(ips) == 0 {
+			return false, nil
+		}
+
+		existing = &discoveryv1.EndpointSlice{
+			ObjectMeta:  dnsServiceSliceMeta(service, name, w.owner()),
+			AddressType: addressType,
+			Endpoints:   dnsServiceEndpoints(ips),
+		}
+
+		if _, err = endpointSlices.Create(ctx, existing, metav1.CreateOptions{}); err != nil {
+			return false, fmt.Errorf(`error occurred creating the EndpointSlice: %w`, err)
+		}
+
+	case err != nil:
+		return false, fmt.Errorf(`error occurred getting the EndpointSlice: %w`, err)
+
+	case !w.ownedBy(existing.ObjectMeta):
+		return false, fmt.Errorf(`the EndpointSlice %s/%s was not created by this instance of NLK, its %s annotation is not %q`, service.Namespace, name, configuration.OwnerAnnotation, w.owner())
+
+	case len(ips) == 0:
+		err = endpointSlices.Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf(`error occurred deleting the EndpointSlice: %w`, err)
+		}
+
+	case !slices.Equal(endpointSliceIps(existing), ips) || existing.Labels[configuration.ManagedByLabel] != configuration.ManagedByNlk:
+		patch, err := dnsServicePatch(w.owner(), dnsServiceSliceLabels(service.Name), map[string]interface{}{"endpoints": dnsServiceEndpoints(ips)})
+		if err == nil {
+			_, err = endpointSlices.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+
+		if err != nil {
+			return false, fmt.Errorf(`error occurred patching the EndpointSlice: %w`, err)
+		}
+
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// dnsServicePatch returns the merge patch setting the ownership label and annotation, along with the labels, of the DNS
+// Service, or of its EndpointSlices along with their endpoints.
+func dnsServicePatch(owner string, labels map[string]string, fields map[string]interface{}) ([]byte, error) {
+	patchLabels := map[string]string{configuration.ManagedByLabel: configuration.ManagedByNlk}
+	for key, value := range labels {
+		patchLabels[key] = value
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      patchLabels,
+			"annotations": map[string]string{configuration.OwnerAnnotation: owner},
+		},
+	}
+	for key, value := range fields {
+		patch[key] = value
+	}
+
+	return json.Marshal(patch)
+}
+
+// collect deletes the DNS Services this instance of NLK created, and their EndpointSlices, other than the named one; an
+// empty name deletes them all. They are listed in every namespace, or only in the namespace when NLK may not list the
+// Services of every namespace. A DNS Service NLK may not delete, e.g. in a namespace without the Role of the DNS Service,
+// is reported and skipped.
+func (w *dnsServiceWriter) collect(ctx context.Context, namespace string, name string) error {
+	selector := metav1.ListOptions{LabelSelector: fmt.Sprintf(`%s=%s`, configuration.ManagedByLabel, configuration.ManagedByNlk)}
+
+	services, err := w.client.CoreV1().Services(metav1.NamespaceAll).List(ctx, selector)
+	if apierrors.IsForbidden(err) {
+		services, err = w.client.CoreV1().Services(namespace).List(ctx, selector)
+	}
+
+	if apierrors.IsForbidden(err) {
+		logrus.WithError(err).Debug(`dnsServiceWriter::collect: NLK may not list the Services, the DNS Services are not collected`)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf(`error occurred listing the Services: %w`, err)
+	}
+
+	for _, service := range services.Items {
+		if !w.ownedBy(service.ObjectMeta) || (service.Namespace == namespace && service.Name == name) {
+			continue
+		}
+
+		fields := logrus.Fields{"namespace": service.Namespace, "service": service.Name}
+
+		err = w.deleteSlices(ctx, service.Namespace, service.Name)
+		if err == nil {
+			err = w.client.CoreV1().Services(service.Namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+		}
+
+		switch {
+		case apierrors.IsForbidden(err):
+			logrus.WithFields(fields).WithError(err).Warn(`dnsServiceWriter::collect: NLK may not delete the DNS Service no longer configured`)
+			continue
+
+		case err != nil && !apierrors.IsNotFound(err):
+			return fmt.Errorf(`error occurred deleting the DNS Service %s/%s: %w`, service.Namespace, service.Name, err)
+		}
+
+		logrus.WithFields(fields).Info(`dnsServiceWriter::collect: deleted the DNS Service, it is no longer configured`)
+	}
+
+	return nil
+}
+
+// deleteSlices deletes the EndpointSlices this instance of NLK created for the named DNS Service.
+func (w *dnsServiceWriter) deleteSlices(ctx context.Context, namespace string, name string) error {
+	endpointSlices := w.client.DiscoveryV1().EndpointSlices(namespace)
+
+	list, err := endpointSlices.List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf(`%s=%s`, discoveryv1.LabelServiceName, name)})
+	if err != nil {
+		return err
+	}
+
+	for _, endpointSlice := range list.Items {
+		if !w.ownedBy(endpointSlice.ObjectMeta) {
+			continue
+		}
+
+		err = endpointSlices.Delete(ctx, endpointSlice.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ownedBy determines whether the OwnerAnnotation of the object names this instance of NLK.
+func (w *dnsServiceWriter) ownedBy(meta metav1.ObjectMeta) bool {
+	return meta.Annotations[configuration.OwnerAnnotation] == w.owner()
+}
+
+// dnsServiceOwner returns the value of the OwnerAnnotation of the objects created by the instance of NLK configured by the
+// settings: the namespace and name of its ConfigMap, which tell the instances of NLK sharing a cluster apart.
+func dnsServiceOwner(settings *configuration.Settings) string {
+	return fmt.Sprintf("%s/%s", settings.ConfigMapNamespace, settings.ConfigMapName)
+}
+
+// dnsServiceIps returns the IPs of the NGINX Plus hosts, sorted and without duplicates, and the hosts whose API is not
+// addressed by an IP, which cannot be endpoints.
+func dnsServiceIps(settings *configuration.Settings) (ips []string, unresolved []string) {
+	for _, host := range settings.Hosts() {
+		nginxPlusHost, found := settings.NginxPlusHost(host)
+		if !found {
+			continue
+		}
+
+		endpoint, err := url.Parse(nginxPlusHost.Endpoint)
+		if err != nil {
+			continue
+		}
+
+		ip, err := netip.ParseAddr(endpoint.Hostname())
+		if err != nil {
+			unresolved = append(unresolved, host)
+			continue
+		}
+
+		if !slices.Contains(ips, ip.String()) {
+			ips = append(ips, ip.String())
+		}
+	}
+
+	sort.Strings(ips)
+
+	return ips, unresolved
+}
+
+// familyIps returns the IPs of the address family.
+func familyIps(ips []string, addressType discoveryv1.AddressType) []string {
+	var familyIps []string
+	for _, ip := range ips {
+		if netip.MustParseAddr(ip).Is4() == (addressType == discoveryv1.AddressTypeIPv4) {
+			familyIps = append(familyIps, ip)
+		}
+	}
+
+	return familyIps
+}
+
+// dnsServiceMeta returns the metadata of the DNS Service, marking it as created by the owner.
+func dnsServiceMeta(namespace string, name string, owner string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{configuration.ManagedByLabel: configuration.ManagedByNlk},
+		Annotations: map[string]string{configuration.OwnerAnnotation: owner},
+	}
+}
+
+// dnsServiceSliceName returns the name of the EndpointSlice of the address family of the DNS Service.
+func dnsServiceSliceName(name string, addressType discoveryv1.AddressType) string {
+	return fmt.Sprintf("%s-%s", name, strings.ToLower(string(addressType)))
+}
+
+// dnsServiceSliceLabels returns the labels binding an EndpointSlice to the DNS Service, and telling the EndpointSlice
+// controller that NLK manages it.
+func dnsServiceSliceLabels(name string) map[string]string {
+	return map[string]string{
+		configuration.ManagedByLabel: configuration.ManagedByNlk,
+		discoveryv1.LabelServiceName: name,
+		discoveryv1.LabelManagedBy:   configuration.ManagedByNlk,
+	}
+}
+
+// dnsServiceSliceMeta returns the metadata of an EndpointSlice of the DNS Service, marking it as created by the owner, and
+// owned by the Service, so that it is garbage collected along with it.
+func dnsServiceSliceMeta(service *corev1.Service, name string, owner string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   service.Namespace,
+		Labels:      dnsServiceSliceLabels(service.Name),
+		Annotations: map[string]string{configuration.OwnerAnnotation: owner},
+		OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(service, corev1.SchemeGroupVersion.WithKind("Service")),
+		},
+	}
+}
+
+// dnsServiceEndpoints returns the endpoints of an EndpointSlice of the DNS Service, a ready endpoint per IP.
+func dnsServiceEndpoints(ips []string) []discoveryv1.Endpoint {
+	ready := true
+
+	endpoints := make([]discoveryv1.Endpoint, 0, len(ips))
+	for _, ip := range ips {
+		endpoints = append(endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		})
+	}
+
+	return endpoints
+}
+
+// endpointSliceIps returns the addresses of the endpoints of the EndpointSlice, sorted.
+func endpointSliceIps(endpointSlice *discoveryv1.EndpointSlice) []string {
+	var ips []string
+	for _, endpoint := range endpointSlice.Endpoints {
+		ips = append(ips, endpoint.Addresses...)
+	}
+
+	sort.Strings(ips)
+
+	return ips
+}
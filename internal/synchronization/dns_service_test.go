@@ -0,0 +1,148 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDnsServiceWriter_WritesTheIpsOfTheHosts(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	writer := buildDnsServiceWriter(t, k8sClient, "nlk-dns")
+	writer.settings.SetHosts([]string{"https://10.0.0.2:9000/api", "https://10.0.0.1:9000/api", "https://nginx.example.com/api"})
+
+	writer.write()
+
+	service, err := k8sClient.CoreV1().Services("nlk").Get(context.Background(), "nlk-dns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(`expected the DNS Service to be created, got %v`, err)
+	}
+
+	if service.Spec.ClusterIP != corev1.ClusterIPNone || service.Annotations[configuration.OwnerAnnotation] != "nlk/nlk-config" || service.Labels[configuration.ManagedByLabel] != configuration.ManagedByNlk {
+		t.Fatalf(`expected a headless Service owned by NLK, got %+v`, service)
+	}
+
+	if ips := getDnsServiceIps(t, k8sClient); !slices.Equal(ips, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Fatalf(`expected the IPs of the hosts addressed by an IP, got %v`, ips)
+	}
+
+	// the addresses are already written
+	writer.write()
+	if patches := countPatches(k8sClient); patches != 0 {
+		t.Fatalf(`expected no patch when nothing changed, got %d`, patches)
+	}
+
+	writer.settings.SetHosts([]string{"https://10.0.0.1:9000/api", "https://[2001:db8::1]:9000/api"})
+	writer.write()
+
+	if ips := getDnsServiceIps(t, k8sClient); !slices.Equal(ips, []string{"10.0.0.1", "2001:db8::1"}) {
+		t.Fatalf(`expected the IPs of the new hosts, got %v`, ips)
+	}
+}
+
+func TestDnsServiceWriter_DeletesTheDnsServicesNoLongerConfigured(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	writer := buildDnsServiceWriter(t, k8sClient, "nlk-dns")
+	writer.settings.SetHosts([]string{"https://10.0.0.1:9000/api"})
+
+	writer.write()
+
+	writer.settings.Synchronizer.DnsServiceName = "nlk-vips"
+	writer.write()
+
+	if _, err := k8sClient.CoreV1().Services("nlk").Get(context.Background(), "nlk-dns", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf(`expected the renamed DNS Service to be deleted, got %v`, err)
+	}
+
+	if _, err := k8sClient.DiscoveryV1().EndpointSlices("nlk").Get(context.Background(), "nlk-dns-ipv4", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf(`expected the EndpointSlice of the renamed DNS Service to be deleted, got %v`, err)
+	}
+
+	writer.settings.Synchronizer.DnsServiceName = ""
+	writer.write()
+
+	if _, err := k8sClient.CoreV1().Services("nlk").Get(context.Background(), "nlk-vips", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf(`expected the DNS Service to be deleted once disabled, got %v`, err)
+	}
+}
+
+func TestDnsServiceWriter_LeavesTheServicesOfOthersAlone(t *testing.T) {
+	foreign := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nlk-dns",
+			Namespace: "nlk",
+			Labels:    map[string]string{configuration.ManagedByLabel: configuration.ManagedByNlk},
+		},
+	}
+	k8sClient := fake.NewSimpleClientset(foreign)
+	writer := buildDnsServiceWriter(t, k8sClient, "nlk-dns")
+	writer.settings.SetHosts([]string{"https://10.0.0.1:9000/api"})
+
+	writer.write()
+
+	if _, err := k8sClient.DiscoveryV1().EndpointSlices("nlk").Get(context.Background(), "nlk-dns-ipv4", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf(`expected no EndpointSlice for a Service NLK did not create, got %v`, err)
+	}
+
+	writer.settings.Synchronizer.DnsServiceName = ""
+	writer.write()
+
+	if _, err := k8sClient.CoreV1().Services("nlk").Get(context.Background(), "nlk-dns", metav1.GetOptions{}); err != nil {
+		t.Fatalf(`expected the Service NLK did not create to be kept, got %v`, err)
+	}
+}
+
+func TestDnsServiceWriter_LeavesTheDnsServicesOfOtherInstancesAlone(t *testing.T) {
+	other := &corev1.Service{
+		ObjectMeta: dnsServiceMeta("nlk", "nlk-dns", "nlk/nlk-config-other"),
+	}
+	k8sClient := fake.NewSimpleClientset(other)
+	writer := buildDnsServiceWriter(t, k8sClient, "nlk-vips")
+	writer.settings.SetHosts([]string{"https://10.0.0.1:9000/api"})
+
+	writer.write()
+
+	if _, err := k8sClient.CoreV1().Services("nlk").Get(context.Background(), "nlk-dns", metav1.GetOptions{}); err != nil {
+		t.Fatalf(`expected the DNS Service of another instance to be kept, got %v`, err)
+	}
+}
+
+func buildDnsServiceWriter(t *testing.T, k8sClient *fake.Clientset, name string) *dnsServiceWriter {
+	settings, err := configuration.NewSettings(context.Background(), k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.ConfigMapNamespace = "nlk"
+	settings.Synchronizer.DnsServiceName = name
+
+	return newDnsServiceWriter(k8sClient, settings)
+}
+
+func getDnsServiceIps(t *testing.T, k8sClient *fake.Clientset) []string {
+	endpointSlices, err := k8sClient.DiscoveryV1().EndpointSlices("nlk").List(context.Background(), metav1.ListOptions{})
+	if err != nil || len(endpointSlices.Items) == 0 {
+		t.Fatalf(`expected the EndpointSlices of the DNS Service, got %v`, err)
+	}
+
+	var ips []string
+	for _, endpointSlice := range endpointSlices.Items {
+		ips = append(ips, endpointSliceIps(&endpointSlice)...)
+	}
+
+	sort.Strings(ips)
+
+	return ips
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// drainCheckInterval is how often the active connections of the draining servers are checked, see confirmDrains.
+const drainCheckInterval = time.Second * 10
+
+// drainingUpstreams records the last event applied to each upstream on each host that drains servers, so the active
+// connections of the draining servers can be checked, see confirmDrains.
+type drainingUpstreams struct {
+
+	// lock guards events, the events are recorded by the Synchronizer workers.
+	lock sync.Mutex
+
+	events map[appliedKey]*core.ServerUpdateEvent
+}
+
+// newDrainingUpstreams creates a new, empty drainingUpstreams.
+func newDrainingUpstreams() *drainingUpstreams {
+	return &drainingUpstreams{
+		events: make(map[appliedKey]*core.ServerUpdateEvent),
+	}
+}
+
+// record records the event applied to its upstream on its host, or forgets the upstream once none of its servers drain.
+func (d *drainingUpstreams) record(event *core.ServerUpdateEvent) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	draining := slices.ContainsFunc(event.UpstreamServers, func(server *core.UpstreamServer) bool { return server.Drain })
+	if draining {
+		d.events[keyOf(event)] = event
+	} else {
+		delete(d.events, keyOf(event))
+	}
+}
+
+// copy returns the events recorded on the hosts, and forgets those of the other hosts, e.g. hosts that were removed.
+func (d *drainingUpstreams) copy(hosts []string) []*core.ServerUpdateEvent {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var events []*core.ServerUpdateEvent
+	for key, event := range d.events {
+		if !slices.Contains(hosts, key.host) {
+			delete(d.events, key)
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// SetDrainConfirmer sets the function notified of the addresses whose draining servers are drained, typically
+// Watcher::ConfirmDrained; without it the draining servers are not checked.
+func (s *Synchronizer) SetDrainConfirmer(confirmer func(addresses []string)) {
+	s.drainConfirmer = confirmer
+}
+
+// confirmDrains asks the Border Clients for the active connections of the draining servers, and notifies the drainConfirmer
+// of the addresses whose servers have at most WatcherSettings::DrainedConnectionsThreshold left, none by default, in every
+// upstream of every host. An address is not confirmed while one of its servers has more active connections, or cannot be
+// checked, e.g. the Border Server does not report the connections. The checks run on their own goroutine, see Run, so a
+// long drain does not hold up the syncs.
+func (s *Synchronizer) confirmDrains() {
+	if s.drainConfirmer == nil {
+		return
+	}
+
+	threshold := uint64(s.settings.Watcher.DrainedConnectionsThreshold)
+	drained := make(map[string]bool)
+
+	for _, event := range s.drainingUpstreams.copy(s.settings.Hosts()) {
+		connections, known := s.activeConnections(event)
+
+		for _, server := range event.UpstreamServers {
+			if !server.Drain {
+				continue
+			}
+
+			address, _, err := net.SplitHostPort(server.Host)
+			if err != nil {
+				continue
+			}
+
+			if previous, found := drained[address]; !found || previous {
+				drained[address] = known && connections[server.Host] <= threshold
+			}
+		}
+	}
+
+	var addresses []string
+	for address, isDrained := range drained {
+		if isDrained {
+			addresses = append(addresses, address)
+		}
+	}
+
+	if len(addresses) == 0 {
+		return
+	}
+
+	sort.Strings(addresses)
+
+	logrus.WithField("addresses", addresses).Debug(`Synchronizer::confirmDrains: the draining servers are drained`)
+
+	s.drainConfirmer(addresses)
+}
+
+// activeConnections returns the active connections of the servers of the upstream of the event on its host, known is false
+// when the Border Client cannot tell.
+func (s *Synchronizer) activeConnections(event *core.ServerUpdateEvent) (map[string]uint64, bool) {
+	borderClient, err := s.borderClientFactory(event)
+	if err != nil {
+		logrus.WithFields(event.LogFields()).Debugf(`Synchronizer::activeConnections: error occurred creating the border client: %v`, err)
+		return nil, false
+	}
+
+	reporter, ok := borderClient.(application.ActiveConnectionsReporter)
+	if !ok {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.Synchronizer.UpstreamTimeout)
+	defer cancel()
+
+	connections, known, err := reporter.ActiveConnections(ctx, event)
+	if err != nil {
+		logrus.WithFields(event.LogFields()).Warnf(`Synchronizer::activeConnections: unable to tell the active connections of the draining servers: %v`, err)
+		return nil, false
+	}
+
+	return connections, known
+}
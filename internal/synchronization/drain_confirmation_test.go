@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// connectionsBorderClient is a fakeBorderClient that reports the active connections of the servers.
+type connectionsBorderClient struct {
+	*fakeBorderClient
+	connections map[string]uint64
+}
+
+func (c *connectionsBorderClient) ActiveConnections(_ context.Context, _ *core.ServerUpdateEvent) (map[string]uint64, bool, error) {
+	return c.connections, true, nil
+}
+
+func TestSynchronizer_ConfirmsTheDrainedServers(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	borderClient := &connectionsBorderClient{fakeBorderClient: newFakeBorderClient(), connections: map[string]uint64{
+		"10.0.0.1:30080": 5, "10.0.0.2:30080": 2, "10.0.0.3:30080": 0,
+	}}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	var confirmed [][]string
+	synchronizer.SetDrainConfirmer(func(addresses []string) { confirmed = append(confirmed, addresses) })
+
+	events := buildUpdateEvents(1)
+	events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")}
+	for _, host := range []string{"10.0.0.2:30080", "10.0.0.3:30080"} {
+		server := core.NewUpstreamServer(host)
+		server.Drain = true
+		events[0].UpstreamServers = append(events[0].UpstreamServers, server)
+	}
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+	synchronizer.confirmDrains()
+
+	if !reflect.DeepEqual(confirmed, [][]string{{"10.0.0.3"}}) {
+		t.Fatalf(`expected only the draining server without active connections to be confirmed, got %v`, confirmed)
+	}
+
+	// the connections close
+	borderClient.connections = map[string]uint64{"10.0.0.1:30080": 5}
+	synchronizer.confirmDrains()
+
+	if len(confirmed) != 2 || !reflect.DeepEqual(confirmed[1], []string{"10.0.0.2", "10.0.0.3"}) {
+		t.Fatalf(`expected both draining servers to be confirmed, got %v`, confirmed)
+	}
+
+	// the servers are no longer drained
+	events = buildUpdateEvents(1)
+	events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")}
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+	synchronizer.confirmDrains()
+
+	if len(confirmed) != 2 {
+		t.Fatalf(`expected nothing to be confirmed once no server drains, got %v`, confirmed)
+	}
+}
+
+func TestSynchronizer_DoesNotConfirmTheServersThatCannotBeChecked(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	confirmed := false
+	synchronizer.SetDrainConfirmer(func([]string) { confirmed = true })
+
+	events := buildUpdateEvents(1)
+	server := core.NewUpstreamServer("10.0.0.2:30080")
+	server.Drain = true
+	events[0].UpstreamServers = core.UpstreamServers{server}
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+	synchronizer.confirmDrains()
+
+	if confirmed {
+		t.Fatalf(`expected the servers to be left to the drain timeout when their connections are not reported`)
+	}
+}
+
+func TestSynchronizer_ConfirmsTheServersBelowTheDrainedConnectionsThreshold(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Watcher.DrainedConnectionsThreshold = 10
+	})
+
+	// the long-lived connections of a stream upstream move at different paces on each host
+	connections := map[string]map[string]uint64{
+		"https://localhost:8080": {"10.0.0.2:1883": 4, "10.0.0.3:1883": 10},
+		"https://localhost:8081": {"10.0.0.2:1883": 2000, "10.0.0.3:1883": 0},
+	}
+	synchronizer.borderClientFactory = func(event *core.ServerUpdateEvent) (application.Interface, error) {
+		return &connectionsBorderClient{fakeBorderClient: newFakeBorderClient(), connections: connections[event.NginxHost]}, nil
+	}
+
+	var confirmed [][]string
+	synchronizer.SetDrainConfirmer(func(addresses []string) { confirmed = append(confirmed, addresses) })
+
+	events := buildUpdateEvents(1)
+	events[0].ClientType = application.ClientTypeNginxStream
+	for _, host := range []string{"10.0.0.2:1883", "10.0.0.3:1883"} {
+		server := core.NewUpstreamServer(host)
+		server.Drain = true
+		events[0].UpstreamServers = append(events[0].UpstreamServers, server)
+	}
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+	synchronizer.confirmDrains()
+
+	if !reflect.DeepEqual(confirmed, [][]string{{"10.0.0.3"}}) {
+		t.Fatalf(`expected only the server at or below the threshold on every host to be confirmed, got %v`, confirmed)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// detectDrift lists the upstreams of the host NLK has applied servers to, and compares the checksum of the servers each one
+// has with the checksum of the servers last applied, see appliedCache. An upstream whose servers differ, e.g. a server added
+// or removed through the NGINX Plus dashboard, has drifted: the drift is reported, then handled per the DriftPolicy.
+// Only the membership is compared, by server address; the parameters of the servers are not, nor, while an OwnershipTag is
+// set, the servers added outside NLK, which it does not own. The upstreams that are not defined on the host are left to
+// the UpstreamNotFound handling of the syncs.
+func (s *Synchronizer) detectDrift(host string) {
+	applied := s.appliedCache.appliedOn(host)
+	if len(applied) == 0 {
+		return
+	}
+
+	actual, err := s.listUpstreamServers(host, applied)
+	if err != nil {
+		logrus.WithField("host", host).WithError(err).Warn(`Synchronizer::detectDrift: error occurred listing the upstreams`)
+		return
+	}
+
+	for _, event := range applied {
+		servers, found := actual[keyOf(event)]
+		if !found {
+			continue
+		}
+
+		expected := make([]string, 0, len(event.UpstreamServers))
+		for _, server := range event.UpstreamServers {
+			expected = append(expected, server.Host)
+		}
+
+		// the servers NLK does not own are expected to be added outside NLK, see SynchronizerSettings::OwnershipTag
+		if s.settings.Synchronizer.OwnershipTag != "" {
+			servers = slices.DeleteFunc(servers, func(server string) bool { return !slices.Contains(expected, server) })
+		}
+
+		if serversChecksum(expected) == serversChecksum(servers) {
+			continue
+		}
+
+		// an update applied while the upstreams were listed is not a drift
+		if !s.appliedCache.stillApplied(event) {
+			continue
+		}
+
+		s.handleDrift(event, expected, servers)
+	}
+}
+
+// listUpstreamServers returns the servers of the upstreams of the events on the host, by upstream; the HTTP and the stream
+// upstreams are only listed when one of the events targets them.
+func (s *Synchronizer) listUpstreamServers(host string, events []*core.ServerUpdateEvent) (map[appliedKey][]string, error) {
+	lister, err := s.upstreamListerFactory(host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	servers := make(map[appliedKey][]string)
+
+	if slices.ContainsFunc(events, func(event *core.ServerUpdateEvent) bool { return event.ClientType == application.ClientTypeNginxHttp }) {
+		upstreams, err := lister.GetUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the HTTP upstreams: %w`, err)
+		}
+
+		for name, upstream := range *upstreams {
+			key := appliedKey{host: host, clientType: application.ClientTypeNginxHttp, upstream: name}
+			servers[key] = []string{}
+			for _, peer := range upstream.Peers {
+				servers[key] = append(servers[key], peer.Server)
+			}
+		}
+	}
+
+	if slices.ContainsFunc(events, func(event *core.ServerUpdateEvent) bool { return event.ClientType == application.ClientTypeNginxStream }) {
+		upstreams, err := lister.GetStreamUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the stream upstreams: %w`, err)
+		}
+
+		for name, upstream := range *upstreams {
+			key := appliedKey{host: host, clientType: application.ClientTypeNginxStream, upstream: name}
+			servers[key] = []string{}
+			for _, peer := range upstream.Peers {
+				servers[key] = append(servers[key], peer.Server)
+			}
+		}
+	}
+
+	return servers, nil
+}
+
+// handleDrift reports the drift of the upstream of the event with a warning, a metric, and a DriftDetected Event on the
+// Service, then applies the DriftPolicy: the servers are queued again with DriftPolicyOverwrite, the next event for the
+// upstream is no longer skipped as unchanged with DriftPolicyNextEvent, and nothing more is done with DriftPolicyLog.
+func (s *Synchronizer) handleDrift(event *core.ServerUpdateEvent, expected []string, actual []string) {
+	policy := s.settings.Synchronizer.DriftPolicy
+	added, missing := serversDifference(expected, actual)
+
+	instrumentation.ObserveDriftDetected(event.NginxHost, event.UpstreamName)
+
+	logrus.WithFields(event.LogFields()).WithFields(logrus.Fields{
+		"expectedChecksum": serversChecksum(expected),
+		"actualChecksum":   serversChecksum(actual),
+		"added":            added,
+		"missing":          missing,
+		"policy":           policy,
+	}).Warn(`Synchronizer::detectDrift: the servers of the upstream were changed outside NLK`)
+
+	if s.settings.EventRecorder != nil && event.Service != nil {
+		s.settings.EventRecorder.Eventf(event.Service, corev1.EventTypeWarning, configuration.DriftDetectedReason,
+			"The servers of the upstream %s on %s differ from the servers last applied, added %v, missing %v; drift policy %s",
+			event.UpstreamName, configuration.RedactHost(event.NginxHost), added, missing, policy)
+	}
+
+	switch policy {
+	case configuration.DriftPolicyOverwrite:
+		s.appliedCache.invalidate(event)
+
+		correction := core.ServerUpdateEventWithIdAndHost(event, fmt.Sprintf(`[drift]-[%s]-[%s]`, RandomString(12), event.UpstreamName), event.NginxHost)
+		correction.Type = core.Updated
+		correction.ObservedAt = time.Time{}
+		correction.Resync = true
+		s.AddEvent(correction)
+
+	case configuration.DriftPolicyNextEvent:
+		s.appliedCache.invalidate(event)
+	}
+}
+
+// serversChecksum returns the SHA-256 checksum of the server addresses, in any order.
+func serversChecksum(servers []string) string {
+	sorted := slices.Clone(servers)
+	sort.Strings(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// serversDifference returns the servers that are actual but not expected, and those that are expected but not actual.
+func serversDifference(expected []string, actual []string) (added []string, missing []string) {
+	for _, server := range actual {
+		if !slices.Contains(expected, server) {
+			added = append(added, server)
+		}
+	}
+
+	for _, server := range expected {
+		if !slices.Contains(actual, server) {
+			missing = append(missing, server)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(missing)
+
+	return added, missing
+}
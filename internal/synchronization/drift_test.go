@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSynchronizer_DriftPolicy(t *testing.T) {
+	testCases := []struct {
+		policy           string
+		correctedAtOnce  bool
+		correctedByEvent bool
+	}{
+		{policy: configuration.DriftPolicyLog},
+		{policy: configuration.DriftPolicyOverwrite, correctedAtOnce: true, correctedByEvent: true},
+		{policy: configuration.DriftPolicyNextEvent, correctedByEvent: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+				settings.Synchronizer.CoalesceWindow = 0
+				settings.Synchronizer.HostStagger = 0
+				settings.Synchronizer.DriftPolicy = tc.policy
+				settings.EventRecorder = recorder
+			})
+
+			apply := func() {
+				event := buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")
+				event.Service = buildService()
+				synchronizer.AddEvents(core.ServerUpdateEvents{event})
+				drain(synchronizer, rateLimiter)
+			}
+
+			apply()
+			<-recorder.Events
+
+			// a server is added through the NGINX Plus dashboard
+			lister := &fakeUpstreamLister{upstreams: nginxClient.Upstreams{
+				"nlk-upstream": {Peers: []nginxClient.Peer{{Server: "10.0.0.2:30080"}, {Server: "10.0.0.1:30080"}, {Server: "192.168.1.1:8080"}}},
+			}}
+			synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) { return lister, nil }
+
+			before := testutil.ToFloat64(instrumentation.DriftDetected.WithLabelValues("https://localhost:8080", "nlk-upstream"))
+			synchronizer.reconcile()
+
+			if testutil.ToFloat64(instrumentation.DriftDetected.WithLabelValues("https://localhost:8080", "nlk-upstream")) != before+1 {
+				t.Fatalf(`expected the drift to be counted`)
+			}
+
+			if message := <-recorder.Events; !strings.Contains(message, configuration.DriftDetectedReason) || !strings.Contains(message, "added [192.168.1.1:8080]") {
+				t.Fatalf(`expected a DriftDetected Event naming the added server, got %s`, message)
+			}
+
+			if queued := rateLimiter.Len() == 1; queued != tc.correctedAtOnce {
+				t.Fatalf(`expected the servers to be queued again: %t, got %d queued event(s)`, tc.correctedAtOnce, rateLimiter.Len())
+			}
+			drain(synchronizer, rateLimiter)
+
+			// the next event for the upstream, e.g. the resync of the Service, pushes the same servers
+			calls := borderClient.callCount()
+			apply()
+
+			if corrected := borderClient.callCount() > calls; corrected != (tc.correctedByEvent && !tc.correctedAtOnce) {
+				t.Fatalf(`expected the next event to correct the drift: %t, got %v`, tc.correctedByEvent && !tc.correctedAtOnce, borderClient.calls)
+			}
+		})
+	}
+}
+
+func TestSynchronizer_DoesNotReportTheUpstreamsWithoutDrift(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.DriftPolicy = configuration.DriftPolicyOverwrite
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+
+	synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) {
+		return &fakeUpstreamLister{upstreams: nginxClient.Upstreams{
+			"nlk-upstream": {Peers: []nginxClient.Peer{{Server: "10.0.0.1:30080"}}},
+		}}, nil
+	}
+
+	synchronizer.reconcile()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected nothing to be queued when the servers match, got %d`, rateLimiter.Len())
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// errEmptyServers is the failure of the hosts to which an update leaving the upstream without servers was not applied,
+// as the EmptyServerPolicy is fail.
+var errEmptyServers = errors.New(`the update leaves the upstream without servers, it was not applied`)
+
+// emptyServerPolicy returns the EmptyServerPolicy of the upstream of the event: the policy annotated on the Service,
+// or SynchronizerSettings::EmptyServerPolicy.
+func (s *Synchronizer) emptyServerPolicy(event *core.ServerUpdateEvent) string {
+	if event.EmptyServerPolicy != "" {
+		return event.EmptyServerPolicy
+	}
+
+	return s.settings.Synchronizer.EmptyServerPolicy
+}
+
+// withholdEmptyServers enforces the EmptyServerPolicy of an update that leaves its upstream without servers, e.g. while
+// no node hosts a ready endpoint of the Service, and returns true when the update is not applied to the hosts: the hosts
+// keep the previous servers, and the update of the upstream that brings servers back is applied as usual.
+func (s *Synchronizer) withholdEmptyServers(event *syncEvent) bool {
+	if event.event.Type == core.Deleted || len(event.event.UpstreamServers) > 0 {
+		return false
+	}
+
+	switch s.emptyServerPolicy(event.event) {
+	case configuration.EmptyServerPolicyRetain:
+		logrus.WithFields(event.event.LogFields()).Warn(`Synchronizer::withholdEmptyServers: the update leaves the upstream without servers, the previous servers are retained`)
+
+		if s.settings.EventRecorder != nil && event.event.Service != nil {
+			s.settings.EventRecorder.Eventf(event.event.Service, corev1.EventTypeWarning, configuration.EmptyServersRetainedReason,
+				"upstream %s has no servers, its previous servers are retained on the NGINX Plus hosts", event.event.UpstreamName)
+		}
+
+		return true
+
+	case configuration.EmptyServerPolicyFail:
+		logrus.WithFields(event.event.LogFields()).Error(`Synchronizer::withholdEmptyServers: the update leaves the upstream without servers, it is rejected`)
+
+		// the update that brings servers back is applied to the hosts, rather than skipped as unchanged, so it clears the failure
+		now := time.Now()
+		for _, host := range event.pendingHosts {
+			hostEvent := core.ServerUpdateEventWithIdAndHost(event.event, event.event.Id, host)
+			instrumentation.ObserveSync(host, event.event.UpstreamName, now, errEmptyServers)
+			s.syncStatuses.record(hostEvent, now, errEmptyServers)
+			s.appliedCache.invalidate(hostEvent)
+		}
+
+		if s.settings.EventRecorder != nil && event.event.Service != nil {
+			s.settings.EventRecorder.Eventf(event.event.Service, corev1.EventTypeWarning, configuration.EmptyServersRejectedReason,
+				"upstream %s has no servers, the update was rejected and the NGINX Plus hosts keep its previous servers", event.event.UpstreamName)
+		}
+
+		s.serviceStatus.failed(event.event.Service, event.event.UpstreamName, errEmptyServers.Error())
+		s.loadBalancerStatus.failed(event.event.Service, event.event.UpstreamName)
+
+		return true
+
+	default:
+		return false
+	}
+}
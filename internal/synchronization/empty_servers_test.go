@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSynchronizer_EmptyServerPolicy(t *testing.T) {
+	testCases := []struct {
+		name           string
+		policy         string
+		annotated      string
+		emptyApplied   bool
+		warningReason  string
+		emptyLastError bool
+	}{
+		{name: "apply", policy: configuration.EmptyServerPolicyApply, emptyApplied: true},
+		{name: "retain", policy: configuration.EmptyServerPolicyRetain, warningReason: configuration.EmptyServersRetainedReason},
+		{name: "fail", policy: configuration.EmptyServerPolicyFail, warningReason: configuration.EmptyServersRejectedReason, emptyLastError: true},
+		{name: "annotated retain", policy: configuration.EmptyServerPolicyApply, annotated: configuration.EmptyServerPolicyRetain, warningReason: configuration.EmptyServersRetainedReason},
+		{name: "annotated apply", policy: configuration.EmptyServerPolicyFail, annotated: configuration.EmptyServerPolicyApply, emptyApplied: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+				settings.Synchronizer.CoalesceWindow = 0
+				settings.Synchronizer.HostStagger = 0
+				settings.Synchronizer.EmptyServerPolicy = tc.policy
+				settings.EventRecorder = recorder
+			})
+
+			apply := func(servers ...string) {
+				event := buildServerUpdateEvent(core.Updated, servers...)
+				event.Service = buildService()
+				event.EmptyServerPolicy = tc.annotated
+
+				synchronizer.AddEvents(core.ServerUpdateEvents{event})
+				drain(synchronizer, rateLimiter)
+			}
+
+			apply("10.0.0.1:30080")
+			if borderClient.callCount() != 1 || !strings.Contains(<-recorder.Events, configuration.SyncedReason) {
+				t.Fatalf(`expected the servers to be applied, got %v`, borderClient.calls)
+			}
+
+			// the last ready endpoint of the Service goes away
+			apply()
+
+			if tc.emptyApplied {
+				if borderClient.callCount() != 2 || len(borderClient.events[1].UpstreamServers) != 0 {
+					t.Fatalf(`expected the upstream to be emptied, got %v`, borderClient.calls)
+				}
+				<-recorder.Events
+			} else {
+				if borderClient.callCount() != 1 {
+					t.Fatalf(`expected the previous servers to be kept, got %v`, borderClient.calls)
+				}
+
+				if event := <-recorder.Events; !strings.HasPrefix(event, "Warning "+tc.warningReason) {
+					t.Fatalf(`expected a %s Warning Event, got %q`, tc.warningReason, event)
+				}
+			}
+
+			lastError := synchronizer.Snapshot().Upstreams[0].Hosts[0].LastError
+			if tc.emptyLastError != (lastError != "") {
+				t.Fatalf(`expected the sync to be failed: %t, got %q`, tc.emptyLastError, lastError)
+			}
+
+			// the endpoint is ready again
+			apply("10.0.0.1:30080")
+
+			if event := <-recorder.Events; !strings.Contains(event, configuration.SyncedReason) {
+				t.Fatalf(`expected the upstream to be synced, got %q`, event)
+			}
+
+			if lastError = synchronizer.Snapshot().Upstreams[0].Hosts[0].LastError; lastError != "" {
+				t.Fatalf(`expected the failure to be cleared, got %q`, lastError)
+			}
+		})
+	}
+}
+
+func TestSynchronizer_AlwaysAppliesTheDeletions(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.HostStagger = 0
+		settings.Synchronizer.EmptyServerPolicy = configuration.EmptyServerPolicyFail
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 1 {
+		t.Fatalf(`expected the deletion to be applied, got %v`, borderClient.calls)
+	}
+}
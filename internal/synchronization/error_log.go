@@ -0,0 +1,177 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// errorLogFlushInterval is how often the error log summarizes the repeats of the windows that have ended.
+const errorLogFlushInterval = time.Second
+
+// errorLogKey identifies the repeats of a sync error: the same class of error for the same upstream on the same host.
+type errorLogKey struct {
+	host     string
+	upstream string
+	class    string
+}
+
+// errorLogEntry counts the repeats of a sync error since its window started.
+type errorLogEntry struct {
+	windowStart time.Time
+	repeats     int
+	lastError   error
+}
+
+// errorLog logs the failed syncs, without flooding the log when a host keeps failing the same way, e.g. while it is down:
+// the first failure of each errorLogKey is logged as an error, its repeats are suppressed and counted for the
+// SynchronizerSettings::ErrorLogWindow, then summarized once the window ends. A success for the upstream on the host resets
+// the suppression of its errors. The permanent failures and the missing upstreams are left to their own reports.
+type errorLog struct {
+	window time.Duration
+
+	// now returns the current time, time.Now by default.
+	now func() time.Time
+
+	// lock guards entries, the failures are recorded by the Synchronizer workers.
+	lock sync.Mutex
+
+	entries map[errorLogKey]*errorLogEntry
+}
+
+// newErrorLog creates a new errorLog suppressing the repeats for the window; a zero window logs every failure.
+func newErrorLog(window time.Duration) *errorLog {
+	return &errorLog{
+		window:  window,
+		now:     time.Now,
+		entries: make(map[errorLogKey]*errorLogEntry),
+	}
+}
+
+// failed logs the error of the sync of the event, unless it repeats an error logged within the window.
+func (l *errorLog) failed(event *core.ServerUpdateEvent, err error) {
+	if application.IsPermanent(err) || errors.Is(err, application.ErrUpstreamNotFound) {
+		return
+	}
+
+	if l.window <= 0 {
+		logrus.WithFields(event.LogFields()).WithFields(errorFields(err)).WithError(err).Error(`Synchronizer::handleEvent: error occurred updating the nginx+ host`)
+		return
+	}
+
+	key := errorLogKey{host: event.NginxHost, upstream: event.UpstreamName, class: errorClass(err)}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if entry, found := l.entries[key]; found {
+		entry.repeats++
+		entry.lastError = err
+		return
+	}
+
+	l.entries[key] = &errorLogEntry{windowStart: l.now(), lastError: err}
+
+	logrus.WithFields(event.LogFields()).WithFields(errorFields(err)).WithError(err).
+		Errorf(`Synchronizer::handleEvent: error occurred updating the nginx+ host, the repeats of this error are suppressed for %v`, l.window)
+}
+
+// succeeded resets the suppression of the errors of the upstream of the event on its host, summarizing their repeats.
+func (l *errorLog) succeeded(event *core.ServerUpdateEvent) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for key, entry := range l.entries {
+		if key.host != event.NginxHost || key.upstream != event.UpstreamName {
+			continue
+		}
+
+		l.summarize(key, entry)
+		delete(l.entries, key)
+	}
+}
+
+// flush summarizes the repeats of the windows that have ended, and starts a new window for the errors that repeated, so a
+// host that keeps failing is reported once per window; the errors that did not repeat are logged again when they recur.
+func (l *errorLog) flush() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	for key, entry := range l.entries {
+		if now.Sub(entry.windowStart) < l.window {
+			continue
+		}
+
+		if entry.repeats == 0 {
+			delete(l.entries, key)
+			continue
+		}
+
+		l.summarize(key, entry)
+		entry.windowStart = now
+		entry.repeats = 0
+	}
+}
+
+// summarize logs the number of repeats of the error since its window started, if any. lock must be held.
+func (l *errorLog) summarize(key errorLogKey, entry *errorLogEntry) {
+	if entry.repeats == 0 {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{"host": key.host, "upstream": key.upstream, "class": key.class}).WithError(entry.lastError).
+		Errorf(`Synchronizer::errorLog: the error repeated %d times in the last %v`, entry.repeats, l.now().Sub(entry.windowStart).Round(time.Second))
+}
+
+// errorClass returns the class of a sync error, the classification of the error by the Border Clients, or its text when
+// it is not classified.
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, application.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, application.ErrTransient):
+		return "transient"
+	default:
+		return err.Error()
+	}
+}
+
+// errorFields returns the log fields of the failed NGINX Plus API call of a sync error, if any.
+func errorFields(err error) logrus.Fields {
+	var upstreamError *application.UpstreamError
+	if !errors.As(err, &upstreamError) {
+		return logrus.Fields{}
+	}
+
+	return logrus.Fields{"operation": upstreamError.Operation, "server": upstreamError.Server}
+}
+
+// describeError describes a sync error of a host for the Events and the status of the Service: the failed NGINX Plus API
+// call and the error the host returned, without the wrapping of the Border Clients, followed by the diagnosis of the host
+// if its probe failed. The errors without a failed call are described as they are.
+func describeError(err error) string {
+	var upstreamError *application.UpstreamError
+	if !errors.As(err, &upstreamError) {
+		return fmt.Sprint(err)
+	}
+
+	description := fmt.Sprintf(`%s: %v`, upstreamError.Call(), upstreamError.Err)
+
+	var diagnosed *diagnosedError
+	if errors.As(err, &diagnosed) {
+		description += diagnosed.suffix()
+	}
+
+	return description
+}
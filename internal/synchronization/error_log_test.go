@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+)
+
+func TestErrorLog_SuppressesTheRepeatsUntilTheWindowEnds(t *testing.T) {
+	now := time.Now()
+	log := newErrorLog(time.Minute)
+	log.now = func() time.Time { return now }
+
+	event := buildUpdateEvents(1)[0]
+	refused := fmt.Errorf(`%w: dial tcp: connection refused`, application.ErrTransient)
+
+	for i := 0; i < 5; i++ {
+		log.failed(event, refused)
+	}
+
+	// another class of error is logged on its own
+	log.failed(event, fmt.Errorf(`%w: %w`, application.ErrTimeout, application.ErrTransient))
+
+	transient := log.entries[errorLogKey{host: event.NginxHost, upstream: event.UpstreamName, class: "transient"}]
+	if len(log.entries) != 2 || transient == nil || transient.repeats != 4 {
+		t.Fatalf(`expected the repeats of the transient error to be counted, got %v`, log.entries)
+	}
+
+	now = now.Add(time.Minute)
+	log.flush()
+
+	if len(log.entries) != 1 || transient.repeats != 0 || !transient.windowStart.Equal(now) {
+		t.Fatalf(`expected a new window for the error that repeated, and the other one to be forgotten, got %v`, log.entries)
+	}
+
+	log.succeeded(event)
+
+	if len(log.entries) != 0 {
+		t.Fatalf(`expected the suppression to be reset once the upstream is synced, got %v`, log.entries)
+	}
+}
+
+func TestErrorLog_LeavesTheReportedErrorsAlone(t *testing.T) {
+	log := newErrorLog(time.Minute)
+	event := buildUpdateEvents(1)[0]
+
+	log.failed(event, fmt.Errorf(`%w: invalid weight`, application.ErrInvalidParameter))
+	log.failed(event, application.ErrUpstreamNotFound)
+
+	if len(log.entries) != 0 {
+		t.Fatalf(`expected the permanent failures and the missing upstreams to be left to their reports, got %v`, log.entries)
+	}
+
+	// without a window every failure is logged
+	log = newErrorLog(0)
+	log.failed(event, errors.New(`unexpected`))
+
+	if len(log.entries) != 0 {
+		t.Fatalf(`expected no suppression, got %v`, log.entries)
+	}
+}
+
+func TestDescribeError_NamesTheFailedCall(t *testing.T) {
+	failed := &application.UpstreamError{
+		Host:      "https://localhost:8080",
+		Upstream:  "nlk-upstream",
+		Operation: application.OperationDelete,
+		Server:    "10.0.0.1:30080",
+		Err:       fmt.Errorf(`%w: expected 200 response, got 400`, application.ErrInvalidParameter),
+	}
+	err := &diagnosedError{err: fmt.Errorf(`error occurred deleting the nginx+ upstream server: %w`, failed), diagnosis: "connection refused"}
+
+	expected := `delete server 10.0.0.1:30080 of upstream nlk-upstream: the NGINX Plus API rejected the call as invalid: expected 200 response, got 400 ` +
+		`(the connectivity probe of the host failed: connection refused)`
+	if description := describeError(err); description != expected {
+		t.Fatalf(`expected the error to be described as %q, got %q`, expected, description)
+	}
+
+	if fields := errorFields(err); fields["operation"] != application.OperationDelete || fields["server"] != "10.0.0.1:30080" {
+		t.Fatalf(`expected the operation and the server to be logged, got %v`, fields)
+	}
+
+	other := errors.New(`something went horribly horribly wrong`)
+	if description := describeError(other); description != other.Error() {
+		t.Fatalf(`expected the other errors to be described as they are, got %q`, description)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// healthCheckStatus is whether NGINX Plus health checks the servers of an upstream whose Service has the Local
+// externalTrafficPolicy, see core.HealthCheckHint.
+type healthCheckStatus struct {
+	healthCheckNodePort int32
+	checked             bool
+}
+
+// healthCheckStatuses records the healthCheckStatus of each upstream on each host, for the Snapshot.
+type healthCheckStatuses struct {
+
+	// lock guards statuses, the statuses are recorded by the Synchronizer workers.
+	lock sync.Mutex
+
+	statuses map[appliedKey]healthCheckStatus
+}
+
+// newHealthCheckStatuses creates a new, empty healthCheckStatuses.
+func newHealthCheckStatuses() *healthCheckStatuses {
+	return &healthCheckStatuses{
+		statuses: make(map[appliedKey]healthCheckStatus),
+	}
+}
+
+// record records the status of the upstream of the event on its host, and returns the previous status, if any.
+func (h *healthCheckStatuses) record(event *core.ServerUpdateEvent, checked bool) (healthCheckStatus, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := keyOf(event)
+	previous, found := h.statuses[key]
+	h.statuses[key] = healthCheckStatus{healthCheckNodePort: event.HealthCheck.HealthCheckNodePort, checked: checked}
+
+	return previous, found
+}
+
+// forget drops the status of the upstream of the event on its host, once its Service no longer has the Local policy.
+func (h *healthCheckStatuses) forget(event *core.ServerUpdateEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.statuses, keyOf(event))
+}
+
+// copy returns a copy of the statuses.
+func (h *healthCheckStatuses) copy() map[appliedKey]healthCheckStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	statuses := make(map[appliedKey]healthCheckStatus, len(h.statuses))
+	for key, status := range h.statuses {
+		statuses[key] = status
+	}
+
+	return statuses
+}
+
+// inspectHealthChecks asks the Border Client, after a successful update, whether NGINX Plus health checks the servers of the
+// upstream of a Service with the Local externalTrafficPolicy. When it does not, the nodes without a ready endpoint of the
+// Service keep receiving the traffic they drop, which shows as 503s whenever the endpoints move; a warning is logged and a
+// HealthCheckNotAligned Warning Event is recorded on the Service, once until the health checks are found again.
+func (s *Synchronizer) inspectHealthChecks(ctx context.Context, borderClient application.Interface, event *core.ServerUpdateEvent) {
+	if event.HealthCheck == nil {
+		s.healthChecks.forget(event)
+		return
+	}
+
+	reporter, ok := borderClient.(application.HealthCheckReporter)
+	if !ok {
+		return
+	}
+
+	checked, known, err := reporter.HealthChecked(ctx, event)
+	if err != nil {
+		logrus.WithFields(event.LogFields()).Warnf(`Synchronizer::inspectHealthChecks: unable to tell whether the servers are health checked: %v`, err)
+		return
+	}
+
+	if !known {
+		logrus.WithFields(event.LogFields()).Debug(`Synchronizer::inspectHealthChecks: the servers have not been checked yet`)
+		return
+	}
+
+	previous, found := s.healthChecks.record(event, checked)
+	if checked || (found && !previous.checked) {
+		return
+	}
+
+	logrus.WithFields(event.LogFields()).WithField("healthCheckNodePort", event.HealthCheck.HealthCheckNodePort).
+		Warnf(`Synchronizer::inspectHealthChecks: the Service has the Local externalTrafficPolicy, but NGINX Plus does not health check the servers of the upstream; `+
+			`the nodes without a ready endpoint drop the traffic, add a health_check probing /healthz on port %d`, event.HealthCheck.HealthCheckNodePort)
+
+	if s.settings.EventRecorder != nil && event.Service != nil {
+		s.settings.EventRecorder.Eventf(event.Service, corev1.EventTypeWarning, configuration.HealthCheckNotAlignedReason,
+			"the externalTrafficPolicy is Local, but NGINX Plus host %s does not health check the servers of upstream %s; add a health_check probing /healthz on the healthCheckNodePort %d",
+			configuration.RedactHost(event.NginxHost), event.UpstreamName, event.HealthCheck.HealthCheckNodePort)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// healthCheckingBorderClient is a fakeBorderClient that reports whether the servers are health checked.
+type healthCheckingBorderClient struct {
+	*fakeBorderClient
+	checked bool
+}
+
+func (h *healthCheckingBorderClient) HealthChecked(_ context.Context, _ *core.ServerUpdateEvent) (bool, bool, error) {
+	return h.checked, true, nil
+}
+
+func TestSynchronizer_ReportsTheUpstreamsOfTheLocalPolicyThatAreNotHealthChecked(t *testing.T) {
+	recorder := record.NewFakeRecorder(20)
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.EventRecorder = recorder
+	})
+
+	borderClient := &healthCheckingBorderClient{fakeBorderClient: newFakeBorderClient()}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	sync := func(servers ...string) {
+		events := buildUpdateEvents(1)
+		events[0].Service = buildService()
+		events[0].HealthCheck = &core.HealthCheckHint{ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyLocal, HealthCheckNodePort: 32000}
+		for _, server := range servers {
+			events[0].UpstreamServers = append(events[0].UpstreamServers, core.NewUpstreamServer(server))
+		}
+
+		synchronizer.AddEvents(events)
+		synchronizer.handleNextEvent()
+	}
+
+	sync("10.0.0.1:30080")
+	sync("10.0.0.1:30080", "10.0.0.2:30080")
+
+	if count := countEvents(recorder, configuration.HealthCheckNotAlignedReason); count != 1 {
+		t.Fatalf(`expected a single %s Event, got %d`, configuration.HealthCheckNotAlignedReason, count)
+	}
+
+	host := synchronizer.Snapshot().Upstreams[0].Hosts[0]
+	if host.HealthChecked == nil || *host.HealthChecked || host.HealthCheckNodePort != 32000 {
+		t.Fatalf(`expected the upstream not to be health checked, got %#v`, host)
+	}
+
+	borderClient.checked = true
+	sync("10.0.0.1:30080")
+
+	host = synchronizer.Snapshot().Upstreams[0].Hosts[0]
+	if host.HealthChecked == nil || !*host.HealthChecked {
+		t.Fatalf(`expected the upstream to be health checked, got %#v`, host)
+	}
+
+	if count := countEvents(recorder, configuration.HealthCheckNotAlignedReason); count != 0 {
+		t.Fatalf(`expected no %s Event once the servers are health checked, got %d`, configuration.HealthCheckNotAlignedReason, count)
+	}
+}
+
+// countEvents drains the recorder and counts the Events with the reason.
+func countEvents(recorder *record.FakeRecorder, reason string) int {
+	count := 0
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.Contains(event, " "+reason+" ") {
+				count++
+			}
+		default:
+			return count
+		}
+	}
+}
@@ -0,0 +1,312 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+// hostReachability is the outcome of the last connectivity probe of an NGINX Plus host.
+type hostReachability struct {
+
+	// reachable is false while the host is suspect, i.e. the last probe failed and no sync has succeeded since.
+	reachable bool
+
+	// version is the NGINX Plus version reported by the host, e.g. nginx-plus-r33 (1.27.2), empty if it is not known.
+	version string
+
+	// diagnosis describes why the host could not be reached, e.g. the DNS lookup failed, empty while the host is reachable.
+	diagnosis string
+
+	// probedAt is when the host was last probed.
+	probedAt time.Time
+}
+
+// hostReachabilities records the reachability of each NGINX Plus host, probed when the hosts are set or changed, so a
+// misconfigured host is reported at once rather than when the first sync fails.
+type hostReachabilities struct {
+
+	// lock guards reachabilities, the hosts are probed and synced concurrently.
+	lock sync.Mutex
+
+	reachabilities map[string]hostReachability
+}
+
+// newHostReachabilities creates a new hostReachabilities, no host has been probed.
+func newHostReachabilities() *hostReachabilities {
+	return &hostReachabilities{
+		reachabilities: make(map[string]hostReachability),
+	}
+}
+
+// record records the outcome of a probe of the host.
+func (h *hostReachabilities) record(host string, reachability hostReachability) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.reachabilities[host] = reachability
+}
+
+// recordSuccess records that a sync of the host succeeded, so the host is no longer suspect; returns true if it was.
+func (h *hostReachabilities) recordSuccess(host string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	reachability, found := h.reachabilities[host]
+	if !found || reachability.reachable {
+		return false
+	}
+
+	reachability.reachable = true
+	reachability.diagnosis = ""
+	h.reachabilities[host] = reachability
+
+	return true
+}
+
+// diagnosis returns why the host could not be reached when it was last probed, or false unless the host is suspect.
+func (h *hostReachabilities) diagnosis(host string) (string, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	reachability, found := h.reachabilities[host]
+
+	return reachability.diagnosis, found && !reachability.reachable
+}
+
+// forget drops the reachability of the hosts that are no longer configured.
+func (h *hostReachabilities) forget(hosts []string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	configured := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		configured[host] = true
+	}
+
+	for host := range h.reachabilities {
+		if !configured[host] {
+			delete(h.reachabilities, host)
+		}
+	}
+}
+
+// copy returns a copy of the reachabilities.
+func (h *hostReachabilities) copy() map[string]hostReachability {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	reachabilities := make(map[string]hostReachability, len(h.reachabilities))
+	for host, reachability := range h.reachabilities {
+		reachabilities[host] = reachability
+	}
+
+	return reachabilities
+}
+
+// HostDiagnoses returns why each suspect NGINX Plus host could not be reached, by the API base URL of the host, e.g. to
+// describe the hosts failing the readiness check.
+func (s *Synchronizer) HostDiagnoses() map[string]string {
+	diagnoses := make(map[string]string)
+	for host, reachability := range s.hostReachabilities.copy() {
+		if reachability.reachable {
+			continue
+		}
+
+		if nginxPlusHost, err := s.nginxPlusHost(host); err == nil {
+			diagnoses[nginxPlusHost.Endpoint] = reachability.diagnosis
+		}
+	}
+
+	return diagnoses
+}
+
+// probeConnectivity probes each of the hosts concurrently, logging the NGINX Plus version of the hosts that respond and
+// the diagnosis of the hosts that cannot be reached. A host that cannot be reached is kept, but is suspect until a sync
+// of the host succeeds, so the errors of its syncs include the diagnosis of the probe.
+func (s *Synchronizer) probeConnectivity(hosts []string) {
+	var group sync.WaitGroup
+
+	for _, host := range hosts {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			reachability := s.reachabilityProber(host)
+			reachability.probedAt = time.Now()
+			s.hostReachabilities.record(host, reachability)
+			instrumentation.ObserveHostReachable(host, reachability.reachable)
+
+			if reachability.reachable {
+				logrus.WithField("host", host).WithField("version", reachability.version).Info(`Synchronizer::probeConnectivity: the host is reachable`)
+			} else {
+				logrus.WithField("host", host).Errorf(`Synchronizer::probeConnectivity: the host cannot be reached, %s`, reachability.diagnosis)
+			}
+		}()
+	}
+
+	group.Wait()
+}
+
+// recordHostReachable marks the host as reachable once a sync succeeds, after a probe of the host failed.
+func (s *Synchronizer) recordHostReachable(host string) {
+	if s.hostReachabilities.recordSuccess(host) {
+		instrumentation.ObserveHostReachable(host, true)
+		logrus.WithField("host", host).Info(`Synchronizer::recordHostReachable: the host is reachable again`)
+	}
+}
+
+// withDiagnosis adds the diagnosis of the last probe of a suspect host to the error of a sync of the host.
+func (s *Synchronizer) withDiagnosis(host string, err error) error {
+	if diagnosis, suspect := s.hostReachabilities.diagnosis(host); suspect {
+		return &diagnosedError{err: err, diagnosis: diagnosis}
+	}
+
+	return err
+}
+
+// diagnosedError is the error of a sync of a suspect host, with the diagnosis of the last probe of the host.
+type diagnosedError struct {
+	err       error
+	diagnosis string
+}
+
+func (e *diagnosedError) Error() string {
+	return fmt.Sprintf(`%v%s`, e.err, e.suffix())
+}
+
+func (e *diagnosedError) Unwrap() error {
+	return e.err
+}
+
+// suffix returns the diagnosis as appended to the error.
+func (e *diagnosedError) suffix() string {
+	return fmt.Sprintf(` (the connectivity probe of the host failed: %s)`, e.diagnosis)
+}
+
+// probeReachability calls the root of the NGINX Plus API of the host, then the nginx endpoint of its API version to
+// determine the NGINX Plus version. The host is reachable if the root responds, the version is left empty otherwise.
+// The hosts of the other border types are pinged, their version is not known.
+func (s *Synchronizer) probeReachability(host string) hostReachability {
+	if !s.isNginxPlus() {
+		if err := s.probeHost(host); err != nil {
+			return hostReachability{diagnosis: diagnose(err)}
+		}
+
+		return hostReachability{reachable: true}
+	}
+
+	nginxPlusHost, err := s.nginxPlusHost(host)
+	if err != nil {
+		return hostReachability{diagnosis: fmt.Sprintf(`the host is invalid: %v`, err)}
+	}
+
+	if response, err := s.getApi(nginxPlusHost.Endpoint); err != nil {
+		return hostReachability{diagnosis: diagnose(err)}
+	} else if response.statusCode == http.StatusNotFound {
+		return hostReachability{diagnosis: fmt.Sprintf(`the API path %s was not found (404), check the path of the nginx-hosts entry`, nginxPlusHost.Endpoint)}
+	} else if response.statusCode < http.StatusOK || response.statusCode >= http.StatusMultipleChoices {
+		return hostReachability{diagnosis: fmt.Sprintf(`the API responded with the status %s`, response.status)}
+	}
+
+	apiVersion := nginxPlusHost.ApiVersion
+	if apiVersion == 0 {
+		apiVersion = nginxClient.APIVersion
+	}
+
+	reachability := hostReachability{reachable: true}
+
+	response, err := s.getApi(nginxPlusHost.Endpoint + "/" + strconv.Itoa(apiVersion) + "/nginx")
+	if err != nil || response.statusCode != http.StatusOK {
+		return reachability
+	}
+
+	var info nginxClient.NginxInfo
+	if json.Unmarshal(response.body, &info) == nil && info.Version != "" {
+		reachability.version = fmt.Sprintf(`%s (%s)`, info.Build, info.Version)
+	}
+
+	return reachability
+}
+
+// apiResponse is the status and body of a call to the NGINX Plus API.
+type apiResponse struct {
+	statusCode int
+	status     string
+	body       []byte
+}
+
+// getApi calls the NGINX Plus API with the shared HTTP client, so the TLS configuration and credentials of the hosts apply.
+func (s *Synchronizer) getApi(url string) (*apiResponse, error) {
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	var body json.RawMessage
+	if err = json.NewDecoder(response.Body).Decode(&body); err != nil {
+		body = nil
+	}
+
+	return &apiResponse{statusCode: response.StatusCode, status: response.Status, body: body}, nil
+}
+
+// diagnose describes the cause of an error calling the NGINX Plus API, e.g. a DNS or TLS failure.
+func diagnose(err error) string {
+	var dnsError *net.DNSError
+	var verificationError *tls.CertificateVerificationError
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var recordHeaderError tls.RecordHeaderError
+	var opError *net.OpError
+
+	switch {
+	case errors.As(err, &dnsError):
+		return fmt.Sprintf(`the DNS lookup of %s failed: %v`, dnsError.Name, err)
+	case errors.As(err, &verificationError), errors.As(err, &unknownAuthorityError), errors.As(err, &hostnameError):
+		return fmt.Sprintf(`the TLS certificate of the host could not be verified: %v`, err)
+	case errors.As(err, &recordHeaderError), strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"):
+		return fmt.Sprintf(`the TLS handshake failed, the host may not serve TLS on this port: %v`, err)
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return fmt.Sprintf(`the host did not respond in time: %v`, err)
+	case errors.As(err, &opError) && opError.Op == "dial":
+		return fmt.Sprintf(`the connection to the host failed, check the address and port: %v`, err)
+	default:
+		return err.Error()
+	}
+}
+
+// isTimeout determines whether the error is a network timeout.
+func isTimeout(err error) bool {
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
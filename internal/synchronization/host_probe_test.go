@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSynchronizer_ProbeReachabilityReportsTheVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write([]byte(`[8,9]`))
+	})
+	mux.HandleFunc("/api/8/nginx", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write([]byte(`{"version":"1.27.2","build":"nginx-plus-r33"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	reachability := synchronizer.probeReachability(server.URL + "/api;version=8")
+	if !reachability.reachable || reachability.version != "nginx-plus-r33 (1.27.2)" {
+		t.Fatalf(`expected the host to be reachable with its version, got %+v`, reachability)
+	}
+}
+
+func TestSynchronizer_ProbeReachabilityDiagnosesTheFailures(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	closedAddress := listener.Addr().String()
+	_ = listener.Close()
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	for host, expected := range map[string]string{
+		server.URL + "/nginx-api":                    "was not found (404)",
+		"http://" + closedAddress + "/api":           "the connection to the host failed",
+		"https://" + server.Listener.Addr().String(): "TLS handshake failed",
+	} {
+		reachability := synchronizer.probeReachability(host)
+		if reachability.reachable || !strings.Contains(reachability.diagnosis, expected) {
+			t.Errorf(`expected the diagnosis of %s to contain %q, got %+v`, host, expected, reachability)
+		}
+	}
+}
+
+func TestSynchronizer_SyncErrorsOfASuspectHostIncludeTheDiagnosis(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+	synchronizer.reachabilityProber = func(string) hostReachability {
+		return hostReachability{diagnosis: "the DNS lookup of nginx failed"}
+	}
+
+	borderClient := newFakeBorderClient("https://localhost:8080")
+	synchronizer.borderClientFactory = borderClient.forEvent
+
+	synchronizer.probeConnectivity([]string{"https://localhost:8080"})
+
+	if diagnoses := synchronizer.HostDiagnoses(); diagnoses["https://localhost:8080"] != "the DNS lookup of nginx failed" {
+		t.Fatalf(`expected the diagnosis of the host, got %v`, diagnoses)
+	}
+
+	err := synchronizer.handleEvent(buildUpdateEvents(1)[0])
+	if err == nil || !strings.Contains(err.Error(), "the connectivity probe of the host failed: the DNS lookup of nginx failed") {
+		t.Fatalf(`expected the error to include the diagnosis, got %v`, err)
+	}
+
+	borderClient.failedHosts = map[string]bool{}
+	if err = synchronizer.handleEvent(buildUpdateEvents(1)[0]); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if diagnoses := synchronizer.HostDiagnoses(); len(diagnoses) != 0 {
+		t.Fatalf(`expected the host to be reachable once a sync succeeded, got %v`, diagnoses)
+	}
+
+	snapshot := synchronizer.Snapshot()
+	if snapshot.Hosts[0].Reachable == nil || !*snapshot.Hosts[0].Reachable {
+		t.Fatalf(`expected the snapshot to report the host as reachable, got %+v`, snapshot.Hosts[0])
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errHostStaggered is the failure of a host whose updates wait for the stagger delay of the host, see hostStagger.
+// The event is requeued once the delay has elapsed, without counting against the RetryCount.
+var errHostStaggered = errors.New(`the updates of the host are staggered`)
+
+// hostStagger delays the updates of each NGINX Plus host by a random delay after NLK starts, or the host is added, so the
+// full syncs of the hosts are spread rather than hitting every host at the same time. The Deleted events are not delayed.
+type hostStagger struct {
+
+	// lock guards readyAt, the delays are checked by the Synchronizer workers.
+	lock sync.Mutex
+
+	// readyAt is when the updates of each host may be sent, kept once they are so the convergence of the changes held
+	// back by the stagger is measured from then, see readyAtOf; the hosts that are removed are dropped.
+	readyAt map[string]time.Time
+}
+
+// newHostStagger creates a new hostStagger, the updates of no host are delayed.
+func newHostStagger() *hostStagger {
+	return &hostStagger{
+		readyAt: make(map[string]time.Time),
+	}
+}
+
+// stagger delays the updates of each of the hosts by a random delay up to spread.
+func (h *hostStagger) stagger(hosts []string, spread time.Duration) {
+	if spread <= 0 {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	now := time.Now()
+	for _, host := range hosts {
+		h.readyAt[host] = now.Add(randomDuration(spread))
+	}
+}
+
+// wait returns how long the updates of the host are still delayed, zero once they may be sent.
+func (h *hostStagger) wait(host string) time.Duration {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	readyAt, found := h.readyAt[host]
+	if !found {
+		return 0
+	}
+
+	return max(time.Until(readyAt), 0)
+}
+
+// readyAtOf returns when the updates of the host may be sent after its last stagger, zero when it was never staggered.
+func (h *hostStagger) readyAtOf(host string) time.Time {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.readyAt[host]
+}
+
+// earliest returns how long until the updates of the first of the hosts may be sent.
+func (h *hostStagger) earliest(hosts []string) time.Duration {
+	var earliest time.Duration
+	for i, host := range hosts {
+		if wait := h.wait(host); i == 0 || wait < earliest {
+			earliest = wait
+		}
+	}
+
+	return earliest
+}
+
+// forget drops the delays of the hosts that are no longer configured.
+func (h *hostStagger) forget(hosts []string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for host := range h.readyAt {
+		if !slices.Contains(hosts, host) {
+			delete(h.readyAt, host)
+		}
+	}
+}
+
+// onlyStaggered determines whether every failure is a host whose updates are staggered.
+func onlyStaggered(failures map[string]error) bool {
+	if len(failures) == 0 {
+		return false
+	}
+
+	for _, err := range failures {
+		if !errors.Is(err, errHostStaggered) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// staggeredDelay is the delay after which a host is visited by a reconciliation.
+type staggeredDelay struct {
+	host  string
+	delay time.Duration
+}
+
+// staggerDelays assigns a random delay up to spread to each of the hosts, sorted by delay.
+func staggerDelays(hosts []string, spread time.Duration) []staggeredDelay {
+	delays := make([]staggeredDelay, 0, len(hosts))
+	for _, host := range hosts {
+		delays = append(delays, staggeredDelay{host: host, delay: randomDuration(spread)})
+	}
+
+	sort.Slice(delays, func(i, j int) bool { return delays[i].delay < delays[j].delay })
+
+	return delays
+}
+
+// randomDuration returns a random duration between zero and spread, zero when spread is not positive.
+func randomDuration(spread time.Duration) time.Duration {
+	if spread <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(spread)))
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestSynchronizer_DelaysTheUpdatesOfTheStaggeredHosts(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+	})
+	synchronizer.hostStagger.readyAt["https://localhost:8081"] = time.Now().Add(time.Hour)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 1 || borderClient.calls[0] != "https://localhost:8080" {
+		t.Fatalf(`expected only the host that is not staggered to be updated, got %v`, borderClient.calls)
+	}
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the event to be requeued for the staggered host, got %d events`, rateLimiter.Len())
+	}
+
+	item, _ := rateLimiter.Get()
+	event := item.(*syncEvent)
+	if event.attempts != 0 || len(event.pendingHosts) != 1 || event.pendingHosts[0] != "https://localhost:8081" {
+		t.Fatalf(`expected the staggered host to be pending without counting an attempt, got %d attempts for %v`, event.attempts, event.pendingHosts)
+	}
+
+	// the host is ready once its delay has elapsed
+	synchronizer.hostStagger.readyAt["https://localhost:8081"] = time.Now()
+	rateLimiter.AddAfter(event, 0)
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 2 || rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the staggered host to be updated once ready, got %v`, borderClient.calls)
+	}
+}
+
+func TestSynchronizer_DoesNotDelayTheDeletions(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+	})
+	synchronizer.hostStagger.stagger([]string{"https://localhost:8080", "https://localhost:8081"}, time.Hour)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Deleted, "10.0.0.1:30080")})
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 2 || rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the deletion to be applied to both hosts, got %v`, borderClient.calls)
+	}
+}
+
+func TestSynchronizer_StaggersTheAddedHosts(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+	})
+	synchronizer.settings.Synchronizer.HostStagger = time.Hour
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080", "https://localhost:8082"})
+
+	if synchronizer.hostStagger.wait("https://localhost:8080") != 0 {
+		t.Fatal(`expected the host that was kept not to be staggered`)
+	}
+
+	if wait := synchronizer.hostStagger.wait("https://localhost:8082"); wait <= 0 || wait > time.Hour {
+		t.Fatalf(`expected the added host to be staggered within the spread, got %v`, wait)
+	}
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080"})
+
+	if synchronizer.hostStagger.wait("https://localhost:8082") != 0 {
+		t.Fatal(`expected the delay of the removed host to be forgotten`)
+	}
+}
+
+func TestStaggerDelays(t *testing.T) {
+	delays := staggerDelays([]string{"a", "b", "c", "d"}, time.Second)
+
+	for i, staggered := range delays {
+		if staggered.delay < 0 || staggered.delay >= time.Second {
+			t.Fatalf(`expected the delays to be within the spread, got %v`, staggered.delay)
+		}
+
+		if i > 0 && staggered.delay < delays[i-1].delay {
+			t.Fatalf(`expected the hosts to be sorted by delay, got %v`, delays)
+		}
+	}
+
+	for _, staggered := range staggerDelays([]string{"a", "b"}, 0) {
+		if staggered.delay != 0 {
+			t.Fatalf(`expected no delay without a spread, got %v`, staggered.delay)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// loadBalancerStatusWriter writes the load balancer ingress IPs, see SynchronizerSettings::LoadBalancerIngressIps, to the
+// status.loadBalancer.ingress of each watched Service of type LoadBalancer while its upstreams are synced, and removes them
+// once the sync of one of its upstreams fails, so tooling like external-dns sees where the Service is served.
+// The status is compared with the Service of each event, so a status overwritten by something else is restored with the
+// next event for the Service; the Watcher does not ignore the changes of the status.loadBalancer. The events queued before
+// a write carry the previous version of the Service, the write is not repeated for them.
+type loadBalancerStatusWriter struct {
+	client   kubernetes.Interface
+	settings *configuration.Settings
+
+	// lock guards the upstreams, written, serviceLocks, and forbidden.
+	lock sync.Mutex
+
+	// upstreams holds whether each upstream of each Service is synced, keyed by namespace/name, then upstream name.
+	upstreams map[string]map[string]bool
+
+	// written holds the last write to each Service, keyed by namespace/name.
+	written map[string]loadBalancerStatusWrite
+
+	// serviceLocks orders the writes to each Service, keyed by namespace/name.
+	serviceLocks map[string]*sync.Mutex
+
+	// forbidden is set once a write has been forbidden, so the missing permission is reported once until a write succeeds.
+	forbidden bool
+}
+
+// newLoadBalancerStatusWriter creates a new loadBalancerStatusWriter.
+func newLoadBalancerStatusWriter(client kubernetes.Interface, settings *configuration.Settings) *loadBalancerStatusWriter {
+	return &loadBalancerStatusWriter{
+		client:       client,
+		settings:     settings,
+		upstreams:    make(map[string]map[string]bool),
+		written:      make(map[string]loadBalancerStatusWrite),
+		serviceLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// loadBalancerStatusWrite is a write of the ingress IPs, along with the resource version of the Service it was compared to.
+type loadBalancerStatusWrite struct {
+	ips             []string
+	resourceVersion string
+}
+
+// synced records that the upstream of the Service has been synced, and writes the ingress IPs once every upstream is.
+func (w *loadBalancerStatusWriter) synced(service *corev1.Service, upstream string) {
+	w.record(service, upstream, true)
+}
+
+// failed records that the upstream of the Service did not converge, and removes the ingress IPs.
+func (w *loadBalancerStatusWriter) failed(service *corev1.Service, upstream string) {
+	w.record(service, upstream, false)
+}
+
+// removed forgets the upstream of the Service, once deleted; the status is left to the next event of the Service.
+func (w *loadBalancerStatusWriter) removed(service *corev1.Service, upstream string) {
+	if w == nil || service == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	key := serviceKey(service.Namespace, service.Name)
+	delete(w.upstreams[key], upstream)
+	if len(w.upstreams[key]) == 0 {
+		delete(w.upstreams, key)
+		delete(w.written, key)
+		delete(w.serviceLocks, key)
+	}
+}
+
+// record records whether the upstream of the Service is synced, and writes the status of the Service if it differs.
+// The write is made under the lock of the Service alone, so the sync workers of the other Services are not held up by
+// the call to the Kubernetes API; the IPs are decided again once the lock is taken, so the last write reflects the
+// latest state of the upstreams.
+func (w *loadBalancerStatusWriter) record(service *corev1.Service, upstream string, synced bool) {
+	if w == nil || service == nil || service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return
+	}
+
+	configured := w.ingressIps(service)
+	if len(configured) == 0 {
+		return
+	}
+
+	key := serviceKey(service.Namespace, service.Name)
+
+	w.lock.Lock()
+	if w.upstreams[key] == nil {
+		w.upstreams[key] = make(map[string]bool)
+	}
+	w.upstreams[key][upstream] = synced
+
+	_, changed := w.pendingIps(service, key, configured)
+	serviceLock := w.serviceLockOf(key)
+	w.lock.Unlock()
+
+	if !changed {
+		return
+	}
+
+	serviceLock.Lock()
+	defer serviceLock.Unlock()
+
+	w.lock.Lock()
+	ips, changed := w.pendingIps(service, key, configured)
+	w.lock.Unlock()
+
+	if changed {
+		w.write(service, ips)
+	}
+}
+
+// pendingIps returns the ingress IPs of the Service, none while one of its upstreams is failed, and whether they differ
+// from its status and from the last write. lock must be held.
+func (w *loadBalancerStatusWriter) pendingIps(service *corev1.Service, key string, configured []string) ([]string, bool) {
+	upstreams, found := w.upstreams[key]
+	if !found {
+		return nil, false
+	}
+
+	ips := configured
+	for _, upstreamSynced := range upstreams {
+		if !upstreamSynced {
+			ips = nil
+			break
+		}
+	}
+
+	if slices.Equal(ingressIpsOf(service), ips) {
+		return ips, false
+	}
+
+	if written, found := w.written[key]; found && written.resourceVersion == service.ResourceVersion && slices.Equal(written.ips, ips) {
+		return ips, false
+	}
+
+	return ips, true
+}
+
+// serviceLockOf returns the lock ordering the writes to the Service, creating it if needed. lock must be held.
+func (w *loadBalancerStatusWriter) serviceLockOf(key string) *sync.Mutex {
+	serviceLock, found := w.serviceLocks[key]
+	if !found {
+		serviceLock = &sync.Mutex{}
+		w.serviceLocks[key] = serviceLock
+	}
+
+	return serviceLock
+}
+
+// ingressIps returns the ingress IPs of the Service: the LoadBalancerIngressIpsAnnotation of the Service, or the
+// LoadBalancerIngressIps. An annotation that lists an invalid IP is ignored.
+func (w *loadBalancerStatusWriter) ingressIps(service *corev1.Service) []string {
+	value, found := service.Annotations[configuration.LoadBalancerIngressIpsAnnotation]
+	if !found {
+		return w.settings.Synchronizer.LoadBalancerIngressIps
+	}
+
+	var ips []string
+	for _, ip := range strings.Split(value, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+
+	if err := configuration.ValidateLoadBalancerIngressIps(ips); err != nil {
+		logrus.WithFields(logrus.Fields{"namespace": service.Namespace, "service": service.Name}).WithError(err).
+			Warnf(`loadBalancerStatusWriter::ingressIps: the %s annotation is ignored`, configuration.LoadBalancerIngressIpsAnnotation)
+
+		if w.settings.EventRecorder != nil {
+			w.settings.EventRecorder.Eventf(service, corev1.EventTypeWarning, configuration.InvalidAnnotationReason,
+				"annotation %s has an invalid value %q (%v), using the default", configuration.LoadBalancerIngressIpsAnnotation, value, err)
+		}
+
+		return w.settings.Synchronizer.LoadBalancerIngressIps
+	}
+
+	return ips
+}
+
+// write patches the status.loadBalancer.ingress of the Service with the IPs, or removes it when there are none. The lock
+// of the Service must be held, see serviceLockOf.
+func (w *loadBalancerStatusWriter) write(service *corev1.Service, ips []string) {
+	fields := logrus.Fields{"namespace": service.Namespace, "service": service.Name, "ips": ips}
+
+	if w.settings.IsDryRun() {
+		logrus.WithFields(fields).Info(`loadBalancerStatusWriter::write: dry-run, the load balancer status is not written`)
+		return
+	}
+
+	patch, err := loadBalancerStatusPatch(ips)
+	if err != nil {
+		logrus.WithFields(fields).WithError(err).Warn(`loadBalancerStatusWriter::write: error occurred building the load balancer status`)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.settings.Context, w.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	_, err = w.client.CoreV1().Services(service.Namespace).Patch(ctx, service.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	switch {
+	case err == nil:
+		w.forbidden = false
+		w.written[serviceKey(service.Namespace, service.Name)] = loadBalancerStatusWrite{ips: ips, resourceVersion: service.ResourceVersion}
+		logrus.WithFields(fields).Info(`loadBalancerStatusWriter::write: wrote the load balancer status`)
+
+	case apierrors.IsNotFound(err):
+		delete(w.upstreams, serviceKey(service.Namespace, service.Name))
+		delete(w.written, serviceKey(service.Namespace, service.Name))
+
+	case apierrors.IsForbidden(err):
+		if w.forbidden {
+			logrus.WithFields(fields).WithError(err).Debug(`loadBalancerStatusWriter::write: the load balancer status is still forbidden`)
+			return
+		}
+
+		w.forbidden = true
+		logrus.WithFields(fields).WithError(err).
+			Warnf(`loadBalancerStatusWriter::write: NLK is not allowed to patch the status of Services, grant the patch verb on services/status or unset %s`, configuration.LoadBalancerIngressIpsEnv)
+
+	default:
+		logrus.WithFields(fields).WithError(err).Warn(`loadBalancerStatusWriter::write: error occurred writing the load balancer status`)
+	}
+}
+
+// loadBalancerStatusPatch returns the merge patch setting the status.loadBalancer.ingress to the IPs, or removing it.
+func loadBalancerStatusPatch(ips []string) ([]byte, error) {
+	var ingress []corev1.LoadBalancerIngress
+	for _, ip := range ips {
+		ingress = append(ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"loadBalancer": map[string]interface{}{"ingress": ingress}},
+	})
+}
+
+// ingressIpsOf returns the IPs of the status.loadBalancer.ingress of the Service.
+func ingressIpsOf(service *corev1.Service) []string {
+	var ips []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		ips = append(ips, ingress.IP)
+	}
+
+	return ips
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLoadBalancerStatusWriter_WritesAndClearsTheIngressIps(t *testing.T) {
+	service := buildLoadBalancerService(nil)
+	k8sClient := fake.NewSimpleClientset(service)
+	writer := buildLoadBalancerStatusWriter(t, k8sClient, "192.0.2.10", "192.0.2.11")
+
+	writer.synced(service, "nginx-ingress-http")
+	writer.synced(service, "nginx-ingress-https")
+
+	service = getLoadBalancerService(t, k8sClient)
+	if ips := ingressIpsOf(service); !slices.Equal(ips, []string{"192.0.2.10", "192.0.2.11"}) {
+		t.Fatalf(`expected the ingress IPs once the upstreams are synced, got %v`, ips)
+	}
+
+	// the status is already written
+	writer.synced(service, "nginx-ingress-http")
+	if patches := countPatches(k8sClient); patches != 1 {
+		t.Fatalf(`expected a single patch, got %d`, patches)
+	}
+
+	writer.failed(service, "nginx-ingress-https")
+
+	service = getLoadBalancerService(t, k8sClient)
+	if ips := ingressIpsOf(service); len(ips) != 0 {
+		t.Fatalf(`expected the ingress IPs to be cleared once an upstream fails, got %v`, ips)
+	}
+
+	// the failed upstream keeps the IPs cleared until it is synced again
+	writer.synced(service, "nginx-ingress-http")
+	if patches := countPatches(k8sClient); patches != 2 {
+		t.Fatalf(`expected no patch while an upstream is failed, got %d`, patches)
+	}
+
+	writer.synced(service, "nginx-ingress-https")
+	if ips := ingressIpsOf(getLoadBalancerService(t, k8sClient)); len(ips) != 2 {
+		t.Fatalf(`expected the ingress IPs to be written again, got %v`, ips)
+	}
+}
+
+func TestLoadBalancerStatusWriter_TheAnnotationOverridesTheSetting(t *testing.T) {
+	service := buildLoadBalancerService(map[string]string{configuration.LoadBalancerIngressIpsAnnotation: "198.51.100.1, 2001:db8::1"})
+	k8sClient := fake.NewSimpleClientset(service)
+	writer := buildLoadBalancerStatusWriter(t, k8sClient, "192.0.2.10")
+
+	writer.synced(service, "nginx-ingress-http")
+
+	if ips := ingressIpsOf(getLoadBalancerService(t, k8sClient)); !slices.Equal(ips, []string{"198.51.100.1", "2001:db8::1"}) {
+		t.Fatalf(`expected the ingress IPs of the annotation, got %v`, ips)
+	}
+
+	// an invalid annotation falls back to the setting
+	invalid := buildLoadBalancerService(map[string]string{configuration.LoadBalancerIngressIpsAnnotation: "nginx.example.com"})
+	if ips := writer.ingressIps(invalid); !slices.Equal(ips, []string{"192.0.2.10"}) {
+		t.Fatalf(`expected the ingress IPs of the setting, got %v`, ips)
+	}
+}
+
+func TestLoadBalancerStatusWriter_LeavesTheOtherServicesAlone(t *testing.T) {
+	clusterIp := buildLoadBalancerService(nil)
+	clusterIp.Spec.Type = corev1.ServiceTypeClusterIP
+	k8sClient := fake.NewSimpleClientset(clusterIp)
+
+	buildLoadBalancerStatusWriter(t, k8sClient, "192.0.2.10").synced(clusterIp, "nginx-ingress-http")
+
+	// no IP is set
+	buildLoadBalancerStatusWriter(t, k8sClient).synced(buildLoadBalancerService(nil), "nginx-ingress-http")
+
+	if patches := countPatches(k8sClient); patches != 0 {
+		t.Fatalf(`expected no patch, got %d`, patches)
+	}
+}
+
+func TestLoadBalancerStatusWriter_WritesWithoutHoldingUpTheOtherServices(t *testing.T) {
+	service := buildLoadBalancerService(nil)
+	k8sClient := fake.NewSimpleClientset(service)
+	writer := buildLoadBalancerStatusWriter(t, k8sClient, "192.0.2.10")
+
+	// the sync workers of the other Services record their upstreams while the status is patched
+	unlocked := false
+	k8sClient.PrependReactor("patch", "services", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		if unlocked = writer.lock.TryLock(); unlocked {
+			writer.lock.Unlock()
+		}
+		return false, nil, nil
+	})
+
+	writer.synced(service, "nginx-ingress-http")
+
+	if countPatches(k8sClient) != 1 || !unlocked {
+		t.Fatalf(`expected the status to be patched without holding the lock of the writer`)
+	}
+}
+
+func TestNewSynchronizer_WritesTheLoadBalancerStatusWithoutTheStatusAnnotations(t *testing.T) {
+	settings, err := configuration.NewSettings(context.Background(), fake.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Synchronizer.StatusAnnotationInterval = 0
+
+	synchronizer, err := NewSynchronizer(settings, &mocks.MockRateLimiter{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if synchronizer.serviceStatus != nil || synchronizer.loadBalancerStatus == nil {
+		t.Fatalf(`expected the load balancer status to be written although the status annotations are disabled`)
+	}
+}
+
+func buildLoadBalancerStatusWriter(t *testing.T, k8sClient *fake.Clientset, ips ...string) *loadBalancerStatusWriter {
+	settings, err := configuration.NewSettings(context.Background(), k8sClient)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.Synchronizer.LoadBalancerIngressIps = ips
+
+	return newLoadBalancerStatusWriter(k8sClient, settings)
+}
+
+func buildLoadBalancerService(annotations map[string]string) *corev1.Service {
+	service := buildAnnotatedService(annotations)
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+
+	return service
+}
+
+func getLoadBalancerService(t *testing.T, k8sClient *fake.Clientset) *corev1.Service {
+	service, err := k8sClient.CoreV1().Services("nginx-ingress").Get(context.Background(), "nginx-ingress", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	return service
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// persistedOwnership is the record of the servers NLK has applied to an upstream of a host, persisted in the state ConfigMap.
+type persistedOwnership struct {
+	Host       string   `json:"host"`
+	ClientType string   `json:"clientType"`
+	Upstream   string   `json:"upstream"`
+	Servers    []string `json:"servers"`
+}
+
+// serverOwnership records the servers NLK has applied to each upstream of each host, so that it only deletes its own
+// servers while an OwnershipTag is set, see SynchronizerSettings::OwnershipTag. The record is the only mark of the servers,
+// the route of the HTTP servers is left to their sticky routing.
+type serverOwnership struct {
+
+	// lock guards owned, the servers are applied by the Synchronizer workers.
+	lock sync.Mutex
+
+	// owned holds the addresses of the servers applied to each upstream.
+	owned map[appliedKey]map[string]bool
+}
+
+// newServerOwnership creates a new, empty serverOwnership.
+func newServerOwnership() *serverOwnership {
+	return &serverOwnership{
+		owned: make(map[appliedKey]map[string]bool),
+	}
+}
+
+// withOwnership returns a copy of the event with the ownership of its upstream, or the event itself when NLK manages every
+// server of the upstreams.
+func (s *Synchronizer) withOwnership(event *core.ServerUpdateEvent) *core.ServerUpdateEvent {
+	if s.settings.Synchronizer.OwnershipTag == "" || !s.isNginxPlus() {
+		return event
+	}
+
+	owned := *event
+	owned.Ownership = &core.ServerOwnership{
+		Owned: s.serverOwnership.ownedBy(keyOf(event)),
+		Force: s.settings.Synchronizer.ForcePrune,
+	}
+
+	return &owned
+}
+
+// recordOwnership records the servers of an applied event as owned by NLK: the servers of an update replace those of the
+// upstream, as the other owned servers have been deleted, and the servers of a deletion are no longer owned.
+func (s *Synchronizer) recordOwnership(event *core.ServerUpdateEvent) {
+	if s.settings.Synchronizer.OwnershipTag == "" || !s.isNginxPlus() {
+		return
+	}
+
+	if event.Type == core.Deleted {
+		s.serverOwnership.deleted(event)
+	} else {
+		s.serverOwnership.applied(event)
+	}
+}
+
+// ownedBy returns a copy of the addresses of the servers owned in the upstream.
+func (o *serverOwnership) ownedBy(key appliedKey) map[string]bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	return maps.Clone(o.owned[key])
+}
+
+// ownedOn returns a copy of the addresses of the servers owned in each upstream of the host.
+func (o *serverOwnership) ownedOn(host string) map[appliedKey]map[string]bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	owned := make(map[appliedKey]map[string]bool)
+	for key, servers := range o.owned {
+		if key.host == host {
+			owned[key] = maps.Clone(servers)
+		}
+	}
+
+	return owned
+}
+
+// applied records the servers of the event as the servers owned in its upstream.
+func (o *serverOwnership) applied(event *core.ServerUpdateEvent) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	owned := make(map[string]bool, len(event.UpstreamServers))
+	for _, server := range event.UpstreamServers {
+		owned[server.Host] = true
+	}
+
+	o.owned[keyOf(event)] = owned
+}
+
+// deleted forgets the servers of the event.
+func (o *serverOwnership) deleted(event *core.ServerUpdateEvent) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, server := range event.UpstreamServers {
+		delete(o.owned[keyOf(event)], server.Host)
+	}
+
+	if len(o.owned[keyOf(event)]) == 0 {
+		delete(o.owned, keyOf(event))
+	}
+}
+
+// persisted returns the record of the owned servers to persist, sorted.
+func (o *serverOwnership) persisted() []persistedOwnership {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	var ownerships []persistedOwnership
+	for key, owned := range o.owned {
+		ownerships = append(ownerships, persistedOwnership{
+			Host:       key.host,
+			ClientType: key.clientType,
+			Upstream:   key.upstream,
+			Servers:    sortedKeys(owned),
+		})
+	}
+
+	sort.Slice(ownerships, func(i, j int) bool {
+		return slices.Compare(
+			[]string{ownerships[i].Host, ownerships[i].ClientType, ownerships[i].Upstream},
+			[]string{ownerships[j].Host, ownerships[j].ClientType, ownerships[j].Upstream}) < 0
+	})
+
+	return ownerships
+}
+
+// restore adds the persisted record of the owned servers to the servers owned since NLK started.
+func (o *serverOwnership) restore(ownerships []persistedOwnership) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for _, ownership := range ownerships {
+		key := appliedKey{host: ownership.Host, clientType: ownership.ClientType, upstream: ownership.Upstream}
+		if o.owned[key] == nil {
+			o.owned[key] = make(map[string]bool, len(ownership.Servers))
+		}
+
+		for _, server := range ownership.Servers {
+			o.owned[key][server] = true
+		}
+	}
+}
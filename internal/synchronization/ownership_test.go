@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSynchronizer_TellsTheBorderClientsTheServersItOwns(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.OwnershipTag = "nlk"
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	drain(synchronizer, rateLimiter)
+
+	if ownership := borderClient.events[0].Ownership; ownership == nil || len(ownership.Owned) != 0 {
+		t.Fatalf(`expected the first update to own no server yet, got %v`, ownership)
+	}
+
+	synchronizer.AddEvent(deletionEvent(`prune`, "https://localhost:8080", "nlk-upstream", "http", "10.0.0.2:30080"))
+	drain(synchronizer, rateLimiter)
+
+	if owned := borderClient.events[1].Ownership.Owned; !owned["10.0.0.1:30080"] || !owned["10.0.0.2:30080"] {
+		t.Fatalf(`expected the deletion to know the servers applied, got %v`, owned)
+	}
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.3:30080")})
+	drain(synchronizer, rateLimiter)
+
+	if owned := borderClient.events[2].Ownership.Owned; len(owned) != 1 || !owned["10.0.0.1:30080"] {
+		t.Fatalf(`expected the deleted server to no longer be owned, got %v`, owned)
+	}
+
+	synchronizer.settings.Synchronizer.ForcePrune = true
+	synchronizer.AddEvent(deletionEvent(`prune`, "https://localhost:8080", "nlk-upstream", "http", "192.168.1.1:8080"))
+	drain(synchronizer, rateLimiter)
+
+	if !borderClient.events[3].Ownership.Force {
+		t.Fatalf(`expected the deletion to be forced`)
+	}
+}
+
+func TestSynchronizer_LeavesTheServersToTheBorderClientsWithoutAnOwnershipTag(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+	})
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.events[0].Ownership != nil || len(synchronizer.serverOwnership.owned) != 0 {
+		t.Fatalf(`expected no ownership without an ownership tag`)
+	}
+}
+
+func TestSynchronizer_PersistsTheServersItOwns(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.K8sClient = client
+		settings.Synchronizer.OwnershipTag = "nlk"
+	})
+	synchronizer.SetDesiredStateSource(func() (*core.DesiredState, error) { return buildDesiredState("10.0.0.1:30443"), nil })
+
+	event := core.ServerUpdateEventWithIdAndHost(buildServerUpdateEvent(core.Updated, "10.0.0.1:30443"), "owned", "https://localhost:8080")
+	event.ClientType = "stream"
+	synchronizer.recordOwnership(event)
+
+	if err := synchronizer.writeState(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// after a restart
+	restarted, err := NewSynchronizer(synchronizer.settings, &mocks.MockRateLimiter{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	restarted.SetDesiredStateSource(func() (*core.DesiredState, error) { return buildDesiredState("10.0.0.1:30443"), nil })
+
+	if err = restarted.recoverState(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if owned := restarted.withOwnership(event).Ownership.Owned; !owned["10.0.0.1:30443"] {
+		t.Fatalf(`expected the owned stream server to be restored, got %v`, owned)
+	}
+}
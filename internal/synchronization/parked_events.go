@@ -0,0 +1,106 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"sync"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+// parkedEvents records the last event of each upstream missing from the NGINX Plus configuration of each host once its
+// retries are used up, see withRetry; the event is queued again once a reconciliation finds the upstream defined on the
+// host, see releaseParkedEvents.
+type parkedEvents struct {
+
+	// lock guards events, the events are parked by the Synchronizer workers.
+	lock sync.Mutex
+
+	events map[appliedKey]*core.ServerUpdateEvent
+}
+
+// newParkedEvents creates a new, empty parkedEvents.
+func newParkedEvents() *parkedEvents {
+	return &parkedEvents{
+		events: make(map[appliedKey]*core.ServerUpdateEvent),
+	}
+}
+
+// park records the event for each of the hosts, replacing the event parked before for its upstream.
+func (p *parkedEvents) park(event *core.ServerUpdateEvent, hosts []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, host := range hosts {
+		p.events[appliedKey{host: host, clientType: event.ClientType, upstream: event.UpstreamName}] = event
+	}
+}
+
+// unpark forgets the event parked for the upstream of the event on the host, once an event for the upstream is applied.
+func (p *parkedEvents) unpark(event *core.ServerUpdateEvent, host string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.events, appliedKey{host: host, clientType: event.ClientType, upstream: event.UpstreamName})
+}
+
+// on returns the events parked on the host, each bound to the host.
+func (p *parkedEvents) on(host string) []*core.ServerUpdateEvent {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var events []*core.ServerUpdateEvent
+	for key, event := range p.events {
+		if key.host == host {
+			events = append(events, core.ServerUpdateEventWithIdAndHost(event, event.Id, host))
+		}
+	}
+
+	return events
+}
+
+// forgetHost forgets the events parked on a host that was removed.
+func (p *parkedEvents) forgetHost(host string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key := range p.events {
+		if key.host == host {
+			delete(p.events, key)
+		}
+	}
+}
+
+// releaseParkedEvents queues again the events parked on the host whose upstream is now defined in its NGINX Plus
+// configuration; they are queued for every host, like any event, the hosts already holding their servers are skipped.
+func (s *Synchronizer) releaseParkedEvents(host string) {
+	parked := s.parkedEvents.on(host)
+	if len(parked) == 0 {
+		return
+	}
+
+	actual, err := s.listUpstreamServers(host, parked)
+	if err != nil {
+		logrus.WithField("host", host).WithError(err).Warn(`Synchronizer::releaseParkedEvents: error occurred listing the upstreams`)
+		return
+	}
+
+	var released core.ServerUpdateEvents
+	for _, event := range parked {
+		if _, found := actual[keyOf(event)]; !found {
+			continue
+		}
+
+		s.parkedEvents.unpark(event, host)
+		logrus.WithFields(event.LogFields()).Info(`Synchronizer::releaseParkedEvents: the upstream is now defined, queuing the parked event`)
+		released = append(released, core.ServerUpdateEventWithIdAndHost(event, event.Id, ``))
+	}
+
+	if len(released) > 0 {
+		s.AddEvents(released)
+	}
+}
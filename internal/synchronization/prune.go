@@ -0,0 +1,269 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/sirupsen/logrus"
+)
+
+// upstreamLister defines the functions of the NGINX Plus client used to list the servers of the upstreams when pruning.
+type upstreamLister interface {
+
+	// GetUpstreams returns the HTTP upstreams and their servers.
+	GetUpstreams(ctx context.Context) (*nginxClient.Upstreams, error)
+
+	// GetStreamUpstreams returns the stream upstreams and their servers.
+	GetStreamUpstreams(ctx context.Context) (*nginxClient.StreamUpstreams, error)
+}
+
+// SetDesiredStateSource sets the function that provides the desired state of the upstreams, typically Watcher::DesiredState.
+// Orphaned servers are only pruned once a source has been set.
+func (s *Synchronizer) SetDesiredStateSource(source func() (*core.DesiredState, error)) {
+	s.desiredStateSource = source
+}
+
+// buildUpstreamLister creates the NGINX Plus client used to list the upstreams of the host.
+func (s *Synchronizer) buildUpstreamLister(host string) (upstreamLister, error) {
+	ngxClient, err := s.buildNginxClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return ngxClient, nil
+}
+
+// reconcileEvery runs reconcile each time the interval elapses until the stop signal; the first run waits for the interval,
+// which leaves time for the informers to sync. The DNS Service is written again first, which restores a DNS Service
+// changed by something else, and applies a change of its name.
+func (s *Synchronizer) reconcileEvery(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			s.dnsService.write()
+			s.reconcile()
+		}
+	}
+}
+
+// reconcile visits the NGINX Plus hosts: when Prune is enabled, it compares the upstreams of each host with the desired
+// state, and queues a Deleted event for each orphaned server, so the deletions are retried, measured, and honor dry-run mode
+// like any other change; it checks the upstreams of each host for servers changed outside NLK, see detectDrift; and it
+// queues again the events parked until their upstream is defined on the host, see releaseParkedEvents.
+// Only the servers NLK owns are pruned, see orphaned, so servers added by other tooling are left alone. Only the NGINX Plus hosts list their upstreams, the other border types
+// are not reconciled.
+// The hosts are visited at random times within the HostStagger, at most half the ReconcileInterval, so they are not all
+// listed at the same time; the desired state is read when each host is visited, so it includes the changes made meanwhile.
+func (s *Synchronizer) reconcile() {
+	if !s.isNginxPlus() {
+		return
+	}
+
+	logrus.Debug(`Synchronizer::reconcile`)
+
+	prune := s.settings.Synchronizer.Prune && s.desiredStateSource != nil
+	if prune {
+		if _, err := s.desiredStateSource(); err != nil {
+			logrus.WithError(err).Warn(`Synchronizer::reconcile: the desired state is not available, skipping the pruning`)
+			prune = false
+		}
+	}
+
+	spread := min(s.settings.Synchronizer.HostStagger, s.settings.Synchronizer.ReconcileInterval/2)
+	started := time.Now()
+
+	for _, staggered := range staggerDelays(s.settings.Hosts(), spread) {
+		host := staggered.host
+
+		select {
+		case <-s.settings.Context.Done():
+			return
+		case <-time.After(time.Until(started.Add(staggered.delay))):
+		}
+
+		if prune {
+			s.pruneHost(host)
+		}
+
+		s.detectDrift(host)
+		s.releaseParkedEvents(host)
+	}
+
+	s.lastReconcile.Store(time.Now())
+}
+
+// pruneHost queues a Deleted event for each orphaned server of the host, see findOrphanedServers.
+func (s *Synchronizer) pruneHost(host string) {
+	desiredState, err := s.desiredStateSource()
+	if err != nil {
+		logrus.WithField("host", host).WithError(err).Warn(`Synchronizer::reconcile: the desired state is not available, skipping the host`)
+		return
+	}
+
+	events, err := s.findOrphanedServers(host, desiredState)
+	if err != nil {
+		logrus.WithField("host", host).WithError(err).Warn(`Synchronizer::reconcile: error occurred listing the upstreams`)
+		return
+	}
+
+	if len(events) > 0 {
+		logrus.WithField("host", host).Infof(`Synchronizer::reconcile: pruning %d orphaned server(s)`, len(events))
+	}
+
+	for _, event := range events {
+		s.AddEvent(event)
+	}
+}
+
+// findOrphanedServers returns a Deleted event for each server of the host that NLK owns but is not desired, see orphaned.
+// The upstreams visited are those of the desired state, and those NLK has applied servers to on the host, see
+// ownedServersOn, e.g. the upstreams of a Service whose deletion was missed, which are no longer in the desired state.
+func (s *Synchronizer) findOrphanedServers(host string, desiredState *core.DesiredState) ([]*core.ServerUpdateEvent, error) {
+	lister, err := s.upstreamListerFactory(host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	owned := s.ownedServersOn(host)
+	httpUpstreams := ownedUpstreams(desiredState.HttpUpstreams, owned, ProtocolHttp)
+	streamUpstreams := ownedUpstreams(desiredState.StreamUpstreams, owned, ProtocolStream)
+
+	var events []*core.ServerUpdateEvent
+
+	if len(httpUpstreams) > 0 {
+		upstreams, err := lister.GetUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the HTTP upstreams: %w`, err)
+		}
+
+		for name, clientType := range httpUpstreams {
+			desired, isDesired := desiredState.HttpUpstreams[name]
+			for _, peer := range (*upstreams)[name].Peers {
+				if orphaned(peer.Server, desired, isDesired, owned[appliedKey{host, clientType, name}], desiredState.KnownNodeAddresses) {
+					events = append(events, deletionEvent(`prune`, host, name, clientType, peer.Server))
+				}
+			}
+		}
+	}
+
+	if len(streamUpstreams) > 0 {
+		upstreams, err := lister.GetStreamUpstreams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error occurred retrieving the stream upstreams: %w`, err)
+		}
+
+		for name, clientType := range streamUpstreams {
+			desired, isDesired := desiredState.StreamUpstreams[name]
+			for _, peer := range (*upstreams)[name].Peers {
+				if orphaned(peer.Server, desired, isDesired, owned[appliedKey{host, clientType, name}], desiredState.KnownNodeAddresses) {
+					events = append(events, deletionEvent(`prune`, host, name, clientType, peer.Server))
+				}
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// ownedServersOn returns the servers NLK has applied to each upstream of the host: those last applied since it started,
+// see appliedCache, and those recorded, and persisted, while an OwnershipTag is set, see serverOwnership.
+func (s *Synchronizer) ownedServersOn(host string) map[appliedKey]map[string]bool {
+	owned := make(map[appliedKey]map[string]bool)
+	own := func(key appliedKey, server string) {
+		if owned[key] == nil {
+			owned[key] = make(map[string]bool)
+		}
+
+		owned[key][server] = true
+	}
+
+	for _, event := range s.appliedCache.appliedOn(host) {
+		for _, server := range event.UpstreamServers {
+			own(keyOf(event), server.Host)
+		}
+	}
+
+	for key, servers := range s.serverOwnership.ownedOn(host) {
+		for server := range servers {
+			own(key, server)
+		}
+	}
+
+	return owned
+}
+
+// ownedUpstreams returns the client type of each upstream of the protocol that is desired, or that NLK owns servers of.
+func ownedUpstreams(desired map[string]map[string]bool, owned map[appliedKey]map[string]bool, protocol string) map[string]string {
+	clientTypes := make(map[string]string)
+	for key := range owned {
+		if protocolOf(key.clientType) == protocol {
+			clientTypes[key.upstream] = key.clientType
+		}
+	}
+
+	desiredClientType := application.ClientTypeNginxStream
+	if protocol == ProtocolHttp {
+		desiredClientType = application.ClientTypeNginxHttp
+	}
+
+	for name := range desired {
+		if _, found := clientTypes[name]; !found {
+			clientTypes[name] = desiredClientType
+		}
+	}
+
+	return clientTypes
+}
+
+// orphaned determines whether a server is not desired and NLK owns it: NLK has applied it to the upstream, see
+// ownedServersOn, or the upstream is desired and the server is on the address of a known cluster node, e.g. a server
+// of a node deleted while NLK was down. The servers of the other upstreams, and those added by other tooling, are left alone.
+func orphaned(server string, desired map[string]bool, isDesired bool, owned map[string]bool, knownNodeAddresses map[string]bool) bool {
+	if desired[server] {
+		return false
+	}
+
+	if owned[server] {
+		return true
+	}
+
+	address, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(address)
+
+	return isDesired && ip != nil && knownNodeAddresses[ip.String()]
+}
+
+// deletionEvent creates the Deleted event that removes the server from the upstream of the host; the origin, e.g. "prune",
+// prefixes the event id.
+func deletionEvent(origin string, host string, upstreamName string, clientType string, server string) *core.ServerUpdateEvent {
+	return &core.ServerUpdateEvent{
+		Id:              fmt.Sprintf(`[%s]-[%s]-[%s]`, origin, RandomString(12), upstreamName),
+		ClientType:      clientType,
+		NginxHost:       host,
+		Type:            core.Deleted,
+		UpstreamName:    upstreamName,
+		UpstreamServers: core.UpstreamServers{core.NewUpstreamServer(server)},
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+)
+
+func TestSynchronizer_ReconcilePrunesOrphanedServersOnKnownNodes(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Prune = true
+		settings.Synchronizer.HostStagger = 0
+	})
+	stubPrunableUpstreams(synchronizer)
+
+	synchronizer.reconcile()
+
+	if rateLimiter.Len() != 2 {
+		t.Fatalf(`expected 2 prune events, got %d`, rateLimiter.Len())
+	}
+
+	pruned := make(map[string]string)
+	for rateLimiter.Len() > 0 {
+		item, _ := rateLimiter.Get()
+		event := item.(*syncEvent).event
+
+		if event.Type != core.Deleted || event.NginxHost != "https://10.0.0.100:9000/api" {
+			t.Errorf(`expected a Deleted event for the host, got %s for %q`, event.TypeName(), event.NginxHost)
+		}
+
+		pruned[event.UpstreamServers[0].Host] = event.ClientType
+	}
+
+	if pruned["10.0.0.2:30080"] != application.ClientTypeNginxHttp {
+		t.Errorf(`expected the HTTP server of the removed node to be pruned, got %v`, pruned)
+	}
+
+	if pruned["10.0.0.2:30443"] != application.ClientTypeNginxStream {
+		t.Errorf(`expected the stream server of the removed node to be pruned, got %v`, pruned)
+	}
+}
+
+func TestSynchronizer_ReconcilePrunesTheServersNlkAppliedToUpstreamsNoLongerDesired(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Prune = true
+		settings.Synchronizer.HostStagger = 0
+	})
+	stubPrunableUpstreams(synchronizer)
+
+	// the deletion of the Service of nginx-removed was missed, its server is on a node that is no longer known
+	applied := deletionEvent(`test`, "https://10.0.0.100:9000/api", "nginx-removed", application.ClientTypeNginxHttp, "10.0.0.3:30080")
+	applied.Type = core.Updated
+	synchronizer.appliedCache.store(synchronizer.settings.Hosts(), applied)
+
+	synchronizer.reconcile()
+
+	pruned := make(map[string]bool)
+	for rateLimiter.Len() > 0 {
+		item, _ := rateLimiter.Get()
+		event := item.(*syncEvent).event
+		pruned[event.UpstreamName+"/"+event.UpstreamServers[0].Host] = true
+	}
+
+	if !pruned["nginx-removed/10.0.0.3:30080"] {
+		t.Errorf(`expected the server NLK applied to the upstream no longer desired to be pruned, got %v`, pruned)
+	}
+
+	if pruned["unmanaged/10.0.0.2:30080"] {
+		t.Errorf(`expected the upstream NLK does not own to be left alone, got %v`, pruned)
+	}
+}
+
+func TestSynchronizer_ReconcileDoesNothingUnlessPruneIsEnabled(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Prune = false
+		settings.Synchronizer.HostStagger = 0
+	})
+	stubPrunableUpstreams(synchronizer)
+
+	synchronizer.reconcile()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected no events when pruning is disabled, got %d`, rateLimiter.Len())
+	}
+}
+
+func TestSynchronizer_ReconcileSkipsWhenTheDesiredStateIsNotAvailable(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Prune = true
+		settings.Synchronizer.HostStagger = 0
+	})
+	stubPrunableUpstreams(synchronizer)
+	synchronizer.SetDesiredStateSource(func() (*core.DesiredState, error) {
+		return nil, fmt.Errorf(`the informers have not synced`)
+	})
+
+	synchronizer.reconcile()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected no events without a desired state, got %d`, rateLimiter.Len())
+	}
+}
+
+func TestSynchronizer_ReconcileSkipsHostsThatCannotBeListed(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://10.0.0.100:9000/api"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Prune = true
+		settings.Synchronizer.HostStagger = 0
+	})
+	stubPrunableUpstreams(synchronizer)
+	synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) {
+		return &fakeUpstreamLister{err: fmt.Errorf(`connection refused`)}, nil
+	}
+
+	synchronizer.reconcile()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected no events when the upstreams cannot be listed, got %d`, rateLimiter.Len())
+	}
+}
+
+// stubPrunableUpstreams gives the host of the Synchronizer, in each upstream, a desired server, a server of a removed node,
+// and a server that was not added by NLK.
+func stubPrunableUpstreams(synchronizer *Synchronizer) {
+	lister := &fakeUpstreamLister{
+		upstreams: nginxClient.Upstreams{
+			"nginx-http":    {Peers: []nginxClient.Peer{{Server: "10.0.0.1:30080"}, {Server: "10.0.0.2:30080"}, {Server: "192.168.1.1:8080"}}},
+			"unmanaged":     {Peers: []nginxClient.Peer{{Server: "10.0.0.2:30080"}}},
+			"nginx-removed": {Peers: []nginxClient.Peer{{Server: "10.0.0.3:30080"}}},
+		},
+		streamUpstreams: nginxClient.StreamUpstreams{
+			"nginx-tls": {Peers: []nginxClient.StreamPeer{{Server: "10.0.0.1:30443"}, {Server: "10.0.0.2:30443"}}},
+		},
+	}
+
+	synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) { return lister, nil }
+	synchronizer.SetDesiredStateSource(func() (*core.DesiredState, error) {
+		desiredState := core.NewDesiredState()
+		desiredState.HttpUpstreams["nginx-http"] = map[string]bool{"10.0.0.1:30080": true}
+		desiredState.StreamUpstreams["nginx-tls"] = map[string]bool{"10.0.0.1:30443": true}
+		desiredState.KnownNodeAddresses["10.0.0.1"] = true
+		desiredState.KnownNodeAddresses["10.0.0.2"] = true
+
+		return desiredState, nil
+	})
+}
+
+// fakeUpstreamLister returns the configured upstreams, or the configured error.
+type fakeUpstreamLister struct {
+	upstreams       nginxClient.Upstreams
+	streamUpstreams nginxClient.StreamUpstreams
+	err             error
+}
+
+func (f *fakeUpstreamLister) GetUpstreams(_ context.Context) (*nginxClient.Upstreams, error) {
+	return &f.upstreams, f.err
+}
+
+func (f *fakeUpstreamLister) GetStreamUpstreams(_ context.Context) (*nginxClient.StreamUpstreams, error) {
+	return &f.streamUpstreams, f.err
+}
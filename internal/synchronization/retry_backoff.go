@@ -0,0 +1,75 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ workqueue.RateLimiter = (*retryBackoff)(nil)
+
+// retryBackoff is the rate limiter of the retries of the events: the delay grows exponentially with the failures of
+// each event, from RateLimiterBase to RateLimiterMax, and is jittered down to half its value, so the events that failed
+// together, e.g. against a host that was briefly unavailable, are not all retried at the same instant.
+type retryBackoff struct {
+	// settings holds the RateLimiterBase and RateLimiterMax of the backoff.
+	settings configuration.WorkQueueSettings
+
+	// jitter returns a random duration in [0, d), rand.Int63n by default.
+	jitter func(d time.Duration) time.Duration
+
+	// lock guards failures, the retries are computed by the Synchronizer workers.
+	lock sync.Mutex
+
+	// failures is the number of retries of each event since it was last forgotten.
+	failures map[interface{}]int
+}
+
+// newRetryBackoff creates a new retryBackoff with the rate limiter settings of the queue.
+func newRetryBackoff(settings configuration.WorkQueueSettings) *retryBackoff {
+	return &retryBackoff{
+		settings: settings,
+		jitter:   func(d time.Duration) time.Duration { return time.Duration(rand.Int63n(int64(d))) },
+		failures: make(map[interface{}]int),
+	}
+}
+
+// When records a failure of the item, and returns the delay of its retry: RateLimiterBase doubled for each earlier
+// failure, capped at RateLimiterMax, of which the second half is jittered.
+func (b *retryBackoff) When(item interface{}) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	backoff := b.settings.Backoff(b.failures[item])
+	b.failures[item]++
+
+	if half := backoff / 2; half > 0 {
+		return backoff - half + b.jitter(half+1)
+	}
+
+	return backoff
+}
+
+// Forget resets the backoff of the item, once it has succeeded or been dropped.
+func (b *retryBackoff) Forget(item interface{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.failures, item)
+}
+
+// NumRequeues returns the number of retries of the item since it was last forgotten.
+func (b *retryBackoff) NumRequeues(item interface{}) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.failures[item]
+}
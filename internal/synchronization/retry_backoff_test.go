@@ -0,0 +1,67 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+)
+
+func TestRetryBackoff_GrowsAndCaps(t *testing.T) {
+	backoff := newRetryBackoff(configuration.WorkQueueSettings{RateLimiterBase: time.Second, RateLimiterMax: 10 * time.Second})
+	backoff.jitter = func(d time.Duration) time.Duration { return d - 1 }
+
+	item := &syncEvent{}
+
+	var delays []time.Duration
+	for i := 0; i < 6; i++ {
+		delays = append(delays, backoff.When(item))
+	}
+
+	// without jitter, the delays double from the base up to the max
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	if !reflect.DeepEqual(delays, expected) {
+		t.Fatalf(`expected the delays %v, got %v`, expected, delays)
+	}
+
+	if requeues := backoff.NumRequeues(item); requeues != 6 {
+		t.Fatalf(`expected 6 requeues, got %d`, requeues)
+	}
+
+	// a success resets the backoff of the item, not of the others
+	other := &syncEvent{}
+	backoff.When(other)
+	backoff.Forget(item)
+
+	if delay := backoff.When(item); delay != time.Second || backoff.NumRequeues(other) != 1 {
+		t.Fatalf(`expected the backoff of the item to be reset, got %v`, delay)
+	}
+}
+
+func TestRetryBackoff_JittersTheSecondHalf(t *testing.T) {
+	backoff := newRetryBackoff(configuration.WorkQueueSettings{RateLimiterBase: time.Second, RateLimiterMax: 8 * time.Second})
+	backoff.jitter = func(time.Duration) time.Duration { return 0 }
+
+	item := &syncEvent{}
+
+	for _, expected := range []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if delay := backoff.When(item); delay != expected {
+			t.Fatalf(`expected the delay to be jittered down to %v, got %v`, expected, delay)
+		}
+	}
+
+	// the default jitter stays within the second half of the backoff
+	backoff = newRetryBackoff(configuration.WorkQueueSettings{RateLimiterBase: time.Second, RateLimiterMax: 8 * time.Second})
+	for i := 0; i < 100; i++ {
+		backoff.Forget(item)
+		if delay := backoff.When(item); delay < 500*time.Millisecond || delay > time.Second {
+			t.Fatalf(`expected a delay between 500ms and 1s, got %v`, delay)
+		}
+	}
+}
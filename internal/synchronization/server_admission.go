@@ -0,0 +1,372 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// serverProbeTimeout is how long a new server is given to answer its admission probe.
+	serverProbeTimeout = time.Second * 2
+
+	// serverProbeConcurrency bounds the admission probes run at the same time, so a batch of new nodes is probed in parallel
+	// without opening a connection to every one of them at once.
+	serverProbeConcurrency = 16
+
+	// serverAdmissionRetryBase and serverAdmissionRetryMax bound the delay before the servers held back are probed again,
+	// which doubles with each attempt.
+	serverAdmissionRetryBase = time.Second * 2
+	serverAdmissionRetryMax  = time.Second * 15
+)
+
+// serverAdmissionKey identifies a server of an upstream on an NGINX Plus host.
+type serverAdmissionKey struct {
+	upstream appliedKey
+	server   string
+}
+
+// serverAdmissions tracks the servers held back from their upstreams because their NodePort does not answer yet, see
+// admitServers.
+type serverAdmissions struct {
+
+	// lock guards the maps, the servers are admitted by the Synchronizer workers and probed in the background.
+	lock sync.Mutex
+
+	// heldSince records when each server held back was first seen, used to apply the ServerAdmissionMaxWait; it is kept
+	// across the events of the upstream until the server answers or is no longer held back.
+	heldSince map[serverAdmissionKey]time.Time
+
+	// answered records the servers held back whose last probe answered, they are admitted by the next event.
+	answered map[serverAdmissionKey]bool
+
+	// probing records the servers whose probe is running, so a server is not probed twice at the same time.
+	probing map[serverAdmissionKey]bool
+
+	// pending holds the last event of each upstream with servers held back, which is applied again once its probes
+	// answered or the retry delay has elapsed.
+	pending map[appliedKey]*core.ServerUpdateEvent
+
+	// attempts counts the retries of each upstream, to compute the retry delay.
+	attempts map[appliedKey]int
+}
+
+// newServerAdmissions creates a new, empty serverAdmissions.
+func newServerAdmissions() *serverAdmissions {
+	return &serverAdmissions{
+		heldSince: make(map[serverAdmissionKey]time.Time),
+		answered:  make(map[serverAdmissionKey]bool),
+		probing:   make(map[serverAdmissionKey]bool),
+		pending:   make(map[appliedKey]*core.ServerUpdateEvent),
+		attempts:  make(map[appliedKey]int),
+	}
+}
+
+// admitServers returns the event without the servers it adds to its upstream, those not in the servers last applied,
+// until a probe finds them answering, e.g. the servers of a new node before kube-proxy has programmed its NodePort. The
+// servers are probed in the background, so the worker is not held up, and the event is applied again once they answer,
+// or after a backoff, until the ServerAdmissionMaxWait has elapsed since they were first held back; the servers are then
+// added, or left out per the ServerAdmissionPolicy. The servers of an upstream not applied since NLK started, the
+// servers that are down or draining, and the servers of the UDP upstreams, which no TCP connection can probe, are not probed.
+func (s *Synchronizer) admitServers(event *core.ServerUpdateEvent) *core.ServerUpdateEvent {
+	maxWait := s.settings.Synchronizer.ServerAdmissionMaxWait
+	key := keyOf(event)
+
+	applied, found := s.appliedCache.appliedServers(event)
+	if maxWait <= 0 || !found || event.ClientType == application.ClientTypeNginxUdp {
+		s.serverAdmissions.forget(key)
+		return event
+	}
+
+	var candidates core.UpstreamServers
+	for _, server := range event.UpstreamServers {
+		if !server.IsDown() && !server.Drain && !applied[server.Host] {
+			candidates = append(candidates, server)
+		}
+	}
+
+	if len(candidates) == 0 {
+		s.serverAdmissions.forget(key)
+		return event
+	}
+
+	now := time.Now()
+	var held, retried, skipped core.UpstreamServers
+
+	for _, server := range candidates {
+		heldSince, answered := s.serverAdmissions.held(key, server.Host, now)
+
+		switch {
+		case answered:
+			continue
+
+		case now.Sub(heldSince) < maxWait:
+			held = append(held, server)
+			retried = append(retried, server)
+
+		case s.settings.Synchronizer.ServerAdmissionPolicy == configuration.ServerAdmissionPolicySkip:
+			held = append(held, server)
+			skipped = append(skipped, server)
+
+		default:
+			logrus.WithFields(event.LogFields()).WithField("server", server.Host).
+				Warnf(`Synchronizer::admitServers: the server still does not answer after %v, adding it anyway`, maxWait)
+		}
+	}
+
+	// the servers no longer held back start over, the skipped servers keep waiting from when they were first seen
+	s.serverAdmissions.retain(key, held)
+
+	if len(skipped) > 0 {
+		logrus.WithFields(event.LogFields()).WithField("servers", hostsOf(skipped)).
+			Warnf(`Synchronizer::admitServers: the servers still do not answer after %v, leaving them out until an event finds them answering`, maxWait)
+	}
+
+	if len(held) == 0 {
+		s.serverAdmissions.stopRetrying(key)
+		return event
+	}
+
+	if len(retried) > 0 {
+		logrus.WithFields(event.LogFields()).WithField("servers", hostsOf(retried)).
+			Info(`Synchronizer::admitServers: the servers are held back until they answer`)
+	}
+
+	s.probeHeldServers(key, event, held, len(retried) > 0)
+
+	return withoutServers(event, held)
+}
+
+// probeHeldServers probes the servers held back in the background. When retry is set, the event is applied again once a
+// server answered, or once the retry delay has elapsed; otherwise, i.e. the servers are skipped, an answer is only
+// picked up by the next event of the upstream.
+func (s *Synchronizer) probeHeldServers(key appliedKey, event *core.ServerUpdateEvent, held core.UpstreamServers, retry bool) {
+	var delay time.Duration
+	if retry {
+		delay = s.serverAdmissions.retryLater(key, event)
+	} else {
+		s.serverAdmissions.stopRetrying(key)
+	}
+
+	servers := s.serverAdmissions.startProbes(key, held)
+
+	go func() {
+		start := time.Now()
+		unanswered := s.probeServers(event.ClientType, servers)
+
+		if answered := s.serverAdmissions.probed(key, servers, unanswered); answered {
+			delay = 0
+		}
+
+		if retry {
+			time.AfterFunc(max(delay-time.Since(start), 0), func() { s.retryAdmission(key) })
+		}
+	}()
+}
+
+// retryAdmission queues the pending event of the upstream again, the last event whose servers were held back.
+func (s *Synchronizer) retryAdmission(key appliedKey) {
+	event := s.serverAdmissions.takePending(key)
+	if event == nil {
+		return
+	}
+
+	retry := core.ServerUpdateEventWithIdAndHost(event, fmt.Sprintf(`[admission]-[%s]-[%s]`, RandomString(12), event.UpstreamName), event.NginxHost)
+	retry.ObservedAt = time.Time{}
+
+	s.AddEvent(retry)
+}
+
+// probeServers probes the servers concurrently, at most serverProbeConcurrency at a time, and returns the error of each
+// server that did not answer, by Host.
+func (s *Synchronizer) probeServers(clientType string, servers core.UpstreamServers) map[string]error {
+	unanswered := make(map[string]error)
+	if len(servers) == 0 {
+		return unanswered
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, serverProbeConcurrency)
+
+	for _, server := range servers {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(host string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			ctx, cancel := context.WithTimeout(s.settings.Context, serverProbeTimeout)
+			defer cancel()
+
+			if err := s.serverProber(ctx, clientType, host); err != nil {
+				lock.Lock()
+				unanswered[host] = err
+				lock.Unlock()
+			}
+		}(server.Host)
+	}
+
+	wg.Wait()
+
+	return unanswered
+}
+
+// probeServer determines whether the server answers with an established TCP connection, whatever the protocol of the
+// upstream: a NodePort may serve TLS, or a protocol other than HTTP, and kube-proxy accepts the connection once programmed.
+// The servers of the UDP upstreams are not probed, see admitServers.
+func probeServer(ctx context.Context, _ string, server string) error {
+	connection, err := (&net.Dialer{}).DialContext(ctx, "tcp", server)
+	if err != nil {
+		return err
+	}
+
+	return connection.Close()
+}
+
+// held records the server as held back since now, unless it already is, and returns since when it is held back, or
+// whether its last probe answered, in which case the server is forgotten.
+func (a *serverAdmissions) held(key appliedKey, server string, now time.Time) (time.Time, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	admissionKey := serverAdmissionKey{upstream: key, server: server}
+	if a.answered[admissionKey] {
+		delete(a.answered, admissionKey)
+		delete(a.heldSince, admissionKey)
+		return now, true
+	}
+
+	heldSince, found := a.heldSince[admissionKey]
+	if !found {
+		heldSince = now
+		a.heldSince[admissionKey] = now
+	}
+
+	return heldSince, false
+}
+
+// retain forgets the servers of the upstream that are no longer held back.
+func (a *serverAdmissions) retain(key appliedKey, held core.UpstreamServers) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	kept := make(map[string]bool, len(held))
+	for _, server := range held {
+		kept[server.Host] = true
+	}
+
+	for admissionKey := range a.heldSince {
+		if admissionKey.upstream == key && !kept[admissionKey.server] {
+			delete(a.heldSince, admissionKey)
+			delete(a.answered, admissionKey)
+		}
+	}
+}
+
+// startProbes records the servers as being probed, and returns those whose probe is not already running.
+func (a *serverAdmissions) startProbes(key appliedKey, servers core.UpstreamServers) core.UpstreamServers {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var started core.UpstreamServers
+	for _, server := range servers {
+		admissionKey := serverAdmissionKey{upstream: key, server: server.Host}
+		if !a.probing[admissionKey] {
+			a.probing[admissionKey] = true
+			started = append(started, server)
+		}
+	}
+
+	return started
+}
+
+// probed records the outcome of the probes of the servers, and returns whether one of the servers still held back answered.
+func (a *serverAdmissions) probed(key appliedKey, servers core.UpstreamServers, unanswered map[string]error) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	answered := false
+	for _, server := range servers {
+		admissionKey := serverAdmissionKey{upstream: key, server: server.Host}
+		delete(a.probing, admissionKey)
+
+		if _, held := a.heldSince[admissionKey]; held && unanswered[server.Host] == nil {
+			a.answered[admissionKey] = true
+			answered = true
+		}
+	}
+
+	return answered
+}
+
+// retryLater records the event as the pending event of the upstream, and returns the delay before it is applied again.
+func (a *serverAdmissions) retryLater(key appliedKey, event *core.ServerUpdateEvent) time.Duration {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.pending[key] = event
+	delay := min(serverAdmissionRetryBase<<a.attempts[key], serverAdmissionRetryMax)
+	a.attempts[key]++
+
+	return delay
+}
+
+// takePending returns the pending event of the upstream, if any, and forgets it.
+func (a *serverAdmissions) takePending(key appliedKey) *core.ServerUpdateEvent {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	event := a.pending[key]
+	delete(a.pending, key)
+
+	return event
+}
+
+// stopRetrying stops applying the events of the upstream again, the servers held back are kept.
+func (a *serverAdmissions) stopRetrying(key appliedKey) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.pending, key)
+	delete(a.attempts, key)
+}
+
+// forget stops tracking the servers held back from the upstream, e.g. once they all answered.
+func (a *serverAdmissions) forget(key appliedKey) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.pending, key)
+	delete(a.attempts, key)
+
+	for admissionKey := range a.heldSince {
+		if admissionKey.upstream == key {
+			delete(a.heldSince, admissionKey)
+			delete(a.answered, admissionKey)
+		}
+	}
+}
+
+// hostsOf returns the Host of each of the servers.
+func hostsOf(servers core.UpstreamServers) []string {
+	hosts := make([]string, 0, len(servers))
+	for _, server := range servers {
+		hosts = append(hosts, server.Host)
+	}
+
+	return hosts
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+func TestSynchronizer_HoldsBackTheServersUntilTheyAnswer(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.ServerAdmissionMaxWait = time.Minute
+		settings.Synchronizer.ServerAdmissionPolicy = configuration.ServerAdmissionPolicyAdd
+	})
+	prober := &fakeServerProber{unanswered: map[string]bool{"10.0.0.2:30080": true}}
+	synchronizer.serverProber = prober.probe
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+
+	// the servers of an upstream not applied yet are not probed
+	if prober.probed() != 0 || borderClient.callCount() != 1 {
+		t.Fatalf(`expected the first servers to be applied without probing, got %d probe(s) and %d call(s)`, prober.probed(), borderClient.callCount())
+	}
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	drain(synchronizer, rateLimiter)
+	waitForProbes(t, synchronizer)
+
+	if borderClient.callCount() != 1 || prober.probed() != 1 {
+		t.Fatalf(`expected the server to be held back while it is probed, got %d call(s)`, borderClient.callCount())
+	}
+
+	if pending, _ := synchronizer.serverAdmissions.counts(); pending != 1 {
+		t.Fatalf(`expected the event to be applied again later`)
+	}
+
+	// the answer is picked up by the event that follows, which is applied again at once
+	prober.answer("10.0.0.2:30080")
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	drain(synchronizer, rateLimiter)
+	waitForProbes(t, synchronizer)
+
+	for deadline := time.Now().Add(5 * time.Second); rateLimiter.Len() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	drain(synchronizer, rateLimiter)
+
+	if borderClient.callCount() != 2 || len(borderClient.events[1].UpstreamServers) != 2 {
+		t.Fatalf(`expected the server to be added once it answers, got %v`, borderClient.events)
+	}
+
+	if pending, held := synchronizer.serverAdmissions.counts(); pending != 0 || held != 0 {
+		t.Fatalf(`expected the admission of the upstream to be forgotten`)
+	}
+}
+
+func TestSynchronizer_ServerAdmissionPolicy(t *testing.T) {
+	testCases := []struct {
+		policy  string
+		servers int
+	}{
+		{policy: configuration.ServerAdmissionPolicyAdd, servers: 2},
+		{policy: configuration.ServerAdmissionPolicySkip, servers: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+				settings.Synchronizer.CoalesceWindow = 0
+				settings.Synchronizer.ServerAdmissionMaxWait = time.Nanosecond
+				settings.Synchronizer.ServerAdmissionPolicy = tc.policy
+			})
+			prober := &fakeServerProber{unanswered: map[string]bool{"10.0.0.2:30080": true}}
+			synchronizer.serverProber = prober.probe
+
+			synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+			drain(synchronizer, rateLimiter)
+
+			for range 2 {
+				time.Sleep(time.Millisecond)
+				synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+				drain(synchronizer, rateLimiter)
+				waitForProbes(t, synchronizer)
+			}
+
+			applied, _ := synchronizer.appliedCache.appliedServers(borderClient.events[len(borderClient.events)-1])
+			if len(applied) != tc.servers {
+				t.Fatalf(`expected %d server(s) to be applied once the max wait has elapsed, got %v`, tc.servers, applied)
+			}
+
+			if pending, _ := synchronizer.serverAdmissions.counts(); pending != 0 {
+				t.Fatalf(`expected no further retry once the max wait has elapsed`)
+			}
+		})
+	}
+}
+
+func TestSynchronizer_SkippedServersKeepWaitingFromWhenTheyWereFirstSeen(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.ServerAdmissionMaxWait = 50 * time.Millisecond
+		settings.Synchronizer.ServerAdmissionPolicy = configuration.ServerAdmissionPolicySkip
+	})
+	prober := &fakeServerProber{unanswered: map[string]bool{"10.0.0.2:30080": true}}
+	synchronizer.serverProber = prober.probe
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	drain(synchronizer, rateLimiter)
+	waitForProbes(t, synchronizer)
+
+	synchronizer.serverAdmissions.lock.Lock()
+	var firstSeen time.Time
+	for _, heldSince := range synchronizer.serverAdmissions.heldSince {
+		firstSeen = heldSince
+	}
+	synchronizer.serverAdmissions.lock.Unlock()
+
+	for range 2 {
+		time.Sleep(60 * time.Millisecond)
+		synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+		drain(synchronizer, rateLimiter)
+		waitForProbes(t, synchronizer)
+	}
+
+	synchronizer.serverAdmissions.lock.Lock()
+	defer synchronizer.serverAdmissions.lock.Unlock()
+
+	if len(synchronizer.serverAdmissions.heldSince) != 1 {
+		t.Fatalf(`expected the skipped server to be tracked, got %v`, synchronizer.serverAdmissions.heldSince)
+	}
+
+	for _, heldSince := range synchronizer.serverAdmissions.heldSince {
+		if !heldSince.Equal(firstSeen) {
+			t.Fatalf(`expected the skipped server to be held since it was first seen, %v, got %v`, firstSeen, heldSince)
+		}
+	}
+}
+
+func TestProbeServer_ConnectsOverTcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+	defer listener.Close()
+
+	// a TLS NodePort answers the connection although it would not answer a plain HTTP request
+	if err = probeServer(context.Background(), "http", listener.Addr().String()); err != nil {
+		t.Fatalf(`expected the server to answer, got %v`, err)
+	}
+}
+
+func TestSynchronizer_DoesNotProbeTheServersOfUdpUpstreams(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.ServerAdmissionMaxWait = time.Minute
+		settings.Synchronizer.ServerAdmissionPolicy = configuration.ServerAdmissionPolicySkip
+	})
+	prober := &fakeServerProber{unanswered: map[string]bool{"10.0.0.2:30053": true}}
+	synchronizer.serverProber = prober.probe
+
+	for _, servers := range [][]string{{"10.0.0.1:30053"}, {"10.0.0.1:30053", "10.0.0.2:30053"}} {
+		event := buildServerUpdateEvent(core.Updated, servers...)
+		event.ClientType = application.ClientTypeNginxUdp
+		synchronizer.AddEvents(core.ServerUpdateEvents{event})
+		drain(synchronizer, rateLimiter)
+	}
+
+	if prober.probed() != 0 || borderClient.callCount() != 2 || len(borderClient.events[1].UpstreamServers) != 2 {
+		t.Fatalf(`expected the servers of the UDP upstream to be added without probing, got %d probe(s) and %v`, prober.probed(), borderClient.events)
+	}
+}
+
+func TestSynchronizer_DoesNotProbeWithoutAMaxWait(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CoalesceWindow = 0
+		settings.Synchronizer.ServerAdmissionMaxWait = 0
+		settings.Synchronizer.ServerAdmissionPolicy = configuration.ServerAdmissionPolicyAdd
+	})
+	prober := &fakeServerProber{unanswered: map[string]bool{"10.0.0.2:30080": true}}
+	synchronizer.serverProber = prober.probe
+
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080")})
+	drain(synchronizer, rateLimiter)
+	synchronizer.AddEvents(core.ServerUpdateEvents{buildServerUpdateEvent(core.Updated, "10.0.0.1:30080", "10.0.0.2:30080")})
+	drain(synchronizer, rateLimiter)
+
+	if prober.probed() != 0 || borderClient.callCount() != 2 {
+		t.Fatalf(`expected the servers to be added without probing, got %d probe(s) and %d call(s)`, prober.probed(), borderClient.callCount())
+	}
+}
+
+// fakeServerProber fails the probes of the servers that do not answer, and counts the probes.
+type fakeServerProber struct {
+	lock       sync.Mutex
+	unanswered map[string]bool
+	probes     int
+}
+
+func (f *fakeServerProber) probe(_ context.Context, _ string, server string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.probes++
+	if f.unanswered[server] {
+		return errors.New(`connection refused`)
+	}
+
+	return nil
+}
+
+func (f *fakeServerProber) answer(server string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.unanswered, server)
+}
+
+func (f *fakeServerProber) probed() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.probes
+}
+
+// counts returns the number of upstreams whose event is applied again later, and of servers held back.
+func (a *serverAdmissions) counts() (int, int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return len(a.pending), len(a.heldSince)
+}
+
+// waitForProbes waits for the admission probes running in the background to complete.
+func waitForProbes(t *testing.T, synchronizer *Synchronizer) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		synchronizer.serverAdmissions.lock.Lock()
+		probing := len(synchronizer.serverAdmissions.probing)
+		synchronizer.serverAdmissions.lock.Unlock()
+
+		if probing == 0 {
+			return
+		}
+	}
+
+	t.Fatalf(`expected the admission probes to complete`)
+}
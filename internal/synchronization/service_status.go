@@ -0,0 +1,294 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxSyncErrorLength is the maximum length of the sync-error annotation, the errors of NGINX Plus can be long.
+const maxSyncErrorLength = 1024
+
+// upstreamStatus is the sync status of an upstream of a Service.
+type upstreamStatus struct {
+
+	// hosts is the number of NGINX Plus hosts the upstream was last synced to.
+	hosts int
+
+	// servers is the number of servers of the upstream when it was last synced.
+	servers int
+
+	// err describes the failures of the last event for the upstream that was dropped, empty once the upstream is synced.
+	err string
+}
+
+// serviceStatus is the sync status of the upstreams of a Service, written to its annotations.
+type serviceStatus struct {
+
+	// namespace and name identify the Service.
+	namespace string
+	name      string
+
+	// upstreams holds the status of each upstream of the Service, keyed by upstream name.
+	upstreams map[string]*upstreamStatus
+
+	// lastSynced is the time of the last successful sync of an upstream of the Service.
+	lastSynced time.Time
+
+	// written is the time the annotations were last written.
+	written time.Time
+
+	// pending is set while a write is scheduled.
+	pending bool
+}
+
+// serviceStatusWriter writes the sync status of the upstreams of each Service to the annotations of the Service, e.g.
+// nginxinc.io/last-synced, so it is shown by kubectl describe. The annotations of a Service are written at most once
+// per StatusAnnotationInterval, with a merge patch that leaves the other annotations alone.
+type serviceStatusWriter struct {
+	client   kubernetes.Interface
+	settings *configuration.Settings
+
+	// lock guards the statuses and forbidden.
+	lock sync.Mutex
+
+	// statuses holds the status of each Service, keyed by namespace/name.
+	statuses map[string]*serviceStatus
+
+	// forbidden is set once a write has been forbidden, so the missing permission is reported once until a write succeeds.
+	forbidden bool
+}
+
+// newServiceStatusWriter creates a new serviceStatusWriter.
+func newServiceStatusWriter(client kubernetes.Interface, settings *configuration.Settings) *serviceStatusWriter {
+	return &serviceStatusWriter{
+		client:   client,
+		settings: settings,
+		statuses: make(map[string]*serviceStatus),
+	}
+}
+
+// synced records that the upstream of the Service has been synced to the hosts, with the servers, and schedules a write.
+func (w *serviceStatusWriter) synced(service *corev1.Service, upstream string, hosts int, servers int) {
+	if w == nil || service == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	status := w.statusOf(service)
+	status.upstreams[upstream] = &upstreamStatus{hosts: hosts, servers: servers}
+	status.lastSynced = time.Now()
+
+	w.schedule(status)
+}
+
+// failed records that the upstream of the Service did not converge on some hosts, and schedules a write;
+// the hosts and servers of the last successful sync are kept.
+func (w *serviceStatusWriter) failed(service *corev1.Service, upstream string, err string) {
+	if w == nil || service == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	status := w.statusOf(service)
+	if _, found := status.upstreams[upstream]; !found {
+		status.upstreams[upstream] = &upstreamStatus{}
+	}
+	status.upstreams[upstream].err = err
+
+	w.schedule(status)
+}
+
+// removed forgets the upstream of the Service, e.g. once the Service is ignored, and schedules a write.
+func (w *serviceStatusWriter) removed(service *corev1.Service, upstream string) {
+	if w == nil || service == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	status, found := w.statuses[serviceKey(service.Namespace, service.Name)]
+	if !found {
+		return
+	}
+
+	if _, found = status.upstreams[upstream]; !found {
+		return
+	}
+
+	delete(status.upstreams, upstream)
+
+	w.schedule(status)
+}
+
+// statusOf returns the status of the Service, creating it if needed. lock must be held.
+func (w *serviceStatusWriter) statusOf(service *corev1.Service) *serviceStatus {
+	key := serviceKey(service.Namespace, service.Name)
+
+	status, found := w.statuses[key]
+	if !found {
+		status = &serviceStatus{namespace: service.Namespace, name: service.Name, upstreams: make(map[string]*upstreamStatus)}
+		w.statuses[key] = status
+	}
+
+	return status
+}
+
+// schedule writes the annotations of the Service once StatusAnnotationInterval has elapsed since the last write;
+// the changes made while a write is pending are included in it. lock must be held.
+func (w *serviceStatusWriter) schedule(status *serviceStatus) {
+	if status.pending {
+		return
+	}
+
+	status.pending = true
+
+	delay := w.settings.Synchronizer.StatusAnnotationInterval - time.Since(status.written)
+	if delay < 0 {
+		delay = 0
+	}
+
+	time.AfterFunc(delay, func() { w.write(status) })
+}
+
+// write patches the annotations of the Service with its status. A Service that no longer exists is forgotten.
+func (w *serviceStatusWriter) write(status *serviceStatus) {
+	w.lock.Lock()
+	status.pending = false
+	status.written = time.Now()
+	patch, err := statusAnnotationsPatch(status)
+	w.lock.Unlock()
+
+	if err != nil {
+		logrus.WithError(err).Warn(`serviceStatusWriter::write: error occurred building the status annotations`)
+		return
+	}
+
+	if w.settings.IsDryRun() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.settings.Context, w.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	fields := logrus.Fields{"namespace": status.namespace, "service": status.name}
+
+	_, err = w.client.CoreV1().Services(status.namespace).Patch(ctx, status.name, types.MergePatchType, patch, metav1.PatchOptions{})
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	switch {
+	case err == nil:
+		w.forbidden = false
+		logrus.WithFields(fields).Debug(`serviceStatusWriter::write: wrote the status annotations`)
+
+	case apierrors.IsNotFound(err):
+		if w.statuses[serviceKey(status.namespace, status.name)] == status {
+			delete(w.statuses, serviceKey(status.namespace, status.name))
+		}
+
+	case apierrors.IsForbidden(err):
+		if w.forbidden {
+			logrus.WithFields(fields).WithError(err).Debug(`serviceStatusWriter::write: the status annotations are still forbidden`)
+			return
+		}
+
+		w.forbidden = true
+		logrus.WithFields(fields).WithError(err).
+			Warnf(`serviceStatusWriter::write: NLK is not allowed to patch Services, grant the patch verb on services or set %s=0s to disable the status annotations`, configuration.StatusAnnotationIntervalEnv)
+
+	default:
+		logrus.WithFields(fields).WithError(err).Warn(`serviceStatusWriter::write: error occurred writing the status annotations`)
+	}
+}
+
+// statusAnnotationsPatch returns the merge patch setting the status annotations of the Service, the annotations of an
+// upstream-less Service, e.g. one that is now ignored, are removed. lock must be held.
+func statusAnnotationsPatch(status *serviceStatus) ([]byte, error) {
+	annotations := map[string]*string{
+		statusAnnotation(configuration.LastSyncedAnnotation):  nil,
+		statusAnnotation(configuration.SyncedHostsAnnotation): nil,
+		statusAnnotation(configuration.ServerCountAnnotation): nil,
+		statusAnnotation(configuration.SyncErrorAnnotation):   nil,
+	}
+
+	if len(status.upstreams) > 0 {
+		names := make([]string, 0, len(status.upstreams))
+		for name := range status.upstreams {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var hosts, servers, errs []string
+		for _, name := range names {
+			upstream := status.upstreams[name]
+			hosts = append(hosts, fmt.Sprintf("%s=%d", name, upstream.hosts))
+			servers = append(servers, fmt.Sprintf("%s=%d", name, upstream.servers))
+
+			if upstream.err != "" {
+				errs = append(errs, fmt.Sprintf("%s: %s", name, upstream.err))
+			}
+		}
+
+		syncedHosts := strings.Join(hosts, ",")
+		serverCount := strings.Join(servers, ",")
+		annotations[statusAnnotation(configuration.SyncedHostsAnnotation)] = &syncedHosts
+		annotations[statusAnnotation(configuration.ServerCountAnnotation)] = &serverCount
+
+		if !status.lastSynced.IsZero() {
+			lastSynced := status.lastSynced.UTC().Format(time.RFC3339)
+			annotations[statusAnnotation(configuration.LastSyncedAnnotation)] = &lastSynced
+		}
+
+		if len(errs) > 0 {
+			syncError := truncate(strings.Join(errs, "; "), maxSyncErrorLength)
+			annotations[statusAnnotation(configuration.SyncErrorAnnotation)] = &syncError
+		}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+}
+
+// statusAnnotation returns the key of the status annotation with the suffix.
+func statusAnnotation(suffix string) string {
+	return fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, suffix)
+}
+
+// serviceKey returns the key of a Service in the statuses.
+func serviceKey(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+// truncate shortens the value to at most length bytes.
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return value[:length-3] + "..."
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+const (
+
+	// ProtocolHttp identifies the upstreams of the NGINX Plus http context in a Snapshot.
+	ProtocolHttp = "http"
+
+	// ProtocolStream identifies the upstreams of the NGINX Plus stream context, TCP and UDP, in a Snapshot.
+	ProtocolStream = "stream"
+)
+
+// Snapshot is the state of the Synchronizer, as served by the debug endpoint. It is built from the state the
+// Synchronizer keeps in memory and from the informer caches, the NGINX Plus API is not called.
+type Snapshot struct {
+
+	// NginxPlusHosts are the NGINX Plus hosts the upstreams are synced to.
+	NginxPlusHosts []string `json:"nginxPlusHosts"`
+
+	// Hosts holds the circuit breaker state of each NGINX Plus host, sorted by host.
+	Hosts []HostSnapshot `json:"hosts"`
+
+	// Upstreams are the upstreams that are desired or have been synced, sorted by protocol and name.
+	Upstreams []UpstreamSnapshot `json:"upstreams"`
+
+	// DesiredStateError explains why the desired servers are not available, e.g. until the informers have synced.
+	DesiredStateError string `json:"desiredStateError,omitempty"`
+
+	// DeferredDeletes are the deletions of the Services held by the Handler in case they are recreated, sorted by Service.
+	DeferredDeletes []DeferredDeleteSnapshot `json:"deferredDeletes,omitempty"`
+}
+
+// DeferredDeleteSnapshot is the deletion of a Service held by the Handler in a Snapshot, see HandlerSettings::DeleteDeferral.
+type DeferredDeleteSnapshot struct {
+
+	// Service is the deleted Service, by namespace/name.
+	Service string `json:"service"`
+
+	// HeldAt is when the deletion was received.
+	HeldAt time.Time `json:"heldAt"`
+
+	// DeleteAt is when the servers of the Service are deleted, unless it is recreated first.
+	DeleteAt time.Time `json:"deleteAt"`
+}
+
+// HostSnapshot is the circuit breaker state of an NGINX Plus host in a Snapshot.
+type HostSnapshot struct {
+
+	// Host is the NGINX Plus host.
+	Host string `json:"host"`
+
+	// Secondary is true for a secondary host, whose failures do not affect the readiness.
+	Secondary bool `json:"secondary,omitempty"`
+
+	// CircuitOpen is true while the host is skipped, until a probe succeeds.
+	CircuitOpen bool `json:"circuitOpen"`
+
+	// ConsecutiveFailures is the number of syncs and probes of the host that failed in a row.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+
+	// NextProbe is when the host is next probed, while the circuit is open.
+	NextProbe *time.Time `json:"nextProbe,omitempty"`
+
+	// Reachable is the outcome of the connectivity probe of the host, nil until the host has been probed.
+	Reachable *bool `json:"reachable,omitempty"`
+
+	// Version is the NGINX Plus version reported by the host when it was probed.
+	Version string `json:"version,omitempty"`
+
+	// Diagnosis explains why the host could not be reached when it was probed, e.g. the TLS handshake failed.
+	Diagnosis string `json:"diagnosis,omitempty"`
+}
+
+// UpstreamSnapshot is the state of an upstream in a Snapshot.
+type UpstreamSnapshot struct {
+
+	// Name is the name of the upstream.
+	Name string `json:"name"`
+
+	// Protocol is either ProtocolHttp or ProtocolStream.
+	Protocol string `json:"protocol"`
+
+	// Desired are the servers the upstream should have, sorted, nil when the desired state is not available.
+	Desired []string `json:"desired"`
+
+	// Hosts holds the state of the upstream on each NGINX Plus host it has been synced to, sorted by host.
+	Hosts []UpstreamHostSnapshot `json:"hosts"`
+}
+
+// UpstreamHostSnapshot is the state of an upstream on an NGINX Plus host in a Snapshot.
+type UpstreamHostSnapshot struct {
+
+	// Host is the NGINX Plus host.
+	Host string `json:"host"`
+
+	// Applied are the servers last applied to the upstream, sorted, nil when they are not known, e.g. after a failure.
+	Applied []string `json:"applied"`
+
+	// Down are the servers applied to the upstream marked down, as their nodes are, sorted.
+	Down []string `json:"down,omitempty"`
+
+	// LastSync is the time of the last sync of the upstream to the host, successful or not.
+	LastSync *time.Time `json:"lastSync,omitempty"`
+
+	// LastSuccess is the time of the last successful sync of the upstream to the host.
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+
+	// LastError is the error of the last sync, empty when it succeeded.
+	LastError string `json:"lastError,omitempty"`
+
+	// NextRetry is when the failed sync is retried, with the jittered exponential backoff of the retries.
+	NextRetry *time.Time `json:"nextRetry,omitempty"`
+
+	// ResourceVersion is the resourceVersion of the Service last applied to the upstream, the events of older versions are dropped.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// HealthCheckNodePort is the healthCheckNodePort of the Service, when its externalTrafficPolicy is Local and the health
+	// checks of the upstream have been inspected.
+	HealthCheckNodePort int32 `json:"healthCheckNodePort,omitempty"`
+
+	// HealthChecked is whether NGINX Plus health checks the servers of the upstream, nil when it has not been inspected.
+	HealthChecked *bool `json:"healthChecked,omitempty"`
+}
+
+// syncStatus is the outcome of the syncs of an upstream to a host.
+type syncStatus struct {
+	lastSync    time.Time
+	lastSuccess time.Time
+	lastError   string
+	nextRetry   time.Time
+}
+
+// syncStatuses records the outcome of the syncs of each upstream to each host, for the Snapshot.
+type syncStatuses struct {
+
+	// lock guards statuses, the statuses are recorded by the Synchronizer workers.
+	lock sync.Mutex
+
+	statuses map[appliedKey]syncStatus
+}
+
+// newSyncStatuses creates a new, empty syncStatuses.
+func newSyncStatuses() *syncStatuses {
+	return &syncStatuses{
+		statuses: make(map[appliedKey]syncStatus),
+	}
+}
+
+// record records the outcome of the sync of the event to its host.
+func (s *syncStatuses) record(event *core.ServerUpdateEvent, at time.Time, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := keyOf(event)
+	status := s.statuses[key]
+	status.lastSync = at
+	status.nextRetry = time.Time{}
+
+	if err == nil {
+		status.lastSuccess = at
+		status.lastError = ``
+	} else {
+		status.lastError = err.Error()
+	}
+
+	s.statuses[key] = status
+}
+
+// scheduleRetry records when the sync of the event to each of the hosts is retried, the zero time once it is no longer retried.
+func (s *syncStatuses) scheduleRetry(event *core.ServerUpdateEvent, hosts []string, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, host := range hosts {
+		key := keyOf(core.ServerUpdateEventWithIdAndHost(event, event.Id, host))
+		if status, found := s.statuses[key]; found {
+			status.nextRetry = at
+			s.statuses[key] = status
+		}
+	}
+}
+
+// copy returns a copy of the statuses.
+func (s *syncStatuses) copy() map[appliedKey]syncStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	statuses := make(map[appliedKey]syncStatus, len(s.statuses))
+	for key, status := range s.statuses {
+		statuses[key] = status
+	}
+
+	return statuses
+}
+
+// copy returns a copy of the servers last applied to each upstream.
+func (c *appliedCache) copy() map[appliedKey][]core.UpstreamServer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	servers := make(map[appliedKey][]core.UpstreamServer, len(c.servers))
+	for key, applied := range c.servers {
+		servers[key] = append([]core.UpstreamServer{}, applied...)
+	}
+
+	return servers
+}
+
+// snapshotKey identifies an upstream in a Snapshot, the UDP and TCP upstreams share the stream context.
+type snapshotKey struct {
+	protocol string
+	upstream string
+}
+
+// Snapshot returns the hosts and their circuit breaker state, the desired servers of each upstream, and the servers last applied to each upstream on
+// each host, along with the time and error of the last sync. It is safe to call while the Synchronizer is running.
+// The passwords of the hosts are redacted, see configuration.RedactHost, including in the errors naming them.
+func (s *Synchronizer) Snapshot() *Snapshot {
+	nginxPlusHosts := s.settings.Hosts()
+	snapshot := &Snapshot{
+		NginxPlusHosts: []string{},
+		Hosts:          []HostSnapshot{},
+		Upstreams:      []UpstreamSnapshot{},
+	}
+
+	circuits := s.circuitBreaker.copy()
+	reachabilities := s.hostReachabilities.copy()
+	for _, host := range nginxPlusHosts {
+		snapshot.NginxPlusHosts = append(snapshot.NginxPlusHosts, configuration.RedactHost(host))
+
+		hostCircuit := circuits[host]
+		hostSnapshot := HostSnapshot{
+			Host:                configuration.RedactHost(host),
+			Secondary:           s.settings.IsSecondaryHost(host),
+			CircuitOpen:         hostCircuit.open,
+			ConsecutiveFailures: hostCircuit.consecutiveFailures,
+			NextProbe:           timeOrNil(hostCircuit.probeAt),
+		}
+
+		if reachability, probed := reachabilities[host]; probed {
+			hostSnapshot.Reachable = &reachability.reachable
+			hostSnapshot.Version = reachability.version
+			hostSnapshot.Diagnosis = configuration.RedactHostsIn(reachability.diagnosis, nginxPlusHosts)
+		}
+
+		snapshot.Hosts = append(snapshot.Hosts, hostSnapshot)
+	}
+
+	sort.Slice(snapshot.Hosts, func(i, j int) bool { return snapshot.Hosts[i].Host < snapshot.Hosts[j].Host })
+
+	upstreams := make(map[snapshotKey]*UpstreamSnapshot)
+	upstreamOf := func(key snapshotKey) *UpstreamSnapshot {
+		if upstreams[key] == nil {
+			upstreams[key] = &UpstreamSnapshot{Name: key.upstream, Protocol: key.protocol, Hosts: []UpstreamHostSnapshot{}}
+		}
+
+		return upstreams[key]
+	}
+
+	if s.desiredStateSource == nil {
+		snapshot.DesiredStateError = `the desired state is not available`
+	} else if desiredState, err := s.desiredStateSource(); err != nil {
+		snapshot.DesiredStateError = err.Error()
+	} else {
+		for name, servers := range desiredState.HttpUpstreams {
+			upstreamOf(snapshotKey{ProtocolHttp, name}).Desired = sortedKeys(servers)
+		}
+
+		for name, servers := range desiredState.StreamUpstreams {
+			upstreamOf(snapshotKey{ProtocolStream, name}).Desired = sortedKeys(servers)
+		}
+	}
+
+	hosts := make(map[appliedKey]*UpstreamHostSnapshot)
+	hostOf := func(key appliedKey) *UpstreamHostSnapshot {
+		if hosts[key] == nil {
+			hosts[key] = &UpstreamHostSnapshot{Host: configuration.RedactHost(key.host)}
+		}
+
+		return hosts[key]
+	}
+
+	for key, servers := range s.appliedCache.copy() {
+		applied := make([]string, 0, len(servers))
+		var down []string
+		for _, server := range servers {
+			applied = append(applied, server.Host)
+			if server.IsDown() {
+				down = append(down, server.Host)
+			}
+		}
+
+		hostOf(key).Applied = applied
+		hostOf(key).Down = down
+	}
+
+	for key, status := range s.syncStatuses.copy() {
+		host := hostOf(key)
+		host.LastSync = timeOrNil(status.lastSync)
+		host.LastSuccess = timeOrNil(status.lastSuccess)
+		host.LastError = configuration.RedactHostsIn(status.lastError, append([]string{key.host}, nginxPlusHosts...))
+		host.NextRetry = timeOrNil(status.nextRetry)
+	}
+
+	for key, version := range s.appliedVersions.copy() {
+		hostOf(key).ResourceVersion = strconv.FormatUint(version, 10)
+	}
+
+	for key, status := range s.healthChecks.copy() {
+		host := hostOf(key)
+		host.HealthCheckNodePort = status.healthCheckNodePort
+		host.HealthChecked = &status.checked
+	}
+
+	for key, host := range hosts {
+		upstream := upstreamOf(snapshotKey{protocolOf(key.clientType), key.upstream})
+		upstream.Hosts = append(upstream.Hosts, *host)
+	}
+
+	for _, upstream := range upstreams {
+		sort.Slice(upstream.Hosts, func(i, j int) bool { return upstream.Hosts[i].Host < upstream.Hosts[j].Host })
+		snapshot.Upstreams = append(snapshot.Upstreams, *upstream)
+	}
+
+	sort.Slice(snapshot.Upstreams, func(i, j int) bool {
+		if snapshot.Upstreams[i].Protocol != snapshot.Upstreams[j].Protocol {
+			return snapshot.Upstreams[i].Protocol < snapshot.Upstreams[j].Protocol
+		}
+
+		return snapshot.Upstreams[i].Name < snapshot.Upstreams[j].Name
+	})
+
+	return snapshot
+}
+
+// protocolOf returns the protocol of the upstreams updated by the client type.
+func protocolOf(clientType string) string {
+	if clientType == application.ClientTypeNginxHttp {
+		return ProtocolHttp
+	}
+
+	return ProtocolStream
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
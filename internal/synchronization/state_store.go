@@ -0,0 +1,306 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+
+	// stateDataKey is the key of the persisted desired state in the data of the state ConfigMap.
+	stateDataKey = "state.json"
+
+	// stateRecoveryInterval is how often the desired state is checked for availability at startup, so that it can be
+	// compared with the persisted state once the informers have synced.
+	stateRecoveryInterval = time.Second
+)
+
+// persistedState is the desired state persisted in the state ConfigMap: the servers of each upstream managed by NLK.
+type persistedState struct {
+
+	// HttpUpstreams holds the servers of each HTTP upstream, keyed by upstream name.
+	HttpUpstreams map[string][]string `json:"httpUpstreams"`
+
+	// StreamUpstreams holds the servers of each stream upstream, keyed by upstream name.
+	StreamUpstreams map[string][]string `json:"streamUpstreams"`
+
+	// Owned holds the servers NLK has applied to each upstream of each host while an OwnershipTag is set, see serverOwnership.
+	Owned []persistedOwnership `json:"owned,omitempty"`
+}
+
+// newPersistedState creates the persistedState of the desired state, with the servers of each upstream sorted.
+func newPersistedState(desiredState *core.DesiredState) *persistedState {
+	state := &persistedState{
+		HttpUpstreams:   make(map[string][]string, len(desiredState.HttpUpstreams)),
+		StreamUpstreams: make(map[string][]string, len(desiredState.StreamUpstreams)),
+	}
+
+	for name, servers := range desiredState.HttpUpstreams {
+		state.HttpUpstreams[name] = sortedKeys(servers)
+	}
+
+	for name, servers := range desiredState.StreamUpstreams {
+		state.StreamUpstreams[name] = sortedKeys(servers)
+	}
+
+	return state
+}
+
+// stateStore defines the functions needed to persist the desired state.
+type stateStore interface {
+
+	// load returns the persisted state, nil when no state has been persisted.
+	load(ctx context.Context) (*persistedState, error)
+
+	// save persists the state, replacing the previous one.
+	save(ctx context.Context, state *persistedState) error
+}
+
+// configMapStateStore persists the desired state in a ConfigMap.
+type configMapStateStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// newConfigMapStateStore creates a new configMapStateStore for the named ConfigMap, which is created on the first save.
+func newConfigMapStateStore(client kubernetes.Interface, namespace string, name string) *configMapStateStore {
+	return &configMapStateStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// load returns the state held by the ConfigMap, nil when the ConfigMap or its key do not exist.
+func (c *configMapStateStore) load(ctx context.Context) (*persistedState, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(`error occurred retrieving the %s/%s ConfigMap: %w`, c.namespace, c.name, err)
+	}
+
+	data, found := configMap.Data[stateDataKey]
+	if !found {
+		return nil, nil
+	}
+
+	state := &persistedState{}
+	if err = json.Unmarshal([]byte(data), state); err != nil {
+		return nil, fmt.Errorf(`error occurred parsing the %s key of the %s/%s ConfigMap: %w`, stateDataKey, c.namespace, c.name, err)
+	}
+
+	return state, nil
+}
+
+// save writes the state to the ConfigMap, creating it if needed.
+func (c *configMapStateStore) save(ctx context.Context, state *persistedState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf(`error occurred serializing the state: %w`, err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: c.name, Namespace: c.namespace},
+		Data:       map[string]string{stateDataKey: string(data)},
+	}
+
+	configMaps := c.client.CoreV1().ConfigMaps(c.namespace)
+
+	_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	}
+
+	if err != nil {
+		return fmt.Errorf(`error occurred writing the %s/%s ConfigMap: %w`, c.namespace, c.name, err)
+	}
+
+	return nil
+}
+
+// requestStatePersist requests that the desired state be persisted after StatePersistDebounce; it never blocks,
+// a request made while one is pending is merged into it.
+func (s *Synchronizer) requestStatePersist() {
+	if s.stateStore == nil {
+		return
+	}
+
+	select {
+	case s.statePersistRequests <- struct{}{}:
+	default:
+	}
+}
+
+// persistState recovers the deletions missed while NLK was down, then persists the desired state StatePersistDebounce
+// after each request until the stop signal. A failed write is retried after the debounce, it never delays the syncs.
+func (s *Synchronizer) persistState(stopCh <-chan struct{}) {
+	if !s.recoverStateEvery(stateRecoveryInterval, stopCh) {
+		return
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-s.statePersistRequests:
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(s.settings.Synchronizer.StatePersistDebounce):
+		}
+
+		// the requests made during the debounce are satisfied by this write
+		select {
+		case <-s.statePersistRequests:
+		default:
+		}
+
+		if err := s.writeState(); err != nil {
+			logrus.WithError(err).Warn(`Synchronizer::persistState: error occurred persisting the desired state, retrying`)
+			s.requestStatePersist()
+		}
+	}
+}
+
+// recoverStateEvery runs recoverState each time the interval elapses until the desired state is available, and returns
+// false if the stop signal came first. The desired state is only persisted once the persisted state has been recovered, so that the
+// record of the servers whose deletion was missed is not overwritten.
+func (s *Synchronizer) recoverStateEvery(interval time.Duration, stopCh <-chan struct{}) bool {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.recoverState()
+		if err == nil {
+			return true
+		}
+
+		logrus.WithError(err).Debug(`Synchronizer::recoverStateEvery: the persisted state has not been recovered yet`)
+
+		select {
+		case <-stopCh:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverState compares the persisted state with the desired state, and queues a Deleted event on each host for every
+// persisted server that is no longer desired, e.g. the servers of a node deleted while NLK was down. An error is only
+// returned while the desired state is not available; a persisted state that cannot be loaded is reported and skipped,
+// so that the persistence is not blocked by it.
+func (s *Synchronizer) recoverState() error {
+	if s.desiredStateSource == nil {
+		return fmt.Errorf(`the desired state is not available`)
+	}
+
+	desiredState, err := s.desiredStateSource()
+	if err != nil {
+		return err
+	}
+
+	persisted, err := s.stateStore.load(s.settings.Context)
+	if err != nil {
+		logrus.WithError(err).Warn(`Synchronizer::recoverState: error occurred loading the persisted state, the deletions missed while NLK was down will not be recovered`)
+	}
+
+	var events []*core.ServerUpdateEvent
+	if persisted != nil {
+		s.serverOwnership.restore(persisted.Owned)
+		events = staleServers(s.settings.Hosts(), persisted, desiredState)
+	}
+
+	logrus.Infof(`Synchronizer::recoverState: deleting %d server(s) that are no longer desired since the state was persisted`, len(events))
+
+	for _, event := range events {
+		s.AddEvent(event)
+	}
+
+	// the persisted state is kept until a sync succeeds, so the record of the servers to delete survives another restart
+	if len(events) == 0 {
+		s.requestStatePersist()
+	}
+
+	return nil
+}
+
+// writeState persists the desired state; nothing is written in dry-run mode, as nothing has been applied.
+func (s *Synchronizer) writeState() error {
+	if s.settings.IsDryRun() {
+		return nil
+	}
+
+	desiredState, err := s.desiredStateSource()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(s.settings.Context, s.settings.HttpClient.RequestTimeout)
+	defer cancel()
+
+	state := newPersistedState(desiredState)
+	state.Owned = s.serverOwnership.persisted()
+
+	if err = s.stateStore.save(ctx, state); err != nil {
+		return err
+	}
+
+	logrus.Debug(`Synchronizer::writeState: persisted the desired state`)
+
+	return nil
+}
+
+// staleServers returns a Deleted event on each host for every server of the persisted state that is not desired, the
+// servers of the withheld upstreams are kept.
+func staleServers(hosts []string, persisted *persistedState, desiredState *core.DesiredState) []*core.ServerUpdateEvent {
+	var events []*core.ServerUpdateEvent
+
+	for _, host := range hosts {
+		for name, servers := range persisted.HttpUpstreams {
+			if desiredState.WithheldUpstreams[name] {
+				continue
+			}
+
+			for _, server := range servers {
+				if !desiredState.HttpUpstreams[name][server] {
+					events = append(events, deletionEvent(`recover`, host, name, application.ClientTypeNginxHttp, server))
+				}
+			}
+		}
+
+		for name, servers := range persisted.StreamUpstreams {
+			if desiredState.WithheldUpstreams[name] {
+				continue
+			}
+
+			for _, server := range servers {
+				if !desiredState.StreamUpstreams[name][server] {
+					events = append(events, deletionEvent(`recover`, host, name, application.ClientTypeNginxStream, server))
+				}
+			}
+		}
+	}
+
+	return events
+}
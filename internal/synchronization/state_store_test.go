@@ -0,0 +1,125 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapStateStore_LoadsNothingBeforeTheFirstSave(t *testing.T) {
+	store := newConfigMapStateStore(fake.NewSimpleClientset(), "nlk", "nlk-state")
+
+	state, err := store.load(context.Background())
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if state != nil {
+		t.Fatalf(`expected no state, got %v`, state)
+	}
+}
+
+func TestConfigMapStateStore_LoadsTheSavedState(t *testing.T) {
+	store := newConfigMapStateStore(fake.NewSimpleClientset(), "nlk", "nlk-state")
+
+	desiredState := buildDesiredState("10.0.0.1:30080", "10.0.0.2:30080")
+	if err := store.save(context.Background(), newPersistedState(desiredState)); err != nil {
+		t.Fatalf(`should have been no error creating the ConfigMap, %v`, err)
+	}
+
+	desiredState = buildDesiredState("10.0.0.1:30080")
+	if err := store.save(context.Background(), newPersistedState(desiredState)); err != nil {
+		t.Fatalf(`should have been no error updating the ConfigMap, %v`, err)
+	}
+
+	state, err := store.load(context.Background())
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	servers := state.HttpUpstreams["nlk-upstream"]
+	if len(servers) != 1 || servers[0] != "10.0.0.1:30080" {
+		t.Fatalf(`expected the last saved servers, got %v`, state.HttpUpstreams)
+	}
+}
+
+func TestSynchronizer_RecoversTheDeletionsMissedWhileDown(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.K8sClient = fake.NewSimpleClientset()
+	})
+
+	persisted := newPersistedState(buildDesiredState("10.0.0.1:30080", "10.0.0.2:30080"))
+	persisted.StreamUpstreams["nlk-deleted-service"] = []string{"10.0.0.1:30443"}
+
+	if err := synchronizer.stateStore.save(context.Background(), persisted); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	if err := synchronizer.recoverState(); err == nil {
+		t.Fatal(`expected an error until the desired state is available`)
+	}
+
+	synchronizer.SetDesiredStateSource(func() (*core.DesiredState, error) {
+		return buildDesiredState("10.0.0.1:30080"), nil
+	})
+
+	if err := synchronizer.recoverState(); err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	// the server of the deleted node, and the server of the deleted Service, on each host
+	if rateLimiter.Len() != 4 {
+		t.Fatalf(`expected 4 deletions, got %d`, rateLimiter.Len())
+	}
+
+	for rateLimiter.Len() > 0 {
+		item, _ := rateLimiter.Get()
+		event := item.(*syncEvent).event
+		if event.Type != core.Deleted || len(event.UpstreamServers) != 1 || event.UpstreamServers[0].Host == "10.0.0.1:30080" {
+			t.Errorf(`expected the deletion of a server that is no longer desired, got %v %v`, event.Type, event.UpstreamServers)
+		}
+	}
+}
+
+func TestSynchronizer_PersistsOnlyWithAKubernetesClient(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, nil, nil)
+
+	if synchronizer.stateStore != nil {
+		t.Fatal(`expected the persistence to be disabled without a Kubernetes client`)
+	}
+
+	// requests never block, even when nothing persists them
+	synchronizer.requestStatePersist()
+	synchronizer.requestStatePersist()
+}
+
+func TestStaleServers_KeepsTheServersOfTheWithheldUpstreams(t *testing.T) {
+	persisted := newPersistedState(buildDesiredState("10.0.0.1:30080"))
+
+	// the upstream is left without servers while its policy retains them
+	desiredState := core.NewDesiredState()
+	desiredState.WithheldUpstreams["nlk-upstream"] = true
+
+	if events := staleServers([]string{"https://localhost:8080"}, persisted, desiredState); len(events) != 0 {
+		t.Fatalf(`expected the servers of the withheld upstream to be kept, got %d deletions`, len(events))
+	}
+}
+
+func buildDesiredState(servers ...string) *core.DesiredState {
+	desiredState := core.NewDesiredState()
+	desiredState.HttpUpstreams["nlk-upstream"] = make(map[string]bool)
+
+	for _, server := range servers {
+		desiredState.HttpUpstreams["nlk-upstream"][server] = true
+	}
+
+	return desiredState
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package synchronization
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+)
+
+// syncEvent is the item placed on the "nlk-synchronizer" queue. It carries a ServerUpdateEvent along with the
+// retry bookkeeping: the hosts that have yet to converge, the number of attempts made, and the last error per host.
+// When only some hosts fail, the event is requeued with just those hosts so hosts that succeeded are not updated again.
+type syncEvent struct {
+
+	// event is the update to apply to each of the pending hosts.
+	event *core.ServerUpdateEvent
+
+	// pendingHosts are the NGINX Plus hosts that have not yet been updated successfully.
+	pendingHosts []string
+
+	// hostCount is the number of hosts the event was queued for.
+	hostCount int
+
+	// attempts is the number of sync cycles that have been made for this event, not counting the cycles in which
+	// only missing upstreams failed.
+	attempts int
+
+	// missingUpstreamAttempts is the number of sync cycles in which only missing upstreams failed, see withRetry.
+	missingUpstreamAttempts int
+
+	// lastErrors holds the most recent error for each host that failed.
+	lastErrors map[string]error
+
+	// started is set once the event has been taken from the queue, see coalescer.
+	started bool
+
+	// superseded is set once a newer event for the upstream makes this event obsolete, see coalescer.
+	superseded bool
+
+	// queuedAt is when the event was last added to the queue, to trace the time it waited before being synced.
+	queuedAt time.Time
+
+	// removedServers are the servers deleted while the event was being applied, see coalescer.
+	removedServers core.UpstreamServers
+
+	// reportedMissingUpstreams records the hosts for which the missing upstream has been reported, so it is reported once.
+	reportedMissingUpstreams map[string]bool
+}
+
+// newSyncEvent creates a new syncEvent for the given hosts.
+func newSyncEvent(event *core.ServerUpdateEvent, hosts []string) *syncEvent {
+	return &syncEvent{
+		event:                    event,
+		pendingHosts:             append([]string{}, hosts...),
+		hostCount:                len(hosts),
+		lastErrors:               make(map[string]error),
+		reportedMissingUpstreams: make(map[string]bool),
+	}
+}
+
+// dropRemovedHosts removes the pending hosts that are no longer configured, and returns them; a retry for a host that
+// has been removed from nginx-hosts would never succeed.
+func (e *syncEvent) dropRemovedHosts(configured []string) []string {
+	var pendingHosts, removed []string
+	for _, host := range e.pendingHosts {
+		if slices.Contains(configured, host) {
+			pendingHosts = append(pendingHosts, host)
+		} else {
+			removed = append(removed, host)
+			delete(e.lastErrors, host)
+		}
+	}
+
+	e.pendingHosts = pendingHosts
+
+	return removed
+}
+
+// failedHosts returns the hosts that failed, sorted.
+func (e *syncEvent) failedHosts() []string {
+	hosts := make([]string, 0, len(e.lastErrors))
+	for host := range e.lastErrors {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// describeFailures formats the failed hosts and their last errors for logging.
+func (e *syncEvent) describeFailures() string {
+	hosts := e.failedHosts()
+
+	descriptions := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", host, describeError(e.lastErrors[host])))
+	}
+
+	return strings.Join(descriptions, "; ")
+}
@@ -6,17 +6,32 @@
 package synchronization
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/communication"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/workqueue"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// shutdownFlushTimeout bounds the NGINX Plus API calls made for the events flushed as NLK shuts down, see FlushEvents,
+// within the default termination grace period of a Pod.
+const shutdownFlushTimeout = time.Second * 10
+
 // Interface defines the interface needed to implement a synchronizer.
 type Interface interface {
 
@@ -26,6 +41,9 @@ type Interface interface {
 	// AddEvent adds an event to the queue.
 	AddEvent(event *core.ServerUpdateEvent)
 
+	// FlushEvents applies a list of events at once, as NLK shuts down.
+	FlushEvents(events core.ServerUpdateEvents)
+
 	// Run starts the synchronizer.
 	Run(stopCh <-chan struct{})
 
@@ -38,168 +56,684 @@ type Interface interface {
 // Service annotation for the Upstream. see application/border_client.go and application/application_constants.go for details.
 type Synchronizer struct {
 	eventQueue workqueue.RateLimitingInterface
+	httpClient *http.Client
 	settings   *configuration.Settings
+
+	// borderClientFactory creates the Border Client for an event, defaults to buildBorderClient.
+	borderClientFactory func(*core.ServerUpdateEvent) (application.Interface, error)
+
+	// upstreamListerFactory creates the client used to list the upstreams of a host when pruning, defaults to buildUpstreamLister.
+	upstreamListerFactory func(string) (upstreamLister, error)
+
+	// appliedCache records the servers last applied to each upstream, used to skip the syncs that would change nothing.
+	appliedCache *appliedCache
+
+	// serverOwnership records the servers NLK has applied to each upstream while an OwnershipTag is set, see withOwnership.
+	serverOwnership *serverOwnership
+
+	// hostStagger delays the updates of each host after NLK starts, or the host is added, see HostStagger.
+	hostStagger *hostStagger
+
+	// appliedVersions records the resourceVersion of the Service last applied to each upstream, the events translated
+	// from an older version of the Service are dropped.
+	appliedVersions *appliedVersions
+
+	// coalescer merges the events queued for the same upstream.
+	coalescer *coalescer
+
+	// backlog tracks the events waiting in the event queue, once it is full the Deleted events are coalesced too, see coalescer.
+	backlog *instrumentation.QueueBacklog
+
+	// retryBackoff computes the jittered delays of the retries of the events, instead of the rate limiter of the eventQueue.
+	retryBackoff *retryBackoff
+
+	// syncStatuses records the time and error of the last sync of each upstream to each host, see Snapshot.
+	syncStatuses *syncStatuses
+
+	// errorLog logs the failed syncs, suppressing the repeats of the same error, see SynchronizerSettings::ErrorLogWindow.
+	errorLog *errorLog
+
+	// healthChecks records whether the upstreams of the Services with the Local externalTrafficPolicy are health checked, see Snapshot.
+	healthChecks *healthCheckStatuses
+
+	// parkedEvents records the events whose upstream is still missing once their retries are used up, see releaseParkedEvents.
+	parkedEvents *parkedEvents
+
+	// drainingUpstreams records the upstreams whose servers drain, so their active connections are checked, see confirmDrains.
+	drainingUpstreams *drainingUpstreams
+
+	// drainConfirmer is notified of the addresses whose draining servers have no active connection left, see SetDrainConfirmer.
+	drainConfirmer func(addresses []string)
+
+	// desiredStateSource provides the desired state of the upstreams when pruning, see SetDesiredStateSource.
+	desiredStateSource func() (*core.DesiredState, error)
+
+	// unsupportedApiVersions records the hosts whose API version has been reported as unsupported, so each is reported once.
+	unsupportedApiVersions map[string]bool
+
+	// unsupportedApiVersionsLock guards unsupportedApiVersions, the hosts are synced concurrently.
+	unsupportedApiVersionsLock sync.Mutex
+
+	// circuitBreaker skips the hosts that have failed too many times in a row, see circuitBreaker.
+	circuitBreaker *circuitBreaker
+
+	// hostSyncs bounds the events applied to the hosts at once, by all the workers together, to SynchronizerSettings::Threads, see syncHosts.
+	hostSyncs chan struct{}
+
+	// hostProber calls a host whose circuit is open to determine whether it has recovered, defaults to probeHost.
+	hostProber func(string) error
+
+	// serverAdmissions tracks the new servers held back until their NodePort answers, see admitServers.
+	serverAdmissions *serverAdmissions
+
+	// serverProber probes a new server of an upstream before it is added, defaults to probeServer.
+	serverProber func(context.Context, string, string) error
+
+	// hostReachabilities records the outcome of the connectivity probe of each host, see probeConnectivity.
+	hostReachabilities *hostReachabilities
+
+	// reachabilityProber probes the connectivity of a host when the hosts are set or changed, defaults to probeReachability.
+	reachabilityProber func(string) hostReachability
+
+	// borderServers creates the Border Clients, and pings the hosts, of the border type, see SynchronizerSettings::BorderType.
+	borderServers application.BorderServers
+
+	// resyncer pushes the servers of every upstream to a recovered host, see SetResyncer.
+	resyncer func()
+
+	// stateStore persists the desired state, so the deletions missed while NLK was down are recovered; nil disables the persistence.
+	stateStore stateStore
+
+	// statePersistRequests holds a pending request to persist the desired state, see requestStatePersist.
+	statePersistRequests chan struct{}
+
+	// serviceStatus writes the sync status of the upstreams to the annotations of their Service; nil disables the annotations.
+	serviceStatus *serviceStatusWriter
+
+	// loadBalancerStatus writes the load balancer ingress IPs to the status of the synced Services; nil disables the status.
+	loadBalancerStatus *loadBalancerStatusWriter
+
+	// controllerStatus writes the summary of the health of NLK to the status ConfigMap; nil disables the ConfigMap.
+	controllerStatus *controllerStatusWriter
+
+	// dnsService writes the DNS Service with the IPs of the hosts, and deletes the DNS Services no longer configured.
+	dnsService *dnsServiceWriter
+
+	// leaderIdentity is the identity of the replica reported in the status ConfigMap, see SetLeaderIdentity.
+	leaderIdentity string
+
+	// lastReconcile holds the time the last reconciliation completed, see reconcile.
+	lastReconcile atomic.Value
 }
 
 // NewSynchronizer creates a new Synchronizer.
+// A single HTTP client is shared by all Border Clients so that connections and TLS configuration are reused;
+// the client rebuilds its TLS configuration when the TLS settings change.
 func NewSynchronizer(settings *configuration.Settings, eventQueue workqueue.RateLimitingInterface) (*Synchronizer, error) {
+	httpClient, err := communication.NewHttpClient(settings)
+	if err != nil {
+		return nil, fmt.Errorf(`error creating HTTP client: %v`, err)
+	}
+
+	workQueueSettings := settings.Synchronizer.WorkQueueSettings
+
 	synchronizer := Synchronizer{
-		eventQueue: eventQueue,
-		settings:   settings,
+		eventQueue:             eventQueue,
+		httpClient:             httpClient,
+		settings:               settings,
+		appliedCache:           newAppliedCache(),
+		serverOwnership:        newServerOwnership(),
+		appliedVersions:        newAppliedVersions(),
+		hostStagger:            newHostStagger(),
+		coalescer:              newCoalescer(),
+		backlog:                instrumentation.NewQueueBacklog(workQueueSettings.Name, workQueueSettings.MaxDepth, workQueueSettings.DegradedAge),
+		retryBackoff:           newRetryBackoff(workQueueSettings),
+		syncStatuses:           newSyncStatuses(),
+		healthChecks:           newHealthCheckStatuses(),
+		errorLog:               newErrorLog(settings.Synchronizer.ErrorLogWindow),
+		drainingUpstreams:      newDrainingUpstreams(),
+		parkedEvents:           newParkedEvents(),
+		unsupportedApiVersions: make(map[string]bool),
+		circuitBreaker:         newCircuitBreaker(),
+		hostSyncs:              make(chan struct{}, max(settings.Synchronizer.Threads, 1)),
+		hostReachabilities:     newHostReachabilities(),
+		statePersistRequests:   make(chan struct{}, 1),
+	}
+
+	if synchronizer.isNginxPlus() {
+		synchronizer.borderServers = &nginxPlusBorderServers{synchronizer: &synchronizer}
+	} else if synchronizer.borderServers, err = application.NewBorderServers(settings.Synchronizer.BorderType, httpClient); err != nil {
+		return nil, fmt.Errorf(`error creating the %s border servers: %w`, settings.Synchronizer.BorderType, err)
 	}
 
+	if settings.Synchronizer.PersistState && settings.K8sClient != nil {
+		synchronizer.stateStore = newConfigMapStateStore(settings.K8sClient, settings.ConfigMapNamespace, settings.Synchronizer.StateConfigMapName)
+	}
+
+	if settings.Synchronizer.StatusAnnotationInterval > 0 && settings.K8sClient != nil {
+		synchronizer.serviceStatus = newServiceStatusWriter(settings.K8sClient, settings)
+	}
+
+	// the ingress IPs may be set later, by the ConfigMap or the annotation of a Service; the writer writes none until then
+	if settings.K8sClient != nil {
+		synchronizer.loadBalancerStatus = newLoadBalancerStatusWriter(settings.K8sClient, settings)
+	}
+
+	if settings.Synchronizer.StatusConfigMapInterval > 0 && settings.K8sClient != nil {
+		synchronizer.controllerStatus = newControllerStatusWriter(settings.K8sClient, settings.ConfigMapNamespace, settings.Synchronizer.StatusConfigMapName)
+	}
+
+	if settings.K8sClient != nil {
+		synchronizer.dnsService = newDnsServiceWriter(settings.K8sClient, settings)
+	}
+
+	synchronizer.borderClientFactory = synchronizer.buildBorderClient
+	synchronizer.upstreamListerFactory = synchronizer.buildUpstreamLister
+	synchronizer.hostProber = synchronizer.probeHost
+	synchronizer.serverAdmissions = newServerAdmissions()
+	synchronizer.serverProber = probeServer
+	synchronizer.reachabilityProber = synchronizer.probeReachability
+
+	settings.SubscribeToHostChanges(synchronizer.handleHostChanges)
+
 	return &synchronizer, nil
 }
 
+// handleHostChanges is notified when the list of hosts changes. The hosts that were added are probed, and receive the
+// servers of every upstream, as the events queued before they were added only target the previous hosts. The state kept
+// for the hosts that were removed is dropped, and their pending retries are dropped when they are taken from the queue.
+// The DNS Service is written with the IPs of the new hosts.
+func (s *Synchronizer) handleHostChanges(added []string, removed []string) {
+	go s.dnsService.write()
+
+	for _, host := range removed {
+		s.appliedCache.invalidateHost(host)
+		s.appliedVersions.forgetHost(host)
+		s.parkedEvents.forgetHost(host)
+		instrumentation.ForgetHost(host)
+	}
+
+	s.circuitBreaker.forget(s.settings.Hosts())
+	s.hostReachabilities.forget(s.settings.Hosts())
+	s.hostStagger.forget(s.settings.Hosts())
+
+	if len(added) == 0 {
+		return
+	}
+
+	s.hostStagger.stagger(added, s.settings.Synchronizer.HostStagger)
+
+	go s.probeConnectivity(added)
+
+	logrus.WithField("hosts", added).Info(`Synchronizer::handleHostChanges: pushing the servers of every upstream to the added host(s)`)
+
+	if s.resyncer != nil {
+		s.resyncer()
+	}
+}
+
 // AddEvents adds a list of events to the queue. If no hosts are specified this is a null operation.
-// Events will fan out to the number of hosts specified before being added to the queue.
+// Each event is queued once and fans out to all the hosts specified when it is handled; an event for an upstream
+// that already has an update waiting in the queue is merged into it, see coalescer.
 func (s *Synchronizer) AddEvents(events core.ServerUpdateEvents) {
 	logrus.Debugf(`Synchronizer::AddEvents adding %d events`, len(events))
 
-	if len(s.settings.NginxPlusHosts) == 0 {
+	hosts := s.settings.Hosts()
+	if len(hosts) == 0 {
 		logrus.Warnf(`No Nginx Plus hosts were specified. Skipping synchronization.`)
 		return
 	}
 
-	updatedEvents := s.fanOutEventToHosts(events)
+	for eidx, event := range events {
+		id := fmt.Sprintf(`[%d]-[%s]-[%s]`, eidx, RandomString(12), event.UpstreamName)
+		syncEvent := newSyncEvent(core.ServerUpdateEventWithIdAndHost(event, id, ``), hosts)
+
+		full := s.backlog.Full()
+		if !s.coalescer.add(syncEvent, full) {
+			logrus.WithFields(syncEvent.event.LogFields()).Debug(`Synchronizer::AddEvents: merged into the pending event of the upstream`)
+			if full {
+				s.backlog.Coalesced()
+			}
 
-	for _, event := range updatedEvents {
-		s.AddEvent(event)
+			continue
+		}
+
+		// updates wait for the coalesce window, so that the changes that follow within the window are merged into them
+		if event.Type == core.Deleted {
+			s.addSyncEvent(syncEvent)
+		} else {
+			s.addSyncEventAfter(syncEvent, s.settings.Synchronizer.CoalesceWindow)
+		}
 	}
 }
 
-// AddEvent adds an event to the queue. If no hosts are specified this is a null operation.
-// Events will be added to the queue after a random delay between MinMillisecondsJitter and MaxMillisecondsJitter.
+// AddEvent adds an event for a single host to the queue. If no host is specified this is a null operation.
 func (s *Synchronizer) AddEvent(event *core.ServerUpdateEvent) {
-	logrus.Debugf(`Synchronizer::AddEvent: %#v`, event)
+	logrus.WithFields(event.LogFields()).Debug(`Synchronizer::AddEvent`)
 
 	if event.NginxHost == `` {
 		logrus.Warnf(`Nginx host was not specified. Skipping synchronization.`)
 		return
 	}
 
+	s.addSyncEvent(newSyncEvent(event, []string{event.NginxHost}))
+}
+
+// addSyncEvent adds a syncEvent to the queue after a random delay between MinMillisecondsJitter and MaxMillisecondsJitter.
+func (s *Synchronizer) addSyncEvent(event *syncEvent) {
+	s.addSyncEventAfter(event, 0)
+}
+
+// addSyncEventAfter adds a syncEvent to the queue after the delay, plus a random delay between MinMillisecondsJitter and MaxMillisecondsJitter.
+func (s *Synchronizer) addSyncEventAfter(event *syncEvent, delay time.Duration) {
 	after := RandomMilliseconds(s.settings.Synchronizer.MinMillisecondsJitter, s.settings.Synchronizer.MaxMillisecondsJitter)
-	s.eventQueue.AddAfter(event, after)
+	event.queuedAt = time.Now()
+	s.backlog.Queued(event, delay+after)
+	s.eventQueue.AddAfter(event, delay+after)
 }
 
-// Run starts the Synchronizer, spins up Goroutines to process events, and waits for a stop signal.
+// Run starts the Synchronizer, probes the connectivity of the hosts, spins up Goroutines to process events, to prune orphaned
+// servers and detect the drift of the upstreams every ReconcileInterval, to probe the hosts whose circuit is open, to persist the desired state, and to write the status
+// ConfigMap every StatusConfigMapInterval, writes the DNS Service, and waits for a stop signal.
 func (s *Synchronizer) Run(stopCh <-chan struct{}) {
 	logrus.Debug(`Synchronizer::Run`)
 
+	go s.probeConnectivity(s.settings.Hosts())
+	go s.dnsService.write()
+
+	// the full sync of the Services that follows the start is spread across the hosts
+	s.hostStagger.stagger(s.settings.Hosts(), s.settings.Synchronizer.HostStagger)
+
 	for i := 0; i < s.settings.Synchronizer.Threads; i++ {
 		go wait.Until(s.worker, 0, stopCh)
 	}
 
+	go s.reconcileEvery(s.settings.Synchronizer.ReconcileInterval, stopCh)
+	go wait.Until(s.probeOpenCircuits, circuitProbeInterval, stopCh)
+	go wait.Until(s.confirmDrains, drainCheckInterval, stopCh)
+	go wait.Until(s.errorLog.flush, errorLogFlushInterval, stopCh)
+	go wait.Until(s.backlog.Observe, instrumentation.BacklogObserveInterval, stopCh)
+
+	if s.stateStore != nil {
+		go s.persistState(stopCh)
+	}
+
+	if s.controllerStatus != nil {
+		go wait.Until(s.writeControllerStatus, s.settings.Synchronizer.StatusConfigMapInterval, stopCh)
+	}
+
 	<-stopCh
 }
 
+// Degradation returns why the replica is degraded, e.g. the oldest event has waited in the queue for too long as the
+// NGINX Plus hosts are slow, or an empty string.
+func (s *Synchronizer) Degradation() string {
+	return s.backlog.Degradation()
+}
+
+// HttpClient returns the HTTP client shared by the Border Clients, for the other calls to the NGINX Plus hosts to reuse
+// its connections.
+func (s *Synchronizer) HttpClient() *http.Client {
+	return s.httpClient
+}
+
+// FlushEvents applies the events to each host at once, rather than queueing them, as NLK shuts down: the event queue
+// drops the events delayed by the jitter once it is shut down, and the calls made for the queued events are aborted at
+// shutdown. The calls are aborted once the shutdownFlushTimeout has elapsed instead; the servers left in place then
+// are left to the pruning of the orphaned servers once NLK is back, see prune.go.
+func (s *Synchronizer) FlushEvents(events core.ServerUpdateEvents) {
+	logrus.Debugf(`Synchronizer::FlushEvents flushing %d events`, len(events))
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.settings.Context), shutdownFlushTimeout)
+	defer cancel()
+
+	for eidx, event := range events {
+		for hidx, host := range s.settings.Hosts() {
+			id := fmt.Sprintf(`[%d]-[%s]-[%s]-[%d]-[%s]`, eidx, RandomString(12), event.UpstreamName, hidx, host)
+			hostEvent := core.ServerUpdateEventWithIdAndHost(event, id, host)
+
+			if err := s.handleEventWithin(ctx, hostEvent); err != nil {
+				logrus.WithFields(hostEvent.LogFields()).Warnf(`Synchronizer::FlushEvents: the servers are left in place: %v`, err)
+			}
+		}
+	}
+}
+
 // ShutDown stops the Synchronizer and shuts down the event queue
 func (s *Synchronizer) ShutDown() {
 	logrus.Debugf(`Synchronizer::ShutDown`)
 	s.eventQueue.ShutDownWithDrain()
 }
 
-// buildBorderClient creates a Border Client for the specified event.
-// NOTE: There is an open issue (https://github.com/nginxinc/nginx-loadbalancer-kubernetes/issues/36) to move creation
-// of the underlying Border Server client to the NewBorderClient function.
+// buildBorderClient creates a Border Client for the specified event, with the BorderServers of the border type.
+// In dry-run mode the changes are logged rather than applied, and are treated as successful.
 func (s *Synchronizer) buildBorderClient(event *core.ServerUpdateEvent) (application.Interface, error) {
 	logrus.Debugf(`Synchronizer::buildBorderClient`)
 
-	var err error
+	// the NGINX Plus hosts compute the changes from their current servers, see nginxPlusBorderServers
+	if s.settings.IsDryRun() && !s.isNginxPlus() {
+		return application.NewDryRunBorderClient(event.NginxHost), nil
+	}
+
+	return s.borderServers.BorderClient(event.NginxHost, event.ClientType)
+}
+
+// isNginxPlus determines whether the upstreams are synced to NGINX Plus hosts, which support the pruning, the keyval
+// zones, and the inspection of the health checks, rather than to another kind of Border Server.
+func (s *Synchronizer) isNginxPlus() bool {
+	return s.settings.Synchronizer.BorderType == core.BorderTypeNginxPlus
+}
+
+// nginxPlusHost returns the parsed nginx-hosts entry of the host, parsing it only when it has been removed from the Settings
+// in the meantime, e.g. to delete its servers.
+func (s *Synchronizer) nginxPlusHost(host string) (configuration.NginxPlusHost, error) {
+	if nginxPlusHost, found := s.settings.NginxPlusHost(host); found {
+		return nginxPlusHost, nil
+	}
+
+	return configuration.ParseNginxPlusHost(host)
+}
 
-	httpClient, err := communication.NewHttpClient(s.settings)
+// buildNginxClient creates the NGINX Plus client of the host, for the API base URL and version of its nginx-hosts entry.
+// A version the NGINX Plus client does not support is reported as an ErrUnsupportedApiVersion.
+func (s *Synchronizer) buildNginxClient(host string) (*nginxClient.NginxClient, error) {
+	nginxPlusHost, err := s.nginxPlusHost(host)
 	if err != nil {
-		return nil, fmt.Errorf(`error creating HTTP client: %v`, err)
+		return nil, fmt.Errorf(`error parsing the Nginx Plus host: %w`, err)
 	}
 
-	opts := nginxClient.WithHTTPClient(httpClient)
+	opts := []nginxClient.Option{nginxClient.WithHTTPClient(s.httpClient)}
+	if nginxPlusHost.ApiVersion != 0 {
+		opts = append(opts, nginxClient.WithAPIVersion(nginxPlusHost.ApiVersion))
+	}
+
+	ngxClient, err := nginxClient.NewNginxClient(nginxPlusHost.Endpoint, opts...)
+	if errors.Is(err, nginxClient.ErrNotSupported) {
+		return nil, fmt.Errorf(`error creating Nginx Plus client: %w: %w`, application.ErrUnsupportedApiVersion, err)
+	}
 
-	ngxClient, err := nginxClient.NewNginxClient(event.NginxHost, opts)
 	if err != nil {
 		return nil, fmt.Errorf(`error creating Nginx Plus client: %v`, err)
 	}
 
-	return application.NewBorderClient(event.ClientType, ngxClient)
+	return ngxClient, nil
 }
 
-// fanOutEventToHosts takes a list of events and returns a list of events, one for each Border Server.
-func (s *Synchronizer) fanOutEventToHosts(event core.ServerUpdateEvents) core.ServerUpdateEvents {
-	logrus.Debugf(`Synchronizer::fanOutEventToHosts: %#v`, event)
+// syncHosts applies the event to each of the pending hosts concurrently; the hosts of the events of all the workers share
+// the SynchronizerSettings::Threads slots of hostSyncs, so there are no more than Threads calls in flight.
+// The hosts that failed are returned along with their errors. The hosts whose circuit is open are skipped, they receive
+// the servers of every upstream once they recover.
+func (s *Synchronizer) syncHosts(event *syncEvent) map[string]error {
+	logrus.WithFields(event.event.LogFields()).WithField("hosts", event.pendingHosts).Debug(`Synchronizer::syncHosts`)
 
-	var events core.ServerUpdateEvents
+	var lock sync.Mutex
+	var group sync.WaitGroup
 
-	for hidx, host := range s.settings.NginxPlusHosts {
-		for eidx, event := range event {
-			id := fmt.Sprintf(`[%d:%d]-[%s]-[%s]-[%s]`, hidx, eidx, RandomString(12), event.UpstreamName, host)
-			updatedEvent := core.ServerUpdateEventWithIdAndHost(event, id, host)
+	failures := make(map[string]error)
 
-			events = append(events, updatedEvent)
+	for hidx, host := range event.pendingHosts {
+		// the Deleted events are not delayed, e.g. the servers of a removed node
+		if wait := s.hostStagger.wait(host); wait > 0 && event.event.Type != core.Deleted {
+			logrus.WithFields(event.event.LogFields()).WithField("host", host).Debugf(`Synchronizer::syncHosts: delayed for %v, the updates of the host are staggered`, wait)
+			lock.Lock()
+			failures[host] = errHostStaggered
+			lock.Unlock()
+			continue
 		}
+
+		if s.circuitBreaker.isOpen(host) {
+			instrumentation.ObserveSyncCircuitOpen(host, event.event.UpstreamName)
+			logrus.WithFields(event.event.LogFields()).WithField("host", host).Debug(`Synchronizer::syncHosts: skipped, the circuit of the host is open`)
+			continue
+		}
+
+		group.Add(1)
+		s.hostSyncs <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-s.hostSyncs }()
+
+			id := fmt.Sprintf(`%s-[%d]-[%s]`, event.event.Id, hidx, host)
+			if err := s.handleEvent(core.ServerUpdateEventWithIdAndHost(event.event, id, host)); err != nil {
+				lock.Lock()
+				failures[host] = err
+				lock.Unlock()
+			}
+		}()
 	}
 
-	return events
+	group.Wait()
+
+	return failures
 }
 
-// handleEvent dispatches an event to the proper handler function.
+// handleEvent dispatches an event to the proper handler function and records the outcome in the sync metrics.
 func (s *Synchronizer) handleEvent(event *core.ServerUpdateEvent) error {
-	logrus.Debugf(`Synchronizer::handleEvent: Id: %s`, event.Id)
+	return s.handleEventWithin(s.settings.Context, event)
+}
+
+// withSite returns a copy of the event with the site of its host, so the site is logged with the updates to the host,
+// or the event itself when the host sets no site.
+func (s *Synchronizer) withSite(event *core.ServerUpdateEvent) *core.ServerUpdateEvent {
+	nginxPlusHost, found := s.settings.NginxPlusHost(event.NginxHost)
+	if !found || nginxPlusHost.Site == "" || nginxPlusHost.Site == event.Site {
+		return event
+	}
+
+	sited := *event
+	sited.Site = nginxPlusHost.Site
+
+	return &sited
+}
+
+// handleEventWithin handles an event like handleEvent, the NGINX Plus API calls being aborted once the parent context is
+// done rather than at shutdown, see FlushEvents.
+func (s *Synchronizer) handleEventWithin(parent context.Context, event *core.ServerUpdateEvent) error {
+	event = s.withSite(event)
+	logrus.WithFields(event.LogFields()).Debug(`Synchronizer::handleEvent`)
+
+	// the port of the servers to delete is resolved on the host, then each server is deleted on its own
+	if event.AnyPort {
+		return s.handleAnyPortEvent(parent, event)
+	}
+
+	// the events of the upstream on the host are applied one at a time, so they are applied in the order of their versions
+	defer s.appliedVersions.lockUpstream(event)()
 
 	var err error
 
+	// the NGINX Plus API calls made for the upstream are aborted once the UpstreamTimeout has elapsed, or at shutdown
+	ctx, cancel := context.WithTimeout(parent, s.settings.Synchronizer.UpstreamTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// an event delivered out of order, or retried once a newer one has been applied, would revert the upstream servers
+	if applied, stale := s.appliedVersions.stale(event); stale {
+		instrumentation.ObserveSyncStale(event.NginxHost, event.UpstreamName)
+		logrus.WithFields(event.LogFields()).Warnf(`Synchronizer::handleEvent: dropped, the event stems from the resourceVersion %s of the Service, the resourceVersion %d has been applied`, event.ResourceVersion, applied)
+		return nil
+	}
+
 	switch event.Type {
 	case core.Created:
 		fallthrough
 
 	case core.Updated:
-		err = s.handleCreatedUpdatedEvent(event)
+		instrumentation.ObserveDesiredServers(event.UpstreamName, len(event.UpstreamServers))
+
+		// the servers whose NodePort does not answer yet are held back, the remainder may be what is already applied
+		event = s.admitServers(event)
+
+		// a resync is applied even when unchanged, the servers of the host may have changed since they were applied
+		if !event.Resync && s.appliedCache.unchanged(s.settings.Hosts(), event) {
+			instrumentation.ObserveSyncSkipped(event.NginxHost, event.UpstreamName)
+			instrumentation.ObserveUpstreamServers(event.NginxHost, event.UpstreamName, len(event.UpstreamServers))
+			s.appliedVersions.store(event)
+			logrus.WithFields(event.LogFields()).Debug(`Synchronizer::handleEvent: skipped, the upstream servers are unchanged`)
+			return nil
+		}
+
+		err = s.handleCreatedUpdatedEvent(ctx, event)
 
 	case core.Deleted:
-		err = s.handleDeletedEvent(event)
+		err = s.handleDeletedEvent(ctx, event)
+
+	default:
+		logrus.WithFields(event.LogFields()).Warnf(`Synchronizer::handleEvent: unknown event type: %d`, event.Type)
+		return nil
+	}
+
+	// the calls aborted at shutdown tell nothing about the host
+	if err != nil && parent.Err() != nil {
+		return fmt.Errorf(`the sync was aborted at shutdown: %w`, err)
+	}
+
+	instrumentation.ObserveSync(event.NginxHost, event.UpstreamName, start, err)
+	s.syncStatuses.record(event, start, err)
 
+	if errors.Is(err, application.ErrTimeout) {
+		instrumentation.ObserveSyncTimeout(event.NginxHost, event.UpstreamName)
+		logrus.WithFields(event.LogFields()).Warnf(`Synchronizer::handleEvent: the NGINX Plus API calls did not complete within %v, the update is retried`, s.settings.Synchronizer.UpstreamTimeout)
+	}
+
+	s.recordHostOutcome(event.NginxHost, err)
+
+	if errors.Is(err, application.ErrUnsupportedApiVersion) {
+		s.reportUnsupportedApiVersion(event.NginxHost, err)
+	}
+
+	// the servers are only known to be applied after a successful update; in dry-run mode nothing has been applied
+	remaining, known := 0, false
+	switch {
+	case err == nil && s.settings.IsDryRun():
+		s.appliedCache.invalidate(event)
+	case err == nil && event.Type == core.Deleted:
+		remaining, known = s.appliedCache.deleted(event)
+	case err == nil:
+		s.appliedCache.store(s.settings.Hosts(), event)
+		s.drainingUpstreams.record(event)
 	default:
-		logrus.Warnf(`Synchronizer::handleEvent: unknown event type: %d`, event.Type)
+		s.appliedCache.invalidate(event)
+	}
+
+	if err == nil && !s.settings.IsDryRun() {
+		s.observeMembership(event, remaining, known)
+		s.recordOwnership(event)
+		s.appliedVersions.store(event)
+		s.observeConvergence(event, time.Now())
 	}
 
 	if err == nil {
-		logrus.Infof(`Synchronizer::handleEvent: successfully %s the nginx+ host(s) for Upstream: %s: Id(%s)`, event.TypeName(), event.UpstreamName, event.Id)
+		s.errorLog.succeeded(event)
+		logrus.WithFields(event.LogFields()).Info(`Synchronizer::handleEvent: successfully updated the nginx+ host`)
+		return nil
 	}
 
+	err = s.withDiagnosis(event.NginxHost, err)
+	s.errorLog.failed(event, err)
+
 	return err
 }
 
+// observeMembership exports the number of servers an applied event programmed in its upstream on its host. A Deleted event
+// removes a single server, the servers remaining in the upstream are observed while they are known, see
+// appliedCache::deleted; the servers of an upstream no longer desired are no longer managed, so they are dropped rather
+// than left at their last value.
+func (s *Synchronizer) observeMembership(event *core.ServerUpdateEvent, remaining int, known bool) {
+	if event.Type == core.Deleted {
+		switch {
+		case known:
+			instrumentation.ObserveUpstreamServers(event.NginxHost, event.UpstreamName, remaining)
+		case !s.upstreamDesired(event):
+			instrumentation.ForgetUpstreamServers(event.NginxHost, event.UpstreamName)
+			instrumentation.ForgetDesiredServers(event.UpstreamName)
+		default:
+			instrumentation.ObserveUpstreamServers(event.NginxHost, event.UpstreamName, 0)
+		}
+
+		return
+	}
+
+	instrumentation.ObserveUpstreamServers(event.NginxHost, event.UpstreamName, len(event.UpstreamServers))
+}
+
+// upstreamDesired determines whether the upstream of the event is in the desired state; false when the desired state is
+// not available.
+func (s *Synchronizer) upstreamDesired(event *core.ServerUpdateEvent) bool {
+	if s.desiredStateSource == nil {
+		return false
+	}
+
+	desiredState, err := s.desiredStateSource()
+	if err != nil {
+		return false
+	}
+
+	if protocolOf(event.ClientType) == ProtocolHttp {
+		_, found := desiredState.HttpUpstreams[event.UpstreamName]
+		return found
+	}
+
+	_, found := desiredState.StreamUpstreams[event.UpstreamName]
+	return found
+}
+
 // handleCreatedUpdatedEvent handles events of type Created or Updated.
-func (s *Synchronizer) handleCreatedUpdatedEvent(serverUpdateEvent *core.ServerUpdateEvent) error {
-	logrus.Debugf(`Synchronizer::handleCreatedUpdatedEvent: Id: %s`, serverUpdateEvent.Id)
+func (s *Synchronizer) handleCreatedUpdatedEvent(ctx context.Context, serverUpdateEvent *core.ServerUpdateEvent) error {
+	logrus.WithFields(serverUpdateEvent.LogFields()).Debug(`Synchronizer::handleCreatedUpdatedEvent`)
 
 	var err error
 
-	borderClient, err := s.buildBorderClient(serverUpdateEvent)
+	borderClient, err := s.borderClientFactory(serverUpdateEvent)
 	if err != nil {
 		return fmt.Errorf(`error occurred creating the border client: %w`, err)
 	}
 
-	if err = borderClient.Update(serverUpdateEvent); err != nil {
+	if err = borderClient.Update(ctx, s.withOwnership(serverUpdateEvent)); err != nil {
 		return fmt.Errorf(`error occurred updating the %s upstream servers: %w`, serverUpdateEvent.ClientType, err)
 	}
 
+	if updater, ok := borderClient.(application.KeyValUpdater); ok && serverUpdateEvent.KeyValZone != nil {
+		if err = updater.UpdateKeyVals(ctx, serverUpdateEvent); err != nil {
+			return fmt.Errorf(`error occurred updating the %s keyval zone: %w`, serverUpdateEvent.KeyValZone.Name, err)
+		}
+	}
+
+	s.inspectHealthChecks(ctx, borderClient, serverUpdateEvent)
+
 	return nil
 }
 
 // handleDeletedEvent handles events of type Deleted.
-func (s *Synchronizer) handleDeletedEvent(serverUpdateEvent *core.ServerUpdateEvent) error {
-	logrus.Debugf(`Synchronizer::handleDeletedEvent: Id: %s`, serverUpdateEvent.Id)
+func (s *Synchronizer) handleDeletedEvent(ctx context.Context, serverUpdateEvent *core.ServerUpdateEvent) error {
+	logrus.WithFields(serverUpdateEvent.LogFields()).Debug(`Synchronizer::handleDeletedEvent`)
 
 	var err error
 
-	borderClient, err := s.buildBorderClient(serverUpdateEvent)
+	borderClient, err := s.borderClientFactory(serverUpdateEvent)
 	if err != nil {
 		return fmt.Errorf(`error occurred creating the border client: %w`, err)
 	}
 
-	if err = borderClient.Delete(serverUpdateEvent); err != nil {
+	// the server cannot be in an upstream that is not defined, so there is nothing to delete
+	err = borderClient.Delete(ctx, s.withOwnership(serverUpdateEvent))
+	if errors.Is(err, application.ErrUpstreamNotFound) {
+		logrus.WithFields(serverUpdateEvent.LogFields()).Info(`Synchronizer::handleDeletedEvent: the upstream is not defined, there is nothing to delete`)
+		err = nil
+	}
+
+	if err != nil {
 		return fmt.Errorf(`error occurred deleting the %s upstream servers: %w`, serverUpdateEvent.ClientType, err)
 	}
 
+	if updater, ok := borderClient.(application.KeyValUpdater); ok && serverUpdateEvent.KeyValZone != nil {
+		if err = updater.DeleteKeyVals(ctx, serverUpdateEvent); err != nil {
+			return fmt.Errorf(`error occurred deleting from the %s keyval zone: %w`, serverUpdateEvent.KeyValZone.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -214,12 +748,40 @@ func (s *Synchronizer) handleNextEvent() bool {
 
 	defer s.eventQueue.Done(evt)
 
-	event := evt.(*core.ServerUpdateEvent)
-	s.withRetry(s.handleEvent(event), event)
+	event := evt.(*syncEvent)
+	s.backlog.Taken(event)
+	instrumentation.RecordQueueWait(event.event.SpanContext, "Synchronizer::queue", event.queuedAt,
+		instrumentation.UpstreamAttribute.String(event.event.UpstreamName))
+
+	if !s.coalescer.start(event) {
+		logrus.WithFields(event.event.LogFields()).Debug(`Synchronizer::handleNextEvent: skipped, superseded by a newer event for the upstream`)
+		s.forget(event)
+		s.coalescer.done(event)
+		return true
+	}
+
+	if removed := event.dropRemovedHosts(s.settings.Hosts()); len(removed) > 0 {
+		logrus.WithFields(event.event.LogFields()).WithField("hosts", removed).Info(`Synchronizer::handleNextEvent: skipped the host(s) that are no longer configured`)
+	}
+
+	if len(event.pendingHosts) == 0 || s.withholdEmptyServers(event) {
+		s.forget(event)
+		s.coalescer.done(event)
+		return true
+	}
+
+	s.withRetry(s.syncHosts(event), event)
 
 	return true
 }
 
+// forget resets the backoff of the retries of the event, once it has succeeded, been dropped, or is no longer retried.
+func (s *Synchronizer) forget(event *syncEvent) {
+	s.eventQueue.Forget(event)
+	s.retryBackoff.Forget(event)
+	s.syncStatuses.scheduleRetry(event.event, event.pendingHosts, time.Time{})
+}
+
 // worker is the main message loop
 func (s *Synchronizer) worker() {
 	logrus.Debug(`Synchronizer::worker`)
@@ -227,19 +789,281 @@ func (s *Synchronizer) worker() {
 	}
 }
 
-// withRetry handles errors from the event handler and requeues events that fail
-func (s *Synchronizer) withRetry(err error, event *core.ServerUpdateEvent) {
+// withRetry records the outcome of a sync cycle and requeues the event for the hosts that failed.
+// Once RetryCount attempts have been made the event is dropped and the hosts that never converged are logged.
+// When the only failures are upstreams missing from the NGINX Plus configuration, which retrying soon will not fix,
+// they are reported once and the event is retried every MissingUpstreamRetryInterval without counting against the RetryCount;
+// once it has been retried RetryCount times this way, it is parked until a reconciliation finds the upstream, see releaseParkedEvents;
+// when the only failures are hosts whose updates are staggered, the event is requeued once the first of them is ready.
+// The hosts whose failure is permanent, see application.IsPermanent, are reported and not retried.
+func (s *Synchronizer) withRetry(failures map[string]error, event *syncEvent) {
 	logrus.Debug("Synchronizer::withRetry")
-	if err != nil {
-		// TODO: Add Telemetry
-		if s.eventQueue.NumRequeues(event) < s.settings.Synchronizer.RetryCount { // TODO: Make this configurable
-			s.eventQueue.AddRateLimited(event)
-			logrus.Infof(`Synchronizer::withRetry: requeued event: %s; error: %v`, event.Id, err)
+
+	succeeded := len(event.pendingHosts) - len(failures)
+	rejected := s.rejectPermanentFailures(failures, event)
+
+	missingUpstreams := onlyMissingUpstreams(failures)
+	staggered := onlyStaggered(failures)
+	if !missingUpstreams && !staggered {
+		event.attempts++
+	}
+
+	if missingUpstreams {
+		event.missingUpstreamAttempts++
+	}
+
+	var pendingHosts []string
+	for _, host := range event.pendingHosts {
+		if _, failed := failures[host]; failed {
+			pendingHosts = append(pendingHosts, host)
 		} else {
-			s.eventQueue.Forget(event)
-			logrus.Warnf(`Synchronizer::withRetry: event %#v has been dropped due to too many retries`, event)
+			delete(event.lastErrors, host)
+			s.parkedEvents.unpark(event.event, host)
+		}
+	}
+
+	for host, err := range failures {
+		event.lastErrors[host] = err
+	}
+
+	if len(pendingHosts) == 0 {
+		logrus.WithFields(event.event.LogFields()).Infof(`Synchronizer::withRetry: %d host(s) succeeded, %d rejected, attempt %d`, succeeded, rejected, event.attempts)
+		s.forget(event)
+		s.coalescer.done(event)
+		if rejected == 0 {
+			s.recordSynced(event)
+		}
+		s.requestStatePersist()
+		return
+	}
+
+	// the errors of the hosts are logged by the errorLog, without their repeats
+	if !missingUpstreams && !staggered {
+		logrus.WithFields(event.event.LogFields()).WithField("failures", event.describeFailures()).
+			Debugf(`Synchronizer::withRetry: %d host(s) succeeded, %d failed, attempt %d`, succeeded, len(pendingHosts), event.attempts)
+	}
+
+	event.pendingHosts = pendingHosts
+
+	if !s.coalescer.requeue(event) {
+		s.forget(event)
+		s.coalescer.done(event)
+		logrus.WithFields(event.event.LogFields()).Info(`Synchronizer::withRetry: not requeued, superseded by a newer event for the upstream`)
+	} else if missingUpstreams && event.missingUpstreamAttempts > s.settings.Synchronizer.RetryCount {
+		s.forget(event)
+		s.coalescer.done(event)
+		s.parkedEvents.park(event.event, pendingHosts)
+		logrus.WithFields(event.event.LogFields()).WithField("hosts", pendingHosts).
+			Warn(`Synchronizer::withRetry: the upstream is still not defined, parked until a reconciliation finds it`)
+	} else if missingUpstreams {
+		s.reportMissingUpstreams(event)
+		event.queuedAt = time.Now()
+		s.backlog.Queued(event, s.settings.Synchronizer.MissingUpstreamRetryInterval)
+		s.eventQueue.AddAfter(event, s.settings.Synchronizer.MissingUpstreamRetryInterval)
+	} else if staggered {
+		delay := s.hostStagger.earliest(pendingHosts)
+		event.queuedAt = time.Now()
+		s.backlog.Queued(event, delay)
+		s.eventQueue.AddAfter(event, delay)
+	} else if event.attempts < s.settings.Synchronizer.RetryCount {
+		delay := s.retryBackoff.When(event)
+		event.queuedAt = time.Now()
+		s.backlog.Queued(event, delay)
+		s.eventQueue.AddAfter(event, delay)
+		logrus.WithFields(event.event.LogFields()).WithField("hosts", event.pendingHosts).Info(`Synchronizer::withRetry: requeued event`)
+		s.syncStatuses.scheduleRetry(event.event, event.pendingHosts, event.queuedAt.Add(delay))
+		logrus.WithFields(event.event.LogFields()).Debugf(`Synchronizer::withRetry: retry %d in %v, at %s`,
+			s.retryBackoff.NumRequeues(event), delay, event.queuedAt.Add(delay).Format(time.RFC3339Nano))
+	} else {
+		s.forget(event)
+		s.coalescer.done(event)
+		s.reportDroppedEvent(event)
+	}
+}
+
+// reportDroppedEvent reports the hosts that never converged once the event has been dropped. A failure of a primary host
+// is an error; a failure of a secondary host is a warning, counted in the secondary sync failures metric.
+func (s *Synchronizer) reportDroppedEvent(event *syncEvent) {
+	var primaryHosts, secondaryHosts []string
+	for _, host := range event.failedHosts() {
+		if s.settings.IsSecondaryHost(host) {
+			secondaryHosts = append(secondaryHosts, host)
+			instrumentation.ObserveSecondarySyncFailure(host, event.event.UpstreamName)
+		} else {
+			primaryHosts = append(primaryHosts, host)
+		}
+	}
+
+	entry := logrus.WithFields(event.event.LogFields()).WithField("failures", event.describeFailures())
+	if len(primaryHosts) > 0 {
+		entry.Errorf(`Synchronizer::withRetry: event has been dropped after %d attempts, some host(s) never converged`, event.attempts)
+	} else {
+		entry.Warnf(`Synchronizer::withRetry: event has been dropped after %d attempts, some secondary host(s) never converged`, event.attempts)
+	}
+
+	s.recordSyncFailed(event, primaryHosts, configuration.SyncFailedReason)
+	s.recordSyncFailed(event, secondaryHosts, configuration.SecondarySyncFailedReason)
+
+	if event.event.Type != core.Deleted {
+		// the annotation is readable by anyone who can get the Service, so the passwords of the hosts are redacted
+		s.serviceStatus.failed(event.event.Service, event.event.UpstreamName, configuration.RedactHostsIn(event.describeFailures(), s.settings.Hosts()))
+		s.loadBalancerStatus.failed(event.event.Service, event.event.UpstreamName)
+	}
+}
+
+// rejectPermanentFailures removes the hosts whose failure retrying will not fix, e.g. an invalid parameter or a missing
+// permission, from the failures and the pending hosts of the event, instead of retrying them RetryCount times. Each of them
+// is logged, and reported with a Warning Event on the Service. The number of hosts rejected is returned.
+func (s *Synchronizer) rejectPermanentFailures(failures map[string]error, event *syncEvent) int {
+	var rejected []string
+	for host, err := range failures {
+		if application.IsPermanent(err) {
+			rejected = append(rejected, host)
+		}
+	}
+
+	if len(rejected) == 0 {
+		return 0
+	}
+
+	sort.Strings(rejected)
+
+	descriptions := make([]string, 0, len(rejected))
+	for _, host := range rejected {
+		err := failures[host]
+
+		delete(failures, host)
+		delete(event.lastErrors, host)
+		event.pendingHosts = slices.DeleteFunc(event.pendingHosts, func(pending string) bool { return pending == host })
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", host, describeError(err)))
+
+		logrus.WithFields(event.event.LogFields()).WithField("host", host).WithFields(errorFields(err)).WithError(err).
+			Errorf(`Synchronizer::rejectPermanentFailures: the host rejected the event, it is not retried`)
+
+		// the Events are readable by anyone who can list those of the namespace, so the passwords of the hosts are redacted
+		if s.settings.EventRecorder != nil && event.event.Service != nil {
+			s.settings.EventRecorder.Eventf(event.event.Service, corev1.EventTypeWarning, configuration.SyncRejectedReason,
+				"%s upstream %s was rejected by NGINX Plus host %s, not retrying: %s",
+				event.event.TypeName(), event.event.UpstreamName, configuration.RedactHost(host), configuration.RedactHostsIn(describeError(err), []string{host}))
+		}
+	}
+
+	if event.event.Type != core.Deleted {
+		s.serviceStatus.failed(event.event.Service, event.event.UpstreamName, configuration.RedactHostsIn(strings.Join(descriptions, "; "), s.settings.Hosts()))
+		s.loadBalancerStatus.failed(event.event.Service, event.event.UpstreamName)
+	}
+
+	return len(rejected)
+}
+
+// onlyMissingUpstreams determines whether every failure is an upstream missing from the NGINX Plus configuration.
+func onlyMissingUpstreams(failures map[string]error) bool {
+	if len(failures) == 0 {
+		return false
+	}
+
+	for _, err := range failures {
+		if !errors.Is(err, application.ErrUpstreamNotFound) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reportMissingUpstreams logs an error, and records a Warning Event on the Service, the first time the upstream of the
+// event is found to be missing from the NGINX Plus configuration of each pending host.
+func (s *Synchronizer) reportMissingUpstreams(event *syncEvent) {
+	for _, host := range event.pendingHosts {
+		fields := logrus.Fields{"host": host, "retryInterval": s.settings.Synchronizer.MissingUpstreamRetryInterval}
+
+		if event.reportedMissingUpstreams[host] {
+			logrus.WithFields(event.event.LogFields()).WithFields(fields).Debug(`Synchronizer::reportMissingUpstreams: the upstream is still not defined`)
+			continue
 		}
+
+		event.reportedMissingUpstreams[host] = true
+
+		logrus.WithFields(event.event.LogFields()).WithFields(fields).
+			Errorf(`Synchronizer::reportMissingUpstreams: upstream %s is not defined in the NGINX Plus configuration of %s; add it, with a shared memory zone, or map the port to an existing upstream`, event.event.UpstreamName, host)
+
+		if s.settings.EventRecorder != nil && event.event.Service != nil {
+			s.settings.EventRecorder.Eventf(event.event.Service, corev1.EventTypeWarning, configuration.UpstreamNotFoundReason,
+				"upstream %s is not defined in the NGINX Plus configuration of host %s, retrying every %v up to %d times, then at each reconciliation",
+				event.event.UpstreamName, configuration.RedactHost(host), s.settings.Synchronizer.MissingUpstreamRetryInterval, s.settings.Synchronizer.RetryCount)
+		}
+	}
+}
+
+// reportUnsupportedApiVersion logs an error the first time the API version of the host is found to be unsupported.
+// The host keeps failing until its nginx-hosts entry is changed, which makes it a different host.
+func (s *Synchronizer) reportUnsupportedApiVersion(host string, err error) {
+	s.unsupportedApiVersionsLock.Lock()
+	defer s.unsupportedApiVersionsLock.Unlock()
+
+	if s.unsupportedApiVersions[host] {
+		return
+	}
+
+	s.unsupportedApiVersions[host] = true
+
+	logrus.WithField("host", host).WithError(err).
+		Errorf(`Synchronizer::reportUnsupportedApiVersion: the NGINX Plus API version of %s is not supported, set a version supported by the host with the %s suffix`, host, configuration.ApiVersionSuffix)
+}
+
+// recordSynced records a Normal Event on the Service once the event has been applied to all of its hosts, and updates
+// the sync status annotations, and the load balancer status, of the Service.
+func (s *Synchronizer) recordSynced(event *syncEvent) {
+	if event.event.Type == core.Deleted {
+		s.serviceStatus.removed(event.event.Service, event.event.UpstreamName)
+		s.loadBalancerStatus.removed(event.event.Service, event.event.UpstreamName)
 	} else {
-		s.eventQueue.Forget(event)
-	} // TODO: Add error logging
+		s.serviceStatus.synced(event.event.Service, event.event.UpstreamName, event.hostCount, len(event.event.UpstreamServers))
+		s.loadBalancerStatus.synced(event.event.Service, event.event.UpstreamName)
+	}
+
+	if s.settings.EventRecorder == nil || event.event.Service == nil {
+		return
+	}
+
+	message := fmt.Sprintf("%s upstream %s: %d server(s) on %d NGINX Plus host(s)",
+		event.event.TypeName(), event.event.UpstreamName, len(event.event.UpstreamServers), event.hostCount)
+
+	// the servers of the nodes marked down are listed, so the Events show when they are added down and brought up
+	if down := downServers(event.event.UpstreamServers); len(down) > 0 && event.event.Type != core.Deleted {
+		message += fmt.Sprintf(", marked down: %s", strings.Join(down, ", "))
+	}
+
+	if s.settings.IsDryRun() {
+		message = "dry run, not applied: " + message
+	}
+
+	s.settings.EventRecorder.Event(event.event.Service, corev1.EventTypeNormal, configuration.SyncedReason, message)
+}
+
+// recordSyncFailed records a Warning Event with the reason on the Service for each of the hosts, which did not converge
+// after RetryCount attempts.
+func (s *Synchronizer) recordSyncFailed(event *syncEvent, hosts []string, reason string) {
+	if s.settings.EventRecorder == nil || event.event.Service == nil {
+		return
+	}
+
+	for _, host := range hosts {
+		s.settings.EventRecorder.Eventf(event.event.Service, corev1.EventTypeWarning, reason,
+			"%s upstream %s failed on NGINX Plus host %s after %d attempts: %s",
+			event.event.TypeName(), event.event.UpstreamName, configuration.RedactHost(host), event.attempts,
+			configuration.RedactHostsIn(describeError(event.lastErrors[host]), []string{host}))
+	}
+}
+
+// downServers returns the hosts of the servers marked down.
+func downServers(servers core.UpstreamServers) []string {
+	var down []string
+	for _, server := range servers {
+		if server.IsDown() {
+			down = append(down, server.Host)
+		}
+	}
+
+	return down
 }
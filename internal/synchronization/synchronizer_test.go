@@ -7,12 +7,27 @@ package synchronization
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/instrumentation"
 	"github.com/nginxinc/kubernetes-nginx-ingress/test/mocks"
+	nginxClient "github.com/nginxinc/nginx-plus-go-client/v2/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestSynchronizer_NewSynchronizer(t *testing.T) {
@@ -64,7 +79,7 @@ func TestSynchronizer_AddEventOneHost(t *testing.T) {
 	const expectedEventCount = 1
 	events := buildEvents(1)
 	settings, err := configuration.NewSettings(context.Background(), nil)
-	settings.NginxPlusHosts = []string{"https://localhost:8080"}
+	settings.SetHosts([]string{"https://localhost:8080"})
 	rateLimiter := &mocks.MockRateLimiter{}
 
 	synchronizer, err := NewSynchronizer(settings, rateLimiter)
@@ -87,11 +102,11 @@ func TestSynchronizer_AddEventManyHosts(t *testing.T) {
 	const expectedEventCount = 1
 	events := buildEvents(1)
 	settings, err := configuration.NewSettings(context.Background(), nil)
-	settings.NginxPlusHosts = []string{
+	settings.SetHosts([]string{
 		"https://localhost:8080",
 		"https://localhost:8081",
 		"https://localhost:8082",
-	}
+	})
 	rateLimiter := &mocks.MockRateLimiter{}
 
 	synchronizer, err := NewSynchronizer(settings, rateLimiter)
@@ -138,7 +153,7 @@ func TestSynchronizer_AddEventsOneHost(t *testing.T) {
 	const expectedEventCount = 4
 	events := buildEvents(4)
 	settings, err := configuration.NewSettings(context.Background(), nil)
-	settings.NginxPlusHosts = []string{"https://localhost:8080"}
+	settings.SetHosts([]string{"https://localhost:8080"})
 	rateLimiter := &mocks.MockRateLimiter{}
 
 	synchronizer, err := NewSynchronizer(settings, rateLimiter)
@@ -162,12 +177,13 @@ func TestSynchronizer_AddEventsManyHosts(t *testing.T) {
 	events := buildEvents(eventCount)
 	rateLimiter := &mocks.MockRateLimiter{}
 	settings, err := configuration.NewSettings(context.Background(), nil)
-	settings.NginxPlusHosts = []string{
+	settings.SetHosts([]string{
 		"https://localhost:8080",
 		"https://localhost:8081",
 		"https://localhost:8082",
-	}
-	expectedEventCount := eventCount * len(settings.NginxPlusHosts)
+	})
+	// each event is queued once and fans out to the hosts when it is handled
+	expectedEventCount := eventCount
 
 	synchronizer, err := NewSynchronizer(settings, rateLimiter)
 	if err != nil {
@@ -185,6 +201,694 @@ func TestSynchronizer_AddEventsManyHosts(t *testing.T) {
 	}
 }
 
+func TestSynchronizer_RetriesOnlyFailedHosts(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081", "https://localhost:8082"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Threads = 2
+	})
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	synchronizer.AddEvents(buildUpdateEvents(1))
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 3 {
+		t.Fatalf(`expected all three hosts to be updated, got %d calls`, borderClient.callCount())
+	}
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the event to be requeued, got %d events`, rateLimiter.Len())
+	}
+
+	item, _ := rateLimiter.Get()
+	requeued := item.(*syncEvent)
+	if len(requeued.pendingHosts) != 1 || requeued.pendingHosts[0] != "https://localhost:8081" {
+		t.Fatalf(`expected only the failed host to be pending, got %v`, requeued.pendingHosts)
+	}
+
+	if requeued.attempts != 1 {
+		t.Fatalf(`expected one attempt, got %d`, requeued.attempts)
+	}
+}
+
+func TestSynchronizer_DropsEventAfterRetryCount(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.RetryCount = 2
+	})
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	synchronizer.AddEvents(buildUpdateEvents(1))
+	synchronizer.handleNextEvent()
+	synchronizer.handleNextEvent()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event to be dropped, got %d events`, rateLimiter.Len())
+	}
+
+	// three calls: both hosts on the first attempt, then only the failed host
+	if borderClient.callCount() != 3 {
+		t.Fatalf(`expected 3 calls, got %d`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_RecordsSyncedEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.EventRecorder = recorder
+	})
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+	events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080"), core.NewUpstreamServer("10.0.0.2:30080")}
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	expected := "Normal Synced Updated upstream nlk-upstream: 2 server(s) on 2 NGINX Plus host(s)"
+	if event := <-recorder.Events; event != expected {
+		t.Fatalf(`expected event %q, got %q`, expected, event)
+	}
+}
+
+func TestSynchronizer_RecordsTheDownServersInTheSyncedEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.EventRecorder = recorder
+	})
+
+	apply := func(down bool) string {
+		pending := core.NewUpstreamServer("10.0.0.2:30080")
+		pending.Down = &down
+
+		events := buildUpdateEvents(1)
+		events[0].Service = buildService()
+		events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080"), pending}
+
+		synchronizer.AddEvents(events)
+		synchronizer.handleNextEvent()
+
+		return <-recorder.Events
+	}
+
+	// the server of the node being onboarded is added down, then brought up
+	expected := "Normal Synced Updated upstream nlk-upstream: 2 server(s) on 1 NGINX Plus host(s), marked down: 10.0.0.2:30080"
+	if event := apply(true); event != expected {
+		t.Fatalf(`expected event %q, got %q`, expected, event)
+	}
+
+	if down := synchronizer.Snapshot().Upstreams[0].Hosts[0].Down; !reflect.DeepEqual(down, []string{"10.0.0.2:30080"}) {
+		t.Fatalf(`expected the down server in the snapshot, got %v`, down)
+	}
+
+	expected = "Normal Synced Updated upstream nlk-upstream: 2 server(s) on 1 NGINX Plus host(s)"
+	if event := apply(false); event != expected {
+		t.Fatalf(`expected event %q, got %q`, expected, event)
+	}
+
+	if down := synchronizer.Snapshot().Upstreams[0].Hosts[0].Down; len(down) != 0 {
+		t.Fatalf(`expected no down server in the snapshot, got %v`, down)
+	}
+}
+
+func TestSynchronizer_RecordsSyncFailedEventAfterRetryCount(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.RetryCount = 2
+		settings.EventRecorder = recorder
+	})
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	if len(recorder.Events) != 0 {
+		t.Fatalf(`expected no events before RetryCount attempts, got %d`, len(recorder.Events))
+	}
+
+	synchronizer.handleNextEvent()
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected one event, got %d`, len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning SyncFailed") || !strings.Contains(event, "https://localhost:8081") || !strings.Contains(event, "unable to reach") {
+		t.Fatalf(`expected a SyncFailed event for the failed host, got %q`, event)
+	}
+}
+
+func TestSynchronizer_ReportsSecondaryHostFailuresAsWarnings(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, nil, func(settings *configuration.Settings) {
+		settings.SetHostGroups([]string{"https://localhost:8080"}, []string{"https://localhost:8091"})
+		settings.Synchronizer.RetryCount = 1
+		settings.EventRecorder = recorder
+	})
+	borderClient.failedHosts["https://localhost:8091"] = true
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+
+	failures := testutil.ToFloat64(instrumentation.SecondarySyncFailures.WithLabelValues("https://localhost:8091", "nlk-upstream"))
+
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	// both hosts are updated, the secondary host failing does not keep the event
+	if borderClient.callCount() != 2 || rateLimiter.Len() != 0 {
+		t.Fatalf(`expected both hosts to be updated once, got %v and %d events`, borderClient.calls, rateLimiter.Len())
+	}
+
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning SecondarySyncFailed") || !strings.Contains(event, "https://localhost:8091") {
+		t.Fatalf(`expected a SecondarySyncFailed event for the secondary host, got %q`, event)
+	}
+
+	if after := testutil.ToFloat64(instrumentation.SecondarySyncFailures.WithLabelValues("https://localhost:8091", "nlk-upstream")); after != failures+1 {
+		t.Fatalf(`expected the secondary sync failure to be counted, got %v`, after-failures)
+	}
+}
+
+func TestSynchronizer_RetriesTimedOutHosts(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return &timingOutBorderClient{}, nil
+	}
+
+	synchronizer.AddEvents(buildUpdateEvents(1))
+	synchronizer.handleNextEvent()
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the timed out event to be requeued, got %d events`, rateLimiter.Len())
+	}
+}
+
+func TestSynchronizer_TimesOutHungApiCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+	}))
+	defer server.Close()
+
+	host := server.URL + "/api"
+
+	synchronizer, _, _ := buildSynchronizer(t, []string{host}, func(settings *configuration.Settings) {
+		settings.Synchronizer.UpstreamTimeout = 50 * time.Millisecond
+	})
+	synchronizer.borderClientFactory = synchronizer.buildBorderClient
+
+	start := time.Now()
+
+	event := buildUpdateEvents(1)[0]
+	event.ClientType = application.ClientTypeNginxHttp
+
+	err := synchronizer.handleEvent(core.ServerUpdateEventWithIdAndHost(event, "id-0", host))
+	if !errors.Is(err, application.ErrTimeout) || !errors.Is(err, application.ErrTransient) {
+		t.Fatalf(`expected the hung call to time out, got %v`, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf(`expected the call to be aborted after the upstream timeout, took %v`, elapsed)
+	}
+
+	if timeouts := testutil.ToFloat64(instrumentation.SyncTimeouts.WithLabelValues(host, "nlk-upstream")); timeouts != 1 {
+		t.Fatalf(`expected 1 timeout, got %v`, timeouts)
+	}
+}
+
+func TestSynchronizer_ShutdownAbortsInFlightApiCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+	}))
+	defer server.Close()
+
+	host := server.URL + "/api"
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings, err := configuration.NewSettings(ctx, nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.SetHosts([]string{host})
+
+	synchronizer, err := NewSynchronizer(settings, &mocks.MockRateLimiter{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	event := buildUpdateEvents(1)[0]
+	event.ClientType = application.ClientTypeNginxHttp
+
+	err = synchronizer.handleEvent(core.ServerUpdateEventWithIdAndHost(event, "id-0", host))
+	if err == nil || !strings.Contains(err.Error(), "aborted at shutdown") {
+		t.Fatalf(`expected the call to be aborted at shutdown, got %v`, err)
+	}
+
+	if failures := testutil.ToFloat64(instrumentation.SyncFailures.WithLabelValues(host, "nlk-upstream")); failures != 0 {
+		t.Fatalf(`expected the aborted call not to count as a failure, got %v`, failures)
+	}
+}
+
+func TestSynchronizer_BacksOffFromMissingUpstreams(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.RetryCount = 2
+		settings.EventRecorder = recorder
+	})
+
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return &missingUpstreamBorderClient{}, nil
+	}
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+	synchronizer.AddEvents(events)
+
+	// the first attempt and the RetryCount retries
+	for i := 0; i < 2; i++ {
+		synchronizer.handleNextEvent()
+
+		if rateLimiter.Len() != 1 {
+			t.Fatalf(`expected the event to be requeued, got %d events`, rateLimiter.Len())
+		}
+	}
+
+	synchronizer.handleNextEvent()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event to be parked once its retries are used up, got %d events`, rateLimiter.Len())
+	}
+
+	if parked := synchronizer.parkedEvents.on("https://localhost:8080"); len(parked) != 1 {
+		t.Fatalf(`expected the event to be parked on the host, got %d events`, len(parked))
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected a single Warning Event, got %d`, len(recorder.Events))
+	}
+
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning UpstreamNotFound") || !strings.Contains(event, "nlk-upstream") {
+		t.Fatalf(`expected an UpstreamNotFound event naming the upstream, got %q`, event)
+	}
+}
+
+func TestSynchronizer_ReleasesTheParkedEventsOnceTheUpstreamIsDefined(t *testing.T) {
+	host := "https://localhost:8080"
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{host}, nil)
+
+	lister := &fakeUpstreamLister{upstreams: nginxClient.Upstreams{}}
+	synchronizer.upstreamListerFactory = func(_ string) (upstreamLister, error) { return lister, nil }
+
+	event := buildUpdateEvents(1)[0]
+	event.ClientType = application.ClientTypeNginxHttp
+	synchronizer.parkedEvents.park(event, []string{host})
+
+	synchronizer.releaseParkedEvents(host)
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event to stay parked while the upstream is missing, got %d events`, rateLimiter.Len())
+	}
+
+	lister.upstreams["nlk-upstream"] = nginxClient.Upstream{}
+	synchronizer.releaseParkedEvents(host)
+
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the parked event to be queued once the upstream is defined, got %d events`, rateLimiter.Len())
+	}
+
+	if parked := synchronizer.parkedEvents.on(host); len(parked) != 0 {
+		t.Fatalf(`expected no event left parked, got %d events`, len(parked))
+	}
+}
+
+func TestSynchronizer_DeletesFromMissingUpstreamsSucceed(t *testing.T) {
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return &missingUpstreamBorderClient{}, nil
+	}
+
+	events := buildUpdateEvents(1)
+	events[0].Type = core.Deleted
+	events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")}
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected nothing to be retried, got %d events`, rateLimiter.Len())
+	}
+}
+
+func TestSynchronizer_DoesNotRetryPermanentFailures(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081", "https://localhost:8082"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.RetryCount = 3
+		settings.EventRecorder = recorder
+	})
+
+	borderClient := &rejectingBorderClient{errs: map[string]error{
+		"https://localhost:8080": fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, application.ErrInvalidParameter),
+		"https://localhost:8081": fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, application.ErrTransient),
+	}}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	// only the transient failure is retried
+	if rateLimiter.Len() != 1 {
+		t.Fatalf(`expected the event to be requeued, got %d events`, rateLimiter.Len())
+	}
+
+	synchronizer.handleNextEvent()
+
+	if calls := borderClient.calls["https://localhost:8080"]; calls != 1 {
+		t.Fatalf(`expected the rejecting host to be called once, got %d calls`, calls)
+	}
+
+	if calls := borderClient.calls["https://localhost:8081"]; calls != 2 {
+		t.Fatalf(`expected the transient failure to be retried, got %d calls`, calls)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected a single Warning Event, got %d`, len(recorder.Events))
+	}
+
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning SyncRejected") || !strings.Contains(event, "https://localhost:8080") {
+		t.Fatalf(`expected a SyncRejected event for the rejecting host, got %q`, event)
+	}
+}
+
+func TestSynchronizer_DropsTheEventsRejectedByEveryHost(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	synchronizer, rateLimiter, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.EventRecorder = recorder
+	})
+
+	borderClient := &rejectingBorderClient{errs: map[string]error{
+		"https://localhost:8080": fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, application.ErrUnauthorized),
+	}}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	events := buildUpdateEvents(1)
+	events[0].Service = buildService()
+	synchronizer.AddEvents(events)
+	synchronizer.handleNextEvent()
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event not to be retried, got %d events`, rateLimiter.Len())
+	}
+
+	// no Synced Event, only the rejection
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected a single Warning Event, got %d`, len(recorder.Events))
+	}
+
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning SyncRejected") {
+		t.Fatalf(`expected a SyncRejected event, got %q`, event)
+	}
+}
+
+// rejectingBorderClient fails the calls to the hosts with the error of the host, and counts the calls to each host.
+type rejectingBorderClient struct {
+	lock  sync.Mutex
+	errs  map[string]error
+	calls map[string]int
+}
+
+func (c *rejectingBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[event.NginxHost]++
+
+	return c.errs[event.NginxHost]
+}
+
+func (c *rejectingBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return c.Update(ctx, event)
+}
+
+// missingUpstreamBorderClient fails every call as if the upstream was not defined in the NGINX Plus configuration.
+type missingUpstreamBorderClient struct{}
+
+func (c *missingUpstreamBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	return fmt.Errorf(`error occurred updating the nginx+ upstream server: %w`, application.ErrUpstreamNotFound)
+}
+
+func (c *missingUpstreamBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return c.Update(ctx, event)
+}
+
+// timingOutBorderClient fails every call with the error returned by an http.Client that timed out.
+type timingOutBorderClient struct{}
+
+func (c *timingOutBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	return &url.Error{Op: "Post", URL: event.NginxHost, Err: context.DeadlineExceeded}
+}
+
+func (c *timingOutBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return c.Update(ctx, event)
+}
+
+func TestSynchronizer_SkipsHostsWithAnOpenCircuit(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CircuitBreakerThreshold = 1
+	})
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	synchronizer.AddEvents(buildUpdateEvents(1))
+	synchronizer.handleNextEvent()
+
+	// the failed host has opened its circuit, the retry skips it and the event completes
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 2 {
+		t.Fatalf(`expected 2 calls, got %d`, borderClient.callCount())
+	}
+
+	if rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event to be done, got %d events`, rateLimiter.Len())
+	}
+
+	snapshot := synchronizer.Snapshot()
+	if !snapshot.Hosts[1].CircuitOpen || snapshot.Hosts[1].NextProbe == nil || snapshot.Hosts[0].CircuitOpen {
+		t.Fatalf(`expected only the failed host to have an open circuit, got %+v`, snapshot.Hosts)
+	}
+}
+
+func TestSynchronizer_BoundsTheHostsSyncedByAllTheWorkers(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081", "https://localhost:8082"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.Threads = 2
+	})
+
+	borderClient := &concurrentBorderClient{}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) {
+		return borderClient, nil
+	}
+
+	var workers sync.WaitGroup
+	for worker := 0; worker < synchronizer.settings.Synchronizer.Threads; worker++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			event := buildUpdateEvents(1)[0]
+			event.UpstreamName = fmt.Sprintf(`nlk-upstream-%d`, worker)
+			synchronizer.syncHosts(newSyncEvent(event, synchronizer.settings.Hosts()))
+		}()
+	}
+
+	workers.Wait()
+
+	if borderClient.maxInFlight() > synchronizer.settings.Synchronizer.Threads {
+		t.Errorf(`expected no more than %d calls in flight, got %d`, synchronizer.settings.Synchronizer.Threads, borderClient.maxInFlight())
+	}
+}
+
+// concurrentBorderClient holds each call for a while, and records the most calls in flight at once.
+type concurrentBorderClient struct {
+	lock     sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (c *concurrentBorderClient) Update(_ context.Context, _ *core.ServerUpdateEvent) error {
+	c.lock.Lock()
+	c.inFlight++
+	c.max = max(c.max, c.inFlight)
+	c.lock.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.lock.Lock()
+	c.inFlight--
+	c.lock.Unlock()
+
+	return nil
+}
+
+func (c *concurrentBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return c.Update(ctx, event)
+}
+
+func (c *concurrentBorderClient) maxInFlight() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.max
+}
+
+func TestSynchronizer_OnlyCountsTheHostFailuresOfTheCircuit(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CircuitBreakerThreshold = 1
+	})
+
+	for _, status := range []int{409, 429} {
+		synchronizer.recordHostOutcome("https://localhost:8080", fmt.Errorf(`failed to update the server: expected 200 response, got %d`, status))
+
+		if synchronizer.circuitBreaker.isOpen("https://localhost:8080") {
+			t.Fatalf(`expected a %d response to keep the circuit closed`, status)
+		}
+	}
+
+	synchronizer.recordHostOutcome("https://localhost:8080", errors.New(`failed to update the server: expected 200 response, got 503`))
+
+	if !synchronizer.circuitBreaker.isOpen("https://localhost:8080") {
+		t.Fatal(`expected a 503 response to open the circuit`)
+	}
+}
+
+func TestSynchronizer_ResyncsWhenAHostRecovers(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.CircuitBreakerThreshold = 1
+		settings.Synchronizer.CircuitBreakerBackoff = 0
+	})
+
+	resyncs := 0
+	synchronizer.SetResyncer(func() { resyncs++ })
+
+	probeErr := errors.New(`unable to reach the host`)
+	synchronizer.hostProber = func(string) error { return probeErr }
+
+	synchronizer.recordHostOutcome("https://localhost:8080", probeErr)
+	synchronizer.probeOpenCircuits()
+
+	if resyncs != 0 || !synchronizer.circuitBreaker.isOpen("https://localhost:8080") {
+		t.Fatalf(`expected the circuit to stay open after a failed probe, got %d resyncs`, resyncs)
+	}
+
+	synchronizer.hostProber = func(string) error { return nil }
+	synchronizer.probeOpenCircuits()
+
+	if resyncs != 1 || synchronizer.circuitBreaker.isOpen("https://localhost:8080") {
+		t.Fatalf(`expected the circuit to close and the Services to be resynced, got %d resyncs`, resyncs)
+	}
+}
+
+// buildSynchronizer creates a Synchronizer for the hosts, whose settings are changed by configure when it is not nil, with
+// a fakeBorderClient in place of the Border Clients of the hosts.
+func buildSynchronizer(t *testing.T, hosts []string, configure func(settings *configuration.Settings)) (*Synchronizer, *mocks.MockRateLimiter, *fakeBorderClient) {
+	t.Helper()
+
+	settings, err := configuration.NewSettings(context.Background(), nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.SetHosts(hosts)
+	if configure != nil {
+		configure(settings)
+	}
+
+	rateLimiter := &mocks.MockRateLimiter{}
+
+	synchronizer, err := NewSynchronizer(settings, rateLimiter)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	borderClient := newFakeBorderClient()
+	synchronizer.borderClientFactory = borderClient.forEvent
+
+	return synchronizer, rateLimiter, borderClient
+}
+
+// fakeBorderClient records the hosts and events it was called for and fails for the specified hosts.
+type fakeBorderClient struct {
+	lock        sync.Mutex
+	calls       []string
+	events      []*core.ServerUpdateEvent
+	failedHosts map[string]bool
+}
+
+func newFakeBorderClient(failedHosts ...string) *fakeBorderClient {
+	borderClient := &fakeBorderClient{failedHosts: make(map[string]bool)}
+	for _, host := range failedHosts {
+		borderClient.failedHosts[host] = true
+	}
+
+	return borderClient
+}
+
+func (f *fakeBorderClient) forEvent(_ *core.ServerUpdateEvent) (application.Interface, error) {
+	return f, nil
+}
+
+func (f *fakeBorderClient) Update(_ context.Context, event *core.ServerUpdateEvent) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.calls = append(f.calls, event.NginxHost)
+	f.events = append(f.events, event)
+	if f.failedHosts[event.NginxHost] {
+		return fmt.Errorf(`unable to reach %s`, event.NginxHost)
+	}
+
+	return nil
+}
+
+func (f *fakeBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	return f.Update(ctx, event)
+}
+
+func (f *fakeBorderClient) callCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.calls)
+}
+
+func buildService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress", Namespace: "nginx-ingress"},
+	}
+}
+
+func buildUpdateEvents(count int) core.ServerUpdateEvents {
+	events := buildEvents(count)
+	for _, event := range events {
+		event.Type = core.Updated
+		event.UpstreamName = "nlk-upstream"
+	}
+
+	return events
+}
+
 func buildEvents(count int) core.ServerUpdateEvents {
 	events := make(core.ServerUpdateEvents, count)
 	for i := 0; i < count; i++ {
@@ -192,9 +896,217 @@ func buildEvents(count int) core.ServerUpdateEvents {
 			Id:              fmt.Sprintf("id-%v", i),
 			NginxHost:       "https://localhost:8080",
 			Type:            0,
-			UpstreamName:    "",
+			UpstreamName:    fmt.Sprintf("upstream-%v", i),
 			UpstreamServers: nil,
 		}
 	}
 	return events
 }
+
+func TestSynchronizer_SkipsUnchangedUpdates(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081"}, func(settings *configuration.Settings) {
+		settings.Synchronizer.HostStagger = 0
+	})
+	borderClient.failedHosts["https://localhost:8081"] = true
+
+	apply := func(servers ...string) {
+		events := buildUpdateEvents(1)
+		for _, server := range servers {
+			events[0].UpstreamServers = append(events[0].UpstreamServers, core.NewUpstreamServer(server))
+		}
+
+		synchronizer.AddEvents(events)
+		synchronizer.handleNextEvent()
+
+		for rateLimiter.Len() > 0 {
+			_, _ = rateLimiter.Get()
+		}
+	}
+
+	apply("10.0.0.1:30080", "10.0.0.2:30080")
+	if borderClient.callCount() != 2 {
+		t.Fatalf(`expected both hosts to be updated, got %d calls`, borderClient.callCount())
+	}
+
+	// the servers are unchanged, in a different order; only the host that failed is called again
+	apply("10.0.0.2:30080", "10.0.0.1:30080")
+	if borderClient.callCount() != 3 {
+		t.Fatalf(`expected only the failed host to be updated, got %d calls`, borderClient.callCount())
+	}
+
+	apply("10.0.0.1:30080")
+	if borderClient.callCount() != 5 {
+		t.Fatalf(`expected both hosts to be updated when the servers change, got %d calls`, borderClient.callCount())
+	}
+
+	// a new list of hosts clears the cache
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080", "https://localhost:8082"})
+	apply("10.0.0.1:30080")
+	if borderClient.callCount() != 7 {
+		t.Fatalf(`expected both hosts to be updated when the hosts change, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_AppliesUnchangedResyncs(t *testing.T) {
+	synchronizer, _, borderClient := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	apply := func(resync bool) {
+		events := buildUpdateEvents(1)
+		events[0].UpstreamServers = core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:30080")}
+		events[0].Resync = resync
+
+		synchronizer.AddEvents(events)
+		synchronizer.handleNextEvent()
+	}
+
+	apply(false)
+	apply(false)
+	if borderClient.callCount() != 1 {
+		t.Fatalf(`expected the unchanged update to be skipped, got %d calls`, borderClient.callCount())
+	}
+
+	// the servers of the host may have been changed outside NLK since they were applied
+	apply(true)
+	if borderClient.callCount() != 2 {
+		t.Fatalf(`expected the unchanged resync to be applied, got %d calls`, borderClient.callCount())
+	}
+}
+
+func TestSynchronizer_BuildNginxClientUsesTheApiVersionOfEachHost(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, nil, nil)
+
+	for host, expected := range map[string]int{
+		"https://localhost:8080/api":                 nginxClient.APIVersion,
+		"https://localhost:8081/nginx-api;version=8": 8,
+	} {
+		ngxClient, err := synchronizer.buildNginxClient(host)
+		if err != nil {
+			t.Fatalf(`should have been no error for %s, %v`, host, err)
+		}
+
+		if ngxClient.Version() != expected {
+			t.Errorf(`expected version %d for %s, got %d`, expected, host, ngxClient.Version())
+		}
+	}
+}
+
+func TestSynchronizer_BuildNginxClientReportsUnsupportedApiVersions(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, nil, nil)
+	synchronizer.borderClientFactory = synchronizer.buildBorderClient
+
+	_, err := synchronizer.buildNginxClient("https://localhost:8080/api;version=99")
+	if !errors.Is(err, application.ErrUnsupportedApiVersion) {
+		t.Fatalf(`expected an unsupported API version error, got %v`, err)
+	}
+
+	event := buildUpdateEvents(1)[0]
+	event.NginxHost = "https://localhost:8080/api;version=99"
+
+	for i := 0; i < 2; i++ {
+		if err := synchronizer.handleEvent(event); !errors.Is(err, application.ErrUnsupportedApiVersion) {
+			t.Fatalf(`expected an unsupported API version error, got %v`, err)
+		}
+	}
+
+	if !synchronizer.unsupportedApiVersions[event.NginxHost] || len(synchronizer.unsupportedApiVersions) != 1 {
+		t.Errorf(`expected the host to be reported, got %v`, synchronizer.unsupportedApiVersions)
+	}
+}
+
+func TestSynchronizer_ResyncsWhenHostsAreAdded(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080"}, nil)
+
+	resyncs := 0
+	synchronizer.SetResyncer(func() { resyncs++ })
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080", "https://localhost:8081"})
+	if resyncs != 1 {
+		t.Fatalf(`expected a resync when a host is added, got %d`, resyncs)
+	}
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8081"})
+	synchronizer.settings.SetHosts([]string{"https://localhost:8081"})
+	if resyncs != 1 {
+		t.Fatalf(`expected no resync when a host is removed or nothing changes, got %d`, resyncs)
+	}
+}
+
+func TestSynchronizer_DropsThePendingRetriesOfRemovedHosts(t *testing.T) {
+	synchronizer, rateLimiter, borderClient := buildSynchronizer(t, []string{"https://localhost:8080", "https://localhost:8081", "https://localhost:8082"}, nil)
+	borderClient.failedHosts["https://localhost:8081"] = true
+	borderClient.failedHosts["https://localhost:8082"] = true
+
+	synchronizer.AddEvents(buildUpdateEvents(1))
+	synchronizer.handleNextEvent()
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080", "https://localhost:8082"})
+	synchronizer.handleNextEvent()
+
+	// three calls on the first attempt, then only the failed host that is still configured
+	if borderClient.callCount() != 4 || borderClient.calls[3] != "https://localhost:8082" {
+		t.Fatalf(`expected the retry to skip the removed host, got %v`, borderClient.calls)
+	}
+
+	synchronizer.settings.SetHosts([]string{"https://localhost:8080"})
+	synchronizer.handleNextEvent()
+
+	if borderClient.callCount() != 4 || rateLimiter.Len() != 0 {
+		t.Fatalf(`expected the event to be dropped once its hosts are removed, got %v and %d events`, borderClient.calls, rateLimiter.Len())
+	}
+}
+
+func TestSynchronizer_FlushEventsAppliesTheEventsOnceShuttingDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	settings, err := configuration.NewSettings(ctx, nil)
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	settings.SetHosts([]string{"https://10.0.0.100:9000/api", "https://10.0.0.101:9000/api"})
+
+	synchronizer, err := NewSynchronizer(settings, &mocks.MockRateLimiter{})
+	if err != nil {
+		t.Fatalf(`should have been no error, %v`, err)
+	}
+
+	borderClient := &abortingBorderClient{fakeBorderClient: newFakeBorderClient()}
+	synchronizer.borderClientFactory = func(_ *core.ServerUpdateEvent) (application.Interface, error) { return borderClient, nil }
+
+	cancel()
+
+	event := core.NewServerUpdateEvent(core.Deleted, "https", application.ClientTypeNginxHttp, core.UpstreamServers{core.NewUpstreamServer("10.0.0.1:443")})
+	synchronizer.FlushEvents(core.ServerUpdateEvents{event})
+
+	if calls := borderClient.callCount(); calls != 2 {
+		t.Fatalf(`expected the deletion to be applied to each host although NLK is shutting down, got %d calls`, calls)
+	}
+}
+
+// abortingBorderClient fails the calls whose context is done, as the NGINX Plus client does.
+type abortingBorderClient struct {
+	*fakeBorderClient
+}
+
+func (a *abortingBorderClient) Delete(ctx context.Context, event *core.ServerUpdateEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return a.fakeBorderClient.Delete(ctx, event)
+}
+
+func TestSynchronizer_WithSiteLogsTheSiteOfTheHost(t *testing.T) {
+	synchronizer, _, _ := buildSynchronizer(t, []string{"https://localhost:8080;site=east", "https://localhost:8081"}, nil)
+	event := buildUpdateEvents(1)[0]
+
+	sited := synchronizer.withSite(core.ServerUpdateEventWithIdAndHost(event, event.Id, "https://localhost:8080;site=east"))
+	if sited.Site != "east" || sited.LogFields()["site"] != "east" {
+		t.Fatalf(`expected the site of the host to be logged, got %v`, sited.LogFields())
+	}
+
+	unsited := synchronizer.withSite(core.ServerUpdateEventWithIdAndHost(event, event.Id, "https://localhost:8081"))
+	if _, found := unsited.LogFields()["site"]; found {
+		t.Fatalf(`expected no site for a host that sets none, got %v`, unsited.LogFields())
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+)
+
+// portMapping is the upstream and client type a port is explicitly mapped to by the ports annotation.
+type portMapping struct {
+
+	// upstreamName is the name of the upstream targeted by the port.
+	upstreamName string
+
+	// clientType is the client type of the upstream, see application.ClientTypeNginxHttp.
+	clientType string
+
+	// conflicting is set when the port is mapped more than once, the port is then skipped.
+	conflicting bool
+}
+
+// getPortMappings parses the ports annotation, e.g. `nginxinc.io/ports: "8443:my-tls-upstream:stream,8080:my-http-upstream:http"`,
+// into a map of Service port number to portMapping. The mapped ports are handled whatever their name, and the mappings take
+// precedence over the upstream map and the NlkPrefix. Invalid entries are ignored, and a port mapped more than once is skipped;
+// a Warning Event is recorded on the Service for each.
+func getPortMappings(service *v1.Service, recorder record.EventRecorder) map[int32]*portMapping {
+	mappings := make(map[int32]*portMapping)
+
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.PortsAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return mappings
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		port, mapping, err := parsePortMapping(entry)
+		if err != nil {
+			recordInvalidAnnotation(service, recorder, key, entry, err.Error())
+			continue
+		}
+
+		if existing, found := mappings[port]; found {
+			if !existing.conflicting {
+				recordConflictingPortMapping(service, recorder, key, port)
+			}
+			existing.conflicting = true
+			continue
+		}
+
+		mappings[port] = mapping
+	}
+
+	return mappings
+}
+
+// ValidatePortMappings returns an error for each entry of the ports annotation of the Service that getPortMappings would
+// ignore, and for each port mapped more than once; each error names the annotation and the position of the entry.
+func ValidatePortMappings(service *v1.Service) field.ErrorList {
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.PortsAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return nil
+	}
+
+	path := field.NewPath("metadata", "annotations").Key(key)
+
+	var errs field.ErrorList
+	mapped := make(map[int32]bool)
+
+	for position, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		port, _, err := parsePortMapping(entry)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Index(position), entry, err.Error()))
+			continue
+		}
+
+		if mapped[port] {
+			errs = append(errs, field.Duplicate(path.Index(position), entry))
+			continue
+		}
+
+		mapped[port] = true
+	}
+
+	return errs
+}
+
+// parsePortMapping parses an entry of the ports annotation, port:upstream-name:client-type.
+func parsePortMapping(entry string) (int32, *portMapping, error) {
+	fields := strings.Split(entry, ":")
+	if len(fields) != 3 {
+		return 0, nil, fmt.Errorf(`entries must be port:upstream-name:client-type`)
+	}
+
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	port, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, nil, fmt.Errorf(`the port must be a number between 1 and 65535`)
+	}
+
+	if fields[1] == "" {
+		return 0, nil, fmt.Errorf(`the upstream name must not be empty`)
+	}
+
+	switch fields[2] {
+	case application.ClientTypeNginxHttp, application.ClientTypeNginxStream, application.ClientTypeNginxUdp:
+	default:
+		return 0, nil, fmt.Errorf(`the client type must be one of %s, %s, or %s`, application.ClientTypeNginxHttp, application.ClientTypeNginxStream, application.ClientTypeNginxUdp)
+	}
+
+	return int32(port), &portMapping{upstreamName: fields[1], clientType: fields[2]}, nil
+}
+
+// recordConflictingPortMapping logs a warning, and records a Warning Event on the Service, for a port mapped more than once.
+func recordConflictingPortMapping(service *v1.Service, recorder record.EventRecorder, key string, port int32) {
+	message := fmt.Sprintf("annotation %s maps port %d more than once, the port is skipped", key, port)
+	logrus.Warnf("Translate::recordConflictingPortMapping: service %s/%s: %s", service.Namespace, service.Name, message)
+
+	if recorder != nil {
+		recorder.Event(service, v1.EventTypeWarning, configuration.InvalidAnnotationReason, message)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestTranslatePortMappings(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "tls", Protocol: v1.ProtocolTCP, Port: 8443, NodePort: 30443},
+		{Name: "nlk-web", Protocol: v1.ProtocolTCP, Port: 8080, NodePort: 30080},
+		{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9113, NodePort: 30913},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/ports":        "8443:my-tls-upstream:stream, 8080:my-http-upstream:http",
+		"nginxinc.io/upstream-map": "web=mapped-web-upstream",
+	}
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	clientTypes := map[string]string{}
+	for _, translatedEvent := range translatedEvents {
+		clientTypes[translatedEvent.UpstreamName] = translatedEvent.ClientType
+	}
+
+	// the mappings take precedence over the upstream map, and the ports mentioned by neither are ignored
+	expected := map[string]string{"my-tls-upstream": application.ClientTypeNginxStream, "my-http-upstream": application.ClientTypeNginxHttp}
+	if len(clientTypes) != len(expected) || clientTypes["my-tls-upstream"] != expected["my-tls-upstream"] || clientTypes["my-http-upstream"] != expected["my-http-upstream"] {
+		t.Fatalf(`expected the upstreams %v, got %v`, expected, clientTypes)
+	}
+}
+
+func TestTranslatePortMappedTwiceIsSkipped(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-tls", Protocol: v1.ProtocolTCP, Port: 8443, NodePort: 30443},
+		{Name: "web", Protocol: v1.ProtocolTCP, Port: 8080, NodePort: 30080},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/ports": "8443:tls-a:stream,8443:tls-b:stream,8443:tls-c:http,8080:web-upstream:http",
+	}
+	recorder := record.NewFakeRecorder(10)
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 1 || translatedEvents[0].UpstreamName != "web-upstream" {
+		t.Fatalf(`expected only the upstream of the unambiguous port, got %d events`, len(translatedEvents))
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected a single Warning Event for the conflicting port, got %d`, len(recorder.Events))
+	}
+}
+
+func TestTranslateInvalidPortMappingsRecordEvents(t *testing.T) {
+	invalid := []string{"8443:my-tls-upstream", "https:my-tls-upstream:stream", "70000:my-tls-upstream:stream", "8443::stream", "8443:my-tls-upstream:grpc"}
+
+	for _, value := range invalid {
+		service := serviceWithPorts([]v1.ServicePort{{Name: "tls", Protocol: v1.ProtocolTCP, Port: 8443, NodePort: 30443}})
+		service.Annotations = map[string]string{"nginxinc.io/ports": value}
+		recorder := record.NewFakeRecorder(1)
+
+		event := buildCreatedEvent(service, OneNode)
+
+		translatedEvents, err := Translate(&event, recorder)
+		if err != nil {
+			t.Fatalf(TranslateErrorFormat, err)
+		}
+
+		if len(translatedEvents) != 0 {
+			t.Errorf(`expected the port to be ignored for %q, got %d events`, value, len(translatedEvents))
+		}
+
+		if len(recorder.Events) != 1 {
+			t.Errorf(`expected a Warning Event for %q, got %d`, value, len(recorder.Events))
+		}
+	}
+}
+
+func TestValidatePortMappings(t *testing.T) {
+	service := serviceWithPorts(nil)
+	service.Annotations = map[string]string{
+		"nginxinc.io/ports": "8443:tls:stream, 8080::http,8443:other:stream,9000:metrics:grpc",
+	}
+
+	errs := ValidatePortMappings(service)
+
+	expected := []string{
+		`metadata.annotations[nginxinc.io/ports][1]`,
+		`metadata.annotations[nginxinc.io/ports][2]`,
+		`metadata.annotations[nginxinc.io/ports][3]`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf(`expected %d errors, got %v`, len(expected), errs)
+	}
+
+	for i, err := range errs {
+		if err.Field != expected[i] {
+			t.Fatalf(`expected the error of the entry %s, got %v`, expected[i], err)
+		}
+	}
+
+	service.Annotations["nginxinc.io/ports"] = "8443:tls:stream"
+	if errs = ValidatePortMappings(service); len(errs) != 0 {
+		t.Fatalf(`expected a well formed annotation to be valid, got %v`, errs)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+)
+
+// serverPorts resolves the port of the upstream servers of each port of a Service: its nodePort, or the host port of the
+// nodes when the Service uses host ports, see configuration.UseHostPortsAnnotation. The host port is assumed to be the
+// targetPort, as the hostPorts of a DaemonSet usually equal its containerPorts; a named targetPort is resolved with the
+// ports of the endpoints of the Service. A Service without nodePorts, e.g. of type ClusterIP, uses host ports when the
+// ports of its endpoints are known, i.e. in the TargetModeEndpointSlices.
+type serverPorts struct {
+	service      *v1.Service
+	useHostPorts bool
+	targetPorts  map[string]int32
+}
+
+// getServerPorts returns the serverPorts of the Service, given the ports of its endpoints, nil when they are not known.
+// An invalid UseHostPortsAnnotation is treated as false and a Warning Event is recorded on the Service.
+func getServerPorts(service *v1.Service, targetPorts map[string]int32, recorder record.EventRecorder) *serverPorts {
+	useHostPorts := getUseHostPorts(service, recorder)
+
+	return &serverPorts{
+		service:      service,
+		useHostPorts: useHostPorts || (targetPorts != nil && !hasNodePorts(service)),
+		targetPorts:  targetPorts,
+	}
+}
+
+// portOf returns the port of the upstream servers of the Service port, or an error describing what is missing.
+func (p *serverPorts) portOf(port v1.ServicePort) (int32, error) {
+	if !p.useHostPorts {
+		if port.NodePort == 0 && !hasNodePorts(p.service) {
+			return 0, fmt.Errorf(`port %s has no nodePort as the Service is of type %s; annotate the Service with %s: "true" to use the host ports of the nodes, or use the %s target mode`,
+				port.Name, p.service.Spec.Type, configuration.UseHostPortsAnnotation, configuration.TargetModeEndpointSlices)
+		}
+
+		return port.NodePort, nil
+	}
+
+	if targetPort, found := p.targetPorts[port.Name]; found {
+		return targetPort, nil
+	}
+
+	switch {
+	case port.TargetPort.Type == intstr.String && port.TargetPort.StrVal != "":
+		return 0, fmt.Errorf(`port %s uses host ports, and its named targetPort %s cannot be resolved without a ready endpoint of the Service in the %s target mode; use a numeric targetPort`,
+			port.Name, port.TargetPort.StrVal, configuration.TargetModeEndpointSlices)
+
+	case port.TargetPort.IntVal != 0:
+		return port.TargetPort.IntVal, nil
+	}
+
+	return port.Port, nil
+}
+
+// hasNodePorts determines whether Kubernetes allocates a nodePort to the ports of the Service.
+func hasNodePorts(service *v1.Service) bool {
+	switch service.Spec.Type {
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeExternalName:
+		return false
+
+	case v1.ServiceTypeLoadBalancer:
+		return service.Spec.AllocateLoadBalancerNodePorts == nil || *service.Spec.AllocateLoadBalancerNodePorts
+	}
+
+	return true
+}
+
+// getUseHostPorts determines whether the Service asks for its upstream servers to use the host ports of the nodes.
+// An invalid value is treated as false and a Warning Event is recorded on the Service.
+func getUseHostPorts(service *v1.Service, recorder record.EventRecorder) bool {
+	value, ok := service.Annotations[configuration.UseHostPortsAnnotation]
+	if !ok {
+		return false
+	}
+
+	useHostPorts, err := strconv.ParseBool(value)
+	if err != nil {
+		recordInvalidAnnotation(service, recorder, configuration.UseHostPortsAnnotation, value, "must be true or false")
+		return false
+	}
+
+	return useHostPorts
+}
+
+// recordUnresolvedPort logs a warning, and records a Warning Event on the Service, for a port whose upstream servers are
+// skipped as their port cannot be resolved.
+func recordUnresolvedPort(service *v1.Service, recorder record.EventRecorder, err error) {
+	message := fmt.Sprintf("%v; the port is skipped", err)
+	logrus.Warnf("Translate::recordUnresolvedPort: service %s/%s: %s", service.Namespace, service.Name, message)
+
+	if recorder != nil {
+		recorder.Event(service, v1.EventTypeWarning, configuration.UnresolvedPortReason, message)
+	}
+}
+
+// buildAnyPortEvents builds a Deleted event for the servers of the upstream on the address of each node, whatever their
+// port, for a port whose servers are deleted although their port cannot be resolved, see core.ServerUpdateEvent::AnyPort.
+func buildAnyPortEvents(upstreamName string, clientType string, nodeIps []string, drainingNodeIps []string) core.ServerUpdateEvents {
+	var events core.ServerUpdateEvents
+
+	for _, nodeIp := range append(append([]string{}, nodeIps...), drainingNodeIps...) {
+		event := core.NewServerUpdateEvent(core.Deleted, upstreamName, clientType, core.UpstreamServers{core.NewUpstreamServer(nodeIp)})
+		event.AnyPort = true
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// ValidateServerPorts returns an error when the UseHostPortsAnnotation of the Service is not a boolean, and for each port
+// targeted by NLK whose upstream servers would have no port: a port without a nodePort, unless the Service uses host ports
+// or the EndpointSlices are watched, or a named targetPort of a Service using host ports while the EndpointSlices are not.
+func ValidateServerPorts(service *v1.Service, endpointSlices bool) field.ErrorList {
+	var errs field.ErrorList
+
+	if value, ok := service.Annotations[configuration.UseHostPortsAnnotation]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("metadata", "annotations").Key(configuration.UseHostPortsAnnotation), value, "must be true or false"))
+		}
+	}
+
+	useHostPorts := getUseHostPorts(service, nil) || (endpointSlices && !hasNodePorts(service))
+	portsOfInterest := filterPorts(service.Spec.Ports, getUpstreamMap(service, nil), getPortMappings(service, nil))
+
+	for index, port := range service.Spec.Ports {
+		if !containsPort(portsOfInterest, port) {
+			continue
+		}
+
+		path := field.NewPath("spec", "ports").Index(index)
+
+		switch {
+		case !useHostPorts && !hasNodePorts(service):
+			errs = append(errs, field.Invalid(path.Child("nodePort"), port.NodePort,
+				fmt.Sprintf("the Service has no nodePort, annotate it with %s: \"true\" to use the host ports of the nodes", configuration.UseHostPortsAnnotation)))
+
+		case useHostPorts && !endpointSlices && port.TargetPort.Type == intstr.String && port.TargetPort.StrVal != "":
+			errs = append(errs, field.Invalid(path.Child("targetPort"), port.TargetPort.StrVal,
+				fmt.Sprintf("a named targetPort of a Service using host ports is only resolved in the %s target mode", configuration.TargetModeEndpointSlices)))
+		}
+	}
+
+	return errs
+}
+
+// containsPort determines whether the port, by name and number, is one of the ports.
+func containsPort(ports []v1.ServicePort, port v1.ServicePort) bool {
+	for _, candidate := range ports {
+		if candidate.Name == port.Name && candidate.Port == port.Port {
+			return true
+		}
+	}
+
+	return false
+}
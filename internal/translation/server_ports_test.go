@@ -0,0 +1,190 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestTranslateUsesTheHostPortsOfTheAnnotatedService(t *testing.T) {
+	service := daemonSetService()
+	service.Annotations = map[string]string{configuration.UseHostPortsAnnotation: "true"}
+
+	event := buildCreatedEvent(service, OneNode)
+	event.TargetPorts = map[string]int32{"nlk-https": 443}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	hosts := serverHostsByUpstream(translatedEvents)
+	if hosts["http"] != "10.0.0.0:80" || hosts["https"] != "10.0.0.0:443" {
+		t.Fatalf(`expected the servers on the host ports, got %v`, hosts)
+	}
+}
+
+func TestTranslateUsesTheHostPortsOfAServiceWithoutNodePortsInEndpointsMode(t *testing.T) {
+	service := daemonSetService()
+
+	event := buildUpdatedEvent(service, OneNode)
+	event.TargetPorts = map[string]int32{"nlk-https": 8443}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	hosts := serverHostsByUpstream(translatedEvents)
+	if hosts["http"] != "10.0.0.0:80" || hosts["https"] != "10.0.0.0:8443" {
+		t.Fatalf(`expected the servers on the ports of the endpoints, got %v`, hosts)
+	}
+}
+
+func TestTranslateSkipsThePortsThatCannotBeResolved(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+
+	// without the annotation or the ports of the endpoints, the ClusterIP Service has no port for the servers
+	event := buildCreatedEvent(daemonSetService(), OneNode)
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 0 || len(recorder.Events) != 2 {
+		t.Fatalf(`expected both ports to be skipped with a Warning Event, got %d events and %d Warnings`, len(translatedEvents), len(recorder.Events))
+	}
+
+	if warning := <-recorder.Events; !strings.Contains(warning, configuration.UnresolvedPortReason) || !strings.Contains(warning, configuration.UseHostPortsAnnotation) {
+		t.Fatalf(`expected the Warning to name the annotation, got %s`, warning)
+	}
+
+	// the named targetPort has no endpoint to resolve it
+	service := daemonSetService()
+	service.Annotations = map[string]string{configuration.UseHostPortsAnnotation: "true"}
+	event = buildCreatedEvent(service, OneNode)
+
+	if translatedEvents, _ = Translate(&event, nil); len(translatedEvents) != 1 || translatedEvents[0].UpstreamName != "http" {
+		t.Fatalf(`expected only the port with a numeric targetPort, got %d events`, len(translatedEvents))
+	}
+}
+
+func TestTranslateDeletesTheServersOfAnUnresolvedPortOnAnyPort(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+
+	service := daemonSetService()
+	service.Annotations = map[string]string{configuration.UseHostPortsAnnotation: "true"}
+
+	// the EndpointSlices of a deleted Service are gone, so its named targetPort cannot be resolved
+	event := buildDeletedEvent(service, OneNode)
+	event.TargetPorts = nil
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 2 || len(recorder.Events) != 0 {
+		t.Fatalf(`expected a Deleted event for each port and no Warning, got %d events and %d Warnings`, len(translatedEvents), len(recorder.Events))
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		switch translatedEvent.UpstreamName {
+		case "http":
+			if translatedEvent.AnyPort || translatedEvent.UpstreamServers[0].Host != "10.0.0.0:80" {
+				t.Errorf(`expected the server on the numeric targetPort, got %#v`, translatedEvent)
+			}
+
+		case "https":
+			if !translatedEvent.AnyPort || translatedEvent.UpstreamServers[0].Host != "10.0.0.0" {
+				t.Errorf(`expected the servers on the address of the node on any port, got %#v`, translatedEvent)
+			}
+		}
+	}
+}
+
+func TestTranslateKeepsTheNodePortsWithoutTheAnnotation(t *testing.T) {
+	service := daemonSetService()
+	service.Spec.Type = v1.ServiceTypeNodePort
+	service.Spec.Ports[0].NodePort = 30080
+	service.Spec.Ports[1].NodePort = 30443
+
+	event := buildCreatedEvent(service, OneNode)
+	event.TargetPorts = map[string]int32{"nlk-https": 443}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	hosts := serverHostsByUpstream(translatedEvents)
+	if hosts["http"] != "10.0.0.0:30080" || hosts["https"] != "10.0.0.0:30443" {
+		t.Fatalf(`expected the servers on the nodePorts, got %v`, hosts)
+	}
+}
+
+func TestValidateServerPorts(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotation     string
+		endpointSlices bool
+		expected       []string
+	}{
+		{"no nodePorts", "", false, []string{"spec.ports[0].nodePort", "spec.ports[1].nodePort"}},
+		{"no nodePorts in endpoints mode", "", true, nil},
+		{"named targetPort", "true", false, []string{"spec.ports[1].targetPort"}},
+		{"named targetPort in endpoints mode", "true", true, nil},
+		{"invalid annotation", "yes please", true, []string{"metadata.annotations[nkl.nginx.com/use-host-ports]"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := daemonSetService()
+			if test.annotation != "" {
+				service.Annotations = map[string]string{configuration.UseHostPortsAnnotation: test.annotation}
+			}
+
+			errs := ValidateServerPorts(service, test.endpointSlices)
+			if len(errs) != len(test.expected) {
+				t.Fatalf(`expected %d errors, got %v`, len(test.expected), errs)
+			}
+
+			for i, expected := range test.expected {
+				if errs[i].Field != expected {
+					t.Errorf(`expected an error on %s, got %v`, expected, errs[i])
+				}
+			}
+		})
+	}
+}
+
+// daemonSetService returns the ClusterIP Service of an NGINX Ingress Controller DaemonSet declaring hostPorts.
+func daemonSetService() *v1.Service {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt32(80)},
+		{Name: "nlk-https", Protocol: v1.ProtocolTCP, Port: 443, TargetPort: intstr.FromString("https")},
+	})
+	service.Spec.Type = v1.ServiceTypeClusterIP
+
+	return service
+}
+
+func serverHostsByUpstream(events []*core.ServerUpdateEvent) map[string]string {
+	hosts := make(map[string]string)
+	for _, event := range events {
+		hosts[event.UpstreamName] = event.UpstreamServers[0].Host
+	}
+
+	return hosts
+}
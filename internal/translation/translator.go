@@ -12,25 +12,91 @@ import (
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"net"
+	"strconv"
 	"strings"
 )
 
 // Translate transforms event data into an intermediate format that can be consumed by the BorderClient implementations
-// and used to update the Border Servers.
-func Translate(event *core.Event) (core.ServerUpdateEvents, error) {
+// and used to update the Border Servers. Warnings about invalid Service Annotations are recorded with the recorder, which may be nil.
+func Translate(event *core.Event, recorder record.EventRecorder) (core.ServerUpdateEvents, error) {
 	logrus.Debug("Translate::Translate")
 
-	portsOfInterest := filterPorts(event.Service.Spec.Ports)
+	upstreamMap := getUpstreamMap(event.Service, recorder)
+	portMappings := getPortMappings(event.Service, recorder)
+	portsOfInterest := filterPorts(event.Service.Spec.Ports, upstreamMap, portMappings)
 
-	return buildServerUpdateEvents(portsOfInterest, event)
+	events, err := buildServerUpdateEvents(portsOfInterest, upstreamMap, portMappings, event, recorder)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Type == core.Updated && event.PreviousService != nil {
+		logNodePortChanges(event, portsOfInterest)
+		events = append(events, buildStaleUpstreamEvents(event, events)...)
+	}
+
+	healthCheck := getHealthCheckHint(event.Service)
+	for _, serverUpdateEvent := range events {
+		serverUpdateEvent.Service = event.Service
+		serverUpdateEvent.ResourceVersion = event.Service.ResourceVersion
+		serverUpdateEvent.SpanContext = event.SpanContext
+		serverUpdateEvent.ObservedAt = event.ObservedAt
+		serverUpdateEvent.Resync = event.Resync
+
+		if serverUpdateEvent.ClientType == application.ClientTypeNginxHttp && serverUpdateEvent.Type != core.Deleted {
+			serverUpdateEvent.HealthCheck = healthCheck
+		}
+	}
+
+	return events, nil
+}
+
+// logNodePortChanges logs the ports whose nodePort changed, e.g. when the Service was recreated. The Updated events carry
+// the complete list of servers, on the new nodePort, and the Border Clients delete the servers of the upstream that are not
+// listed, so the servers on the previous nodePort are replaced rather than left in the upstream.
+func logNodePortChanges(event *core.Event, ports []v1.ServicePort) {
+	previousNodePorts := make(map[string]int32, len(event.PreviousService.Spec.Ports))
+	for _, port := range event.PreviousService.Spec.Ports {
+		previousNodePorts[port.Name] = port.NodePort
+	}
+
+	for _, port := range ports {
+		if previous, found := previousNodePorts[port.Name]; found && previous != port.NodePort {
+			logrus.Infof("Translate::logNodePortChanges: the nodePort of port %s of service %s/%s changed from %d to %d, replacing the servers on the previous nodePort",
+				port.Name, event.Service.Namespace, event.Service.Name, previous, port.NodePort)
+		}
+	}
 }
 
-// filterPorts returns a list of ports that have the NlkPrefix in the port name.
-func filterPorts(ports []v1.ServicePort) []v1.ServicePort {
+// getHealthCheckHint returns the HealthCheckHint of a Service whose externalTrafficPolicy is Local, nil otherwise.
+// The healthCheckNodePort is only allocated for the NodePort and LoadBalancer Services.
+func getHealthCheckHint(service *v1.Service) *core.HealthCheckHint {
+	if service.Spec.ExternalTrafficPolicy != v1.ServiceExternalTrafficPolicyLocal {
+		return nil
+	}
+
+	return &core.HealthCheckHint{
+		ExternalTrafficPolicy: service.Spec.ExternalTrafficPolicy,
+		HealthCheckNodePort:   service.Spec.HealthCheckNodePort,
+	}
+}
+
+// filterPorts returns a list of ports that are mapped by the ports annotation, that have the NlkPrefix in the port name,
+// or that are named in the upstream map. The ports mapped more than once by the ports annotation are skipped.
+func filterPorts(ports []v1.ServicePort, upstreamMap map[string]string, portMappings map[int32]*portMapping) []v1.ServicePort {
 	var portsOfInterest []v1.ServicePort
 
 	for _, port := range ports {
-		if strings.HasPrefix(port.Name, configuration.NlkPrefix) {
+		if mapping, found := portMappings[port.Port]; found {
+			if !mapping.conflicting {
+				portsOfInterest = append(portsOfInterest, port)
+			}
+			continue
+		}
+
+		if _, mapped := upstreamMap[port.Name]; mapped || strings.HasPrefix(port.Name, configuration.NlkPrefix) {
 			portsOfInterest = append(portsOfInterest, port)
 		}
 	}
@@ -38,25 +104,79 @@ func filterPorts(ports []v1.ServicePort) []v1.ServicePort {
 	return portsOfInterest
 }
 
+// applyDrainOnCordon returns the node IPs of the servers, and those of the draining servers, of the event. The cordoned
+// nodes are regular servers, unless the Service drains on cordon: they are then draining servers within the drain timeout,
+// and are removed afterwards. Deleted events keep the cordoned nodes, so that their servers do not linger in the upstream.
+func applyDrainOnCordon(event *core.Event, drainOnCordon bool) ([]string, []string) {
+	if !drainOnCordon || event.Type == core.Deleted || len(event.CordonedNodeIps) == 0 {
+		return event.NodeIps, event.DrainingNodeIps
+	}
+
+	nodeIps := make([]string, 0, len(event.NodeIps))
+	drainingNodeIps := append([]string{}, event.DrainingNodeIps...)
+
+	for _, nodeIp := range event.NodeIps {
+		draining, cordoned := event.CordonedNodeIps[nodeIp]
+		switch {
+		case !cordoned:
+			nodeIps = append(nodeIps, nodeIp)
+		case draining:
+			drainingNodeIps = append(drainingNodeIps, nodeIp)
+		}
+	}
+
+	return nodeIps, drainingNodeIps
+}
+
 // buildServerUpdateEvents builds a list of ServerUpdateEvents based on the event type
 // The NGINX+ Client uses a list of servers for Created and Updated events; the client performs reconciliation between
 // the list of servers in the NGINX+ Client call and the list of servers in NGINX+.
 // The NGINX+ Client uses a single server for Deleted events; so the list of servers is broken up into individual events.
-func buildServerUpdateEvents(ports []v1.ServicePort, event *core.Event) (core.ServerUpdateEvents, error) {
+func buildServerUpdateEvents(ports []v1.ServicePort, upstreamMap map[string]string, portMappings map[int32]*portMapping, event *core.Event, recorder record.EventRecorder) (core.ServerUpdateEvents, error) {
 	logrus.Debugf("Translate::buildServerUpdateEvents(ports=%#v)", ports)
 
 	events := core.ServerUpdateEvents{}
+	drainOnCordon := (len(event.DrainingNodeIps) > 0 || len(event.CordonedNodeIps) > 0) && getDrainOnCordon(event.Service, recorder)
+	nodeIps, drainingNodeIps := applyDrainOnCordon(event, drainOnCordon)
+	serverPorts := getServerPorts(event.Service, event.TargetPorts, recorder)
+
 	for _, port := range ports {
-		ingressName := fixIngressName(port.Name)
-		upstreamServers, _ := buildUpstreamServers(event.NodeIps, port)
-		clientType := getClientType(port.Name, event.Service.Annotations)
+		ingressName := applyUpstreamNameTemplate(event.UpstreamNameTemplate, event.Service, getUpstreamName(port, upstreamMap, portMappings))
+
+		serverPort, err := serverPorts.portOf(port)
+		if err != nil && event.Type == core.Deleted {
+			logrus.Infof("Translate::buildServerUpdateEvents: service %s/%s: %v; the servers of upstream %s are deleted on any port", event.Service.Namespace, event.Service.Name, err, ingressName)
+			events = append(events, buildAnyPortEvents(ingressName, getClientType(port, event.Service.Annotations, portMappings), nodeIps, drainingNodeIps)...)
+			continue
+		}
+
+		if err != nil {
+			recordUnresolvedPort(event.Service, recorder, err)
+			continue
+		}
+
+		parameters := getUpstreamParameters(port, event.Service, recorder)
+		upstreamServers, _ := buildUpstreamServers(nodeIps, event.NodeNames, event.BackupNodeIps, event.DownNodeIps, serverPort, parameters)
+
+		// The servers of unavailable nodes are drained if the Service asks for it, and are always included in
+		// Deleted events so that they do not linger in the upstream after the Service is gone.
+		if event.Type == core.Deleted || drainOnCordon {
+			drainingServers, _ := buildUpstreamServers(drainingNodeIps, event.NodeNames, event.BackupNodeIps, event.DownNodeIps, serverPort, parameters)
+			for _, server := range drainingServers {
+				server.Drain = true
+			}
+			upstreamServers = append(upstreamServers, drainingServers...)
+		}
+		clientType := getClientType(port, event.Service.Annotations, portMappings)
 
 		switch event.Type {
 		case core.Created:
 			fallthrough
 
 		case core.Updated:
-			events = append(events, core.NewServerUpdateEvent(event.Type, ingressName, clientType, upstreamServers))
+			serverUpdateEvent := core.NewServerUpdateEvent(event.Type, ingressName, clientType, upstreamServers)
+			serverUpdateEvent.EmptyServerPolicy = getEmptyServerPolicy(port, event.Service, recorder)
+			events = append(events, serverUpdateEvent)
 
 		case core.Deleted:
 			for _, server := range upstreamServers {
@@ -69,15 +189,39 @@ func buildServerUpdateEvents(ports []v1.ServicePort, event *core.Event) (core.Se
 
 	}
 
+	// the node addresses are written to the keyval zone along with the servers, and removed with the servers of a Deleted event
+	if keyValZone := getKeyValZone(event.Service, recorder); keyValZone != nil {
+		for _, serverUpdateEvent := range events {
+			serverUpdateEvent.KeyValZone = keyValZone
+		}
+	}
+
 	return events, nil
 }
 
-func buildUpstreamServers(nodeIps []string, port v1.ServicePort) (core.UpstreamServers, error) {
+// buildUpstreamServers builds an upstream server on the port of each node, its nodePort or host port, see serverPorts; the
+// node names are used to template the routes, the servers of the backup nodes are marked backup, and the servers of the
+// nodes whose state NLK manages are marked down, or up.
+func buildUpstreamServers(nodeIps []string, nodeNames map[string]string, backupNodeIps map[string]bool, downNodeIps map[string]bool, serverPort int32, parameters upstreamParameters) (core.UpstreamServers, error) {
 	var servers core.UpstreamServers
 
 	for _, nodeIp := range nodeIps {
-		host := fmt.Sprintf("%s:%d", nodeIp, port.NodePort)
+		// JoinHostPort brackets IPv6 addresses, e.g. [fd00::1]:30080
+		host := net.JoinHostPort(nodeIp, strconv.Itoa(int(serverPort)))
 		server := core.NewUpstreamServer(host)
+		server.Weight = parameters.weight
+		server.MaxFails = parameters.maxFails
+		server.FailTimeout = parameters.failTimeout
+		server.Route = parameters.route(nodeIp, nodeNames[nodeIp])
+		server.Service = parameters.service
+		server.SlowStart = parameters.slowStart
+		if backupNodeIps != nil {
+			backup := backupNodeIps[nodeIp]
+			server.Backup = &backup
+		}
+		if down, managed := downNodeIps[nodeIp]; managed {
+			server.Down = &down
+		}
 		servers = append(servers, server)
 	}
 
@@ -89,15 +233,30 @@ func fixIngressName(name string) string {
 	return name[4:]
 }
 
-// getClientType returns the client type for the port, defaults to ClientTypeNginxHttp if no Annotation is found.
-func getClientType(portName string, annotations map[string]string) string {
-	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, portName)
+// getClientType returns the client type for the port: the mapped client type if the port is mapped by the ports annotation,
+// otherwise the port Annotation, defaults to ClientTypeNginxHttp if no Annotation is found.
+// UDP ports always use ClientTypeNginxUdp, so they are never pushed to a TCP upstream.
+func getClientType(port v1.ServicePort, annotations map[string]string, portMappings map[int32]*portMapping) string {
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, port.Name)
 	logrus.Infof("getClientType: key=%s", key)
-	if annotations != nil {
-		if clientType, ok := annotations[key]; ok {
-			return clientType
+
+	clientType := application.ClientTypeNginxHttp
+	if mapping, found := portMappings[port.Port]; found {
+		clientType = mapping.clientType
+	} else if annotations != nil {
+		if annotatedClientType, ok := annotations[key]; ok {
+			clientType = annotatedClientType
 		}
 	}
 
-	return application.ClientTypeNginxHttp
+	if port.Protocol == v1.ProtocolUDP && clientType != application.ClientTypeNginxUdp {
+		logrus.Warnf("getClientType: port %s uses the UDP protocol, using the %s client type instead of %s", port.Name, application.ClientTypeNginxUdp, clientType)
+		return application.ClientTypeNginxUdp
+	}
+
+	if port.Protocol != v1.ProtocolUDP && clientType == application.ClientTypeNginxUdp {
+		logrus.Warnf("getClientType: port %s is annotated with the %s client type but uses the %s protocol", port.Name, application.ClientTypeNginxUdp, port.Protocol)
+	}
+
+	return clientType
 }
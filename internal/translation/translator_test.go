@@ -7,10 +7,13 @@ package translation
 
 import (
 	"fmt"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/application"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
 	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 )
@@ -33,7 +36,7 @@ func TestCreatedTranslateNoPorts(t *testing.T) {
 	service := defaultService()
 	event := buildCreatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -52,7 +55,7 @@ func TestCreatedTranslateNoInterestingPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildCreatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -71,7 +74,7 @@ func TestCreatedTranslateOneInterestingPort(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildCreatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -92,7 +95,7 @@ func TestCreatedTranslateManyInterestingPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildCreatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -114,7 +117,7 @@ func TestCreatedTranslateManyMixedPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildCreatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -136,7 +139,7 @@ func TestCreatedTranslateManyMixedPortsAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildCreatedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -159,7 +162,7 @@ func TestUpdatedTranslateNoPorts(t *testing.T) {
 	service := defaultService()
 	event := buildUpdatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -178,7 +181,7 @@ func TestUpdatedTranslateNoInterestingPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildUpdatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -197,7 +200,7 @@ func TestUpdatedTranslateOneInterestingPort(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildUpdatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -218,7 +221,7 @@ func TestUpdatedTranslateManyInterestingPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildUpdatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -240,7 +243,7 @@ func TestUpdatedTranslateManyMixedPorts(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildUpdatedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -262,7 +265,7 @@ func TestUpdatedTranslateManyMixedPortsAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildUpdatedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -285,7 +288,7 @@ func TestDeletedTranslateNoPortsAndNoNodes(t *testing.T) {
 	service := defaultService()
 	event := buildDeletedEvent(service, NoNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -306,7 +309,7 @@ func TestDeletedTranslateNoInterestingPortsAndNoNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, NoNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -327,7 +330,7 @@ func TestDeletedTranslateOneInterestingPortAndNoNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, NoNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -348,7 +351,7 @@ func TestDeletedTranslateManyInterestingPortsAndNoNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, NoNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -370,7 +373,7 @@ func TestDeletedTranslateManyMixedPortsAndNoNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, NoNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -389,7 +392,7 @@ func TestDeletedTranslateNoPortsAndOneNode(t *testing.T) {
 	service := defaultService()
 	event := buildDeletedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -410,7 +413,7 @@ func TestDeletedTranslateNoInterestingPortsAndOneNode(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -431,7 +434,7 @@ func TestDeletedTranslateOneInterestingPortAndOneNode(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -452,7 +455,7 @@ func TestDeletedTranslateManyInterestingPortsAndOneNode(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -474,7 +477,7 @@ func TestDeletedTranslateManyMixedPortsAndOneNode(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, OneNode)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -493,7 +496,7 @@ func TestDeletedTranslateNoPortsAndManyNodes(t *testing.T) {
 	service := defaultService()
 	event := buildDeletedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -515,7 +518,7 @@ func TestDeletedTranslateNoInterestingPortsAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -536,7 +539,7 @@ func TestDeletedTranslateOneInterestingPortAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -557,7 +560,7 @@ func TestDeletedTranslateManyInterestingPortsAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -579,7 +582,7 @@ func TestDeletedTranslateManyMixedPortsAndManyNodes(t *testing.T) {
 	service := serviceWithPorts(ports)
 	event := buildDeletedEvent(service, ManyNodes)
 
-	translatedEvents, err := Translate(&event)
+	translatedEvents, err := Translate(&event, nil)
 	if err != nil {
 		t.Fatalf(TranslateErrorFormat, err)
 	}
@@ -601,6 +604,451 @@ func assertExpectedServerCount(t *testing.T, expectedCount int, events core.Serv
 	}
 }
 
+func TestTranslateUdpPorts(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-dns", Protocol: v1.ProtocolTCP, Port: 53, NodePort: 30053},
+		{Name: "nlk-dns-udp", Protocol: v1.ProtocolUDP, Port: 53, NodePort: 30054},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/nlk-dns":     application.ClientTypeNginxStream,
+		"nginxinc.io/nlk-dns-udp": application.ClientTypeNginxStream,
+	}
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 2 {
+		t.Fatalf(AssertionFailureFormat, 2, len(translatedEvents))
+	}
+
+	expected := map[string]string{
+		"dns":     application.ClientTypeNginxStream,
+		"dns-udp": application.ClientTypeNginxUdp,
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		if expected[translatedEvent.UpstreamName] != translatedEvent.ClientType {
+			t.Errorf(`expected upstream %s to use the %s client type, got %s`, translatedEvent.UpstreamName, expected[translatedEvent.UpstreamName], translatedEvent.ClientType)
+		}
+	}
+
+	if translatedEvents[0].UpstreamServers[0].Host == translatedEvents[1].UpstreamServers[0].Host {
+		t.Errorf(`expected distinct upstream servers for the TCP and UDP ports`)
+	}
+}
+
+func TestTranslateUdpPortWithoutAnnotation(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-syslog", Protocol: v1.ProtocolUDP, Port: 514, NodePort: 30514},
+	})
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 1 || translatedEvents[0].ClientType != application.ClientTypeNginxUdp {
+		t.Fatalf(`expected a single %s event, got %#v`, application.ClientTypeNginxUdp, translatedEvents)
+	}
+}
+
+func TestTranslateUpstreamParameters(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-tcp", Protocol: v1.ProtocolTCP, Port: 5432, NodePort: 30432},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/nlk-tcp":        application.ClientTypeNginxStream,
+		"nginxinc.io/weight":         "2",
+		"nginxinc.io/max-fails":      "3",
+		"nginxinc.io/fail-timeout":   "30s",
+		"nginxinc.io/nlk-tcp.weight": "5",
+		"nginxinc.io/slow-start":     "1m",
+	}
+
+	event := buildCreatedEvent(service, ManyNodes)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	expectedWeights := map[string]int{"http": 2, "tcp": 5}
+
+	for _, translatedEvent := range translatedEvents {
+		for _, server := range translatedEvent.UpstreamServers {
+			if server.Weight == nil || *server.Weight != expectedWeights[translatedEvent.UpstreamName] {
+				t.Errorf(`expected upstream %s servers to have weight %d, got %v`, translatedEvent.UpstreamName, expectedWeights[translatedEvent.UpstreamName], server.Weight)
+			}
+
+			if server.MaxFails == nil || *server.MaxFails != 3 {
+				t.Errorf(`expected upstream %s servers to have max fails 3, got %v`, translatedEvent.UpstreamName, server.MaxFails)
+			}
+
+			if server.FailTimeout != "30s" {
+				t.Errorf(`expected upstream %s servers to have fail timeout 30s, got %q`, translatedEvent.UpstreamName, server.FailTimeout)
+			}
+
+			if server.SlowStart != "1m" {
+				t.Errorf(`expected upstream %s servers to have slow start 1m, got %q`, translatedEvent.UpstreamName, server.SlowStart)
+			}
+		}
+	}
+}
+
+func TestTranslateInvalidUpstreamParametersUseDefaults(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/nlk-http.weight": "0",
+		"nginxinc.io/max-fails":       "many",
+		"nginxinc.io/fail-timeout":    "soon",
+		"nginxinc.io/slow-start":      "gently",
+	}
+
+	recorder := record.NewFakeRecorder(4)
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	server := translatedEvents[0].UpstreamServers[0]
+	if server.Weight != nil || server.MaxFails != nil || server.FailTimeout != "" || server.SlowStart != "" {
+		t.Errorf(`expected the invalid annotations to be ignored, got %#v`, server)
+	}
+
+	if len(recorder.Events) != 4 {
+		t.Fatalf(`expected 4 Warning Events, got %d`, len(recorder.Events))
+	}
+
+	for i := 0; i < 4; i++ {
+		if recorded := <-recorder.Events; !strings.Contains(recorded, configuration.InvalidAnnotationReason) {
+			t.Errorf(`expected an %s Event, got %s`, configuration.InvalidAnnotationReason, recorded)
+		}
+	}
+}
+
+func TestTranslateRouteAndService(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/route-template": "{node}-{address}",
+		"nginxinc.io/service":        "_http._tcp",
+	}
+
+	event := buildCreatedEvent(service, 0)
+	event.NodeIps = []string{"10.0.0.1", "10.0.0.2"}
+	event.NodeNames = map[string]string{"10.0.0.1": "worker-1", "10.0.0.2": "worker-2"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	servers := translatedEvents[0].UpstreamServers
+	if len(servers) != 2 || servers[0].Route != "worker-1-10.0.0.1" || servers[1].Route != "worker-2-10.0.0.2" {
+		t.Fatalf(`expected a route per node, got %#v`, servers)
+	}
+
+	for _, server := range servers {
+		if server.Service != "_http._tcp" {
+			t.Errorf(`expected the service to be set, got %q`, server.Service)
+		}
+	}
+}
+
+func TestTranslateRouteLongerThanNginxPlusAcceptsIsOmitted(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+	service.Annotations = map[string]string{"nginxinc.io/route-template": "{node}"}
+
+	event := buildCreatedEvent(service, 0)
+	event.NodeIps = []string{"10.0.0.1"}
+	event.NodeNames = map[string]string{"10.0.0.1": strings.Repeat("worker", 6)}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if route := translatedEvents[0].UpstreamServers[0].Route; route != "" {
+		t.Errorf(`expected no route, got %q`, route)
+	}
+}
+
+func TestTranslateInvalidRouteAndServiceAreIgnored(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/nlk-http.route-template": "{node} a",
+		"nginxinc.io/service":                 "",
+	}
+
+	recorder := record.NewFakeRecorder(2)
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	server := translatedEvents[0].UpstreamServers[0]
+	if server.Route != "" || server.Service != "" {
+		t.Errorf(`expected the invalid annotations to be ignored, got %#v`, server)
+	}
+
+	if len(recorder.Events) != 2 {
+		t.Fatalf(`expected 2 Warning Events, got %d`, len(recorder.Events))
+	}
+}
+
+func TestTranslateDrainOnCordon(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+	service.Annotations = map[string]string{"nginxinc.io/drain-on-cordon": "true"}
+
+	event := buildCreatedEvent(service, 3)
+	event.CordonedNodeIps = map[string]bool{"10.0.0.1": true, "10.0.0.2": false}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	servers := translatedEvents[0].UpstreamServers
+	if len(servers) != 2 {
+		t.Fatalf(`expected the cordoned node past the drain timeout to be removed, got %d servers`, len(servers))
+	}
+
+	if !strings.HasPrefix(servers[0].Host, "10.0.0.0:") || servers[0].Drain || !strings.HasPrefix(servers[1].Host, "10.0.0.1:") || !servers[1].Drain {
+		t.Errorf(`expected only the server of the cordoned node to be draining, got %#v`, servers)
+	}
+}
+
+func TestTranslateWithoutDrainOnCordonKeepsCordonedNodes(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+
+	event := buildUpdatedEvent(service, 2)
+	event.CordonedNodeIps = map[string]bool{"10.0.0.1": true}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	servers := translatedEvents[0].UpstreamServers
+	if len(servers) != 2 || !strings.HasPrefix(servers[1].Host, "10.0.0.1:") || servers[1].Drain {
+		t.Errorf(`expected the server of the cordoned node to be kept, got %#v`, servers)
+	}
+}
+
+func TestTranslateWithoutDrainOnCordonRemovesUnavailableNodes(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+
+	event := buildUpdatedEvent(service, OneNode)
+	event.DrainingNodeIps = []string{"10.0.1.1"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	assertExpectedServerCount(t, OneNode, translatedEvents)
+}
+
+func TestTranslateDeletedIncludesDrainingNodes(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+
+	event := buildDeletedEvent(service, OneNode)
+	event.DrainingNodeIps = []string{"10.0.1.1"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 2 {
+		t.Fatalf(AssertionFailureFormat, 2, len(translatedEvents))
+	}
+}
+
+func TestTranslateBracketsIPv6Addresses(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+
+	event := buildCreatedEvent(service, NoNodes)
+	event.NodeIps = []string{"10.0.0.1", "fd00::1"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	servers := translatedEvents[0].UpstreamServers
+	if len(servers) != 2 || servers[0].Host != "10.0.0.1:30080" || servers[1].Host != "[fd00::1]:30080" {
+		t.Fatalf(`expected an upstream server for each address family, got %#v`, servers)
+	}
+}
+
+func TestTranslateDeletedDualStackRemovesBothFamilies(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+	})
+
+	event := buildDeletedEvent(service, NoNodes)
+	event.NodeIps = []string{"10.0.0.1", "fd00::1"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 2 || translatedEvents[1].UpstreamServers[0].Host != "[fd00::1]:30080" {
+		t.Fatalf(`expected a Deleted event for each address family, got %#v`, translatedEvents)
+	}
+}
+
+func TestTranslateReferencesTheService(t *testing.T) {
+	service := serviceWithPorts(generatePorts(2))
+	event := buildDeletedEvent(service, ManyNodes)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		if translatedEvent.Service != service {
+			t.Fatalf(`expected each event to reference the Service, got %#v`, translatedEvent.Service)
+		}
+	}
+}
+
+func TestTranslateHealthCheckHintOfTheLocalPolicy(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-web", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-dns", Protocol: v1.ProtocolTCP, Port: 53, NodePort: 30053},
+	})
+	service.Annotations = map[string]string{"nginxinc.io/nlk-dns": application.ClientTypeNginxStream}
+	service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyLocal
+	service.Spec.HealthCheckNodePort = 32000
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		if translatedEvent.ClientType == application.ClientTypeNginxStream && translatedEvent.HealthCheck != nil {
+			t.Errorf(`expected no health check hint for the stream upstream, got %#v`, translatedEvent.HealthCheck)
+		}
+
+		if translatedEvent.ClientType == application.ClientTypeNginxHttp &&
+			(translatedEvent.HealthCheck == nil || translatedEvent.HealthCheck.HealthCheckNodePort != 32000) {
+			t.Errorf(`expected the healthCheckNodePort on the http upstream, got %#v`, translatedEvent.HealthCheck)
+		}
+	}
+
+	service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyCluster
+
+	translatedEvents, err = Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		if translatedEvent.HealthCheck != nil {
+			t.Errorf(`expected no health check hint with the Cluster policy, got %#v`, translatedEvent.HealthCheck)
+		}
+	}
+}
+
+func TestTranslateKeyValZone(t *testing.T) {
+	zones := map[string]core.KeyValZone{
+		"allowed_nodes":        {Name: "allowed_nodes", Owner: "nginx-ingress/nginx-ingress"},
+		"stream:allowed_nodes": {Name: "allowed_nodes", Stream: true, Owner: "nginx-ingress/nginx-ingress"},
+	}
+
+	for value, expected := range zones {
+		service := serviceWithPorts(generatePorts(2))
+		service.Namespace = "nginx-ingress"
+		service.Name = "nginx-ingress"
+		service.Annotations = map[string]string{"nginxinc.io/keyval-zone": value}
+
+		event := buildCreatedEvent(service, OneNode)
+
+		translatedEvents, err := Translate(&event, nil)
+		if err != nil {
+			t.Fatalf(TranslateErrorFormat, err)
+		}
+
+		for _, translatedEvent := range translatedEvents {
+			if translatedEvent.KeyValZone == nil || *translatedEvent.KeyValZone != expected {
+				t.Errorf(`expected the zone %#v for %q, got %#v`, expected, value, translatedEvent.KeyValZone)
+			}
+		}
+	}
+}
+
+func TestTranslateInvalidKeyValZoneIsIgnored(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+	service.Annotations = map[string]string{"nginxinc.io/keyval-zone": "allowed nodes"}
+	recorder := record.NewFakeRecorder(1)
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, recorder)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if translatedEvents[0].KeyValZone != nil {
+		t.Errorf(`expected no zone, got %#v`, translatedEvents[0].KeyValZone)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Errorf(`expected a Warning Event for the invalid zone, got %d`, len(recorder.Events))
+	}
+}
+
+func TestTranslateStaleUpstreamsKeepTheKeyValZone(t *testing.T) {
+	ports := []v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080}}
+
+	previousService := serviceWithPorts(ports)
+	previousService.Annotations = map[string]string{"nginxinc.io/keyval-zone": "allowed_nodes"}
+	service := serviceWithPorts(ports)
+	service.Annotations = map[string]string{"nginxinc.io/keyval-zone": "allowed_nodes", "nginxinc.io/upstream-map": "http=prod-http-upstream"}
+
+	event := buildUpdatedEvent(service, ManyNodes)
+	event.PreviousService = previousService
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	// the nodes are still servers of the renamed upstream, so the keys are not deleted with the stale servers
+	for _, translatedEvent := range translatedEvents {
+		if (translatedEvent.KeyValZone != nil) != (translatedEvent.Type != core.Deleted) {
+			t.Errorf(`expected only the Updated event to name the zone, got %s %#v`, translatedEvent.TypeName(), translatedEvent.KeyValZone)
+		}
+	}
+}
+
 func defaultService() *v1.Service {
 	return &v1.Service{}
 }
@@ -676,3 +1124,135 @@ func generateUpdatablePorts(portCount int, updatableCount int) []v1.ServicePort
 
 	return ports
 }
+
+func TestIsIgnored(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+	recorder := record.NewFakeRecorder(1)
+
+	if IsIgnored(service, recorder) {
+		t.Fatal(`expected a Service without the annotation not to be ignored`)
+	}
+
+	service.Annotations = map[string]string{"nginxinc.io/ignore": "true"}
+	if !IsIgnored(service, recorder) {
+		t.Fatal(`expected the annotated Service to be ignored`)
+	}
+
+	service.Annotations["nginxinc.io/ignore"] = "yes please"
+	if IsIgnored(service, recorder) || len(recorder.Events) != 1 {
+		t.Fatal(`expected an invalid value not to ignore the Service, and to record a Warning Event`)
+	}
+}
+
+func TestTranslateBackupNodes(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-tcp", Protocol: v1.ProtocolTCP, Port: 5432, NodePort: 30432},
+	})
+	service.Annotations = map[string]string{"nginxinc.io/nlk-tcp": application.ClientTypeNginxStream}
+
+	event := buildCreatedEvent(service, 0)
+	event.NodeIps = []string{"10.0.0.1", "10.0.0.2"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, server := range translatedEvents[0].UpstreamServers {
+		if server.Backup != nil {
+			t.Fatalf(`expected the backup parameter to be left alone without backup nodes, got %#v`, server)
+		}
+	}
+
+	event.BackupNodeIps = map[string]bool{"10.0.0.2": true}
+
+	translatedEvents, err = Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		servers := translatedEvent.UpstreamServers
+		if len(servers) != 2 || servers[0].Backup == nil || *servers[0].Backup || servers[1].Backup == nil || !*servers[1].Backup {
+			t.Fatalf(`expected the servers of upstream %s on the backup node to be backup, got %#v`, translatedEvent.UpstreamName, servers)
+		}
+	}
+}
+
+func TestTranslateDownNodes(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-tcp", Protocol: v1.ProtocolTCP, Port: 5432, NodePort: 30432},
+	})
+	service.Annotations = map[string]string{"nginxinc.io/nlk-tcp": application.ClientTypeNginxStream}
+
+	event := buildCreatedEvent(service, 0)
+	event.NodeIps = []string{"10.0.0.1", "10.0.0.2"}
+	event.DownNodeIps = map[string]bool{"10.0.0.2": true}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		servers := translatedEvent.UpstreamServers
+		if len(servers) != 2 || servers[0].Down != nil || !servers[1].IsDown() {
+			t.Fatalf(`expected the servers of upstream %s on the down node to be down, got %#v`, translatedEvent.UpstreamName, servers)
+		}
+	}
+
+	// the node is no longer marked down
+	event.DownNodeIps = map[string]bool{"10.0.0.2": false}
+
+	translatedEvents, _ = Translate(&event, nil)
+	for _, server := range translatedEvents[0].UpstreamServers {
+		if server.IsDown() {
+			t.Fatalf(`expected the servers to be up, got %#v`, server)
+		}
+	}
+
+	if down := translatedEvents[0].UpstreamServers[1].Down; down == nil || *down {
+		t.Fatalf(`expected the server of the managed node to be brought up, got %v`, down)
+	}
+}
+
+func TestTranslateEmptyServerPolicy(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-tcp", Protocol: v1.ProtocolTCP, Port: 5432, NodePort: 30432},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/nlk-tcp":                     application.ClientTypeNginxStream,
+		"nginxinc.io/empty-server-policy":         "retain",
+		"nginxinc.io/nlk-tcp.empty-server-policy": "Fail",
+	}
+
+	event := buildCreatedEvent(service, 0)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	expected := map[string]string{"http": "retain", "tcp": "fail"}
+	for _, translatedEvent := range translatedEvents {
+		if translatedEvent.EmptyServerPolicy != expected[translatedEvent.UpstreamName] {
+			t.Errorf(`expected the %s policy for upstream %s, got %q`, expected[translatedEvent.UpstreamName], translatedEvent.UpstreamName, translatedEvent.EmptyServerPolicy)
+		}
+	}
+
+	// an invalid policy is ignored, so the setting applies
+	service.Annotations["nginxinc.io/empty-server-policy"] = "ignore"
+	recorder := record.NewFakeRecorder(1)
+
+	translatedEvents, _ = Translate(&event, recorder)
+	if translatedEvents[0].EmptyServerPolicy != "" {
+		t.Errorf(`expected the invalid policy to be ignored, got %q`, translatedEvents[0].EmptyServerPolicy)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected 1 Warning Event, got %d`, len(recorder.Events))
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// getUpstreamMap parses the upstream map annotation, e.g. `nginxinc.io/upstream-map: "http=prod-http,https=prod-https"`,
+// into a map of port name to upstream name. A port may be named with or without the NlkPrefix, and a mapped port does
+// not need the NlkPrefix to be handled. Invalid entries are ignored and a Warning Event is recorded on the Service.
+func getUpstreamMap(service *v1.Service, recorder record.EventRecorder) map[string]string {
+	upstreamMap := make(map[string]string)
+
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.UpstreamMapAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return upstreamMap
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		portName, upstreamName, found := strings.Cut(entry, "=")
+		portName = strings.TrimSpace(portName)
+		upstreamName = strings.TrimSpace(upstreamName)
+
+		if !found || portName == "" || upstreamName == "" {
+			recordInvalidAnnotation(service, recorder, key, entry, "entries must be port-name=upstream-name")
+			continue
+		}
+
+		upstreamMap[portName] = upstreamName
+		if !strings.HasPrefix(portName, configuration.NlkPrefix) {
+			upstreamMap[configuration.NlkPrefix+portName] = upstreamName
+		}
+	}
+
+	return upstreamMap
+}
+
+// getUpstreamName returns the name of the upstream targeted by the port: the upstream name of the ports annotation if
+// the port is mapped by it, the mapped upstream name if the port is named in the upstream map, otherwise the port name
+// without the NlkPrefix.
+func getUpstreamName(port v1.ServicePort, upstreamMap map[string]string, portMappings map[int32]*portMapping) string {
+	if mapping, found := portMappings[port.Port]; found {
+		return mapping.upstreamName
+	}
+
+	if upstreamName, ok := upstreamMap[port.Name]; ok {
+		return upstreamName
+	}
+
+	return fixIngressName(port.Name)
+}
+
+// applyUpstreamNameTemplate replaces the {name} and {namespace} placeholders of the template with the upstream name and
+// the namespace of the Service, e.g. "{namespace}-{name}" names the upstream "nginx-ingress-internal-http".
+func applyUpstreamNameTemplate(template string, service *v1.Service, upstreamName string) string {
+	if template == "" {
+		return upstreamName
+	}
+
+	return strings.NewReplacer(
+		configuration.UpstreamNameTemplateName, upstreamName,
+		configuration.UpstreamNameTemplateNamespace, service.Namespace,
+	).Replace(template)
+}
+
+// buildStaleUpstreamEvents builds Deleted events for the servers of the upstreams that were targeted by the previous
+// state of the Service but are no longer targeted, e.g. because the upstream map changed or a port was renamed, so that
+// stale servers do not linger in the previously targeted upstreams. The servers are deleted on the nodes of the previous
+// event of the Service, see core.Event::PreviousNodeIps, as well as the current nodes.
+func buildStaleUpstreamEvents(event *core.Event, events core.ServerUpdateEvents) core.ServerUpdateEvents {
+	targeted := make(map[string]bool)
+	for _, serverUpdateEvent := range events {
+		targeted[serverUpdateEvent.UpstreamName] = true
+	}
+
+	// warnings were recorded when the previous state of the Service was translated
+	previousUpstreamMap := getUpstreamMap(event.PreviousService, nil)
+	previousPortMappings := getPortMappings(event.PreviousService, nil)
+	nodeIps := distinct(event.PreviousNodeIps, event.NodeIps, event.DrainingNodeIps)
+	previousServerPorts := getServerPorts(event.PreviousService, event.TargetPorts, nil)
+
+	staleEvents := core.ServerUpdateEvents{}
+	for _, port := range filterPorts(event.PreviousService.Spec.Ports, previousUpstreamMap, previousPortMappings) {
+		upstreamName := applyUpstreamNameTemplate(event.UpstreamNameTemplate, event.PreviousService, getUpstreamName(port, previousUpstreamMap, previousPortMappings))
+		if targeted[upstreamName] {
+			continue
+		}
+
+		logrus.Infof("Translate::buildStaleUpstreamEvents: service %s/%s no longer targets upstream %s, removing its servers", event.Service.Namespace, event.Service.Name, upstreamName)
+
+		clientType := getClientType(port, event.PreviousService.Annotations, previousPortMappings)
+
+		serverPort, err := previousServerPorts.portOf(port)
+		if err != nil {
+			staleEvents = append(staleEvents, buildAnyPortEvents(upstreamName, clientType, nodeIps, nil)...)
+			targeted[upstreamName] = true
+			continue
+		}
+
+		servers, _ := buildUpstreamServers(nodeIps, nil, nil, nil, serverPort, upstreamParameters{})
+		for _, server := range servers {
+			staleEvents = append(staleEvents, core.NewServerUpdateEvent(core.Deleted, upstreamName, clientType, core.UpstreamServers{server}))
+		}
+
+		targeted[upstreamName] = true
+	}
+
+	return staleEvents
+}
+
+// distinct returns the node IPs of the lists, each once, in the order they are first listed.
+func distinct(lists ...[]string) []string {
+	seen := make(map[string]bool)
+
+	var nodeIps []string
+	for _, list := range lists {
+		for _, nodeIp := range list {
+			if !seen[nodeIp] {
+				seen[nodeIp] = true
+				nodeIps = append(nodeIps, nodeIp)
+			}
+		}
+	}
+
+	return nodeIps
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestTranslateUpstreamMap(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{
+		{Name: "http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "nlk-https", Protocol: v1.ProtocolTCP, Port: 443, NodePort: 30443},
+		{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9113, NodePort: 30913},
+	})
+	service.Annotations = map[string]string{
+		"nginxinc.io/upstream-map": "http=prod-http-upstream, https=prod-https-upstream",
+	}
+
+	event := buildCreatedEvent(service, OneNode)
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	upstreamNames := map[string]bool{}
+	for _, translatedEvent := range translatedEvents {
+		upstreamNames[translatedEvent.UpstreamName] = true
+	}
+
+	if len(upstreamNames) != 2 || !upstreamNames["prod-http-upstream"] || !upstreamNames["prod-https-upstream"] {
+		t.Fatalf(`expected the mapped upstreams only, got %v`, upstreamNames)
+	}
+}
+
+func TestTranslateInvalidUpstreamMapEntryRecordsEvent(t *testing.T) {
+	service := serviceWithPorts(generatePorts(1))
+	service.Annotations = map[string]string{"nginxinc.io/upstream-map": "http"}
+	recorder := record.NewFakeRecorder(1)
+
+	event := buildCreatedEvent(service, OneNode)
+
+	if _, err := Translate(&event, recorder); err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf(`expected a Warning Event for the invalid entry, got %d`, len(recorder.Events))
+	}
+}
+
+func TestTranslateUpstreamRenameRemovesStaleServers(t *testing.T) {
+	ports := []v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080}}
+
+	previousService := serviceWithPorts(ports)
+	service := serviceWithPorts(ports)
+	service.Annotations = map[string]string{"nginxinc.io/upstream-map": "http=prod-http-upstream"}
+
+	event := buildUpdatedEvent(service, ManyNodes)
+	event.PreviousService = previousService
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 1+ManyNodes {
+		t.Fatalf(AssertionFailureFormat, 1+ManyNodes, len(translatedEvents))
+	}
+
+	if translatedEvents[0].Type != core.Updated || translatedEvents[0].UpstreamName != "prod-http-upstream" {
+		t.Errorf(`expected the new upstream to be updated, got %s %s`, translatedEvents[0].TypeName(), translatedEvents[0].UpstreamName)
+	}
+
+	for _, translatedEvent := range translatedEvents[1:] {
+		if translatedEvent.Type != core.Deleted || translatedEvent.UpstreamName != "http" {
+			t.Errorf(`expected the servers of the previous upstream to be deleted, got %s %s`, translatedEvent.TypeName(), translatedEvent.UpstreamName)
+		}
+	}
+}
+
+func TestTranslateUpstreamRenameRemovesTheStaleServersOfTheRemovedNodes(t *testing.T) {
+	ports := []v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080}}
+
+	previousService := serviceWithPorts(ports)
+	service := serviceWithPorts(ports)
+	service.Annotations = map[string]string{"nginxinc.io/upstream-map": "http=prod-http-upstream"}
+
+	event := buildUpdatedEvent(service, 1)
+	event.PreviousService = previousService
+	event.PreviousNodeIps = []string{event.NodeIps[0], "10.0.0.99"}
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 3 {
+		t.Fatalf(AssertionFailureFormat, 3, len(translatedEvents))
+	}
+
+	// the node removed since the previous event of the Service holds a server of the previous upstream too
+	deleted := make(map[string]bool)
+	for _, translatedEvent := range translatedEvents[1:] {
+		deleted[translatedEvent.UpstreamServers[0].Host] = true
+	}
+
+	if len(deleted) != 2 || !deleted["10.0.0.99:30080"] || !deleted[event.NodeIps[0]+":30080"] {
+		t.Fatalf(`expected the servers of the previous and the current nodes to be deleted once, got %v`, deleted)
+	}
+}
+
+func TestTranslateNodePortChangeReplacesTheServers(t *testing.T) {
+	previousService := serviceWithPorts([]v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080}})
+	service := serviceWithPorts([]v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 31080}})
+
+	event := buildUpdatedEvent(service, ManyNodes)
+	event.PreviousService = previousService
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	// the Updated event lists every server of the upstream, so the Border Clients delete the servers on the previous nodePort
+	if len(translatedEvents) != 1 || translatedEvents[0].Type != core.Updated {
+		t.Fatalf(`expected a single Updated event, got %d events`, len(translatedEvents))
+	}
+
+	for _, server := range translatedEvents[0].UpstreamServers {
+		if !strings.HasSuffix(server.Host, ":31080") {
+			t.Errorf(`expected the servers to be on the new nodePort, got %s`, server.Host)
+		}
+	}
+}
+
+func TestTranslateUnchangedUpstreamsRemoveNothing(t *testing.T) {
+	service := serviceWithPorts(generatePorts(2))
+
+	event := buildUpdatedEvent(service, ManyNodes)
+	event.PreviousService = service
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	for _, translatedEvent := range translatedEvents {
+		if translatedEvent.Type == core.Deleted {
+			t.Fatalf(`expected no Deleted events, got one for upstream %s`, translatedEvent.UpstreamName)
+		}
+	}
+}
+
+func TestTranslateUpstreamNameTemplate(t *testing.T) {
+	service := serviceWithPorts([]v1.ServicePort{{Name: "nlk-http", Protocol: v1.ProtocolTCP, Port: 80, NodePort: 30080}})
+	service.Namespace = "nginx-ingress-internal"
+
+	event := buildCreatedEvent(service, OneNode)
+	event.UpstreamNameTemplate = "{namespace}-{name}"
+
+	translatedEvents, err := Translate(&event, nil)
+	if err != nil {
+		t.Fatalf(TranslateErrorFormat, err)
+	}
+
+	if len(translatedEvents) != 1 || translatedEvents[0].UpstreamName != "nginx-ingress-internal-http" {
+		t.Fatalf(`expected the upstream to be named after the namespace, got %v`, translatedEvents)
+	}
+}
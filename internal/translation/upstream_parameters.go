@@ -0,0 +1,229 @@
+/*
+ * Copyright 2023 F5 Inc. All rights reserved.
+ * Use of this source code is governed by the Apache License that can be found in the LICENSE file.
+ */
+
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/configuration"
+	"github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+
+	// routeTemplateNode is replaced with the name of the node of the upstream server in the RouteTemplateAnnotation.
+	routeTemplateNode = "{node}"
+
+	// routeTemplateAddress is replaced with the address of the node of the upstream server in the RouteTemplateAnnotation.
+	routeTemplateAddress = "{address}"
+
+	// maxRouteLength is the longest route NGINX Plus accepts.
+	maxRouteLength = 32
+)
+
+// timePattern matches the NGINX time formats accepted for fail_timeout and slow_start, e.g.: "10", "10s", "1m30s", "500ms".
+var timePattern = regexp.MustCompile(`^([0-9]+|([0-9]+(ms|s|m|h|d))+)$`)
+
+// zoneNamePattern matches the names of the NGINX Plus shared memory zones, e.g. the keyval zones.
+var zoneNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// upstreamParameters are the optional upstream server parameters read from the Service Annotations.
+type upstreamParameters struct {
+	weight        *int
+	maxFails      *int
+	failTimeout   string
+	routeTemplate string
+	service       string
+	slowStart     string
+}
+
+// getUpstreamParameters reads the upstream server parameters for the port from the Service Annotations.
+// A per-port annotation, e.g. `nginxinc.io/nlk-http.weight`, takes precedence over the Service-wide annotation,
+// e.g. `nginxinc.io/weight`. Invalid values are ignored, so the NGINX Plus defaults apply, and a Warning Event
+// is recorded on the Service.
+func getUpstreamParameters(port v1.ServicePort, service *v1.Service, recorder record.EventRecorder) upstreamParameters {
+	parameters := upstreamParameters{}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.WeightAnnotation); ok {
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 1 {
+			recordInvalidAnnotation(service, recorder, key, value, "must be a positive integer")
+		} else {
+			parameters.weight = &weight
+		}
+	}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.MaxFailsAnnotation); ok {
+		maxFails, err := strconv.Atoi(value)
+		if err != nil || maxFails < 0 {
+			recordInvalidAnnotation(service, recorder, key, value, "must be a non-negative integer")
+		} else {
+			parameters.maxFails = &maxFails
+		}
+	}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.FailTimeoutAnnotation); ok {
+		if !timePattern.MatchString(value) {
+			recordInvalidAnnotation(service, recorder, key, value, "must be an NGINX time, e.g. 10s")
+		} else {
+			parameters.failTimeout = value
+		}
+	}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.RouteTemplateAnnotation); ok {
+		if value == "" || strings.ContainsAny(value, " \t\n;") {
+			recordInvalidAnnotation(service, recorder, key, value, "must be a route without spaces or semicolons, e.g. {node}")
+		} else {
+			parameters.routeTemplate = value
+		}
+	}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.ServiceAnnotation); ok {
+		if value == "" || strings.ContainsAny(value, " \t\n;") {
+			recordInvalidAnnotation(service, recorder, key, value, "must be a service name without spaces or semicolons")
+		} else {
+			parameters.service = value
+		}
+	}
+
+	if key, value, ok := lookupAnnotation(port, service.Annotations, configuration.SlowStartAnnotation); ok {
+		if !timePattern.MatchString(value) {
+			recordInvalidAnnotation(service, recorder, key, value, "must be an NGINX time, e.g. 30s")
+		} else {
+			parameters.slowStart = value
+		}
+	}
+
+	return parameters
+}
+
+// route returns the route of the upstream server on the node, or an empty route if the template is not set, or if the
+// route would be longer than NGINX Plus accepts.
+func (p upstreamParameters) route(nodeIp string, nodeName string) string {
+	if p.routeTemplate == "" {
+		return ""
+	}
+
+	route := strings.NewReplacer(routeTemplateNode, nodeName, routeTemplateAddress, nodeIp).Replace(p.routeTemplate)
+	if len(route) > maxRouteLength {
+		logrus.WithFields(logrus.Fields{"route": route, "node": nodeName}).
+			Warnf("Translate::route: the route is longer than %d characters, the server has no route", maxRouteLength)
+		return ""
+	}
+
+	return route
+}
+
+// IsIgnored determines if the Service opts out of synchronization with the IgnoreAnnotation.
+// An invalid value is treated as false and a Warning Event is recorded on the Service.
+func IsIgnored(service *v1.Service, recorder record.EventRecorder) bool {
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.IgnoreAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return false
+	}
+
+	ignored, err := strconv.ParseBool(value)
+	if err != nil {
+		recordInvalidAnnotation(service, recorder, key, value, "must be true or false")
+		return false
+	}
+
+	return ignored
+}
+
+// getDrainOnCordon determines if the Service asks for the upstream servers of unschedulable nodes to be drained, rather
+// than kept, and those of NotReady nodes to be drained rather than removed. An invalid value is treated as false and a Warning Event is recorded on the Service.
+func getDrainOnCordon(service *v1.Service, recorder record.EventRecorder) bool {
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.DrainOnCordonAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return false
+	}
+
+	drain, err := strconv.ParseBool(value)
+	if err != nil {
+		recordInvalidAnnotation(service, recorder, key, value, "must be true or false")
+		return false
+	}
+
+	return drain
+}
+
+// getEmptyServerPolicy returns the EmptyServerPolicy of the upstream of the port set by the Service, empty when the
+// Service does not set one, so the SynchronizerSettings::EmptyServerPolicy applies. An invalid policy is ignored and
+// a Warning Event is recorded on the Service.
+func getEmptyServerPolicy(port v1.ServicePort, service *v1.Service, recorder record.EventRecorder) string {
+	key, value, ok := lookupAnnotation(port, service.Annotations, configuration.EmptyServerPolicyAnnotation)
+	if !ok {
+		return ""
+	}
+
+	policy := strings.ToLower(strings.TrimSpace(value))
+	if err := configuration.ValidateEmptyServerPolicy(policy); err != nil {
+		recordInvalidAnnotation(service, recorder, key, value, "must be apply, retain, or fail")
+		return ""
+	}
+
+	return policy
+}
+
+// getKeyValZone returns the key-value zone in which the node addresses of the upstream servers of the Service are written,
+// nil if the Service does not name one. An invalid zone name is ignored and a Warning Event is recorded on the Service.
+func getKeyValZone(service *v1.Service, recorder record.EventRecorder) *core.KeyValZone {
+	key := fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, configuration.KeyValZoneAnnotation)
+
+	value, ok := service.Annotations[key]
+	if !ok {
+		return nil
+	}
+
+	name, stream := strings.CutPrefix(strings.TrimSpace(value), configuration.KeyValZoneStreamPrefix)
+	if !zoneNamePattern.MatchString(name) {
+		recordInvalidAnnotation(service, recorder, key, value, "must be a zone name, optionally prefixed with "+configuration.KeyValZoneStreamPrefix)
+		return nil
+	}
+
+	return &core.KeyValZone{
+		Name:   name,
+		Stream: stream,
+		Owner:  service.Namespace + "/" + service.Name,
+	}
+}
+
+// lookupAnnotation returns the key and value of the per-port annotation if present, otherwise of the Service-wide annotation.
+func lookupAnnotation(port v1.ServicePort, annotations map[string]string, suffix string) (string, string, bool) {
+	keys := []string{
+		fmt.Sprintf("%s/%s.%s", configuration.PortAnnotationPrefix, port.Name, suffix),
+		fmt.Sprintf("%s/%s", configuration.PortAnnotationPrefix, suffix),
+	}
+
+	for _, key := range keys {
+		if value, ok := annotations[key]; ok {
+			return key, value, true
+		}
+	}
+
+	return "", "", false
+}
+
+// recordInvalidAnnotation logs, and records a Warning Event on the Service for, an annotation that cannot be used.
+func recordInvalidAnnotation(service *v1.Service, recorder record.EventRecorder, key string, value string, problem string) {
+	message := fmt.Sprintf("annotation %s has an invalid value %q (%s), using the default", key, value, problem)
+	logrus.Warnf("Translate::recordInvalidAnnotation: service %s/%s: %s", service.Namespace, service.Name, message)
+
+	if recorder != nil {
+		recorder.Event(service, v1.EventTypeWarning, configuration.InvalidAnnotationReason, message)
+	}
+}
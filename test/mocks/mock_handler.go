@@ -8,10 +8,11 @@ package mocks
 import "github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 
 type MockHandler struct {
+	Events []*core.Event
 }
 
-func (h *MockHandler) AddRateLimitedEvent(_ *core.Event) {
-
+func (h *MockHandler) AddRateLimitedEvent(event *core.Event) {
+	h.Events = append(h.Events, event)
 }
 
 func (h *MockHandler) Initialize() {
@@ -5,9 +5,14 @@
 
 package mocks
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
+// MockRateLimiter is safe for concurrent use, e.g. by the timers of the Synchronizer adding items in the background.
 type MockRateLimiter struct {
+	lock  sync.Mutex
 	items []interface{}
 }
 
@@ -15,10 +20,16 @@ func (m *MockRateLimiter) Add(_ interface{}) {
 }
 
 func (m *MockRateLimiter) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	return len(m.items)
 }
 
 func (m *MockRateLimiter) Get() (item interface{}, shutdown bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if len(m.items) > 0 {
 		item = m.items[0]
 		m.items = m.items[1:]
@@ -41,10 +52,16 @@ func (m *MockRateLimiter) ShuttingDown() bool {
 }
 
 func (m *MockRateLimiter) AddAfter(item interface{}, _ time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.items = append(m.items, item)
 }
 
 func (m *MockRateLimiter) AddRateLimited(item interface{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.items = append(m.items, item)
 }
 
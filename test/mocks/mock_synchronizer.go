@@ -8,7 +8,8 @@ package mocks
 import "github.com/nginxinc/kubernetes-nginx-ingress/internal/core"
 
 type MockSynchronizer struct {
-	Events []core.ServerUpdateEvent
+	Events  []core.ServerUpdateEvent
+	Flushed []core.ServerUpdateEvent
 }
 
 func (s *MockSynchronizer) AddEvents(events core.ServerUpdateEvents) {
@@ -21,6 +22,12 @@ func (s *MockSynchronizer) AddEvent(event *core.ServerUpdateEvent) {
 	s.Events = append(s.Events, *event)
 }
 
+func (s *MockSynchronizer) FlushEvents(events core.ServerUpdateEvents) {
+	for _, event := range events {
+		s.Flushed = append(s.Flushed, *event)
+	}
+}
+
 func (s *MockSynchronizer) Initialize() error {
 	return nil
 }